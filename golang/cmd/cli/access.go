@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/spf13/cobra"
+)
+
+// accessOutput is the stable JSON shape printed by `server whitelist|op add|remove --output json`
+type accessOutput struct {
+	List    string   `json:"list"`   // whitelist or ops
+	Action  string   `json:"action"` // add or remove
+	Players []string `json:"players"`
+	Live    bool     `json:"live"` // sent to the running server's console instead of editing the file
+}
+
+func serverWhitelistCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "whitelist",
+		Short: "List, add and remove whitelisted players.",
+		Long: `Manage whitelist.json. While the server runs and answers on
+server.rcon.address, players are added and removed with the whitelist
+command in its console, so the change applies at once; otherwise the file is
+edited and UUIDs are looked up with Mojang, or derived from the name when
+online-mode is false.`,
+	}
+	list := &cobra.Command{
+		Use:         "list",
+		Short:       "List the whitelisted players.",
+		Annotations: map[string]string{annotationServers: config.AllInstances},
+		RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+			players, err := server.NewAccess(cfg.ServerPath, nil, nil).Whitelist()
+			if err != nil {
+				return err
+			}
+			return render(cmd, players, func(w io.Writer, format string) error {
+				if len(players) == 0 {
+					fmt.Fprintln(w, "No whitelisted players.")
+					return nil
+				}
+				tw := newTable(w)
+				fmt.Fprintln(tw, "NAME\tUUID")
+				for _, p := range players {
+					fmt.Fprintf(tw, "%s\t%s\n", p.Name, p.UUID)
+				}
+				return tw.Flush()
+			})
+		}),
+	}
+	cmd.AddCommand(
+		list,
+		accessChangeCmd(cfg, "whitelist", "add", "Whitelist players.", (*server.Access).AddToWhitelist),
+		accessChangeCmd(cfg, "whitelist", "remove", "Take players off the whitelist.", (*server.Access).RemoveFromWhitelist),
+	)
+	return cmd
+}
+
+func serverOpCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "op",
+		Short: "List, add and remove server operators.",
+		Long: `Manage ops.json. While the server runs and answers on
+server.rcon.address, the op and deop commands are run in its console;
+otherwise the file is edited, and new operators get the op-permission-level
+from server.properties.`,
+	}
+	list := &cobra.Command{
+		Use:         "list",
+		Short:       "List the operators.",
+		Annotations: map[string]string{annotationServers: config.AllInstances},
+		RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+			ops, err := server.NewAccess(cfg.ServerPath, nil, nil).Ops()
+			if err != nil {
+				return err
+			}
+			return render(cmd, ops, func(w io.Writer, format string) error {
+				if len(ops) == 0 {
+					fmt.Fprintln(w, "No operators.")
+					return nil
+				}
+				tw := newTable(w)
+				fmt.Fprintln(tw, "NAME\tLEVEL\tUUID")
+				for _, op := range ops {
+					fmt.Fprintf(tw, "%s\t%d\t%s\n", op.Name, op.Level, op.UUID)
+				}
+				return tw.Flush()
+			})
+		}),
+	}
+	cmd.AddCommand(
+		list,
+		accessChangeCmd(cfg, "ops", "add", "Make players operators.", (*server.Access).AddOp),
+		accessChangeCmd(cfg, "ops", "remove", "Take the operator status from players.", (*server.Access).RemoveOp),
+	)
+	return cmd
+}
+
+// accessChangeCmd builds an add or remove command that applies change to every player
+// named on the command line
+func accessChangeCmd(cfg *config.Config, list, action, short string, change func(a *server.Access, name string) error) *cobra.Command {
+	audit := "server.whitelist." + action
+	if list == "ops" {
+		audit = "server.op." + action
+	}
+	return &cobra.Command{
+		Use:         action + " <player>...",
+		Short:       short,
+		Args:        cobra.MinimumNArgs(1),
+		Annotations: map[string]string{annotationServers: config.AllInstances, annotationAudit: audit},
+		RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+			a, err := serverAccess(cfg)
+			if err != nil {
+				return err
+			}
+			for _, name := range args {
+				if err := change(a, name); err != nil {
+					return err
+				}
+			}
+			out := accessOutput{List: list, Action: action, Players: args, Live: a.Live()}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				verb := map[string]string{"add": "Added", "remove": "Removed"}[action]
+				where := map[string]string{"add": "to", "remove": "from"}[action]
+				fmt.Fprintf(w, "✅ %s %s %s %s\n", verb, strings.Join(out.Players, ", "), where, list)
+				return nil
+			})
+		}),
+	}
+}
+
+// serverAccess manages the access lists through the server console when the server is
+// up and answers on RCON, and through the files otherwise
+func serverAccess(cfg *config.Config) (*server.Access, error) {
+	running := false
+	if cfg.Server.Mode != "" && cfg.Server.Mode != server.ModeProcess {
+		c, err := managedServer(cfg)
+		if err != nil {
+			return nil, err
+		}
+		running = c.IsRunning()
+	}
+
+	if cfg.Server.RCON.Address != "" {
+		console := server.NewRCONCommander(cfg.Server.RCON)
+		err := console.Ping()
+		if err == nil {
+			return server.NewAccess(cfg.ServerPath, console, nil), nil
+		}
+		if running {
+			return nil, fmt.Errorf("the server is running but RCON failed, so the change could not be applied: %w", err)
+		}
+		slog.Debug("RCON unavailable, editing the files", "error", err)
+	}
+	switch {
+	case cfg.Server.Mode == server.ModePterodactyl:
+		// server_path is only a local copy of the panel's files
+		return nil, fmt.Errorf("in pterodactyl mode the whitelist and ops are changed over RCON: start the server and set server.rcon.address")
+	case running:
+		slog.Warn("the server is running without server.rcon.address; it only reads the changed file after a restart, and may overwrite it before then")
+	}
+	return server.NewAccess(cfg.ServerPath, nil, httpclient.New(cfg.HTTP)), nil
+}
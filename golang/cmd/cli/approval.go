@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/approval"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// approvalNone is the status printed when no update has asked for approval
+const approvalNone = "none"
+
+// approvalOutput is the stable JSON shape printed by `approval status|approve|deny --output json`
+type approvalOutput struct {
+	FileID      int        `json:"file_id"`
+	Version     string     `json:"version"`
+	FromVersion string     `json:"from_version"`
+	Status      string     `json:"status"`
+	RequestedAt *time.Time `json:"requested_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	DecidedBy   string     `json:"decided_by"`
+	DecidedAt   *time.Time `json:"decided_at"`
+}
+
+func newApprovalOutput(a *state.Approval, now time.Time) approvalOutput {
+	if a == nil {
+		return approvalOutput{Status: approvalNone}
+	}
+	out := approvalOutput{
+		FileID:      a.FileID,
+		Version:     a.Version,
+		FromVersion: a.FromVersion,
+		Status:      a.Status,
+		RequestedAt: timeOrNil(a.RequestedAt),
+		ExpiresAt:   timeOrNil(a.ExpiresAt),
+		DecidedBy:   a.DecidedBy,
+		DecidedAt:   timeOrNil(a.DecidedAt),
+	}
+	// The daemon only marks a request expired on its next check
+	if a.Status == approval.StatusPending && !a.ExpiresAt.IsZero() && !now.Before(a.ExpiresAt) {
+		out.Status = approval.StatusExpired
+	}
+	return out
+}
+
+func approvalCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "approval",
+		Short: "Approve or deny the update waiting for approval.",
+		Long: `With approval.required and update_policy.auto_update, the daemon asks for approval
+through the notifications before it installs an update, and only installs it
+after the update was approved here, at /approvals in the web UI or through the
+REST API. The daemon installs an approved update on its next check.`,
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:         "status",
+			Short:       "Show the update waiting for approval, or the last decision.",
+			Annotations: map[string]string{annotationServers: config.AllInstances},
+			RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+				st, err := state.NewStore(filepath.Join(cfg.DataDir, state.FileName)).Load()
+				if err != nil {
+					return err
+				}
+				return renderApproval(cmd, newApprovalOutput(st.Approval, time.Now()))
+			}),
+		},
+		approvalDecideCmd(cfg, "approve", true),
+		approvalDecideCmd(cfg, "deny", false),
+	)
+	return cmd
+}
+
+func approvalDecideCmd(cfg *config.Config, use string, approve bool) *cobra.Command {
+	short := "Approve the update waiting for approval; the daemon installs it on its next check."
+	if !approve {
+		short = "Deny the update waiting for approval; it is not installed or asked about again."
+	}
+	return &cobra.Command{
+		Use:         use,
+		Short:       short,
+		Annotations: map[string]string{annotationServers: config.AllInstances, annotationAudit: "approval." + use},
+		RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			st, err := state.NewStore(filepath.Join(cfg.DataDir, state.FileName)).Update(func(st *state.State) error {
+				return approval.Decide(st, approve, "cli "+currentUser(), now)
+			})
+			if err != nil {
+				return err
+			}
+			return renderApproval(cmd, newApprovalOutput(st.Approval, now))
+		}),
+	}
+}
+
+// renderApproval prints an approval request
+func renderApproval(cmd *cobra.Command, out approvalOutput) error {
+	return render(cmd, out, func(w io.Writer, format string) error {
+		icon := "⏳"
+		switch out.Status {
+		case approvalNone:
+			fmt.Fprintln(w, "No update is waiting for approval.")
+			return nil
+		case approval.StatusApproved:
+			icon = "✅"
+		case approval.StatusDenied:
+			icon = "⛔"
+		case approval.StatusExpired:
+			icon = "⌛"
+		}
+		fmt.Fprintf(w, "%s %s -> %s: %s\n", icon, orNone(out.FromVersion), out.Version, out.Status)
+		fmt.Fprintf(w, "   requested: %s\n", out.RequestedAt.Format("2006-01-02 15:04:05"))
+		if out.ExpiresAt != nil && out.Status == approval.StatusPending {
+			fmt.Fprintf(w, "   expires:   %s\n", out.ExpiresAt.Format("2006-01-02 15:04:05"))
+		}
+		if out.DecidedBy != "" {
+			fmt.Fprintf(w, "   %s by %s at %s\n", out.Status, out.DecidedBy, out.DecidedAt.Format("2006-01-02 15:04:05"))
+		}
+		return nil
+	})
+}
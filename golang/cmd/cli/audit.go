@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/audit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// annotationAudit marks commands that change state; its value is the action recorded in
+// the audit log
+const annotationAudit = "audit"
+
+// auditOutput is the stable JSON shape of one entry printed by `audit --output json`
+type auditOutput struct {
+	Timestamp time.Time `json:"timestamp"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Result    string    `json:"result"`
+	Details   string    `json:"details"`
+}
+
+// auditLog opens the audit log of the config as loaded, so that every [[servers]] entry
+// shares one log with the web UI
+func auditLog() *audit.Log {
+	return audit.NewLog(filepath.Join(loadedConfig.DataDir, audit.FileName))
+}
+
+// recordAudit appends the outcome of cmd to the audit log when cmd changes state. Dry runs
+// and commands that ran without a config are not recorded.
+func recordAudit(cmd *cobra.Command, err error) {
+	if cmd == nil || loadedConfig == nil || dryRun {
+		return
+	}
+	action := cmd.Annotations[annotationAudit]
+	if action == "" {
+		return
+	}
+
+	var flags []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags = append(flags, fmt.Sprintf("--%s=%s", f.Name, f.Value))
+	})
+	entry := audit.Entry{
+		Actor:   "cli " + currentUser(),
+		Action:  action,
+		Target:  strings.Join(cmd.Flags().Args(), " "),
+		Result:  audit.ResultSuccess,
+		Details: strings.Join(flags, " "),
+	}
+	var exitErr *exitCodeError
+	if err != nil && (!errors.As(err, &exitErr) || exitErr.code == exitError) {
+		entry.Result = audit.ResultFailed
+		entry.Details = strings.TrimPrefix(entry.Details+": "+err.Error(), ": ")
+	}
+	if err := auditLog().Append(entry); err != nil {
+		slog.Error("failed to write audit log", "error", err)
+	}
+}
+
+// currentUser names the user running the CLI for the audit log
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+func auditCmd() *cobra.Command {
+	var filter audit.Filter
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show the actions operators took from the CLI and the web UI.",
+		Long: `Show the audit log in data_dir, newest first. Every command and web or API
+request that changes state is recorded with who ran it, what it did, when, and
+whether it succeeded.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := auditLog().List(filter)
+			if err != nil {
+				return err
+			}
+
+			out := []auditOutput{}
+			for _, e := range entries {
+				out = append(out, auditOutput(e))
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if len(out) == 0 {
+					fmt.Fprintln(w, "No actions recorded.")
+					return nil
+				}
+				if format == outputTable {
+					tw := newTable(w)
+					fmt.Fprintln(tw, "TIME\tACTOR\tACTION\tTARGET\tRESULT")
+					for _, e := range out {
+						target := e.Target
+						if target == "" {
+							target = "-"
+						}
+						fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Timestamp.Format("2006-01-02 15:04:05"), e.Actor, e.Action, target, e.Result)
+					}
+					return tw.Flush()
+				}
+				for _, e := range out {
+					icon := "✅"
+					if e.Result == audit.ResultFailed {
+						icon = "❌"
+					}
+					fmt.Fprintf(w, "%s %s %s by %s", icon, e.Timestamp.Format("2006-01-02 15:04:05"), e.Action, e.Actor)
+					if e.Target != "" {
+						fmt.Fprintf(w, " on %s", e.Target)
+					}
+					fmt.Fprintln(w)
+					if e.Details != "" {
+						fmt.Fprintf(w, "   %s\n", e.Details)
+					}
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&filter.Action, "action", "", "Only show this action, or a group of actions such as server")
+	cmd.Flags().StringVar(&filter.Actor, "actor", "", "Only show actions whose actor contains this, such as cli or an IP address")
+	cmd.Flags().IntVarP(&filter.Limit, "limit", "n", 0, "Show at most this many entries (0 for all)")
+	return cmd
+}
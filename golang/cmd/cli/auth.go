@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// authOutput is the stable JSON shape printed by `auth verify --output json`
+type authOutput struct {
+	Result     string            `json:"result"`
+	Valid      bool              `json:"valid"`
+	StatusCode int               `json:"status_code"`
+	RateLimits map[string]string `json:"rate_limits"`
+	Message    string            `json:"message,omitempty"`
+	Guidance   string            `json:"guidance,omitempty"`
+}
+
+func authCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Check the CurseForge API key.",
+	}
+	cmd.AddCommand(authVerifyCmd(cfg))
+	return cmd
+}
+
+func authVerifyCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify",
+		Short: "Confirm the API key works and show the rate limits.",
+		Long: `Call a cheap authenticated endpoint of the CurseForge API with the
+configured key, bypassing the cache, and report whether it was accepted along
+with any rate-limit headers of the response. A missing, rejected or blocked
+key is explained with what to do about it. Exits with 1 unless the key works.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := api.NewClientFromConfig(cfg).VerifyAPIKey()
+			if err != nil {
+				return fmt.Errorf("failed to reach the CurseForge API: %w", err)
+			}
+
+			out := authOutput{
+				Result:     status.Result,
+				Valid:      status.Result == api.KeyValid,
+				StatusCode: status.StatusCode,
+				RateLimits: map[string]string{},
+				Message:    status.Message,
+				Guidance:   keyGuidance(status.Result, cfg.File),
+			}
+			for name, value := range status.RateLimits {
+				out.RateLimits[name] = value
+			}
+			if err := render(cmd, out, func(w io.Writer, format string) error {
+				if out.Valid {
+					fmt.Fprintln(w, colorize(w, colorGreen, "✅ API key accepted"))
+				} else {
+					fmt.Fprintln(w, colorize(w, colorRed, "❌ "+status.Err().Error()))
+				}
+				names := make([]string, 0, len(out.RateLimits))
+				for name := range out.RateLimits {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					fmt.Fprintf(w, "   %s: %s\n", name, out.RateLimits[name])
+				}
+				if out.Message != "" && out.Result == api.KeyError {
+					fmt.Fprintf(w, "   %s\n", out.Message)
+				}
+				if out.Guidance != "" {
+					fmt.Fprintf(w, "💡 %s\n", out.Guidance)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			if !out.Valid {
+				return &exitCodeError{code: exitError}
+			}
+			return nil
+		},
+	}
+}
+
+// keyGuidance explains how to fix the API key problem reported as result
+func keyGuidance(result, configFile string) string {
+	switch result {
+	case api.KeyMissing:
+		return fmt.Sprintf("Set api_key or api_key_file in %s, or the CURSEFORGE_API_KEY environment variable. Keys are created at https://console.curseforge.com.", configFile)
+	case api.KeyInvalid:
+		return "The key was rejected. Copy it again from https://console.curseforge.com, check for stray quotes or whitespace, and note that a ${NAME} reference must resolve to the key."
+	case api.KeyBlocked:
+		return "The request was refused before the key was checked, usually because the server's IP address is blocked. Try from another network or through a proxy (http.proxy_url)."
+	case api.KeyRateLimited:
+		return "Too many requests were made with this key. Wait for the time in Retry-After, and enable the API cache (cache.ttl) to make fewer requests."
+	case api.KeyError:
+		return "The CurseForge API returned an unexpected error; try again later."
+	}
+	return ""
+}
@@ -2,16 +2,251 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"time"
 
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
 	"github.com/spf13/cobra"
 )
 
-func backupCmd() *cobra.Command {
-	return &cobra.Command{
+// backupOutput is the stable JSON shape of one entry printed by `backup list --output json`
+type backupOutput struct {
+	Name       string    `json:"name"`
+	Path       string    `json:"path"`
+	Type       string    `json:"type"`
+	SizeBytes  int64     `json:"size_bytes"`
+	Created    time.Time `json:"created"`
+	Compressed bool      `json:"compressed"`
+	Encrypted  bool      `json:"encrypted"`
+	Protected  bool      `json:"protected"`
+
+	// Recorded in the backup index; empty for backups made before it
+	Trigger      string  `json:"trigger"`
+	Version      string  `json:"version"`
+	Checksum     string  `json:"checksum"`
+	DurationSecs float64 `json:"duration_seconds"`
+}
+
+func backupCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
 		Use:   "backup",
 		Short: "Manual backup operations.",
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Fprintln(cmd.OutOrStdout(), "[backup] Manual backup not yet implemented.")
 		},
 	}
+
+	cmd.AddCommand(backupListCmd(cfg), backupCreateCmd(cfg), backupPruneCmd(cfg), backupProtectCmd(cfg, true), backupProtectCmd(cfg, false))
+	return cmd
+}
+
+// newBackupManager creates a backup manager from the backup settings in cfg, which only
+// logs what it would write or delete with --dry-run
+func newBackupManager(cfg *config.Config) *server.BackupManager {
+	bm := server.NewBackupManager(cfg.ServerPath, cfg.BackupPath, cfg.Backup.Compression, cfg.Backup.RetentionDays)
+	bm.SetDryRun(dryRun)
+	bm.SetTrigger(server.TriggerCLI)
+	bm.SetVersionSource(state.NewStore(filepath.Join(cfg.DataDir, state.FileName)).InstalledVersion)
+	bm.SetRestoreOwner(cfg.Backup.RestoreOwner)
+	bm.SetSnapshot(cfg.Backup.Snapshot)
+	bm.SetEncryption(cfg.Backup.Encryption)
+	bm.SetRetention(server.NewRetentionPolicy(cfg.Backup))
+	return bm
+}
+
+// toBackupOutput converts b for output
+func toBackupOutput(b server.BackupInfo) backupOutput {
+	return backupOutput{
+		Name:       filepath.Base(b.Path),
+		Path:       b.Path,
+		Type:       b.Type,
+		SizeBytes:  b.Size,
+		Created:    b.Created,
+		Compressed: b.IsCompressed,
+		Encrypted:  b.Encrypted,
+		Protected:  b.Protected,
+
+		Trigger:      b.Trigger,
+		Version:      b.Version,
+		Checksum:     b.Checksum,
+		DurationSecs: b.Duration.Seconds(),
+	}
+}
+
+func backupListCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List existing backups.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bm := newBackupManager(cfg)
+			backups, err := bm.ListBackups()
+			if err != nil {
+				return fmt.Errorf("failed to list backups: %w", err)
+			}
+
+			out := []backupOutput{}
+			for _, b := range backups {
+				out = append(out, toBackupOutput(b))
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if len(out) == 0 {
+					fmt.Fprintf(w, "No backups found in %s\n", cfg.BackupPath)
+					return nil
+				}
+				if format == outputTable {
+					tw := newTable(w)
+					fmt.Fprintln(tw, "NAME\tTYPE\tSIZE\tCREATED\tVERSION\tTRIGGER\tPROTECTED")
+					for _, b := range out {
+						fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%t\n", b.Name, b.Type, filesystem.FormatBytes(b.SizeBytes), b.Created.Format("2006-01-02 15:04:05"), orNone(b.Version), orNone(b.Trigger), b.Protected)
+					}
+					return tw.Flush()
+				}
+				for _, b := range out {
+					icon := "💾"
+					if b.Protected {
+						icon = "🔒"
+					}
+					fmt.Fprintf(w, "%s %s (%s, %s, %s)\n", icon, b.Name, b.Type, filesystem.FormatBytes(b.SizeBytes), b.Created.Format("2006-01-02 15:04:05"))
+				}
+				return nil
+			})
+		},
+	}
+}
+
+func backupCreateCmd(cfg *config.Config) *cobra.Command {
+	var (
+		name  string
+		world bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a backup now.",
+		Long: `Back up the whole server directory, or with --world only the world
+folders: level-name from server.properties plus the separate nether and
+end folders of Bukkit-based servers. With --dry-run the backup is described,
+with the size of the files it would contain, but not written.`,
+		Annotations: map[string]string{annotationDryRun: "true", annotationAudit: "backup.create"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bm := newBackupManager(cfg)
+			bm.SetLogger(slog.Default())
+
+			var (
+				b   *server.BackupInfo
+				err error
+			)
+			if world {
+				b, err = bm.CreateWorldBackup(name)
+			} else {
+				b, err = bm.CreateManualBackup(name)
+			}
+			if err != nil {
+				return err
+			}
+
+			out := toBackupOutput(*b)
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if dryRun {
+					fmt.Fprintf(w, "🔍 Would create backup %s (%s, %s before compression)\n", out.Path, out.Type, filesystem.FormatBytes(out.SizeBytes))
+					return nil
+				}
+				fmt.Fprintf(w, "💾 Backup created: %s (%s, %s)\n", out.Name, out.Type, filesystem.FormatBytes(out.SizeBytes))
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Label to include in the backup name")
+	cmd.Flags().BoolVar(&world, "world", false, "Only back up the world folders")
+	return cmd
+}
+
+func backupPruneCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the backups the retention policy does not keep.",
+		Long: `Remove the backups that neither backup.retention_days nor keep_last,
+keep_daily, keep_weekly or keep_monthly keep, as the daemon does after every
+update. Nothing is removed when they are all 0, and protected backups are
+always kept. With --dry-run the backups are listed but kept.`,
+		Annotations: map[string]string{annotationDryRun: "true", annotationAudit: "backup.prune"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bm := newBackupManager(cfg)
+			bm.SetLogger(slog.Default())
+			pruned, err := bm.PruneBackups()
+			if err != nil {
+				return err
+			}
+
+			out := []backupOutput{}
+			var total int64
+			for _, b := range pruned {
+				out = append(out, toBackupOutput(b))
+				total += b.Size
+			}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				verb := "Removed"
+				if dryRun {
+					verb = "Would remove"
+				}
+				if len(out) == 0 {
+					fmt.Fprintf(w, "No backups to remove in %s\n", cfg.BackupPath)
+					return nil
+				}
+				fmt.Fprintf(w, "🧹 %s %d backups (%s):\n", verb, len(out), filesystem.FormatBytes(total))
+				for _, b := range out {
+					fmt.Fprintf(w, "  - %s (%s)\n", b.Name, b.Created.Format("2006-01-02 15:04:05"))
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// backupProtectOutput is the stable JSON shape printed by `backup protect` and `backup unprotect`
+type backupProtectOutput struct {
+	Name      string `json:"name"`
+	Protected bool   `json:"protected"`
+}
+
+// backupProtectCmd builds `backup protect`, or `backup unprotect` when protect is false
+func backupProtectCmd(cfg *config.Config, protect bool) *cobra.Command {
+	use, short, action := "protect", "Keep a backup from being pruned or deleted.", "backup.protect"
+	if !protect {
+		use, short, action = "unprotect", "Let a protected backup be pruned and deleted again.", "backup.unprotect"
+	}
+	return &cobra.Command{
+		Use:         use + " <name>",
+		Short:       short,
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{annotationAudit: action},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bm := newBackupManager(cfg)
+			bm.SetLogger(slog.Default())
+			set := bm.ProtectBackup
+			if !protect {
+				set = bm.UnprotectBackup
+			}
+			if err := set(args[0]); err != nil {
+				return err
+			}
+
+			out := backupProtectOutput{Name: args[0], Protected: protect}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if protect {
+					fmt.Fprintf(w, "🔒 %s is protected\n", out.Name)
+				} else {
+					fmt.Fprintf(w, "🔓 %s is no longer protected\n", out.Name)
+				}
+				return nil
+			})
+		},
+	}
 }
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// bootstrapOutput is the stable JSON shape printed by `server bootstrap --output json`
+type bootstrapOutput struct {
+	ServerPath       string                `json:"server_path"`
+	InstalledVersion string                `json:"installed_version"`
+	EULAAccepted     bool                  `json:"eula_accepted"`
+	Properties       []string              `json:"properties"` // keys set in server.properties
+	Started          bool                  `json:"started"`
+	Startup          *server.StartupReport `json:"startup,omitempty"` // with --start
+}
+
+func serverBootstrapCmd(cfg *config.Config) *cobra.Command {
+	var acceptEULA, start, force bool
+	var startTimeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "bootstrap",
+		Short: "Prepare a fresh server directory from the modpack.",
+		Long: `Install the latest server pack into an empty server_path, write
+server.properties from server.port, server.max_players, server.name (as the
+motd) and server.rcon, and with --accept-eula accept the Minecraft EULA
+(` + server.EULAURL + `) in eula.txt. Values the pack's server.properties
+already has are replaced, the rest of the file is kept.
+
+With --start the server is started once to generate the world and stopped
+again once it has finished starting. This needs the EULA to be accepted.
+In process mode the server runs as a child of this command; in docker and
+systemd mode the configured container or unit is started.`,
+		Annotations: map[string]string{annotationServers: config.AllInstances, annotationAudit: "server.bootstrap"},
+		RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+			if !cfg.HasAPIAccess() || cfg.ModpackID == 0 {
+				return fmt.Errorf("missing config: api_key and modpack_id are required. Hint: run `init` to scaffold one")
+			}
+			if !force {
+				if entries, err := os.ReadDir(cfg.ServerPath); err == nil && len(entries) > 0 {
+					return fmt.Errorf("server_path %s is not empty; pass --force to bootstrap it anyway", cfg.ServerPath)
+				}
+			}
+			// Check before installing, so that a bootstrap that cannot start fails early
+			if start && !acceptEULA && !server.EULAAccepted(cfg.ServerPath) {
+				return fmt.Errorf("--start needs the EULA to be accepted; read %s and pass --accept-eula", server.EULAURL)
+			}
+			if start && (cfg.Server.Mode == server.ModePterodactyl || cfg.Server.Mode == server.ModeKubernetes) {
+				return fmt.Errorf("--start needs server.mode process, docker or systemd to follow the server log")
+			}
+
+			u := updater.NewFromConfig(cfg, slog.Default())
+			u.SetDownloadProgress(newProgressBar(cmd.ErrOrStderr()))
+			result, err := u.Update(false)
+			if err != nil {
+				return err
+			}
+			out := bootstrapOutput{ServerPath: cfg.ServerPath, InstalledVersion: result.ToVersion, Properties: []string{}}
+
+			if acceptEULA {
+				if err := server.AcceptEULA(cfg.ServerPath, time.Now()); err != nil {
+					return err
+				}
+			}
+			out.EULAAccepted = server.EULAAccepted(cfg.ServerPath)
+
+			values := server.BootstrapProperties(cfg)
+			if err := server.SetProperties(cfg.ServerPath, values); err != nil {
+				return err
+			}
+			for key := range values {
+				out.Properties = append(out.Properties, key)
+			}
+			sort.Strings(out.Properties)
+
+			if start {
+				report, err := startOnce(cmd, cfg, startTimeout)
+				if err != nil {
+					return err
+				}
+				out.Started, out.Startup = true, report
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				fmt.Fprintf(w, "📦 Installed %s into %s\n", out.InstalledVersion, out.ServerPath)
+				if len(out.Properties) > 0 {
+					fmt.Fprintf(w, "⚙️  Set in server.properties: %s\n", strings.Join(out.Properties, ", "))
+				}
+				if out.EULAAccepted {
+					fmt.Fprintln(w, "📜 EULA accepted")
+				} else {
+					fmt.Fprintf(w, "⚠️  EULA not accepted: read %s, then pass --accept-eula or set eula=true in %s\n", server.EULAURL, server.EULAFile)
+				}
+				if out.Started {
+					fmt.Fprintf(w, "🚀 Started in %s to generate the world, then stopped\n", out.Startup.StartupTime.Round(time.Millisecond))
+				}
+				return nil
+			})
+		}),
+	}
+
+	cmd.Flags().BoolVar(&acceptEULA, "accept-eula", false, "Accept the Minecraft EULA ("+server.EULAURL+") in eula.txt")
+	cmd.Flags().BoolVar(&start, "start", false, "Start the server once to generate the world, then stop it")
+	cmd.Flags().BoolVar(&force, "force", false, "Bootstrap a server_path that is not empty")
+	cmd.Flags().DurationVar(&startTimeout, "start-timeout", 10*time.Minute, "How long the server may take to start with --start")
+	return cmd
+}
+
+// startOnce starts the server, waits until it has finished starting and stops it again
+func startOnce(cmd *cobra.Command, cfg *config.Config, timeout time.Duration) (*server.StartupReport, error) {
+	var c server.Controller
+	if cfg.Server.Mode == "" || cfg.Server.Mode == server.ModeProcess {
+		process, err := server.NewController(cfg)
+		if err != nil {
+			return nil, err
+		}
+		c = process
+	} else {
+		managed, err := managedServer(cfg)
+		if err != nil {
+			return nil, err
+		}
+		c = managed
+	}
+	if c.IsRunning() {
+		return nil, fmt.Errorf("the server is already running")
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	fmt.Fprintln(cmd.ErrOrStderr(), "🚀 Starting the server to generate the world...")
+	if err := c.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the server: %w", err)
+	}
+	lines, err := server.FollowLog(ctx, c)
+	if err != nil {
+		_ = c.Stop(cfg.Server.ShutdownTimeout)
+		return nil, err
+	}
+	report := server.WatchStartup(ctx, lines, 1)
+	if c.IsRunning() {
+		if err := c.Stop(cfg.Server.ShutdownTimeout); err != nil {
+			return nil, fmt.Errorf("failed to stop the server: %w", err)
+		}
+	}
+	switch {
+	case report.Done:
+		return report, nil
+	case report.Failed():
+		return nil, fmt.Errorf("server %s", report.Summary())
+	default:
+		return nil, fmt.Errorf("server stopped or did not finish starting within %s", timeout)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// channelListOutput is the stable JSON shape printed by `channel list --output json`
+type channelListOutput struct {
+	Tracked          string                  `json:"tracked"`
+	InstalledVersion string                  `json:"installed_version"`
+	Channels         []updater.ChannelStatus `json:"channels"`
+}
+
+// channelSetOutput is the stable JSON shape printed by `channel set --output json`
+type channelSetOutput struct {
+	Previous string      `json:"previous"`
+	Channel  string      `json:"channel"`
+	Check    checkOutput `json:"check"` // same fields as `check --output json`
+}
+
+func channelCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "channel",
+		Short: "Show what each release channel offers and switch update_channel.",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "Show the file each release channel would install.",
+		Long: `Check for updates and show the newest file on the stable, beta and alpha
+channels, whichever one update_channel tracks.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cfg.HasAPIAccess() || cfg.ModpackID == 0 {
+				return fmt.Errorf("missing config: api_key and modpack_id are required. Hint: run `init` to scaffold one")
+			}
+			result, err := updater.NewFromConfig(cfg, slog.Default()).Check()
+			if err != nil {
+				return err
+			}
+			out := channelListOutput{
+				Tracked:          cfg.UpdateChannel,
+				InstalledVersion: result.State.InstalledVersion,
+				Channels:         result.Channels,
+			}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				tw := newTable(w)
+				fmt.Fprintf(tw, "CHANNEL\tLATEST\tPUBLISHED\tUPDATE AVAILABLE\n")
+				for _, c := range out.Channels {
+					name := c.Channel
+					if c.Tracked {
+						name += " (tracked)"
+					}
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%t\n", name, c.Version, c.Published.Format(time.DateOnly), c.UpdateAvailable)
+				}
+				if err := tw.Flush(); err != nil {
+					return err
+				}
+				if format == outputPlain {
+					fmt.Fprintf(w, "Installed: %s\n", orNone(out.InstalledVersion))
+				}
+				return nil
+			})
+		},
+	}
+
+	set := &cobra.Command{
+		Use:   "set <" + strings.Join(updater.Channels, "|") + ">",
+		Short: "Switch update_channel and check for updates on the new channel.",
+		Long: `Write update_channel to the config file, into the [[servers]] entry with
+--server, and check for updates on the new channel right away. A running
+daemon picks the change up when it reloads the config.
+
+Switching to a more stable channel does not downgrade the installed files;
+updates resume once the channel has a newer file.`,
+		Args:        cobra.ExactArgs(1),
+		ValidArgs:   updater.Channels,
+		Annotations: map[string]string{annotationAudit: "channel.set"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			channel := strings.ToLower(args[0])
+			if !slices.Contains(updater.Channels, channel) {
+				return fmt.Errorf("unknown channel %q, want one of: %s", args[0], strings.Join(updater.Channels, ", "))
+			}
+			if cfg.File == "" {
+				return fmt.Errorf("no config file was loaded, set update_channel by hand")
+			}
+
+			previous := cfg.UpdateChannel
+			if channel != previous {
+				cfg.UpdateChannel = channel
+				if err := saveSetting(cfg, func(inst *config.InstanceConfig) { inst.UpdateChannel = channel }); err != nil {
+					return fmt.Errorf("failed to save update_channel: %w", err)
+				}
+				slog.Info("update channel switched", "from", previous, "to", channel)
+			}
+			if !cfg.HasAPIAccess() || cfg.ModpackID == 0 {
+				return fmt.Errorf("update_channel is now %s, but checking needs api_key and modpack_id", channel)
+			}
+
+			u := updater.NewFromConfig(cfg, slog.Default())
+			result, err := u.Check()
+			if err != nil {
+				return fmt.Errorf("update_channel is now %s, but the check failed: %w", channel, err)
+			}
+			out := channelSetOutput{Previous: previous, Channel: channel, Check: newCheckOutput(cfg, u, result)}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if out.Channel == out.Previous {
+					fmt.Fprintf(w, "📡 Already tracking the %s channel.\n", out.Channel)
+				} else {
+					fmt.Fprintf(w, "📡 Now tracking the %s channel (was %s).\n", out.Channel, out.Previous)
+				}
+				return printCheck(w, format, out.Check)
+			})
+		},
+	}
+
+	cmd.AddCommand(list, set)
+	return cmd
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/policy"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/provider"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// checkOutput is the stable JSON shape printed by `check --output json`
+type checkOutput struct {
+	ModID            int       `json:"mod_id"`
+	InstalledFileID  int       `json:"installed_file_id"`
+	InstalledVersion string    `json:"installed_version"`
+	LatestFileID     int       `json:"latest_file_id"`
+	LatestVersion    string    `json:"latest_version"`
+	LatestFileDate   time.Time `json:"latest_file_date"`
+	UpdateAvailable  bool      `json:"update_available"`
+
+	HeldBack []heldOutput `json:"held_back"`
+
+	// HeldForReview is the update_policy hold rule that keeps the daemon from installing
+	// the update, or null
+	HeldForReview *policy.Hold `json:"held_for_review"`
+
+	// MinecraftUpgrade is set when the update moves to a newer Minecraft version, or null
+	MinecraftUpgrade *updater.MinecraftUpgrade `json:"minecraft_upgrade"`
+
+	// Channels is what each release channel would install, also the ones not tracked
+	Channels []updater.ChannelStatus `json:"channels"`
+}
+
+// heldOutput is a newer file that the release policy held back, with the reason
+type heldOutput struct {
+	Version   string    `json:"version"`
+	Channel   string    `json:"channel"`
+	Published time.Time `json:"published"`
+	Reason    string    `json:"reason"`
+}
+
+func newHeldOutput(held []provider.Held) []heldOutput {
+	out := []heldOutput{}
+	for _, h := range held {
+		out = append(out, heldOutput{Version: h.Version.Name, Channel: h.Version.Channel, Published: h.Version.Published, Reason: h.Reason})
+	}
+	return out
+}
+
+// printHeld lists the files the release policy held back below a status line
+func printHeld(w io.Writer, held []heldOutput) {
+	for _, h := range held {
+		fmt.Fprintf(w, "   ⏸  %s held back: %s\n", h.Version, h.Reason)
+	}
+}
+
+func checkCmd(cfg *config.Config) *cobra.Command {
+	var ci bool
+	cmd := &cobra.Command{
+		Use:     "check",
+		Aliases: []string{"verify"},
+		Short:   "Check whether an update is available.",
+		Long: `Check whether a newer file is available for the configured mod.
+
+Exit codes:
+  0   up to date
+  10  update available
+  1   error
+
+With --server all every server is checked, exiting with 10 when any of them
+has an update.
+
+With --ci the result is also reported to GitHub Actions: an update is shown as
+an annotation on the run, and update_available, mod_id, installed_version,
+new_version, new_file_id, held_for_review and minecraft_upgrade are set as step
+outputs.`,
+		Annotations: map[string]string{annotationServers: config.AllInstances},
+		RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+			if err := validateCI(ci); err != nil {
+				return err
+			}
+			if !cfg.HasAPIAccess() || cfg.ModpackID == 0 {
+				return fmt.Errorf("missing config: api_key and modpack_id are required. Hint: run `init` to scaffold one")
+			}
+
+			u := updater.NewFromConfig(cfg, slog.Default())
+			result, err := u.Check()
+			if err != nil {
+				if ci {
+					ciAnnotate(cmd.OutOrStdout(), cfg, ciError, "Update check failed", err.Error())
+				}
+				return err
+			}
+			out := newCheckOutput(cfg, u, result)
+			if err := renderCheck(cmd, out); err != nil {
+				return err
+			}
+			if ci {
+				if err := reportCheckToCI(cmd.OutOrStdout(), cfg, out); err != nil {
+					return err
+				}
+			}
+
+			if out.UpdateAvailable {
+				return &exitCodeError{code: exitUpdateAvailable}
+			}
+			return nil
+		}),
+	}
+
+	cmd.Flags().BoolVarP(&quietMode, "quiet", "q", false, "Suppress all output; rely on the exit code")
+	cmd.Flags().BoolVar(&ci, "ci", false, "Report the result as GitHub Actions annotations and step outputs")
+	return cmd
+}
+
+// newCheckOutput fills the output of a check by u, looking for a matching hold rule when
+// an update is available
+func newCheckOutput(cfg *config.Config, u *updater.Updater, result *updater.CheckResult) checkOutput {
+	st, latest := result.State, result.Latest
+	out := checkOutput{
+		ModID:            cfg.ModpackID,
+		InstalledFileID:  st.InstalledFileID,
+		InstalledVersion: st.InstalledVersion,
+		LatestFileID:     latest.ID,
+		LatestVersion:    latest.DisplayName,
+		LatestFileDate:   latest.FileDate,
+		UpdateAvailable:  result.UpdateAvailable,
+		HeldBack:         newHeldOutput(result.HeldBack),
+		MinecraftUpgrade: result.MinecraftUpgrade,
+		Channels:         result.Channels,
+	}
+	if rules := cfg.UpdatePolicy.HoldRules; result.UpdateAvailable && len(rules) > 0 {
+		candidate := policy.CandidateFor(latest, rules, u.Changelog)
+		if candidate.ChangelogErr != nil {
+			slog.Warn("failed to read the changelog for hold_rules", "error", candidate.ChangelogErr)
+		}
+		out.HeldForReview = policy.MatchHoldRules(rules, candidate)
+	}
+	return out
+}
+
+// renderCheck prints the outcome of a check
+func renderCheck(cmd *cobra.Command, out checkOutput) error {
+	if err := render(cmd, out, func(w io.Writer, format string) error { return printCheck(w, format, out) }); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// printCheck writes the outcome of a check in the plain or table format
+func printCheck(w io.Writer, format string, out checkOutput) error {
+	installedVersion := orNone(out.InstalledVersion)
+	if format == outputTable {
+		tw := newTable(w)
+		fmt.Fprintln(tw, "MOD ID\tINSTALLED\tLATEST\tUPDATE AVAILABLE")
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%t\n", out.ModID, installedVersion, out.LatestVersion, out.UpdateAvailable)
+		return tw.Flush()
+	}
+	if out.UpdateAvailable {
+		fmt.Fprintf(w, "⬆️  Update available for mod %d: %s -> %s (file %s)\n", out.ModID, installedVersion, out.LatestVersion, strconv.Itoa(out.LatestFileID))
+	} else {
+		fmt.Fprintf(w, "✅ Mod %d is up to date (%s).\n", out.ModID, out.LatestVersion)
+	}
+	if out.MinecraftUpgrade != nil {
+		printMinecraftUpgrade(w, out.MinecraftUpgrade)
+	}
+	if out.HeldForReview != nil {
+		fmt.Fprintf(w, "   🔍 held for manual review: %s\n", out.HeldForReview)
+	}
+	printHeld(w, out.HeldBack)
+	// The less stable channels, when they offer a newer file than the tracked one
+	shown, beyond := out.LatestFileID, false
+	for _, c := range out.Channels {
+		if beyond && c.UpdateAvailable && c.FileID != shown {
+			fmt.Fprintf(w, "   📡 %s channel: %s (switch with `channel set %s`)\n", c.Channel, c.Version, c.Channel)
+			shown = c.FileID
+		}
+		beyond = beyond || c.Tracked
+	}
+	return nil
+}
+
+// reportCheckToCI annotates the run with an available update and sets the step outputs
+// of `check --ci`
+func reportCheckToCI(w io.Writer, cfg *config.Config, out checkOutput) error {
+	heldForReview := ""
+	if out.HeldForReview != nil {
+		heldForReview = out.HeldForReview.String()
+	}
+	if out.UpdateAvailable {
+		ciAnnotate(w, cfg, ciNotice, "Update available", fmt.Sprintf("Mod %d: %s -> %s (file %d)",
+			out.ModID, orNone(out.InstalledVersion), out.LatestVersion, out.LatestFileID))
+	}
+	minecraftUpgrade := ""
+	if up := out.MinecraftUpgrade; up != nil {
+		minecraftUpgrade = up.String()
+		ciAnnotate(w, cfg, ciWarning, "Minecraft version upgrade", fmt.Sprintf("%s: worlds loaded by %s cannot be opened by %s again", up, up.To, up.From))
+	}
+	if heldForReview != "" {
+		ciAnnotate(w, cfg, ciWarning, "Held for manual review", heldForReview)
+	}
+	return setCIOutputs(w, cfg, []ciOutput{
+		{"update_available", strconv.FormatBool(out.UpdateAvailable)},
+		{"mod_id", strconv.Itoa(out.ModID)},
+		{"installed_version", out.InstalledVersion},
+		{"new_version", out.LatestVersion},
+		{"new_file_id", strconv.Itoa(out.LatestFileID)},
+		{"held_for_review", heldForReview},
+		{"minecraft_upgrade", minecraftUpgrade},
+	})
+}
+
+// printMinecraftUpgrade warns below a status line that worlds cannot go back after up
+func printMinecraftUpgrade(w io.Writer, up *updater.MinecraftUpgrade) {
+	fmt.Fprintf(w, "   %s %s: worlds loaded by %s cannot be opened by %s again; keep a backup\n",
+		colorize(w, colorYellow, "⚠️  World upgrade"), up, up.To, up.From)
+}
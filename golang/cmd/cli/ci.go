@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// Annotation levels of GitHub Actions workflow commands
+const (
+	ciNotice  = "notice"
+	ciWarning = "warning"
+	ciError   = "error"
+)
+
+// ciOutput is one step output written by --ci
+type ciOutput struct {
+	Key   string
+	Value string
+}
+
+// validateCI rejects --ci together with --output json, whose stdout must stay parseable
+func validateCI(ci bool) error {
+	if ci && outputFormat == outputJSON {
+		return fmt.Errorf("--ci cannot be combined with --output json")
+	}
+	return nil
+}
+
+// ciAnnotate writes a workflow command that GitHub Actions shows as an annotation on the
+// run. With --server all the title names the server.
+func ciAnnotate(w io.Writer, cfg *config.Config, level, title, message string) {
+	if serverName == config.AllInstances && cfg.InstanceName != "" {
+		title += " (" + cfg.InstanceName + ")"
+	}
+	fmt.Fprintf(w, "::%s title=%s::%s\n", level, ciEscapeProperty(title), ciEscapeData(message))
+}
+
+// setCIOutputs appends outputs to the file named by GITHUB_OUTPUT, so later steps can read
+// them as steps.<id>.outputs.<key>. Outside GitHub Actions they are printed as key=value
+// lines instead. With --server all every key is prefixed with the server name.
+func setCIOutputs(w io.Writer, cfg *config.Config, outputs []ciOutput) error {
+	var b strings.Builder
+	for _, o := range outputs {
+		key := o.Key
+		if serverName == config.AllInstances && cfg.InstanceName != "" {
+			key = cfg.InstanceName + "_" + key
+		}
+		// Values are single-line; GITHUB_OUTPUT would need a heredoc delimiter otherwise
+		value := strings.Join(strings.Fields(o.Value), " ")
+		fmt.Fprintf(&b, "%s=%s\n", key, value)
+	}
+
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open GITHUB_OUTPUT: %w", err)
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write GITHUB_OUTPUT: %w", err)
+	}
+	return f.Close()
+}
+
+// ciEscapeData escapes the message of a workflow command
+func ciEscapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// ciEscapeProperty escapes a property value of a workflow command, such as its title
+func ciEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
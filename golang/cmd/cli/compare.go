@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// compareOutput is the stable JSON shape printed by `compare --output json`
+type compareOutput struct {
+	ModID      int               `json:"mod_id"`
+	From       updater.PackFile  `json:"from"`
+	To         updater.PackFile  `json:"to"`
+	Added      []updater.ModDiff `json:"added"`
+	Removed    []updater.ModDiff `json:"removed"`
+	Upgraded   []updater.ModDiff `json:"upgraded"`
+	Downgraded []updater.ModDiff `json:"downgraded"`
+	Unchanged  int               `json:"unchanged"`
+}
+
+func compareCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "compare <file-a> <file-b>",
+		Short: "List the mods that differ between two modpack files.",
+		Long: `Download the manifest.json of two files of the configured modpack and list the
+mods file-b adds, removes, upgrades and downgrades compared with file-a, with
+their versions. Nothing is installed.
+
+A file is a CurseForge file ID, "installed" for the installed file or "latest"
+for the newest file on update_channel, so "compare installed latest" shows
+what the next update changes. Server packs are compared by the client file
+they belong to. FTB modpacks are not supported.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cfg.HasAPIAccess() || cfg.ModpackID == 0 {
+				return fmt.Errorf("missing config: api_key and modpack_id are required. Hint: run `init` to scaffold one")
+			}
+			u := updater.NewFromConfig(cfg, slog.Default())
+
+			var check *updater.CheckResult
+			fileIDs := make([]int, 0, len(args))
+			for _, arg := range args {
+				id, err := strconv.Atoi(arg)
+				if name := strings.ToLower(arg); name == "installed" || name == "latest" {
+					if check == nil {
+						if check, err = u.Check(); err != nil {
+							return err
+						}
+					}
+					id = check.Latest.ID
+					if name == "installed" {
+						if !check.State.IsInstalled() {
+							return errors.New("nothing is installed yet, compare file IDs instead")
+						}
+						id = check.State.InstalledFileID
+					}
+				} else if err != nil || id <= 0 {
+					return fmt.Errorf("invalid file %q, want a file ID, installed or latest", arg)
+				}
+				fileIDs = append(fileIDs, id)
+			}
+
+			cmp, err := u.Compare(fileIDs[0], fileIDs[1])
+			if err != nil {
+				return err
+			}
+			out := compareOutput{
+				ModID:      cfg.ModpackID,
+				From:       cmp.From,
+				To:         cmp.To,
+				Added:      cmp.Added,
+				Removed:    cmp.Removed,
+				Upgraded:   cmp.Upgraded,
+				Downgraded: cmp.Downgraded,
+				Unchanged:  cmp.Unchanged,
+			}
+			return render(cmd, out, func(w io.Writer, format string) error { return printCompare(w, format, out) })
+		},
+	}
+}
+
+// printCompare writes the outcome of `compare` in the plain or table format
+func printCompare(w io.Writer, format string, out compareOutput) error {
+	groups := []struct {
+		change string
+		mark   string
+		mods   []updater.ModDiff
+	}{
+		{"added", "+", out.Added},
+		{"removed", "-", out.Removed},
+		{"upgraded", "↑", out.Upgraded},
+		{"downgraded", "↓", out.Downgraded},
+	}
+	if format == outputTable {
+		tw := newTable(w)
+		fmt.Fprintln(tw, "CHANGE\tMOD\tFROM\tTO")
+		for _, g := range groups {
+			for _, m := range g.mods {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", g.change, m.Name, orNone(m.FromVersion), orNone(m.ToVersion))
+			}
+		}
+		return tw.Flush()
+	}
+
+	fmt.Fprintf(w, "Mod %d: %s -> %s\n", out.ModID, out.From.Version, out.To.Version)
+	if out.From.Minecraft != out.To.Minecraft {
+		fmt.Fprintf(w, "   Minecraft %s -> %s\n", orNone(out.From.Minecraft), orNone(out.To.Minecraft))
+	}
+	if out.From.Loaders != out.To.Loaders {
+		fmt.Fprintf(w, "   Mod loader %s -> %s\n", orNone(out.From.Loaders), orNone(out.To.Loaders))
+	}
+	changed := len(out.Added) + len(out.Removed) + len(out.Upgraded) + len(out.Downgraded)
+	if changed == 0 {
+		fmt.Fprintf(w, "✅ Both files ship the same %d mods.\n", out.Unchanged)
+		return nil
+	}
+	for _, g := range groups {
+		for _, m := range g.mods {
+			switch {
+			case m.FromVersion == "":
+				fmt.Fprintf(w, "  %s %s (%s)\n", g.mark, m.Name, m.ToVersion)
+			case m.ToVersion == "":
+				fmt.Fprintf(w, "  %s %s (%s)\n", g.mark, m.Name, m.FromVersion)
+			default:
+				fmt.Fprintf(w, "  %s %s: %s -> %s\n", g.mark, m.Name, m.FromVersion, m.ToVersion)
+			}
+		}
+	}
+	fmt.Fprintf(w, "%d mods changed, %d unchanged.\n", changed, out.Unchanged)
+	return nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/provider"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/spf13/cobra"
+)
+
+// validationCheck is one line of the `config validate` report
+type validationCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped"`
+	Message string `json:"message"`
+}
+
+// validationOutput is the stable JSON shape printed by `config validate --output json`
+type validationOutput struct {
+	ConfigPath string            `json:"config_path"`
+	Valid      bool              `json:"valid"`
+	Checks     []validationCheck `json:"checks"`
+}
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and validate the configuration.",
+	}
+
+	cmd.AddCommand(configValidateCmd())
+	return cmd
+}
+
+func configValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the config file, API key, modpack and paths.",
+		// The config is loaded here so that load errors end up in the report
+		Annotations: map[string]string{"skipConfig": "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, err := cmd.Flags().GetString("config")
+			if err != nil {
+				return err
+			}
+
+			out := validateConfigFile(config.Options{Path: configPath, Flags: cmd.Flags()})
+			if err := render(cmd, out, func(w io.Writer, format string) error {
+				printValidation(w, out)
+				return nil
+			}); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+
+			if !out.Valid {
+				return &exitCodeError{code: exitError}
+			}
+			return nil
+		},
+	}
+}
+
+// validateConfigFile runs all checks against the config described by opts
+func validateConfigFile(opts config.Options) validationOutput {
+	out := validationOutput{ConfigPath: opts.Path, Valid: true}
+	add := func(check validationCheck) {
+		if !check.Passed && !check.Skipped {
+			out.Valid = false
+		}
+		out.Checks = append(out.Checks, check)
+	}
+	skip := func(name, reason string) {
+		add(validationCheck{Name: name, Skipped: true, Message: reason})
+	}
+
+	cfg, err := config.Load(opts)
+	if err != nil {
+		add(validationCheck{Name: "load", Message: err.Error()})
+		for _, name := range []string{"schema", "api_key", "modpack", "server_path", "backup_path", "docker"} {
+			skip(name, "config could not be loaded")
+		}
+		return out
+	}
+	add(validationCheck{Name: "load", Passed: true, Message: cfg.File + " parsed"})
+	for _, msg := range cfg.Deprecations {
+		add(validationCheck{Name: "legacy_key", Skipped: true, Message: msg})
+	}
+
+	if err := config.Validate(cfg); err != nil {
+		add(validationCheck{Name: "schema", Message: err.Error()})
+	} else {
+		add(validationCheck{Name: "schema", Passed: true, Message: "all required values are set"})
+	}
+
+	client := api.NewClientFromConfig(cfg)
+	apiKeyOK := false
+	if !cfg.HasAPIAccess() {
+		skip("api_key", "api_key is not set")
+	} else if err := client.ValidateAPIKey(); err != nil {
+		add(validationCheck{Name: "api_key", Message: err.Error()})
+	} else {
+		apiKeyOK = true
+		add(validationCheck{Name: "api_key", Passed: true, Message: "API key accepted"})
+	}
+
+	switch {
+	case cfg.ModpackID <= 0:
+		skip("modpack", "modpack_id is not set")
+	case cfg.ModpackProvider == provider.NameFTB:
+		pack, err := provider.NewFTB(cfg).GetProject(strconv.Itoa(cfg.ModpackID))
+		if err != nil {
+			add(validationCheck{Name: "modpack", Message: err.Error()})
+		} else {
+			add(validationCheck{Name: "modpack", Passed: true, Message: fmt.Sprintf("%s (FTB %s)", pack.Name, pack.ID)})
+		}
+	case !apiKeyOK:
+		skip("modpack", "API key could not be verified")
+	default:
+		mod, err := client.GetMod(cfg.ModpackID)
+		switch {
+		case err != nil:
+			add(validationCheck{Name: "modpack", Message: err.Error()})
+		case mod.ClassID != api.ClassIDModpacks:
+			add(validationCheck{Name: "modpack", Message: fmt.Sprintf("%s (%d) is not a modpack (class ID %d)", mod.Name, mod.ID, mod.ClassID)})
+		default:
+			add(validationCheck{Name: "modpack", Passed: true, Message: fmt.Sprintf("%s (%d)", mod.Name, mod.ID)})
+		}
+	}
+
+	for _, p := range []struct{ name, path string }{
+		{"server_path", cfg.ServerPath},
+		{"backup_path", cfg.BackupPath},
+	} {
+		if p.path == "" {
+			skip(p.name, p.name+" is not set")
+			continue
+		}
+		if err := filesystem.CheckWritable(p.path); err != nil {
+			add(validationCheck{Name: p.name, Message: err.Error()})
+		} else {
+			add(validationCheck{Name: p.name, Passed: true, Message: p.path + " is writable"})
+		}
+	}
+
+	if cfg.Server.Mode == server.ModeDocker {
+		add(checkDockerMount(cfg))
+	}
+	if props, err := server.LoadProperties(cfg.ServerPath); errors.Is(err, os.ErrNotExist) {
+		skip("server_properties", "no server.properties in server_path yet")
+	} else {
+		add(checkServerProperties(cfg, props, err))
+	}
+
+	return out
+}
+
+// checkServerProperties validates server.properties and checks that server.rcon reaches the
+// console it enables
+func checkServerProperties(cfg *config.Config, props *server.Properties, err error) validationCheck {
+	check := validationCheck{Name: "server_properties"}
+	if err == nil {
+		err = props.Validate()
+	}
+	if err != nil {
+		check.Message = strings.ReplaceAll(err.Error(), "\n", "; ")
+		return check
+	}
+	if addr := cfg.Server.RCON.Address; addr != "" {
+		_, port, _ := net.SplitHostPort(addr)
+		switch {
+		case !props.RCONEnabled():
+			check.Message = "server.rcon is set but enable-rcon is not true"
+			return check
+		case port != strconv.Itoa(props.RCONPort()):
+			check.Message = fmt.Sprintf("server.rcon.address uses port %s but rcon.port is %d", port, props.RCONPort())
+			return check
+		case cfg.Server.RCON.Password != props.RCONPassword():
+			check.Message = "server.rcon.password does not match rcon.password"
+			return check
+		}
+	}
+	check.Passed = true
+	check.Message = fmt.Sprintf("%d properties valid, port %d", len(props.Keys()), props.Port())
+	return check
+}
+
+// checkDockerMount checks that server_path is the host directory mounted into the container,
+// so installs land where the container reads them
+func checkDockerMount(cfg *config.Config) validationCheck {
+	check := validationCheck{Name: "docker"}
+	c, err := managedServer(cfg)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	hostPath, err := c.(*server.DockerServer).HostPath()
+	switch {
+	case err != nil:
+		check.Message = err.Error()
+	case hostPath == "":
+		check.Message = fmt.Sprintf("%s has no mount at %s", cfg.Server.Docker.Container, cfg.Server.Docker.DataDir)
+	case !samePath(hostPath, cfg.ServerPath):
+		check.Message = fmt.Sprintf("server_path %s is not %s, which is mounted at %s in %s", cfg.ServerPath, hostPath, cfg.Server.Docker.DataDir, cfg.Server.Docker.Container)
+	default:
+		check.Passed = true
+		check.Message = fmt.Sprintf("%s is mounted at %s in %s", hostPath, cfg.Server.Docker.DataDir, cfg.Server.Docker.Container)
+	}
+	return check
+}
+
+// samePath reports whether two paths name the same directory
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && filepath.Clean(absA) == filepath.Clean(absB)
+}
+
+// printValidation prints the report with colored PASS/FAIL markers
+func printValidation(w io.Writer, out validationOutput) {
+	fmt.Fprintf(w, "Validating %s\n\n", out.ConfigPath)
+	for _, check := range out.Checks {
+		status := colorize(w, colorGreen, "PASS")
+		switch {
+		case check.Skipped:
+			status = colorize(w, colorYellow, "SKIP")
+		case !check.Passed:
+			status = colorize(w, colorRed, "FAIL")
+		}
+		fmt.Fprintf(w, "  [%s] %-12s %s\n", status, check.Name, check.Message)
+	}
+	fmt.Fprintln(w)
+	if out.Valid {
+		fmt.Fprintln(w, colorize(w, colorGreen, "✅ Configuration is valid."))
+	} else {
+		fmt.Fprintln(w, colorize(w, colorRed, "❌ Configuration has errors."))
+	}
+}
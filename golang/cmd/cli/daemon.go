@@ -0,0 +1,549 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/approval"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/eventsink"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/fleet"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/logging"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/notification"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/policy"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/scheduler"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/service"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// daemon runs scheduled checks and applies configuration reloads
+type daemon struct {
+	mu     sync.Mutex
+	cfg    *config.Config
+	notify *notification.Manager
+	sched  *scheduler.Scheduler
+	bus    *events.Bus // updates and server problems are published here for the notifications
+	sink   *eventsink.Sink
+	unsink func()
+
+	strained bool // the last resource sample crossed a server.monitor threshold
+}
+
+func daemonCmd(cfg *config.Config) *cobra.Command {
+	var watch bool
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Check for updates on a schedule.",
+		Long: `Run in the foreground and check for updates every check_interval,
+inside the maintenance window if one is configured. Updates are installed
+automatically when update_policy allows them. With --server all every
+[[servers]] entry is checked on its own check_interval.
+
+The config file is reloaded on SIGHUP and, with --watch, whenever it
+changes. Notification, schedule and backup retention settings take effect
+without a restart; an invalid config is rejected and the previous one kept.`,
+		Annotations: map[string]string{annotationServers: config.AllInstances},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.Validate(cfg); err != nil {
+				return fmt.Errorf("invalid config: %w", err)
+			}
+			configPath, err := cmd.Flags().GetString("config")
+			if err != nil {
+				return err
+			}
+
+			// With --server all every server runs on its own schedule
+			instances := []*config.Config{cfg}
+			if serverName == config.AllInstances {
+				instances = cfg.Instances()
+			}
+			daemons := make([]*daemon, 0, len(instances))
+			for _, inst := range instances {
+				d, err := newDaemon(inst)
+				if err != nil {
+					return err
+				}
+				daemons = append(daemons, d)
+			}
+
+			// Under the Windows service control manager a stop request cancels the context
+			return service.Run(cmd.Context(), func(ctx context.Context) error {
+				ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				defer stop()
+
+				watcher := config.NewWatcher(config.Options{Path: configPath, Flags: cmd.Flags()}, func(reloaded *config.Config, err error) {
+					for _, d := range daemons {
+						d.reload(instanceOf(reloaded, d.current().InstanceName, err))
+					}
+				})
+				hup := make(chan os.Signal, 1)
+				signal.Notify(hup, syscall.SIGHUP)
+				defer signal.Stop(hup)
+				go func() {
+					for {
+						select {
+						case <-ctx.Done():
+							return
+						case <-hup:
+							baseLogger.Info("SIGHUP received, reloading config", "file", cfg.File)
+							watcher.Reload()
+						}
+					}
+				}()
+				if watch && cfg.File != "" {
+					go func() {
+						if err := watcher.Run(ctx, cfg.File); err != nil && !errors.Is(err, context.Canceled) {
+							baseLogger.Warn("config file watching stopped", "error", err)
+						}
+					}()
+				}
+
+				errs := make(chan error, len(daemons))
+				for _, d := range daemons {
+					inst := d.current()
+					baseLogger.Info("daemon started", instanceAttrs(inst, logging.KeyModID, inst.ModpackID, "check_interval", inst.CheckInterval)...)
+					go d.monitor(ctx)
+					go func(d *daemon) {
+						if err := d.sched.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+							errs <- err
+							stop()
+							return
+						}
+						errs <- nil
+					}(d)
+				}
+				var runErr error
+				for range daemons {
+					if err := <-errs; err != nil && runErr == nil {
+						runErr = err
+					}
+				}
+				for _, d := range daemons {
+					d.logEndpointStats()
+					// Publishes the events still queued
+					d.setSink(nil)
+				}
+				if runErr != nil {
+					return runErr
+				}
+				baseLogger.Info("daemon stopped")
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&watch, "watch", true, "Reload the config file when it changes")
+	return cmd
+}
+
+// newDaemon sets up the notifications and schedule for cfg
+func newDaemon(cfg *config.Config) (*daemon, error) {
+	d := &daemon{cfg: cfg, notify: notification.NewManager(&cfg.Notifications), bus: events.NewBus(nil)}
+	d.notify.SetHTTPClient(httpclient.New(cfg.HTTP))
+	d.notify.Subscribe(d.bus, func() string { return notificationName(d.current()) }, func(event string, err error) {
+		d.notified(baseLogger.With(instanceAttrs(d.current())...), event, err)
+	})
+	d.sched = scheduler.New(clock.Real(), cfg.CheckInterval, d.run)
+	window, err := scheduler.WindowFromConfig(&cfg.Maintenance)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window: %w", err)
+	}
+	d.sched.SetWindow(window)
+	d.sched.SetAnnouncement(cfg.Maintenance.AnnounceBefore, d.announce)
+	lock, err := fleetLock(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fleet lock: %w", err)
+	}
+	d.sched.SetJitter(cfg.Fleet.Jitter)
+	d.sched.SetLock(lock)
+	sink, err := eventsink.NewFromConfig(cfg, baseLogger.With(instanceAttrs(cfg)...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid event sink: %w", err)
+	}
+	d.setSink(sink)
+	return d, nil
+}
+
+// setSink publishes the events of the daemon to sink instead of the previous sink,
+// which is closed once it published its queued events; nil stops publishing
+func (d *daemon) setSink(sink *eventsink.Sink) {
+	d.mu.Lock()
+	old, unsubscribe := d.sink, d.unsink
+	d.sink, d.unsink = sink, nil
+	if sink != nil {
+		d.unsink = sink.Subscribe(d.bus)
+	}
+	d.mu.Unlock()
+	if old != nil {
+		unsubscribe()
+		old.Close()
+	}
+}
+
+// fleetLock creates the lock that the daemons of a fleet take turns with, or returns nil
+// when cfg sets none
+func fleetLock(cfg *config.Config) (scheduler.Locker, error) {
+	lock, err := fleet.NewFromConfig(cfg.Fleet, baseLogger.With(instanceAttrs(cfg)...))
+	if err != nil || lock == nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// instanceOf picks the [[servers]] entry called name from a reloaded config, or the
+// config itself for the top-level server
+func instanceOf(cfg *config.Config, name string, err error) (*config.Config, error) {
+	if err != nil || name == "" {
+		return cfg, err
+	}
+	return cfg.Instance(name)
+}
+
+// instanceAttrs prepends the server name to log attributes when cfg is a [[servers]] entry
+func instanceAttrs(cfg *config.Config, attrs ...any) []any {
+	if cfg.InstanceName == "" {
+		return attrs
+	}
+	return append([]any{"server", cfg.InstanceName}, attrs...)
+}
+
+// current returns the configuration currently in effect
+func (d *daemon) current() *config.Config {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cfg
+}
+
+// run is the scheduled task: check, update if enabled, then prune old backups.
+// Every run logs with its own run ID.
+func (d *daemon) run(ctx context.Context) error {
+	cfg := d.current()
+	logger := logging.WithRun(baseLogger, logging.NewRunID(), cfg.ModpackID)
+	if cfg.InstanceName != "" {
+		logger = logger.With("server", cfg.InstanceName)
+	}
+
+	checker := updater.NewFromConfig(cfg, logger)
+	result, err := checker.Check()
+	if err != nil {
+		logger.Error("update check failed", "error", err)
+		return err
+	}
+	if !result.UpdateAvailable {
+		return nil
+	}
+
+	name := notificationName(cfg)
+	current := orNone(result.State.InstalledVersion)
+	decision := d.evaluatePolicy(cfg, logger, cfg.UpdatePolicy, candidateFor(checker, result, cfg.UpdatePolicy), time.Now())
+	if decision.Install && cfg.Approval.Required {
+		if approved, err := d.awaitApproval(cfg, logger, name, current, result.Latest); err != nil || !approved {
+			return err
+		}
+	} else {
+		d.publish(cfg, events.UpdateAvailable, map[string]interface{}{
+			"installed_version": current,
+			"latest_version":    result.Latest.DisplayName,
+			"policy":            decision.String(),
+		})
+		if !decision.Install {
+			return nil
+		}
+	}
+
+	// The updater publishes the outcome, which is sent as a notification
+	u := updater.NewFromConfig(cfg, logger)
+	u.SetBus(d.bus)
+	if _, err := u.Update(false); err != nil {
+		return err
+	}
+
+	backups := newBackupManager(cfg)
+	backups.SetLogger(logger)
+	if err := backups.CleanupOldBackups(); err != nil {
+		logger.Warn("failed to clean up old backups", "error", err)
+	}
+	return nil
+}
+
+// announce runs ahead of a scheduled run in the maintenance window and sends
+// update_scheduled when that run will install an update
+func (d *daemon) announce(ctx context.Context, at time.Time) {
+	cfg := d.current()
+	if !cfg.UpdatePolicy.AutoUpdate {
+		return
+	}
+	logger := logging.WithRun(baseLogger, logging.NewRunID(), cfg.ModpackID)
+	if cfg.InstanceName != "" {
+		logger = logger.With("server", cfg.InstanceName)
+	}
+
+	checker := updater.NewFromConfig(cfg, logger)
+	result, err := checker.Check()
+	if err != nil {
+		logger.Warn("update check before the maintenance window failed", "error", err)
+		return
+	}
+	if !result.UpdateAvailable {
+		return
+	}
+	// Who will be online at the run is not known yet
+	rules := cfg.UpdatePolicy
+	rules.RequireEmptyServer = false
+	if decision := d.evaluatePolicy(cfg, logger, rules, candidateFor(checker, result, rules), at); !decision.Install {
+		return
+	}
+	// Without approval the update would not run; the request goes out at the run itself
+	if a := result.State.Approval; cfg.Approval.Required && (a == nil || a.FileID != result.Latest.ID || a.Status != approval.StatusApproved) {
+		logger.Info("update needs approval, not announcing it", "version", result.Latest.DisplayName)
+		return
+	}
+	logger.Info("update scheduled", "version", result.Latest.DisplayName, "at", at)
+	d.notified(logger, "update_scheduled", d.notify.SendUpdateScheduledNotification(notificationName(cfg), orNone(result.State.InstalledVersion), result.Latest.DisplayName, at))
+}
+
+// candidateFor describes the update found by checker for the update policy
+func candidateFor(checker *updater.Updater, result *updater.CheckResult, rules config.UpdatePolicyConfig) policy.Candidate {
+	candidate := policy.CandidateFor(result.Latest, rules.HoldRules, checker.Changelog)
+	if result.MinecraftUpgrade != nil {
+		candidate.MinecraftUpgrade = result.MinecraftUpgrade.String()
+	}
+	return candidate
+}
+
+// evaluatePolicy decides whether the run at now installs candidate under rules, and logs
+// the decision with its reasons
+func (d *daemon) evaluatePolicy(cfg *config.Config, logger *slog.Logger, rules config.UpdatePolicyConfig, candidate policy.Candidate, now time.Time) policy.Decision {
+	if cfg.Maintenance.Timezone != "" {
+		if loc, err := time.LoadLocation(cfg.Maintenance.Timezone); err == nil {
+			now = now.In(loc)
+		}
+	}
+	facts := policy.Facts{Now: now}
+	if rules.MaxUpdatesPerWeek > 0 {
+		entries, err := history.NewLog(filepath.Join(cfg.DataDir, history.FileName)).List(history.Filter{Result: history.ResultSuccess})
+		if err != nil {
+			logger.Warn("failed to read the update history for max_updates_per_week", "error", err)
+		}
+		for _, entry := range entries {
+			if entry.ModID == cfg.ModpackID && now.Sub(entry.Timestamp) < policy.Week {
+				facts.RecentInstalls++
+			}
+		}
+	}
+	if rules.RequireEmptyServer {
+		facts.PlayersOnline, facts.PlayersErr = server.NewRCONCommander(cfg.Server.RCON).OnlinePlayers()
+	}
+
+	decision := policy.Evaluate(rules, candidate, facts)
+	logger.Info("update policy decision", "version", candidate.Version, "install", decision.Install, "reasons", decision.Reasons())
+	return decision
+}
+
+// monitorIdle is how often the daemon looks whether a reload enabled server.monitor
+const monitorIdle = time.Minute
+
+// monitor samples the server every server.monitor.interval until ctx ends
+func (d *daemon) monitor(ctx context.Context) {
+	for {
+		interval := d.current().Server.Monitor.Interval
+		wait := interval
+		if wait <= 0 {
+			wait = monitorIdle
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if interval > 0 {
+			d.checkResources()
+		}
+	}
+}
+
+// checkResources samples the server and sends server_degraded when it crosses a
+// threshold, and server_recovered once it is back within all of them
+func (d *daemon) checkResources() {
+	cfg := d.current()
+	logger := baseLogger
+	if cfg.InstanceName != "" {
+		logger = logger.With("server", cfg.InstanceName)
+	}
+
+	// In process mode the web UI owns the server process, so only the TPS can be read
+	var controller server.Controller
+	if cfg.Server.Mode != "" && cfg.Server.Mode != server.ModeProcess {
+		c, err := server.NewController(cfg)
+		if err != nil {
+			logger.Warn("server monitoring failed", "error", err)
+			return
+		}
+		controller = c
+	}
+	res, err := server.SampleResources(cfg, controller)
+	if errors.Is(err, server.ErrNotRunning) {
+		return
+	}
+	if err != nil {
+		logger.Warn("server monitoring failed", "error", err)
+		return
+	}
+	logger.Debug("server resources", "cpu_percent", res.CPUPercent, "memory_bytes", res.MemoryBytes, "tps", res.TPS)
+
+	problems := res.Exceeds(cfg.Server.Monitor)
+	switch {
+	case len(problems) > 0 && !d.strained:
+		logger.Warn("server resources above threshold", "problems", problems)
+		d.publish(cfg, events.ServerDegraded, map[string]interface{}{"problems": problems})
+	case len(problems) == 0 && d.strained:
+		logger.Info("server resources back within thresholds")
+		d.publish(cfg, events.ServerRecovered, map[string]interface{}{})
+	}
+	d.strained = len(problems) > 0
+}
+
+// publish sends an event about the modpack of cfg to the bus of the daemon
+func (d *daemon) publish(cfg *config.Config, eventType string, data map[string]interface{}) {
+	data["mod_id"] = cfg.ModpackID
+	d.bus.Publish(eventType, data)
+}
+
+// notificationName names the modpack of cfg in notifications
+func notificationName(cfg *config.Config) string {
+	if cfg.InstanceName != "" {
+		return fmt.Sprintf("%s (mod %d)", cfg.InstanceName, cfg.ModpackID)
+	}
+	return fmt.Sprintf("Mod %d", cfg.ModpackID)
+}
+
+// awaitApproval moves the approval request for latest along and reports whether it may be
+// installed. The request, reminders and expiry are sent as notifications.
+func (d *daemon) awaitApproval(cfg *config.Config, logger *slog.Logger, name, current string, latest *api.ModFile) (bool, error) {
+	var action string
+	st, err := state.NewStore(filepath.Join(cfg.DataDir, state.FileName)).Update(func(st *state.State) error {
+		action = approval.Advance(st, latest.ID, latest.DisplayName, time.Now(), cfg.Approval)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	a := st.Approval
+	req := notification.ApprovalRequest{ModpackName: name, CurrentVersion: current, NewVersion: a.Version, ExpiresAt: a.ExpiresAt}
+	req.ApproveURL, req.DenyURL = approvalLinks(cfg)
+	switch action {
+	case approval.ActionInstall:
+		logger.Info("update approved", "version", a.Version, "approved_by", a.DecidedBy)
+		return true, nil
+	case approval.ActionRequest:
+		logger.Info("update waiting for approval", "version", a.Version, "expires_at", a.ExpiresAt)
+		d.notified(logger, notification.EventApprovalRequested, d.notify.SendApprovalNotification(notification.EventApprovalRequested, req))
+	case approval.ActionRemind:
+		logger.Info("update still waiting for approval, reminder sent", "version", a.Version)
+		d.notified(logger, notification.EventApprovalReminder, d.notify.SendApprovalNotification(notification.EventApprovalReminder, req))
+	case approval.ActionExpire:
+		logger.Warn("approval expired, the update is not installed", "version", a.Version)
+		d.notified(logger, notification.EventApprovalExpired, d.notify.SendApprovalNotification(notification.EventApprovalExpired, req))
+	case approval.ActionWait:
+		logger.Info("update still waiting for approval", "version", a.Version)
+	default:
+		logger.Info("update not approved, skipping it", "version", a.Version, "status", a.Status)
+	}
+	return false, nil
+}
+
+// approvalLinks returns the web UI links that approve and deny the pending update of cfg,
+// or empty strings without web.public_url
+func approvalLinks(cfg *config.Config) (string, string) {
+	base := strings.TrimRight(cfg.Web.PublicURL, "/")
+	if base == "" {
+		return "", ""
+	}
+	link := func(decision string) string {
+		q := url.Values{"decision": {decision}}
+		if cfg.InstanceName != "" {
+			q.Set("server", cfg.InstanceName)
+		}
+		return base + "/approvals?" + q.Encode()
+	}
+	return link("approve"), link("deny")
+}
+
+// notified logs the outcome of sending a notification
+func (d *daemon) notified(logger *slog.Logger, kind string, err error) {
+	if err != nil {
+		logger.Warn("failed to send notification", "notification", kind, "error", err)
+		return
+	}
+	if d.notify.IsEnabled() {
+		logger.Info("notification sent", "notification", kind)
+	}
+}
+
+// logEndpointStats logs how the notification endpoints fared, e.g. when stopping
+func (d *daemon) logEndpointStats() {
+	for _, s := range d.notify.EndpointStats() {
+		baseLogger.Info("notification endpoint stats", instanceAttrs(d.current(), "host", s.Host, "requests", s.Requests,
+			"retries", s.Retries, "failures", s.Failures, "skipped", s.Skipped, "open", s.Open)...)
+	}
+}
+
+// reload applies a reloaded config, or keeps the current one if loading failed
+func (d *daemon) reload(cfg *config.Config, err error) {
+	var window *scheduler.Window
+	var lock scheduler.Locker
+	var sink *eventsink.Sink
+	if err == nil {
+		if window, err = scheduler.WindowFromConfig(&cfg.Maintenance); err != nil {
+			err = fmt.Errorf("invalid maintenance window: %w", err)
+		} else if lock, err = fleetLock(cfg); err != nil {
+			err = fmt.Errorf("invalid fleet lock: %w", err)
+		} else if sink, err = eventsink.NewFromConfig(cfg, baseLogger.With(instanceAttrs(cfg)...)); err != nil {
+			err = fmt.Errorf("invalid event sink: %w", err)
+		}
+	}
+	if err != nil {
+		baseLogger.Error("config reload failed, keeping previous config", instanceAttrs(d.current(), "error", err)...)
+		d.notified(baseLogger, "config_reload_failed", d.notify.SendMessage(fmt.Sprintf("❌ Configuration reload failed: %v", err)))
+		return
+	}
+
+	d.mu.Lock()
+	d.cfg = cfg
+	d.mu.Unlock()
+	logOutput.SetSecrets(cfg.Secrets()...)
+
+	d.notify.SetHTTPClient(httpclient.New(cfg.HTTP))
+	d.notify.UpdateConfig(&cfg.Notifications)
+	d.sched.SetInterval(cfg.CheckInterval)
+	d.sched.SetWindow(window)
+	d.sched.SetAnnouncement(cfg.Maintenance.AnnounceBefore, d.announce)
+	d.sched.SetJitter(cfg.Fleet.Jitter)
+	d.sched.SetLock(lock)
+	d.setSink(sink)
+	baseLogger.Info("config reloaded", instanceAttrs(cfg, "file", cfg.File, "check_interval", cfg.CheckInterval)...)
+	for _, msg := range cfg.Deprecations {
+		baseLogger.Warn(msg)
+	}
+	d.notified(baseLogger, "config_reloaded", d.notify.SendMessage("🔄 Configuration reloaded"))
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// diffOutput is the stable JSON shape printed by `diff --output json`
+type diffOutput struct {
+	ModID           int             `json:"mod_id"`
+	FromVersion     string          `json:"from_version"`
+	ToVersion       string          `json:"to_version"`
+	UpdateAvailable bool            `json:"update_available"`
+	ModsAdded       []string        `json:"mods_added"`
+	ModsRemoved     []string        `json:"mods_removed"`
+	ModsChanged     []modChangeJSON `json:"mods_changed"`
+	FilesAdded      []string        `json:"files_added"`
+	FilesChanged    []string        `json:"files_changed"`
+	Preserved       []string        `json:"preserved"`
+}
+
+// modChangeJSON is a mod shipped in another jar than the one installed
+type modChangeJSON struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func diffCmd(cfg *config.Config) *cobra.Command {
+	var waitManual bool
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Preview what the latest server pack would change.",
+		Long: `Download the latest server pack, unpack it into a temporary directory and
+compare it with server_path, without touching the server.
+
+Mods are compared by the jars in the mods folder: jars the pack adds, jars it
+no longer ships, and mods that come in another file, usually a new version.
+Other files, such as configs, are listed when the pack adds them or when their
+content differs from the live server. Changed files matching preserve are
+marked, as an update keeps or merges them.
+
+With --server all every server is compared.`,
+		Annotations: map[string]string{annotationServers: config.AllInstances},
+		RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+			if !cfg.HasAPIAccess() || cfg.ModpackID == 0 {
+				return fmt.Errorf("missing config: api_key and modpack_id are required. Hint: run `init` to scaffold one")
+			}
+
+			u := updater.NewFromConfig(cfg, slog.Default())
+			u.SetDownloadProgress(newProgressBar(cmd.ErrOrStderr()))
+			if waitManual {
+				u.SetManualWait(promptManualDownload(cmd.InOrStdin(), cmd.ErrOrStderr()))
+			}
+			diff, err := u.Diff()
+			if err != nil {
+				return err
+			}
+
+			out := diffOutput{
+				ModID:           cfg.ModpackID,
+				FromVersion:     diff.FromVersion,
+				ToVersion:       diff.ToVersion,
+				UpdateAvailable: diff.UpdateAvailable,
+				ModsAdded:       append([]string{}, diff.ModsAdded...),
+				ModsRemoved:     append([]string{}, diff.ModsRemoved...),
+				ModsChanged:     []modChangeJSON{},
+				FilesAdded:      append([]string{}, diff.FilesAdded...),
+				FilesChanged:    append([]string{}, diff.FilesChanged...),
+				Preserved:       append([]string{}, diff.Preserved...),
+			}
+			for _, c := range diff.ModsChanged {
+				out.ModsChanged = append(out.ModsChanged, modChangeJSON{From: c.From, To: c.To})
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if format == outputTable {
+					tw := newTable(w)
+					fmt.Fprintln(tw, "CHANGE\tKIND\tPATH")
+					for _, m := range out.ModsAdded {
+						fmt.Fprintf(tw, "added\tmod\t%s\n", m)
+					}
+					for _, m := range out.ModsRemoved {
+						fmt.Fprintf(tw, "removed\tmod\t%s\n", m)
+					}
+					for _, m := range out.ModsChanged {
+						fmt.Fprintf(tw, "changed\tmod\t%s\n", modChangeLabel(m))
+					}
+					for _, f := range out.FilesAdded {
+						fmt.Fprintf(tw, "added\tfile\t%s\n", f)
+					}
+					for _, f := range out.FilesChanged {
+						change := "changed"
+						if slices.Contains(out.Preserved, f) {
+							change = "preserved"
+						}
+						fmt.Fprintf(tw, "%s\tfile\t%s\n", change, f)
+					}
+					return tw.Flush()
+				}
+
+				fmt.Fprintf(w, "Mod %d: %s -> %s\n", out.ModID, orNone(out.FromVersion), out.ToVersion)
+				if !out.UpdateAvailable {
+					fmt.Fprintln(w, "ℹ️  The latest version is already installed; showing how the server differs from it.")
+				}
+				if len(out.ModsAdded)+len(out.ModsRemoved)+len(out.ModsChanged)+len(out.FilesAdded)+len(out.FilesChanged) == 0 {
+					fmt.Fprintln(w, "✅ The server matches the pack.")
+					return nil
+				}
+				for _, m := range out.ModsAdded {
+					fmt.Fprintf(w, "  + %s\n", m)
+				}
+				for _, m := range out.ModsRemoved {
+					fmt.Fprintf(w, "  - %s\n", m)
+				}
+				for _, m := range out.ModsChanged {
+					fmt.Fprintf(w, "  ~ %s\n", modChangeLabel(m))
+				}
+				if len(out.FilesAdded)+len(out.FilesChanged) > 0 {
+					fmt.Fprintln(w, "Files:")
+				}
+				for _, f := range out.FilesAdded {
+					fmt.Fprintf(w, "  + %s\n", f)
+				}
+				for _, f := range out.FilesChanged {
+					if slices.Contains(out.Preserved, f) {
+						fmt.Fprintf(w, "  ~ %s (preserved)\n", f)
+						continue
+					}
+					fmt.Fprintf(w, "  ~ %s\n", f)
+				}
+				return nil
+			})
+		}),
+	}
+
+	cmd.Flags().BoolVar(&waitManual, "wait-manual", false, "Wait for files that must be downloaded by hand instead of failing")
+	return cmd
+}
+
+// modChangeLabel renders a mod change as "old.jar -> new.jar", or just the name when the
+// jar kept its name
+func modChangeLabel(m modChangeJSON) string {
+	if m.From == m.To {
+		return m.To
+	}
+	return m.From + " -> " + m.To
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/downloads"
+	"github.com/spf13/cobra"
+)
+
+// prunedDownloadOutput is the stable JSON shape of one entry printed by `downloads prune --output json`
+type prunedDownloadOutput struct {
+	FileID    int    `json:"file_id"`
+	FileName  string `json:"file_name"`
+	Version   string `json:"version"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// downloadCacheOutput is the stable JSON shape printed by `downloads cache --output json`
+type downloadCacheOutput struct {
+	Dir          string `json:"dir"`
+	Files        int    `json:"files"`
+	SizeBytes    int64  `json:"size_bytes"`
+	MaxSizeBytes int64  `json:"max_size_bytes"` // 0 for no limit
+}
+
+// cachedFileOutput is the stable JSON shape of one entry printed by `downloads cache prune --output json`
+type cachedFileOutput struct {
+	SHA1      string    `json:"sha1"`
+	SizeBytes int64     `json:"size_bytes"`
+	LastUsed  time.Time `json:"last_used"`
+}
+
+func downloadsCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "downloads",
+		Short: "Manage the modpack files kept in download_path and the download cache.",
+	}
+	cmd.AddCommand(downloadsPruneCmd(cfg), downloadsCacheCmd(cfg))
+	return cmd
+}
+
+func downloadsPruneCmd(cfg *config.Config) *cobra.Command {
+	var keep int
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove old modpack downloads, keeping download.keep_versions of each.",
+		Long: `Remove the downloads in download_path that newer versions of the same
+modpack have superseded, keeping the newest download.keep_versions (or --keep)
+of each, as every update does. Only files recorded in download_metadata.json are
+considered. With --dry-run the files are listed but kept.`,
+		Annotations: map[string]string{annotationServers: config.AllInstances, annotationDryRun: "true", annotationAudit: "downloads.prune"},
+		RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("keep") {
+				keep = cfg.Download.KeepVersions
+			}
+			if keep <= 0 {
+				return fmt.Errorf("download.keep_versions is 0, so every download is kept; pass --keep to prune anyway")
+			}
+			pruned, err := downloads.Prune(cfg.DownloadPath, keep, dryRun)
+			out := []prunedDownloadOutput{}
+			var total int64
+			for _, p := range pruned {
+				out = append(out, prunedDownloadOutput{
+					FileID:    p.FileID,
+					FileName:  p.Record.FileName,
+					Version:   p.Record.DisplayName,
+					SizeBytes: p.Record.FileLength,
+				})
+				total += p.Record.FileLength
+			}
+			if err != nil {
+				return err
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				verb := "Removed"
+				if dryRun {
+					verb = "Would remove"
+				}
+				if len(out) == 0 {
+					fmt.Fprintf(w, "No downloads beyond the newest %d in %s\n", keep, cfg.DownloadPath)
+					return nil
+				}
+				fmt.Fprintf(w, "🧹 %s %d downloads (%s):\n", verb, len(out), filesystem.FormatBytes(total))
+				for _, d := range out {
+					fmt.Fprintf(w, "  - %s (%s)\n", d.FileName, orNone(d.Version))
+				}
+				return nil
+			})
+		}),
+	}
+	cmd.Flags().IntVar(&keep, "keep", 0, "Versions to keep of each modpack (default download.keep_versions)")
+	return cmd
+}
+
+// downloadCache returns the cache in download.cache_dir, which every server shares
+func downloadCache(cfg *config.Config) (*downloads.Cache, error) {
+	cache := downloads.NewCache(cfg.Download.CacheDir, cfg.Download.CacheMaxSizeBytes())
+	if cache == nil {
+		return nil, errors.New("download.cache_dir is not set, so downloads are not cached")
+	}
+	return cache, nil
+}
+
+func downloadsCacheCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Show the download cache shared by all servers.",
+		Long: `Show how many files the download cache in download.cache_dir holds and how
+much space they take. Modpack, mod and server pack files are kept there by their
+SHA-1 hash, so that every server and every reinstall reuses them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := downloadCache(cfg)
+			if err != nil {
+				return err
+			}
+			entries, err := cache.List()
+			if err != nil {
+				return err
+			}
+			out := downloadCacheOutput{Dir: cache.Dir(), Files: len(entries), MaxSizeBytes: cfg.Download.CacheMaxSizeBytes()}
+			for _, e := range entries {
+				out.SizeBytes += e.Size
+			}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				limit := "no limit"
+				if out.MaxSizeBytes > 0 {
+					limit = filesystem.FormatBytes(out.MaxSizeBytes)
+				}
+				fmt.Fprintf(w, "📦 %d files, %s of %s in %s\n", out.Files, filesystem.FormatBytes(out.SizeBytes), limit, out.Dir)
+				return nil
+			})
+		},
+	}
+	cmd.AddCommand(downloadsCachePruneCmd(cfg))
+	return cmd
+}
+
+func downloadsCachePruneCmd(cfg *config.Config) *cobra.Command {
+	var maxSize string
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the least recently used files from the download cache.",
+		Long: `Remove the least recently used files from the download cache until it holds at
+most download.cache_max_size (or --max-size), as every download into the cache
+does. --max-size 0 empties the cache. With --dry-run the files are listed but kept.`,
+		Annotations: map[string]string{annotationDryRun: "true", annotationAudit: "downloads.cache.prune"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := downloadCache(cfg)
+			if err != nil {
+				return err
+			}
+			limit := cfg.Download.CacheMaxSizeBytes()
+			if cmd.Flags().Changed("max-size") {
+				if limit, err = config.ParseSize(maxSize); err != nil {
+					return fmt.Errorf("invalid --max-size: %w", err)
+				}
+			} else if limit == 0 {
+				return fmt.Errorf("download.cache_max_size is 0, so the cache has no limit; pass --max-size to prune anyway")
+			}
+			pruned, err := cache.Prune(limit, dryRun)
+			out := []cachedFileOutput{}
+			var total int64
+			for _, e := range pruned {
+				out = append(out, cachedFileOutput{SHA1: e.Hash, SizeBytes: e.Size, LastUsed: e.LastUsed})
+				total += e.Size
+			}
+			if err != nil {
+				return err
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				verb := "Removed"
+				if dryRun {
+					verb = "Would remove"
+				}
+				if len(out) == 0 {
+					fmt.Fprintf(w, "The download cache is within %s\n", filesystem.FormatBytes(limit))
+					return nil
+				}
+				fmt.Fprintf(w, "🧹 %s %d cached files (%s):\n", verb, len(out), filesystem.FormatBytes(total))
+				for _, e := range out {
+					fmt.Fprintf(w, "  - %s (%s, last used %s)\n", e.SHA1, filesystem.FormatBytes(e.SizeBytes), e.LastUsed.Format("2006-01-02 15:04"))
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Size to shrink the cache to, e.g. 5GB (default download.cache_max_size)")
+	return cmd
+}
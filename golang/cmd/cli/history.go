@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/spf13/cobra"
+)
+
+// historyOutput is the stable JSON shape of one entry printed by `history --output json`
+type historyOutput struct {
+	Timestamp    time.Time `json:"timestamp"`
+	ModID        int       `json:"mod_id"`
+	FromFileID   int       `json:"from_file_id"`
+	FromVersion  string    `json:"from_version"`
+	ToFileID     int       `json:"to_file_id"`
+	ToVersion    string    `json:"to_version"`
+	Result       string    `json:"result"`
+	DurationSecs float64   `json:"duration_seconds"`
+	Backup       string    `json:"backup"`
+	Error        string    `json:"error"`
+
+	Mods []history.ModChange `json:"mods"`
+}
+
+func historyCmd(cfg *config.Config) *cobra.Command {
+	var filter history.Filter
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show past update attempts.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if filter.Result != "" && !history.ValidResult(filter.Result) {
+				return fmt.Errorf("invalid --result %q (supported: success, failed, skipped)", filter.Result)
+			}
+
+			entries, err := history.NewLog(filepath.Join(cfg.DataDir, history.FileName)).List(filter)
+			if err != nil {
+				return err
+			}
+
+			out := []historyOutput{}
+			for _, e := range entries {
+				out = append(out, historyOutput{
+					Timestamp:    e.Timestamp,
+					ModID:        e.ModID,
+					FromFileID:   e.FromFileID,
+					FromVersion:  e.FromVersion,
+					ToFileID:     e.ToFileID,
+					ToVersion:    e.ToVersion,
+					Result:       e.Result,
+					DurationSecs: e.Duration.Round(time.Millisecond).Seconds(),
+					Backup:       e.Backup,
+					Error:        e.Error,
+					Mods:         append([]history.ModChange{}, e.Mods...),
+				})
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if len(out) == 0 {
+					fmt.Fprintln(w, "No update attempts recorded.")
+					return nil
+				}
+				if format == outputTable {
+					tw := newTable(w)
+					fmt.Fprintln(tw, "TIME\tRESULT\tFROM\tTO\tDURATION\tBACKUP")
+					for _, e := range out {
+						backup := e.Backup
+						if backup == "" {
+							backup = "-"
+						}
+						fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.1fs\t%s\n", e.Timestamp.Format("2006-01-02 15:04:05"), e.Result, orNone(e.FromVersion), e.ToVersion, e.DurationSecs, backup)
+					}
+					return tw.Flush()
+				}
+				for _, e := range out {
+					icon := "✅"
+					switch e.Result {
+					case history.ResultFailed:
+						icon = "❌"
+					case history.ResultSkipped:
+						icon = "⏭️ "
+					}
+					fmt.Fprintf(w, "%s %s %s -> %s (%.1fs)\n", icon, e.Timestamp.Format("2006-01-02 15:04:05"), orNone(e.FromVersion), e.ToVersion, e.DurationSecs)
+					if e.Error != "" {
+						fmt.Fprintf(w, "   error: %s\n", e.Error)
+					}
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&filter.Result, "result", "", "Only show attempts with this result: success, failed, skipped")
+	cmd.Flags().IntVarP(&filter.Limit, "limit", "n", 0, "Show at most this many entries (0 for all)")
+	return cmd
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/version"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// modInfoOutput is the stable JSON shape printed by `info --output json`
+type modInfoOutput struct {
+	ID            int                   `json:"id"`
+	Name          string                `json:"name"`
+	Slug          string                `json:"slug"`
+	Summary       string                `json:"summary"`
+	Description   string                `json:"description,omitempty"`
+	WebsiteURL    string                `json:"website_url,omitempty"`
+	Authors       []string              `json:"authors"`
+	Categories    []string              `json:"categories"`
+	DownloadCount int64                 `json:"download_count"`
+	DateModified  time.Time             `json:"date_modified"`
+	MainFileID    int                   `json:"main_file_id"`
+	LatestFiles   []latestFileOutput    `json:"latest_files"`
+	GameVersions  map[string][]fileStub `json:"game_versions"`
+}
+
+// latestFileOutput describes one entry of ModInfo.LatestFiles
+type latestFileOutput struct {
+	ID          int       `json:"id"`
+	DisplayName string    `json:"display_name"`
+	FileName    string    `json:"file_name"`
+	ReleaseType string    `json:"release_type"`
+	FileDate    time.Time `json:"file_date"`
+}
+
+// fileStub describes one entry of ModInfo.LatestFilesIndexes
+type fileStub struct {
+	FileID      int    `json:"file_id"`
+	FileName    string `json:"file_name"`
+	ReleaseType string `json:"release_type"`
+	ModLoader   string `json:"mod_loader"`
+}
+
+func infoCmd(cfg *config.Config) *cobra.Command {
+	var jsonOutput, description bool
+
+	cmd := &cobra.Command{
+		Use:   "info [modID]",
+		Short: "Show detailed information about a mod.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			modID := cfg.ModpackID
+			if len(args) > 0 {
+				id, err := strconv.Atoi(args[0])
+				if err != nil {
+					return fmt.Errorf("invalid mod ID %q: %w", args[0], err)
+				}
+				modID = id
+			}
+			if !cfg.HasAPIAccess() || modID == 0 {
+				return fmt.Errorf("missing config: api_key and modpack_id are required. Hint: run `init` to scaffold one")
+			}
+
+			client := api.NewClientFromConfig(cfg)
+			mod, err := client.GetMod(modID)
+			if err != nil {
+				return fmt.Errorf("failed to get mod info: %w", err)
+			}
+
+			if jsonOutput {
+				outputFormat = outputJSON
+			}
+
+			out := buildModInfoOutput(mod)
+			if description {
+				if out.Description, err = client.GetModDescription(modID); err != nil {
+					return fmt.Errorf("failed to get mod description: %w", err)
+				}
+			}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				printModInfo(w, out)
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print mod information as JSON (shorthand for --output json)")
+	cmd.Flags().BoolVar(&description, "description", false, "Include the full description from the mod's CurseForge page")
+	return cmd
+}
+
+// buildModInfoOutput converts the API model into the CLI output model
+func buildModInfoOutput(mod *api.ModInfo) modInfoOutput {
+	out := modInfoOutput{
+		ID:            mod.ID,
+		Name:          mod.Name,
+		Slug:          mod.Slug,
+		Summary:       mod.Summary,
+		WebsiteURL:    mod.Links.WebsiteURL,
+		Authors:       []string{},
+		Categories:    []string{},
+		DownloadCount: mod.DownloadCount,
+		DateModified:  mod.DateModified,
+		MainFileID:    mod.MainFileID,
+		LatestFiles:   []latestFileOutput{},
+		GameVersions:  make(map[string][]fileStub),
+	}
+
+	for _, author := range mod.Authors {
+		out.Authors = append(out.Authors, author.Name)
+	}
+	for _, category := range mod.Categories {
+		out.Categories = append(out.Categories, category.Name)
+	}
+	for _, file := range mod.LatestFiles {
+		out.LatestFiles = append(out.LatestFiles, latestFileOutput{
+			ID:          file.ID,
+			DisplayName: file.DisplayName,
+			FileName:    file.FileName,
+			ReleaseType: api.ReleaseTypeName(file.ReleaseType),
+			FileDate:    file.FileDate,
+		})
+	}
+	for _, index := range mod.LatestFilesIndexes {
+		out.GameVersions[index.GameVersion] = append(out.GameVersions[index.GameVersion], fileStub{
+			FileID:      index.FileID,
+			FileName:    index.Filename,
+			ReleaseType: api.ReleaseTypeName(index.ReleaseType),
+			ModLoader:   api.ModLoaderName(index.ModLoader),
+		})
+	}
+
+	return out
+}
+
+// printModInfo prints mod information in a human-readable layout
+func printModInfo(w io.Writer, out modInfoOutput) {
+	fmt.Fprintf(w, "📦 %s (ID %d, %s)\n", out.Name, out.ID, out.Slug)
+	if out.Summary != "" {
+		fmt.Fprintf(w, "   %s\n", out.Summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Authors:       %s\n", joinOrDash(out.Authors))
+	fmt.Fprintf(w, "Categories:    %s\n", joinOrDash(out.Categories))
+	fmt.Fprintf(w, "Downloads:     %d\n", out.DownloadCount)
+	fmt.Fprintf(w, "Last modified: %s\n", out.DateModified.Format("2006-01-02 15:04:05"))
+	if out.WebsiteURL != "" {
+		fmt.Fprintf(w, "Website:       %s\n", out.WebsiteURL)
+	}
+
+	if out.Description != "" {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, out.Description)
+	}
+
+	if len(out.LatestFiles) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Latest files:")
+		for _, file := range out.LatestFiles {
+			marker := " "
+			if file.ID == out.MainFileID {
+				marker = "*"
+			}
+			fmt.Fprintf(w, " %s %-10d %-8s %s (%s)\n", marker, file.ID, file.ReleaseType, file.DisplayName, file.FileDate.Format("2006-01-02"))
+		}
+	}
+
+	if len(out.GameVersions) > 0 {
+		versions := make([]string, 0, len(out.GameVersions))
+		for gameVersion := range out.GameVersions {
+			versions = append(versions, gameVersion)
+		}
+		// Newest first, numerically, so that 1.20.10 comes before 1.20.9
+		sort.Slice(versions, func(i, j int) bool {
+			if cmp, ok := version.CompareNames(versions[i], versions[j], ""); ok && cmp != 0 {
+				return cmp > 0
+			}
+			return versions[i] > versions[j]
+		})
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Latest files per game version:")
+		for _, gameVersion := range versions {
+			for _, stub := range out.GameVersions[gameVersion] {
+				fmt.Fprintf(w, "   %-10s %-9s %-8s %-10d %s\n", gameVersion, stub.ModLoader, stub.ReleaseType, stub.FileID, stub.FileName)
+			}
+		}
+	}
+}
+
+// joinOrDash joins values with commas, or returns "-" when empty
+func joinOrDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ", ")
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
 	"github.com/spf13/cobra"
@@ -24,13 +26,8 @@ func initCmd() *cobra.Command {
 				if filesystem.FileExists(filename) {
 					return fmt.Errorf("%s already exists", filename)
 				}
-				templateName := "template." + format
-				contentBytes, err := embeddedTemplates.ReadFile(templateName)
-				if err != nil {
-					return fmt.Errorf("failed to read embedded template: %w", err)
-				}
-				if err := os.WriteFile(filename, contentBytes, 0600); err != nil {
-					return fmt.Errorf("failed to write %s: %w", filename, err)
+				if err := writeConfigTemplate(filename); err != nil {
+					return err
 				}
 				fmt.Fprintf(cmd.OutOrStdout(), "✅ %s created.\n", filename)
 				return nil
@@ -42,3 +39,26 @@ func initCmd() *cobra.Command {
 		},
 	}
 }
+
+// writeConfigTemplate writes the embedded template matching the extension of path
+func writeConfigTemplate(path string) error {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	switch ext {
+	case "", "toml":
+		ext = "toml"
+	case "yml", "yaml":
+		ext = "yaml"
+	case "json":
+	default:
+		return fmt.Errorf("unsupported config format: %s (supported: toml, yaml, yml, json)", ext)
+	}
+
+	contentBytes, err := embeddedTemplates.ReadFile("template." + ext)
+	if err != nil {
+		return fmt.Errorf("failed to read embedded template: %w", err)
+	}
+	if err := os.WriteFile(path, contentBytes, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/jobs"
+	"github.com/spf13/cobra"
+)
+
+// jobOutput is the stable JSON shape of one job printed by `jobs --output json`
+type jobOutput struct {
+	ID       string          `json:"id"`
+	Kind     string          `json:"kind"`
+	Target   string          `json:"target"`
+	Actor    string          `json:"actor"`
+	State    string          `json:"state"`
+	Phase    string          `json:"phase"`
+	Progress int             `json:"progress"`
+	Created  time.Time       `json:"created"`
+	Started  *time.Time      `json:"started"`
+	Finished *time.Time      `json:"finished"`
+	Error    string          `json:"error"`
+	Result   json.RawMessage `json:"result"`
+	Log      []string        `json:"log"`
+}
+
+func newJobOutput(j jobs.Job) jobOutput {
+	out := jobOutput{
+		ID:       j.ID,
+		Kind:     j.Kind,
+		Target:   j.Target,
+		Actor:    j.Actor,
+		State:    j.State,
+		Phase:    j.Phase,
+		Progress: j.Progress,
+		Created:  j.Created,
+		Started:  j.Started,
+		Finished: j.Finished,
+		Error:    j.Error,
+		Result:   j.Result,
+		Log:      append([]string{}, j.Log...),
+	}
+	if out.Result == nil {
+		out.Result = json.RawMessage("null")
+	}
+	return out
+}
+
+func jobsCmd(cfg *config.Config) *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "jobs [id]",
+		Short: "Show the updates, backups and restores queued from the web UI and the API.",
+		Long: `List the jobs the web UI ran in the background, newest first, or show one job
+with its log. The ID may be shortened to its first characters. Jobs that were
+queued or running when the web UI stopped are shown as failed.
+
+Jobs are started and canceled through POST /api/v1/jobs and
+POST /api/v1/jobs/<id>/cancel.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, err := jobs.Load(filepath.Join(cfg.DataDir, jobs.FileName))
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				job, err := jobs.Find(all, args[0])
+				if err != nil {
+					return err
+				}
+				out := newJobOutput(job)
+				return render(cmd, out, func(w io.Writer, format string) error {
+					fmt.Fprintf(w, "%s %s %s\n", jobIcon(out.State), out.Kind, out.ID)
+					if out.Target != "" {
+						fmt.Fprintf(w, "   target:   %s\n", out.Target)
+					}
+					fmt.Fprintf(w, "   state:    %s\n", out.State)
+					if out.Phase != "" {
+						fmt.Fprintf(w, "   progress: %s %d%%\n", out.Phase, out.Progress)
+					}
+					fmt.Fprintf(w, "   by:       %s\n", orNone(out.Actor))
+					fmt.Fprintf(w, "   created:  %s\n", out.Created.Format("2006-01-02 15:04:05"))
+					if out.Finished != nil {
+						fmt.Fprintf(w, "   finished: %s\n", out.Finished.Format("2006-01-02 15:04:05"))
+					}
+					if out.Error != "" {
+						fmt.Fprintf(w, "   error:    %s\n", out.Error)
+					}
+					for _, line := range out.Log {
+						fmt.Fprintf(w, "   | %s\n", line)
+					}
+					return nil
+				})
+			}
+
+			if limit > 0 && len(all) > limit {
+				all = all[:limit]
+			}
+			out := []jobOutput{}
+			for _, j := range all {
+				out = append(out, newJobOutput(j))
+			}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if len(out) == 0 {
+					fmt.Fprintln(w, "No jobs recorded.")
+					return nil
+				}
+				if format == outputTable {
+					tw := newTable(w)
+					fmt.Fprintln(tw, "ID\tKIND\tTARGET\tSTATE\tPROGRESS\tCREATED")
+					for _, j := range out {
+						target := j.Target
+						if target == "" {
+							target = "-"
+						}
+						fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d%%\t%s\n", j.ID, j.Kind, target, j.State, j.Progress, j.Created.Format("2006-01-02 15:04:05"))
+					}
+					return tw.Flush()
+				}
+				for _, j := range out {
+					fmt.Fprintf(w, "%s %s %s %s", jobIcon(j.State), j.Created.Format("2006-01-02 15:04:05"), j.Kind, j.ID)
+					if j.State == jobs.StateRunning {
+						fmt.Fprintf(w, " (%d%%)", j.Progress)
+					}
+					fmt.Fprintln(w)
+					if j.Error != "" {
+						fmt.Fprintf(w, "   error: %s\n", j.Error)
+					}
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "Show at most this many jobs (0 for all)")
+	return cmd
+}
+
+// jobIcon marks the state of a job in text output
+func jobIcon(state string) string {
+	switch state {
+	case jobs.StateQueued:
+		return "⏳"
+	case jobs.StateRunning:
+		return "🔄"
+	case jobs.StateSucceeded:
+		return "✅"
+	case jobs.StateCanceled:
+		return "🚫"
+	}
+	return "❌"
+}
@@ -2,16 +2,57 @@ package main
 
 import (
 	"fmt"
+	"io"
 
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
 	"github.com/spf13/cobra"
 )
 
-func listCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "list",
-		Short: "List available commands/info.",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Fprintln(cmd.OutOrStdout(), "[list] List not yet implemented.")
+// commandOutput is the stable JSON shape of one entry printed by `list --output json`
+type commandOutput struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases"`
+	Description string   `json:"description"`
+}
+
+func listCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "list",
+		Short:       "List available commands/info.",
+		Annotations: map[string]string{"skipConfig": "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := []commandOutput{}
+			for _, c := range cmd.Root().Commands() {
+				if c.Hidden || c.Name() == "help" || c.Name() == "completion" {
+					continue
+				}
+				aliases := c.Aliases
+				if aliases == nil {
+					aliases = []string{}
+				}
+				out = append(out, commandOutput{
+					Name:        c.Name(),
+					Aliases:     aliases,
+					Description: c.Short,
+				})
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if format == outputTable {
+					tw := newTable(w)
+					fmt.Fprintln(tw, "COMMAND\tDESCRIPTION")
+					for _, c := range out {
+						fmt.Fprintf(tw, "%s\t%s\n", c.Name, c.Description)
+					}
+					return tw.Flush()
+				}
+				for _, c := range out {
+					fmt.Fprintf(w, "%-10s %s\n", c.Name, c.Description)
+				}
+				return nil
+			})
 		},
 	}
+	cmd.AddCommand(listServersCmd(cfg))
+	return cmd
 }
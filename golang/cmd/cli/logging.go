@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/logging"
+)
+
+var (
+	// logOutput redacts secrets from the loaded config in log and error output
+	logOutput = logging.NewRedactWriter(os.Stderr)
+
+	// baseLogger is the configured logger without per-run fields
+	baseLogger = slog.New(slog.NewTextHandler(logOutput, nil))
+
+	// logFile is the open log_file, if any
+	logFile *os.File
+)
+
+// setupLogger applies log_level, log_format and log_file from cfg and installs a
+// default logger tagged with a fresh run ID and the configured mod ID
+func setupLogger(cfg *config.Config) error {
+	if quietMode {
+		baseLogger = logging.Discard()
+		slog.SetDefault(baseLogger)
+		return nil
+	}
+
+	out := io.Writer(os.Stderr)
+	if cfg.LogFile != "" {
+		f, err := logging.OpenFile(cfg.LogFile)
+		if err != nil {
+			return err
+		}
+		logFile = f
+		out = io.MultiWriter(os.Stderr, f)
+	}
+	logOutput.SetOutput(out)
+	logOutput.SetSecrets(cfg.Secrets()...)
+
+	level := cfg.LogLevel
+	if verboseMode {
+		level = "debug"
+	}
+	logger, err := logging.New(logOutput, logging.Options{Level: level, Format: cfg.LogFormat})
+	if err != nil {
+		return err
+	}
+	baseLogger = logger
+	slog.SetDefault(logging.WithRun(logger, logging.NewRunID(), cfg.ModpackID))
+	return nil
+}
+
+// closeLogFile closes log_file before the process exits
+func closeLogFile() {
+	if logFile != nil {
+		_ = logFile.Close()
+	}
+}
@@ -1,150 +1,200 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 
-	"github.com/damianko135/curseforge-autoupdate/golang/helper/env"
-	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/logging"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/service"
 	"github.com/damianko135/curseforge-autoupdate/golang/templates"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var (
 	embeddedTemplates = templates.EmbeddedTemplates
 	verboseMode       bool
+	quietMode         bool
+	noCache           bool
+
+	// dryRun holds the value of the global --dry-run flag
+	dryRun bool
+)
+
+// annotationDryRun marks commands that honour --dry-run
+const annotationDryRun = "dryRun"
+
+// Exit codes returned by the CLI
+const (
+	exitOK              = 0
+	exitError           = 1
+	exitUpdateAvailable = 10
 )
 
-type Config struct {
-	APIToken string `mapstructure:"api_key"`
-	ModID    int    `mapstructure:"mod_id"`
+// exitCodeError makes a command exit with a specific code without printing an error
+type exitCodeError struct {
+	code int
 }
 
-// getConfigValue tries config, then env var, then default
-func getConfigValue(key, defaultVal string) string {
-	if val := viper.GetString(key); val != "" {
-		return val
-	}
-	if envVal := os.Getenv(key); envVal != "" {
-		return envVal
-	}
-	return defaultVal
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
 }
 
 func main() {
 	var (
 		configFilePath     string
 		initTemplateFormat string
-		userConfig         Config
+		userConfig         config.Config
 	)
 
 	rootCmd, err := setupRootCommand(&userConfig, &configFilePath, &initTemplateFormat)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error setting up root command: %v\n", err)
-		return
+		os.Exit(exitError)
 	}
 
 	// Let Cobra handle all CLI parsing, config, and command dispatching
 	// All logic for --init, --config, --verbose, --version, etc. is now handled by the registered commands and PersistentPreRunE
 	// This makes the CLI idiomatic and ensures all subcommands in cmd/cli are used
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return
+	cmd, err := rootCmd.ExecuteC()
+	recordAudit(cmd, err)
+	closeLogFile()
+	if err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+		if !quietMode {
+			fmt.Fprintf(logOutput, "Error: %v\n", err)
+		}
+		os.Exit(exitError)
 	}
 }
 
 // newRootCmd sets up the root command and all subcommands
-func setupRootCommand(cfg *Config, configPath *string, initFormat *string) (*cobra.Command, error) {
+func setupRootCommand(cfg *config.Config, configPath *string, initFormat *string) (*cobra.Command, error) {
 	rootCmd := &cobra.Command{
 		Use:   "curseforge-autoupdater",
 		Short: "A CLI tool to interact with CurseForge mods and configs.",
+		// Errors are printed by main so exit codes can be controlled
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 
 	rootCmd.PersistentFlags().StringVar(configPath, "config", "config.toml", "Path to config file")
 	rootCmd.PersistentFlags().StringVar(initFormat, "init", "", "Initialize a new project with configuration templates (e.g. --init toml)")
 	rootCmd.PersistentFlags().BoolVarP(&verboseMode, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputPlain, "Output format: plain, table, json")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Log what update, backup, restore, rollback and prune would do without changing anything")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Bypass the API response cache")
+	rootCmd.PersistentFlags().StringVar(&serverName, "server", "", "Use the [[servers]] entry with this name, or all of them with \"all\"")
+
+	// Config overrides; these take precedence over the environment and the config file
+	rootCmd.PersistentFlags().Int("modpack-id", 0, "Override modpack_id")
+	rootCmd.PersistentFlags().String("game-version", "", "Override game_version")
+	rootCmd.PersistentFlags().String("update-channel", "", "Override update_channel (stable, beta, alpha)")
+	rootCmd.PersistentFlags().String("server-path", "", "Override server_path")
+	rootCmd.PersistentFlags().String("backup-path", "", "Override backup_path")
+	rootCmd.PersistentFlags().String("download-path", "", "Override download_path")
+	rootCmd.PersistentFlags().String("data-dir", "", "Override data_dir")
 
 	// Register only essential top-level commands
 	rootCmd.AddCommand(
 		checkCmd(cfg),
-		updateCmd(),
-		backupCmd(),
-		restoreCmd(),
+		channelCmd(cfg),
+		infoCmd(cfg),
+		statusCmd(cfg),
+		updateCmd(cfg),
+		approvalCmd(cfg),
+		diffCmd(cfg),
+		compareCmd(cfg),
+		modsCmd(cfg),
+		serverJarCmd(cfg),
+		pluginsCmd(cfg),
+		serverCmd(cfg),
+		rollbackCmd(cfg),
+		historyCmd(cfg),
+		jobsCmd(cfg),
+		auditCmd(),
+		daemonCmd(cfg),
+		serviceCmd(cfg),
+		backupCmd(cfg),
+		downloadsCmd(cfg),
+		restoreCmd(cfg),
 		notifyCmd(),
-		listCmd(),
+		authCmd(cfg),
+		configCmd(),
+		listCmd(cfg),
 		versionCmd(),
 		initCmd(),
+		exportStateCmd(),
+		importStateCmd(),
 	)
 
 	// Only load config for commands that need it
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		slog.SetDefault(baseLogger)
+		if err := validateOutputFormat(outputFormat); err != nil {
+			return err
+		}
+		if quietMode {
+			slog.SetDefault(logging.Discard())
+			cmd.SetOut(io.Discard)
+		}
+		if dryRun && cmd.Annotations[annotationDryRun] != "true" {
+			return fmt.Errorf("%s does not support --dry-run", cmd.CommandPath())
+		}
 		if cmd.Annotations["skipConfig"] == "true" {
 			return nil
 		}
 		if *configPath == "" {
 			*configPath = "config.toml"
 		}
-		if err := env.LoadConfig(*configPath); err != nil {
-			if os.IsNotExist(err) {
-				fmt.Printf("Config file '%s' not found. Would you like to create one? [Y/n]: ", *configPath)
-				var resp string
-				if _, err := fmt.Scanln(&resp); err != nil && err.Error() != "unexpected newline" {
-					return fmt.Errorf("failed to read input: %w", err)
-				}
-				if resp == "" || resp == "y" || resp == "Y" {
-					if err := env.WriteTOMLTemplate(*configPath); err != nil {
-						return fmt.Errorf("failed to create config: %w", err)
-					}
-					fmt.Printf("Created %s. Please edit it and re-run.\n", *configPath)
-					return nil
-				} else {
-					return fmt.Errorf("config file required: %s (user declined to create)", *configPath)
-				}
+		// Windows starts services in the system directory; relative paths in the config
+		// are relative to the config file instead
+		if service.Managed() {
+			if err := os.Chdir(filepath.Dir(*configPath)); err != nil {
+				return fmt.Errorf("failed to change to the config directory: %w", err)
+			}
+			*configPath = filepath.Base(*configPath)
+		}
+		loaded, err := config.Load(config.Options{Path: *configPath, Flags: cmd.Flags()})
+		if errors.Is(err, config.ErrNotFound) {
+			fmt.Printf("Config file '%s' not found. Would you like to create one? [Y/n]: ", *configPath)
+			var resp string
+			if _, err := fmt.Scanln(&resp); err != nil && err.Error() != "unexpected newline" {
+				return fmt.Errorf("failed to read input: %w", err)
+			}
+			if resp != "" && resp != "y" && resp != "Y" {
+				return fmt.Errorf("config file required: %s (user declined to create)", *configPath)
 			}
+			if err := writeConfigTemplate(*configPath); err != nil {
+				return fmt.Errorf("failed to create config: %w", err)
+			}
+			fmt.Printf("Created %s. Please edit it and re-run.\n", *configPath)
+			return &exitCodeError{code: exitOK}
+		}
+		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
-		if err := viper.Unmarshal(cfg); err != nil {
-			return fmt.Errorf("failed to read values: %w", err)
+		if noCache {
+			loaded.Cache.TTL = 0
 		}
-		if cfg.APIToken == "" {
-			cfg.APIToken = getConfigValue("API_KEY", "")
+		if err := setupLogger(loaded); err != nil {
+			return fmt.Errorf("failed to set up logging: %w", err)
 		}
-		if cfg.ModID == 0 {
-			modIDStr := getConfigValue("MOD_ID", "0")
-			if _, err := fmt.Sscanf(modIDStr, "%d", &cfg.ModID); err != nil {
-				return fmt.Errorf("failed to parse MOD_ID: %w", err)
-			}
+		slog.Info("config loaded", "file", loaded.File)
+		for _, msg := range loaded.Deprecations {
+			slog.Warn(msg)
 		}
-		return nil
+		*cfg = *loaded
+		loadedConfig = loaded
+		return selectServer(cmd, cfg)
 	}
 	return rootCmd, nil
 }
-
-func checkCmd(cfg *Config) *cobra.Command {
-	return &cobra.Command{
-		Use:     "check",
-		Aliases: []string{"verify"},
-		Short:   "Check if a mod exists using config/env variables.",
-		Run: func(cmd *cobra.Command, args []string) {
-			if cfg.APIToken == "" || cfg.ModID == 0 {
-				fmt.Fprintf(os.Stderr, "Missing config: api_key='%s', mod_id='%d'. Hint: run `init` to scaffold one.\n", cfg.APIToken, cfg.ModID)
-				return
-			}
-
-			client := api.NewClient(cfg.APIToken)
-			exists, err := client.CheckIfExists(cfg.ModID)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error checking mod: %v\n", err)
-				return
-			}
-
-			if exists {
-				fmt.Printf("✅ Mod with ID %d found.\n", cfg.ModID)
-			} else {
-				fmt.Printf("❌ Mod with ID %d not found.\n", cfg.ModID)
-			}
-		},
-	}
-}
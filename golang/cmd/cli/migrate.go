@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/migrate"
+	"github.com/spf13/cobra"
+)
+
+// exportStateOutput is the stable JSON shape printed by `export-state --output json`
+type exportStateOutput struct {
+	Path      string   `json:"path"`
+	SizeBytes int64    `json:"size_bytes"`
+	Secrets   bool     `json:"secrets"`
+	Servers   []string `json:"servers"`
+	Files     []string `json:"files"`
+}
+
+// importStateOutput is the stable JSON shape printed by `import-state --output json`
+type importStateOutput struct {
+	Config        string   `json:"config"`
+	ConfigWritten bool     `json:"config_written"`
+	Restored      []string `json:"restored"`
+	Skipped       []string `json:"skipped"`
+}
+
+func exportStateCmd() *cobra.Command {
+	var secrets bool
+	cmd := &cobra.Command{
+		Use:   "export-state [file]",
+		Short: "Bundle the config and tracking data into one archive for a new host.",
+		Long: `Write the config, the state store, update history, preserved pack files and
+download metadata of every server, and the audit log, into one zip archive that
+import-state restores on another host. The default file name carries the current
+time.
+
+API keys, tokens and passwords are left out of the bundled config unless
+--secrets is given. Secrets read from a *_file setting are never bundled, only
+the path to the file. Backups, downloads and the server itself are not included.`,
+		Args:        cobra.MaximumNArgs(1),
+		Annotations: map[string]string{annotationAudit: "state.export"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "state-" + time.Now().Format("20060102-150405") + ".zip"
+			if len(args) > 0 {
+				path = args[0]
+			}
+			manifest, size, err := exportState(loadedConfig, path, secrets)
+			if err != nil {
+				return err
+			}
+
+			out := exportStateOutput{Path: path, SizeBytes: size, Secrets: secrets, Servers: manifest.Servers, Files: manifest.Files}
+			if out.Servers == nil {
+				out.Servers = []string{}
+			}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				fmt.Fprintf(w, "📦 Exported %d files to %s (%s)\n", len(out.Files), out.Path, filesystem.FormatBytes(out.SizeBytes))
+				if !out.Secrets {
+					fmt.Fprintln(w, "   Secrets were left out of the config; set them again after importing")
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&secrets, "secrets", false, "Keep API keys, tokens and passwords in the bundled config")
+	return cmd
+}
+
+// exportState writes a bundle of cfg to path and returns its manifest and size. The bundle
+// is written next to path first so that a failed export leaves nothing behind.
+func exportState(cfg *config.Config, path string, secrets bool) (*migrate.Manifest, int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	manifest, err := migrate.Export(cfg, tmp, migrate.Options{Secrets: secrets})
+	if err != nil {
+		_ = tmp.Close()
+		return nil, 0, fmt.Errorf("failed to export state: %w", err)
+	}
+	info, err := tmp.Stat()
+	if err == nil {
+		err = tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return manifest, info.Size(), nil
+}
+
+func importStateCmd() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "import-state <file>",
+		Short: "Restore the config and tracking data bundled by export-state.",
+		Long: `Restore a bundle written by export-state. The bundled config is written to
+--config when that file does not exist yet, or with --force; otherwise the
+config already there is kept. The state, history, pack files and download
+metadata then go to the data_dir and download_path of that config, so paths
+may differ between the hosts. Servers are matched by their [[servers]] name;
+data of servers the config does not list is skipped.
+
+Nothing is written when it would overwrite existing data, unless --force is
+given. Config overrides such as --data-dir apply to where the data goes.`,
+		Args: cobra.ExactArgs(1),
+		// The config may only exist once the bundle is unpacked
+		Annotations: map[string]string{"skipConfig": "true", annotationAudit: "state.import"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, err := cmd.Flags().GetString("config")
+			if err != nil {
+				return err
+			}
+			bundle, err := migrate.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer bundle.Close()
+
+			out := importStateOutput{Config: configPath, Restored: []string{}, Skipped: []string{}}
+			if _, err := os.Stat(configPath); force || errors.Is(err, fs.ErrNotExist) {
+				if err := bundle.WriteConfig(configPath); err != nil {
+					return err
+				}
+				out.ConfigWritten = true
+			}
+			cfg, err := config.Load(config.Options{Path: configPath, Flags: cmd.Flags()})
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			// Record the import in the audit log it may just have restored
+			loadedConfig = cfg
+
+			result, err := bundle.Restore(cfg, force)
+			if errors.Is(err, migrate.ErrExists) {
+				return fmt.Errorf("%w; pass --force to overwrite it", err)
+			}
+			if err != nil {
+				return err
+			}
+			out.Restored = append(out.Restored, result.Restored...)
+			out.Skipped = append(out.Skipped, result.Skipped...)
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if out.ConfigWritten {
+					fmt.Fprintf(w, "📝 Wrote %s\n", out.Config)
+				} else {
+					fmt.Fprintf(w, "📝 Kept the existing %s\n", out.Config)
+				}
+				fmt.Fprintf(w, "✅ Restored %d files\n", len(out.Restored))
+				for _, name := range out.Skipped {
+					fmt.Fprintf(w, "  - skipped %s, no server in the config takes it\n", name)
+				}
+				if !bundle.Manifest.Secrets && out.ConfigWritten {
+					fmt.Fprintln(w, "   The bundle has no secrets; set api_key and any tokens before running updates")
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite the config and existing data")
+	return cmd
+}
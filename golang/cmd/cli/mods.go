@@ -0,0 +1,451 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/checksums"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// modStatusOutput is the stable JSON shape of one entry printed by `mods check|update --output json`
+type modStatusOutput struct {
+	Key              string `json:"key"`
+	Provider         string `json:"provider"`
+	Project          string `json:"project"`
+	Name             string `json:"name"`
+	InstalledVersion string `json:"installed_version"`
+	LatestVersion    string `json:"latest_version"`
+	UpdateAvailable  bool   `json:"update_available"`
+	Pinned           bool   `json:"pinned"`
+	Error            string `json:"error,omitempty"`
+
+	HeldBack []heldOutput `json:"held_back"`
+}
+
+// modsVerifyOutput is the stable JSON shape printed by `mods verify --output json`
+type modsVerifyOutput struct {
+	Dir      string   `json:"dir"`
+	Manifest string   `json:"manifest"`
+	Clean    bool     `json:"clean"`
+	Missing  []string `json:"missing"`
+	Extra    []string `json:"extra"`
+	Changed  []string `json:"changed"`
+}
+
+// modsSkewOutput is the stable JSON shape printed by `mods skew --output json`
+type modsSkewOutput struct {
+	Dir        string                `json:"dir"`
+	ClientList string                `json:"client_list"`
+	Compatible bool                  `json:"compatible"`
+	Missing    []checksums.SkewEntry `json:"missing"`
+	Mismatched []checksums.SkewEntry `json:"mismatched"`
+	Extra      []checksums.SkewEntry `json:"extra"`
+}
+
+func modsCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mods",
+		Short: "Check and update the tracked mods from CurseForge and Modrinth.",
+	}
+	cmd.AddCommand(
+		modsCheckCmd(cfg),
+		&cobra.Command{
+			Use:         "update",
+			Short:       "Install the latest version of every tracked mod into server_path/mods.",
+			Annotations: map[string]string{annotationAudit: "mods.update"},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				statuses, err := updater.NewModUpdater(cfg, slog.Default()).Update()
+				if err != nil {
+					return err
+				}
+				if err := renderModStatuses(cmd, newModStatusOutputs(statuses)); err != nil {
+					return err
+				}
+				for _, s := range statuses {
+					if s.Err != nil {
+						return fmt.Errorf("failed to update %s: %w", s.Mod.Key(), s.Err)
+					}
+				}
+				return nil
+			},
+		},
+		modsExportCmd(cfg),
+		modsVerifyCmd(cfg),
+		modsSkewCmd(cfg),
+	)
+	return cmd
+}
+
+func modsCheckCmd(cfg *config.Config) *cobra.Command {
+	var ci bool
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Show the installed and latest version of every tracked mod.",
+		Long: `Show the installed and latest version of every [[mods]] entry.
+
+Exits with 10 when any mod has an update, like check.
+
+With --ci every update is also shown as a GitHub Actions annotation, and
+update_available, updates (their number) and mods (a JSON array of the mods
+with an update) are set as step outputs.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateCI(ci); err != nil {
+				return err
+			}
+			statuses, err := updater.NewModUpdater(cfg, slog.Default()).Check()
+			if err != nil {
+				if ci {
+					ciAnnotate(cmd.OutOrStdout(), cfg, ciError, "Mod check failed", err.Error())
+				}
+				return err
+			}
+			out := newModStatusOutputs(statuses)
+			if err := renderModStatuses(cmd, out); err != nil {
+				return err
+			}
+			if ci {
+				if err := reportModsToCI(cmd.OutOrStdout(), cfg, out); err != nil {
+					return err
+				}
+			}
+			for _, s := range statuses {
+				if s.Err != nil {
+					return fmt.Errorf("failed to check %s: %w", s.Mod.Key(), s.Err)
+				}
+			}
+			for _, s := range statuses {
+				if s.UpdateAvailable {
+					return &exitCodeError{code: exitUpdateAvailable}
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&ci, "ci", false, "Report the result as GitHub Actions annotations and step outputs")
+	return cmd
+}
+
+// reportModsToCI annotates the run with every mod update or failed check and sets the
+// step outputs of `mods check --ci`
+func reportModsToCI(w io.Writer, cfg *config.Config, out []modStatusOutput) error {
+	updates := []modStatusOutput{}
+	for _, o := range out {
+		switch {
+		case o.Error != "":
+			ciAnnotate(w, cfg, ciError, "Mod check failed", modLabel(o)+": "+o.Error)
+		case o.UpdateAvailable:
+			updates = append(updates, o)
+			ciAnnotate(w, cfg, ciNotice, "Mod update available", fmt.Sprintf("%s: %s -> %s", modLabel(o), orNone(o.InstalledVersion), o.LatestVersion))
+		}
+	}
+	mods, err := json.Marshal(updates)
+	if err != nil {
+		return err
+	}
+	return setCIOutputs(w, cfg, []ciOutput{
+		{"update_available", strconv.FormatBool(len(updates) > 0)},
+		{"updates", strconv.Itoa(len(updates))},
+		{"mods", string(mods)},
+	})
+}
+
+func modsExportCmd(cfg *config.Config) *cobra.Command {
+	var dir string
+	var noMatch bool
+	cmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Write a manifest of the files in the mods folder.",
+		Long: `Write a JSON manifest of every file below the mods folder with its size,
+SHA-1 and CurseForge fingerprint. Files whose fingerprint CurseForge knows are
+annotated with their project and file, unless --no-match is given or no API key
+is configured. The manifest is written to file, or to stdout without one or
+when it is "-".`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir == "" {
+				dir = filepath.Join(cfg.ServerPath, "mods")
+			}
+			manifest, err := checksums.Build(dir, time.Now())
+			if err != nil {
+				return err
+			}
+			if !noMatch && cfg.HasAPIAccess() {
+				if err := manifest.Match(api.NewClientFromConfig(cfg)); err != nil {
+					slog.Warn("exporting without CurseForge projects", "error", err)
+				}
+			}
+
+			if len(args) == 0 || args[0] == "-" {
+				return manifest.Write(cmd.OutOrStdout())
+			}
+			f, err := os.Create(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to create manifest: %w", err)
+			}
+			if err := manifest.Write(f); err != nil {
+				_ = f.Close()
+				return fmt.Errorf("failed to write manifest: %w", err)
+			}
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("failed to write manifest: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "✅ %d files from %s written to %s\n", len(manifest.Files), dir, args[0])
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "", "Folder to export (default server_path/mods)")
+	cmd.Flags().BoolVar(&noMatch, "no-match", false, "Do not look up the files on CurseForge")
+	return cmd
+}
+
+func modsVerifyCmd(cfg *config.Config) *cobra.Command {
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "verify <manifest>",
+		Short: "Compare the mods folder with a manifest written by mods export.",
+		Long: `Compare the files below the mods folder with a manifest written by
+mods export and list the files that are missing, extra or changed. Exits with 1
+when the folder does not match.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir == "" {
+				dir = filepath.Join(cfg.ServerPath, "mods")
+			}
+			manifest, err := checksums.Load(args[0])
+			if err != nil {
+				return err
+			}
+			drift, err := checksums.Verify(dir, manifest)
+			if err != nil {
+				return err
+			}
+
+			out := modsVerifyOutput{
+				Dir:      dir,
+				Manifest: args[0],
+				Clean:    drift.Clean(),
+				Missing:  drift.Missing,
+				Extra:    drift.Extra,
+				Changed:  drift.Changed,
+			}
+			if err := render(cmd, out, func(w io.Writer, format string) error {
+				if out.Clean {
+					fmt.Fprintf(w, "✅ %s matches %s\n", dir, args[0])
+					return nil
+				}
+				fmt.Fprintf(w, "❌ %s does not match %s\n", dir, args[0])
+				for _, group := range []struct {
+					mark  string
+					paths []string
+				}{{"-", out.Missing}, {"+", out.Extra}, {"~", out.Changed}} {
+					for _, path := range group.paths {
+						fmt.Fprintf(w, "  %s %s\n", group.mark, path)
+					}
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			if !out.Clean {
+				return &exitCodeError{code: exitError}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "", "Folder to verify (default server_path/mods)")
+	return cmd
+}
+
+func modsSkewCmd(cfg *config.Config) *cobra.Command {
+	var dir string
+	var noMatch bool
+	cmd := &cobra.Command{
+		Use:   "skew <client-list>",
+		Short: "Compare a player's mods with the server's and list what to fix.",
+		Long: `Compare the mods of a player's client with the jars in the mods folder and
+list the mods the client lacks or has in another version, which usually keep
+players from joining, as a fix list. Mods only the client has are listed too;
+they are usually client-side mods and do not fail the command.
+
+The client list is a manifest written by mods export on the client's mods
+folder, or a text file with one jar name per line, such as the output of
+ls mods. Mods are paired by CurseForge project when both sides know it, which
+needs an API key, and otherwise by their file name without the version.
+
+Exits with 1 when the client is missing a mod or has one in another version.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir == "" {
+				dir = filepath.Join(cfg.ServerPath, "mods")
+			}
+			clientList, err := checksums.LoadList(args[0])
+			if err != nil {
+				return err
+			}
+			server, err := checksums.Build(dir, time.Now())
+			if err != nil {
+				return err
+			}
+			if !noMatch && cfg.HasAPIAccess() {
+				client := api.NewClientFromConfig(cfg)
+				for _, m := range []*checksums.Manifest{server, clientList} {
+					// Plain lists have no fingerprints to match
+					if len(m.Files) > 0 && m.Files[0].Fingerprint != 0 {
+						if err := m.Match(client); err != nil {
+							slog.Warn("comparing without CurseForge projects", "error", err)
+						}
+					}
+				}
+			}
+
+			skew := checksums.Compare(server, clientList)
+			out := modsSkewOutput{
+				Dir:        dir,
+				ClientList: args[0],
+				Compatible: skew.Compatible(),
+				Missing:    skew.Missing,
+				Mismatched: skew.Mismatched,
+				Extra:      skew.Extra,
+			}
+			if err := render(cmd, out, func(w io.Writer, format string) error { return printSkew(w, format, out) }); err != nil {
+				return err
+			}
+			if !out.Compatible {
+				return &exitCodeError{code: exitError}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "", "Server mods folder to compare with (default server_path/mods)")
+	cmd.Flags().BoolVar(&noMatch, "no-match", false, "Do not look up the files on CurseForge")
+	return cmd
+}
+
+// printSkew writes the fix list of `mods skew` in the plain or table format
+func printSkew(w io.Writer, format string, out modsSkewOutput) error {
+	if format == outputTable {
+		tw := newTable(w)
+		fmt.Fprintln(tw, "MOD\tSTATUS\tSERVER\tCLIENT")
+		for _, group := range []struct {
+			status  string
+			entries []checksums.SkewEntry
+		}{{"missing", out.Missing}, {"mismatched", out.Mismatched}, {"client only", out.Extra}} {
+			for _, e := range group.entries {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Name, group.status, orNone(e.Server), orNone(e.Client))
+			}
+		}
+		return tw.Flush()
+	}
+
+	if out.Compatible {
+		fmt.Fprintf(w, "✅ %s has every mod of %s in the same version\n", out.ClientList, out.Dir)
+	} else {
+		fmt.Fprintf(w, "❌ %d mods differ from the server, players will likely fail to join. To fix:\n", len(out.Missing)+len(out.Mismatched))
+	}
+	for _, e := range out.Missing {
+		fmt.Fprintf(w, "  + install %s%s\n", skewTarget(e), skewLink(e))
+	}
+	for _, e := range out.Mismatched {
+		fmt.Fprintf(w, "  ~ replace %s with %s%s\n", e.Client, skewTarget(e), skewLink(e))
+	}
+	if len(out.Extra) > 0 {
+		fmt.Fprintln(w, "Only on the client, usually client-side mods that can stay:")
+		for _, e := range out.Extra {
+			fmt.Fprintf(w, "  ? %s\n", e.Client)
+		}
+	}
+	return nil
+}
+
+// skewTarget names the server's file of e, by its CurseForge version when known
+func skewTarget(e checksums.SkewEntry) string {
+	if e.Version != "" {
+		return fmt.Sprintf("%s (%s)", e.Version, e.Server)
+	}
+	return e.Server
+}
+
+// skewLink returns a link to the CurseForge project of e, or "" when it is not known
+func skewLink(e checksums.SkewEntry) string {
+	if e.ProjectID == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" from https://www.curseforge.com/projects/%d", e.ProjectID)
+}
+
+// newModStatusOutputs converts the result of checking or updating the tracked mods
+func newModStatusOutputs(statuses []updater.ModStatus) []modStatusOutput {
+	out := make([]modStatusOutput, 0, len(statuses))
+	for _, s := range statuses {
+		o := modStatusOutput{
+			Key:             s.Mod.Key(),
+			Provider:        s.Mod.ProviderName(),
+			Project:         s.Mod.ProjectID(),
+			Name:            s.Mod.Name,
+			UpdateAvailable: s.UpdateAvailable,
+			Pinned:          s.Mod.Pin != "",
+			HeldBack:        newHeldOutput(s.HeldBack),
+		}
+		if s.Installed != nil {
+			o.InstalledVersion = s.Installed.Version
+		}
+		if s.Latest != nil {
+			o.LatestVersion = s.Latest.Name
+		}
+		if s.Err != nil {
+			o.Error = s.Err.Error()
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+// renderModStatuses prints one line or row per tracked mod
+func renderModStatuses(cmd *cobra.Command, out []modStatusOutput) error {
+	return render(cmd, out, func(w io.Writer, format string) error {
+		if len(out) == 0 {
+			fmt.Fprintln(w, "No mods are tracked. Add [[mods]] entries to the config.")
+			return nil
+		}
+		if format == outputTable {
+			tw := newTable(w)
+			fmt.Fprintln(tw, "MOD\tPROVIDER\tINSTALLED\tLATEST\tUPDATE AVAILABLE")
+			for _, o := range out {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\n", modLabel(o), o.Provider, orNone(o.InstalledVersion), o.LatestVersion, o.UpdateAvailable)
+			}
+			return tw.Flush()
+		}
+		for _, o := range out {
+			switch {
+			case o.Error != "":
+				fmt.Fprintf(w, "❌ %s: %s\n", modLabel(o), o.Error)
+			case o.UpdateAvailable:
+				fmt.Fprintf(w, "⬆️  %s: %s -> %s\n", modLabel(o), orNone(o.InstalledVersion), o.LatestVersion)
+			case o.Pinned:
+				fmt.Fprintf(w, "📌 %s is pinned to %s\n", modLabel(o), o.InstalledVersion)
+			default:
+				fmt.Fprintf(w, "✅ %s is up to date (%s)\n", modLabel(o), o.InstalledVersion)
+			}
+			printHeld(w, o.HeldBack)
+		}
+		return nil
+	})
+}
+
+// modLabel names a mod by its configured name, falling back to its key
+func modLabel(o modStatusOutput) string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return o.Key
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// Output formats accepted by the global --output flag
+const (
+	outputPlain = "plain"
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// outputFormat holds the value of the global --output flag
+var outputFormat = outputPlain
+
+// validateOutputFormat checks the --output flag value
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputPlain, outputTable, outputJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s (supported: plain, table, json)", format)
+	}
+}
+
+// textPrinter writes a human-readable representation for the plain or table format
+type textPrinter func(w io.Writer, format string) error
+
+// collected receives the JSON output instead of stdout while a command runs once per
+// server for --server all, see forEachServer
+var collected *[]interface{}
+
+// render writes v as indented JSON when --output json is selected,
+// otherwise it delegates to the text printer
+func render(cmd *cobra.Command, v interface{}, printer textPrinter) error {
+	w := cmd.OutOrStdout()
+	if outputFormat == outputJSON && collected != nil {
+		*collected = append(*collected, v)
+		return nil
+	}
+	if outputFormat == outputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	return printer(w, outputFormat)
+}
+
+// newTable returns a tabwriter configured for CLI tables; callers must Flush it
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+// ANSI colors used for pass/fail reports
+const (
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+// colorize wraps s in an ANSI color when w is a terminal and NO_COLOR is unset
+func colorize(w io.Writer, color, s string) string {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" || !isatty.IsTerminal(f.Fd()) {
+		return s
+	}
+	return color + s + colorReset
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/plugin"
+	"github.com/spf13/cobra"
+)
+
+// pluginOutput is the stable JSON shape of one entry printed by `plugins list --output json`
+type pluginOutput struct {
+	Name   string   `json:"name"`
+	Path   string   `json:"path"`
+	Stages []string `json:"stages"`
+}
+
+func pluginsCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "Inspect the plugins run during updates.",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the plugins in plugins.dir and the stages they run at.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins, err := plugin.NewRunner(cfg.Plugins, slog.Default()).Plugins()
+			if err != nil {
+				return err
+			}
+			out := make([]pluginOutput, 0, len(plugins))
+			for _, p := range plugins {
+				out = append(out, pluginOutput{Name: p.Name, Path: p.Path, Stages: p.Stages})
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if len(out) == 0 {
+					fmt.Fprintf(w, "No plugins found in %s\n", cfg.Plugins.Dir)
+					return nil
+				}
+				if format == outputTable {
+					tw := newTable(w)
+					fmt.Fprintln(tw, "NAME\tSTAGES\tPATH")
+					for _, p := range out {
+						fmt.Fprintf(tw, "%s\t%s\t%s\n", p.Name, strings.Join(p.Stages, ","), p.Path)
+					}
+					return tw.Flush()
+				}
+				for _, p := range out {
+					fmt.Fprintf(w, "🔌 %s (%s): %s\n", p.Name, p.Path, orNone(strings.Join(p.Stages, ", ")))
+				}
+				return nil
+			})
+		},
+	})
+	return cmd
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/mattn/go-isatty"
+)
+
+// progressBarWidth is the number of cells in the download bar
+const progressBarWidth = 30
+
+// newProgressBar returns a callback that draws a download bar on w, or nil when w is
+// not a terminal or output is meant for scripts
+func newProgressBar(w io.Writer) api.ProgressFunc {
+	f, ok := w.(*os.File)
+	if !ok || quietMode || outputFormat == outputJSON || !isatty.IsTerminal(f.Fd()) {
+		return nil
+	}
+	return func(p api.Progress) {
+		fmt.Fprintf(w, "\r\033[K%s", formatProgress(p))
+		if p.Done {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// formatProgress renders one line of download progress
+func formatProgress(p api.Progress) string {
+	rate := filesystem.FormatBytes(int64(p.Rate)) + "/s"
+	percent := p.Percent()
+	if percent < 0 {
+		return fmt.Sprintf("⬇️  %s  %s", filesystem.FormatBytes(p.Downloaded), rate)
+	}
+	filled := percent * progressBarWidth / 100
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	return fmt.Sprintf("⬇️  %s %3d%%  %s / %s  %s", bar, percent, filesystem.FormatBytes(p.Downloaded), filesystem.FormatBytes(p.Total), rate)
+}
@@ -2,17 +2,100 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
 	"github.com/spf13/cobra"
 )
 
-func restoreCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "restore",
+// restoreOutput is the stable JSON shape printed by `restore --output json`
+type restoreOutput struct {
+	RestoredBackup string `json:"restored_backup"`
+	Snapshot       string `json:"snapshot,omitempty"`
+}
+
+// restorePlanOutput is the stable JSON shape printed by `restore --dry-run` and `rollback --dry-run`
+type restorePlanOutput struct {
+	Backup  string   `json:"backup"`
+	Type    string   `json:"type"`
+	Added   []string `json:"added"`
+	Changed []string `json:"changed"`
+	Removed []string `json:"removed"`
+}
+
+func restoreCmd(cfg *config.Config) *cobra.Command {
+	var noSnapshot bool
+
+	cmd := &cobra.Command{
+		Use:   "restore <backup>",
 		Short: "Restore from backup.",
-		Run: func(cmd *cobra.Command, args []string) {
-			// TODO: Replace with the restore logic
-			fmt.Fprintln(cmd.OutOrStdout(), "[restore] Restore not yet implemented.")
+		Long: `Restore a backup from the backup directory. The backup is extracted next to
+the server directory and swapped in only once extraction succeeded, and the
+files it replaces are saved as a pre_restore backup first. Stop the server
+before restoring.`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{annotationDryRun: "true", annotationAudit: "backup.restore"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bm := newBackupManager(cfg)
+			bm.SetLogger(slog.Default())
+
+			if dryRun {
+				plan, err := bm.PlanRestore(args[0])
+				if err != nil {
+					return err
+				}
+				return renderRestorePlan(cmd, plan)
+			}
+
+			result, err := bm.Restore(args[0], server.RestoreOptions{Snapshot: !noSnapshot})
+			if err != nil {
+				return err
+			}
+			out := restoreOutput{RestoredBackup: result.Backup, Snapshot: result.Snapshot}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				fmt.Fprintf(w, "⏪ Restored %s.\n", out.RestoredBackup)
+				if out.Snapshot != "" {
+					fmt.Fprintf(w, "💾 Replaced files saved as %s\n", out.Snapshot)
+				}
+				return nil
+			})
 		},
 	}
+
+	cmd.Flags().BoolVar(&noSnapshot, "no-snapshot", false, "Do not back up the files being replaced")
+	return cmd
+}
+
+// renderRestorePlan prints what a restore would change
+func renderRestorePlan(cmd *cobra.Command, plan *server.RestorePlan) error {
+	out := restorePlanOutput{
+		Backup:  plan.Backup,
+		Type:    plan.Type,
+		Added:   orEmpty(plan.Added),
+		Changed: orEmpty(plan.Changed),
+		Removed: orEmpty(plan.Removed),
+	}
+	return render(cmd, out, func(w io.Writer, format string) error {
+		fmt.Fprintf(w, "🔍 Restoring %s (%s) would add %d, change %d and remove %d files.\n",
+			out.Backup, out.Type, len(out.Added), len(out.Changed), len(out.Removed))
+		for _, group := range []struct {
+			mark  string
+			paths []string
+		}{{"+", out.Added}, {"~", out.Changed}, {"-", out.Removed}} {
+			for _, path := range group.paths {
+				fmt.Fprintf(w, "  %s %s\n", group.mark, path)
+			}
+		}
+		return nil
+	})
+}
+
+// orEmpty keeps nil slices from being printed as null in JSON output
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+// rollbackOutput is the stable JSON shape printed by `rollback --output json`
+type rollbackOutput struct {
+	RestoredBackup   string `json:"restored_backup"`
+	Snapshot         string `json:"snapshot,omitempty"`
+	InstalledFileID  int    `json:"installed_file_id"`
+	InstalledVersion string `json:"installed_version"`
+}
+
+func rollbackCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:         "rollback",
+		Short:       "Restore the backup taken before the last update.",
+		Annotations: map[string]string{annotationDryRun: "true", annotationAudit: "rollback"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u := updater.NewFromConfig(cfg, slog.Default())
+			if dryRun {
+				plan, err := u.PlanRollback()
+				if err != nil {
+					return err
+				}
+				return renderRestorePlan(cmd, plan)
+			}
+
+			result, err := u.Rollback()
+			if err != nil {
+				return err
+			}
+
+			out := rollbackOutput{
+				RestoredBackup:   result.Backup,
+				Snapshot:         result.Snapshot,
+				InstalledFileID:  result.State.InstalledFileID,
+				InstalledVersion: result.State.InstalledVersion,
+			}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				fmt.Fprintf(w, "⏪ Restored %s; installed version is now %s.\n", out.RestoredBackup, orNone(out.InstalledVersion))
+				if out.Snapshot != "" {
+					fmt.Fprintf(w, "💾 Replaced files saved as %s\n", out.Snapshot)
+				}
+				return nil
+			})
+		},
+	}
+}
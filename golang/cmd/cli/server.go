@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/maintenance"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// serverOutput is the stable JSON shape printed by `server start|stop|restart|status --output json`
+type serverOutput struct {
+	Mode          string  `json:"mode"`
+	Container     string  `json:"container,omitempty"`
+	Unit          string  `json:"unit,omitempty"`
+	PanelServer   string  `json:"panel_server,omitempty"`
+	Deployment    string  `json:"deployment,omitempty"`
+	Running       bool    `json:"running"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+
+	Resources *server.Resources `json:"resources,omitempty"` // status only, when the mode can report them
+}
+
+func serverCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "server",
+		Short: "Control the server container, systemd unit, panel server or Deployment.",
+		Long: `Start, stop and restart the Minecraft server and stream its logs, through
+the Docker API when server.mode is docker, systemctl and journalctl when it is
+systemd, the panel's client API when it is pterodactyl, or the Kubernetes API
+when it is kubernetes. In process mode the server is a child process of the web
+UI and is controlled from there instead.`,
+	}
+
+	var countdown bool
+	action := func(use, short string, fn func(cmd *cobra.Command, c server.Controller) error) *cobra.Command {
+		annotations := map[string]string{annotationServers: config.AllInstances}
+		if fn != nil {
+			annotations[annotationAudit] = "server." + use
+		}
+		return &cobra.Command{
+			Use:         use,
+			Short:       short,
+			Annotations: annotations,
+			RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+				c, err := managedServer(cfg)
+				if err != nil {
+					return err
+				}
+				if fn != nil {
+					if err := fn(cmd, c); err != nil {
+						return err
+					}
+				}
+				out := serverOutput{
+					Mode:          cfg.Server.Mode,
+					Running:       c.IsRunning(),
+					UptimeSeconds: c.GetUptime().Round(time.Second).Seconds(),
+				}
+				if fn == nil && out.Running {
+					if res, err := server.SampleResources(cfg, c); err == nil {
+						out.Resources = res
+					} else {
+						slog.Debug("server resources unavailable", "error", err)
+					}
+				}
+				var name string
+				switch cfg.Server.Mode {
+				case server.ModeDocker:
+					out.Container = cfg.Server.Docker.Container
+					name = out.Container
+				case server.ModeSystemd:
+					out.Unit = cfg.Server.Systemd.Unit
+					name = out.Unit
+				case server.ModePterodactyl:
+					out.PanelServer = cfg.Server.Pterodactyl.ServerID
+					name = "Panel server " + out.PanelServer
+				}
+				return render(cmd, out, func(w io.Writer, format string) error {
+					if out.Running {
+						fmt.Fprintf(w, "🟢 %s is running (up %s)\n", name, time.Duration(out.UptimeSeconds)*time.Second)
+						if out.Resources != nil && (out.Resources.MemoryBytes > 0 || out.Resources.TPS > 0) {
+							fmt.Fprintf(w, "   %s\n", formatResources(out.Resources))
+						}
+					} else {
+						fmt.Fprintf(w, "🔴 %s is stopped\n", name)
+					}
+					return nil
+				})
+			}),
+		}
+	}
+
+	var tail int
+	var follow bool
+	logs := &cobra.Command{
+		Use:   "logs",
+		Short: "Print the container log or the unit's journal.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := managedServer(cfg)
+			if err != nil {
+				return err
+			}
+			streamer, ok := c.(server.LogStreamer)
+			if !ok {
+				return fmt.Errorf("server.mode %q cannot stream logs", cfg.Server.Mode)
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return streamer.Logs(ctx, cmd.OutOrStdout(), tail, follow)
+		},
+	}
+	logs.Flags().IntVar(&tail, "tail", 100, "Number of lines to show from the end of the log")
+	logs.Flags().BoolVarP(&follow, "follow", "f", false, "Keep streaming new log lines")
+
+	stop := action("stop", "Stop the server, waiting server.shutdown_timeout for it to shut down.", func(cmd *cobra.Command, c server.Controller) error {
+		if countdown {
+			if err := runCountdown(cmd, cfg); err != nil {
+				return err
+			}
+		}
+		return c.Stop(cfg.Server.ShutdownTimeout)
+	})
+	restart := action("restart", "Restart the server.", func(cmd *cobra.Command, c server.Controller) error {
+		if countdown {
+			if err := runCountdown(cmd, cfg); err != nil {
+				return err
+			}
+		}
+		return c.Restart(cfg.Server.ShutdownTimeout)
+	})
+	for _, c := range []*cobra.Command{stop, restart} {
+		c.Flags().BoolVar(&countdown, "countdown", false, "Warn players over RCON on the server.countdown schedule first; Ctrl+C cancels")
+	}
+
+	cmd.AddCommand(
+		action("status", "Show whether the server is running.", nil),
+		action("start", "Start the server.", func(cmd *cobra.Command, c server.Controller) error { return c.Start() }),
+		stop,
+		restart,
+		logs,
+		serverMaintenanceCmd(cfg),
+		serverBootstrapCmd(cfg),
+		serverWhitelistCmd(cfg),
+		serverOpCmd(cfg),
+	)
+	return cmd
+}
+
+func serverMaintenanceCmd(cfg *config.Config) *cobra.Command {
+	var flags config.MaintenanceModeConfig
+	cmd := &cobra.Command{
+		Use:         "maintenance",
+		Short:       "Answer players on the server port until interrupted.",
+		Annotations: map[string]string{annotationAudit: "server.maintenance"},
+		Long: `Stand in for the stopped server: the server list shows the
+server.maintenance_mode motd, or --motd, and players who try to join are
+disconnected with it. {version} is the latest known modpack version. Runs until
+Ctrl+C; updates do the same on their own with server.maintenance_mode.enabled.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mm := cfg.Server.MaintenanceMode
+			if cmd.Flags().Changed("motd") {
+				mm.MOTD = flags.MOTD
+			}
+			if cmd.Flags().Changed("eta") {
+				mm.ETA = flags.ETA
+			}
+			if cmd.Flags().Changed("port") {
+				mm.Port = flags.Port
+			}
+			if mm.Port == 0 {
+				mm.Port = server.ServerPort(cfg.ServerPath)
+			}
+			st, err := state.NewStore(filepath.Join(cfg.DataDir, state.FileName)).Load()
+			if err != nil {
+				return err
+			}
+			version := st.LatestVersion
+			if version == "" {
+				version = st.InstalledVersion
+			}
+
+			motd := maintenance.FormatMOTD(mm.MOTD, version, mm.ETA)
+			r, err := maintenance.Listen(net.JoinHostPort("", strconv.Itoa(mm.Port)), motd, slog.Default())
+			if err != nil {
+				return fmt.Errorf("failed to listen on the server port, stop the server first: %w", err)
+			}
+			defer r.Close()
+			fmt.Fprintf(cmd.OutOrStdout(), "🚧 Answering players on %s with %q, Ctrl+C to stop\n", r.Addr(), motd)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			<-ctx.Done()
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&flags.MOTD, "motd", "", "Message to show instead of server.maintenance_mode.motd")
+	cmd.Flags().DurationVar(&flags.ETA, "eta", 0, "Expected downtime for {eta} instead of server.maintenance_mode.eta")
+	cmd.Flags().IntVar(&flags.Port, "port", 0, "Port to answer on instead of server.maintenance_mode.port")
+	return cmd
+}
+
+// runCountdown broadcasts the server.countdown warnings over RCON; interrupting it
+// cancels the stop
+func runCountdown(cmd *cobra.Command, cfg *config.Config) error {
+	if cfg.Server.RCON.Address == "" {
+		return fmt.Errorf("--countdown needs server.rcon.address to reach the server console")
+	}
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := server.NewCountdown(cfg.Server.Countdown, server.NewRCONCommander(cfg.Server.RCON)).Run(ctx); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("countdown cancelled, the server keeps running")
+		}
+		return err
+	}
+	return nil
+}
+
+// formatResources summarizes a resource sample in one line, e.g.
+// "CPU 142%, memory 5.1 GB of 8.0 GB, 19.8 TPS"
+func formatResources(res *server.Resources) string {
+	var parts []string
+	// Modes that only read the TPS over RCON leave the memory at 0
+	if res.MemoryBytes > 0 {
+		memory := filesystem.FormatBytes(int64(res.MemoryBytes))
+		if res.MemoryLimitBytes > 0 {
+			memory += " of " + filesystem.FormatBytes(int64(res.MemoryLimitBytes))
+		}
+		parts = append(parts, fmt.Sprintf("CPU %.0f%%", res.CPUPercent), "memory "+memory)
+	}
+	if res.TPS > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f TPS", res.TPS))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// managedServer returns the configured container, unit, panel server or Deployment, failing in process mode where
+// only the web UI owns the server process
+func managedServer(cfg *config.Config) (server.Controller, error) {
+	switch cfg.Server.Mode {
+	case server.ModeDocker:
+		if cfg.Server.Docker.Container == "" {
+			return nil, fmt.Errorf("server.docker.container is not set")
+		}
+	case server.ModeSystemd:
+		if cfg.Server.Systemd.Unit == "" {
+			return nil, fmt.Errorf("server.systemd.unit is not set")
+		}
+	case server.ModePterodactyl:
+		if cfg.Server.Pterodactyl.ServerID == "" {
+			return nil, fmt.Errorf("server.pterodactyl.server_id is not set")
+		}
+	case server.ModeKubernetes:
+	default:
+		return nil, fmt.Errorf("server.mode is %q: set server.mode to docker, systemd, pterodactyl or kubernetes to control the server from here, or use the web UI to run the server process", cfg.Server.Mode)
+	}
+	return server.NewController(cfg)
+}
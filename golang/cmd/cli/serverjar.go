@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/serverjar"
+	"github.com/spf13/cobra"
+)
+
+// serverJarOutput is the stable JSON shape printed by `server-jar check|update --output json`
+type serverJarOutput struct {
+	Type            string `json:"type"`
+	Installed       string `json:"installed"`
+	Latest          string `json:"latest"`
+	UpdateAvailable bool   `json:"update_available"`
+	JarName         string `json:"jar_name"`
+	Skipped         bool   `json:"skipped"`
+}
+
+func serverJarCmd(cfg *config.Config) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "server-jar",
+		Short: "Check and update the server software configured under [server_jar].",
+	}
+
+	check := &cobra.Command{
+		Use:   "check",
+		Short: "Compare the installed server software with the configured version.",
+		Long: `Compare the installed server software with the version selected by
+[server_jar]. Exits with 10 when it differs, like check.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := serverjar.New(cfg, slog.Default()).Check()
+			if err != nil {
+				return err
+			}
+			out := newServerJarOutput(status)
+			if status.Installed != nil {
+				out.JarName = status.Installed.JarName
+			}
+			if err := renderServerJar(cmd, out); err != nil {
+				return err
+			}
+			if out.UpdateAvailable {
+				return &exitCodeError{code: exitUpdateAvailable}
+			}
+			return nil
+		},
+	}
+
+	update := &cobra.Command{
+		Use:         "update",
+		Short:       "Install the configured server software into server_path.",
+		Annotations: map[string]string{annotationAudit: "server_jar.update"},
+		Long: `Download the configured server software into server_path. Vanilla and
+Fabric servers are a single jar; Forge and NeoForge installers are run with
+server_jar.java. server_jar_name is updated in the config file to point at
+the new jar.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := serverjar.New(cfg, slog.Default()).Update(force)
+			if err != nil {
+				return err
+			}
+			out := newServerJarOutput(&result.Status)
+			out.JarName = result.JarName
+			out.Skipped = result.Skipped
+
+			if result.JarName != "" && result.JarName != cfg.ServerJarName {
+				cfg.ServerJarName = result.JarName
+				if cfg.File == "" {
+					slog.Warn("no config file was loaded, set server_jar_name by hand", "server_jar_name", result.JarName)
+				} else if err := saveServerJarName(cfg); err != nil {
+					return fmt.Errorf("server jar installed, but failed to update server_jar_name: %w", err)
+				}
+			}
+			return renderServerJar(cmd, out)
+		},
+	}
+	update.Flags().BoolVar(&force, "force", false, "Reinstall even when the configured version is already installed")
+
+	cmd.AddCommand(check, update)
+	return cmd
+}
+
+// saveServerJarName writes cfg.ServerJarName to the config file, into the [[servers]]
+// entry when cfg was selected with --server
+func saveServerJarName(cfg *config.Config) error {
+	return saveSetting(cfg, func(inst *config.InstanceConfig) { inst.ServerJarName = cfg.ServerJarName })
+}
+
+// newServerJarOutput fills the output from a status
+func newServerJarOutput(status *serverjar.Status) serverJarOutput {
+	out := serverJarOutput{
+		Type:            status.Latest.Type,
+		Latest:          status.Latest.String(),
+		UpdateAvailable: status.UpdateAvailable,
+	}
+	if i := status.Installed; i != nil {
+		out.Installed = (&serverjar.Release{Type: i.Type, MinecraftVersion: i.MinecraftVersion, LoaderVersion: i.LoaderVersion}).String()
+	}
+	return out
+}
+
+// renderServerJar prints the installed and configured server software
+func renderServerJar(cmd *cobra.Command, out serverJarOutput) error {
+	return render(cmd, out, func(w io.Writer, format string) error {
+		if format == outputTable {
+			tw := newTable(w)
+			fmt.Fprintln(tw, "INSTALLED\tLATEST\tJAR\tUPDATE AVAILABLE")
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%t\n", orNone(out.Installed), out.Latest, orNone(out.JarName), out.UpdateAvailable)
+			return tw.Flush()
+		}
+		switch {
+		case out.Skipped:
+			fmt.Fprintf(w, "✅ %s is already installed. Use --force to reinstall.\n", out.Latest)
+		case out.UpdateAvailable:
+			fmt.Fprintf(w, "⬆️  Server software: %s -> %s\n", orNone(out.Installed), out.Latest)
+		case out.JarName == "" && out.Installed == out.Latest:
+			fmt.Fprintf(w, "✅ Installed %s. Start the server with the generated run script.\n", out.Latest)
+		default:
+			fmt.Fprintf(w, "✅ %s is installed (%s)\n", out.Latest, orNone(out.JarName))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// annotationServers marks commands that accept --server all
+const annotationServers = "servers"
+
+var (
+	// serverName holds the value of the global --server flag
+	serverName string
+
+	// loadedConfig is the config as loaded, before --server picked one of its [[servers]]
+	loadedConfig *config.Config
+)
+
+// serverResultOutput is the stable JSON shape of one entry printed with --server all --output json
+type serverResultOutput struct {
+	Server string      `json:"server"`
+	Result interface{} `json:"result"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// serverListOutput is the stable JSON shape of one entry printed by `list servers --output json`
+type serverListOutput struct {
+	Name             string     `json:"name"`
+	ModpackID        int        `json:"modpack_id"`
+	ServerPath       string     `json:"server_path"`
+	InstalledVersion string     `json:"installed_version"`
+	LatestVersion    string     `json:"latest_version"`
+	LastCheckAt      *time.Time `json:"last_check_at"`
+	AutoUpdate       bool       `json:"auto_update"`
+	CheckInterval    string     `json:"check_interval"`
+	Running          *bool      `json:"running,omitempty"`
+}
+
+// selectServer narrows cfg to the [[servers]] entry chosen with --server
+func selectServer(cmd *cobra.Command, cfg *config.Config) error {
+	switch serverName {
+	case "":
+		return nil
+	case config.AllInstances:
+		if !cfg.HasInstances() {
+			return fmt.Errorf("--server all needs [[servers]] entries in the config")
+		}
+		if cmd.Annotations[annotationServers] != config.AllInstances {
+			return fmt.Errorf("%s does not support --server all, pick one of: %s", cmd.CommandPath(), strings.Join(cfg.InstanceNames(), ", "))
+		}
+		return nil
+	}
+	inst, err := cfg.Instance(serverName)
+	if err != nil {
+		return err
+	}
+	*cfg = *inst
+	return nil
+}
+
+// saveSetting writes cfg to the config file after a command changed one of its settings.
+// When cfg was selected with --server only its [[servers]] entry changes, through apply.
+func saveSetting(cfg *config.Config, apply func(inst *config.InstanceConfig)) error {
+	if cfg.InstanceName == "" || loadedConfig == nil {
+		return config.SaveConfig(cfg, cfg.File)
+	}
+	root := *loadedConfig
+	root.Servers = append([]config.InstanceConfig(nil), loadedConfig.Servers...)
+	for i := range root.Servers {
+		if root.Servers[i].Name == cfg.InstanceName {
+			apply(&root.Servers[i])
+		}
+	}
+	return config.SaveConfig(&root, root.File)
+}
+
+// forEachServer makes run handle --server all by running it once for every [[servers]]
+// entry. Plain and table output is headed by the server name; JSON output is collected
+// into one array. Errors do not stop the remaining servers.
+func forEachServer(cfg *config.Config, run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if serverName != config.AllInstances {
+			return run(cmd, args)
+		}
+		root := *cfg
+		defer func() {
+			*cfg = root
+			collected = nil
+		}()
+
+		results := []serverResultOutput{}
+		var errs []error
+		code := exitOK
+		for i, inst := range root.Instances() {
+			*cfg = *inst
+			var out []interface{}
+			collected = &out
+			if outputFormat != outputJSON {
+				if i > 0 {
+					fmt.Fprintln(cmd.OutOrStdout())
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "[%s]\n", inst.InstanceName)
+			}
+
+			err := run(cmd, args)
+			result := serverResultOutput{Server: inst.InstanceName}
+			if len(out) > 0 {
+				result.Result = out[0]
+			}
+			var exitErr *exitCodeError
+			switch {
+			case errors.As(err, &exitErr):
+				if exitErr.code != exitOK {
+					code = exitErr.code
+				}
+			case err != nil:
+				result.Error = err.Error()
+				errs = append(errs, fmt.Errorf("%s: %w", inst.InstanceName, err))
+			}
+			results = append(results, result)
+		}
+
+		collected = nil
+		if err := render(cmd, results, func(w io.Writer, format string) error { return nil }); err != nil {
+			return err
+		}
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+		if code != exitOK {
+			return &exitCodeError{code: code}
+		}
+		return nil
+	}
+}
+
+// listServersCmd shows every [[servers]] entry with its installed version
+func listServersCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "servers",
+		Short: "List the configured servers and what they have installed.",
+		Long: `List every [[servers]] entry with its modpack, installed and latest
+known version, and schedule. Without [[servers]] the top-level settings are
+listed as the only server. Whether a server is running is only shown when
+server.mode controls it from outside the web UI.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := []serverListOutput{}
+			for _, inst := range cfg.Instances() {
+				st, err := state.NewStore(filepath.Join(inst.DataDir, state.FileName)).Load()
+				if err != nil {
+					return fmt.Errorf("%s: %w", inst.InstanceName, err)
+				}
+				entry := serverListOutput{
+					Name:             inst.InstanceName,
+					ModpackID:        inst.ModpackID,
+					ServerPath:       inst.ServerPath,
+					InstalledVersion: st.InstalledVersion,
+					LatestVersion:    st.LatestVersion,
+					LastCheckAt:      timeOrNil(st.LastCheckAt),
+					AutoUpdate:       inst.UpdatePolicy.AutoUpdate,
+					CheckInterval:    inst.CheckInterval.String(),
+				}
+				if inst.Server.Mode != "" && inst.Server.Mode != server.ModeProcess {
+					if c, err := server.NewController(inst); err == nil {
+						running := c.IsRunning()
+						entry.Running = &running
+					}
+				}
+				out = append(out, entry)
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				tw := newTable(w)
+				fmt.Fprintln(tw, "NAME\tMODPACK\tINSTALLED\tLATEST\tLAST CHECK\tAUTO UPDATE\tRUNNING\tSERVER PATH")
+				for _, s := range out {
+					name, lastCheck, running := s.Name, "never", "-"
+					if name == "" {
+						name = "-"
+					}
+					if s.LastCheckAt != nil {
+						lastCheck = s.LastCheckAt.Format("2006-01-02 15:04")
+					}
+					if s.Running != nil {
+						running = fmt.Sprint(*s.Running)
+					}
+					autoUpdate := "off"
+					if s.AutoUpdate {
+						autoUpdate = "every " + s.CheckInterval
+					}
+					fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", name, s.ModpackID, orNone(s.InstalledVersion),
+						orNone(s.LatestVersion), lastCheck, autoUpdate, running, s.ServerPath)
+				}
+				return tw.Flush()
+			})
+		},
+	}
+}
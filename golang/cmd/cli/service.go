@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// serviceOutput is the stable JSON shape printed by `service install|status --output json`
+type serviceOutput struct {
+	Name      string `json:"name"`
+	Platform  string `json:"platform"`
+	Installed bool   `json:"installed"`
+	Running   bool   `json:"running"`
+	Path      string `json:"path"`
+}
+
+func serviceCmd(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service",
+		Short: "Run the daemon as a systemd unit, launchd job or Windows service.",
+		Long: `Register the daemon with the operating system so it starts at boot and is
+restarted after a crash: a systemd unit on Linux, a launchd job on macOS and a
+service on Windows. The definition runs this binary with the current config
+file and --server, from the config file's directory.
+
+System-wide services need root or an elevated prompt; --user installs a
+systemd user unit or a launchd agent instead.`,
+	}
+
+	var name string
+	var user bool
+	definition := func() service.Definition {
+		n := name
+		if n == "" {
+			n = service.DefaultName
+			if serverName != "" {
+				n += "-" + serverName
+			}
+		}
+		return service.Definition{Name: n, User: user}
+	}
+	cmd.PersistentFlags().StringVar(&name, "name", "", "Service name (default curseforge-autoupdater, suffixed with --server)")
+	cmd.PersistentFlags().BoolVar(&user, "user", false, "Use a systemd user unit or launchd agent (Linux and macOS)")
+
+	var printOnly bool
+	install := &cobra.Command{
+		Use:         "install",
+		Short:       "Install and start the daemon as a service.",
+		Annotations: map[string]string{annotationAudit: "service.install"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.Validate(cfg); err != nil {
+				return fmt.Errorf("invalid config: %w", err)
+			}
+			def, err := daemonDefinition(cfg, definition())
+			if err != nil {
+				return err
+			}
+			if printOnly {
+				text, err := service.Render(def)
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(cmd.OutOrStdout(), text)
+				return nil
+			}
+			st, err := service.Install(def)
+			if err != nil {
+				return fmt.Errorf("failed to install service: %w", err)
+			}
+			return renderService(cmd, def.Name, st)
+		},
+	}
+	install.Flags().BoolVar(&printOnly, "print", false, "Print the unit, plist or service definition instead of installing it")
+
+	uninstall := &cobra.Command{
+		Use:         "uninstall",
+		Short:       "Stop and remove the service.",
+		Annotations: map[string]string{"skipConfig": "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			def := definition()
+			if err := service.Uninstall(def); err != nil {
+				return fmt.Errorf("failed to uninstall service: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Service %s removed\n", def.Name)
+			return nil
+		},
+	}
+
+	status := &cobra.Command{
+		Use:         "status",
+		Short:       "Show whether the service is installed and running.",
+		Annotations: map[string]string{"skipConfig": "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			def := definition()
+			st, err := service.Query(def)
+			if err != nil {
+				return err
+			}
+			return renderService(cmd, def.Name, st)
+		},
+	}
+
+	cmd.AddCommand(install, uninstall, status)
+	return cmd
+}
+
+// daemonDefinition completes def with the command line that runs the daemon for cfg
+func daemonDefinition(cfg *config.Config, def service.Definition) (service.Definition, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return def, fmt.Errorf("failed to find the executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	if strings.Contains(exe, "go-build") {
+		return def, fmt.Errorf("%s is a temporary build, install the binary with go build or go install first", exe)
+	}
+	if cfg.File == "" {
+		return def, fmt.Errorf("a config file is required")
+	}
+	configFile, err := filepath.Abs(cfg.File)
+	if err != nil {
+		return def, err
+	}
+	dataDir, err := filepath.Abs(cfg.DataDir)
+	if err != nil {
+		return def, err
+	}
+
+	def.Description = "CurseForge modpack auto-updater"
+	def.Executable = exe
+	def.Args = []string{"--config", configFile}
+	if serverName != "" {
+		def.Args = append(def.Args, "--server", serverName)
+	}
+	def.Args = append(def.Args, "daemon")
+	def.WorkingDir = filepath.Dir(configFile)
+	def.LogFile = filepath.Join(dataDir, "daemon.log")
+	return def, nil
+}
+
+// renderService prints the state of the service called name
+func renderService(cmd *cobra.Command, name string, st *service.Status) error {
+	out := serviceOutput{
+		Name:      name,
+		Platform:  runtime.GOOS,
+		Installed: st.Installed,
+		Running:   st.Running,
+		Path:      st.Path,
+	}
+	return render(cmd, out, func(w io.Writer, format string) error {
+		switch {
+		case !out.Installed:
+			fmt.Fprintf(w, "⚪ %s is not installed\n", name)
+		case out.Running:
+			fmt.Fprintf(w, "🟢 %s is running (%s)\n", name, out.Path)
+		default:
+			fmt.Fprintf(w, "🔴 %s is installed but not running (%s)\n", name, out.Path)
+		}
+		return nil
+	})
+}
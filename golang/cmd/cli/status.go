@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// statusOutput is the stable JSON shape printed by `status --output json`
+type statusOutput struct {
+	InstalledFileID  int           `json:"installed_file_id"`
+	InstalledVersion string        `json:"installed_version"`
+	InstalledAt      *time.Time    `json:"installed_at"`
+	LastUpdateAt     *time.Time    `json:"last_update_at"`
+	ServerPath       string        `json:"server_path"`
+	ServerJarName    string        `json:"server_jar_name"`
+	ServerJarExists  bool          `json:"server_jar_exists"`
+	BackupPath       string        `json:"backup_path"`
+	BackupCount      int           `json:"backup_count"`
+	BackupSizeBytes  int64         `json:"backup_size_bytes"`
+	LatestBackup     *backupOutput `json:"latest_backup"`
+}
+
+func statusCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:         "status",
+		Short:       "Show server and backup status.",
+		Annotations: map[string]string{annotationServers: config.AllInstances},
+		RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+			out := statusOutput{
+				ServerPath:      cfg.ServerPath,
+				ServerJarName:   cfg.ServerJarName,
+				ServerJarExists: filesystem.FileExists(filepath.Join(cfg.ServerPath, cfg.ServerJarName)),
+				BackupPath:      cfg.BackupPath,
+			}
+
+			st, err := state.NewStore(filepath.Join(cfg.DataDir, state.FileName)).Load()
+			if err != nil {
+				return err
+			}
+			out.InstalledFileID = st.InstalledFileID
+			out.InstalledVersion = st.InstalledVersion
+			out.InstalledAt = timeOrNil(st.InstalledAt)
+			out.LastUpdateAt = timeOrNil(st.LastUpdateAt)
+
+			bm := newBackupManager(cfg)
+			backups, err := bm.ListBackups()
+			if err != nil {
+				return fmt.Errorf("failed to list backups: %w", err)
+			}
+			out.BackupCount = len(backups)
+			if len(backups) > 0 {
+				latest := backups[0]
+				out.LatestBackup = &backupOutput{
+					Name:       latest.Name,
+					Path:       latest.Path,
+					Type:       latest.Type,
+					SizeBytes:  latest.Size,
+					Created:    latest.Created,
+					Compressed: latest.IsCompressed,
+				}
+			}
+			if out.BackupSizeBytes, err = bm.GetBackupSpace(); err != nil {
+				return fmt.Errorf("failed to calculate backup space: %w", err)
+			}
+
+			return render(cmd, out, func(w io.Writer, format string) error {
+				latest := "-"
+				if out.LatestBackup != nil {
+					latest = fmt.Sprintf("%s (%s)", out.LatestBackup.Name, out.LatestBackup.Created.Format("2006-01-02 15:04:05"))
+				}
+				installed := orNone(out.InstalledVersion)
+				if out.InstalledFileID != 0 {
+					installed = fmt.Sprintf("%s (file %d)", installed, out.InstalledFileID)
+				}
+				jar := "missing"
+				if out.ServerJarExists {
+					jar = "present"
+				}
+
+				if format == outputTable {
+					tw := newTable(w)
+					fmt.Fprintln(tw, "FIELD\tVALUE")
+					fmt.Fprintf(tw, "installed\t%s\n", installed)
+					fmt.Fprintf(tw, "server_path\t%s\n", out.ServerPath)
+					fmt.Fprintf(tw, "server_jar\t%s (%s)\n", out.ServerJarName, jar)
+					fmt.Fprintf(tw, "backup_path\t%s\n", out.BackupPath)
+					fmt.Fprintf(tw, "backups\t%d (%s)\n", out.BackupCount, filesystem.FormatBytes(out.BackupSizeBytes))
+					fmt.Fprintf(tw, "latest_backup\t%s\n", latest)
+					return tw.Flush()
+				}
+
+				fmt.Fprintf(w, "📦 Installed: %s\n", installed)
+				fmt.Fprintf(w, "🖥️  Server:  %s\n", out.ServerPath)
+				fmt.Fprintf(w, "📄 Jar:     %s (%s)\n", out.ServerJarName, jar)
+				fmt.Fprintf(w, "💾 Backups: %d in %s (%s)\n", out.BackupCount, out.BackupPath, filesystem.FormatBytes(out.BackupSizeBytes))
+				fmt.Fprintf(w, "🕒 Latest:  %s\n", latest)
+				return nil
+			})
+		}),
+	}
+}
+
+// timeOrNil returns nil for a zero time so JSON output shows null
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
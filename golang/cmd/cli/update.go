@@ -1,18 +1,260 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
 
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
 	"github.com/spf13/cobra"
 )
 
-func updateCmd() *cobra.Command {
-	return &cobra.Command{
+// updateOutput is the stable JSON shape printed by `update --output json`
+type updateOutput struct {
+	ModID          int     `json:"mod_id"`
+	FromFileID     int     `json:"from_file_id"`
+	FromVersion    string  `json:"from_version"`
+	ToFileID       int     `json:"to_file_id"`
+	ToVersion      string  `json:"to_version"`
+	Backup         string  `json:"backup"`
+	DownloadedFile string  `json:"downloaded_file"`
+	DurationSecs   float64 `json:"duration_seconds"`
+	Skipped        bool    `json:"skipped"`
+
+	Preserved []string                `json:"preserved"`
+	Merged    []propertiesMergeOutput `json:"merged"`
+	Mods      []history.ModChange     `json:"mods"`
+	Startup   *server.StartupReport   `json:"startup,omitempty"` // with server.health_check
+	Tasks     []server.TaskResult     `json:"tasks,omitempty"`   // with server.post_update_tasks
+	Sync      *updater.ModSync        `json:"sync,omitempty"`    // with differential_sync
+
+	MinecraftUpgrade *updater.MinecraftUpgrade `json:"minecraft_upgrade"`
+}
+
+// updatePlanOutput is the stable JSON shape printed by `update --dry-run --output json`
+type updatePlanOutput struct {
+	ModID       int      `json:"mod_id"`
+	FromFileID  int      `json:"from_file_id"`
+	FromVersion string   `json:"from_version"`
+	ToFileID    int      `json:"to_file_id"`
+	ToVersion   string   `json:"to_version"`
+	Skipped     bool     `json:"skipped"`
+	Steps       []string `json:"steps"`
+
+	MinecraftUpgrade *updater.MinecraftUpgrade `json:"minecraft_upgrade"`
+}
+
+// propertiesMergeOutput is one preserved properties file merged with the new pack's copy
+type propertiesMergeOutput struct {
+	File      string   `json:"file"`
+	FromPack  []string `json:"from_pack"`
+	Removed   []string `json:"removed"`
+	Conflicts []string `json:"conflicts"`
+}
+
+func updateCmd(cfg *config.Config) *cobra.Command {
+	var force, waitManual, allowMCUpgrade bool
+
+	cmd := &cobra.Command{
 		Use:   "update",
 		Short: "Perform the full update process.",
-		Run: func(cmd *cobra.Command, args []string) {
-			// TODO: Replace with the update logic
-			fmt.Fprintln(cmd.OutOrStdout(), "[update] Update process not yet implemented.")
-		},
+		Long: `Download the latest file for the configured mod, back up the server
+and install the new version. The installed version is recorded in the
+state file inside data_dir.
+
+Files whose author does not allow third-party downloads are listed with
+their CurseForge page; download them into manual_download_path and run
+update again, or pass --wait-manual to be prompted while the update waits.
+
+An update to a newer Minecraft version is pointed out, because worlds loaded
+by it cannot be opened by the old version again. With
+update_policy.confirm_minecraft_upgrades it is only installed with
+--allow-mc-upgrade.
+
+With --server all the servers are updated one after another. With --dry-run
+the steps the update would take are listed without downloading, backing up
+or installing anything.`,
+		Annotations: map[string]string{annotationServers: config.AllInstances, annotationDryRun: "true", annotationAudit: "update"},
+		RunE: forEachServer(cfg, func(cmd *cobra.Command, args []string) error {
+			if !cfg.HasAPIAccess() || cfg.ModpackID == 0 {
+				return fmt.Errorf("missing config: api_key and modpack_id are required. Hint: run `init` to scaffold one")
+			}
+
+			u := updater.NewFromConfig(cfg, slog.Default())
+			u.SetConfirmMinecraftUpgrades(cfg.UpdatePolicy.ConfirmMinecraftUpgrades && !allowMCUpgrade)
+			if dryRun {
+				plan, err := u.PlanUpdate(force)
+				if err != nil {
+					return minecraftUpgradeHint(err)
+				}
+				return renderUpdatePlan(cmd, cfg.ModpackID, plan)
+			}
+			u.SetDownloadProgress(newProgressBar(cmd.ErrOrStderr()))
+			if waitManual {
+				u.SetManualWait(promptManualDownload(cmd.InOrStdin(), cmd.ErrOrStderr()))
+			}
+			result, err := u.Update(force)
+			if err != nil {
+				return minecraftUpgradeHint(err)
+			}
+
+			out := updateOutput{
+				ModID:          cfg.ModpackID,
+				FromFileID:     result.FromFileID,
+				FromVersion:    result.FromVersion,
+				ToFileID:       result.ToFileID,
+				ToVersion:      result.ToVersion,
+				Backup:         result.Backup,
+				DownloadedFile: result.DownloadedFile,
+				DurationSecs:   result.Duration.Round(time.Millisecond).Seconds(),
+				Skipped:        result.Skipped,
+				Preserved:      []string{},
+				Merged:         []propertiesMergeOutput{},
+				Mods:           append([]history.ModChange{}, result.Mods...),
+				Startup:        result.Startup,
+				Tasks:          result.Tasks,
+				Sync:           result.Sync,
+
+				MinecraftUpgrade: result.MinecraftUpgrade,
+			}
+			if p := result.Preserved; p != nil {
+				out.Preserved = append(out.Preserved, p.Restored...)
+				for _, m := range p.Merged {
+					out.Merged = append(out.Merged, propertiesMergeOutput{
+						File:      m.File,
+						FromPack:  append([]string{}, m.FromPack...),
+						Removed:   append([]string{}, m.Removed...),
+						Conflicts: append([]string{}, m.Conflicts...),
+					})
+				}
+			}
+			return render(cmd, out, func(w io.Writer, format string) error {
+				if out.Skipped {
+					fmt.Fprintf(w, "✅ Mod %d is already up to date (%s). Use --force to reinstall.\n", out.ModID, out.ToVersion)
+					return nil
+				}
+				if out.Backup != "" {
+					fmt.Fprintf(w, "💾 Backup created: %s\n", out.Backup)
+				}
+				fmt.Fprintf(w, "✅ Updated mod %d: %s -> %s in %s\n", out.ModID, orNone(out.FromVersion), out.ToVersion, result.Duration.Round(time.Millisecond))
+				if out.MinecraftUpgrade != nil {
+					printMinecraftUpgrade(w, out.MinecraftUpgrade)
+				}
+				if s := out.Sync; s != nil {
+					fmt.Fprintf(w, "🔄 Synced mods: %d downloaded (%s), %d removed, %d override files\n", len(s.Downloaded), filesystem.FormatBytes(s.DownloadBytes), len(s.Removed), s.Overrides)
+				}
+				if out.Startup != nil && out.Startup.Done {
+					fmt.Fprintf(w, "🚀 Server started in %s\n", out.Startup.StartupTime.Round(time.Millisecond))
+				}
+				for _, t := range out.Tasks {
+					switch {
+					case t.Skipped:
+						fmt.Fprintf(w, "⏭️  Skipped task %s\n", t.Name)
+					case t.Error != "":
+						fmt.Fprintf(w, "⚠️  Task %s failed: %s\n", t.Name, t.Error)
+					default:
+						fmt.Fprintf(w, "🧹 Ran task %s in %s\n", t.Name, t.Duration.Round(time.Second))
+					}
+				}
+				if len(out.Preserved) > 0 {
+					fmt.Fprintf(w, "🔒 Kept local copies of: %s\n", strings.Join(out.Preserved, ", "))
+				}
+				if len(out.Mods) > 0 {
+					fmt.Fprintf(w, "📝 %d mods changed:\n", len(out.Mods))
+					for _, m := range out.Mods {
+						switch {
+						case m.FromVersion == "":
+							fmt.Fprintf(w, "  + %s %s\n", m.Name, m.ToVersion)
+						case m.ToVersion == "":
+							fmt.Fprintf(w, "  - %s %s\n", m.Name, m.FromVersion)
+						default:
+							fmt.Fprintf(w, "  ~ %s %s -> %s\n", m.Name, m.FromVersion, m.ToVersion)
+						}
+					}
+				}
+				for _, m := range out.Merged {
+					fmt.Fprintf(w, "🔀 Merged %s: %d keys taken from the pack, %d removed by the pack\n", m.File, len(m.FromPack), len(m.Removed))
+					if len(m.Conflicts) > 0 {
+						fmt.Fprintf(w, "   ⚠️  Kept local values the pack changes: %s\n", strings.Join(m.Conflicts, ", "))
+					}
+				}
+				return nil
+			})
+		}),
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Reinstall the latest file even when it is already installed")
+	cmd.Flags().BoolVar(&waitManual, "wait-manual", false, "Wait for files that must be downloaded by hand instead of failing")
+	cmd.Flags().BoolVar(&allowMCUpgrade, "allow-mc-upgrade", false, "Install an update to a newer Minecraft version with update_policy.confirm_minecraft_upgrades")
+	return cmd
+}
+
+// minecraftUpgradeHint points out --allow-mc-upgrade when err refused a Minecraft upgrade
+func minecraftUpgradeHint(err error) error {
+	if errors.Is(err, updater.ErrMinecraftUpgrade) {
+		return fmt.Errorf("%w. Hint: back up the worlds and pass --allow-mc-upgrade to install it", err)
+	}
+	return err
+}
+
+// renderUpdatePlan prints what an update would do
+func renderUpdatePlan(cmd *cobra.Command, modID int, plan *updater.UpdatePlan) error {
+	out := updatePlanOutput{
+		ModID:       modID,
+		FromFileID:  plan.FromFileID,
+		FromVersion: plan.FromVersion,
+		ToFileID:    plan.ToFileID,
+		ToVersion:   plan.ToVersion,
+		Skipped:     plan.Skipped,
+		Steps:       orEmpty(plan.Steps),
+
+		MinecraftUpgrade: plan.MinecraftUpgrade,
+	}
+	return render(cmd, out, func(w io.Writer, format string) error {
+		if out.Skipped {
+			fmt.Fprintf(w, "✅ Mod %d is already up to date (%s); update would do nothing.\n", out.ModID, out.ToVersion)
+			return nil
+		}
+		fmt.Fprintf(w, "🔍 Updating mod %d from %s to %s would:\n", out.ModID, orNone(out.FromVersion), out.ToVersion)
+		if out.MinecraftUpgrade != nil {
+			printMinecraftUpgrade(w, out.MinecraftUpgrade)
+		}
+		for i, step := range out.Steps {
+			fmt.Fprintf(w, "  %d. %s\n", i+1, step)
+		}
+		return nil
+	})
+}
+
+// promptManualDownload lists the files to download by hand on w and waits for Enter on r
+func promptManualDownload(r io.Reader, w io.Writer) updater.ManualWaitFunc {
+	reader := bufio.NewReader(r)
+	return func(dir string, files []updater.ManualDownload) error {
+		fmt.Fprintf(w, "⚠️  The author does not allow these files to be downloaded automatically.\n")
+		fmt.Fprintf(w, "   Download them into %s:\n", dir)
+		for _, f := range files {
+			fmt.Fprintf(w, "   - %s (%s)\n     %s\n", f.FileName, filesystem.FormatBytes(f.Size), f.ProjectURL)
+		}
+		fmt.Fprint(w, "Press Enter when done (Ctrl+C to abort): ")
+		if _, err := reader.ReadString('\n'); err != nil {
+			return fmt.Errorf("waiting for manual downloads: %w", err)
+		}
+		return nil
+	}
+}
+
+// orNone returns "none" for an empty version
+func orNone(version string) string {
+	if version == "" {
+		return "none"
 	}
+	return version
 }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/views"
+	"github.com/labstack/echo/v4"
+)
+
+// accessRequest is the JSON body of POST /api/v1/whitelist and POST /api/v1/ops
+type accessRequest struct {
+	Name string `json:"name"`
+}
+
+// accessResponse is the JSON shape of the whitelist and ops changes
+type accessResponse struct {
+	Name string `json:"name"`
+	Live bool   `json:"live"` // sent to the running server's console instead of editing the file
+}
+
+// playersPage renders /players with the whitelist and the operators
+func playersPage(cfg *config.Config) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		a := server.NewAccess(cfg.ServerPath, nil, nil)
+		page := views.PlayersPage{APIEnabled: cfg.Web.APIToken != ""}
+		var err error
+		if page.Whitelist, err = a.Whitelist(); err != nil {
+			return err
+		}
+		if page.Ops, err = a.Ops(); err != nil {
+			return err
+		}
+		if props, err := server.LoadProperties(cfg.ServerPath); err == nil {
+			page.WhitelistEnabled = props.Whitelist()
+		}
+		return render(c, views.Players(page))
+	}
+}
+
+// access manages the whitelist and ops through the console of the process the web UI
+// started, over RCON while a server in another mode runs, or in the files otherwise
+func (a *api) access() (*server.Access, error) {
+	running := a.minecraft.IsRunning()
+	if mc, ok := a.minecraft.(*server.MinecraftServer); ok && running {
+		return server.NewAccess(a.cfg.ServerPath, mc, nil), nil
+	}
+	if running && a.cfg.Server.RCON.Address != "" {
+		console := server.NewRCONCommander(a.cfg.Server.RCON)
+		if err := console.Ping(); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadGateway, "the server is running but RCON failed: "+err.Error())
+		}
+		return server.NewAccess(a.cfg.ServerPath, console, nil), nil
+	}
+	if a.cfg.Server.Mode == server.ModePterodactyl {
+		// server_path is only a local copy of the panel's files
+		return nil, echo.NewHTTPError(http.StatusConflict, "in pterodactyl mode the whitelist and ops are changed over RCON: start the server and set server.rcon.address")
+	}
+	return server.NewAccess(a.cfg.ServerPath, nil, httpclient.New(a.cfg.HTTP)), nil
+}
+
+func (a *api) listWhitelist(c echo.Context) error {
+	players, err := server.NewAccess(a.cfg.ServerPath, nil, nil).Whitelist()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, players)
+}
+
+func (a *api) listOps(c echo.Context) error {
+	ops, err := server.NewAccess(a.cfg.ServerPath, nil, nil).Ops()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, ops)
+}
+
+// addPlayer handles POST /api/v1/whitelist and POST /api/v1/ops
+func (a *api) addPlayer(action string, change func(acc *server.Access, name string) error) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req accessRequest
+		if err := c.Bind(&req); err != nil {
+			return err
+		}
+		return a.changeAccess(c, action, req.Name, http.StatusCreated, change)
+	}
+}
+
+// removePlayer handles DELETE /api/v1/whitelist/:name and DELETE /api/v1/ops/:name
+func (a *api) removePlayer(action string, change func(acc *server.Access, name string) error) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return a.changeAccess(c, action, c.Param("name"), http.StatusOK, change)
+	}
+}
+
+func (a *api) changeAccess(c echo.Context, action, name string, status int, change func(acc *server.Access, name string) error) error {
+	if !a.busy.TryLock() {
+		return errBusy
+	}
+	defer a.busy.Unlock()
+
+	acc, err := a.access()
+	if err != nil {
+		return err
+	}
+	err = change(acc, name)
+	a.record(c, action, name, "", err)
+	switch {
+	case errors.Is(err, server.ErrInvalidPlayerName):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, server.ErrPlayerNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case err != nil:
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	return c.JSON(status, accessResponse{Name: name, Live: acc.Live()})
+}
@@ -0,0 +1,722 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	curseforge "github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/audit"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/jobs"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/logging"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/policy"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/provider"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// serverStopTimeout is how long the server gets to shut down before it is killed when
+// server.shutdown_timeout is not set
+const serverStopTimeout = 60 * time.Second
+
+// errBusy is returned while another update, backup or server operation is running
+var errBusy = echo.NewHTTPError(http.StatusConflict, "another operation is in progress")
+
+// checkResponse is the JSON shape of POST /api/v1/check
+type checkResponse struct {
+	ModID            int       `json:"mod_id"`
+	InstalledFileID  int       `json:"installed_file_id"`
+	InstalledVersion string    `json:"installed_version"`
+	LatestFileID     int       `json:"latest_file_id"`
+	LatestVersion    string    `json:"latest_version"`
+	LatestFileDate   time.Time `json:"latest_file_date"`
+	UpdateAvailable  bool      `json:"update_available"`
+
+	HeldBack      []heldResponse `json:"held_back"`
+	HeldForReview *policy.Hold   `json:"held_for_review"` // the matching update_policy hold rule
+
+	MinecraftUpgrade *updater.MinecraftUpgrade `json:"minecraft_upgrade"`
+	Channels         []updater.ChannelStatus   `json:"channels"` // what each release channel would install
+}
+
+// heldResponse is a newer file that the release policy held back
+type heldResponse struct {
+	Version   string    `json:"version"`
+	Channel   string    `json:"channel"`
+	Published time.Time `json:"published"`
+	Reason    string    `json:"reason"`
+}
+
+// updateResponse is the JSON shape of POST /api/v1/update
+type updateResponse struct {
+	ModID          int     `json:"mod_id"`
+	FromFileID     int     `json:"from_file_id"`
+	FromVersion    string  `json:"from_version"`
+	ToFileID       int     `json:"to_file_id"`
+	ToVersion      string  `json:"to_version"`
+	Backup         string  `json:"backup"`
+	DownloadedFile string  `json:"downloaded_file"`
+	DurationSecs   float64 `json:"duration_seconds"`
+	Skipped        bool    `json:"skipped"`
+
+	Preserved []string                  `json:"preserved"`
+	Merged    []propertiesMergeResponse `json:"merged"`
+	Mods      []history.ModChange       `json:"mods"`
+	Startup   *server.StartupReport     `json:"startup,omitempty"`
+	Tasks     []server.TaskResult       `json:"tasks,omitempty"`
+	Sync      *updater.ModSync          `json:"sync,omitempty"`
+
+	MinecraftUpgrade *updater.MinecraftUpgrade `json:"minecraft_upgrade"`
+}
+
+// propertiesMergeResponse is one preserved properties file merged with the new pack's copy
+type propertiesMergeResponse struct {
+	File      string   `json:"file"`
+	FromPack  []string `json:"from_pack"`
+	Removed   []string `json:"removed"`
+	Conflicts []string `json:"conflicts"`
+}
+
+// backupResponse is the JSON shape of one backup
+type backupResponse struct {
+	Name       string    `json:"name"`
+	Path       string    `json:"path"`
+	Type       string    `json:"type"`
+	SizeBytes  int64     `json:"size_bytes"`
+	Created    time.Time `json:"created"`
+	Compressed bool      `json:"compressed"`
+	Encrypted  bool      `json:"encrypted"`
+	Protected  bool      `json:"protected"`
+
+	// Recorded in the backup index; empty for backups made before it
+	Trigger      string  `json:"trigger"`
+	Version      string  `json:"version"`
+	Checksum     string  `json:"checksum"`
+	DurationSecs float64 `json:"duration_seconds"`
+}
+
+// serverResponse is the JSON shape of the server endpoints
+type serverResponse struct {
+	Running       bool    `json:"running"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+
+	Resources *server.Resources `json:"resources,omitempty"` // GET /server only, when they can be sampled
+}
+
+// historyResponse is the JSON shape of one history entry
+type historyResponse struct {
+	Timestamp    time.Time `json:"timestamp"`
+	ModID        int       `json:"mod_id"`
+	FromFileID   int       `json:"from_file_id"`
+	FromVersion  string    `json:"from_version"`
+	ToFileID     int       `json:"to_file_id"`
+	ToVersion    string    `json:"to_version"`
+	Result       string    `json:"result"`
+	DurationSecs float64   `json:"duration_seconds"`
+	Backup       string    `json:"backup"`
+	Error        string    `json:"error"`
+
+	Mods []history.ModChange `json:"mods"`
+}
+
+// modResponse is the JSON shape of one tracked mod
+type modResponse struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"`
+	Project  string `json:"project,omitempty"`
+}
+
+// api implements the /api/v1 endpoints
+type api struct {
+	cfg       *config.Config
+	minecraft server.Controller
+	resources *resourceSampler
+	bus       *events.Bus
+	counts    *eventCounts
+	jobs      *jobs.Queue
+	editor    *configEditor
+	done      <-chan struct{} // closed when the web server shuts down
+
+	// busy serialises operations that change the server or its files
+	busy sync.Mutex
+}
+
+// registerAPI mounts the REST API; it stays disabled until web.api_token is set. The
+// webhook receiver is mounted on its own once web.webhook_secret is set.
+// Check and update progress is published to bus and streamed from /api/v1/events
+// until done is closed; counts feeds the totals of /api/v1/metrics. Updates, backups and
+// restores can also run in the background as jobs of queue. The gRPC control API of
+// grpc.go serves the returned api.
+func registerAPI(e *echo.Echo, cfg *config.Config, minecraft server.Controller, resources *resourceSampler, bus *events.Bus, counts *eventCounts, queue *jobs.Queue, editor *configEditor, done <-chan struct{}) *api {
+	a := &api{cfg: cfg, minecraft: minecraft, resources: resources, bus: bus, counts: counts, jobs: queue, editor: editor, done: done}
+	if cfg.Web.WebhookSecret != "" {
+		e.POST("/hooks/:action", a.hook)
+	}
+	if cfg.Web.APIToken == "" {
+		slog.Warn("REST API disabled: web.api_token is not set")
+		return a
+	}
+
+	validate := func(key string, c echo.Context) (bool, error) {
+		return subtle.ConstantTimeCompare([]byte(key), []byte(cfg.Web.APIToken)) == 1, nil
+	}
+	// A missing header is as unauthorized as a wrong token
+	unauthorized := func(err error, c echo.Context) error {
+		return echo.NewHTTPError(http.StatusUnauthorized, "missing or invalid API token")
+	}
+	headerAuth := middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		KeyLookup:    "header:" + echo.HeaderAuthorization + ":Bearer ",
+		Validator:    validate,
+		ErrorHandler: unauthorized,
+	})
+	linkAuth := middleware.KeyAuthWithConfig(middleware.KeyAuthConfig{
+		KeyLookup:    "header:" + echo.HeaderAuthorization + ":Bearer ,query:token",
+		Validator:    validate,
+		ErrorHandler: unauthorized,
+	})
+	g := e.Group("/api/v1", func(next echo.HandlerFunc) echo.HandlerFunc {
+		header, link := headerAuth(next), linkAuth(next)
+		return func(c echo.Context) error {
+			if queryTokenRoutes[c.Path()] {
+				return link(c)
+			}
+			return header(c)
+		}
+	})
+
+	g.GET("/status", a.status)
+	g.POST("/check", a.check)
+	g.POST("/update", a.update)
+	g.GET("/backups", a.listBackups)
+	g.POST("/backups", a.createBackup)
+	g.POST("/backups/:name/restore", a.restoreBackup)
+	g.POST("/backups/:name/validate", a.validateBackup)
+	g.GET("/backups/:name/download", a.downloadBackup)
+	g.DELETE("/backups/:name", a.deleteBackup)
+	g.GET("/server", a.serverStatus)
+	g.GET("/metrics", a.metrics)
+	g.POST("/server/start", a.startServer)
+	g.POST("/server/stop", a.stopServer)
+	g.POST("/server/restart", a.restartServer)
+	g.GET("/servers", a.listServers)
+	g.GET("/approvals", a.listApprovals)
+	g.POST("/approvals/approve", a.decideApproval(true))
+	g.POST("/approvals/deny", a.decideApproval(false))
+	g.GET("/history", a.history)
+	g.GET("/jobs", a.listJobs)
+	g.POST("/jobs", a.createJob)
+	g.GET("/jobs/:id", a.getJob)
+	g.POST("/jobs/:id/cancel", a.cancelJob)
+	g.GET("/events", a.events)
+	g.GET("/console", a.console)
+	g.GET("/mods", a.listMods)
+	g.POST("/mods", a.trackMod)
+	g.DELETE("/mods/:id", a.untrackMod)
+	g.GET("/whitelist", a.listWhitelist)
+	g.POST("/whitelist", a.addPlayer("server.whitelist.add", (*server.Access).AddToWhitelist))
+	g.DELETE("/whitelist/:name", a.removePlayer("server.whitelist.remove", (*server.Access).RemoveFromWhitelist))
+	g.GET("/ops", a.listOps)
+	g.POST("/ops", a.addPlayer("server.op.add", (*server.Access).AddOp))
+	g.DELETE("/ops/:name", a.removePlayer("server.op.remove", (*server.Access).RemoveOp))
+	return a
+}
+
+// queryTokenRoutes may also pass the API token as ?token=, since EventSource, WebSocket
+// and download links cannot set headers. Tokens in URLs end up in logs and browser
+// history, so no other route takes one.
+var queryTokenRoutes = map[string]bool{
+	"/api/v1/events":                 true,
+	"/api/v1/console":                true,
+	"/api/v1/backups/:name/download": true,
+}
+
+// actor names the API client of c in the audit log and in jobs
+func actor(c echo.Context) string {
+	return "api " + c.RealIP()
+}
+
+// logger returns a logger tagged with a fresh run ID for one request
+func (a *api) logger() *slog.Logger {
+	return logging.WithRun(slog.Default(), logging.NewRunID(), a.cfg.ModpackID)
+}
+
+// record publishes an action taken through the API for the audit log
+func (a *api) record(c echo.Context, action, target, details string, err error) {
+	a.recordAs(actor(c), action, target, details, err)
+}
+
+// recordAs publishes an action taken by actor, e.g. from a job, for the audit log
+func (a *api) recordAs(actor, action, target, details string, err error) {
+	entry := audit.Entry{Actor: actor, Action: action, Target: target, Result: audit.ResultSuccess, Details: details}
+	if err != nil {
+		entry.Result = audit.ResultFailed
+		entry.Details = strings.TrimPrefix(entry.Details+": "+err.Error(), ": ")
+	}
+	audit.Publish(a.bus, entry)
+}
+
+// updater creates an updater that logs to logger, publishes its progress to the event bus
+// and, in process mode, verifies that the server it runs starts again after an update and
+// then runs the post-update tasks in its console
+func (a *api) updater(logger *slog.Logger) *updater.Updater {
+	u := updater.NewFromConfig(a.cfg, logger)
+	u.SetBus(a.bus)
+	if mc, ok := a.minecraft.(*server.MinecraftServer); ok && a.cfg.Server.HealthCheck.Enabled {
+		u.SetStartupCheck(mc, a.stopTimeout(), a.cfg.Server.HealthCheck)
+		u.SetPostUpdateTasks(mc, a.cfg.Server.PostUpdateTasks)
+	}
+	return u
+}
+
+// backups creates a backup manager from the backup settings
+func (a *api) backups() *server.BackupManager {
+	bm := server.NewBackupManager(a.cfg.ServerPath, a.cfg.BackupPath, a.cfg.Backup.Compression, a.cfg.Backup.RetentionDays)
+	bm.SetLogger(a.logger())
+	bm.SetTrigger(server.TriggerWeb)
+	bm.SetVersionSource(state.NewStore(filepath.Join(a.cfg.DataDir, state.FileName)).InstalledVersion)
+	bm.SetRestoreOwner(a.cfg.Backup.RestoreOwner)
+	bm.SetSnapshot(a.cfg.Backup.Snapshot)
+	bm.SetEncryption(a.cfg.Backup.Encryption)
+	bm.SetRetention(server.NewRetentionPolicy(a.cfg.Backup))
+	return bm
+}
+
+func (a *api) check(c echo.Context) error {
+	resp, err := a.runCheck(a.logger())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// runCheck looks for an update, for the check endpoint and check jobs
+func (a *api) runCheck(logger *slog.Logger) (*checkResponse, error) {
+	u := a.updater(logger)
+	result, err := u.Check()
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	resp := &checkResponse{
+		ModID:            a.cfg.ModpackID,
+		InstalledFileID:  result.State.InstalledFileID,
+		InstalledVersion: result.State.InstalledVersion,
+		LatestFileID:     result.Latest.ID,
+		LatestVersion:    result.Latest.DisplayName,
+		LatestFileDate:   result.Latest.FileDate,
+		UpdateAvailable:  result.UpdateAvailable,
+		HeldBack:         []heldResponse{},
+		MinecraftUpgrade: result.MinecraftUpgrade,
+		Channels:         result.Channels,
+	}
+	for _, h := range result.HeldBack {
+		resp.HeldBack = append(resp.HeldBack, heldResponse{Version: h.Version.Name, Channel: h.Version.Channel, Published: h.Version.Published, Reason: h.Reason})
+	}
+	if rules := a.cfg.UpdatePolicy.HoldRules; result.UpdateAvailable && len(rules) > 0 {
+		candidate := policy.CandidateFor(result.Latest, rules, u.Changelog)
+		if candidate.ChangelogErr != nil {
+			logger.Warn("failed to read the changelog for hold_rules", "error", candidate.ChangelogErr)
+		}
+		resp.HeldForReview = policy.MatchHoldRules(rules, candidate)
+	}
+	return resp, nil
+}
+
+func (a *api) update(c echo.Context) error {
+	force, _ := strconv.ParseBool(c.QueryParam("force"))
+	allowMCUpgrade, _ := strconv.ParseBool(c.QueryParam("allow_mc_upgrade"))
+	if !a.busy.TryLock() {
+		return errBusy
+	}
+	defer a.busy.Unlock()
+
+	resp, err := a.runUpdate(c.Request().Context(), actor(c), a.logger(), force, allowMCUpgrade)
+	if errors.Is(err, updater.ErrMinecraftUpgrade) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error()+"; pass allow_mc_upgrade=true to install it")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// runUpdate installs the latest file and records it for actor; a.busy must be held.
+// allowMCUpgrade overrides update_policy.confirm_minecraft_upgrades.
+func (a *api) runUpdate(ctx context.Context, actor string, logger *slog.Logger, force, allowMCUpgrade bool) (*updateResponse, error) {
+	u := a.updater(logger)
+	u.SetConfirmMinecraftUpgrades(a.cfg.UpdatePolicy.ConfirmMinecraftUpgrades && !allowMCUpgrade)
+	u.SetContext(ctx)
+	result, err := u.Update(force)
+	details := ""
+	if err == nil && !result.Skipped {
+		details = fmt.Sprintf("%s -> %s", result.FromVersion, result.ToVersion)
+	}
+	a.recordAs(actor, "update", strconv.Itoa(a.cfg.ModpackID), details, err)
+	if err != nil {
+		return nil, err
+	}
+	resp := &updateResponse{
+		ModID:          a.cfg.ModpackID,
+		FromFileID:     result.FromFileID,
+		FromVersion:    result.FromVersion,
+		ToFileID:       result.ToFileID,
+		ToVersion:      result.ToVersion,
+		Backup:         result.Backup,
+		DownloadedFile: result.DownloadedFile,
+		DurationSecs:   result.Duration.Round(time.Millisecond).Seconds(),
+		Skipped:        result.Skipped,
+		Preserved:      []string{},
+		Merged:         []propertiesMergeResponse{},
+		Mods:           append([]history.ModChange{}, result.Mods...),
+		Startup:        result.Startup,
+		Tasks:          result.Tasks,
+		Sync:           result.Sync,
+
+		MinecraftUpgrade: result.MinecraftUpgrade,
+	}
+	if p := result.Preserved; p != nil {
+		resp.Preserved = append(resp.Preserved, p.Restored...)
+		for _, m := range p.Merged {
+			resp.Merged = append(resp.Merged, propertiesMergeResponse{
+				File:      m.File,
+				FromPack:  append([]string{}, m.FromPack...),
+				Removed:   append([]string{}, m.Removed...),
+				Conflicts: append([]string{}, m.Conflicts...),
+			})
+		}
+	}
+	return resp, nil
+}
+
+func (a *api) listBackups(c echo.Context) error {
+	backups, err := a.backups().ListBackups()
+	if err != nil {
+		return err
+	}
+	out := []backupResponse{}
+	for _, b := range backups {
+		out = append(out, newBackupResponse(&b))
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+// backupRequest is the JSON body of POST /api/v1/backups
+type backupRequest struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // manual (default) or world
+}
+
+// validate checks the request before anything is backed up
+func (req backupRequest) validate() error {
+	if req.Name != "" && req.Name != filepath.Base(req.Name) {
+		return echo.NewHTTPError(http.StatusBadRequest, "backup name must not contain a path")
+	}
+	if req.Type != "" && req.Type != "manual" && req.Type != server.BackupTypeWorld {
+		return echo.NewHTTPError(http.StatusBadRequest, "backup type must be manual or world")
+	}
+	return nil
+}
+
+func (a *api) createBackup(c echo.Context) error {
+	var req backupRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	if !a.busy.TryLock() {
+		return errBusy
+	}
+	defer a.busy.Unlock()
+
+	resp, err := a.runBackup(c.Request().Context(), actor(c), a.logger(), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// runBackup makes the backup req asks for and records it for actor; a.busy must be held
+func (a *api) runBackup(ctx context.Context, actor string, logger *slog.Logger, req backupRequest) (*backupResponse, error) {
+	bm := a.backups()
+	bm.SetLogger(logger)
+	bm.SetContext(ctx)
+	create := bm.CreateManualBackup
+	if req.Type == server.BackupTypeWorld {
+		create = bm.CreateWorldBackup
+	}
+	backup, err := create(req.Name)
+	if err != nil {
+		a.recordAs(actor, "backup.create", req.Name, req.Type, err)
+		return nil, err
+	}
+	a.recordAs(actor, "backup.create", filepath.Base(backup.Path), req.Type, nil)
+	a.bus.Publish(events.BackupCreated, map[string]interface{}{
+		"name": filepath.Base(backup.Path),
+		"size": backup.Size,
+	})
+	resp := newBackupResponse(backup)
+	return &resp, nil
+}
+
+func (a *api) restoreBackup(c echo.Context) error {
+	name := c.Param("name")
+	if c.QueryParam("dry_run") == "true" {
+		plan, err := a.backups().PlanRestore(name)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"backup":  plan.Backup,
+			"type":    plan.Type,
+			"added":   nonNil(plan.Added),
+			"changed": nonNil(plan.Changed),
+			"removed": nonNil(plan.Removed),
+		})
+	}
+	if !a.busy.TryLock() {
+		return errBusy
+	}
+	defer a.busy.Unlock()
+
+	resp, err := a.runRestore(c.Request().Context(), actor(c), a.logger(), name)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// runRestore restores the backup called name, after a snapshot of the server, and records
+// it for actor; a.busy must be held
+func (a *api) runRestore(ctx context.Context, actor string, logger *slog.Logger, name string) (map[string]string, error) {
+	if a.minecraft.IsRunning() {
+		return nil, echo.NewHTTPError(http.StatusConflict, "stop the server before restoring a backup")
+	}
+	bm := a.backups()
+	bm.SetLogger(logger)
+	bm.SetContext(ctx)
+	if _, err := bm.GetBackupInfo(name); err != nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	result, err := bm.Restore(name, server.RestoreOptions{Snapshot: true})
+	a.recordAs(actor, "backup.restore", name, "", err)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"restored_backup": name, "snapshot": result.Snapshot}, nil
+}
+
+// nonNil keeps empty lists from being encoded as null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func (a *api) serverStatus(c echo.Context) error {
+	resp := a.serverResponse()
+	resp.Resources = a.resources.sample()
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (a *api) startServer(c echo.Context) error {
+	if !a.busy.TryLock() {
+		return errBusy
+	}
+	defer a.busy.Unlock()
+
+	err := a.minecraft.Start()
+	a.record(c, "server.start", a.cfg.ServerPath, "", err)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return c.JSON(http.StatusOK, a.serverResponse())
+}
+
+func (a *api) stopServer(c echo.Context) error {
+	if !a.busy.TryLock() {
+		return errBusy
+	}
+	defer a.busy.Unlock()
+
+	if !a.minecraft.IsRunning() {
+		return echo.NewHTTPError(http.StatusConflict, "server is not running")
+	}
+	err := a.minecraft.Stop(a.stopTimeout())
+	a.record(c, "server.stop", a.cfg.ServerPath, "", err)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, a.serverResponse())
+}
+
+func (a *api) restartServer(c echo.Context) error {
+	if !a.busy.TryLock() {
+		return errBusy
+	}
+	defer a.busy.Unlock()
+
+	err := a.minecraft.Restart(a.stopTimeout())
+	a.record(c, "server.restart", a.cfg.ServerPath, "", err)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return c.JSON(http.StatusOK, a.serverResponse())
+}
+
+// stopTimeout is how long the server gets to shut down before it is killed
+func (a *api) stopTimeout() time.Duration {
+	if a.cfg.Server.ShutdownTimeout > 0 {
+		return a.cfg.Server.ShutdownTimeout
+	}
+	return serverStopTimeout
+}
+
+func (a *api) history(c echo.Context) error {
+	filter := history.Filter{Result: c.QueryParam("result")}
+	if filter.Result != "" && !history.ValidResult(filter.Result) {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid result filter")
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be a non-negative integer")
+		}
+		filter.Limit = n
+	}
+
+	entries, err := history.NewLog(filepath.Join(a.cfg.DataDir, history.FileName)).List(filter)
+	if err != nil {
+		return err
+	}
+	out := []historyResponse{}
+	for _, e := range entries {
+		out = append(out, historyResponse{
+			Timestamp:    e.Timestamp,
+			ModID:        e.ModID,
+			FromFileID:   e.FromFileID,
+			FromVersion:  e.FromVersion,
+			ToFileID:     e.ToFileID,
+			ToVersion:    e.ToVersion,
+			Result:       e.Result,
+			DurationSecs: e.Duration.Round(time.Millisecond).Seconds(),
+			Backup:       e.Backup,
+			Error:        e.Error,
+			Mods:         append([]history.ModChange{}, e.Mods...),
+		})
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func (a *api) listMods(c echo.Context) error {
+	out := []modResponse{}
+	for _, m := range a.editor.Current().Mods {
+		out = append(out, modResponse{ID: m.ID, Name: m.Name, Provider: m.ProviderName(), Project: m.Project})
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func (a *api) trackMod(c echo.Context) error {
+	var req modResponse
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if req.Provider != "" && req.Provider != provider.NameCurseForge {
+		return echo.NewHTTPError(http.StatusBadRequest, "only curseforge mods can be tracked here; add other providers to the config file")
+	}
+	if req.ID <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "id must be greater than 0")
+	}
+	if a.editor.Current().IsTracked(req.ID) {
+		return echo.NewHTTPError(http.StatusConflict, "mod is already tracked")
+	}
+	if req.Name == "" {
+		mod, err := curseforge.NewClientFromConfig(a.cfg).GetMod(req.ID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+		}
+		req.Name = mod.Name
+	}
+
+	_, err := a.editor.Update("api "+c.RealIP(), "mods.track", func(cfg *config.Config) ([]string, error) {
+		if cfg.IsTracked(req.ID) {
+			return nil, nil
+		}
+		cfg.Mods = append(cfg.Mods, config.ModConfig{ID: req.ID, Name: req.Name})
+		return []string{fmt.Sprintf("mods +%d %s", req.ID, req.Name)}, nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, req)
+}
+
+func (a *api) untrackMod(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || !a.editor.Current().IsTracked(id) {
+		return echo.NewHTTPError(http.StatusNotFound, "mod is not tracked")
+	}
+
+	_, err = a.editor.Update("api "+c.RealIP(), "mods.untrack", func(cfg *config.Config) ([]string, error) {
+		var changed []string
+		kept := cfg.Mods[:0]
+		for _, m := range cfg.Mods {
+			if m.ID == id {
+				changed = append(changed, fmt.Sprintf("mods -%d %s", m.ID, m.Name))
+				continue
+			}
+			kept = append(kept, m)
+		}
+		cfg.Mods = kept
+		return changed, nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// serverResponse describes the managed server process
+func (a *api) serverResponse() serverResponse {
+	return serverResponse{
+		Running:       a.minecraft.IsRunning(),
+		UptimeSeconds: a.minecraft.GetUptime().Round(time.Second).Seconds(),
+	}
+}
+
+// newBackupResponse converts a backup into its JSON shape
+func newBackupResponse(b *server.BackupInfo) backupResponse {
+	return backupResponse{
+		// CreateBackup reports the name without the archive extension; the file name
+		// is what restore expects
+		Name:       filepath.Base(b.Path),
+		Path:       b.Path,
+		Type:       b.Type,
+		SizeBytes:  b.Size,
+		Created:    b.Created,
+		Compressed: b.IsCompressed,
+		Encrypted:  b.Encrypted,
+		Protected:  b.Protected,
+
+		Trigger:      b.Trigger,
+		Version:      b.Version,
+		Checksum:     b.Checksum,
+		DurationSecs: b.Duration.Seconds(),
+	}
+}
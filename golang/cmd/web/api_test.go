@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/jobs"
+	"github.com/labstack/echo/v4"
+)
+
+func TestAPITokenInQuery(t *testing.T) {
+	queue, err := jobs.Open(filepath.Join(t.TempDir(), jobs.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := newTestAPI(t)
+	e := echo.New()
+	registerAPI(e, base.cfg, nil, nil, base.bus, nil, queue, nil, base.done)
+
+	tests := []struct {
+		name   string
+		path   string
+		header bool
+		want   int
+	}{
+		{"header", "/api/v1/status", true, http.StatusOK},
+		{"query on status", "/api/v1/status?token=" + testToken, false, http.StatusUnauthorized},
+		{"query on jobs", "/api/v1/jobs?token=" + testToken, false, http.StatusUnauthorized},
+		{"missing", "/api/v1/jobs", false, http.StatusUnauthorized},
+		// Past the token check, the backup is not found
+		{"query on download", "/api/v1/backups/nope.zip/download?token=" + testToken, false, http.StatusNotFound},
+		{"wrong query on download", "/api/v1/backups/nope.zip/download?token=guess", false, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+testToken)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET %s = %d, want %d: %s", tt.path, rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/approval"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/damianko135/curseforge-autoupdate/golang/views"
+	"github.com/labstack/echo/v4"
+)
+
+// approvalResponse is the JSON shape of one approval request
+type approvalResponse struct {
+	Server      string     `json:"server,omitempty"`
+	FileID      int        `json:"file_id"`
+	Version     string     `json:"version"`
+	FromVersion string     `json:"from_version"`
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	DecidedBy   string     `json:"decided_by"`
+	DecidedAt   *time.Time `json:"decided_at"`
+}
+
+// approvalEntries reads the approval request of every server that has one
+func approvalEntries(cfg *config.Config) ([]views.ApprovalEntry, error) {
+	now := time.Now()
+	var out []views.ApprovalEntry
+	for _, inst := range cfg.Instances() {
+		st, err := state.NewStore(filepath.Join(inst.DataDir, state.FileName)).Load()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", inst.InstanceName, err)
+		}
+		if st.Approval == nil {
+			continue
+		}
+		a := *st.Approval
+		out = append(out, views.ApprovalEntry{
+			Server:   inst.InstanceName,
+			Approval: a,
+			Expired:  a.Status == approval.StatusPending && !a.ExpiresAt.IsZero() && !now.Before(a.ExpiresAt),
+		})
+	}
+	return out, nil
+}
+
+// approvalsPage renders /approvals; the links in approval notifications open it with
+// ?decision= and ?server= to highlight the button to press
+func approvalsPage(cfg *config.Config) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		entries, err := approvalEntries(cfg)
+		if err != nil {
+			return err
+		}
+		return render(c, views.Approvals(views.ApprovalsPage{
+			Entries:    entries,
+			Decision:   c.QueryParam("decision"),
+			Server:     c.QueryParam("server"),
+			APIEnabled: cfg.Web.APIToken != "",
+		}))
+	}
+}
+
+func (a *api) listApprovals(c echo.Context) error {
+	entries, err := approvalEntries(a.cfg)
+	if err != nil {
+		return err
+	}
+	out := []approvalResponse{}
+	for _, e := range entries {
+		resp := approvalResponse{
+			Server:      e.Server,
+			FileID:      e.Approval.FileID,
+			Version:     e.Approval.Version,
+			FromVersion: e.Approval.FromVersion,
+			Status:      e.Approval.Status,
+			RequestedAt: e.Approval.RequestedAt,
+			DecidedBy:   e.Approval.DecidedBy,
+		}
+		if e.Expired {
+			resp.Status = approval.StatusExpired
+		}
+		if !e.Approval.ExpiresAt.IsZero() {
+			resp.ExpiresAt = &e.Approval.ExpiresAt
+		}
+		if !e.Approval.DecidedAt.IsZero() {
+			resp.DecidedAt = &e.Approval.DecidedAt
+		}
+		out = append(out, resp)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+// decideApproval approves or denies the pending update of ?server=, or of the only
+// server without [[servers]]
+func (a *api) decideApproval(approve bool) echo.HandlerFunc {
+	action := "approval.deny"
+	if approve {
+		action = "approval.approve"
+	}
+	return func(c echo.Context) error {
+		name := c.QueryParam("server")
+		inst := a.cfg
+		if name != "" || a.cfg.HasInstances() {
+			var err error
+			if inst, err = a.cfg.Instance(name); err != nil {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+		}
+
+		var version string
+		_, err := state.NewStore(filepath.Join(inst.DataDir, state.FileName)).Update(func(st *state.State) error {
+			if st.Approval != nil {
+				version = st.Approval.Version
+			}
+			return approval.Decide(st, approve, "api "+c.RealIP(), time.Now())
+		})
+		a.record(c, action, name, version, err)
+		switch {
+		case errors.Is(err, approval.ErrNoRequest):
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case err != nil:
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return a.listApprovals(c)
+	}
+}
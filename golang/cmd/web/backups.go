@@ -0,0 +1,107 @@
+package main
+
+import (
+	"archive/zip"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/views"
+	"github.com/labstack/echo/v4"
+)
+
+// validateResponse is the JSON shape of POST /api/v1/backups/:name/validate
+type validateResponse struct {
+	Name  string `json:"name"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// backupsPage renders /backups with every backup, newest first
+func backupsPage(cfg *config.Config) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		bm := server.NewBackupManager(cfg.ServerPath, cfg.BackupPath, cfg.Backup.Compression, cfg.Backup.RetentionDays)
+		backups, err := bm.ListBackups()
+		if err != nil {
+			return err
+		}
+		page := views.BackupsPage{APIEnabled: cfg.Web.APIToken != ""}
+		for _, b := range backups {
+			page.Backups = append(page.Backups, views.BackupSummary{
+				Name:      filepath.Base(b.Path),
+				Type:      b.Type,
+				SizeBytes: b.Size,
+				Created:   b.Created,
+				Protected: b.Protected,
+				Version:   b.Version,
+			})
+		}
+		return render(c, views.Backups(page))
+	}
+}
+
+// backup looks up the backup named in the path; names with a path in them are never found
+func (a *api) backup(c echo.Context) (*server.BackupInfo, error) {
+	name := c.Param("name")
+	if name != filepath.Base(name) {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "backup not found: "+name)
+	}
+	b, err := a.backups().GetBackupInfo(name)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return b, nil
+}
+
+func (a *api) deleteBackup(c echo.Context) error {
+	b, err := a.backup(c)
+	if err != nil {
+		return err
+	}
+	if !a.busy.TryLock() {
+		return errBusy
+	}
+	defer a.busy.Unlock()
+
+	err = a.backups().DeleteBackup(b.Name)
+	a.record(c, "backup.delete", b.Name, "", err)
+	if err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (a *api) validateBackup(c echo.Context) error {
+	b, err := a.backup(c)
+	if err != nil {
+		return err
+	}
+	resp := validateResponse{Name: b.Name, Valid: true}
+	if err := a.backups().ValidateBackup(b.Name); err != nil {
+		resp.Valid, resp.Error = false, err.Error()
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// downloadBackup sends a backup archive; uncompressed backups are zipped on the fly
+func (a *api) downloadBackup(c echo.Context) error {
+	b, err := a.backup(c)
+	if err != nil {
+		return err
+	}
+	if b.IsCompressed {
+		return c.Attachment(b.Path, b.Name)
+	}
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+b.Name+`.zip"`)
+	c.Response().WriteHeader(http.StatusOK)
+	zw := zip.NewWriter(c.Response())
+	if err := zw.AddFS(os.DirFS(b.Path)); err != nil {
+		// The status is sent already; a truncated archive is all the client can get
+		a.logger().Error("failed to send backup", "backup", b.Name, "error", err)
+		return nil
+	}
+	return zw.Close()
+}
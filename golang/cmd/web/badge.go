@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/labstack/echo/v4"
+)
+
+// Badge colours, as on shields.io
+const (
+	badgeGreen  = "#4c1"
+	badgeOrange = "#fe7d37"
+	badgeGrey   = "#9f9f9f"
+)
+
+// statusResponse is the JSON shape of GET /api/v1/status
+type statusResponse struct {
+	Server           string     `json:"server,omitempty"`
+	ModpackID        int        `json:"modpack_id"`
+	InstalledFileID  int        `json:"installed_file_id"`
+	InstalledVersion string     `json:"installed_version"`
+	LatestFileID     int        `json:"latest_file_id"`
+	LatestVersion    string     `json:"latest_version"`
+	LastCheckAt      *time.Time `json:"last_check_at"`
+	UpToDate         bool       `json:"up_to_date"`
+}
+
+// updateStatus reads the installed and latest known version of the [[servers]] entry
+// name, or of the only server without [[servers]]. Nothing is up to date before the first
+// check.
+func updateStatus(cfg *config.Config, name string) (statusResponse, error) {
+	inst := cfg
+	if name != "" || cfg.HasInstances() {
+		var err error
+		if inst, err = cfg.Instance(name); err != nil {
+			return statusResponse{}, echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+	}
+	st, err := state.NewStore(filepath.Join(inst.DataDir, state.FileName)).Load()
+	if err != nil {
+		return statusResponse{}, err
+	}
+	resp := statusResponse{
+		Server:           name,
+		ModpackID:        inst.ModpackID,
+		InstalledFileID:  st.InstalledFileID,
+		InstalledVersion: st.InstalledVersion,
+		LatestFileID:     st.LatestFileID,
+		LatestVersion:    st.LatestVersion,
+		UpToDate:         st.InstalledFileID != 0 && st.LatestFileID == st.InstalledFileID,
+	}
+	if !st.LastCheckAt.IsZero() {
+		resp.LastCheckAt = &st.LastCheckAt
+	}
+	return resp, nil
+}
+
+func (a *api) status(c echo.Context) error {
+	resp, err := updateStatus(a.cfg, c.QueryParam("server"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// badgeHandler serves /badge.svg without a token, so that it can be embedded on other
+// sites. ?label= replaces the "modpack" label.
+func badgeHandler(cfg *config.Config) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		status, err := updateStatus(cfg, c.QueryParam("server"))
+		if err != nil {
+			return err
+		}
+		label := c.QueryParam("label")
+		if label == "" {
+			label = "modpack"
+		}
+		message, color := "unknown", badgeGrey
+		switch {
+		case status.UpToDate:
+			message, color = status.InstalledVersion, badgeGreen
+		case status.InstalledFileID != 0 && status.LatestFileID != 0:
+			message, color = fmt.Sprintf("%s (%s available)", status.InstalledVersion, status.LatestVersion), badgeOrange
+		case status.InstalledVersion != "":
+			message = status.InstalledVersion
+		}
+		// Image proxies such as GitHub's would otherwise keep showing an old version
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache, max-age=0")
+		return c.Blob(http.StatusOK, "image/svg+xml", []byte(badgeSVG(label, message, color)))
+	}
+}
+
+// badgeSVG draws a flat shields.io-style badge. Text widths are estimated, as the
+// font is up to the browser.
+func badgeSVG(label, message, color string) string {
+	textWidth := func(s string) int { return 7*utf8.RuneCountInString(s) + 10 }
+	lw, mw := textWidth(label), textWidth(message)
+	w := lw + mw
+	label, message = html.EscapeString(label), html.EscapeString(message)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">`+
+		`<title>%[4]s: %[5]s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text><text x="%[7]d" y="14">%[4]s</text>`+
+		`<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[5]s</text><text x="%[8]d" y="14">%[5]s</text>`+
+		`</g></svg>`,
+		w, lw, mw, label, message, color, lw/2, lw+mw/2)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	curseforge "github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/views" //nolint:all
+	"github.com/labstack/echo/v4"
+)
+
+// browsePageSize is how many search results are shown
+const browsePageSize = 20
+
+// browseLoaders are the mod loaders offered as a search filter
+var browseLoaders = []int{
+	curseforge.ModLoaderTypeAny,
+	curseforge.ModLoaderTypeForge,
+	curseforge.ModLoaderTypeNeoForge,
+	curseforge.ModLoaderTypeFabric,
+	curseforge.ModLoaderTypeQuilt,
+}
+
+// browsePage serves /browse, a CurseForge search whose results can be tracked
+type browsePage struct {
+	client   *curseforge.Client
+	editor   *configEditor
+	canTrack bool
+
+	mu         sync.Mutex
+	categories []curseforge.Category // of Minecraft, loaded on first use
+}
+
+// registerBrowse mounts the mod browser; tracking goes through POST /api/v1/mods
+func registerBrowse(e *echo.Echo, cfg *config.Config, editor *configEditor) {
+	b := &browsePage{
+		client:   curseforge.NewClientFromConfig(cfg),
+		editor:   editor,
+		canTrack: cfg.Web.APIToken != "",
+	}
+	e.GET("/browse", b.show)
+}
+
+func (b *browsePage) show(c echo.Context) error {
+	cfg := b.editor.Current()
+	page := views.BrowsePage{
+		Query:       strings.TrimSpace(c.QueryParam("q")),
+		GameVersion: cfg.GameVersion,
+		CanTrack:    b.canTrack,
+	}
+	if c.QueryParams().Has("game_version") {
+		page.GameVersion = strings.TrimSpace(c.QueryParam("game_version"))
+	}
+	page.Loader, _ = strconv.Atoi(c.QueryParam("loader"))
+	for _, loader := range browseLoaders {
+		page.Loaders = append(page.Loaders, views.BrowseOption{Value: loader, Label: curseforge.ModLoaderName(loader)})
+	}
+	page.Class = curseforge.ClassIDMods
+	if class, err := strconv.Atoi(c.QueryParam("class")); err == nil && class > 0 {
+		page.Class = class
+	}
+	page.Category, _ = strconv.Atoi(c.QueryParam("category"))
+	page.Classes, page.Categories = browseCategories(b.loadCategories(), page.Class)
+	if !hasOption(page.Categories, page.Category) {
+		// The category belongs to the class that was selected before
+		page.Category = 0
+	}
+	page.Trackable = page.Class == curseforge.ClassIDMods
+
+	if page.Query != "" || page.Category > 0 {
+		mods, err := b.client.SearchMods(curseforge.GameIDMinecraft, page.Class, page.Category, page.Query, curseforge.SortFieldPopularity, "desc",
+			page.GameVersion, page.Loader, browsePageSize, 0)
+		if err != nil {
+			page.Error = err.Error()
+		}
+		for _, mod := range mods {
+			page.Results = append(page.Results, newBrowseResult(&mod, cfg.IsTracked(mod.ID)))
+		}
+	}
+	return render(c, views.Browse(page))
+}
+
+// loadCategories returns the classes and categories of Minecraft. They are fetched once;
+// when that fails the page is shown without the filters and the next request tries again.
+func (b *browsePage) loadCategories() []curseforge.Category {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.categories == nil {
+		categories, err := b.client.GetCategories(curseforge.GameIDMinecraft, 0)
+		if err != nil {
+			slog.Warn("failed to load CurseForge categories", "error", err)
+			return nil
+		}
+		b.categories = categories
+	}
+	return b.categories
+}
+
+// browseCategories returns the classes and the categories of class as select options,
+// sorted by name. The category options start with "Any category".
+func browseCategories(categories []curseforge.Category, class int) (classes, inClass []views.BrowseOption) {
+	for _, cat := range categories {
+		switch {
+		case cat.IsClass:
+			classes = append(classes, views.BrowseOption{Value: cat.ID, Label: cat.Name})
+		case cat.ClassID == class:
+			inClass = append(inClass, views.BrowseOption{Value: cat.ID, Label: cat.Name})
+		}
+	}
+	byName := func(options []views.BrowseOption) {
+		sort.Slice(options, func(i, j int) bool { return options[i].Label < options[j].Label })
+	}
+	byName(classes)
+	byName(inClass)
+	if len(inClass) > 0 {
+		inClass = append([]views.BrowseOption{{Value: 0, Label: "Any category"}}, inClass...)
+	}
+	return classes, inClass
+}
+
+// hasOption reports whether value is one of options
+func hasOption(options []views.BrowseOption, value int) bool {
+	for _, o := range options {
+		if o.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// newBrowseResult converts a search hit into its view model
+func newBrowseResult(mod *curseforge.ModInfo, tracked bool) views.BrowseResult {
+	result := views.BrowseResult{
+		ID:        mod.ID,
+		Name:      mod.Name,
+		Summary:   mod.Summary,
+		URL:       mod.Links.WebsiteURL,
+		Downloads: formatCount(mod.DownloadCount),
+		Tracked:   tracked,
+	}
+	if mod.Logo != nil {
+		result.LogoURL = mod.Logo.ThumbnailURL
+	}
+	var authors []string
+	for _, a := range mod.Authors {
+		authors = append(authors, a.Name)
+	}
+	result.Authors = strings.Join(authors, ", ")
+	return result
+}
+
+// formatCount shortens large counts, e.g. 12.3M
+func formatCount(n int64) string {
+	switch {
+	case n >= 1_000_000_000:
+		return fmt.Sprintf("%.1fB", float64(n)/1e9)
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1e3)
+	}
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/audit"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/rcon"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// consoleBuffer is how many log lines a slow browser may fall behind before lines are dropped
+	consoleBuffer = 256
+	// consoleTail is how many earlier log lines are shown when the console opens for a
+	// server whose logs are read from Docker, journald and the like
+	consoleTail = 200
+	// consoleRCONTimeout limits connecting to RCON and each command
+	consoleRCONTimeout = 10 * time.Second
+)
+
+// consoleMessage is the JSON shape of the messages on /api/v1/console. The browser sends
+// commands; the server sends log lines, replies to RCON commands and errors.
+type consoleMessage struct {
+	Type    string `json:"type"` // command, log, reply or error
+	Line    string `json:"line,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// console streams the server log over a WebSocket and runs the commands it receives. The
+// log comes from the process the web UI started or from the controller's LogStreamer;
+// commands go to the process' console, or over RCON when server.rcon.address is set.
+func (a *api) console(c echo.Context) error {
+	actor := "api " + c.RealIP()
+	websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+		ctx, cancel := context.WithCancel(c.Request().Context())
+		defer cancel()
+		go func() {
+			// Receive below only returns once the connection is closed
+			select {
+			case <-ctx.Done():
+			case <-a.done:
+			}
+			_ = ws.Close()
+		}()
+
+		var mu sync.Mutex
+		send := func(msg consoleMessage) {
+			mu.Lock()
+			defer mu.Unlock()
+			_ = websocket.JSON.Send(ws, msg)
+		}
+		go a.streamConsole(ctx, send)
+
+		for {
+			var msg consoleMessage
+			if err := websocket.JSON.Receive(ws, &msg); err != nil {
+				return
+			}
+			command := strings.TrimSpace(msg.Command)
+			if msg.Type != "command" || command == "" {
+				continue
+			}
+			reply, err := a.runConsoleCommand(actor, command)
+			switch {
+			case err != nil:
+				send(consoleMessage{Type: "error", Line: err.Error()})
+			case reply != "":
+				send(consoleMessage{Type: "reply", Line: reply})
+			}
+		}
+	}).ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// streamConsole sends the server log to send until ctx is done
+func (a *api) streamConsole(ctx context.Context, send func(consoleMessage)) {
+	if mc, ok := a.minecraft.(*server.MinecraftServer); ok {
+		history, lines, cancel := mc.SubscribeLogs(consoleBuffer)
+		defer cancel()
+		for _, line := range history {
+			send(consoleMessage{Type: "log", Line: line})
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case line := <-lines:
+				send(consoleMessage{Type: "log", Line: line})
+			}
+		}
+	}
+
+	streamer, ok := a.minecraft.(server.LogStreamer)
+	if !ok {
+		send(consoleMessage{Type: "error", Line: "the server log is not available in server.mode " + a.cfg.Server.Mode})
+		return
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(streamer.Logs(ctx, pw, consoleTail, true))
+	}()
+	defer pr.Close()
+	scanner := bufio.NewScanner(pr)
+	for scanner.Scan() {
+		send(consoleMessage{Type: "log", Line: scanner.Text()})
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil && !errors.Is(err, context.Canceled) {
+		send(consoleMessage{Type: "error", Line: "log stream ended: " + err.Error()})
+	}
+}
+
+// runConsoleCommand runs command on the server and records it in the audit log. RCON
+// returns the reply; the process console answers in the log instead.
+func (a *api) runConsoleCommand(actor, command string) (string, error) {
+	var reply string
+	var err error
+	target := "rcon " + a.cfg.Server.RCON.Address
+	if mc, ok := a.minecraft.(*server.MinecraftServer); ok {
+		target = "process console"
+		err = mc.SendCommand(command)
+	} else if a.cfg.Server.RCON.Address != "" {
+		reply, err = rconCommand(a.cfg.Server.RCON.Address, a.cfg.Server.RCON.Password, command)
+	} else {
+		return "", errors.New("commands need server.rcon.address unless the web UI started the server")
+	}
+
+	entry := audit.Entry{Actor: actor, Action: "console.command", Target: target, Result: audit.ResultSuccess, Details: command}
+	if err != nil {
+		entry.Result = audit.ResultFailed
+		entry.Details += ": " + err.Error()
+	}
+	audit.Publish(a.bus, entry)
+	slog.Info("console command", "actor", actor, "command", command, "error", err)
+	return reply, err
+}
+
+// rconCommand runs command over RCON and returns the reply
+func rconCommand(address, password, command string) (string, error) {
+	c, err := rcon.Dial(address, password, consoleRCONTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+	return c.Command(command)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/audit"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+)
+
+// configEditor serialises changes to the config file made from the web UI.
+// Saved changes are picked up by a running daemon; the rest of the web process
+// keeps the settings it started with until it is restarted.
+type configEditor struct {
+	path string
+	bus  *events.Bus // audit entries are published here
+
+	mu  sync.Mutex
+	cfg *config.Config
+}
+
+// newConfigEditor edits cfg.File, or path when the config came from the environment only,
+// and publishes the changes it makes to bus for the audit log
+func newConfigEditor(cfg *config.Config, path string, bus *events.Bus) *configEditor {
+	if cfg.File != "" {
+		path = cfg.File
+	}
+	return &configEditor{path: path, bus: bus, cfg: cfg}
+}
+
+// Current returns the config as last saved
+func (e *configEditor) Current() *config.Config {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.cfg
+}
+
+// Update applies change to a copy of the config, validates it, writes the settings it
+// changed to the file and records the action in the audit log. Settings taken from the
+// environment are only written when they were changed. change returns the keys it
+// changed; nothing is written when there are none.
+func (e *configEditor) Update(actor, action string, change func(cfg *config.Config) ([]string, error)) ([]string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	updated := *e.cfg
+	updated.Mods = append([]config.ModConfig(nil), e.cfg.Mods...)
+	changed, err := change(&updated)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(&updated); err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	entry := audit.Entry{
+		Actor:   actor,
+		Action:  action,
+		Target:  e.path,
+		Result:  audit.ResultSuccess,
+		Details: strings.Join(changed, ", "),
+	}
+	err = config.SaveChanges(e.cfg, &updated, e.path)
+	if err != nil {
+		entry.Result = audit.ResultFailed
+		entry.Details += ": " + err.Error()
+	}
+	audit.Publish(e.bus, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("config saved", "file", e.path, "action", action, "changed", changed)
+	e.cfg = &updated
+	return changed, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/audit"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/eventsink"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/notification"
+	"github.com/labstack/echo/v4"
+)
+
+// sseHeartbeat keeps idle event streams from being closed by proxies
+const sseHeartbeat = 15 * time.Second
+
+// sseBuffer is how many events a slow client may fall behind before events are dropped
+const sseBuffer = 64
+
+// subscribeEvents connects the subsystems that react to what happens on bus: the audit
+// log, the notifications, the event sink if there is one and the event counts of the
+// metrics. The event stream of the API subscribes for each client.
+func subscribeEvents(cfg *config.Config, bus *events.Bus, sink *eventsink.Sink) *eventCounts {
+	audit.NewLog(filepath.Join(cfg.DataDir, audit.FileName)).Subscribe(bus, func(err error) {
+		slog.Error("failed to write audit log", "error", err)
+	})
+
+	notify := notification.NewManager(&cfg.Notifications)
+	notify.SetHTTPClient(httpclient.New(cfg.HTTP))
+	name := func() string { return fmt.Sprintf("Mod %d", cfg.ModpackID) }
+	notify.Subscribe(bus, name, func(event string, err error) {
+		if err != nil {
+			slog.Warn("failed to send notification", "notification", event, "error", err)
+		} else if notify.IsEnabled() {
+			slog.Info("notification sent", "notification", event)
+		}
+	})
+	if sink != nil {
+		sink.Subscribe(bus)
+	}
+
+	return countEvents(bus)
+}
+
+// eventCounts counts events for the metrics, since the web UI started
+type eventCounts struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// countEvents counts installed and failed updates, new backups, and server starts and
+// failures
+func countEvents(bus *events.Bus) *eventCounts {
+	c := &eventCounts{counts: make(map[string]int)}
+	bus.Subscribe(func(e events.Event) {
+		// Updates that found nothing to install and clean exits are not counted
+		if skipped, _ := e.Data["skipped"].(bool); skipped && e.Type == events.UpdateFinished {
+			return
+		}
+		if _, failed := e.Data["error"]; !failed && e.Type == events.ServerStopped {
+			return
+		}
+		c.mu.Lock()
+		c.counts[e.Type]++
+		c.mu.Unlock()
+	}, events.UpdateFinished, events.UpdateFailed, events.BackupCreated, events.ServerStarted, events.ServerStopped)
+	return c
+}
+
+// get returns how many events of eventType were counted
+func (c *eventCounts) get(eventType string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[eventType]
+}
+
+// events streams bus events to the client as Server-Sent Events
+func (a *api) events(c echo.Context) error {
+	ch := make(chan events.Event, sseBuffer)
+	unsubscribe := a.bus.Subscribe(func(event events.Event) {
+		// The bus is synchronous; never let a slow browser stall an update
+		select {
+		case ch <- event:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-a.done:
+			// Let the server shut down instead of waiting for the browser to disconnect
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case event := <-ch:
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return nil
+			}
+		}
+		w.Flush()
+	}
+}
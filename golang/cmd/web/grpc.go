@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/damianko135/curseforge-autoupdate/golang/pkg/controlpb"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// controlServer implements the gRPC control API of pkg/controlpb on top of the REST API,
+// so that both run the same checks, updates and backups and share its busy lock
+type controlServer struct {
+	controlpb.UnimplementedControlServer
+	api *api
+}
+
+// serveGRPC starts the gRPC control API on web.grpc_listen, with TLS when web.tls_cert is
+// set, and sends the error it stops with to errc. It returns nil when the API is disabled.
+func serveGRPC(a *api, errc chan<- error) (*grpc.Server, error) {
+	cfg := a.cfg
+	if cfg.Web.GRPCListen == "" {
+		return nil, nil
+	}
+	if cfg.Web.APIToken == "" {
+		slog.Warn("gRPC control API disabled: web.api_token is not set")
+		return nil, nil
+	}
+
+	var opts []grpc.ServerOption
+	if cfg.Web.TLSCert != "" {
+		creds, err := credentials.NewServerTLSFromFile(cfg.Web.TLSCert, cfg.Web.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the TLS certificate: %w", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	ln, err := net.Listen("tcp", cfg.Web.GRPCListen)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newGRPCServer(a, opts...)
+	go func() {
+		slog.Info("gRPC control API listening", "listen", cfg.Web.GRPCListen, "tls", cfg.Web.TLSCert != "")
+		errc <- g.Serve(ln)
+	}()
+	return g, nil
+}
+
+// newGRPCServer creates the gRPC server of the control API, which only answers clients
+// that pass web.api_token
+func newGRPCServer(a *api, opts ...grpc.ServerOption) *grpc.Server {
+	s := &controlServer{api: a}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := s.authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	g := grpc.NewServer(opts...)
+	controlpb.RegisterControlServer(g, s)
+	return g
+}
+
+// stopGRPC lets the calls in flight finish until ctx ends, then cancels them
+func stopGRPC(ctx context.Context, g *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		g.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		g.Stop()
+	}
+}
+
+// authorize checks the "authorization: Bearer" metadata against web.api_token
+func (s *controlServer) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.api.cfg.Web.APIToken)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid API token")
+}
+
+// grpcActor names the client of ctx in the audit log
+func grpcActor(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "grpc"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "grpc " + host
+}
+
+// grpcError converts an error of the REST handlers to a gRPC status
+func grpcError(err error) error {
+	if errors.Is(err, updater.ErrMinecraftUpgrade) {
+		return status.Error(codes.FailedPrecondition, err.Error()+"; set allow_mc_upgrade to install it")
+	}
+	if err == errBusy {
+		return status.Error(codes.Aborted, fmt.Sprint(errBusy.Message))
+	}
+	var httpErr *echo.HTTPError
+	if !errors.As(err, &httpErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code := codes.Internal
+	switch httpErr.Code {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.FailedPrecondition
+	case http.StatusBadGateway:
+		code = codes.Unavailable
+	}
+	return status.Error(code, fmt.Sprint(httpErr.Message))
+}
+
+func (s *controlServer) GetStatus(ctx context.Context, req *controlpb.GetStatusRequest) (*controlpb.Status, error) {
+	st, err := updateStatus(s.api.cfg, req.GetServer())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &controlpb.Status{
+		Server:           st.Server,
+		ModpackId:        int64(st.ModpackID),
+		InstalledFileId:  int64(st.InstalledFileID),
+		InstalledVersion: st.InstalledVersion,
+		LatestFileId:     int64(st.LatestFileID),
+		LatestVersion:    st.LatestVersion,
+		UpToDate:         st.UpToDate,
+	}
+	if st.LastCheckAt != nil {
+		resp.LastCheckAt = timestamppb.New(*st.LastCheckAt)
+	}
+	return resp, nil
+}
+
+func (s *controlServer) Check(ctx context.Context, _ *controlpb.CheckRequest) (*controlpb.CheckResult, error) {
+	check, err := s.api.runCheck(s.api.logger())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &controlpb.CheckResult{
+		ModId:            int64(check.ModID),
+		InstalledFileId:  int64(check.InstalledFileID),
+		InstalledVersion: check.InstalledVersion,
+		LatestFileId:     int64(check.LatestFileID),
+		LatestVersion:    check.LatestVersion,
+		LatestFileDate:   timestamppb.New(check.LatestFileDate),
+		UpdateAvailable:  check.UpdateAvailable,
+		MinecraftUpgrade: minecraftUpgrade(check.MinecraftUpgrade),
+	}
+	for _, h := range check.HeldBack {
+		resp.HeldBack = append(resp.HeldBack, &controlpb.HeldFile{Version: h.Version, Channel: h.Channel, Published: timestamppb.New(h.Published), Reason: h.Reason})
+	}
+	if h := check.HeldForReview; h != nil {
+		resp.HeldForReview = &controlpb.HoldRuleMatch{Rule: h.Rule, Field: h.Field, Match: h.Match}
+	}
+	for _, ch := range check.Channels {
+		resp.Channels = append(resp.Channels, &controlpb.ChannelStatus{
+			Channel:         ch.Channel,
+			FileId:          int64(ch.FileID),
+			Version:         ch.Version,
+			Published:       timestamppb.New(ch.Published),
+			UpdateAvailable: ch.UpdateAvailable,
+			Tracked:         ch.Tracked,
+		})
+	}
+	return resp, nil
+}
+
+func (s *controlServer) Update(ctx context.Context, req *controlpb.UpdateRequest) (*controlpb.UpdateResult, error) {
+	if !s.api.busy.TryLock() {
+		return nil, grpcError(errBusy)
+	}
+	defer s.api.busy.Unlock()
+
+	update, err := s.api.runUpdate(ctx, grpcActor(ctx), s.api.logger(), req.GetForce(), req.GetAllowMcUpgrade())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &controlpb.UpdateResult{
+		ModId:            int64(update.ModID),
+		FromFileId:       int64(update.FromFileID),
+		FromVersion:      update.FromVersion,
+		ToFileId:         int64(update.ToFileID),
+		ToVersion:        update.ToVersion,
+		Backup:           update.Backup,
+		DownloadedFile:   update.DownloadedFile,
+		DurationSeconds:  update.DurationSecs,
+		Skipped:          update.Skipped,
+		Preserved:        update.Preserved,
+		MinecraftUpgrade: minecraftUpgrade(update.MinecraftUpgrade),
+	}
+	for _, m := range update.Merged {
+		resp.Merged = append(resp.Merged, &controlpb.PropertiesMerge{File: m.File, FromPack: m.FromPack, Removed: m.Removed, Conflicts: m.Conflicts})
+	}
+	for _, m := range update.Mods {
+		resp.Mods = append(resp.Mods, &controlpb.ModChange{ProjectId: int64(m.ProjectID), Name: m.Name, FromVersion: m.FromVersion, ToVersion: m.ToVersion, Changelog: m.Changelog})
+	}
+	if r := update.Startup; r != nil {
+		resp.Startup = &controlpb.StartupReport{
+			Done:           r.Done,
+			StartupSeconds: r.StartupTime.Seconds(),
+			Crashes:        int32(r.Crashes),
+			CrashReports:   r.CrashReports,
+			Errors:         r.Errors,
+		}
+	}
+	for _, t := range update.Tasks {
+		resp.Tasks = append(resp.Tasks, &controlpb.TaskResult{Name: t.Name, Command: t.Command, DurationSeconds: t.Duration.Seconds(), Skipped: t.Skipped, Error: t.Error})
+	}
+	if sync := update.Sync; sync != nil {
+		resp.Sync = &controlpb.ModSync{
+			Downloaded:    sync.Downloaded,
+			Removed:       sync.Removed,
+			Skipped:       sync.Skipped,
+			Overrides:     int32(sync.Overrides),
+			DownloadBytes: sync.DownloadBytes,
+		}
+	}
+	return resp, nil
+}
+
+func (s *controlServer) ListBackups(ctx context.Context, _ *controlpb.ListBackupsRequest) (*controlpb.ListBackupsResponse, error) {
+	backups, err := s.api.backups().ListBackups()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &controlpb.ListBackupsResponse{}
+	for _, b := range backups {
+		backup := newBackupResponse(&b)
+		resp.Backups = append(resp.Backups, backupMessage(&backup))
+	}
+	return resp, nil
+}
+
+func (s *controlServer) CreateBackup(ctx context.Context, req *controlpb.CreateBackupRequest) (*controlpb.Backup, error) {
+	backupReq := backupRequest{Name: req.GetName(), Type: req.GetType()}
+	if err := backupReq.validate(); err != nil {
+		return nil, grpcError(err)
+	}
+	if !s.api.busy.TryLock() {
+		return nil, grpcError(errBusy)
+	}
+	defer s.api.busy.Unlock()
+
+	backup, err := s.api.runBackup(ctx, grpcActor(ctx), s.api.logger(), backupReq)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return backupMessage(backup), nil
+}
+
+// WatchEvents streams bus events to the client, like the events endpoint of the REST API
+func (s *controlServer) WatchEvents(req *controlpb.WatchEventsRequest, stream grpc.ServerStreamingServer[controlpb.Event]) error {
+	ch := make(chan events.Event, sseBuffer)
+	unsubscribe := s.api.bus.Subscribe(func(event events.Event) {
+		// The bus is synchronous; never let a slow client stall an update
+		select {
+		case ch <- event:
+		default:
+		}
+	}, req.GetTypes()...)
+	defer unsubscribe()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.api.done:
+			// Let the server shut down instead of waiting for the client to disconnect
+			return nil
+		case event := <-ch:
+			msg, err := eventMessage(event)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// eventMessage converts an event, passing its data through JSON as GET /api/v1/events does
+func eventMessage(event events.Event) (*controlpb.Event, error) {
+	msg := &controlpb.Event{Type: event.Type, Time: timestamppb.New(event.Time)}
+	if len(event.Data) == 0 {
+		return msg, nil
+	}
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return nil, err
+	}
+	msg.Data = &structpb.Struct{}
+	if err := msg.Data.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// minecraftUpgrade converts an upgrade, which is nil when there is none
+func minecraftUpgrade(m *updater.MinecraftUpgrade) *controlpb.MinecraftUpgrade {
+	if m == nil {
+		return nil
+	}
+	return &controlpb.MinecraftUpgrade{From: m.From, To: m.To}
+}
+
+// backupMessage converts a backup from its JSON shape
+func backupMessage(b *backupResponse) *controlpb.Backup {
+	return &controlpb.Backup{
+		Name:            b.Name,
+		Path:            b.Path,
+		Type:            b.Type,
+		SizeBytes:       b.SizeBytes,
+		Created:         timestamppb.New(b.Created),
+		Compressed:      b.Compressed,
+		Encrypted:       b.Encrypted,
+		Protected:       b.Protected,
+		Trigger:         b.Trigger,
+		Version:         b.Version,
+		Checksum:        b.Checksum,
+		DurationSeconds: b.DurationSecs,
+	}
+}
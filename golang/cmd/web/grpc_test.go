@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/damianko135/curseforge-autoupdate/golang/pkg/controlpb"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testToken = "s3cret"
+
+// newTestControl serves the control API of a over an in-memory connection and returns a
+// client of it
+func newTestControl(t *testing.T, a *api) controlpb.ControlClient {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	g := newGRPCServer(a)
+	go func() { _ = g.Serve(ln) }()
+	t.Cleanup(g.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return controlpb.NewControlClient(conn)
+}
+
+// newTestAPI returns an api over a server, backup and data directory of its own
+func newTestAPI(t *testing.T) *api {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		ModpackID:  1234,
+		ServerPath: filepath.Join(dir, "server"),
+		BackupPath: filepath.Join(dir, "backups"),
+		DataDir:    filepath.Join(dir, "data"),
+	}
+	cfg.Web.APIToken = testToken
+	return &api{cfg: cfg, bus: events.NewBus(clock.Real()), done: make(chan struct{})}
+}
+
+// withToken returns ctx with token as the bearer token of the calls made with it, or
+// without one when token is empty
+func withToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func TestGRPCAuthorize(t *testing.T) {
+	a := newTestAPI(t)
+	client := newTestControl(t, a)
+	tests := []struct {
+		name  string
+		token string
+		want  codes.Code
+	}{
+		{"missing", "", codes.Unauthenticated},
+		{"wrong", "guess", codes.Unauthenticated},
+		{"correct", testToken, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(withToken(context.Background(), tt.token), 5*time.Second)
+			defer cancel()
+
+			_, err := client.GetStatus(ctx, &controlpb.GetStatusRequest{})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("unary call = %v, want %v", err, tt.want)
+			}
+
+			stream, err := client.WatchEvents(ctx, &controlpb.WatchEventsRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == codes.OK {
+				go publishUntilDone(ctx, a.bus)
+			}
+			if _, err := stream.Recv(); status.Code(err) != tt.want {
+				t.Errorf("stream = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCWatchEvents(t *testing.T) {
+	a := newTestAPI(t)
+	client := newTestControl(t, a)
+	ctx, cancel := context.WithTimeout(withToken(context.Background(), testToken), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchEvents(ctx, &controlpb.WatchEventsRequest{Types: []string{events.UpdateProgress}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go publishUntilDone(ctx, a.bus)
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.GetType() != events.UpdateProgress || event.GetData().AsMap()["phase"] != "download" {
+		t.Errorf("event = %v", event)
+	}
+}
+
+// publishUntilDone publishes download progress until ctx is done, since a stream only
+// subscribes to the bus once its call arrives
+func publishUntilDone(ctx context.Context, bus *events.Bus) {
+	for ctx.Err() == nil {
+		bus.Publish(events.UpdateProgress, map[string]interface{}{"phase": "download", "percent": 40})
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGRPCGetStatus(t *testing.T) {
+	a := newTestAPI(t)
+	if err := state.NewStore(filepath.Join(a.cfg.DataDir, state.FileName)).Save(&state.State{
+		InstalledFileID:  100,
+		InstalledVersion: "1.0.0",
+		LatestFileID:     101,
+		LatestVersion:    "1.1.0",
+		LastCheckAt:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	client := newTestControl(t, a)
+
+	st, err := client.GetStatus(withToken(context.Background(), testToken), &controlpb.GetStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.GetModpackId() != 1234 || st.GetInstalledFileId() != 100 || st.GetLatestVersion() != "1.1.0" || st.GetUpToDate() {
+		t.Errorf("status = %v", st)
+	}
+	if !st.GetLastCheckAt().AsTime().Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("last check = %v", st.GetLastCheckAt().AsTime())
+	}
+
+	// Instances are only known with a servers list
+	_, err = client.GetStatus(withToken(context.Background(), testToken), &controlpb.GetStatusRequest{Server: "nope"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("GetStatus of an unknown server = %v, want NotFound", err)
+	}
+}
+
+func TestGRPCListBackups(t *testing.T) {
+	a := newTestAPI(t)
+	if err := os.MkdirAll(a.cfg.ServerPath, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(a.cfg.ServerPath, "server.properties"), []byte("motd=hi\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := server.NewBackupManager(a.cfg.ServerPath, a.cfg.BackupPath, true, 0).CreateManualBackup("nightly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestControl(t, a)
+
+	resp, err := client.ListBackups(withToken(context.Background(), testToken), &controlpb.ListBackupsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.GetBackups()) != 1 {
+		t.Fatalf("backups = %v", resp.GetBackups())
+	}
+	if b := resp.GetBackups()[0]; b.GetName() != filepath.Base(backup.Path) || !b.GetCompressed() || b.GetSizeBytes() == 0 {
+		t.Errorf("backup = %v", b)
+	}
+}
+
+func TestGRPCUpdateBusy(t *testing.T) {
+	a := newTestAPI(t)
+	client := newTestControl(t, a)
+	a.busy.Lock()
+	defer a.busy.Unlock()
+
+	_, err := client.Update(withToken(context.Background(), testToken), &controlpb.UpdateRequest{})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("Update while busy = %v, want Aborted", err)
+	}
+}
+
+func TestGRPCError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{errBusy, codes.Aborted},
+		{fmt.Errorf("1.20.1 to 1.21: %w", updater.ErrMinecraftUpgrade), codes.FailedPrecondition},
+		{echo.NewHTTPError(http.StatusBadRequest, "bad name"), codes.InvalidArgument},
+		{echo.NewHTTPError(http.StatusNotFound, "no such backup"), codes.NotFound},
+		{echo.NewHTTPError(http.StatusConflict, "stop the server first"), codes.FailedPrecondition},
+		{echo.NewHTTPError(http.StatusBadGateway, "CurseForge is down"), codes.Unavailable},
+		{fmt.Errorf("disk full"), codes.Internal},
+	}
+	for _, tt := range tests {
+		if got := status.Code(grpcError(tt.err)); got != tt.want {
+			t.Errorf("grpcError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
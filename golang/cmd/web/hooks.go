@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// hookBodyLimit caps the request body the webhook receiver reads
+const hookBodyLimit = 1 << 20
+
+// hookSignatureHeader carries the HMAC-SHA256 of the body as "sha256=<hex>", the way
+// GitHub and Gitea sign their webhooks
+const hookSignatureHeader = "X-Hub-Signature-256"
+
+// hookSecretHeader carries web.webhook_secret itself, for senders that cannot sign
+const hookSecretHeader = "X-Webhook-Secret"
+
+// hook queues the check, update or backup named by :action for an external system, such
+// as CI, a chat bot or monitoring, and answers at once with the job. The optional JSON
+// body takes the fields of POST /api/v1/jobs for that kind, e.g. {"force": true}.
+func (a *api) hook(c echo.Context) error {
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, hookBodyLimit))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to read the request body")
+	}
+	if !hookAuthorized(c.Request().Header, body, a.cfg.Web.WebhookSecret) {
+		slog.Warn("webhook rejected: missing or invalid secret", "remote", c.RealIP(), "action", c.Param("action"))
+		return echo.NewHTTPError(http.StatusUnauthorized, "missing or invalid webhook secret")
+	}
+
+	action := c.Param("action")
+	switch action {
+	case "check", "update", "backup":
+	default:
+		return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("unknown webhook action %q, want check, update or backup", action))
+	}
+	var req jobRequest
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) && len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		}
+	}
+	req.Kind = action
+
+	who := "webhook " + c.RealIP()
+	// A request submit turns down never became a job, so there is nothing to audit
+	job, err := a.submit(req, who)
+	if err != nil {
+		return err
+	}
+	a.recordAs(who, "webhook."+action, job.ID, "", nil)
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// hookAuthorized reports whether a webhook request proves it knows secret, either by
+// signing body with it or by sending it as X-Webhook-Secret or a bearer token
+func hookAuthorized(h http.Header, body []byte, secret string) bool {
+	if signature, ok := strings.CutPrefix(h.Get(hookSignatureHeader), "sha256="); ok {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		want := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(strings.ToLower(signature)), []byte(want))
+	}
+	given := h.Get(hookSecretHeader)
+	if given == "" {
+		given, _ = strings.CutPrefix(h.Get(echo.HeaderAuthorization), "Bearer ")
+	}
+	return given != "" && subtle.ConstantTimeCompare([]byte(given), []byte(secret)) == 1
+}
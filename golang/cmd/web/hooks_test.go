@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/jobs"
+	"github.com/labstack/echo/v4"
+)
+
+const testHookSecret = "hook-secret"
+
+// sign returns the X-Hub-Signature-256 value of body under secret
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHookAuthorized(t *testing.T) {
+	body := `{"force":true}`
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   bool
+	}{
+		{"valid signature", map[string]string{hookSignatureHeader: sign(testHookSecret, body)}, true},
+		{"upper case signature", map[string]string{hookSignatureHeader: "sha256=" + strings.ToUpper(strings.TrimPrefix(sign(testHookSecret, body), "sha256="))}, true},
+		{"signature of another body", map[string]string{hookSignatureHeader: sign(testHookSecret, "{}")}, false},
+		{"signature with another secret", map[string]string{hookSignatureHeader: sign("guess", body)}, false},
+		{"empty signature", map[string]string{hookSignatureHeader: "sha256="}, false},
+		// A signature that does not verify is not saved by a correct secret header
+		{"invalid signature and secret header", map[string]string{hookSignatureHeader: sign("guess", body), hookSecretHeader: testHookSecret}, false},
+		{"secret header", map[string]string{hookSecretHeader: testHookSecret}, true},
+		{"wrong secret header", map[string]string{hookSecretHeader: "guess"}, false},
+		{"bearer token", map[string]string{echo.HeaderAuthorization: "Bearer " + testHookSecret}, true},
+		{"wrong bearer token", map[string]string{echo.HeaderAuthorization: "Bearer guess"}, false},
+		{"basic auth", map[string]string{echo.HeaderAuthorization: "Basic " + testHookSecret}, false},
+		{"missing", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.header {
+				h.Set(k, v)
+			}
+			if got := hookAuthorized(h, []byte(body), testHookSecret); got != tt.want {
+				t.Errorf("hookAuthorized = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHook(t *testing.T) {
+	queue, err := jobs.Open(filepath.Join(t.TempDir(), jobs.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := newTestAPI(t)
+	a.cfg.Web.WebhookSecret = testHookSecret
+	a.jobs = queue
+	e := echo.New()
+	e.POST("/hooks/:action", a.hook)
+
+	tests := []struct {
+		name   string
+		action string
+		body   string
+		secret string
+		want   int
+		audit  bool
+	}{
+		{"check", "check", "", testHookSecret, http.StatusAccepted, true},
+		{"update with options", "update", `{"force":true}`, testHookSecret, http.StatusAccepted, true},
+		{"wrong secret", "check", "", "guess", http.StatusUnauthorized, false},
+		{"unknown action", "restore", "", testHookSecret, http.StatusNotFound, false},
+		{"invalid body", "update", `{"force":`, testHookSecret, http.StatusBadRequest, false},
+		{"rejected by submit", "backup", `{"name":"../evil"}`, testHookSecret, http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := events.NewRecorder(a.bus, events.Action)
+			defer recorder.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/hooks/"+tt.action, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(hookSignatureHeader, sign(tt.secret, tt.body))
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+
+			audited := recorder.Events()
+			if !tt.audit {
+				if len(audited) != 0 {
+					t.Errorf("audited %v", audited)
+				}
+				return
+			}
+			var job jobs.Job
+			if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+				t.Fatal(err)
+			}
+			if job.Kind != tt.action || job.State != jobs.StateQueued || !strings.HasPrefix(job.Actor, "webhook ") {
+				t.Errorf("job = %+v", job)
+			}
+			if len(audited) != 1 || audited[0].Data["action"] != "webhook."+tt.action || audited[0].Data["target"] != job.ID {
+				t.Errorf("audited %v, want webhook.%s of job %s", audited, tt.action, job.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/jobs"
+	"github.com/labstack/echo/v4"
+)
+
+// jobLockPoll is how often a job waiting for another operation looks whether it finished
+const jobLockPoll = 200 * time.Millisecond
+
+// jobRequest is the JSON body of POST /api/v1/jobs
+type jobRequest struct {
+	Kind           string `json:"kind"`             // check, update, backup or restore
+	Force          bool   `json:"force"`            // update: reinstall the latest file
+	AllowMCUpgrade bool   `json:"allow_mc_upgrade"` // update: despite update_policy.confirm_minecraft_upgrades
+	Name           string `json:"name"`             // backup: name of the archive
+	Type           string `json:"type"`             // backup: manual (default) or world
+	Backup         string `json:"backup"`           // restore: the backup to restore
+}
+
+func (a *api) listJobs(c echo.Context) error {
+	return c.JSON(http.StatusOK, a.jobs.List())
+}
+
+func (a *api) getJob(c echo.Context) error {
+	job, err := a.jobs.Get(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusOK, job)
+}
+
+// createJob queues a check, update, backup or restore and answers at once with the job
+// to poll
+func (a *api) createJob(c echo.Context) error {
+	var req jobRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	job, err := a.submit(req, actor(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// submit queues the job req asks for, started by who
+func (a *api) submit(req jobRequest, who string) (jobs.Job, error) {
+	var target string
+	var run func(ctx context.Context, r *jobs.Run, logger *slog.Logger) (interface{}, error)
+	switch req.Kind {
+	case "check":
+		target = strconv.Itoa(a.cfg.ModpackID)
+		run = func(ctx context.Context, r *jobs.Run, logger *slog.Logger) (interface{}, error) {
+			resp, err := a.runCheck(logger)
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+	case "update":
+		target = strconv.Itoa(a.cfg.ModpackID)
+		run = func(ctx context.Context, r *jobs.Run, logger *slog.Logger) (interface{}, error) {
+			unsubscribe := a.bus.Subscribe(func(e events.Event) {
+				phase, _ := e.Data["phase"].(string)
+				percent, _ := e.Data["percent"].(int)
+				r.Progress(phase, percent)
+			}, events.UpdateProgress)
+			defer unsubscribe()
+			resp, err := a.runUpdate(ctx, who, logger, req.Force, req.AllowMCUpgrade)
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+	case "backup":
+		backup := backupRequest{Name: req.Name, Type: req.Type}
+		if err := backup.validate(); err != nil {
+			return jobs.Job{}, err
+		}
+		target = req.Name
+		run = func(ctx context.Context, r *jobs.Run, logger *slog.Logger) (interface{}, error) {
+			resp, err := a.runBackup(ctx, who, logger, backup)
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+	case "restore":
+		if _, err := a.backups().GetBackupInfo(req.Backup); req.Backup == "" || err != nil {
+			return jobs.Job{}, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("backup %q not found", req.Backup))
+		}
+		target = req.Backup
+		run = func(ctx context.Context, r *jobs.Run, logger *slog.Logger) (interface{}, error) {
+			resp, err := a.runRestore(ctx, who, logger, req.Backup)
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+	default:
+		return jobs.Job{}, echo.NewHTTPError(http.StatusBadRequest, "job kind must be check, update, backup or restore")
+	}
+	return a.jobs.Submit(req.Kind, target, who, a.job(run)), nil
+}
+
+func (a *api) cancelJob(c echo.Context) error {
+	job, err := a.jobs.Cancel(c.Param("id"))
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, jobs.ErrFinished):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	a.record(c, "job.cancel", job.ID, job.Kind, err)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, job)
+}
+
+// job wraps run as a job that waits for the other operations of the API, logs to its job
+// log as well and reports errors without their HTTP status. Canceling it stops a backup
+// at once, and an update or restore before it starts replacing server files.
+func (a *api) job(run func(ctx context.Context, r *jobs.Run, logger *slog.Logger) (interface{}, error)) jobs.Func {
+	return func(ctx context.Context, r *jobs.Run) (interface{}, error) {
+		if err := a.lock(ctx); err != nil {
+			return nil, err
+		}
+		defer a.busy.Unlock()
+
+		result, err := run(ctx, r, r.Logger(a.logger()))
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			err = fmt.Errorf("%v", httpErr.Message)
+		}
+		return result, err
+	}
+}
+
+// lock waits until no other operation runs, or until ctx is canceled
+func (a *api) lock(ctx context.Context) error {
+	for !a.busy.TryLock() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jobLockPoll):
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		a.busy.Unlock()
+		return err
+	}
+	return nil
+}
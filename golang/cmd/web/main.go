@@ -1,39 +1,240 @@
 package main
 
 import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
 	"github.com/a-h/templ"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/audit"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/eventsink"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/health"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/jobs"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/logging"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/damianko135/curseforge-autoupdate/golang/public"
 	"github.com/damianko135/curseforge-autoupdate/golang/views"  //nolint:all
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/spf13/pflag"
 )
 
+// shutdownTimeout is how long requests in flight get to finish after SIGTERM
+const shutdownTimeout = 15 * time.Second
+
+// auditPageSize is how many of the newest audit entries /audit shows
+const auditPageSize = 200
+
 func main() {
+	configPath := pflag.String("config", "config.toml", "Path to config file")
+	pflag.Parse()
+
+	// The web UI reads the same settings as the CLI; without a file it runs on defaults and environment
+	cfg, err := config.Load(config.Options{Path: *configPath, Flags: pflag.CommandLine, AllowMissing: true})
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	// The UI runs without a valid config so that it can be fixed in /settings, but it
+	// cannot serve HTTPS with only half of the key pair
+	if (cfg.Web.TLSCert == "") != (cfg.Web.TLSKey == "") {
+		log.Fatalf("web tls_cert and tls_key must be set together")
+	}
+	out := io.Writer(os.Stderr)
+	if cfg.LogFile != "" {
+		f, err := logging.OpenFile(cfg.LogFile)
+		if err != nil {
+			log.Fatalf("failed to open log file: %v", err)
+		}
+		defer f.Close()
+		out = io.MultiWriter(os.Stderr, f)
+	}
+	logOutput := logging.NewRedactWriter(out, cfg.Secrets()...)
+	logger, err := logging.New(logOutput, logging.Options{Level: cfg.LogLevel, Format: cfg.LogFormat})
+	if err != nil {
+		log.Fatalf("failed to set up logging: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	// SIGINT and SIGTERM start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	e := echo.New()
+	e.Logger.SetOutput(logOutput)
+	e.HideBanner = true
 
 	// Add middleware
 	// e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
+	ready := &readiness{}
+	e.Use(ready.middleware)
+	e.GET("/ready", ready.handler)
 
-	// Serve static files
-	e.Static("/static", "public")
+	// Serve the embedded static files, or web.static_dir during development
+	e.StaticFS("/static", public.FS(cfg.Web.StaticDir))
+
+	// Subsystems react to updates, backups and the server through the event bus
+	bus := events.NewBus(nil)
+	sink, err := eventsink.NewFromConfig(cfg, slog.Default())
+	if err != nil {
+		log.Fatalf("failed to set up the event sink: %v", err)
+	}
+	counts := subscribeEvents(cfg, bus, sink)
+
+	controller, err := server.NewController(cfg)
+	if err != nil {
+		log.Fatalf("failed to set up server control: %v", err)
+	}
+	if mc, ok := controller.(*server.MinecraftServer); ok {
+		mc.SetBus(bus)
+	}
+	started := time.Now()
+	resources := newResourceSampler(cfg, controller)
 
 	// Routes
 	// NOTE: It will through an error if templ hasnt build the files yet.
+	store := state.NewStore(filepath.Join(cfg.DataDir, state.FileName))
 	e.GET("/", func(c echo.Context) error {
-		return render(c, views.Status())
+		st, err := store.Load()
+		if err != nil {
+			return err
+		}
+		servers, err := serverStatuses(cfg)
+		if err != nil {
+			return err
+		}
+		return render(c, views.Dashboard(st, servers, resourcesView(resources.sample()), cfg.Web.APIToken != ""))
 	})
 
+	// /health answers 200 when ok or degraded and 503 when failing, for load balancers
+	// and Docker HEALTHCHECK; browsers asking for HTML get the page instead
+	checker := health.NewChecker(cfg)
 	e.GET("/health", func(c echo.Context) error {
-		return render(c, views.Health())
+		report := checker.Run()
+		code := http.StatusOK
+		if !report.Healthy() {
+			code = http.StatusServiceUnavailable
+		}
+		if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMETextHTML) {
+			c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+			c.Response().WriteHeader(code)
+			return render(c, views.Health(healthPage(report)))
+		}
+		return c.JSON(code, report)
 	})
 
+	// Update status as an SVG badge for other sites, without the API token
+	e.GET("/badge.svg", badgeHandler(cfg))
+
 	e.GET("/status", func(c echo.Context) error {
-		return render(c, views.Status())
+		page, err := statusPage(cfg, controller, started)
+		page.Resources = resourcesView(resources.sample())
+		if err != nil {
+			return err
+		}
+		return render(c, views.Status(page))
+	})
+
+	e.GET("/console", func(c echo.Context) error {
+		mode := cfg.Server.Mode
+		if mode == "" {
+			mode = server.ModeProcess
+		}
+		return render(c, views.Console(views.ConsolePage{Mode: mode, APIEnabled: cfg.Web.APIToken != ""}))
 	})
 
-	// Start server on port 8080
-	e.Logger.Fatal(e.Start(":8080"))
+	e.GET("/history", func(c echo.Context) error {
+		result := c.QueryParam("result")
+		if result != "" && !history.ValidResult(result) {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid result filter")
+		}
+		entries, err := history.NewLog(filepath.Join(cfg.DataDir, history.FileName)).List(history.Filter{Result: result})
+		if err != nil {
+			return err
+		}
+		return render(c, views.History(entries, result))
+	})
+
+	e.GET("/audit", func(c echo.Context) error {
+		action := c.QueryParam("action")
+		entries, err := audit.NewLog(filepath.Join(cfg.DataDir, audit.FileName)).List(audit.Filter{Action: action, Limit: auditPageSize})
+		if err != nil {
+			return err
+		}
+		return render(c, views.Audit(entries, action))
+	})
+
+	e.GET("/approvals", approvalsPage(cfg))
+	e.GET("/backups", backupsPage(cfg))
+	e.GET("/players", playersPage(cfg))
+
+	// Config editor and mod browser, see settings.go and browse.go
+	editor := newConfigEditor(cfg, *configPath, bus)
+	registerSettings(e, editor, cfg.Web.APIToken)
+	registerBrowse(e, cfg, editor)
+
+	// Updates, backups and restores queued as jobs run one at a time in the background
+	queue, err := jobs.Open(filepath.Join(cfg.DataDir, jobs.FileName))
+	if err != nil {
+		log.Fatalf("failed to load jobs: %v", err)
+	}
+	queue.SetRedact(logOutput.Redact)
+	go queue.Run(ctx)
+
+	// REST API for automation, see api.go, and the same as a gRPC service, see grpc.go
+	a := registerAPI(e, cfg, controller, resources, bus, counts, queue, editor, ctx.Done())
+
+	// Start server on web.listen (default :8080), with HTTPS when a certificate is configured
+	errc := make(chan error, 1)
+	go func() {
+		slog.Info("web server listening", "listen", cfg.Web.Listen, "tls", cfg.Web.TLSCert != "")
+		if cfg.Web.TLSCert != "" {
+			errc <- e.StartTLS(cfg.Web.Listen, cfg.Web.TLSCert, cfg.Web.TLSKey)
+		} else {
+			errc <- e.Start(cfg.Web.Listen)
+		}
+	}()
+	grpcServer, err := serveGRPC(a, errc)
+	if err != nil {
+		log.Fatalf("failed to start the gRPC control API: %v", err)
+	}
+	ready.ready.Store(true)
+
+	select {
+	case err := <-errc:
+		slog.Error("web server failed", "error", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down web server", "timeout", shutdownTimeout)
+	ready.ready.Store(false)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		slog.Error("web server did not shut down cleanly", "error", err)
+	}
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+	if sink != nil {
+		// Publishes the events still queued
+		sink.Close()
+	}
+	slog.Info("web server stopped")
 }
 
 // render is a helper function to render templ components
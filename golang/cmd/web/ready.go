@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+)
+
+// readiness tracks whether the web server should get traffic. It is set once the config,
+// API client and routes are initialised and cleared when shutdown starts, so that a load
+// balancer stops sending requests before the listener closes.
+type readiness struct {
+	ready atomic.Bool
+}
+
+// handler answers GET /ready with 200 when ready and 503 otherwise
+func (r *readiness) handler(c echo.Context) error {
+	if !r.ready.Load() {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// middleware answers every request but /health and /ready with 503 while not ready
+func (r *readiness) middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !r.ready.Load() && c.Path() != "/health" && c.Path() != "/ready" {
+			c.Response().Header().Set("Retry-After", "5")
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "the server is starting or shutting down")
+		}
+		return next(c)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/damianko135/curseforge-autoupdate/golang/views"
+	"github.com/labstack/echo/v4"
+)
+
+// resourceCacheTTL is how long a resource sample is reused, so that pages and metric
+// scrapes do not each wait for a new one
+const resourceCacheTTL = 10 * time.Second
+
+// resourceSampler samples the server's CPU, memory and TPS for the status page, the
+// dashboard, the API and the metrics
+type resourceSampler struct {
+	cfg       *config.Config
+	minecraft server.Controller
+
+	mu   sync.Mutex
+	at   time.Time
+	last *server.Resources
+}
+
+func newResourceSampler(cfg *config.Config, minecraft server.Controller) *resourceSampler {
+	return &resourceSampler{cfg: cfg, minecraft: minecraft}
+}
+
+// sample returns a recent sample, or nil when the server is stopped or the mode cannot
+// report its resources
+func (s *resourceSampler) sample() *server.Resources {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.at.IsZero() && time.Since(s.at) < resourceCacheTTL {
+		return s.last
+	}
+	s.last = nil
+	if s.minecraft.IsRunning() {
+		res, err := server.SampleResources(s.cfg, s.minecraft)
+		if err != nil {
+			slog.Debug("server resources unavailable", "error", err)
+		} else {
+			s.last = res
+		}
+	}
+	s.at = time.Now()
+	return s.last
+}
+
+// resourcesView converts a sample for the dashboard and status page
+func resourcesView(res *server.Resources) *views.ServerResources {
+	if res == nil || (res.MemoryBytes == 0 && res.TPS == 0) {
+		return nil
+	}
+	return &views.ServerResources{
+		CPUPercent:       res.CPUPercent,
+		MemoryBytes:      int64(res.MemoryBytes),
+		MemoryLimitBytes: int64(res.MemoryLimitBytes),
+		TPS:              res.TPS,
+	}
+}
+
+// metric is one gauge or counter in the Prometheus text format
+type metric struct {
+	name  string
+	help  string
+	value float64
+}
+
+// metrics serves the server's state and resources in the Prometheus text format.
+// Resources that cannot be sampled are left out instead of reported as 0.
+func (a *api) metrics(c echo.Context) error {
+	running := a.minecraft.IsRunning()
+	gauges := []metric{
+		{"server_running", "Whether the Minecraft server is running.", boolValue(running)},
+		{"server_uptime_seconds", "How long the Minecraft server has been running.", a.minecraft.GetUptime().Seconds()},
+	}
+	if res := a.resources.sample(); res != nil {
+		if res.MemoryBytes > 0 {
+			gauges = append(gauges,
+				metric{"server_cpu_percent", "CPU usage of the server in percent of one core.", res.CPUPercent},
+				metric{"server_memory_bytes", "Memory used by the server.", float64(res.MemoryBytes)})
+		}
+		if res.MemoryLimitBytes > 0 {
+			gauges = append(gauges, metric{"server_memory_limit_bytes", "Memory limit of the server.", float64(res.MemoryLimitBytes)})
+		}
+		if res.TPS > 0 {
+			gauges = append(gauges, metric{"server_tps", "Ticks per second reported by the server.", res.TPS})
+		}
+	}
+	if st, err := state.NewStore(filepath.Join(a.cfg.DataDir, state.FileName)).Load(); err == nil {
+		gauges = append(gauges, metric{"update_available", "Whether a newer modpack version is available.",
+			boolValue(st.LatestFileID != 0 && st.LatestFileID != st.InstalledFileID)})
+	}
+
+	// Counted from the event bus since the web UI started
+	counters := []metric{
+		{"updates_installed_total", "Updates installed since the web UI started.", float64(a.counts.get(events.UpdateFinished))},
+		{"updates_failed_total", "Updates that failed since the web UI started.", float64(a.counts.get(events.UpdateFailed))},
+		{"backups_created_total", "Backups made through the API since the web UI started.", float64(a.counts.get(events.BackupCreated))},
+		{"server_starts_total", "Times the web UI started the server.", float64(a.counts.get(events.ServerStarted))},
+		{"server_failures_total", "Times the server the web UI started exited with an error.", float64(a.counts.get(events.ServerStopped))},
+	}
+
+	var b strings.Builder
+	write := func(kind string, metrics []metric) {
+		for _, m := range metrics {
+			name := "curseforge_autoupdater_" + m.name
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, m.help, name, kind, name, m.value)
+		}
+	}
+	write("gauge", gauges)
+	write("counter", counters)
+	return c.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
+
+// boolValue is 1 for true and 0 for false
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/damianko135/curseforge-autoupdate/golang/views"
+	"github.com/labstack/echo/v4"
+)
+
+// serverStatusResponse is the JSON shape of one entry of GET /api/v1/servers
+type serverStatusResponse struct {
+	Name             string     `json:"name"`
+	ModpackID        int        `json:"modpack_id"`
+	ServerPath       string     `json:"server_path"`
+	InstalledVersion string     `json:"installed_version"`
+	LatestVersion    string     `json:"latest_version"`
+	LastCheckAt      *time.Time `json:"last_check_at"`
+	AutoUpdate       bool       `json:"auto_update"`
+	Running          *bool      `json:"running,omitempty"`
+}
+
+// serverStatuses reads the state of every [[servers]] entry. Whether a server runs is
+// only known when server.mode controls it from outside this process.
+func serverStatuses(cfg *config.Config) ([]views.ServerStatus, error) {
+	var out []views.ServerStatus
+	for _, inst := range cfg.Instances() {
+		if inst.InstanceName == "" {
+			continue
+		}
+		st, err := state.NewStore(filepath.Join(inst.DataDir, state.FileName)).Load()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", inst.InstanceName, err)
+		}
+		status := views.ServerStatus{
+			Name:             inst.InstanceName,
+			ModpackID:        inst.ModpackID,
+			ServerPath:       inst.ServerPath,
+			InstalledVersion: st.InstalledVersion,
+			LatestVersion:    st.LatestVersion,
+			LastCheckAt:      st.LastCheckAt,
+			AutoUpdate:       inst.UpdatePolicy.AutoUpdate,
+		}
+		if inst.Server.Mode != "" && inst.Server.Mode != server.ModeProcess {
+			if c, err := server.NewController(inst); err == nil {
+				running := c.IsRunning()
+				status.Running = &running
+			}
+		}
+		out = append(out, status)
+	}
+	return out, nil
+}
+
+func (a *api) listServers(c echo.Context) error {
+	statuses, err := serverStatuses(a.cfg)
+	if err != nil {
+		return err
+	}
+	out := []serverStatusResponse{}
+	for _, s := range statuses {
+		var lastCheck *time.Time
+		if !s.LastCheckAt.IsZero() {
+			lastCheck = &s.LastCheckAt
+		}
+		out = append(out, serverStatusResponse{
+			Name:             s.Name,
+			ModpackID:        s.ModpackID,
+			ServerPath:       s.ServerPath,
+			InstalledVersion: s.InstalledVersion,
+			LatestVersion:    s.LatestVersion,
+			LastCheckAt:      lastCheck,
+			AutoUpdate:       s.AutoUpdate,
+			Running:          s.Running,
+		})
+	}
+	return c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/views" //nolint:all
+	"github.com/labstack/echo/v4"
+)
+
+// settingField is one editable config key on the settings page
+type settingField struct {
+	key    string
+	label  string
+	kind   string // text, number, checkbox or secret
+	get    func(c *config.Config) string
+	set    func(c *config.Config, value string) error
+	secret func(c *config.Config) string // *_file that replaces the value, for secrets
+	clear  func(c *config.Config)        // removes a secret, when its "<key>.clear" box is checked
+}
+
+// settingSection groups related fields on the page
+type settingSection struct {
+	title  string
+	fields []settingField
+}
+
+// settingSections lists everything that can be edited from the web UI
+var settingSections = []settingSection{
+	{"Paths", []settingField{
+		textField("server_path", "Server path", func(c *config.Config) *string { return &c.ServerPath }),
+		textField("backup_path", "Backup path", func(c *config.Config) *string { return &c.BackupPath }),
+		textField("download_path", "Download path", func(c *config.Config) *string { return &c.DownloadPath }),
+		textField("data_dir", "Data directory", func(c *config.Config) *string { return &c.DataDir }),
+	}},
+	{"Schedule", []settingField{
+		durationField("check_interval", "Check interval", func(c *config.Config) *time.Duration { return &c.CheckInterval }),
+		textField("maintenance.window_start", "Maintenance window start (HH:MM)", func(c *config.Config) *string { return &c.Maintenance.WindowStart }),
+		textField("maintenance.window_end", "Maintenance window end (HH:MM)", func(c *config.Config) *string { return &c.Maintenance.WindowEnd }),
+		textField("maintenance.timezone", "Maintenance timezone", func(c *config.Config) *string { return &c.Maintenance.Timezone }),
+		durationField("maintenance.announce_before", "Announce updates before the window", func(c *config.Config) *time.Duration { return &c.Maintenance.AnnounceBefore }),
+	}},
+	{"Update policy", []settingField{
+		boolField("update_policy.auto_update", "Install updates automatically", func(c *config.Config) *bool { return &c.UpdatePolicy.AutoUpdate }),
+		durationField("update_policy.min_file_age", "Only install files older than", func(c *config.Config) *time.Duration { return &c.UpdatePolicy.MinFileAge }),
+		intField("update_policy.max_updates_per_week", "Most updates a week (0 for no limit)", func(c *config.Config) *int { return &c.UpdatePolicy.MaxUpdatesPerWeek }),
+		boolField("update_policy.require_empty_server", "Only while nobody is online", func(c *config.Config) *bool { return &c.UpdatePolicy.RequireEmptyServer }),
+		boolField("update_policy.confirm_minecraft_upgrades", "Confirm updates to a newer Minecraft version", func(c *config.Config) *bool { return &c.UpdatePolicy.ConfirmMinecraftUpgrades }),
+	}},
+	{"Release policy", []settingField{
+		durationField("release_policy.min_age", "Minimum file age", func(c *config.Config) *time.Duration { return &c.ReleasePolicy.MinAge }),
+		boolField("release_policy.stable_mods", "Only releases of tracked mods", func(c *config.Config) *bool { return &c.ReleasePolicy.StableMods }),
+	}},
+	{"Backups", []settingField{
+		intField("backup.retention_days", "Retention (days, 0 keeps all)", func(c *config.Config) *int { return &c.Backup.RetentionDays }),
+		intField("backup.keep_last", "Also keep the newest", func(c *config.Config) *int { return &c.Backup.KeepLast }),
+		intField("backup.keep_daily", "Also keep one a day for (days)", func(c *config.Config) *int { return &c.Backup.KeepDaily }),
+		intField("backup.keep_weekly", "Also keep one a week for (weeks)", func(c *config.Config) *int { return &c.Backup.KeepWeekly }),
+		intField("backup.keep_monthly", "Also keep one a month for (months)", func(c *config.Config) *int { return &c.Backup.KeepMonthly }),
+		boolField("backup.compression", "Compress backups", func(c *config.Config) *bool { return &c.Backup.Compression }),
+	}},
+	{"Maintenance mode", []settingField{
+		boolField("server.maintenance_mode.enabled", "Answer players during updates", func(c *config.Config) *bool { return &c.Server.MaintenanceMode.Enabled }),
+		textField("server.maintenance_mode.motd", "Message ({version}, {eta})", func(c *config.Config) *string { return &c.Server.MaintenanceMode.MOTD }),
+		durationField("server.maintenance_mode.eta", "Expected downtime", func(c *config.Config) *time.Duration { return &c.Server.MaintenanceMode.ETA }),
+	}},
+	{"Resource monitoring", []settingField{
+		durationField("server.monitor.interval", "Sample every (0 disables)", func(c *config.Config) *time.Duration { return &c.Server.Monitor.Interval }),
+		floatField("server.monitor.max_cpu_percent", "Alert above CPU % (of one core)", func(c *config.Config) *float64 { return &c.Server.Monitor.MaxCPUPercent }),
+		textField("server.monitor.max_memory", "Alert above memory (e.g. 8GB)", func(c *config.Config) *string { return &c.Server.Monitor.MaxMemory }),
+		floatField("server.monitor.min_tps", "Alert below TPS", func(c *config.Config) *float64 { return &c.Server.Monitor.MinTPS }),
+	}},
+	{"Startup check", []settingField{
+		boolField("server.health_check.enabled", "Restart and verify the server after updates", func(c *config.Config) *bool { return &c.Server.HealthCheck.Enabled }),
+		durationField("server.health_check.timeout", "Time allowed to start", func(c *config.Config) *time.Duration { return &c.Server.HealthCheck.Timeout }),
+		intField("server.health_check.max_crashes", "Crashes that make a crash loop", func(c *config.Config) *int { return &c.Server.HealthCheck.MaxCrashes }),
+		boolField("server.health_check.rollback", "Roll back when it crash-loops", func(c *config.Config) *bool { return &c.Server.HealthCheck.Rollback }),
+	}},
+	{"Discord", []settingField{
+		boolField("notifications.discord.enabled", "Enabled", func(c *config.Config) *bool { return &c.Notifications.Discord.Enabled }),
+		secretField("notifications.discord.webhook_url", "Webhook URL",
+			func(c *config.Config) *string { return &c.Notifications.Discord.WebhookURL },
+			func(c *config.Config) string { return c.Notifications.Discord.WebhookURLFile }),
+		textField("notifications.discord.channel_id", "Channel ID", func(c *config.Config) *string { return &c.Notifications.Discord.ChannelID }),
+		textField("notifications.discord.username", "Username", func(c *config.Config) *string { return &c.Notifications.Discord.Username }),
+		textField("notifications.discord.avatar_url", "Avatar URL", func(c *config.Config) *string { return &c.Notifications.Discord.AvatarURL }),
+	}},
+	{"Webhook", []settingField{
+		boolField("notifications.webhook.enabled", "Enabled", func(c *config.Config) *bool { return &c.Notifications.Webhook.Enabled }),
+		secretField("notifications.webhook.url", "URL",
+			func(c *config.Config) *string { return &c.Notifications.Webhook.URL },
+			func(c *config.Config) string { return c.Notifications.Webhook.URLFile }),
+		textField("notifications.webhook.method", "Method", func(c *config.Config) *string { return &c.Notifications.Webhook.Method }),
+		textField("notifications.webhook.content_type", "Content type", func(c *config.Config) *string { return &c.Notifications.Webhook.ContentType }),
+		durationField("notifications.webhook.timeout", "Timeout", func(c *config.Config) *time.Duration { return &c.Notifications.Webhook.Timeout }),
+	}},
+	{"Pushover", []settingField{
+		boolField("notifications.pushover.enabled", "Enabled", func(c *config.Config) *bool { return &c.Notifications.Pushover.Enabled }),
+		secretField("notifications.pushover.token", "Application token",
+			func(c *config.Config) *string { return &c.Notifications.Pushover.Token },
+			func(c *config.Config) string { return c.Notifications.Pushover.TokenFile }),
+		textField("notifications.pushover.user", "User or group key", func(c *config.Config) *string { return &c.Notifications.Pushover.User }),
+		textField("notifications.pushover.device", "Device", func(c *config.Config) *string { return &c.Notifications.Pushover.Device }),
+	}},
+	{"ntfy", []settingField{
+		boolField("notifications.ntfy.enabled", "Enabled", func(c *config.Config) *bool { return &c.Notifications.Ntfy.Enabled }),
+		textField("notifications.ntfy.server", "Server", func(c *config.Config) *string { return &c.Notifications.Ntfy.Server }),
+		textField("notifications.ntfy.topic", "Topic", func(c *config.Config) *string { return &c.Notifications.Ntfy.Topic }),
+		secretField("notifications.ntfy.token", "Access token",
+			func(c *config.Config) *string { return &c.Notifications.Ntfy.Token },
+			func(c *config.Config) string { return c.Notifications.Ntfy.TokenFile }),
+	}},
+	{"Game chat", []settingField{
+		boolField("notifications.minecraft.enabled", "Enabled", func(c *config.Config) *bool { return &c.Notifications.Minecraft.Enabled }),
+		textField("notifications.minecraft.prefix", "Prefix", func(c *config.Config) *string { return &c.Notifications.Minecraft.Prefix }),
+	}},
+	{"Notification retries", []settingField{
+		intField("notifications.retry.attempts", "Attempts", func(c *config.Config) *int { return &c.Notifications.Retry.Attempts }),
+		durationField("notifications.retry.backoff", "Backoff", func(c *config.Config) *time.Duration { return &c.Notifications.Retry.Backoff }),
+		durationField("notifications.retry.max_backoff", "Maximum backoff", func(c *config.Config) *time.Duration { return &c.Notifications.Retry.MaxBackoff }),
+		intField("notifications.retry.breaker_failures", "Failures before skipping", func(c *config.Config) *int { return &c.Notifications.Retry.BreakerFailures }),
+		durationField("notifications.retry.breaker_cooldown", "Skip for", func(c *config.Config) *time.Duration { return &c.Notifications.Retry.BreakerCooldown }),
+	}},
+}
+
+func textField(key, label string, ptr func(c *config.Config) *string) settingField {
+	return settingField{key: key, label: label, kind: "text",
+		get: func(c *config.Config) string { return *ptr(c) },
+		set: func(c *config.Config, value string) error {
+			*ptr(c) = strings.TrimSpace(value)
+			return nil
+		},
+	}
+}
+
+// secretField is a text field that is never sent to the browser; an empty value keeps the
+// current secret, which is only removed with the clear box next to it
+func secretField(key, label string, ptr func(c *config.Config) *string, file func(c *config.Config) string) settingField {
+	return settingField{key: key, label: label, kind: "secret", secret: file,
+		get: func(c *config.Config) string { return *ptr(c) },
+		set: func(c *config.Config, value string) error {
+			if value = strings.TrimSpace(value); value != "" && file(c) == "" {
+				*ptr(c) = value
+			}
+			return nil
+		},
+		clear: func(c *config.Config) {
+			if file(c) == "" {
+				*ptr(c) = ""
+			}
+		},
+	}
+}
+
+func intField(key, label string, ptr func(c *config.Config) *int) settingField {
+	return settingField{key: key, label: label, kind: "number",
+		get: func(c *config.Config) string { return strconv.Itoa(*ptr(c)) },
+		set: func(c *config.Config, value string) error {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("%s must be a whole number", key)
+			}
+			*ptr(c) = n
+			return nil
+		},
+	}
+}
+
+// floatField is a text input, since number inputs only accept whole numbers by default
+func floatField(key, label string, ptr func(c *config.Config) *float64) settingField {
+	return settingField{key: key, label: label, kind: "text",
+		get: func(c *config.Config) string { return strconv.FormatFloat(*ptr(c), 'f', -1, 64) },
+		set: func(c *config.Config, value string) error {
+			f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return fmt.Errorf("%s must be a number", key)
+			}
+			*ptr(c) = f
+			return nil
+		},
+	}
+}
+
+func durationField(key, label string, ptr func(c *config.Config) *time.Duration) settingField {
+	return settingField{key: key, label: label, kind: "text",
+		get: func(c *config.Config) string { return ptr(c).String() },
+		set: func(c *config.Config, value string) error {
+			d, err := time.ParseDuration(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("%s must be a duration such as 30s or 1h", key)
+			}
+			*ptr(c) = d
+			return nil
+		},
+	}
+}
+
+// boolField is a checkbox; browsers only submit checked boxes
+func boolField(key, label string, ptr func(c *config.Config) *bool) settingField {
+	return settingField{key: key, label: label, kind: "checkbox",
+		get: func(c *config.Config) string { return strconv.FormatBool(*ptr(c)) },
+		set: func(c *config.Config, value string) error {
+			*ptr(c) = value != ""
+			return nil
+		},
+	}
+}
+
+// settingsPage serves /settings, where the config file can be reviewed and edited
+type settingsPage struct {
+	editor *configEditor
+	token  string
+}
+
+// registerSettings mounts the settings page; saving needs web.api_token
+func registerSettings(e *echo.Echo, editor *configEditor, token string) {
+	s := &settingsPage{editor: editor, token: token}
+	e.GET("/settings", s.show)
+	e.POST("/settings", s.save)
+}
+
+func (s *settingsPage) show(c echo.Context) error {
+	return render(c, views.Settings(s.view(s.editor.Current(), "", "")))
+}
+
+func (s *settingsPage) save(c echo.Context) error {
+	if s.token == "" {
+		return echo.NewHTTPError(http.StatusForbidden, "set web.api_token to edit settings")
+	}
+	if subtle.ConstantTimeCompare([]byte(c.FormValue("api_token")), []byte(s.token)) != 1 {
+		c.Response().WriteHeader(http.StatusUnauthorized)
+		return render(c, views.Settings(s.view(s.editor.Current(), "", "invalid API token")))
+	}
+
+	var submitted *config.Config
+	changed, err := s.editor.Update("web "+c.RealIP(), "settings.update", func(cfg *config.Config) ([]string, error) {
+		submitted = cfg
+		return applySettings(cfg, c.FormValue)
+	})
+	if err != nil {
+		// Show the rejected values so they can be corrected
+		c.Response().WriteHeader(http.StatusBadRequest)
+		return render(c, views.Settings(s.view(submitted, "", err.Error())))
+	}
+	if len(changed) == 0 {
+		return render(c, views.Settings(s.view(s.editor.Current(), "No changes to save.", "")))
+	}
+	return render(c, views.Settings(s.view(s.editor.Current(), "Saved "+strings.Join(changed, ", ")+".", "")))
+}
+
+// applySettings sets every editable field from the form and returns the keys that changed
+func applySettings(cfg *config.Config, form func(key string) string) ([]string, error) {
+	var changed []string
+	for _, section := range settingSections {
+		for _, f := range section.fields {
+			before := f.get(cfg)
+			if f.clear != nil && form(f.key+".clear") != "" {
+				f.clear(cfg)
+			} else if err := f.set(cfg, form(f.key)); err != nil {
+				return nil, err
+			}
+			if f.get(cfg) != before {
+				changed = append(changed, f.key)
+			}
+		}
+	}
+	return changed, nil
+}
+
+// view builds the settings page; secrets are never included
+func (s *settingsPage) view(cfg *config.Config, message, errMsg string) views.SettingsPage {
+	page := views.SettingsPage{
+		File:    s.editor.path,
+		CanEdit: s.token != "",
+		Message: message,
+		Error:   errMsg,
+		ReadOnly: []views.SettingsField{
+			{Key: "api_key", Value: maskSecret(cfg.APIKey, cfg.APIKeyFile)},
+			{Key: "modpack_id", Value: strconv.Itoa(cfg.ModpackID)},
+			{Key: "game_version", Value: cfg.GameVersion},
+			{Key: "update_channel", Value: cfg.UpdateChannel},
+			{Key: "log_level", Value: cfg.LogLevel},
+			{Key: "log_format", Value: cfg.LogFormat},
+			{Key: "log_file", Value: cfg.LogFile},
+			{Key: "web.listen", Value: cfg.Web.Listen},
+			{Key: "web.api_token", Value: maskSecret(cfg.Web.APIToken, cfg.Web.APITokenFile)},
+			{Key: "web.webhook_secret", Value: maskSecret(cfg.Web.WebhookSecret, cfg.Web.WebhookSecretFile)},
+			{Key: "web.tls_cert", Value: cfg.Web.TLSCert},
+			{Key: "web.tls_key", Value: cfg.Web.TLSKey},
+			{Key: "web.static_dir", Value: cfg.Web.StaticDir},
+			{Key: "web.public_url", Value: cfg.Web.PublicURL},
+			{Key: "web.grpc_listen", Value: cfg.Web.GRPCListen},
+		},
+	}
+	for _, section := range settingSections {
+		vs := views.SettingsSection{Title: section.title}
+		for _, f := range section.fields {
+			field := views.SettingsField{Key: f.key, Label: f.label, Type: f.kind}
+			switch f.kind {
+			case "checkbox":
+				field.Checked = f.get(cfg) == "true"
+			case "secret":
+				field.Value = maskSecret(f.get(cfg), f.secret(cfg))
+				field.Disabled = f.secret(cfg) != ""
+				field.Clearable = !field.Disabled && f.get(cfg) != ""
+			default:
+				field.Value = f.get(cfg)
+			}
+			vs.Fields = append(vs.Fields, field)
+		}
+		page.Sections = append(page.Sections, vs)
+	}
+	return page
+}
+
+// maskSecret describes a secret without revealing it
+func maskSecret(value, file string) string {
+	switch {
+	case file != "":
+		return "read from " + file
+	case value != "":
+		return "••••••••"
+	}
+	return "not set"
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// settingsForm returns the form a browser submits for cfg unchanged, which leaves
+// secrets empty
+func settingsForm(cfg *config.Config) map[string]string {
+	form := map[string]string{}
+	for _, section := range settingSections {
+		for _, f := range section.fields {
+			switch f.kind {
+			case "checkbox":
+				if f.get(cfg) == "true" {
+					form[f.key] = "on"
+				}
+			case "secret":
+			default:
+				form[f.key] = f.get(cfg)
+			}
+		}
+	}
+	return form
+}
+
+func TestApplySettingsSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     map[string]string
+		fromFile bool
+		want     string
+		changed  bool
+	}{
+		{"empty keeps the secret", nil, false, "https://discord.example/old", false},
+		{"new value replaces it", map[string]string{"notifications.discord.webhook_url": "https://discord.example/new"}, false, "https://discord.example/new", true},
+		{"clear removes it", map[string]string{"notifications.discord.webhook_url.clear": "on"}, false, "", true},
+		{"clear wins over a new value", map[string]string{"notifications.discord.webhook_url": "https://discord.example/new", "notifications.discord.webhook_url.clear": "on"}, false, "", true},
+		{"secret from a file is kept", map[string]string{"notifications.discord.webhook_url.clear": "on"}, true, "https://discord.example/old", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Notifications.Discord.WebhookURL = "https://discord.example/old"
+			if tt.fromFile {
+				cfg.Notifications.Discord.WebhookURLFile = "/run/secrets/discord"
+			}
+			form := settingsForm(cfg)
+			for k, v := range tt.form {
+				form[k] = v
+			}
+
+			changed, err := applySettings(cfg, func(key string) string { return form[key] })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.Notifications.Discord.WebhookURL; got != tt.want {
+				t.Errorf("webhook_url = %q, want %q", got, tt.want)
+			}
+			if got := len(changed) > 0; got != tt.changed {
+				t.Errorf("changed = %v, want a change: %v", changed, tt.changed)
+			}
+		})
+	}
+}
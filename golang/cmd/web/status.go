@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/health"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/damianko135/curseforge-autoupdate/golang/views"
+)
+
+// statusBackups is how many recent backups the status page lists
+const statusBackups = 5
+
+// statusPage gathers the installed version, the server controlled through minecraft and the
+// backups for the status page
+func statusPage(cfg *config.Config, minecraft server.Controller, started time.Time) (views.StatusPage, error) {
+	st, err := state.NewStore(filepath.Join(cfg.DataDir, state.FileName)).Load()
+	if err != nil {
+		return views.StatusPage{}, err
+	}
+	mode := cfg.Server.Mode
+	if mode == "" {
+		mode = server.ModeProcess
+	}
+	page := views.StatusPage{
+		ModpackID:        cfg.ModpackID,
+		InstalledVersion: st.InstalledVersion,
+		InstalledFileID:  st.InstalledFileID,
+		InstalledAt:      st.InstalledAt,
+		LatestVersion:    st.LatestVersion,
+		LastCheckAt:      st.LastCheckAt,
+		UpdateAvailable:  st.LatestFileID != 0 && st.LatestFileID != st.InstalledFileID,
+		ServerPath:       cfg.ServerPath,
+		ServerMode:       mode,
+		Running:          minecraft.IsRunning(),
+		StartedAt:        started,
+	}
+	if page.Running {
+		page.Uptime = minecraft.GetUptime()
+	}
+
+	bm := server.NewBackupManager(cfg.ServerPath, cfg.BackupPath, cfg.Backup.Compression, cfg.Backup.RetentionDays)
+	backups, err := bm.ListBackups()
+	if err != nil {
+		return views.StatusPage{}, fmt.Errorf("failed to list backups: %w", err)
+	}
+	page.BackupCount = len(backups)
+	for _, b := range backups {
+		page.BackupSizeBytes += b.Size
+		if len(page.Backups) < statusBackups {
+			page.Backups = append(page.Backups, views.BackupSummary{
+				Name:      filepath.Base(b.Path),
+				Type:      b.Type,
+				SizeBytes: b.Size,
+				Created:   b.Created,
+			})
+		}
+	}
+	return page, nil
+}
+
+// healthPage converts a health report for the health page
+func healthPage(report *health.Report) views.HealthPage {
+	page := views.HealthPage{Status: report.Status, CheckedAt: report.Timestamp}
+	for _, c := range report.Checks {
+		page.Checks = append(page.Checks, views.HealthCheck{Name: c.Name, Status: c.Status, Message: c.Message})
+	}
+	return page
+}
@@ -0,0 +1,17 @@
+package filesystem
+
+// DiskSpace describes the capacity of the filesystem holding a path
+type DiskSpace struct {
+	Total uint64 // bytes
+	Free  uint64 // bytes available to the current user
+}
+
+// GetDiskSpace returns the capacity of the filesystem holding path, or of its
+// nearest existing parent when path does not exist yet
+func GetDiskSpace(path string) (*DiskSpace, error) {
+	dir, err := nearestDir(path)
+	if err != nil {
+		return nil, err
+	}
+	return diskSpace(dir)
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package filesystem
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// diskSpace queries statfs for dir
+func diskSpace(dir string) (*DiskSpace, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return nil, fmt.Errorf("failed to read disk space for %s: %w", dir, err)
+	}
+	return &DiskSpace{
+		Total: uint64(st.Blocks) * uint64(st.Bsize),
+		Free:  uint64(st.Bavail) * uint64(st.Bsize),
+	}, nil
+}
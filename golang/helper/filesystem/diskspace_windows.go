@@ -0,0 +1,22 @@
+//go:build windows
+
+package filesystem
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// diskSpace queries GetDiskFreeSpaceEx for dir
+func diskSpace(dir string) (*DiskSpace, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return nil, err
+	}
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &free, &total, &totalFree); err != nil {
+		return nil, fmt.Errorf("failed to read disk space for %s: %w", dir, err)
+	}
+	return &DiskSpace{Total: total, Free: free}, nil
+}
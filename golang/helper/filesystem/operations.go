@@ -1,6 +1,7 @@
 package filesystem
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -120,39 +121,9 @@ func IsEmpty(path string) (bool, error) {
 	return false, err
 }
 
-// CopyDir copies a directory from src to dst recursively
+// CopyDir copies a directory from src to dst recursively, see CopyDirContext
 func CopyDir(src, dst string) error {
-	srcInfo, err := os.Stat(src)
-	if err != nil {
-		return fmt.Errorf("failed to stat source directory %s: %w", src, err)
-	}
-
-	// #nosec G301 -- dst permissions are inherited from src
-	if err := os.MkdirAll(dst, srcInfo.Mode()); err != nil {
-		return fmt.Errorf("failed to create destination directory %s: %w", dst, err)
-	}
-
-	entries, err := os.ReadDir(src)
-	if err != nil {
-		return fmt.Errorf("failed to read source directory %s: %w", src, err)
-	}
-
-	for _, entry := range entries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
-
-		if entry.IsDir() {
-			if err := CopyDir(srcPath, dstPath); err != nil {
-				return err
-			}
-		} else {
-			if err := CopyFile(srcPath, dstPath); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
+	return CopyDirContext(context.Background(), src, dst)
 }
 
 // ListFiles lists all files in a directory (non-recursively)
@@ -220,25 +191,24 @@ func FindFiles(root, pattern string) ([]string, error) {
 	return matches, nil
 }
 
-// GetDirSize calculates the total size of a directory and all its contents
+// GetDirSize calculates the total size of a directory and all its contents, see
+// GetDirSizeContext
 func GetDirSize(path string) (int64, error) {
-	var size int64
-
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
-	})
+	return GetDirSizeContext(context.Background(), path)
+}
 
-	if err != nil {
-		return 0, fmt.Errorf("failed to calculate directory size for %s: %w", path, err)
+// FormatBytes formats a byte size for people, in binary units such as "1.5 MB"
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
 	}
-
-	return size, nil
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
 }
 
 // CleanPath cleans and normalizes a file path
@@ -304,3 +274,36 @@ func SafeWriteFile(path string, data []byte, perm os.FileMode) error {
 
 	return nil
 }
+
+// CheckWritable verifies that files can be created in path, or in its nearest
+// existing parent when path does not exist yet
+func CheckWritable(path string) error {
+	dir, err := nearestDir(path)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(dir, ".write_check_*")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable: %w", dir, err)
+	}
+	tmpPath := tmpFile.Name()
+	_ = tmpFile.Close()
+	return os.Remove(tmpPath)
+}
+
+// nearestDir returns path if it is a directory, otherwise its nearest existing parent
+func nearestDir(path string) (string, error) {
+	dir := filepath.Clean(path)
+	for !DirExists(dir) {
+		if FileExists(dir) {
+			return "", fmt.Errorf("%s is a file, not a directory", dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no existing parent directory for %s", path)
+		}
+		dir = parent
+	}
+	return dir, nil
+}
@@ -0,0 +1,9 @@
+package filesystem
+
+import "os"
+
+// Owner returns the user and group IDs that own the file described by info. ok is false
+// on systems without Unix ownership, such as Windows.
+func Owner(info os.FileInfo) (uid, gid int, ok bool) {
+	return owner(info)
+}
@@ -0,0 +1,203 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// maxWorkers caps the files copied, sized or hashed at once; beyond it a single disk
+// gets no faster
+const maxWorkers = 8
+
+// FileHash is the size and checksum of one file, see HashDir
+type FileHash struct {
+	Size int64
+	Sum  []byte
+}
+
+// treeEntry is a file or directory found by walkTree
+type treeEntry struct {
+	path string
+	rel  string
+	d    fs.DirEntry
+}
+
+// workers returns how many files are processed at once
+func workers() int {
+	return min(maxWorkers, max(2, runtime.GOMAXPROCS(0)))
+}
+
+// walkTree lists the directories and other entries below root in lexical order, without
+// following symlinks below it. Listing only reads directories; the files are left to the
+// workers.
+func walkTree(ctx context.Context, root string) (dirs, files []treeEntry, err error) {
+	// A root that is a symlink, such as a snapshot linked into place, is walked like its target
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, treeEntry{path: path, rel: rel, d: d})
+		} else {
+			files = append(files, treeEntry{path: path, rel: rel, d: d})
+		}
+		return nil
+	})
+	return dirs, files, err
+}
+
+// parallel runs fn for the items 0 to n-1 on a bounded number of goroutines, taking them
+// in order. Once an item fails, later items are skipped while earlier ones still finish,
+// so the error returned is always that of the first failing item, just as if they ran
+// one after the other. Cancelling ctx stops handing out items.
+func parallel(ctx context.Context, n int, fn func(i int) error) error {
+	errs := make([]error, n)
+	var next atomic.Int64
+	var failed atomic.Int64
+	failed.Store(int64(n))
+
+	var wg sync.WaitGroup
+	for range min(workers(), n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := next.Add(1) - 1
+				if i >= int64(n) || i > failed.Load() {
+					return
+				}
+				if err := fn(int(i)); err != nil {
+					errs[i] = err
+					for f := failed.Load(); i < f && !failed.CompareAndSwap(f, i); f = failed.Load() {
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// CopyDirContext copies a directory from src to dst recursively like CopyDir, copying
+// several files at once. Directories are created first, in order; a failure returns the
+// error of the first file, in lexical order, that could not be copied.
+func CopyDirContext(ctx context.Context, src, dst string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat source directory %s: %w", src, err)
+	}
+	dirs, files, err := walkTree(ctx, src)
+	if err != nil {
+		return fmt.Errorf("failed to read source directory %s: %w", src, err)
+	}
+
+	for _, dir := range dirs {
+		mode := srcInfo.Mode()
+		if dir.rel != "." {
+			info, err := dir.d.Info()
+			if err != nil {
+				return fmt.Errorf("failed to stat source directory %s: %w", dir.path, err)
+			}
+			mode = info.Mode()
+		}
+		// #nosec G301 -- dst permissions are inherited from src
+		if err := os.MkdirAll(filepath.Join(dst, dir.rel), mode.Perm()); err != nil {
+			return fmt.Errorf("failed to create destination directory %s: %w", filepath.Join(dst, dir.rel), err)
+		}
+	}
+	return parallel(ctx, len(files), func(i int) error {
+		return CopyFile(files[i].path, filepath.Join(dst, files[i].rel))
+	})
+}
+
+// GetDirSizeContext calculates the total size of a directory like GetDirSize, reading
+// the sizes of several files at once
+func GetDirSizeContext(ctx context.Context, path string) (int64, error) {
+	_, files, err := walkTree(ctx, path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate directory size for %s: %w", path, err)
+	}
+	sizes := make([]int64, len(files))
+	err = parallel(ctx, len(files), func(i int) error {
+		info, err := files[i].d.Info()
+		if err != nil {
+			return err
+		}
+		sizes[i] = info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate directory size for %s: %w", path, err)
+	}
+	var size int64
+	for _, n := range sizes {
+		size += n
+	}
+	return size, nil
+}
+
+// HashDir returns the size and checksum, computed with a hash from newHash, of every
+// file below root, keyed by its path relative to root. Several files are hashed at once.
+// A missing root has no files.
+func HashDir(ctx context.Context, root string, newHash func() hash.Hash) (map[string]FileHash, error) {
+	if !DirExists(root) {
+		return map[string]FileHash{}, nil
+	}
+	_, files, err := walkTree(ctx, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	hashes := make([]FileHash, len(files))
+	err = parallel(ctx, len(files), func(i int) error {
+		var err error
+		hashes[i], err = hashFile(files[i].path, newHash())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	sums := make(map[string]FileHash, len(files))
+	for i, f := range files {
+		sums[f.rel] = hashes[i]
+	}
+	return sums, nil
+}
+
+// hashFile feeds the file at path through h
+func hashFile(path string, h hash.Hash) (FileHash, error) {
+	// #nosec G304 -- path comes from walking a directory the caller chose
+	f, err := os.Open(path)
+	if err != nil {
+		return FileHash{}, err
+	}
+	defer f.Close()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return FileHash{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return FileHash{Size: n, Sum: h.Sum(nil)}, nil
+}
@@ -0,0 +1,92 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParallelReturnsFirstError(t *testing.T) {
+	for range 20 {
+		err := parallel(context.Background(), 100, func(i int) error {
+			if i == 30 || i == 31 || i == 90 {
+				return fmt.Errorf("item %d", i)
+			}
+			return nil
+		})
+		if err == nil || err.Error() != "item 30" {
+			t.Fatalf("err = %v, want item 30", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := parallel(ctx, 10, func(int) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v after cancelling", err)
+	}
+}
+
+func TestCopyDirSizeAndHash(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	files := map[string]string{"server.properties": "motd=hi\n", "world/level.dat": "level", "world/region/r.0.0.mca": "region"}
+	for i := range 50 {
+		files[fmt.Sprintf("mods/mod%02d.jar", i)] = fmt.Sprintf("jar %d", i)
+	}
+	var size int64
+	for name, content := range files {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		size += int64(len(content))
+	}
+	if err := os.MkdirAll(filepath.Join(src, "empty"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := CopyDir(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if !DirExists(filepath.Join(dst, "empty")) {
+		t.Error("empty directory was not copied")
+	}
+	if got, err := GetDirSize(dst); err != nil || got != size {
+		t.Errorf("GetDirSize = %d, %v, want %d", got, err, size)
+	}
+
+	sums, err := HashDir(context.Background(), dst, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sums) != len(files) {
+		t.Errorf("hashed %d files, want %d", len(sums), len(files))
+	}
+	for name, content := range files {
+		want := sha256.Sum256([]byte(content))
+		got := sums[filepath.FromSlash(name)]
+		if hex.EncodeToString(got.Sum) != hex.EncodeToString(want[:]) || got.Size != int64(len(content)) {
+			t.Errorf("%s: %x, %d bytes", name, got.Sum, got.Size)
+		}
+	}
+
+	// Files that cannot be written fail the copy with the first of them in order
+	blocked := filepath.Join(t.TempDir(), "dst")
+	for _, name := range []string{"mod40.jar", "mod03.jar"} {
+		if err := os.MkdirAll(filepath.Join(blocked, "mods", name), 0o750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := CopyDir(src, blocked); err == nil || !strings.Contains(err.Error(), "mod03.jar") {
+		t.Errorf("err = %v, want the failure for mod03.jar", err)
+	}
+}
@@ -308,3 +308,45 @@ func GetPatchVersion(version string) (int, error) {
 	}
 	return v.Patch, nil
 }
+
+// nameVersionPattern matches the versions ExtractVersionFromString finds, with the number
+// and the pre-release or build suffix as separate groups
+var nameVersionPattern = regexp.MustCompile(`v?(\d+\.\d+(?:\.\d+)?)((?:-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?)`)
+
+// nameVersion returns the first version in name that is not gameVersion, as ModVersion
+// does, or the game version itself when the name holds no other
+func nameVersion(name, gameVersion string) string {
+	var first string
+	for rest := name; ; {
+		m := nameVersionPattern.FindStringSubmatchIndex(rest)
+		if m == nil {
+			return first
+		}
+		number := rest[m[2]:m[3]]
+		if gameVersion == "" || number != gameVersion {
+			return number + rest[m[4]:m[5]]
+		}
+		if first == "" {
+			first = number
+		}
+		// The game version may run into the pack version, as in "Pack-1.20.1-3.3.0"
+		rest = rest[m[3]:]
+	}
+}
+
+// CompareNames compares the versions found in two names, such as the display names
+// "Pack 1.10.1" and "Pack 1.9.9", numerically. The Minecraft version gameVersion is
+// skipped, so that "Pack (1.20.1) 3.3.0" is newer than "Pack 3.2.0 for 1.20.1"; "" skips
+// nothing. ok is false when either name holds no version, so that the caller can fall
+// back to another ordering.
+func CompareNames(a, b, gameVersion string) (cmp int, ok bool) {
+	va, err := Parse(nameVersion(a, gameVersion))
+	if err != nil {
+		return 0, false
+	}
+	vb, err := Parse(nameVersion(b, gameVersion))
+	if err != nil {
+		return 0, false
+	}
+	return va.Compare(vb), true
+}
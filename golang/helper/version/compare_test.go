@@ -23,3 +23,77 @@ func TestCompareVersions(t *testing.T) {
 		}
 	}
 }
+
+func TestConstraint(t *testing.T) {
+	cases := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{">=15.2, <15.3", "15.2.0.10", true},
+		{">=15.2, <15.3", "15.3.0", false},
+		{">=15.2, <15.3", "15.1.9", false},
+		{"1.4.*", "1.4.2", true},
+		{"1.4.x", "1.5.0", false},
+		{"1.4.2", "1.4.2", true},
+		{"==1.4.2", "1.4.2.0", true},
+		{"!=1.4.2", "1.4.2", false},
+		{"<=2", "2.0.0", true},
+		{">1.0", "no version", false},
+	}
+	for _, c := range cases {
+		con, err := ParseConstraint(c.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): %v", c.constraint, err)
+		}
+		if got := con.Match(c.version); got != c.want {
+			t.Errorf("%q.Match(%q) = %t, want %t", c.constraint, c.version, got, c.want)
+		}
+	}
+
+	for _, bad := range []string{"", ">=", ">=1.2,", ">=1.*", "one.two"} {
+		if _, err := ParseConstraint(bad); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestModVersion(t *testing.T) {
+	cases := []struct {
+		name, gameVersion, want string
+	}{
+		{"jei-1.20.1-forge-15.2.0.10", "1.20.1", "15.2.0.10"},
+		{"mc1.20.1-0.5.3", "1.20.1", "0.5.3"},
+		{"Sodium 0.5.3", "1.20.1", "0.5.3"},
+		{"1.20.1", "1.20.1", "1.20.1"},
+		{"latest", "1.20.1", ""},
+	}
+	for _, c := range cases {
+		if got := ModVersion(c.name, c.gameVersion); got != c.want {
+			t.Errorf("ModVersion(%q, %q) = %q, want %q", c.name, c.gameVersion, got, c.want)
+		}
+	}
+}
+
+func TestCompareNames(t *testing.T) {
+	cases := []struct {
+		a, b, game string
+		want       int
+		wantOK     bool
+	}{
+		{"Pack 1.10.1", "Pack 1.9.9", "", 1, true},
+		{"Server-Files-0.2.44.zip", "Server-Files-0.2.45.zip", "", -1, true},
+		{"v2.0", "2.0.0", "", 0, true},
+		{"2.0.0-beta", "2.0.0", "", -1, true},
+		{"Pack Hotfix", "Pack 1.0.0", "", 0, false},
+		// The Minecraft version comes first in one of the names
+		{"Pack (1.20.1) 3.3.0", "Pack 3.2.0 for 1.20.1", "1.20.1", 1, true},
+		{"Pack-1.20.1-3.3.0-beta", "Pack-1.20.1-3.3.0", "1.20.1", -1, true},
+	}
+	for _, c := range cases {
+		got, ok := CompareNames(c.a, c.b, c.game)
+		if got != c.want || ok != c.wantOK {
+			t.Errorf("CompareNames(%q, %q, %q) = %d, %t, want %d, %t", c.a, c.b, c.game, got, ok, c.want, c.wantOK)
+		}
+	}
+}
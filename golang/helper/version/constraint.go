@@ -0,0 +1,150 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberPattern matches dotted version numbers inside mod file names, such as 15.2.0.10
+var numberPattern = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// Constraint is a version range such as ">=1.2, <1.3" or "1.4.*". Versions are compared
+// by their dotted numbers, so it also works for versions with more than three parts.
+type Constraint struct {
+	raw     string
+	clauses []clause
+}
+
+// clause is one comparison of a constraint
+type clause struct {
+	op       string // =, !=, <, <=, >, >= or * for a wildcard
+	segments []int
+}
+
+// ParseConstraint parses comma-separated comparisons, all of which must hold. A bare
+// version means that version, and a trailing .* or .x matches every version below it.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: strings.TrimSpace(s)}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid version range %q: empty comparison", s)
+		}
+		op := "="
+		for _, candidate := range []string{">=", "<=", "!=", "==", ">", "<", "="} {
+			if strings.HasPrefix(part, candidate) {
+				op = candidate
+				part = strings.TrimSpace(strings.TrimPrefix(part, candidate))
+				break
+			}
+		}
+		if op == "==" {
+			op = "="
+		}
+		if trimmed := strings.TrimSuffix(strings.TrimSuffix(part, ".*"), ".x"); trimmed != part {
+			if op != "=" {
+				return nil, fmt.Errorf("invalid version range %q: wildcards only work without an operator", s)
+			}
+			op, part = "*", trimmed
+		}
+		segments, err := parseSegments(strings.TrimPrefix(part, "v"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", s, err)
+		}
+		c.clauses = append(c.clauses, clause{op: op, segments: segments})
+	}
+	return c, nil
+}
+
+// String returns the constraint as written
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Match reports whether version, such as "15.2.0.10", is in the range. Versions without
+// a number never match.
+func (c *Constraint) Match(version string) bool {
+	segments, err := parseSegments(numberPattern.FindString(version))
+	if err != nil {
+		return false
+	}
+	for _, cl := range c.clauses {
+		cmp := compareSegments(segments, cl.segments)
+		var ok bool
+		switch cl.op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "*":
+			ok = len(segments) >= len(cl.segments) && compareSegments(segments[:len(cl.segments)], cl.segments) == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ModVersion returns the version number in a mod's version name, skipping the Minecraft
+// version many mods put in front of it: "jei-1.20.1-forge-15.2.0.10" with game version
+// 1.20.1 gives "15.2.0.10". It returns "" when the name holds no version number.
+func ModVersion(name, gameVersion string) string {
+	numbers := numberPattern.FindAllString(name, -1)
+	for _, n := range numbers {
+		if n != gameVersion {
+			return n
+		}
+	}
+	if len(numbers) > 0 {
+		return numbers[0]
+	}
+	return ""
+}
+
+// parseSegments splits a dotted version number into its parts
+func parseSegments(s string) ([]int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("no version number")
+	}
+	parts := strings.Split(s, ".")
+	segments := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a version number", s)
+		}
+		segments[i] = n
+	}
+	return segments, nil
+}
+
+// compareSegments compares two version numbers, treating missing parts as 0
+func compareSegments(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
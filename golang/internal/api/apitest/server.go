@@ -0,0 +1,359 @@
+// Package apitest provides a fake CurseForge API for tests. It serves mods and files
+// added to it, pages file listings like the real API, and can be told to answer any
+// path with an error, a rate limit or malformed JSON.
+package apitest
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+)
+
+//go:embed testdata/*.json
+var fixtures embed.FS
+
+// JEIModID is the mod loaded by LoadFixtures
+const JEIModID = 238222
+
+// Server is a fake CurseForge API
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	mods       map[int]api.ModInfo
+	files      map[int][]api.ModFile // by mod, in listing order
+	changelogs map[int]string        // by file
+	blobs      map[int][]byte        // file contents, by file
+	overrides  map[string]override   // by URL path
+	requests   []string
+}
+
+// override is a canned answer for a path
+type override struct {
+	status     int
+	body       string
+	retryAfter string
+	times      int // answered this many times, 0 for always
+}
+
+// NewServer starts an empty fake API that is closed when the test ends
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{
+		mods:       map[int]api.ModInfo{},
+		files:      map[int][]api.ModFile{},
+		changelogs: map[int]string{},
+		blobs:      map[int][]byte{},
+		overrides:  map[string]override{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /mods/{mod}", s.getMod)
+	mux.HandleFunc("GET /mods/{mod}/files", s.getModFiles)
+	mux.HandleFunc("GET /mods/{mod}/files/{file}", s.getModFile)
+	mux.HandleFunc("GET /mods/{mod}/files/{file}/download-url", s.getDownloadURL)
+	mux.HandleFunc("GET /mods/{mod}/files/{file}/changelog", s.getChangelog)
+	mux.HandleFunc("POST /mods", s.postMods)
+	mux.HandleFunc("POST /mods/files", s.postFiles)
+	mux.HandleFunc("GET /download/{file}", s.download)
+	s.Server = httptest.NewServer(s.intercept(mux))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// LoadFixtures adds the canned mods from testdata: JEI with a Forge release, a Forge
+// beta and a Fabric release for 1.20.1
+func (s *Server) LoadFixtures(t testing.TB) {
+	t.Helper()
+	var fixture struct {
+		Mod        api.ModInfo       `json:"mod"`
+		Files      []api.ModFile     `json:"files"`
+		Changelogs map[string]string `json:"changelogs"`
+	}
+	data, err := fixtures.ReadFile("testdata/jei.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		t.Fatalf("invalid fixture: %v", err)
+	}
+	s.AddMod(fixture.Mod, fixture.Files...)
+	for id, changelog := range fixture.Changelogs {
+		fileID, _ := strconv.Atoi(id)
+		s.SetChangelog(fileID, changelog)
+	}
+}
+
+// Client returns an API client for the server
+func (s *Server) Client() *api.Client {
+	client := api.NewClient("test-key")
+	client.BaseURL = s.URL
+	return client
+}
+
+// AddMod adds mod with its files, listed in the given order. Files without a download
+// URL get one on the server; their content is set with SetContent.
+func (s *Server) AddMod(mod api.ModInfo, files ...api.ModFile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range files {
+		files[i].ModID = mod.ID
+		if files[i].DownloadURL == "" {
+			files[i].DownloadURL = fmt.Sprintf("%s/download/%d", s.URL, files[i].ID)
+		}
+	}
+	s.mods[mod.ID] = mod
+	s.files[mod.ID] = append(s.files[mod.ID], files...)
+}
+
+// SetContent sets what downloading a file returns
+func (s *Server) SetContent(fileID int, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[fileID] = content
+}
+
+// SetChangelog sets the changelog of a file, as HTML
+func (s *Server) SetChangelog(fileID int, changelog string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changelogs[fileID] = changelog
+}
+
+// Fail answers the next times requests for path, such as "/mods/1", with status and
+// body; times 0 means every request
+func (s *Server) Fail(path string, status int, body string, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[path] = override{status: status, body: body, times: times}
+}
+
+// RateLimit answers the next times requests for path with 429 Too Many Requests,
+// asking to retry after retryAfter seconds
+func (s *Server) RateLimit(path string, retryAfter, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[path] = override{status: http.StatusTooManyRequests, retryAfter: strconv.Itoa(retryAfter), times: times}
+}
+
+// Malform answers every request for path with a body that is not valid JSON
+func (s *Server) Malform(path string) {
+	s.Fail(path, http.StatusOK, `{"data": {`, 0)
+}
+
+// Requests returns the requests served so far, as "GET /mods/1?index=50"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// intercept records requests and answers overridden paths
+func (s *Server) intercept(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		request := r.Method + " " + r.URL.Path
+		if r.URL.RawQuery != "" {
+			request += "?" + r.URL.RawQuery
+		}
+		s.requests = append(s.requests, request)
+		o, ok := s.overrides[r.URL.Path]
+		if ok && o.times > 0 {
+			if o.times--; o.times == 0 {
+				delete(s.overrides, r.URL.Path)
+			} else {
+				s.overrides[r.URL.Path] = o
+			}
+		}
+		s.mu.Unlock()
+
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if o.retryAfter != "" {
+			w.Header().Set("Retry-After", o.retryAfter)
+		}
+		w.WriteHeader(o.status)
+		_, _ = w.Write([]byte(o.body))
+	})
+}
+
+func (s *Server) getMod(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mod, ok := s.mods[pathInt(r, "mod")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeData(w, mod, nil)
+}
+
+// getModFiles filters by gameVersion and modLoaderType and pages like the real API
+func (s *Server) getModFiles(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, ok := s.files[pathInt(r, "mod")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	gameVersion := query.Get("gameVersion")
+	loader, _ := strconv.Atoi(query.Get("modLoaderType"))
+	var matching []api.ModFile
+	for _, f := range all {
+		if (gameVersion == "" || hasFold(f.GameVersions, gameVersion)) &&
+			(loader == 0 || hasFold(f.GameVersions, api.ModLoaderName(loader))) {
+			matching = append(matching, f)
+		}
+	}
+
+	index, _ := strconv.Atoi(query.Get("index"))
+	pageSize, err := strconv.Atoi(query.Get("pageSize"))
+	if err != nil || pageSize <= 0 || pageSize > api.MaxPageSize {
+		pageSize = api.MaxPageSize
+	}
+	page := []api.ModFile{}
+	if index < len(matching) {
+		page = matching[index:min(index+pageSize, len(matching))]
+	}
+	writeData(w, page, &api.Pagination{Index: index, PageSize: pageSize, ResultCount: len(page), TotalCount: len(matching)})
+}
+
+func (s *Server) getModFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.file(pathInt(r, "mod"), pathInt(r, "file"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeData(w, f, nil)
+}
+
+func (s *Server) getDownloadURL(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.file(pathInt(r, "mod"), pathInt(r, "file"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if mod := s.mods[f.ModID]; !mod.AllowModDistribution {
+		writeData(w, nil, nil)
+		return
+	}
+	writeData(w, f.DownloadURL, nil)
+}
+
+func (s *Server) getChangelog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.file(pathInt(r, "mod"), pathInt(r, "file")); !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeData(w, s.changelogs[pathInt(r, "file")], nil)
+}
+
+// postMods answers a batch lookup; unknown IDs are left out
+func (s *Server) postMods(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ModIDs []int `json:"modIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mods := []api.ModInfo{}
+	for _, id := range body.ModIDs {
+		if mod, ok := s.mods[id]; ok {
+			mods = append(mods, mod)
+		}
+	}
+	writeData(w, mods, nil)
+}
+
+// postFiles answers a batch lookup; unknown IDs are left out
+func (s *Server) postFiles(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		FileIDs []int `json:"fileIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := []api.ModFile{}
+	for _, id := range body.FileIDs {
+		for _, modFiles := range s.files {
+			for _, f := range modFiles {
+				if f.ID == id {
+					files = append(files, f)
+				}
+			}
+		}
+	}
+	writeData(w, files, nil)
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, ok := s.blobs[pathInt(r, "file")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = w.Write(content)
+}
+
+// file returns a file of a mod; the caller holds s.mu
+func (s *Server) file(modID, fileID int) (api.ModFile, bool) {
+	for _, f := range s.files[modID] {
+		if f.ID == fileID {
+			return f, true
+		}
+	}
+	return api.ModFile{}, false
+}
+
+// writeData writes data in the envelope of the real API
+func writeData(w http.ResponseWriter, data interface{}, page *api.Pagination) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Data       interface{}     `json:"data"`
+		Pagination *api.Pagination `json:"pagination,omitempty"`
+	}{data, page})
+}
+
+// pathInt returns a numeric path value, or -1
+func pathInt(r *http.Request, name string) int {
+	n, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// hasFold reports whether values holds s, ignoring case
+func hasFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Results of VerifyAPIKey
+const (
+	KeyValid       = "valid"
+	KeyMissing     = "missing"
+	KeyInvalid     = "invalid"
+	KeyBlocked     = "blocked" // refused in front of the API, usually because of the client IP
+	KeyRateLimited = "rate_limited"
+	KeyError       = "error"
+)
+
+// KeyStatus is the outcome of checking the API key
+type KeyStatus struct {
+	Result     string
+	StatusCode int
+	RateLimits map[string]string // rate-limit headers of the response, by canonical name
+	Message    string            // start of the response body when the key was not accepted
+}
+
+// Err describes why the key was not accepted, or returns nil for a valid key
+func (s *KeyStatus) Err() error {
+	switch s.Result {
+	case KeyValid:
+		return nil
+	case KeyMissing:
+		return fmt.Errorf("no API key is configured")
+	case KeyInvalid:
+		return fmt.Errorf("API key rejected with status %d", s.StatusCode)
+	case KeyBlocked:
+		return fmt.Errorf("request blocked with status %d before the API key was checked", s.StatusCode)
+	case KeyRateLimited:
+		return fmt.Errorf("rate limited with status %d", s.StatusCode)
+	default:
+		return fmt.Errorf("API request failed with status %d: %s", s.StatusCode, s.Message)
+	}
+}
+
+// VerifyAPIKey calls a cheap authenticated endpoint, bypassing the cache, and reports whether
+// the key was accepted together with the rate-limit headers of the response. An error is only
+// returned when the API could not be reached.
+func (c *Client) VerifyAPIKey() (*KeyStatus, error) {
+	if strings.TrimSpace(c.APIKey) == "" {
+		return &KeyStatus{Result: KeyMissing}, nil
+	}
+	req, err := c.newRequest(http.MethodGet, fmt.Sprintf("/games/%d", GameIDMinecraft), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	status := &KeyStatus{StatusCode: resp.StatusCode, RateLimits: map[string]string{}}
+	for name, values := range resp.Header {
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "ratelimit") || strings.Contains(lower, "rate-limit") || lower == "retry-after" {
+			status.RateLimits[name] = strings.Join(values, ", ")
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		status.Message = strings.TrimSpace(string(body))
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		status.Result = KeyValid
+	case resp.StatusCode == http.StatusTooManyRequests:
+		status.Result = KeyRateLimited
+	case resp.StatusCode == http.StatusUnauthorized:
+		status.Result = KeyInvalid
+	case resp.StatusCode == http.StatusForbidden:
+		// The API answers a bad key with an empty or JSON body; an HTML page comes from the
+		// CDN or firewall in front of it
+		status.Result = KeyInvalid
+		if strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+			status.Result = KeyBlocked
+		}
+	default:
+		status.Result = KeyError
+	}
+	return status, nil
+}
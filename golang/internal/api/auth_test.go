@@ -0,0 +1,59 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyAPIKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("x-api-key") {
+		case "good":
+			w.Header().Set("X-RateLimit-Remaining", "99")
+			_, _ = io.WriteString(w, `{"data":{"id":432}}`)
+		case "busy":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "blocked":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, "<html>Request blocked</html>")
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		key        string
+		result     string
+		rateLimits map[string]string
+	}{
+		{"", KeyMissing, nil},
+		{"good", KeyValid, map[string]string{"X-Ratelimit-Remaining": "99"}},
+		{"bad", KeyInvalid, nil},
+		{"blocked", KeyBlocked, nil},
+		{"busy", KeyRateLimited, map[string]string{"Retry-After": "30"}},
+	}
+	for _, tt := range tests {
+		client := NewClient(tt.key)
+		client.BaseURL = srv.URL
+		status, err := client.VerifyAPIKey()
+		if err != nil {
+			t.Fatalf("VerifyAPIKey(%q): %v", tt.key, err)
+		}
+		if status.Result != tt.result {
+			t.Errorf("VerifyAPIKey(%q) = %s, want %s", tt.key, status.Result, tt.result)
+		}
+		if (status.Err() == nil) != (tt.result == KeyValid) {
+			t.Errorf("VerifyAPIKey(%q).Err() = %v", tt.key, status.Err())
+		}
+		for name, want := range tt.rateLimits {
+			if got := status.RateLimits[name]; got != want {
+				t.Errorf("VerifyAPIKey(%q) rate limit %s = %q, want %q", tt.key, name, got, want)
+			}
+		}
+	}
+}
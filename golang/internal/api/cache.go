@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+)
+
+// Cache keeps successful GET responses for a TTL. Once an entry expires it is revalidated
+// with If-None-Match / If-Modified-Since, so an unchanged resource costs a 304 instead of
+// a full response. Entries are kept in memory and, when a directory is set, on disk so
+// they survive between runs.
+type Cache struct {
+	ttl     time.Duration
+	dir     string
+	clock   clock.Clock
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry is one cached response body with its validators
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	Body         []byte    `json:"body"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Stored       time.Time `json:"stored"`
+}
+
+// NewCache creates a cache whose entries are fresh for ttl; dir may be empty to keep
+// entries in memory only
+func NewCache(ttl time.Duration, dir string) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		dir:     dir,
+		clock:   clock.Real(),
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// SetClock replaces the clock used to expire entries, for tests
+func (c *Cache) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+// cachedRequest performs a GET through the cache. Only 200 responses are stored; anything
+// else is passed through untouched.
+func (c *Client) cachedRequest(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+	entry, fresh := c.Cache.get(key)
+	if entry != nil && fresh {
+		c.logger().Debug("api cache hit", "path", req.URL.Path)
+		return cachedResponse(req, entry.Body), nil
+	}
+	if entry != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		resp.Body.Close()
+		c.Cache.put(entry)
+		c.logger().Debug("api cache revalidated", "path", req.URL.Path)
+		return cachedResponse(req, entry.Body), nil
+	case resp.StatusCode == http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		c.Cache.put(&cacheEntry{
+			URL:          key,
+			Body:         body,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		})
+		return cachedResponse(req, body), nil
+	default:
+		return resp, nil
+	}
+}
+
+// cachedResponse builds a 200 response for req with the given body
+func cachedResponse(req *http.Request, body []byte) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// get returns the entry for url and whether it is still fresh
+func (c *Cache) get(url string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[url]
+	if !ok {
+		if entry = c.load(url); entry == nil {
+			return nil, false
+		}
+		c.entries[url] = entry
+	}
+	return entry, c.clock.Now().Sub(entry.Stored) < c.ttl
+}
+
+// put stores or refreshes the entry for its URL
+func (c *Cache) put(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.Stored = c.clock.Now()
+	c.entries[entry.URL] = entry
+	c.save(entry)
+}
+
+// file returns where the entry for url is stored on disk
+func (c *Cache) file(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load reads an entry from disk; a missing or unreadable file is a cache miss
+func (c *Cache) load(url string) *cacheEntry {
+	if c.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.file(url))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		return nil
+	}
+	return &entry
+}
+
+// save writes an entry to disk; failures only cost a cache miss on the next run
+func (c *Cache) save(entry *cacheEntry) {
+	if c.dir == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0750); err != nil {
+		return
+	}
+	tmp := c.file(entry.URL) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, c.file(entry.URL)); err != nil {
+		_ = os.Remove(tmp)
+	}
+}
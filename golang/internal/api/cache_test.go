@@ -0,0 +1,88 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+)
+
+func TestCachedRequest(t *testing.T) {
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, `{"data":{"id":42,"name":"Test Pack"}}`)
+	}))
+	defer srv.Close()
+
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	cache := NewCache(time.Minute, t.TempDir())
+	cache.SetClock(fake)
+
+	client := NewClient("key")
+	client.BaseURL = srv.URL
+	client.Cache = cache
+
+	get := func() {
+		t.Helper()
+		mod, err := client.GetMod(42)
+		if err != nil {
+			t.Fatalf("GetMod: %v", err)
+		}
+		if mod.Name != "Test Pack" {
+			t.Fatalf("name = %q, want %q", mod.Name, "Test Pack")
+		}
+	}
+
+	get()
+	get()
+	if requests != 1 {
+		t.Fatalf("requests = %d after a fresh hit, want 1", requests)
+	}
+
+	fake.Advance(2 * time.Minute)
+	get()
+	if requests != 2 || notModified != 1 {
+		t.Fatalf("requests = %d, 304s = %d after expiry, want 2 and 1", requests, notModified)
+	}
+
+	// A new cache on the same directory picks up the stored entry
+	reloaded := NewCache(time.Minute, cache.dir)
+	reloaded.SetClock(fake)
+	client.Cache = reloaded
+	get()
+	if requests != 2 {
+		t.Fatalf("requests = %d with the on-disk entry, want 2", requests)
+	}
+}
+
+func TestCachedRequestSkipsErrors(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := NewClient("key")
+	client.BaseURL = srv.URL
+	client.Cache = NewCache(time.Minute, "")
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetMod(42); err == nil {
+			t.Fatal("GetMod succeeded on a 503")
+		}
+	}
+	if requests != 2 {
+		t.Fatalf("requests = %d, want 2; errors must not be cached", requests)
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// postJSON sends body as JSON to path and decodes the data of the response into out
+func (c *Client) postJSON(path string, body, out interface{}) error {
+	req, err := c.newRequest(http.MethodPost, path, nil)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// GetMods retrieves several mods in one request to the batch endpoint POST /v1/mods;
+// unknown IDs are left out
+func (c *Client) GetMods(modIDs []int) ([]ModInfo, error) {
+	var result APIResponse[[]ModInfo]
+	if err := c.postJSON("/mods", map[string][]int{"modIds": modIDs}, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// GetFiles retrieves several files, of any mods, in one request to the batch endpoint
+// POST /v1/mods/files; unknown IDs are left out
+func (c *Client) GetFiles(fileIDs []int) ([]ModFile, error) {
+	var result APIResponse[[]ModFile]
+	if err := c.postJSON("/mods/files", map[string][]int{"fileIds": fileIDs}, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// GetModFileChangelog retrieves the changelog of a file as plain text
+func (c *Client) GetModFileChangelog(modID, fileID int) (string, error) {
+	path := fmt.Sprintf("/mods/%d/files/%d/changelog", modID, fileID)
+
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result APIResponse[string]
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	return HTMLToText(result.Data), nil
+}
+
+var (
+	htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|h[1-6])>`)
+	htmlTag   = regexp.MustCompile(`<[^>]*>`)
+	blankRuns = regexp.MustCompile(`[ \t\x{00a0}]+`)
+)
+
+// HTMLToText reduces the HTML that CurseForge uses for descriptions and changelogs to
+// plain text lines
+func HTMLToText(s string) string {
+	s = htmlBreak.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(htmlTag.ReplaceAllString(s, ""))
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(blankRuns.ReplaceAllString(line, " ")); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -11,12 +13,25 @@ import (
 	"time"
 )
 
+// ErrDistributionDisallowed is returned for files the author only allows to be downloaded
+// from the CurseForge website (allowModDistribution is false)
+var ErrDistributionDisallowed = errors.New("the author does not allow third-party downloads")
+
 // Client wraps configuration and HTTP client for CurseForge API
 type Client struct {
 	APIKey     string
 	BaseURL    string
 	UserAgent  string
 	HTTPClient *http.Client
+	// DownloadClient fetches files; defaults to HTTPClient, whose timeout may be too short for large packs
+	DownloadClient *http.Client
+	Logger         *slog.Logger // defaults to slog.Default()
+	Cache          *Cache       // caches GET responses when set
+	// DownloadHost, such as http://cache:8080, replaces the scheme and host of files on the
+	// CurseForge CDN; empty downloads them from the CDN
+	DownloadHost string
+
+	sleep func(time.Duration) // waits out rate limits; time.Sleep when nil
 }
 
 // NewClient creates a new CurseForge API client
@@ -31,15 +46,42 @@ func NewClient(apiKey string) *Client {
 	}
 }
 
+// CurseForge is the part of the API that the updater and the mod providers use. Client
+// implements it; tests can substitute a fake, or a Client pointed at an apitest.Server.
+type CurseForge interface {
+	GetMod(modID int) (*ModInfo, error)
+	GetMods(modIDs []int) ([]ModInfo, error)
+	GetModFile(modID, fileID int) (*ModFile, error)
+	GetFiles(fileIDs []int) ([]ModFile, error)
+	GetAllModFiles(modID int, gameVersion string, modLoaderType int) ([]ModFile, error)
+	GetModFileDownloadURL(modID, fileID int) (string, error)
+	GetModFileChangelog(modID, fileID int) (string, error)
+	DownloadFile(url string, writer io.Writer) error
+	DownloadFileProgress(url string, writer io.Writer, fn ProgressFunc) error
+}
+
+var _ CurseForge = (*Client)(nil)
+
+// logger returns the configured logger or the default one
+func (c *Client) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
+}
+
 // addHeaders sets required headers for each request
 func (c *Client) addHeaders(req *http.Request) {
-	req.Header.Set("x-api-key", c.APIKey)
+	if c.APIKey != "" {
+		// A proxy in front of the API may add the key itself
+		req.Header.Set("x-api-key", c.APIKey)
+	}
 	req.Header.Set("User-Agent", c.UserAgent)
 	req.Header.Set("Accept", "application/json")
 }
 
-// doRequest performs an HTTP request and returns the response
-func (c *Client) doRequest(method, path string, params map[string]string) (*http.Response, error) {
+// newRequest builds a request for path on the API with the given query parameters
+func (c *Client) newRequest(method, path string, params map[string]string) (*http.Request, error) {
 	// Build URL with parameters
 	u, err := url.Parse(c.BaseURL + path)
 	if err != nil {
@@ -61,16 +103,52 @@ func (c *Client) doRequest(method, path string, params map[string]string) (*http
 	}
 
 	c.addHeaders(req)
+	return req, nil
+}
+
+// doRequest performs an HTTP request and returns the response, served from the cache when possible
+func (c *Client) doRequest(method, path string, params map[string]string) (*http.Response, error) {
+	req, err := c.newRequest(method, path, params)
+	if err != nil {
+		return nil, err
+	}
+	if c.Cache != nil && method == http.MethodGet {
+		return c.cachedRequest(req)
+	}
+	return c.send(req)
+}
 
-	// Perform request
+// send performs req against the API
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	started := time.Now()
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
+		c.logger().Debug("api request failed", "method", req.Method, "path", req.URL.Path, "error", err)
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
+	c.logger().Debug("api request", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "duration", time.Since(started))
 
 	return resp, nil
 }
 
+// getJSON performs a GET request for path and decodes the response into out
+func (c *Client) getJSON(path string, params map[string]string, out interface{}) error {
+	resp, err := c.doRequest("GET", path, params)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
 // GetMod retrieves information about a specific mod
 func (c *Client) GetMod(modID int) (*ModInfo, error) {
 	path := fmt.Sprintf("/mods/%d", modID)
@@ -98,8 +176,15 @@ func (c *Client) GetMod(modID int) (*ModInfo, error) {
 	return &result.Data, nil
 }
 
-// GetModFiles retrieves files for a specific mod
+// GetModFiles retrieves one page of files for a specific mod, see EachModFile for all of them
 func (c *Client) GetModFiles(modID int, gameVersion string, modLoaderType int, pageSize int, index int) ([]ModFile, error) {
+	files, _, err := c.GetModFilesPage(modID, gameVersion, modLoaderType, pageSize, index)
+	return files, err
+}
+
+// GetModFilesPage retrieves one page of files for a specific mod with its pagination,
+// which is nil when the API did not report one
+func (c *Client) GetModFilesPage(modID int, gameVersion string, modLoaderType int, pageSize int, index int) ([]ModFile, *Pagination, error) {
 	path := fmt.Sprintf("/mods/%d/files", modID)
 
 	params := make(map[string]string)
@@ -118,21 +203,24 @@ func (c *Client) GetModFiles(modID int, gameVersion string, modLoaderType int, p
 
 	resp, err := c.doRequest("GET", path, params)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, nil, &RateLimitError{RetryAfter: retryAfter(resp)}
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var result APIResponse[[]ModFile]
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return result.Data, nil
+	return result.Data, result.Pagination, nil
 }
 
 // GetModFile retrieves a specific mod file
@@ -172,6 +260,10 @@ func (c *Client) GetModFileDownloadURL(modID, fileID int) (string, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusForbidden {
+		return "", fmt.Errorf("file %d of mod %d: %w", fileID, modID, ErrDistributionDisallowed)
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
@@ -181,18 +273,24 @@ func (c *Client) GetModFileDownloadURL(modID, fileID int) (string, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
+	if result.Data == "" {
+		return "", fmt.Errorf("file %d of mod %d: %w", fileID, modID, ErrDistributionDisallowed)
+	}
 
 	return result.Data, nil
 }
 
 // SearchMods searches for mods based on various criteria
-func (c *Client) SearchMods(gameID int, categoryID int, searchFilter string, sortField int, sortOrder string, gameVersion string, pageSize int, index int) ([]ModInfo, error) {
+func (c *Client) SearchMods(gameID int, classID int, categoryID int, searchFilter string, sortField int, sortOrder string, gameVersion string, modLoaderType int, pageSize int, index int) ([]ModInfo, error) {
 	path := "/mods/search"
 
 	params := make(map[string]string)
 	if gameID > 0 {
 		params["gameId"] = strconv.Itoa(gameID)
 	}
+	if classID > 0 {
+		params["classId"] = strconv.Itoa(classID)
+	}
 	if categoryID > 0 {
 		params["categoryId"] = strconv.Itoa(categoryID)
 	}
@@ -208,6 +306,9 @@ func (c *Client) SearchMods(gameID int, categoryID int, searchFilter string, sor
 	if gameVersion != "" {
 		params["gameVersion"] = gameVersion
 	}
+	if modLoaderType > 0 {
+		params["modLoaderType"] = strconv.Itoa(modLoaderType)
+	}
 	if pageSize > 0 {
 		params["pageSize"] = strconv.Itoa(pageSize)
 	}
@@ -257,6 +358,15 @@ func (c *Client) GetGameVersions(gameID int) ([]GameVersion, error) {
 	return result.Data, nil
 }
 
+// ValidateAPIKey checks the API key against a lightweight endpoint
+func (c *Client) ValidateAPIKey() error {
+	status, err := c.VerifyAPIKey()
+	if err != nil {
+		return err
+	}
+	return status.Err()
+}
+
 // CheckIfModExists checks if a mod with the given ID exists
 func (c *Client) CheckIfModExists(modID int) (bool, error) {
 	_, err := c.GetMod(modID)
@@ -271,11 +381,12 @@ func (c *Client) CheckIfModExists(modID int) (bool, error) {
 
 // GetLatestModFile retrieves the latest file for a mod based on game version and release type
 func (c *Client) GetLatestModFile(modID int, gameVersion string, releaseType int) (*ModFile, error) {
-	files, err := c.GetModFiles(modID, gameVersion, 0, 50, 0)
+	files, err := c.GetAllModFiles(modID, gameVersion, 0)
 	if err != nil {
 		return nil, err
 	}
 
+	files = ReleasedFiles(files)
 	if len(files) == 0 {
 		return nil, fmt.Errorf("no files found for mod %d", modID)
 	}
@@ -296,20 +407,50 @@ func (c *Client) GetLatestModFile(modID int, gameVersion string, releaseType int
 		return nil, fmt.Errorf("no files found for mod %d with release type %d", modID, releaseType)
 	}
 
-	// Return the most recent file (files are usually sorted by date)
 	return &filteredFiles[0], nil
 }
 
+// cdnDomain is the domain CurseForge serves files from, e.g. edge.forgecdn.net
+const cdnDomain = "forgecdn.net"
+
+// downloadURL points raw at DownloadHost when it is a file on the CurseForge CDN
+func (c *Client) downloadURL(raw string) string {
+	if c.DownloadHost == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Hostname() != cdnDomain && !strings.HasSuffix(u.Hostname(), "."+cdnDomain)) {
+		return raw
+	}
+	host, err := url.Parse(c.DownloadHost)
+	if err != nil || host.Host == "" {
+		return raw
+	}
+	u.Scheme, u.Host = host.Scheme, host.Host
+	u.Path = strings.TrimSuffix(host.Path, "/") + u.Path
+	u.RawPath = ""
+	return u.String()
+}
+
 // DownloadFile downloads a file from the given URL
 func (c *Client) DownloadFile(url string, writer io.Writer) error {
-	req, err := http.NewRequest("GET", url, nil)
+	return c.DownloadFileProgress(url, writer, nil)
+}
+
+// DownloadFileProgress downloads a file from the given URL, reporting progress to fn if set
+func (c *Client) DownloadFileProgress(url string, writer io.Writer, fn ProgressFunc) error {
+	req, err := http.NewRequest("GET", c.downloadURL(url), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create download request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", c.UserAgent)
 
-	resp, err := c.HTTPClient.Do(req)
+	client := c.DownloadClient
+	if client == nil {
+		client = c.HTTPClient
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("download request failed: %w", err)
 	}
@@ -319,10 +460,21 @@ func (c *Client) DownloadFile(url string, writer io.Writer) error {
 		return fmt.Errorf("download failed with status %d", resp.StatusCode)
 	}
 
-	_, err = io.Copy(writer, resp.Body)
+	var progress *ProgressWriter
+	if fn != nil {
+		progress = NewProgressWriter(writer, max(resp.ContentLength, 0), fn)
+		writer = progress
+	}
+
+	started := time.Now()
+	n, err := io.Copy(writer, resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to write downloaded data: %w", err)
 	}
+	if progress != nil {
+		progress.Finish()
+	}
+	c.logger().Debug("download complete", "bytes", n, "duration", time.Since(started))
 
 	return nil
 }
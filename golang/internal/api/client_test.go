@@ -0,0 +1,242 @@
+package api_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api/apitest"
+)
+
+func TestClientResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(s *apitest.Server)
+		call    func(c *api.Client) (interface{}, error)
+		want    interface{}
+		wantErr string
+	}{
+		{
+			name: "mod",
+			call: func(c *api.Client) (interface{}, error) {
+				mod, err := c.GetMod(apitest.JEIModID)
+				if err != nil {
+					return nil, err
+				}
+				return mod.Slug, nil
+			},
+			want: "jei",
+		},
+		{
+			name: "unknown mod",
+			call: func(c *api.Client) (interface{}, error) {
+				return c.GetMod(1)
+			},
+			wantErr: "mod with ID 1 not found",
+		},
+		{
+			name:    "rate limited",
+			prepare: func(s *apitest.Server) { s.RateLimit("/mods/238222", 30, 1) },
+			call: func(c *api.Client) (interface{}, error) {
+				return c.GetMod(apitest.JEIModID)
+			},
+			wantErr: "status 429",
+		},
+		{
+			name:    "server error",
+			prepare: func(s *apitest.Server) { s.Fail("/mods/238222", http.StatusInternalServerError, "boom", 0) },
+			call: func(c *api.Client) (interface{}, error) {
+				return c.GetMod(apitest.JEIModID)
+			},
+			wantErr: "status 500: boom",
+		},
+		{
+			name:    "malformed JSON",
+			prepare: func(s *apitest.Server) { s.Malform("/mods/238222") },
+			call: func(c *api.Client) (interface{}, error) {
+				return c.GetMod(apitest.JEIModID)
+			},
+			wantErr: "failed to decode response",
+		},
+		{
+			name: "file",
+			call: func(c *api.Client) (interface{}, error) {
+				f, err := c.GetModFile(apitest.JEIModID, 5101366)
+				if err != nil {
+					return nil, err
+				}
+				return f.FileDate.Format(time.DateOnly), nil
+			},
+			want: "2024-02-03",
+		},
+		{
+			name: "unknown file",
+			call: func(c *api.Client) (interface{}, error) {
+				return c.GetModFile(apitest.JEIModID, 1)
+			},
+			wantErr: "file with ID 1 not found",
+		},
+		{
+			name: "files for a loader",
+			call: func(c *api.Client) (interface{}, error) {
+				files, err := c.GetAllModFiles(apitest.JEIModID, "1.20.1", api.ModLoaderTypeFabric)
+				return len(files), err
+			},
+			want: 1,
+		},
+		{
+			name: "latest release",
+			call: func(c *api.Client) (interface{}, error) {
+				f, err := c.GetLatestModFile(apitest.JEIModID, "1.20.1", api.ReleaseTypeRelease)
+				if err != nil {
+					return nil, err
+				}
+				return f.ID, nil
+			},
+			want: 5101366,
+		},
+		{
+			name:    "malformed file listing",
+			prepare: func(s *apitest.Server) { s.Malform("/mods/238222/files") },
+			call: func(c *api.Client) (interface{}, error) {
+				return c.GetAllModFiles(apitest.JEIModID, "", 0)
+			},
+			wantErr: "failed to decode response",
+		},
+		{
+			name: "batch of mods",
+			call: func(c *api.Client) (interface{}, error) {
+				mods, err := c.GetMods([]int{apitest.JEIModID, 1})
+				return len(mods), err
+			},
+			want: 1,
+		},
+		{
+			name: "changelog",
+			call: func(c *api.Client) (interface{}, error) {
+				return c.GetModFileChangelog(apitest.JEIModID, 5101366)
+			},
+			want: "Fixed recipe lookups",
+		},
+		{
+			name: "distribution disallowed",
+			prepare: func(s *apitest.Server) {
+				s.AddMod(api.ModInfo{ID: 7}, api.ModFile{ID: 70})
+			},
+			call: func(c *api.Client) (interface{}, error) {
+				return c.GetModFileDownloadURL(7, 70)
+			},
+			wantErr: api.ErrDistributionDisallowed.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.NewServer(t)
+			s.LoadFixtures(t)
+			if tt.prepare != nil {
+				tt.prepare(s)
+			}
+			got, err := tt.call(s.Client())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientPagesThroughFiles(t *testing.T) {
+	s := apitest.NewServer(t)
+	var files []api.ModFile
+	for id := 1; id <= 2*api.MaxPageSize+10; id++ {
+		files = append(files, api.ModFile{ID: id, DisplayName: fmt.Sprintf("file %d", id), GameVersions: []string{"1.20.1"}})
+	}
+	s.AddMod(api.ModInfo{ID: 1}, files...)
+	s.RateLimit("/mods/1/files", 0, 1)
+
+	got, err := s.Client().GetAllModFiles(1, "1.20.1", 0)
+	if err != nil {
+		t.Fatalf("GetAllModFiles: %v", err)
+	}
+	if len(got) != len(files) || got[len(got)-1].ID != len(files) {
+		t.Fatalf("got %d files, want %d", len(got), len(files))
+	}
+	// One rate limited request, then three pages
+	if requests := s.Requests(); len(requests) != 4 {
+		t.Fatalf("requests = %v, want 4", requests)
+	}
+}
+
+func TestClientDownloads(t *testing.T) {
+	s := apitest.NewServer(t)
+	s.LoadFixtures(t)
+	s.SetContent(5101366, []byte("jar"))
+	client := s.Client()
+
+	url, err := client.GetModFileDownloadURL(apitest.JEIModID, 5101366)
+	if err != nil {
+		t.Fatalf("GetModFileDownloadURL: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := client.DownloadFile(url, &buf); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	if buf.String() != "jar" {
+		t.Fatalf("downloaded %q, want %q", buf.String(), "jar")
+	}
+	if err := client.DownloadFile(s.URL+"/download/1", &buf); err == nil {
+		t.Fatal("downloading a missing file succeeded")
+	}
+	if _, err := client.GetModFileDownloadURL(apitest.JEIModID, 1); errors.Is(err, api.ErrDistributionDisallowed) || err == nil {
+		t.Fatalf("unknown file: error %v, want a 404", err)
+	}
+}
+
+func TestClientThroughProxy(t *testing.T) {
+	var paths, keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		keys = append(keys, r.Header.Get("x-api-key"))
+		if strings.HasPrefix(r.URL.Path, "/cdn/") {
+			_, _ = w.Write([]byte("jar"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"id":1,"name":"Pack"}}`))
+	}))
+	defer srv.Close()
+
+	client := api.NewClient("")
+	client.BaseURL = srv.URL + "/v1"
+	client.DownloadHost = srv.URL + "/cdn"
+	if _, err := client.GetMod(1); err != nil {
+		t.Fatalf("GetMod: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := client.DownloadFile("https://edge.forgecdn.net/files/4712/345/pack.zip", &buf); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	if buf.String() != "jar" {
+		t.Fatalf("downloaded %q, want %q", buf.String(), "jar")
+	}
+	if want := []string{"/v1/mods/1", "/cdn/files/4712/345/pack.zip"}; strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Fatalf("requested %v, want %v", paths, want)
+	}
+	if keys[0] != "" {
+		t.Fatalf("x-api-key = %q without a key, want none", keys[0])
+	}
+}
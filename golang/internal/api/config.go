@@ -0,0 +1,25 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
+)
+
+// NewClientFromConfig creates a client for cfg.APIKey and the endpoints in cfg.API, with
+// the response cache from cfg.Cache and the proxy, TLS and timeout settings from cfg.HTTP;
+// downloads are limited to cfg.Download.max_rate
+func NewClientFromConfig(cfg *config.Config) *Client {
+	client := NewClient(cfg.APIKey)
+	if cfg.API.BaseURL != "" {
+		client.BaseURL = strings.TrimSuffix(cfg.API.BaseURL, "/")
+	}
+	client.DownloadHost = cfg.API.DownloadHost
+	client.HTTPClient = httpclient.New(cfg.HTTP)
+	client.DownloadClient = httpclient.ForDownloads(cfg)
+	if cfg.Cache.TTL > 0 {
+		client.Cache = NewCache(cfg.Cache.TTL, cfg.Cache.Dir)
+	}
+	return client
+}
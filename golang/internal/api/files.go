@@ -0,0 +1,33 @@
+package api
+
+import "sort"
+
+// IsReleased reports whether the file is published. Files still in review, rejected,
+// deleted or archived are listed to their authors but must not be installed. Files
+// without a status, as some mirrors return them, count as released.
+func (f *ModFile) IsReleased() bool {
+	switch f.FileStatus {
+	case 0, FileStatusApproved, FileStatusReleased:
+		return true
+	}
+	return false
+}
+
+// ReleasedFiles returns the released files, newest first. The API usually lists files by
+// date, but not always, so they are sorted by file date and then by file ID, which
+// CurseForge hands out in upload order.
+func ReleasedFiles(files []ModFile) []ModFile {
+	released := make([]ModFile, 0, len(files))
+	for _, f := range files {
+		if f.IsReleased() {
+			released = append(released, f)
+		}
+	}
+	sort.SliceStable(released, func(i, j int) bool {
+		if !released[i].FileDate.Equal(released[j].FileDate) {
+			return released[i].FileDate.After(released[j].FileDate)
+		}
+		return released[i].ID > released[j].ID
+	})
+	return released
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReleasedFiles(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	files := []ModFile{
+		{ID: 10, FileDate: day(1), FileStatus: FileStatusApproved},
+		{ID: 14, FileDate: day(5), FileStatus: FileStatusUnderReview},
+		{ID: 12, FileDate: day(3), FileStatus: FileStatusReleased},
+		{ID: 13, FileDate: day(3)},
+		{ID: 15, FileDate: day(6), FileStatus: FileStatusDeleted},
+		{ID: 11, FileDate: day(2), FileStatus: FileStatusArchived},
+	}
+
+	got := ReleasedFiles(files)
+	want := []int{13, 12, 10}
+	if len(got) != len(want) {
+		t.Fatalf("ReleasedFiles returned %d files, want %d", len(got), len(want))
+	}
+	for i, id := range want {
+		if got[i].ID != id {
+			t.Fatalf("file %d = %d, want %d", i, got[i].ID, id)
+		}
+	}
+	if files[0].ID != 10 {
+		t.Fatal("ReleasedFiles reordered its input")
+	}
+}
+
+func TestGetLatestModFile(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(APIResponse[[]ModFile]{Data: []ModFile{
+			{ID: 1, DisplayName: "1.0", FileDate: day(1), ReleaseType: ReleaseTypeRelease, FileStatus: FileStatusApproved},
+			{ID: 3, DisplayName: "1.2", FileDate: day(3), ReleaseType: ReleaseTypeRelease, FileStatus: FileStatusApproved},
+			{ID: 4, DisplayName: "1.3", FileDate: day(4), ReleaseType: ReleaseTypeRelease, FileStatus: FileStatusUnderReview},
+			{ID: 2, DisplayName: "1.1", FileDate: day(2), ReleaseType: ReleaseTypeRelease, FileStatus: FileStatusApproved},
+			{ID: 5, DisplayName: "1.4-beta", FileDate: day(5), ReleaseType: ReleaseTypeBeta, FileStatus: FileStatusApproved},
+		}})
+	}))
+	defer srv.Close()
+
+	client := NewClient("key")
+	client.BaseURL = srv.URL
+
+	tests := []struct {
+		releaseType int
+		want        string
+	}{
+		{ReleaseTypeRelease, "1.2"},
+		{0, "1.4-beta"},
+	}
+	for _, tt := range tests {
+		file, err := client.GetLatestModFile(42, "1.20.1", tt.releaseType)
+		if err != nil {
+			t.Fatalf("GetLatestModFile(%d): %v", tt.releaseType, err)
+		}
+		if file.DisplayName != tt.want {
+			t.Errorf("GetLatestModFile(%d) = %s, want %s", tt.releaseType, file.DisplayName, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"io"
+)
+
+// Fingerprint computes the CurseForge file fingerprint of r: MurmurHash2 with seed 1 over
+// the content with tabs, newlines, carriage returns and spaces removed
+func Fingerprint(r io.Reader) (uint32, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	filtered := data[:0]
+	for _, b := range data {
+		if b != 9 && b != 10 && b != 13 && b != 32 {
+			filtered = append(filtered, b)
+		}
+	}
+	return murmur2(filtered, 1), nil
+}
+
+// murmur2 is the 32-bit MurmurHash2 of data
+func murmur2(data []byte, seed uint32) uint32 {
+	const m = 0x5bd1e995
+	const r = 24
+
+	h := seed ^ uint32(len(data))
+	for len(data) >= 4 {
+		k := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+		data = data[4:]
+	}
+
+	switch len(data) {
+	case 3:
+		h ^= uint32(data[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
+
+// fingerprintMatches is the data of a fingerprint lookup
+type fingerprintMatches struct {
+	ExactMatches []struct {
+		ID   int     `json:"id"`
+		File ModFile `json:"file"`
+	} `json:"exactMatches"`
+}
+
+// GetFingerprintMatches looks up Minecraft files by fingerprint and returns the file each
+// known fingerprint belongs to
+func (c *Client) GetFingerprintMatches(fingerprints []uint32) (map[uint32]ModFile, error) {
+	var result APIResponse[fingerprintMatches]
+	path := fmt.Sprintf("/fingerprints/%d", GameIDMinecraft)
+	if err := c.postJSON(path, map[string][]uint32{"fingerprints": fingerprints}, &result); err != nil {
+		return nil, err
+	}
+	files := make(map[uint32]ModFile, len(result.Data.ExactMatches))
+	for _, match := range result.Data.ExactMatches {
+		files[uint32(match.File.FileFingerprint)] = match.File
+	}
+	return files, nil
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprintIgnoresWhitespace(t *testing.T) {
+	a, _ := Fingerprint(strings.NewReader("hello world\n"))
+	b, _ := Fingerprint(strings.NewReader("hello\tworld"))
+	c, _ := Fingerprint(strings.NewReader("hello there"))
+	if a != b || a == c {
+		t.Fatalf("fingerprints %d, %d, %d", a, b, c)
+	}
+}
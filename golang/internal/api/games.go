@@ -0,0 +1,38 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// GetGames retrieves the games the API key has access to
+func (c *Client) GetGames() ([]Game, error) {
+	var result APIResponse[[]Game]
+	if err := c.getJSON("/games", map[string]string{"pageSize": strconv.Itoa(MaxPageSize)}, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// GetCategories retrieves the classes and categories of a game. With a classID, only the
+// categories of that class are returned.
+func (c *Client) GetCategories(gameID, classID int) ([]Category, error) {
+	params := map[string]string{"gameId": strconv.Itoa(gameID)}
+	if classID > 0 {
+		params["classId"] = strconv.Itoa(classID)
+	}
+	var result APIResponse[[]Category]
+	if err := c.getJSON("/categories", params, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// GetModDescription retrieves the full description of a mod as plain text
+func (c *Client) GetModDescription(modID int) (string, error) {
+	var result APIResponse[string]
+	if err := c.getJSON(fmt.Sprintf("/mods/%d/description", modID), nil, &result); err != nil {
+		return "", err
+	}
+	return HTMLToText(result.Data), nil
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetCategoriesAndDescription(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/categories":
+			query = r.URL.RawQuery
+			_, _ = w.Write([]byte(`{"data":[{"id":6,"name":"Mods","isClass":true},{"id":423,"name":"Map and Information","classId":6}]}`))
+		case "/mods/42/description":
+			_, _ = w.Write([]byte(`{"data":"<p>Shows <b>items</b> &amp; recipes.</p><p>Second line</p>"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	client := NewClient("key")
+	client.BaseURL = srv.URL
+
+	categories, err := client.GetCategories(GameIDMinecraft, ClassIDMods)
+	if err != nil {
+		t.Fatalf("GetCategories: %v", err)
+	}
+	if query != "classId=6&gameId=432" {
+		t.Errorf("query = %q, want classId=6&gameId=432", query)
+	}
+	if len(categories) != 2 || !categories[0].IsClass || categories[1].ClassID != ClassIDMods {
+		t.Errorf("categories = %+v", categories)
+	}
+
+	description, err := client.GetModDescription(42)
+	if err != nil {
+		t.Fatalf("GetModDescription: %v", err)
+	}
+	if want := "Shows items & recipes.\nSecond line"; description != want {
+		t.Errorf("description = %q, want %q", description, want)
+	}
+
+	if _, err := client.GetGames(); err == nil {
+		t.Error("GetGames succeeded on a 404")
+	}
+}
@@ -4,18 +4,28 @@ import (
 	"fmt"
 )
 
-// Legacy function to maintain backward compatibility
+// The functions below are older names for Client methods, kept so that existing callers
+// still build. Each one only delegates to the method named in its deprecation note.
+
+// CheckIfExists reports whether a mod with the given ID exists.
+//
+// Deprecated: use CheckIfModExists.
 func (c *Client) CheckIfExists(id int) (bool, error) {
 	return c.CheckIfModExists(id)
 }
 
-// Legacy ModInfo struct for backward compatibility
+// LegacyModInfo is the ID and name of a mod, the subset of ModInfo that
+// GetLegacyModInfo returns.
+//
+// Deprecated: use ModInfo.
 type LegacyModInfo struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
 }
 
-// GetLegacyModInfo retrieves basic mod information for backward compatibility
+// GetLegacyModInfo retrieves the ID and name of a mod.
+//
+// Deprecated: use GetMod.
 func (c *Client) GetLegacyModInfo(id int) (*LegacyModInfo, error) {
 	modInfo, err := c.GetMod(id)
 	if err != nil {
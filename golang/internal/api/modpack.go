@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/version"
 )
 
 // ModpackInfo represents modpack-specific information
@@ -18,17 +20,55 @@ type ModpackInfo struct {
 
 // ModLoaderType constants
 const (
-	ModLoaderTypeAny    int = 0
-	ModLoaderTypeForge  int = 1
-	ModLoaderTypeFabric int = 4
-	ModLoaderTypeQuilt  int = 5
+	ModLoaderTypeAny        int = 0
+	ModLoaderTypeForge      int = 1
+	ModLoaderTypeCauldron   int = 2
+	ModLoaderTypeLiteLoader int = 3
+	ModLoaderTypeFabric     int = 4
+	ModLoaderTypeQuilt      int = 5
+	ModLoaderTypeNeoForge   int = 6
 )
 
+// ModLoaderName returns a human-readable name for a mod loader type
+func ModLoaderName(loader int) string {
+	switch loader {
+	case ModLoaderTypeForge:
+		return "Forge"
+	case ModLoaderTypeCauldron:
+		return "Cauldron"
+	case ModLoaderTypeLiteLoader:
+		return "LiteLoader"
+	case ModLoaderTypeFabric:
+		return "Fabric"
+	case ModLoaderTypeQuilt:
+		return "Quilt"
+	case ModLoaderTypeNeoForge:
+		return "NeoForge"
+	default:
+		return "Any"
+	}
+}
+
 // GameID constants
 const (
 	GameIDMinecraft int = 432
 )
 
+// ClassID constants
+const (
+	ClassIDMods     int = 6
+	ClassIDModpacks int = 4471
+)
+
+// SortField constants for SearchMods
+const (
+	SortFieldFeatured      int = 1
+	SortFieldPopularity    int = 2
+	SortFieldLastUpdated   int = 3
+	SortFieldName          int = 4
+	SortFieldTotalDownload int = 6
+)
+
 // GetModpackInfo retrieves comprehensive information about a modpack
 func (c *Client) GetModpackInfo(modpackID int, gameVersion string, currentVersion string, releaseChannel string) (*ModpackInfo, error) {
 	// Get basic mod info
@@ -38,12 +78,12 @@ func (c *Client) GetModpackInfo(modpackID int, gameVersion string, currentVersio
 	}
 
 	// Check if this is actually a modpack
-	if modInfo.ClassID != 4471 { // 4471 is the class ID for modpacks
+	if modInfo.ClassID != ClassIDModpacks {
 		return nil, fmt.Errorf("mod %d is not a modpack (class ID: %d)", modpackID, modInfo.ClassID)
 	}
 
 	// Get latest file based on release channel
-	releaseType := getReleaseTypeFromChannel(releaseChannel)
+	releaseType := ReleaseTypeFromChannel(releaseChannel)
 	latestFile, err := c.GetLatestModFile(modpackID, gameVersion, releaseType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest modpack file: %w", err)
@@ -59,7 +99,7 @@ func (c *Client) GetModpackInfo(modpackID int, gameVersion string, currentVersio
 
 	// Check if update is available
 	if currentVersion != "" {
-		modpackInfo.HasUpdate = !isVersionEqual(currentVersion, latestFile.DisplayName)
+		modpackInfo.HasUpdate = isNewerVersion(latestFile.DisplayName, currentVersion, gameVersion)
 	} else {
 		modpackInfo.HasUpdate = true // No current version means update is available
 	}
@@ -70,7 +110,7 @@ func (c *Client) GetModpackInfo(modpackID int, gameVersion string, currentVersio
 // GetModpackVersions retrieves all available versions for a modpack
 func (c *Client) GetModpackVersions(modpackID int, gameVersion string) ([]ModFile, error) {
 	// Get all files for the modpack
-	files, err := c.GetModFiles(modpackID, gameVersion, 0, 50, 0)
+	files, err := c.GetAllModFiles(modpackID, gameVersion, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get modpack files: %w", err)
 	}
@@ -193,12 +233,13 @@ func (c *Client) GetModpackDownloadURL(modpackID int, fileID int) (string, error
 
 // GetModpackServerFile retrieves the server file for a modpack if available
 func (c *Client) GetModpackServerFile(modpackID int, gameVersion string, releaseChannel string) (*ModFile, error) {
-	releaseType := getReleaseTypeFromChannel(releaseChannel)
+	releaseType := ReleaseTypeFromChannel(releaseChannel)
 
-	files, err := c.GetModFiles(modpackID, gameVersion, 0, 50, 0)
+	files, err := c.GetAllModFiles(modpackID, gameVersion, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get modpack files: %w", err)
 	}
+	files = ReleasedFiles(files)
 
 	// Look for server files first
 	for _, file := range files {
@@ -217,8 +258,8 @@ func (c *Client) GetModpackServerFile(modpackID int, gameVersion string, release
 	return nil, fmt.Errorf("no suitable file found for modpack %d", modpackID)
 }
 
-// getReleaseTypeFromChannel converts a release channel string to release type int
-func getReleaseTypeFromChannel(channel string) int {
+// ReleaseTypeFromChannel converts a release channel string to release type int
+func ReleaseTypeFromChannel(channel string) int {
 	switch strings.ToLower(channel) {
 	case "stable", "release":
 		return ReleaseTypeRelease
@@ -231,11 +272,14 @@ func getReleaseTypeFromChannel(channel string) int {
 	}
 }
 
-// isVersionEqual compares two version strings for equality
-func isVersionEqual(version1, version2 string) bool {
-	// Simple string comparison for now
-	// This could be enhanced with semantic version comparison
-	return strings.TrimSpace(version1) == strings.TrimSpace(version2)
+// isNewerVersion reports whether the display name latest holds a newer version than
+// current, comparing the version numbers in both; names without one, or with the same
+// one, are newer whenever they differ. The Minecraft version gameVersion is skipped.
+func isNewerVersion(latest, current, gameVersion string) bool {
+	if cmp, ok := version.CompareNames(latest, current, gameVersion); ok && cmp != 0 {
+		return cmp > 0
+	}
+	return strings.TrimSpace(latest) != strings.TrimSpace(current)
 }
 
 // GetModpackDependencies retrieves dependencies for a modpack
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaxPageSize is the largest page the API returns
+const MaxPageSize = 50
+
+// maxResults is how deep the API pages: index + pageSize may not exceed it
+const maxResults = 10000
+
+// maxRateLimitRetries is how often a page is retried after the API rate limited it
+const maxRateLimitRetries = 3
+
+// defaultRetryAfter is the wait after a rate limited request that did not say how long to wait
+const defaultRetryAfter = 5 * time.Second
+
+// ErrStopPaging stops EachModFile early without an error when a callback returns it
+var ErrStopPaging = errors.New("stop paging")
+
+// RateLimitError is returned when the API answered 429 Too Many Requests
+type RateLimitError struct {
+	RetryAfter time.Duration // how long the API asked to wait
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("API rate limit reached, retry after %s", e.RetryAfter)
+}
+
+// retryAfter returns the wait the Retry-After header of resp asks for
+func retryAfter(resp *http.Response) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultRetryAfter
+}
+
+// EachModFile calls fn for every file of a mod, fetching the pages one by one so that
+// mods with hundreds of files need not be held in memory. Rate limited pages are retried
+// after the wait the API asks for. It stops at the first error fn returns, and returns
+// it unless it is ErrStopPaging.
+func (c *Client) EachModFile(modID int, gameVersion string, modLoaderType int, fn func(*ModFile) error) error {
+	for index := 0; index+MaxPageSize <= maxResults; index += MaxPageSize {
+		files, page, err := c.modFilesPage(modID, gameVersion, modLoaderType, index)
+		if err != nil {
+			return err
+		}
+		for i := range files {
+			if err := fn(&files[i]); err != nil {
+				if errors.Is(err, ErrStopPaging) {
+					return nil
+				}
+				return err
+			}
+		}
+		if page == nil || len(files) < MaxPageSize || index+len(files) >= page.TotalCount {
+			return nil
+		}
+	}
+	return nil
+}
+
+// GetAllModFiles retrieves every file of a mod, in the order the API lists them
+func (c *Client) GetAllModFiles(modID int, gameVersion string, modLoaderType int) ([]ModFile, error) {
+	var files []ModFile
+	err := c.EachModFile(modID, gameVersion, modLoaderType, func(f *ModFile) error {
+		files = append(files, *f)
+		return nil
+	})
+	return files, err
+}
+
+// modFilesPage fetches the page of files at index, waiting out rate limits
+func (c *Client) modFilesPage(modID int, gameVersion string, modLoaderType int, index int) ([]ModFile, *Pagination, error) {
+	for attempt := 0; ; attempt++ {
+		files, page, err := c.GetModFilesPage(modID, gameVersion, modLoaderType, MaxPageSize, index)
+		var limited *RateLimitError
+		if !errors.As(err, &limited) || attempt == maxRateLimitRetries {
+			return files, page, err
+		}
+		c.logger().Info("api rate limit reached, waiting", "retry_after", limited.RetryAfter, "mod", modID, "index", index)
+		c.wait(limited.RetryAfter)
+	}
+}
+
+// wait pauses for d
+func (c *Client) wait(d time.Duration) {
+	if c.sleep != nil {
+		c.sleep(d)
+		return
+	}
+	time.Sleep(d)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// pagedFiles serves total files of mod 42, MaxPageSize per page, after answering the
+// first request for each page with a 429
+func pagedFiles(t *testing.T, total int) (*httptest.Server, *int) {
+	t.Helper()
+	limited := map[string]bool{}
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		index, _ := strconv.Atoi(r.URL.Query().Get("index"))
+		if !limited[r.URL.RawQuery] {
+			limited[r.URL.RawQuery] = true
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		var files []ModFile
+		for id := index; id < total && id < index+MaxPageSize; id++ {
+			files = append(files, ModFile{ID: id})
+		}
+		_ = json.NewEncoder(w).Encode(APIResponse[[]ModFile]{
+			Data:       files,
+			Pagination: &Pagination{Index: index, PageSize: MaxPageSize, ResultCount: len(files), TotalCount: total},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestGetAllModFiles(t *testing.T) {
+	srv, requests := pagedFiles(t, 120)
+	client := NewClient("key")
+	client.BaseURL = srv.URL
+	var waited time.Duration
+	client.sleep = func(d time.Duration) { waited += d }
+
+	files, err := client.GetAllModFiles(42, "", 0)
+	if err != nil {
+		t.Fatalf("GetAllModFiles: %v", err)
+	}
+	if len(files) != 120 {
+		t.Fatalf("GetAllModFiles returned %d files, want 120", len(files))
+	}
+	for i, f := range files {
+		if f.ID != i {
+			t.Fatalf("file %d has ID %d", i, f.ID)
+		}
+	}
+	if *requests != 6 || waited != 6*time.Second {
+		t.Fatalf("requests = %d, waited %s; want 6 requests and 6s for 3 rate limited pages", *requests, waited)
+	}
+}
+
+func TestEachModFileStops(t *testing.T) {
+	srv, requests := pagedFiles(t, 120)
+	client := NewClient("key")
+	client.BaseURL = srv.URL
+	client.sleep = func(time.Duration) {}
+
+	var seen int
+	err := client.EachModFile(42, "", 0, func(f *ModFile) error {
+		seen++
+		if f.ID == 10 {
+			return ErrStopPaging
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachModFile: %v", err)
+	}
+	if seen != 11 || *requests != 2 {
+		t.Fatalf("saw %d files in %d requests, want 11 in 2", seen, *requests)
+	}
+}
+
+func TestEachModFileGivesUpWhenRateLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	client := NewClient("key")
+	client.BaseURL = srv.URL
+	client.sleep = func(time.Duration) {}
+
+	err := client.EachModFile(42, "", 0, func(*ModFile) error { return nil })
+	if _, ok := err.(*RateLimitError); !ok {
+		t.Fatalf("EachModFile error = %v, want a RateLimitError", err)
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"io"
+	"time"
+)
+
+// progressInterval limits how often a ProgressFunc is called while downloading
+const progressInterval = 200 * time.Millisecond
+
+// Progress describes a download in flight
+type Progress struct {
+	Downloaded int64   // bytes written so far
+	Total      int64   // expected size, 0 when unknown
+	Rate       float64 // average bytes per second since the download started
+	Done       bool    // set on the final report
+}
+
+// Percent returns how much of the download is done, or -1 when the size is unknown
+func (p Progress) Percent() int {
+	if p.Total <= 0 {
+		return -1
+	}
+	if p.Downloaded >= p.Total {
+		return 100
+	}
+	return int(p.Downloaded * 100 / p.Total)
+}
+
+// ProgressFunc receives download progress
+type ProgressFunc func(Progress)
+
+// ProgressWriter counts bytes written to w and reports them at most every progressInterval
+type ProgressWriter struct {
+	w        io.Writer
+	fn       ProgressFunc
+	progress Progress
+	started  time.Time
+	reported time.Time
+}
+
+// NewProgressWriter wraps w; total may be 0 when the size is unknown
+func NewProgressWriter(w io.Writer, total int64, fn ProgressFunc) *ProgressWriter {
+	now := time.Now()
+	return &ProgressWriter{w: w, fn: fn, progress: Progress{Total: total}, started: now, reported: now}
+}
+
+// Write passes b through to the underlying writer and reports progress when due
+func (p *ProgressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.progress.Downloaded += int64(n)
+	if now := time.Now(); now.Sub(p.reported) >= progressInterval {
+		p.reported = now
+		p.report(now)
+	}
+	return n, err
+}
+
+// Finish sends the final report
+func (p *ProgressWriter) Finish() {
+	p.progress.Done = true
+	p.report(time.Now())
+}
+
+func (p *ProgressWriter) report(now time.Time) {
+	if elapsed := now.Sub(p.started).Seconds(); elapsed > 0 {
+		p.progress.Rate = float64(p.progress.Downloaded) / elapsed
+	}
+	p.fn(p.progress)
+}
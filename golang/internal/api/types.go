@@ -24,6 +24,7 @@ type ModInfo struct {
 	GameID               int         `json:"gameId"`
 	Name                 string      `json:"name"`
 	Slug                 string      `json:"slug"`
+	Links                ModLinks    `json:"links"`
 	Summary              string      `json:"summary"`
 	Status               int         `json:"status"`
 	DownloadCount        int64       `json:"downloadCount"`
@@ -47,7 +48,26 @@ type ModInfo struct {
 	Rating               float64     `json:"rating"`
 }
 
-// Category represents a mod category
+// ModLinks represents the external links of a mod
+type ModLinks struct {
+	WebsiteURL string `json:"websiteUrl"`
+	WikiURL    string `json:"wikiUrl"`
+	IssuesURL  string `json:"issuesUrl"`
+	SourceURL  string `json:"sourceUrl"`
+}
+
+// Game represents a game hosted on CurseForge
+type Game struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Slug         string    `json:"slug"`
+	DateModified time.Time `json:"dateModified"`
+	Status       int       `json:"status"`
+	APIStatus    int       `json:"apiStatus"`
+}
+
+// Category represents a mod category. Classes, such as Mods and Modpacks, are categories
+// with IsClass set; the other categories belong to the class in ClassID.
 type Category struct {
 	ID               int       `json:"id"`
 	GameID           int       `json:"gameId"`
@@ -114,6 +134,12 @@ type FileHash struct {
 	Algo  int    `json:"algo"`
 }
 
+// HashAlgo constants
+const (
+	HashAlgoSHA1 int = 1
+	HashAlgoMD5  int = 2
+)
+
 // SortableGameVersion represents a sortable game version
 type SortableGameVersion struct {
 	GameVersionName        string    `json:"gameVersionName"`
@@ -162,6 +188,20 @@ const (
 	ReleaseTypeAlpha   int = 3
 )
 
+// ReleaseTypeName returns a human-readable name for a release type
+func ReleaseTypeName(releaseType int) string {
+	switch releaseType {
+	case ReleaseTypeRelease:
+		return "release"
+	case ReleaseTypeBeta:
+		return "beta"
+	case ReleaseTypeAlpha:
+		return "alpha"
+	default:
+		return "unknown"
+	}
+}
+
 // RelationType constants for dependencies
 const (
 	RelationTypeEmbeddedLibrary    int = 1
@@ -0,0 +1,90 @@
+// Package approval holds back updates until an operator approves them. The request for
+// the latest file is kept in the state file, so the daemon, the CLI and the web UI all
+// see the same one.
+package approval
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+// Statuses of a request
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusDenied   = "denied"
+	StatusExpired  = "expired"
+)
+
+// Actions returned by Advance
+const (
+	ActionRequest = "request" // a new request was made; ask for approval
+	ActionRemind  = "remind"  // still pending and a reminder is due
+	ActionWait    = "wait"    // still pending
+	ActionExpire  = "expire"  // the request expired just now
+	ActionInstall = "install" // approved
+	ActionSkip    = "skip"    // denied or expired earlier
+)
+
+// ErrNoRequest is returned by Decide when no request is pending
+var ErrNoRequest = errors.New("no update is waiting for approval")
+
+// Advance moves the request in st along for the latest file and returns what to do about
+// it. A request for an older file is replaced by one for latest.
+func Advance(st *state.State, fileID int, version string, now time.Time, cfg config.ApprovalConfig) string {
+	a := st.Approval
+	if a == nil || a.FileID != fileID {
+		st.Approval = &state.Approval{
+			FileID:      fileID,
+			Version:     version,
+			FromVersion: st.InstalledVersion,
+			Status:      StatusPending,
+			RequestedAt: now,
+			RemindedAt:  now,
+		}
+		if cfg.ExpireAfter > 0 {
+			st.Approval.ExpiresAt = now.Add(cfg.ExpireAfter)
+		}
+		return ActionRequest
+	}
+
+	switch a.Status {
+	case StatusApproved:
+		return ActionInstall
+	case StatusDenied, StatusExpired:
+		return ActionSkip
+	}
+	if !a.ExpiresAt.IsZero() && !now.Before(a.ExpiresAt) {
+		a.Status = StatusExpired
+		a.DecidedAt = now
+		return ActionExpire
+	}
+	if cfg.RemindEvery > 0 && now.Sub(a.RemindedAt) >= cfg.RemindEvery {
+		a.RemindedAt = now
+		return ActionRemind
+	}
+	return ActionWait
+}
+
+// Decide approves or denies the pending request in st on behalf of by, e.g. "cli alice"
+// or "api 10.0.0.1"
+func Decide(st *state.State, approve bool, by string, now time.Time) error {
+	a := st.Approval
+	if a == nil || a.Status != StatusPending {
+		return ErrNoRequest
+	}
+	if !a.ExpiresAt.IsZero() && !now.Before(a.ExpiresAt) {
+		return fmt.Errorf("the request to install %s expired at %s", a.Version, a.ExpiresAt.Format(time.RFC3339))
+	}
+	a.Status = StatusDenied
+	if approve {
+		a.Status = StatusApproved
+	}
+	a.DecidedBy = by
+	a.DecidedAt = now
+	return nil
+}
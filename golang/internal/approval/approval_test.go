@@ -0,0 +1,96 @@
+package approval
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+func TestAdvance(t *testing.T) {
+	cfg := config.ApprovalConfig{Required: true, ExpireAfter: 72 * time.Hour, RemindEvery: 24 * time.Hour}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	st := &state.State{InstalledVersion: "1.0"}
+
+	steps := []struct {
+		after time.Duration
+		want  string
+	}{
+		{0, ActionRequest},
+		{time.Hour, ActionWait},
+		{24 * time.Hour, ActionRemind},
+		{30 * time.Hour, ActionWait},
+		{48 * time.Hour, ActionRemind},
+		{72 * time.Hour, ActionExpire},
+		{80 * time.Hour, ActionSkip},
+	}
+	for _, s := range steps {
+		if got := Advance(st, 2, "1.1", start.Add(s.after), cfg); got != s.want {
+			t.Errorf("after %v: Advance = %q, want %q", s.after, got, s.want)
+		}
+	}
+	if st.Approval.Status != StatusExpired || st.Approval.FromVersion != "1.0" {
+		t.Errorf("approval = %+v", st.Approval)
+	}
+
+	// A newer file asks again
+	if got := Advance(st, 3, "1.2", start.Add(81*time.Hour), cfg); got != ActionRequest {
+		t.Errorf("newer file: Advance = %q, want %q", got, ActionRequest)
+	}
+	if st.Approval.FileID != 3 || st.Approval.Status != StatusPending {
+		t.Errorf("approval = %+v", st.Approval)
+	}
+}
+
+func TestAdvanceNoExpiry(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	st := &state.State{}
+	Advance(st, 2, "1.1", start, config.ApprovalConfig{Required: true})
+	if !st.Approval.ExpiresAt.IsZero() {
+		t.Errorf("ExpiresAt = %v, want zero", st.Approval.ExpiresAt)
+	}
+	if got := Advance(st, 2, "1.1", start.Add(1000*time.Hour), config.ApprovalConfig{Required: true}); got != ActionWait {
+		t.Errorf("Advance = %q, want %q", got, ActionWait)
+	}
+}
+
+func TestDecide(t *testing.T) {
+	cfg := config.ApprovalConfig{Required: true, ExpireAfter: time.Hour}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	st := &state.State{}
+	if err := Decide(st, true, "cli alice", start); !errors.Is(err, ErrNoRequest) {
+		t.Errorf("Decide without request = %v, want ErrNoRequest", err)
+	}
+
+	Advance(st, 2, "1.1", start, cfg)
+	if err := Decide(st, true, "cli alice", start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if st.Approval.Status != StatusApproved || st.Approval.DecidedBy != "cli alice" {
+		t.Errorf("approval = %+v", st.Approval)
+	}
+	if got := Advance(st, 2, "1.1", start.Add(2*time.Hour), cfg); got != ActionInstall {
+		t.Errorf("Advance after approval = %q, want %q", got, ActionInstall)
+	}
+	if err := Decide(st, false, "cli bob", start.Add(2*time.Minute)); !errors.Is(err, ErrNoRequest) {
+		t.Errorf("Decide twice = %v, want ErrNoRequest", err)
+	}
+
+	st = &state.State{}
+	Advance(st, 2, "1.1", start, cfg)
+	if err := Decide(st, false, "api 10.0.0.1", start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if got := Advance(st, 2, "1.1", start.Add(2*time.Minute), cfg); got != ActionSkip {
+		t.Errorf("Advance after denial = %q, want %q", got, ActionSkip)
+	}
+
+	st = &state.State{}
+	Advance(st, 2, "1.1", start, cfg)
+	if err := Decide(st, true, "cli alice", start.Add(time.Hour)); err == nil || errors.Is(err, ErrNoRequest) {
+		t.Errorf("Decide after expiry = %v, want an expiry error", err)
+	}
+}
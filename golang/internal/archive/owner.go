@@ -0,0 +1,59 @@
+package archive
+
+import (
+	"encoding/binary"
+
+	"github.com/klauspost/compress/zip"
+)
+
+// unixOwnerTag is the Info-ZIP "ux" extra field, which records the user and group IDs
+// of an entry the way `zip` on Unix writes them
+const unixOwnerTag = 0x7875
+
+// OwnerField returns the extra field recording uid and gid, for zip.FileHeader.Extra
+func OwnerField(uid, gid int) []byte {
+	b := make([]byte, 15)
+	binary.LittleEndian.PutUint16(b[0:], unixOwnerTag)
+	binary.LittleEndian.PutUint16(b[2:], 11)
+	b[4] = 1 // version
+	b[5] = 4
+	binary.LittleEndian.PutUint32(b[6:], uint32(uid))
+	b[10] = 4
+	binary.LittleEndian.PutUint32(b[11:], uint32(gid))
+	return b
+}
+
+// Owner returns the user and group IDs recorded for file, see OwnerField. ok is false
+// when the archive did not record them.
+func Owner(file *zip.File) (uid, gid int, ok bool) {
+	extra := file.Extra
+	for len(extra) >= 4 {
+		tag := binary.LittleEndian.Uint16(extra[0:])
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			return 0, 0, false
+		}
+		if data := extra[4 : 4+size]; tag == unixOwnerTag && len(data) > 0 && data[0] == 1 {
+			id, rest, ok := ownerID(data[1:])
+			if !ok {
+				return 0, 0, false
+			}
+			gid, _, ok := ownerID(rest)
+			return id, gid, ok
+		}
+		extra = extra[4+size:]
+	}
+	return 0, 0, false
+}
+
+// ownerID reads one size-prefixed little-endian ID from the "ux" field
+func ownerID(b []byte) (id int, rest []byte, ok bool) {
+	if len(b) == 0 || int(b[0]) > 8 || len(b) < 1+int(b[0]) {
+		return 0, nil, false
+	}
+	var v uint64
+	for i := int(b[0]); i >= 1; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return int(v), b[1+int(b[0]):], true
+}
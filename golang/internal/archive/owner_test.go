@@ -0,0 +1,37 @@
+package archive
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klauspost/compress/zip"
+)
+
+func TestOwner(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	// Another extra field before the owner, as zip tools write a timestamp first
+	timestamp := []byte{0x55, 0x54, 0x05, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}
+	for _, h := range []*zip.FileHeader{
+		{Name: "owned.txt", Extra: append(timestamp, OwnerField(1000, 50)...)},
+		{Name: "plain.txt"},
+	} {
+		if _, err := zw.CreateHeader(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid, gid, ok := Owner(r.File[0]); !ok || uid != 1000 || gid != 50 {
+		t.Errorf("Owner(owned.txt) = %d, %d, %v", uid, gid, ok)
+	}
+	if _, _, ok := Owner(r.File[1]); ok {
+		t.Error("Owner(plain.txt) found an owner")
+	}
+}
@@ -0,0 +1,233 @@
+// Package archive extracts zip archives, such as server packs and backups, into a
+// directory. Entries are streamed one at a time straight to their destination, keep the
+// permissions and modification times recorded in the archive, optionally their owner, and
+// never land outside it: an archive with an entry that would escape it through "..", an
+// absolute path or a symlink is rejected before anything is written.
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/klauspost/compress/zip"
+)
+
+// copyBufferSize is the size of the buffer shared by all entries of one extraction
+const copyBufferSize = 256 * 1024
+
+// Creator systems in the zip file header whose permissions are kept
+const (
+	creatorUnix   = 3
+	creatorMacOSX = 19
+)
+
+// ErrUnsafePath is returned for archives with an entry that could be written outside the
+// destination
+var ErrUnsafePath = errors.New("unsafe path in archive")
+
+// ProgressFunc is called after each extracted file with the bytes written so far and the
+// uncompressed size of everything being extracted
+type ProgressFunc func(done, total int64)
+
+// Options narrows down and reports on an extraction
+type Options struct {
+	// Prefix is removed from every entry name; entries outside it are skipped
+	Prefix string
+	// Filter decides whether an entry, named without Prefix, is extracted; nil extracts all
+	Filter func(name string) bool
+	// Progress is called as files are extracted; nil reports nothing
+	Progress ProgressFunc
+	// Owner picks the user and group IDs an extracted entry is given, for example the ones
+	// recorded by OwnerField; with ok false, or a nil Owner, entries belong to the process
+	Owner func(file *zip.File) (uid, gid int, ok bool)
+}
+
+// ExtractZip extracts the zip archive at path into dest and returns the extracted files
+// relative to dest, with forward slashes
+func ExtractZip(path, dest string, opts Options) ([]string, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer reader.Close()
+	return Extract(reader.File, dest, opts)
+}
+
+// Extract writes the entries of a zip archive into dest, see ExtractZip. Every entry is
+// checked with CheckEntry first, also those skipped by opts.
+func Extract(files []*zip.File, dest string, opts Options) ([]string, error) {
+	for _, file := range files {
+		if err := CheckEntry(file); err != nil {
+			return nil, err
+		}
+	}
+	if err := filesystem.EnsureDir(dest); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+
+	type entry struct {
+		file   *zip.File
+		name   string
+		target string
+	}
+	var entries []entry
+	var total int64
+	for _, file := range files {
+		name, ok := strings.CutPrefix(entryName(file), opts.Prefix)
+		if !ok || name == "" || opts.Filter != nil && !opts.Filter(name) {
+			continue
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		if !filesystem.IsSubPath(dest, target) {
+			return nil, fmt.Errorf("%w: %q escapes %s", ErrUnsafePath, file.Name, dest)
+		}
+		entries = append(entries, entry{file: file, name: name, target: target})
+		if !file.FileInfo().IsDir() {
+			total += int64(file.UncompressedSize64)
+		}
+	}
+
+	buf := make([]byte, copyBufferSize)
+	var extracted []string
+	var dirs []entry
+	var done int64
+	for _, e := range entries {
+		if e.file.FileInfo().IsDir() {
+			if err := filesystem.EnsureDir(e.target); err != nil {
+				return nil, fmt.Errorf("failed to create directory %s: %w", e.target, err)
+			}
+			dirs = append(dirs, e)
+			continue
+		}
+		n, err := extractFile(e.file, e.target, buf)
+		if err != nil {
+			return nil, err
+		}
+		if err := chown(e.file, e.target, opts.Owner); err != nil {
+			return nil, err
+		}
+		extracted = append(extracted, e.name)
+		done += n
+		if opts.Progress != nil {
+			opts.Progress(done, total)
+		}
+	}
+	// Extracting files into a directory changes its modification time, and a read-only
+	// directory could not take them, so set both last
+	for i := len(dirs) - 1; i >= 0; i-- {
+		d := dirs[i]
+		if perm, ok := recordedMode(d.file); ok {
+			if err := os.Chmod(d.target, perm|0o700); err != nil {
+				return nil, err
+			}
+		}
+		if err := chown(d.file, d.target, opts.Owner); err != nil {
+			return nil, err
+		}
+		setModTime(d.target, d.file.Modified)
+	}
+	return extracted, nil
+}
+
+// CheckEntry returns an error wrapping ErrUnsafePath when file is a symlink or its name is
+// absolute, has a drive letter or climbs out of the destination with "..". Backslashes
+// count as separators, as some Windows tools write them.
+func CheckEntry(file *zip.File) error {
+	name := entryName(file)
+	switch {
+	case file.Mode()&os.ModeSymlink != 0:
+		return fmt.Errorf("%w: %q is a symlink", ErrUnsafePath, file.Name)
+	case strings.HasPrefix(name, "/") || len(name) >= 2 && name[1] == ':':
+		return fmt.Errorf("%w: %q is an absolute path", ErrUnsafePath, file.Name)
+	case name != "" && !filepath.IsLocal(filepath.FromSlash(strings.TrimSuffix(name, "/"))):
+		return fmt.Errorf("%w: %q leaves the destination", ErrUnsafePath, file.Name)
+	}
+	return nil
+}
+
+// entryName returns the name of file with forward slashes
+func entryName(file *zip.File) string {
+	return strings.ReplaceAll(file.Name, `\`, "/")
+}
+
+// extractFile writes a single archive entry to target through buf and returns its size
+func extractFile(file *zip.File, target string, buf []byte) (int64, error) {
+	if err := filesystem.EnsureDir(filepath.Dir(target)); err != nil {
+		return 0, fmt.Errorf("failed to create parent directory for %s: %w", target, err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s in archive: %w", file.Name, err)
+	}
+	defer src.Close()
+
+	// #nosec G304 -- target is validated to stay inside the destination
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fileMode(file))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	n, err := io.CopyBuffer(dst, src, buf)
+	if err != nil {
+		_ = dst.Close()
+		return n, fmt.Errorf("failed to extract %s: %w", file.Name, err)
+	}
+	if err := dst.Close(); err != nil {
+		return n, err
+	}
+	// An existing file keeps its permissions when it is truncated
+	if err := os.Chmod(target, fileMode(file)); err != nil {
+		return n, err
+	}
+	setModTime(target, file.Modified)
+	return n, nil
+}
+
+// fileMode returns the permissions recorded for file by a Unix or macOS zip tool, or 0644
+// for archives made elsewhere, such as on Windows. Files stay writable by their owner, so
+// that the next extraction can replace them.
+func fileMode(file *zip.File) os.FileMode {
+	if perm, ok := recordedMode(file); ok {
+		return perm | 0o200
+	}
+	return 0o644
+}
+
+// recordedMode returns the permissions a Unix or macOS zip tool recorded for file
+func recordedMode(file *zip.File) (os.FileMode, bool) {
+	if creator := file.CreatorVersion >> 8; creator == creatorUnix || creator == creatorMacOSX {
+		if perm := file.Mode().Perm(); perm != 0 {
+			return perm, true
+		}
+	}
+	return 0, false
+}
+
+// chown gives target the owner that owner picks for file
+func chown(file *zip.File, target string, owner func(*zip.File) (int, int, bool)) error {
+	if owner == nil {
+		return nil
+	}
+	uid, gid, ok := owner(file)
+	if !ok {
+		return nil
+	}
+	if err := os.Lchown(target, uid, gid); err != nil {
+		return fmt.Errorf("failed to set the owner of %s: %w", target, err)
+	}
+	return nil
+}
+
+// setModTime sets the modification time of path when the archive recorded one; failing to
+// do so does not fail the extraction
+func setModTime(path string, modified time.Time) {
+	if !modified.IsZero() {
+		_ = os.Chtimes(path, modified, modified)
+	}
+}
@@ -0,0 +1,135 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zip"
+)
+
+// zipEntry is a file to put in a test archive
+type zipEntry struct {
+	name    string
+	content string
+	mode    os.FileMode
+}
+
+// writeZip creates a zip archive with the given entries and returns its path
+func writeZip(t *testing.T, entries []zipEntry, modified time.Time) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: modified}
+		if e.mode != 0 {
+			h.SetMode(e.mode)
+		}
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractZip(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	path := writeZip(t, []zipEntry{
+		{name: "pack/", mode: os.ModeDir | 0o755},
+		{name: "pack/start.sh", content: "#!/bin/sh\n", mode: 0o755},
+		{name: "pack/mods/a.jar", content: "jar"},
+		{name: "pack/shaderpacks/bsl.zip", content: "shader"},
+		{name: "other.txt", content: "outside the prefix"},
+	}, modified)
+	dest := filepath.Join(t.TempDir(), "server")
+
+	var progress []int64
+	files, err := ExtractZip(path, dest, Options{
+		Prefix:   "pack/",
+		Filter:   func(name string) bool { return !strings.HasPrefix(name, "shaderpacks/") },
+		Progress: func(done, total int64) { progress = append(progress, done, total) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(files, ","); got != "start.sh,mods/a.jar" {
+		t.Errorf("files = %s", got)
+	}
+	if got := fmt.Sprint(progress); got != "[10 13 13 13]" {
+		t.Errorf("progress = %s", got)
+	}
+
+	info, err := os.Stat(filepath.Join(dest, "start.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o755 {
+		t.Errorf("start.sh mode = %v, want 0755", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(modified) {
+		t.Errorf("start.sh modified %v, want %v", info.ModTime(), modified)
+	}
+	if info, err := os.Stat(filepath.Join(dest, "mods", "a.jar")); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("a.jar without a recorded mode: %v, %v", info, err)
+	}
+	for _, name := range []string{"shaderpacks", "other.txt"} {
+		if _, err := os.Stat(filepath.Join(dest, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was extracted: %v", name, err)
+		}
+	}
+}
+
+func TestExtractZipRejectsUnsafeEntries(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		entry zipEntry
+	}{
+		{"parent", zipEntry{name: "../evil.txt", content: "evil"}},
+		{"nested parent", zipEntry{name: "mods/../../evil.txt", content: "evil"}},
+		{"parent directory", zipEntry{name: "../evil/", mode: os.ModeDir | 0o755}},
+		{"backslashes", zipEntry{name: `..\evil.txt`, content: "evil"}},
+		{"absolute", zipEntry{name: "/tmp/evil.txt", content: "evil"}},
+		{"drive letter", zipEntry{name: `C:\evil.txt`, content: "evil"}},
+		{"symlink", zipEntry{name: "mods/link", content: "../../../etc", mode: os.ModeSymlink | 0o777}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// The safe entry comes first, to show that nothing is written at all
+			path := writeZip(t, []zipEntry{{name: "mods/a.jar", content: "jar"}, tt.entry}, time.Now())
+			root := t.TempDir()
+			dest := filepath.Join(root, "server")
+			_, err := ExtractZip(path, dest, Options{})
+			if !errors.Is(err, ErrUnsafePath) {
+				t.Fatalf("expected ErrUnsafePath, got %v", err)
+			}
+			// Also when the entry is outside the prefix that would be extracted
+			if _, err := ExtractZip(path, dest, Options{Prefix: "mods/"}); !errors.Is(err, ErrUnsafePath) {
+				t.Fatalf("with a prefix: expected ErrUnsafePath, got %v", err)
+			}
+			entries, err := os.ReadDir(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("extraction wrote %s", entries[0].Name())
+			}
+		})
+	}
+}
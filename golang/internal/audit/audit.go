@@ -0,0 +1,141 @@
+// Package audit records state-changing actions of operators, from the CLI, the web UI
+// and the REST API, in an append-only JSON lines file
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+)
+
+// FileName is the default name of the audit log inside the data directory
+const FileName = "audit.jsonl"
+
+// Results recorded for an action
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+)
+
+// Entry records one state-changing action by an operator
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Actor     string    `json:"actor"`  // who, e.g. "web" with the remote address
+	Action    string    `json:"action"` // what, e.g. "settings.update"
+	Target    string    `json:"target,omitempty"`
+	Result    string    `json:"result"`
+	Details   string    `json:"details,omitempty"`
+}
+
+// Filter selects audit entries; zero values match everything
+type Filter struct {
+	Action string // the action itself, or its group such as "server" for "server.start"
+	Actor  string // part of the actor, such as "cli" or an IP address
+	Limit  int
+}
+
+// matches reports whether entry is selected by f
+func (f Filter) matches(entry Entry) bool {
+	if f.Action != "" && entry.Action != f.Action && !strings.HasPrefix(entry.Action, f.Action+".") {
+		return false
+	}
+	return f.Actor == "" || strings.Contains(entry.Actor, f.Actor)
+}
+
+// Log is an append-only JSON lines file of operator actions
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLog creates an audit log backed by the given file
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the location of the audit file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append adds an entry to the end of the log, stamping it with the current time if unset
+func (l *Log) Append(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	if err := filesystem.EnsureDir(filepath.Dir(l.path)); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	// #nosec G304 -- path comes from configuration
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file %s: %w", l.path, err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return f.Close()
+}
+
+// List returns the entries matching filter, newest first
+func (l *Log) List(filter Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// #nosec G304 -- path comes from configuration
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, nil
+		}
+		return nil, fmt.Errorf("failed to open audit file %s: %w", l.path, err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse audit file %s line %d: %w", l.path, line, err)
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit file %s: %w", l.path, err)
+	}
+
+	// Reverse so the newest action comes first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return entries, nil
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+)
+
+func TestAppendAndList(t *testing.T) {
+	log := NewLog(filepath.Join(t.TempDir(), "data", FileName))
+
+	entries, err := log.List(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 || entries == nil {
+		t.Fatalf("List of a missing file = %#v, want an empty slice", entries)
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, e := range []Entry{
+		{Actor: "cli alice", Action: "update", Result: ResultSuccess},
+		{Actor: "api 10.0.0.1", Action: "server.start", Result: ResultSuccess},
+		{Actor: "web 10.0.0.1", Action: "settings.update", Result: ResultFailed},
+		{Actor: "api 10.0.0.2", Action: "server.stop", Result: ResultSuccess},
+	} {
+		e.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		if err := log.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all, newest first", Filter{}, []string{"server.stop", "settings.update", "server.start", "update"}},
+		{"action group", Filter{Action: "server"}, []string{"server.stop", "server.start"}},
+		{"exact action", Filter{Action: "update"}, []string{"update"}},
+		{"actor", Filter{Actor: "10.0.0.1"}, []string{"settings.update", "server.start"}},
+		{"limit", Filter{Limit: 1}, []string{"server.stop"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := log.List(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Action)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	log := NewLog(filepath.Join(t.TempDir(), FileName))
+	bus := events.NewBus(nil)
+	unsubscribe := log.Subscribe(bus, func(err error) { t.Error(err) })
+
+	Publish(bus, Entry{Actor: "api 10.0.0.1", Action: "server.start", Target: "/srv/mc", Result: ResultSuccess})
+	bus.Publish(events.BackupCreated, map[string]interface{}{"name": "backup.zip"})
+	unsubscribe()
+	Publish(bus, Entry{Actor: "api 10.0.0.1", Action: "server.stop", Result: ResultSuccess})
+
+	entries, err := log.List(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("recorded %d entries, want the action published while subscribed", len(entries))
+	}
+	if e := entries[0]; e.Actor != "api 10.0.0.1" || e.Action != "server.start" || e.Target != "/srv/mc" || e.Result != ResultSuccess || e.Timestamp.IsZero() {
+		t.Errorf("entry = %+v", e)
+	}
+}
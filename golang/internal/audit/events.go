@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+)
+
+// Publish announces entry on bus as an events.Action event, for the log subscribed to
+// it to record
+func Publish(bus *events.Bus, entry Entry) {
+	bus.Publish(events.Action, map[string]interface{}{
+		"actor":   entry.Actor,
+		"action":  entry.Action,
+		"target":  entry.Target,
+		"result":  entry.Result,
+		"details": entry.Details,
+	})
+}
+
+// Subscribe appends the actions published on bus to l until the returned function is
+// called. failed is told about entries that could not be written.
+func (l *Log) Subscribe(bus *events.Bus, failed func(error)) func() {
+	return bus.Subscribe(func(e events.Event) {
+		field := func(key string) string {
+			s, _ := e.Data[key].(string)
+			return s
+		}
+		entry := Entry{
+			Timestamp: e.Time,
+			Actor:     field("actor"),
+			Action:    field("action"),
+			Target:    field("target"),
+			Result:    field("result"),
+			Details:   field("details"),
+		}
+		if err := l.Append(entry); err != nil {
+			failed(err)
+		}
+	}, events.Action)
+}
@@ -0,0 +1,162 @@
+// Package checksums records the files of a folder, usually server_path/mods, in a manifest
+// and verifies a folder against one to detect drift
+package checksums
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint:gosec // CurseForge and Modrinth publish SHA-1 hashes
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+)
+
+// Entry is one file of a manifest
+type Entry struct {
+	Path        string `json:"path"` // relative to the folder, with forward slashes
+	Size        int64  `json:"size"`
+	SHA1        string `json:"sha1"`
+	Fingerprint uint32 `json:"fingerprint"`          // CurseForge fingerprint, see api.Fingerprint
+	ProjectID   int    `json:"project_id,omitempty"` // CurseForge project, when the fingerprint is known
+	FileID      int    `json:"file_id,omitempty"`
+	Version     string `json:"version,omitempty"` // display name of the CurseForge file
+}
+
+// Manifest lists the files of a folder
+type Manifest struct {
+	Generated time.Time `json:"generated"`
+	Dir       string    `json:"dir"`
+	Files     []Entry   `json:"files"`
+}
+
+// Drift is the difference between a folder and a manifest
+type Drift struct {
+	Missing []string // in the manifest but not in the folder
+	Extra   []string // in the folder but not in the manifest
+	Changed []string // in both, with different content
+}
+
+// Clean reports whether the folder matches the manifest
+func (d *Drift) Clean() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Changed) == 0
+}
+
+// Build hashes every file below dir
+func Build(dir string, now time.Time) (*Manifest, error) {
+	m := &Manifest{Generated: now, Dir: dir, Files: []Entry{}}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		entry, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		entry.Path = filepath.ToSlash(rel)
+		m.Files = append(m.Files, *entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	return m, nil
+}
+
+// hashFile returns the size, SHA-1 and fingerprint of the file at path
+func hashFile(path string) (*Entry, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- files of the scanned folder
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(data) //nolint:gosec // see import
+	fingerprint, err := api.Fingerprint(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{Size: int64(len(data)), SHA1: hex.EncodeToString(sum[:]), Fingerprint: fingerprint}, nil
+}
+
+// Match fills in the CurseForge project and file of every entry whose fingerprint client
+// knows; files from elsewhere are left as they are
+func (m *Manifest) Match(client *api.Client) error {
+	if len(m.Files) == 0 {
+		return nil
+	}
+	fingerprints := make([]uint32, 0, len(m.Files))
+	for _, e := range m.Files {
+		fingerprints = append(fingerprints, e.Fingerprint)
+	}
+	files, err := client.GetFingerprintMatches(fingerprints)
+	if err != nil {
+		return fmt.Errorf("failed to match fingerprints: %w", err)
+	}
+	for i, e := range m.Files {
+		if f, ok := files[e.Fingerprint]; ok {
+			m.Files[i].ProjectID = f.ModID
+			m.Files[i].FileID = f.ID
+			m.Files[i].Version = f.DisplayName
+		}
+	}
+	return nil
+}
+
+// Verify compares the files below dir with m by SHA-1
+func Verify(dir string, m *Manifest) (*Drift, error) {
+	current, err := Build(dir, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]string, len(m.Files))
+	for _, e := range m.Files {
+		want[e.Path] = e.SHA1
+	}
+
+	drift := &Drift{Missing: []string{}, Extra: []string{}, Changed: []string{}}
+	for _, e := range current.Files {
+		sum, ok := want[e.Path]
+		switch {
+		case !ok:
+			drift.Extra = append(drift.Extra, e.Path)
+		case sum != e.SHA1:
+			drift.Changed = append(drift.Changed, e.Path)
+		}
+		delete(want, e.Path)
+	}
+	for path := range want {
+		drift.Missing = append(drift.Missing, path)
+	}
+	sort.Strings(drift.Missing)
+	return drift, nil
+}
+
+// Write encodes m as indented JSON
+func (m *Manifest) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Load reads a manifest written by Write
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is given on the command line
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
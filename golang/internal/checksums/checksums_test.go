@@ -0,0 +1,122 @@
+package checksums
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint:gosec // see checksums.go
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "jei.jar"), "jei")
+	writeFile(t, filepath.Join(dir, "create.jar"), "create")
+	writeFile(t, filepath.Join(dir, "extra", "old.jar"), "old")
+
+	m, err := Build(dir, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, e := range m.Files {
+		paths = append(paths, e.Path)
+	}
+	if fmt.Sprint(paths) != "[create.jar extra/old.jar jei.jar]" {
+		t.Fatalf("paths = %v", paths)
+	}
+	if sum := sha1.Sum([]byte("jei")); m.Files[2].SHA1 != hex.EncodeToString(sum[:]) || m.Files[2].Size != 3 {
+		t.Errorf("jei.jar = %+v", m.Files[2])
+	}
+
+	// Round trip through the file format
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	writeFile(t, path, buf.String())
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drift, err := Verify(dir, loaded)
+	if err != nil || !drift.Clean() {
+		t.Fatalf("Verify() = %+v, %v; want clean", drift, err)
+	}
+
+	writeFile(t, filepath.Join(dir, "jei.jar"), "jei 2")
+	writeFile(t, filepath.Join(dir, "new.jar"), "new")
+	if err := os.Remove(filepath.Join(dir, "extra", "old.jar")); err != nil {
+		t.Fatal(err)
+	}
+	drift, err = Verify(dir, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(drift.Missing, drift.Extra, drift.Changed) != "[extra/old.jar] [new.jar] [jei.jar]" {
+		t.Errorf("drift = %+v", drift)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "jei.jar"), "jei")
+	writeFile(t, filepath.Join(dir, "local.jar"), "local")
+	fingerprint, err := api.Fingerprint(strings.NewReader("jei"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/fingerprints/432" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Fingerprints []uint32 `json:"fingerprints"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Fingerprints) != 2 {
+			t.Errorf("unexpected request %v, %v", req, err)
+		}
+		fmt.Fprintf(w, `{"data":{"exactMatches":[{"id":238222,"file":{"id":5101,"modId":238222,"displayName":"JEI 15.2","fileFingerprint":%d}}]}}`, fingerprint)
+	}))
+	defer srv.Close()
+	client := api.NewClient("key")
+	client.BaseURL = srv.URL
+
+	m, err := Build(dir, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Match(client); err != nil {
+		t.Fatal(err)
+	}
+	jei, local := m.Files[0], m.Files[1]
+	if jei.ProjectID != 238222 || jei.FileID != 5101 || jei.Version != "JEI 15.2" {
+		t.Errorf("jei.jar = %+v", jei)
+	}
+	if local.ProjectID != 0 || local.FileID != 0 {
+		t.Errorf("local.jar should not match: %+v", local)
+	}
+}
@@ -0,0 +1,159 @@
+package checksums
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SkewEntry is a mod that differs between the server and a client
+type SkewEntry struct {
+	Name      string `json:"name"`                 // mod name, see ModName
+	ProjectID int    `json:"project_id,omitempty"` // CurseForge project, when known
+	Server    string `json:"server,omitempty"`     // file on the server, empty for mods only the client has
+	Client    string `json:"client,omitempty"`     // file on the client, empty for mods it lacks
+	Version   string `json:"version,omitempty"`    // display name of the server's CurseForge file
+	FileID    int    `json:"file_id,omitempty"`    // the server's CurseForge file
+}
+
+// Skew is how the mods of a client differ from the server's
+type Skew struct {
+	Missing    []SkewEntry // on the server only; players cannot join without them unless they are server-side only
+	Mismatched []SkewEntry // on both in another version
+	Extra      []SkewEntry // on the client only; usually client-side mods such as minimaps, which are fine
+}
+
+// Compatible reports whether the client has every server mod in the same version
+func (s *Skew) Compatible() bool {
+	return len(s.Missing) == 0 && len(s.Mismatched) == 0
+}
+
+// LoadList reads the mods of a client: a manifest written by Write, e.g. by `mods export`
+// on the client's mods folder, or a text file with one jar file name per line as written
+// by `ls mods`. Empty lines and lines starting with # are skipped.
+func LoadList(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is given on the command line
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mod list: %w", err)
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var m Manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+		}
+		return &m, nil
+	}
+
+	m := &Manifest{Dir: path, Files: []Entry{}}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m.Files = append(m.Files, Entry{Path: strings.ReplaceAll(line, `\`, "/")})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read mod list %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// ModName guesses the mod the jar at file belongs to from its base name: the words before
+// the first one that starts with a version number, e.g. "jei" for
+// jei-1.20.1-forge-15.2.0.27.jar
+func ModName(file string) string {
+	base := strings.ToLower(strings.TrimSuffix(path.Base(file), path.Ext(file)))
+	words := strings.FieldsFunc(base, func(r rune) bool { return r == '-' || r == '_' || r == '+' || r == ' ' })
+	var key []string
+	for _, w := range words {
+		// Also stops at words like v1.2 and mc1.20.1
+		if v := strings.TrimPrefix(strings.TrimPrefix(w, "mc"), "v"); v != "" && unicode.IsDigit(rune(v[0])) {
+			break
+		}
+		key = append(key, w)
+	}
+	if len(key) == 0 {
+		return base
+	}
+	return strings.Join(key, "-")
+}
+
+// Compare lists the jars in the client's mods that differ from the server's. Mods are
+// paired by CurseForge project when both sides know it, otherwise by ModName, and are
+// the same when their SHA-1, CurseForge file or file name is.
+func Compare(server, client *Manifest) *Skew {
+	skew := &Skew{Missing: []SkewEntry{}, Mismatched: []SkewEntry{}, Extra: []SkewEntry{}}
+	clientJars := jars(client)
+	used := make([]bool, len(clientJars))
+	byProject := map[int]int{}
+	byName := map[string][]int{}
+	for i, e := range clientJars {
+		if e.ProjectID != 0 {
+			byProject[e.ProjectID] = i
+		}
+		byName[ModName(e.Path)] = append(byName[ModName(e.Path)], i)
+	}
+	find := func(e Entry) int {
+		if i, ok := byProject[e.ProjectID]; ok && e.ProjectID != 0 && !used[i] {
+			return i
+		}
+		for _, i := range byName[ModName(e.Path)] {
+			if !used[i] {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, s := range jars(server) {
+		entry := SkewEntry{Name: ModName(s.Path), ProjectID: s.ProjectID, Server: s.Path, Version: s.Version, FileID: s.FileID}
+		i := find(s)
+		if i < 0 {
+			skew.Missing = append(skew.Missing, entry)
+			continue
+		}
+		used[i] = true
+		if c := clientJars[i]; !sameFile(s, c) {
+			entry.Client = c.Path
+			skew.Mismatched = append(skew.Mismatched, entry)
+		}
+	}
+	for i, c := range clientJars {
+		if !used[i] {
+			skew.Extra = append(skew.Extra, SkewEntry{Name: ModName(c.Path), ProjectID: c.ProjectID, Client: c.Path})
+		}
+	}
+	for _, list := range [][]SkewEntry{skew.Missing, skew.Mismatched, skew.Extra} {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	return skew
+}
+
+// jars returns the entries of m that are jar files
+func jars(m *Manifest) []Entry {
+	var out []Entry
+	for _, e := range m.Files {
+		if strings.EqualFold(path.Ext(e.Path), ".jar") {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// sameFile reports whether a and b are the same file, by the most precise detail both have
+func sameFile(a, b Entry) bool {
+	switch {
+	case a.SHA1 != "" && b.SHA1 != "":
+		return a.SHA1 == b.SHA1
+	case a.FileID != 0 && b.FileID != 0:
+		return a.FileID == b.FileID
+	}
+	return strings.EqualFold(path.Base(a.Path), path.Base(b.Path))
+}
@@ -0,0 +1,80 @@
+package checksums
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestModName(t *testing.T) {
+	for file, want := range map[string]string{
+		"jei-1.20.1-forge-15.2.0.27.jar":       "jei",
+		"sodium-fabric-0.5.8+mc1.20.1.jar":     "sodium-fabric",
+		"Botania-1.20.1-443-FORGE.jar":         "botania",
+		"appleskin-forge-mc1.20.1-2.5.jar":     "appleskin-forge",
+		"journeymap_v5.9.jar":                  "journeymap",
+		"mods/Xaeros_Minimap_23.9.7_Forge.jar": "xaeros-minimap",
+		"1.20.1-only.jar":                      "1.20.1-only",
+	} {
+		if got := ModName(file); got != want {
+			t.Errorf("ModName(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	server := &Manifest{Files: []Entry{
+		{Path: "jei-1.20.1-15.2.jar", SHA1: "a", ProjectID: 238222, FileID: 5101, Version: "JEI 15.2"},
+		{Path: "create-1.20.1-0.5.1.jar", SHA1: "b"},
+		{Path: "ftb-library-2001.1.3.jar", SHA1: "c", ProjectID: 404465, FileID: 7000},
+		{Path: "spark-1.10.53.jar", SHA1: "d"},
+		{Path: "config/jei.toml", SHA1: "e"},
+	}}
+	client := &Manifest{Files: []Entry{
+		{Path: "jei-15.1.jar", SHA1: "x", ProjectID: 238222, FileID: 5000},
+		{Path: "create-1.20.1-0.5.1.jar", SHA1: "b"},
+		{Path: "FTBLibrary.jar", SHA1: "c", ProjectID: 404465, FileID: 7000},
+		{Path: "xaeros_minimap_23.9.7.jar", SHA1: "z"},
+	}}
+
+	skew := Compare(server, client)
+	if skew.Compatible() {
+		t.Fatal("Compatible() = true, want false")
+	}
+	if len(skew.Missing) != 1 || skew.Missing[0].Server != "spark-1.10.53.jar" {
+		t.Errorf("Missing = %+v, want spark", skew.Missing)
+	}
+	want := SkewEntry{Name: "jei", ProjectID: 238222, Server: "jei-1.20.1-15.2.jar", Client: "jei-15.1.jar", Version: "JEI 15.2", FileID: 5101}
+	if len(skew.Mismatched) != 1 || skew.Mismatched[0] != want {
+		t.Errorf("Mismatched = %+v, want %+v", skew.Mismatched, want)
+	}
+	if len(skew.Extra) != 1 || skew.Extra[0].Client != "xaeros_minimap_23.9.7.jar" {
+		t.Errorf("Extra = %+v, want the minimap", skew.Extra)
+	}
+}
+
+func TestLoadList(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "mods.txt")
+	writeFile(t, list, "# from ls mods\njei-15.2.jar\r\n\nsubdir\\create-0.5.1.jar\n")
+	m, err := LoadList(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(m.Files); got != "[{jei-15.2.jar 0  0 0 0 } {subdir/create-0.5.1.jar 0  0 0 0 }]" {
+		t.Errorf("Files = %s", got)
+	}
+
+	server := &Manifest{Files: []Entry{{Path: "jei-15.2.jar", SHA1: "a"}, {Path: "create-0.5.2.jar", SHA1: "b"}}}
+	skew := Compare(server, m)
+	if len(skew.Missing) != 0 || len(skew.Mismatched) != 1 || skew.Mismatched[0].Client != "subdir/create-0.5.1.jar" {
+		t.Errorf("Compare with a plain list = %+v", skew)
+	}
+
+	// A manifest written by mods export
+	manifest := filepath.Join(dir, "client.json")
+	writeFile(t, manifest, `{"dir":"mods","files":[{"path":"jei-15.2.jar","sha1":"a"}]}`)
+	if m, err = LoadList(manifest); err != nil || len(m.Files) != 1 || m.Files[0].SHA1 != "a" {
+		t.Errorf("LoadList(manifest) = %+v, %v", m, err)
+	}
+}
@@ -0,0 +1,35 @@
+package clock
+
+import (
+	"time"
+)
+
+// Clock abstracts the passage of time so time-based features can be tested
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// realClock is a Clock backed by the time package
+type realClock struct{}
+
+// Real returns a Clock that uses the system time
+func Real() Clock {
+	return realClock{}
+}
+
+// Now returns the current system time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time on the returned channel
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Sleep pauses the current goroutine for the given duration
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// AllInstances selects every [[servers]] entry on the command line
+const AllInstances = "all"
+
+// instanceName restricts names to what is safe in paths and flags
+var instanceName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// InstanceConfig is one [[servers]] entry. Unset fields inherit the top-level setting;
+// data_dir, backup_path and download_path default to a subdirectory named after the
+// instance so that instances never share state.
+type InstanceConfig struct {
+	Name          string        `mapstructure:"name"`
+	ModpackID     int           `mapstructure:"modpack_id"`
+	GameVersion   string        `mapstructure:"game_version"`
+	UpdateChannel string        `mapstructure:"update_channel"`
+	ServerPath    string        `mapstructure:"server_path"`
+	ServerJarName string        `mapstructure:"server_jar_name"`
+	BackupPath    string        `mapstructure:"backup_path"`
+	DownloadPath  string        `mapstructure:"download_path"`
+	DataDir       string        `mapstructure:"data_dir"`
+	AutoUpdate    *bool         `mapstructure:"auto_update"` // overrides update_policy.auto_update
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+}
+
+// HasInstances reports whether the config lists [[servers]] entries
+func (c *Config) HasInstances() bool {
+	return len(c.Servers) > 0
+}
+
+// Instance returns the configuration of the [[servers]] entry called name
+func (c *Config) Instance(name string) (*Config, error) {
+	for _, inst := range c.Servers {
+		if inst.Name == name {
+			return c.apply(inst), nil
+		}
+	}
+	if !c.HasInstances() {
+		return nil, fmt.Errorf("no [[servers]] are configured")
+	}
+	return nil, fmt.Errorf("unknown server %q, configured servers: %v", name, c.InstanceNames())
+}
+
+// Instances returns the configuration of every [[servers]] entry, or just c when there
+// are none
+func (c *Config) Instances() []*Config {
+	if !c.HasInstances() {
+		return []*Config{c}
+	}
+	out := make([]*Config, 0, len(c.Servers))
+	for _, inst := range c.Servers {
+		out = append(out, c.apply(inst))
+	}
+	return out
+}
+
+// InstanceNames lists the configured instance names in config order
+func (c *Config) InstanceNames() []string {
+	names := make([]string, 0, len(c.Servers))
+	for _, inst := range c.Servers {
+		names = append(names, inst.Name)
+	}
+	return names
+}
+
+// apply layers inst over a copy of c
+func (c *Config) apply(inst InstanceConfig) *Config {
+	out := *c
+	out.Servers = nil
+	out.InstanceName = inst.Name
+	out.DataDir = filepath.Join(c.DataDir, inst.Name)
+	out.BackupPath = filepath.Join(c.BackupPath, inst.Name)
+	out.DownloadPath = filepath.Join(c.DownloadPath, inst.Name)
+
+	if inst.ModpackID != 0 {
+		out.ModpackID = inst.ModpackID
+	}
+	for dst, src := range map[*string]string{
+		&out.GameVersion:   inst.GameVersion,
+		&out.UpdateChannel: inst.UpdateChannel,
+		&out.ServerPath:    inst.ServerPath,
+		&out.ServerJarName: inst.ServerJarName,
+		&out.BackupPath:    inst.BackupPath,
+		&out.DownloadPath:  inst.DownloadPath,
+		&out.DataDir:       inst.DataDir,
+	} {
+		if src != "" {
+			*dst = src
+		}
+	}
+	if inst.AutoUpdate != nil {
+		out.UpdatePolicy.AutoUpdate = *inst.AutoUpdate
+	}
+	if inst.CheckInterval != 0 {
+		out.CheckInterval = inst.CheckInterval
+	}
+	return &out
+}
+
+// validateInstances checks the [[servers]] entries and the settings each one ends up with
+func validateInstances(config *Config) error {
+	seen := make(map[string]bool, len(config.Servers))
+	paths := make(map[string]string, len(config.Servers))
+	for _, inst := range config.Servers {
+		switch {
+		case !instanceName.MatchString(inst.Name):
+			return fmt.Errorf("servers: name %q must be lowercase letters, digits, - and _", inst.Name)
+		case inst.Name == AllInstances:
+			return fmt.Errorf("servers: %q is reserved for selecting every server", AllInstances)
+		case seen[inst.Name]:
+			return fmt.Errorf("servers: %s is listed more than once", inst.Name)
+		case inst.ServerPath == "":
+			return fmt.Errorf("servers: server_path is required for %s", inst.Name)
+		case inst.ModpackID < 0:
+			return fmt.Errorf("servers: modpack_id of %s must not be negative", inst.Name)
+		case inst.CheckInterval != 0 && inst.CheckInterval < time.Minute:
+			return fmt.Errorf("servers: check_interval of %s must be at least 1m", inst.Name)
+		}
+		switch inst.UpdateChannel {
+		case "", "stable", "beta", "alpha":
+		default:
+			return fmt.Errorf("servers: update_channel of %s must be one of: stable, beta, alpha", inst.Name)
+		}
+		path := filepath.Clean(inst.ServerPath)
+		if other, ok := paths[path]; ok {
+			return fmt.Errorf("servers: %s and %s share server_path %s", other, inst.Name, inst.ServerPath)
+		}
+		seen[inst.Name] = true
+		paths[path] = inst.Name
+	}
+	return nil
+}
+
+// instanceSettings is the [[servers]] list in the shape SaveConfig writes, leaving out
+// inherited fields
+func instanceSettings(servers []InstanceConfig) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(servers))
+	for _, inst := range servers {
+		m := map[string]interface{}{"name": inst.Name}
+		if inst.ModpackID != 0 {
+			m["modpack_id"] = inst.ModpackID
+		}
+		for key, value := range map[string]string{
+			"game_version": inst.GameVersion, "update_channel": inst.UpdateChannel,
+			"server_path": inst.ServerPath, "server_jar_name": inst.ServerJarName,
+			"backup_path": inst.BackupPath, "download_path": inst.DownloadPath, "data_dir": inst.DataDir,
+		} {
+			if value != "" {
+				m[key] = value
+			}
+		}
+		if inst.AutoUpdate != nil {
+			m["auto_update"] = *inst.AutoUpdate
+		}
+		if inst.CheckInterval != 0 {
+			m["check_interval"] = inst.CheckInterval.String()
+		}
+		out = append(out, m)
+	}
+	return out
+}
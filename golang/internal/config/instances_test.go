@@ -0,0 +1,105 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstances(t *testing.T) {
+	path := writeConfig(t, "config.toml", `
+api_key = "key"
+modpack_id = 1
+server_path = "/srv/main"
+backup_path = "/backups"
+data_dir = "/data"
+check_interval = "1h"
+
+[[servers]]
+name = "survival"
+server_path = "/srv/survival"
+
+[[servers]]
+name = "creative"
+modpack_id = 2
+server_path = "/srv/creative"
+server_jar_name = "forge.jar"
+data_dir = "/var/lib/creative"
+auto_update = true
+check_interval = "15m"
+`)
+	cfg, err := Load(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if got := strings.Join(cfg.InstanceNames(), ","); got != "survival,creative" {
+		t.Fatalf("InstanceNames = %s", got)
+	}
+
+	survival, err := cfg.Instance("survival")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if survival.ModpackID != 1 || survival.ServerPath != "/srv/survival" || survival.ServerJarName != "server.jar" ||
+		survival.DataDir != filepath.Join("/data", "survival") || survival.BackupPath != filepath.Join("/backups", "survival") ||
+		survival.CheckInterval != time.Hour || survival.UpdatePolicy.AutoUpdate || survival.InstanceName != "survival" {
+		t.Fatalf("survival should inherit the top-level settings, got %+v", survival)
+	}
+	creative, _ := cfg.Instance("creative")
+	if creative.ModpackID != 2 || creative.ServerJarName != "forge.jar" || creative.DataDir != "/var/lib/creative" ||
+		!creative.UpdatePolicy.AutoUpdate || creative.CheckInterval != 15*time.Minute {
+		t.Fatalf("creative overrides were not applied, got %+v", creative)
+	}
+	if cfg.ServerPath != "/srv/main" || cfg.InstanceName != "" {
+		t.Fatal("Instance must not change the top-level config")
+	}
+	if _, err := cfg.Instance("lobby"); err == nil || !strings.Contains(err.Error(), "survival creative") {
+		t.Fatalf("unknown instance err = %v", err)
+	}
+	if all := cfg.Instances(); len(all) != 2 || all[1].InstanceName != "creative" {
+		t.Fatalf("Instances = %v", all)
+	}
+
+	// Saving keeps the list without the inherited settings
+	saved := filepath.Join(t.TempDir(), "saved.toml")
+	if err := SaveConfig(cfg, saved); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(Options{Path: saved})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.Servers) != 2 || reloaded.Servers[0].ModpackID != 0 || reloaded.Servers[1].CheckInterval != 15*time.Minute ||
+		reloaded.Servers[1].AutoUpdate == nil || !*reloaded.Servers[1].AutoUpdate {
+		t.Fatalf("saved servers = %+v", reloaded.Servers)
+	}
+}
+
+func TestValidateInstances(t *testing.T) {
+	tests := []struct {
+		name    string
+		servers []InstanceConfig
+		want    string
+	}{
+		{"bad name", []InstanceConfig{{Name: "My Server", ServerPath: "/a"}}, "lowercase"},
+		{"reserved name", []InstanceConfig{{Name: "all", ServerPath: "/a"}}, "reserved"},
+		{"duplicate", []InstanceConfig{{Name: "a", ServerPath: "/a"}, {Name: "a", ServerPath: "/b"}}, "more than once"},
+		{"no path", []InstanceConfig{{Name: "a"}}, "server_path is required"},
+		{"shared path", []InstanceConfig{{Name: "a", ServerPath: "/a"}, {Name: "b", ServerPath: "/a/"}}, "share server_path"},
+		{"short interval", []InstanceConfig{{Name: "a", ServerPath: "/a", CheckInterval: time.Second}}, "at least 1m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := GetDefaultConfig()
+			cfg.ModpackID = 1
+			cfg.Servers = tt.servers
+			if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,450 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// ErrNotFound is returned by Load when no config file exists and AllowMissing is not set
+var ErrNotFound = errors.New("config file not found")
+
+// Options controls where Load looks for configuration
+type Options struct {
+	// Path is a config file path, or a bare name searched for in the default locations
+	Path string
+
+	// Flags holds command line flags; changed flags named after config keys
+	// (with dashes instead of underscores) override every other source
+	Flags *pflag.FlagSet
+
+	// AllowMissing loads defaults and environment variables when no file is found
+	AllowMissing bool
+}
+
+// legacyKeys maps keys from older config layouts to the current schema
+var legacyKeys = map[string]string{
+	"mod_id":                   "modpack_id",
+	"curseforge.api_key":       "api_key",
+	"curseforge.mod_id":        "modpack_id",
+	"curseforge.modpack_id":    "modpack_id",
+	"curseforge.download_path": "download_path",
+	"auto_update":              "update_policy.auto_update",
+}
+
+// legacyEnv lists extra environment variable names accepted for a key
+var legacyEnv = map[string][]string{
+	"api_key":    {"API_KEY", "CURSEFORGE_API_KEY"},
+	"modpack_id": {"MODPACK_ID", "MOD_ID"},
+
+	"update_policy.auto_update": {"UPDATE_POLICY_AUTO_UPDATE", "AUTO_UPDATE"},
+}
+
+// LoadConfig loads configuration from file and validates it
+func LoadConfig(configPath string) (*Config, error) {
+	config, err := ReadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if err := Validate(config); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	return config, nil
+}
+
+// ReadConfig loads configuration from file without validating it
+func ReadConfig(configPath string) (*Config, error) {
+	return Load(Options{Path: configPath})
+}
+
+// Load builds the configuration from defaults < file < environment < flags
+func Load(opts Options) (*Config, error) {
+	v := viper.New()
+
+	// Set default values
+	setDefaults(v)
+
+	// Read the config file into its own instance so legacy keys can be migrated
+	// before it is layered over the defaults
+	file, settings, err := readFile(opts.Path)
+	switch {
+	case errors.Is(err, ErrNotFound) && opts.AllowMissing:
+	case err != nil:
+		return nil, err
+	}
+	deprecations := migrateLegacyKeys(settings)
+	expandEnv(settings)
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("error merging config file: %w", err)
+	}
+
+	// Read environment variables, e.g. NOTIFICATIONS_DISCORD_WEBHOOK_URL
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for key, names := range legacyEnv {
+		if err := v.BindEnv(append([]string{key}, names...)...); err != nil {
+			return nil, fmt.Errorf("error binding environment for %s: %w", key, err)
+		}
+	}
+
+	// Apply flags that were set explicitly
+	if opts.Flags != nil {
+		known := make(map[string]bool)
+		for _, key := range v.AllKeys() {
+			known[key] = true
+		}
+		opts.Flags.Visit(func(f *pflag.Flag) {
+			key := strings.ReplaceAll(f.Name, "-", "_")
+			if known[key] {
+				v.Set(key, f.Value.String())
+			}
+		})
+	}
+
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("error unmarshaling config: %w", err)
+	}
+	if err := resolveSecretFiles(&config); err != nil {
+		return nil, err
+	}
+	config.Notifications.Minecraft.RCON = config.Server.RCON
+	config.File = file
+	config.Deprecations = deprecations
+
+	return &config, nil
+}
+
+// readFile reads the config file at path, or searches for it when path has no extension
+func readFile(path string) (string, map[string]interface{}, error) {
+	if path == "" {
+		path = getDefaultConfigPath()
+	}
+
+	fv := viper.New()
+	if ext := strings.TrimPrefix(filepath.Ext(path), "."); ext != "" {
+		if !filesystem.FileExists(path) {
+			return "", map[string]interface{}{}, fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		fv.SetConfigFile(path)
+		fv.SetConfigType(ext)
+	} else {
+		// Treat as config name (no extension), search in current and standard locations
+		fv.SetConfigName(path)
+		fv.SetConfigType("toml")
+		fv.AddConfigPath(".")
+		fv.AddConfigPath("/etc/curseforge-autoupdater")
+		if home, err := os.UserHomeDir(); err == nil {
+			fv.AddConfigPath(filepath.Join(home, ".curseforge-autoupdater"))
+		}
+	}
+
+	if err := fv.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) {
+			return "", map[string]interface{}{}, fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		return "", nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	return fv.ConfigFileUsed(), fv.AllSettings(), nil
+}
+
+// migrateLegacyKeys rewrites legacy keys in settings and reports each one found
+func migrateLegacyKeys(settings map[string]interface{}) []string {
+	var deprecations []string
+	for _, old := range sortedKeys(legacyKeys) {
+		value, ok := lookupKey(settings, old)
+		if !ok {
+			continue
+		}
+		current := legacyKeys[old]
+		deleteKey(settings, old)
+		if _, exists := lookupKey(settings, current); exists {
+			deprecations = append(deprecations, fmt.Sprintf("config key %q is ignored because %q is set", old, current))
+			continue
+		}
+		setKey(settings, current, value)
+		deprecations = append(deprecations, fmt.Sprintf("config key %q is deprecated, use %q", old, current))
+	}
+
+	// Drop sections left empty by the migration
+	for key, value := range settings {
+		if m, ok := value.(map[string]interface{}); ok && len(m) == 0 {
+			delete(settings, key)
+		}
+	}
+	return deprecations
+}
+
+// lookupKey finds a dotted key in nested settings
+func lookupKey(settings map[string]interface{}, key string) (interface{}, bool) {
+	parts := strings.Split(key, ".")
+	current := settings
+	for i, part := range parts {
+		value, ok := current[part]
+		if !ok {
+			return nil, false
+		}
+		if i == len(parts)-1 {
+			return value, true
+		}
+		if current, ok = value.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// setKey sets a dotted key in nested settings, adding the sections it needs
+func setKey(settings map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	current := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// deleteKey removes a dotted key from nested settings
+func deleteKey(settings map[string]interface{}, key string) {
+	parts := strings.Split(key, ".")
+	current := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, parts[len(parts)-1])
+}
+
+// sortedKeys returns the keys of m in a stable order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setDefaults sets default configuration values
+func setDefaults(v *viper.Viper) {
+	// API defaults
+	v.SetDefault("api_key", "")
+	v.SetDefault("api_key_file", "")
+	v.SetDefault("api.base_url", "")
+	v.SetDefault("api.keyless", false)
+	v.SetDefault("api.download_host", "")
+	v.SetDefault("cache.ttl", "10m")
+	v.SetDefault("cache.dir", "")
+
+	// HTTP client defaults
+	v.SetDefault("http.proxy_url", "")
+	v.SetDefault("http.ca_file", "")
+	v.SetDefault("http.insecure_skip_verify", false)
+	v.SetDefault("http.timeout", "30s")
+	v.SetDefault("http.connect_timeout", "10s")
+	v.SetDefault("download.max_rate", "")
+	v.SetDefault("download.keep_versions", 3)
+	v.SetDefault("download.cache_dir", "")
+	v.SetDefault("download.cache_max_size", "10GB")
+
+	// Modpack defaults
+	v.SetDefault("modpack_id", 0)
+	v.SetDefault("modpack_provider", "curseforge")
+	v.SetDefault("game_version", "1.20.1")
+
+	// Server defaults
+	v.SetDefault("server_path", "./server")
+	v.SetDefault("backup_path", "./backups")
+	v.SetDefault("server_jar_name", "server.jar")
+	v.SetDefault("preserve", DefaultPreserve)
+	v.SetDefault("server_jar.type", "")
+	v.SetDefault("server_jar.minecraft_version", "")
+	v.SetDefault("server_jar.loader_version", "")
+	v.SetDefault("server_jar.java", "java")
+	v.SetDefault("server.mode", "process")
+	v.SetDefault("server.shutdown_timeout", "30s")
+	v.SetDefault("server.name", "")
+	v.SetDefault("server.port", 0)
+	v.SetDefault("server.max_players", 0)
+	v.SetDefault("server.process.user", "")
+	v.SetDefault("server.process.working_dir", "")
+	v.SetDefault("server.process.umask", "")
+	v.SetDefault("server.process.env", []string{})
+	v.SetDefault("server.docker.host", "unix:///var/run/docker.sock")
+	v.SetDefault("server.docker.container", "")
+	v.SetDefault("server.docker.data_dir", "/data")
+	v.SetDefault("server.systemd.unit", "")
+	v.SetDefault("server.systemd.user", false)
+	v.SetDefault("server.pterodactyl.panel_url", "")
+	v.SetDefault("server.pterodactyl.api_key", "")
+	v.SetDefault("server.pterodactyl.api_key_file", "")
+	v.SetDefault("server.pterodactyl.server_id", "")
+	v.SetDefault("server.kubernetes.namespace", "")
+	v.SetDefault("server.kubernetes.deployment", "")
+	v.SetDefault("server.kubernetes.ready_file", "")
+	v.SetDefault("server.kubernetes.restart_on_update", false)
+	v.SetDefault("server.rcon.address", "")
+	v.SetDefault("server.rcon.password", "")
+	v.SetDefault("server.rcon.password_file", "")
+	v.SetDefault("server.countdown.schedule", []string{"5m", "1m", "30s", "10s"})
+	v.SetDefault("server.countdown.message", "Server will restart for maintenance in {time}")
+	v.SetDefault("server.countdown.final_message", "Server is restarting now")
+	v.SetDefault("server.countdown.cancel_message", "Restart cancelled")
+	v.SetDefault("server.countdown.channel", "chat")
+	v.SetDefault("server.maintenance_mode.enabled", false)
+	v.SetDefault("server.maintenance_mode.motd", DefaultMaintenanceMOTD)
+	v.SetDefault("server.maintenance_mode.eta", "10m")
+	v.SetDefault("server.maintenance_mode.port", 0)
+	v.SetDefault("server.monitor.interval", "0s")
+	v.SetDefault("server.monitor.max_cpu_percent", 0)
+	v.SetDefault("server.monitor.max_memory", "")
+	v.SetDefault("server.monitor.min_tps", 0)
+	v.SetDefault("server.health_check.enabled", false)
+	v.SetDefault("server.health_check.timeout", "10m")
+	v.SetDefault("server.health_check.max_crashes", 2)
+	v.SetDefault("server.health_check.rollback", true)
+
+	// Storage defaults
+	v.SetDefault("download_path", "./downloads")
+	v.SetDefault("manual_download_path", "./manual")
+	v.SetDefault("data_dir", "./data")
+
+	// Update defaults
+	v.SetDefault("update_channel", "stable")
+	v.SetDefault("mod_changelogs", true)
+	v.SetDefault("differential_sync", false)
+	v.SetDefault("release_policy.min_age", "0s")
+	v.SetDefault("release_policy.stable_mods", false)
+	v.SetDefault("update_policy.auto_update", false)
+	v.SetDefault("update_policy.release_types", []string{})
+	v.SetDefault("update_policy.min_file_age", "0s")
+	v.SetDefault("update_policy.game_versions", []string{})
+	v.SetDefault("update_policy.blackout_dates", []string{})
+	v.SetDefault("update_policy.max_updates_per_week", 0)
+	v.SetDefault("update_policy.require_empty_server", false)
+	v.SetDefault("update_policy.confirm_minecraft_upgrades", false)
+	v.SetDefault("approval.required", false)
+	v.SetDefault("approval.expire_after", "72h")
+	v.SetDefault("approval.remind_every", "24h")
+
+	// Daemon defaults
+	v.SetDefault("check_interval", "1h")
+	v.SetDefault("maintenance.window_start", "")
+	v.SetDefault("maintenance.window_end", "")
+	v.SetDefault("maintenance.timezone", "")
+	v.SetDefault("maintenance.announce_before", "0s")
+	v.SetDefault("fleet.jitter", "0s")
+	v.SetDefault("fleet.lock", "")
+	v.SetDefault("fleet.lock_file", "")
+	v.SetDefault("fleet.redis_url", "")
+	v.SetDefault("fleet.lock_key", "curseforge-autoupdater")
+	v.SetDefault("fleet.lock_ttl", "2m")
+	v.SetDefault("backup.retention_days", 7)
+	v.SetDefault("backup.compression", true)
+	v.SetDefault("backup.incremental", false)
+	v.SetDefault("backup.keep_last", 0)
+	v.SetDefault("backup.keep_daily", 0)
+	v.SetDefault("backup.keep_weekly", 0)
+	v.SetDefault("backup.keep_monthly", 0)
+	v.SetDefault("backup.restore_owner", "")
+	v.SetDefault("backup.snapshot", "")
+	v.SetDefault("backup.encryption.passphrase", "")
+	v.SetDefault("backup.encryption.passphrase_file", "")
+	v.SetDefault("backup.encryption.recipients", []string{})
+	v.SetDefault("backup.encryption.identity_file", "")
+
+	// Plugin defaults
+	v.SetDefault("plugins.dir", "./plugins")
+	v.SetDefault("plugins.timeout", "5m")
+
+	// Web defaults
+	v.SetDefault("web.listen", ":8080")
+	v.SetDefault("web.api_token", "")
+	v.SetDefault("web.api_token_file", "")
+	v.SetDefault("web.webhook_secret", "")
+	v.SetDefault("web.webhook_secret_file", "")
+	v.SetDefault("web.tls_cert", "")
+	v.SetDefault("web.tls_key", "")
+	v.SetDefault("web.static_dir", "")
+	v.SetDefault("web.public_url", "")
+	v.SetDefault("web.grpc_listen", "")
+
+	// Logging defaults
+	v.SetDefault("log_level", "info")
+	v.SetDefault("log_format", "text")
+	v.SetDefault("log_file", "")
+
+	// Notification defaults; every key needs a default so it can be set from the environment
+	v.SetDefault("notifications.discord.enabled", false)
+	v.SetDefault("notifications.discord.webhook_url", "")
+	v.SetDefault("notifications.discord.webhook_url_file", "")
+	v.SetDefault("notifications.discord.channel_id", "")
+	v.SetDefault("notifications.discord.username", "CurseForge Auto-Updater")
+	v.SetDefault("notifications.discord.avatar_url", "")
+	v.SetDefault("notifications.webhook.enabled", false)
+	v.SetDefault("notifications.webhook.url", "")
+	v.SetDefault("notifications.webhook.url_file", "")
+	v.SetDefault("notifications.webhook.method", "POST")
+	v.SetDefault("notifications.webhook.content_type", "application/json")
+	v.SetDefault("notifications.webhook.timeout", "30s")
+	v.SetDefault("notifications.pushover.enabled", false)
+	v.SetDefault("notifications.pushover.token", "")
+	v.SetDefault("notifications.pushover.token_file", "")
+	v.SetDefault("notifications.pushover.user", "")
+	v.SetDefault("notifications.pushover.device", "")
+	v.SetDefault("notifications.ntfy.enabled", false)
+	v.SetDefault("notifications.ntfy.server", "https://ntfy.sh")
+	v.SetDefault("notifications.ntfy.topic", "")
+	v.SetDefault("notifications.ntfy.token", "")
+	v.SetDefault("notifications.ntfy.token_file", "")
+	v.SetDefault("notifications.minecraft.enabled", false)
+	v.SetDefault("notifications.minecraft.prefix", "[Updates]")
+	v.SetDefault("notifications.retry.attempts", 3)
+	v.SetDefault("notifications.retry.backoff", "1s")
+	v.SetDefault("notifications.retry.max_backoff", "30s")
+	v.SetDefault("notifications.retry.breaker_failures", 5)
+	v.SetDefault("notifications.retry.breaker_cooldown", "5m")
+	v.SetDefault("event_sink.type", "")
+	v.SetDefault("event_sink.url", "")
+	v.SetDefault("event_sink.subject", "curseforge-autoupdater.{event}")
+	v.SetDefault("event_sink.events", []string{"update_available", "update_finished", "update_failed", "backup_created"})
+}
+
+// getDefaultConfigPath returns the default configuration file path
+func getDefaultConfigPath() string {
+	// Look for config file in current directory first
+	configFiles := []string{
+		"config.toml",
+		"curseforge-autoupdate.toml",
+		".curseforge-autoupdate.toml",
+	}
+
+	for _, file := range configFiles {
+		if filesystem.FileExists(file) {
+			return file
+		}
+	}
+
+	// If not found, return default name
+	return "config.toml"
+}
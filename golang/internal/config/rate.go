@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rateUnits maps the units accepted by ParseRate to bytes; K, M and G are multiples of
+// 1024, like the sizes and rates shown by the CLI
+var rateUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+}
+
+// ParseRate parses a transfer rate such as "10MB/s", "512 KiB/s" or "2M" into bytes per
+// second. An empty string or "0" means no limit and returns 0.
+func ParseRate(s string) (int64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimSpace(strings.TrimSuffix(s, "/s"))
+	if s == "" {
+		return 0, nil
+	}
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a rate like 10MB/s", s)
+	}
+	unit, ok := rateUnits[strings.TrimSpace(s[i:])]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit in %q, use B, KB, MB or GB per second", s)
+	}
+	return int64(n * unit), nil
+}
+
+// ParseSize parses an amount of memory or data such as "8GB" or "512 MiB" into bytes, with
+// the units of ParseRate. An empty string or "0" returns 0.
+func ParseSize(s string) (int64, error) {
+	if strings.HasSuffix(strings.TrimSpace(s), "/s") {
+		return 0, fmt.Errorf("%q is a rate, not a size like 8GB", s)
+	}
+	size, err := ParseRate(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a size like 8GB", strings.TrimSpace(s))
+	}
+	return size, nil
+}
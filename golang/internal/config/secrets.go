@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envRef matches ${NAME} references in config file values
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${NAME} references in string settings with environment variables.
+// Only the braced form is expanded so that a literal $ in a secret is left alone.
+func expandEnv(settings map[string]interface{}) {
+	for key, value := range settings {
+		settings[key] = expandValue(value)
+	}
+}
+
+// expandValue expands references in a single setting, descending into tables and lists
+func expandValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return envRef.ReplaceAllStringFunc(v, func(ref string) string {
+			return os.Getenv(envRef.FindStringSubmatch(ref)[1])
+		})
+	case map[string]interface{}:
+		expandEnv(v)
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = expandValue(v[i])
+		}
+		return v
+	}
+	return value
+}
+
+// resolveSecretFiles replaces secrets with the contents of their *_file counterparts
+func resolveSecretFiles(config *Config) error {
+	for _, secret := range []struct {
+		key   string
+		file  string
+		value *string
+	}{
+		{"api_key_file", config.APIKeyFile, &config.APIKey},
+		{"notifications.discord.webhook_url_file", config.Notifications.Discord.WebhookURLFile, &config.Notifications.Discord.WebhookURL},
+		{"notifications.webhook.url_file", config.Notifications.Webhook.URLFile, &config.Notifications.Webhook.URL},
+		{"notifications.pushover.token_file", config.Notifications.Pushover.TokenFile, &config.Notifications.Pushover.Token},
+		{"notifications.ntfy.token_file", config.Notifications.Ntfy.TokenFile, &config.Notifications.Ntfy.Token},
+		{"web.api_token_file", config.Web.APITokenFile, &config.Web.APIToken},
+		{"web.webhook_secret_file", config.Web.WebhookSecretFile, &config.Web.WebhookSecret},
+		{"server.pterodactyl.api_key_file", config.Server.Pterodactyl.APIKeyFile, &config.Server.Pterodactyl.APIKey},
+		{"server.rcon.password_file", config.Server.RCON.PasswordFile, &config.Server.RCON.Password},
+		{"backup.encryption.passphrase_file", config.Backup.Encryption.PassphraseFile, &config.Backup.Encryption.Passphrase},
+	} {
+		if secret.file == "" {
+			continue
+		}
+		data, err := os.ReadFile(secret.file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", secret.key, err)
+		}
+		*secret.value = strings.TrimSpace(string(data))
+	}
+	return nil
+}
+
+// Secrets returns the configured secret values so they can be redacted from logs
+func (c *Config) Secrets() []string {
+	candidates := []string{
+		c.APIKey,
+		c.Notifications.Discord.WebhookURL,
+		c.Notifications.Webhook.URL,
+		c.Notifications.Pushover.Token,
+		c.Notifications.Pushover.User,
+		c.Notifications.Ntfy.Token,
+		c.Web.APIToken,
+		c.Web.WebhookSecret,
+		c.Server.Pterodactyl.APIKey,
+		c.Server.RCON.Password,
+		c.Backup.Encryption.Passphrase,
+	}
+	for _, value := range c.Notifications.Webhook.Headers {
+		candidates = append(candidates, value)
+	}
+	for _, raw := range []string{c.HTTP.ProxyURL, c.Fleet.RedisURL, c.EventSink.URL} {
+		if u, err := url.Parse(raw); err == nil && u.User != nil {
+			password, _ := u.User.Password()
+			candidates = append(candidates, password)
+		}
+	}
+	if u, err := url.Parse(c.EventSink.URL); err == nil && u.User != nil && c.EventSink.Type == "nats" {
+		if _, ok := u.User.Password(); !ok {
+			// nats://token@host
+			candidates = append(candidates, u.User.Username())
+		}
+	}
+
+	var secrets []string
+	for _, s := range candidates {
+		if s != "" {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets
+}
+
+// WithoutSecrets returns a copy of c with every value listed by Secrets cleared, for
+// sharing a config. Paths to *_file secrets are kept; the files themselves are not read.
+func (c *Config) WithoutSecrets() *Config {
+	out := *c
+	out.APIKey = ""
+	out.Notifications.Discord.WebhookURL = ""
+	out.Notifications.Webhook.URL = ""
+	out.Notifications.Pushover.Token = ""
+	out.Notifications.Pushover.User = ""
+	out.Notifications.Ntfy.Token = ""
+	out.Web.APIToken = ""
+	out.Web.WebhookSecret = ""
+	out.Server.Pterodactyl.APIKey = ""
+	out.Server.RCON.Password = ""
+	out.Notifications.Minecraft.RCON.Password = ""
+	out.Backup.Encryption.Passphrase = ""
+	if len(c.Notifications.Webhook.Headers) > 0 {
+		out.Notifications.Webhook.Headers = make(map[string]string, len(c.Notifications.Webhook.Headers))
+		for name := range c.Notifications.Webhook.Headers {
+			out.Notifications.Webhook.Headers[name] = ""
+		}
+	}
+	for _, raw := range []*string{&out.HTTP.ProxyURL, &out.Fleet.RedisURL, &out.EventSink.URL} {
+		if u, err := url.Parse(*raw); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.User(u.User.Username())
+				if u.User.Username() == "" {
+					// redis://:password@host
+					u.User = nil
+				}
+				*raw = u.String()
+			}
+		}
+	}
+	if u, err := url.Parse(c.EventSink.URL); err == nil && u.User != nil && c.EventSink.Type == "nats" {
+		if _, ok := u.User.Password(); !ok {
+			u.User = nil
+			out.EventSink.URL = u.String()
+		}
+	}
+	return &out
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"text/template"
+	"time"
 )
 
 // DefaultConfigTemplate is the default configuration template
@@ -41,9 +42,6 @@ server_jar_name = "{{.ServerJarName}}"
 # ============================================================================
 # Update Configuration
 # ============================================================================
-# Enable automatic updates (be careful with this!)
-auto_update = {{.AutoUpdate}}
-
 # Update channel: stable, beta, alpha
 update_channel = "{{.UpdateChannel}}"
 
@@ -53,9 +51,22 @@ update_channel = "{{.UpdateChannel}}"
 # Log level: debug, info, warn, error
 log_level = "{{.LogLevel}}"
 
+# Log format: text or json
+log_format = "{{.LogFormat}}"
+
 # Log file path (empty for stdout only)
 log_file = "{{.LogFile}}"
 
+# ============================================================================
+# Update Policy
+# ============================================================================
+[update_policy]
+# Install updates automatically (be careful with this!)
+auto_update = {{.UpdatePolicy.AutoUpdate}}
+
+# Most updates to install in any 7 days (0 for no limit)
+max_updates_per_week = {{.UpdatePolicy.MaxUpdatesPerWeek}}
+
 # ============================================================================
 # Notification Configuration
 # ============================================================================
@@ -202,10 +213,62 @@ func GetDefaultConfig() *Config {
 		ServerPath:    "./server",
 		BackupPath:    "./backups",
 		ServerJarName: "server.jar",
-		AutoUpdate:    false,
+		Preserve:      DefaultPreserve,
+		ServerJar: ServerJarConfig{
+			Java: "java",
+		},
+		Server: ServerConfig{
+			Mode:            "process",
+			ShutdownTimeout: 30 * time.Second,
+			Docker: DockerConfig{
+				Host:    "unix:///var/run/docker.sock",
+				DataDir: "/data",
+			},
+			Countdown: CountdownConfig{
+				Schedule:      []time.Duration{5 * time.Minute, time.Minute, 30 * time.Second, 10 * time.Second},
+				Message:       "Server will restart for maintenance in {time}",
+				FinalMessage:  "Server is restarting now",
+				CancelMessage: "Restart cancelled",
+				Channel:       "chat",
+			},
+			MaintenanceMode: MaintenanceModeConfig{
+				MOTD: DefaultMaintenanceMOTD,
+				ETA:  10 * time.Minute,
+			},
+			HealthCheck: HealthCheckConfig{
+				Timeout:    10 * time.Minute,
+				MaxCrashes: 2,
+				Rollback:   true,
+			},
+		},
 		UpdateChannel: "stable",
-		LogLevel:      "info",
-		LogFile:       "",
+		ModChangelogs: true,
+		CheckInterval: time.Hour,
+		Approval: ApprovalConfig{
+			ExpireAfter: 72 * time.Hour,
+			RemindEvery: 24 * time.Hour,
+		},
+		Cache: CacheConfig{
+			TTL: 10 * time.Minute,
+		},
+		HTTP: HTTPConfig{
+			Timeout:        30 * time.Second,
+			ConnectTimeout: 10 * time.Second,
+		},
+		Backup: BackupConfig{
+			RetentionDays: 7,
+			Compression:   true,
+		},
+		Plugins: PluginsConfig{
+			Dir:     "./plugins",
+			Timeout: 5 * time.Minute,
+		},
+		Web: WebConfig{
+			Listen: ":8080",
+		},
+		LogLevel:  "info",
+		LogFormat: "text",
+		LogFile:   "",
 		Notifications: NotificationConfig{
 			Discord: DiscordConfig{
 				Enabled:   false,
@@ -218,6 +281,19 @@ func GetDefaultConfig() *Config {
 				ContentType: "application/json",
 				Timeout:     30000000000, // 30 seconds in nanoseconds
 			},
+			Ntfy: NtfyConfig{
+				Server: "https://ntfy.sh",
+			},
+			Minecraft: MinecraftChatConfig{
+				Prefix: "[Updates]",
+			},
+			Retry: RetryConfig{
+				Attempts:        3,
+				Backoff:         time.Second,
+				MaxBackoff:      30 * time.Second,
+				BreakerFailures: 5,
+				BreakerCooldown: 5 * time.Minute,
+			},
 		},
 	}
 }
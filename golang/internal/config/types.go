@@ -1,74 +1,540 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"filippo.io/age"
 	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/version"
 	"github.com/spf13/viper"
 )
 
 // Config represents the main configuration structure
 type Config struct {
 	// API Configuration
-	APIKey string `mapstructure:"api_key"`
+	APIKey     string         `mapstructure:"api_key"`
+	APIKeyFile string         `mapstructure:"api_key_file"` // read api_key from this file, e.g. a Docker secret
+	API        APIConfig      `mapstructure:"api"`
+	Cache      CacheConfig    `mapstructure:"cache"`
+	HTTP       HTTPConfig     `mapstructure:"http"`
+	Download   DownloadConfig `mapstructure:"download"`
 
 	// Modpack Configuration
-	ModpackID   int    `mapstructure:"modpack_id"`
-	GameVersion string `mapstructure:"game_version"`
+	ModpackID       int         `mapstructure:"modpack_id"`
+	ModpackProvider string      `mapstructure:"modpack_provider"` // curseforge or ftb
+	GameVersion     string      `mapstructure:"game_version"`
+	Mods            []ModConfig `mapstructure:"mods"` // tracked projects, added from the web UI mod browser
 
 	// Server Configuration
-	ServerPath    string `mapstructure:"server_path"`
-	BackupPath    string `mapstructure:"backup_path"`
-	ServerJarName string `mapstructure:"server_jar_name"`
+	ServerPath    string          `mapstructure:"server_path"`
+	BackupPath    string          `mapstructure:"backup_path"`
+	ServerJarName string          `mapstructure:"server_jar_name"`
+	ServerJar     ServerJarConfig `mapstructure:"server_jar"` // keep the server jar itself up to date
+	Server        ServerConfig    `mapstructure:"server"`     // how the server is started and stopped
+
+	// Preserve lists files and globs, relative to server_path, that updates must not overwrite
+	Preserve []string `mapstructure:"preserve"`
+
+	// Servers lists further instances managed from this config, see InstanceConfig
+	Servers []InstanceConfig `mapstructure:"servers"`
+
+	// Storage Configuration
+	DownloadPath       string `mapstructure:"download_path"`
+	ManualDownloadPath string `mapstructure:"manual_download_path"` // files the author only allows to be downloaded by hand
+	DataDir            string `mapstructure:"data_dir"`
 
 	// Notification Configuration
 	Notifications NotificationConfig `mapstructure:"notifications"`
 
+	// EventSink publishes lifecycle events to Redis or NATS for other automation
+	EventSink EventSinkConfig `mapstructure:"event_sink"`
+
 	// Update Configuration
-	AutoUpdate    bool   `mapstructure:"auto_update"`
 	UpdateChannel string `mapstructure:"update_channel"` // stable, beta, alpha
+	ModChangelogs bool   `mapstructure:"mod_changelogs"` // list the changed mods and their changelogs after an update
+
+	// DifferentialSync only downloads the mods that changed instead of the whole server pack
+	DifferentialSync bool `mapstructure:"differential_sync"`
+
+	// ReleasePolicy holds back files that are too new or not stable enough
+	ReleasePolicy ReleasePolicyConfig `mapstructure:"release_policy"`
+
+	// UpdatePolicy decides whether the daemon installs the updates it finds
+	UpdatePolicy UpdatePolicyConfig `mapstructure:"update_policy"`
+
+	// Approval makes automatic updates wait for an operator, see ApprovalConfig
+	Approval ApprovalConfig `mapstructure:"approval"`
+
+	// Daemon Configuration
+	CheckInterval time.Duration     `mapstructure:"check_interval"`
+	Maintenance   MaintenanceConfig `mapstructure:"maintenance"`
+	Fleet         FleetConfig       `mapstructure:"fleet"`
+	Backup        BackupConfig      `mapstructure:"backup"`
+
+	// Plugin Configuration
+	Plugins PluginsConfig `mapstructure:"plugins"`
+
+	// Web Configuration
+	Web WebConfig `mapstructure:"web"`
 
 	// Logging Configuration
-	LogLevel string `mapstructure:"log_level"`
-	LogFile  string `mapstructure:"log_file"`
+	LogLevel  string `mapstructure:"log_level"`
+	LogFormat string `mapstructure:"log_format"` // text, json
+	LogFile   string `mapstructure:"log_file"`
+
+	// File is the config file that was loaded, empty when none was found
+	File string `mapstructure:"-"`
+
+	// InstanceName is the [[servers]] entry this config was resolved for, see Instance
+	InstanceName string `mapstructure:"-"`
+
+	// Deprecations lists legacy keys that were migrated while loading
+	Deprecations []string `mapstructure:"-"`
+}
+
+// DefaultPreserve keeps the server settings and player lists that server packs tend to ship
+var DefaultPreserve = []string{"server.properties", "ops.json", "whitelist.json", "banned-players.json", "banned-ips.json"}
+
+// DefaultMaintenanceMOTD is shown to players by server.maintenance_mode while an update runs
+const DefaultMaintenanceMOTD = "§eUpdating to {version}§r, back in ~{eta}"
+
+// ModConfig is one tracked project
+type ModConfig struct {
+	ID       int    `mapstructure:"id"`      // CurseForge project ID
+	Project  string `mapstructure:"project"` // project ID or slug for providers with text IDs, e.g. Modrinth
+	Name     string `mapstructure:"name"`
+	Provider string `mapstructure:"provider"` // curseforge (default), modrinth or generic
+	Loader   string `mapstructure:"loader"`   // only install versions for this loader, e.g. fabric
+	Path     string `mapstructure:"path"`     // folder inside server_path to install into; defaults to mods
+	Channel  string `mapstructure:"channel"`  // least stable channel to install, overriding update_channel and release_policy.stable_mods
+
+	// Pinning, see provider.Policy
+	Pin            string   `mapstructure:"pin"`             // file or version ID to stay on
+	Versions       string   `mapstructure:"versions"`        // version range, e.g. ">=15.2, <15.3" or "15.2.*"
+	IgnoreVersions []string `mapstructure:"ignore_versions"` // versions never to install, by name, number or ID
+
+	// Generic artifacts, see provider.GenericSource
+	URL         string `mapstructure:"url"`          // download URL, "{version}" is replaced
+	VersionURL  string `mapstructure:"version_url"`  // endpoint returning the latest version
+	VersionPath string `mapstructure:"version_path"` // dotted path to the version in a JSON response
+	FileName    string `mapstructure:"file_name"`    // name to install as, "{version}" is replaced
+}
+
+// ProviderName returns the provider of the mod, defaulting to curseforge
+func (m ModConfig) ProviderName() string {
+	if m.Provider == "" {
+		return "curseforge"
+	}
+	return m.Provider
+}
+
+// ProjectID returns the ID the provider knows the mod by
+func (m ModConfig) ProjectID() string {
+	if m.Project != "" {
+		return m.Project
+	}
+	return strconv.Itoa(m.ID)
+}
+
+// Key identifies the mod across providers, e.g. "modrinth:sodium"
+func (m ModConfig) Key() string {
+	return m.ProviderName() + ":" + m.ProjectID()
+}
+
+// IsTracked reports whether a CurseForge project is in the tracked mods list
+func (c *Config) IsTracked(modID int) bool {
+	for _, m := range c.Mods {
+		if m.ProviderName() == "curseforge" && m.ID == modID {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerJarConfig selects the server software that `server-jar update` installs
+type ServerJarConfig struct {
+	Type             string `mapstructure:"type"`              // vanilla, fabric, forge or neoforge; empty leaves the jar alone
+	MinecraftVersion string `mapstructure:"minecraft_version"` // defaults to game_version; "latest" for the newest release
+	LoaderVersion    string `mapstructure:"loader_version"`    // Fabric, Forge or NeoForge version; empty for the newest
+	Java             string `mapstructure:"java"`              // java binary that runs the server and the Forge and NeoForge installers
+}
+
+// PluginsConfig holds the settings for exec plugins run during updates
+type PluginsConfig struct {
+	Dir     string              `mapstructure:"dir"`     // executables in this folder are run as plugins
+	Timeout time.Duration       `mapstructure:"timeout"` // limit for one plugin run; 0 for none
+	Stages  map[string][]string `mapstructure:"stages"`  // plugin name to stages, overriding what the plugin declares
+}
+
+// APIConfig points the CurseForge client at another endpoint, such as a caching proxy
+type APIConfig struct {
+	BaseURL      string `mapstructure:"base_url"`      // empty for https://api.curseforge.com/v1
+	Keyless      bool   `mapstructure:"keyless"`       // base_url adds the API key itself, so api_key is not required
+	DownloadHost string `mapstructure:"download_host"` // e.g. http://cache:8080, fetches CDN files from there instead
+}
+
+// HasAPIAccess reports whether the CurseForge API can be called: with an API key, or
+// through a proxy that adds one
+func (c *Config) HasAPIAccess() bool {
+	return c.APIKey != "" || c.API.Keyless
+}
+
+// CacheConfig holds API response cache settings
+type CacheConfig struct {
+	TTL time.Duration `mapstructure:"ttl"` // 0 disables the cache
+	Dir string        `mapstructure:"dir"` // keep responses on disk between runs; empty for memory only
+}
+
+// HTTPConfig holds proxy, TLS and timeout settings shared by every outgoing HTTP client
+type HTTPConfig struct {
+	ProxyURL           string        `mapstructure:"proxy_url"` // http, https or socks5; empty uses HTTP_PROXY/HTTPS_PROXY
+	CAFile             string        `mapstructure:"ca_file"`   // extra PEM certificates to trust
+	InsecureSkipVerify bool          `mapstructure:"insecure_skip_verify"`
+	Timeout            time.Duration `mapstructure:"timeout"`         // whole request for API calls, response headers for downloads
+	ConnectTimeout     time.Duration `mapstructure:"connect_timeout"` // dialing and TLS handshake
+}
+
+// DownloadConfig holds settings for modpack, mod and server jar downloads
+type DownloadConfig struct {
+	MaxRate string `mapstructure:"max_rate"` // e.g. "10MB/s"; empty or "0" for no limit, see ParseRate
+	// KeepVersions is how many versions of each modpack are kept in download_path after an
+	// update, the installed one included; 0 keeps every version
+	KeepVersions int `mapstructure:"keep_versions"`
+	// CacheDir keeps downloaded files by hash, shared by every [[servers]] entry; empty
+	// disables the cache
+	CacheDir     string `mapstructure:"cache_dir"`
+	CacheMaxSize string `mapstructure:"cache_max_size"` // e.g. "10GB"; empty or "0" for no limit, see ParseSize
+}
+
+// ReleasePolicyConfig decides which files of the modpack and the tracked mods may be
+// installed, to stay clear of broken day-one releases
+type ReleasePolicyConfig struct {
+	MinAge     time.Duration `mapstructure:"min_age"`     // only files published at least this long ago; 0 for any
+	StableMods bool          `mapstructure:"stable_mods"` // only releases of tracked mods, unless their channel allows more
+}
+
+// UpdatePolicyConfig decides whether the daemon installs an update it found by itself.
+// Every rule that is set must allow the update; otherwise it is only announced. Unlike
+// release_policy, which hides files from every check, the policy only holds them back
+// from automatic installs.
+type UpdatePolicyConfig struct {
+	AutoUpdate         bool          `mapstructure:"auto_update"`          // install updates at all
+	ReleaseTypes       []string      `mapstructure:"release_types"`        // release, beta and alpha; empty for any
+	MinFileAge         time.Duration `mapstructure:"min_file_age"`         // only files published at least this long ago
+	GameVersions       []string      `mapstructure:"game_versions"`        // the file must support one; "1.20" also allows 1.20.x
+	BlackoutDates      []string      `mapstructure:"blackout_dates"`       // days without updates, see ParseDateRange
+	MaxUpdatesPerWeek  int           `mapstructure:"max_updates_per_week"` // installs in any 7 days; 0 for no limit
+	RequireEmptyServer bool          `mapstructure:"require_empty_server"` // only while nobody is online, asked over server.rcon
+
+	// ConfirmMinecraftUpgrades keeps updates to a newer Minecraft version from being
+	// installed, by the daemon or by hand, unless `update --allow-mc-upgrade` asks for it
+	ConfirmMinecraftUpgrades bool `mapstructure:"confirm_minecraft_upgrades"`
+
+	// HoldRules hold an update for manual review when its name or changelog matches
+	HoldRules []HoldRule `mapstructure:"hold_rules"`
+}
+
+// HoldRule holds an update for manual review when one of its regular expressions matches
+// the file or its changelog. Patterns are case-sensitive unless they start with (?i).
+type HoldRule struct {
+	Name      string `mapstructure:"name"`      // reported when the rule matches
+	FileName  string `mapstructure:"file_name"` // matched against the display name and the file name
+	Changelog string `mapstructure:"changelog"` // matched against the changelog as plain text
+}
+
+// ParseDateRange parses a blackout date, "2024-12-24", or an inclusive range of them,
+// "2024-12-20..2025-01-02". The dates are returned at midnight UTC.
+func ParseDateRange(s string) (time.Time, time.Time, error) {
+	first, last, isRange := strings.Cut(strings.TrimSpace(s), "..")
+	if !isRange {
+		last = first
+	}
+	from, err := time.Parse(time.DateOnly, strings.TrimSpace(first))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD or YYYY-MM-DD..YYYY-MM-DD", s)
+	}
+	to, err := time.Parse(time.DateOnly, strings.TrimSpace(last))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD or YYYY-MM-DD..YYYY-MM-DD", s)
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("date range %q ends before it starts", s)
+	}
+	return from, to, nil
+}
+
+// ApprovalConfig makes the daemon ask before installing an update with
+// update_policy.auto_update
+type ApprovalConfig struct {
+	Required    bool          `mapstructure:"required"`
+	ExpireAfter time.Duration `mapstructure:"expire_after"` // unanswered requests lapse after this; 0 never
+	RemindEvery time.Duration `mapstructure:"remind_every"` // repeat the request this often; 0 never
+}
+
+// MaxRateBytes returns max_rate in bytes per second, or 0 for no limit
+func (d DownloadConfig) MaxRateBytes() int64 {
+	rate, _ := ParseRate(d.MaxRate)
+	return rate
+}
+
+// CacheMaxSizeBytes returns cache_max_size in bytes, or 0 for no limit
+func (d DownloadConfig) CacheMaxSizeBytes() int64 {
+	size, _ := ParseSize(d.CacheMaxSize)
+	return size
 }
 
 // NotificationConfig holds all notification settings
 type NotificationConfig struct {
-	Discord DiscordConfig `mapstructure:"discord"`
-	Webhook WebhookConfig `mapstructure:"webhook"`
+	Discord   DiscordConfig       `mapstructure:"discord"`
+	Webhook   WebhookConfig       `mapstructure:"webhook"`
+	Pushover  PushoverConfig      `mapstructure:"pushover"`
+	Ntfy      NtfyConfig          `mapstructure:"ntfy"`
+	Minecraft MinecraftChatConfig `mapstructure:"minecraft"`
+	Retry     RetryConfig         `mapstructure:"retry"`
+}
+
+// EventSinkConfig publishes lifecycle events as JSON to a Redis pub/sub channel or a NATS
+// subject, for operators who build their own automation around the updater
+type EventSinkConfig struct {
+	Type    string   `mapstructure:"type"`    // redis or nats; empty for none
+	URL     string   `mapstructure:"url"`     // redis://, rediss://, nats:// or tls://, with the credentials
+	Subject string   `mapstructure:"subject"` // channel or subject; {event} is replaced by the event type
+	Events  []string `mapstructure:"events"`  // event types to publish, e.g. update_finished
+}
+
+// RetryConfig decides how often failed notification requests are retried, and when an
+// endpoint that keeps failing is skipped for a while
+type RetryConfig struct {
+	Attempts   int           `mapstructure:"attempts"`    // tries per request, 0 or 1 for no retries
+	Backoff    time.Duration `mapstructure:"backoff"`     // wait before the first retry, doubled for each further one
+	MaxBackoff time.Duration `mapstructure:"max_backoff"` // longest wait between tries, also for Retry-After
+	// BreakerFailures is how many requests in a row may fail before the endpoint is
+	// skipped for BreakerCooldown; 0 never skips it
+	BreakerFailures int           `mapstructure:"breaker_failures"`
+	BreakerCooldown time.Duration `mapstructure:"breaker_cooldown"`
 }
 
 // DiscordConfig holds Discord-specific notification settings
 type DiscordConfig struct {
-	Enabled    bool   `mapstructure:"enabled"`
-	WebhookURL string `mapstructure:"webhook_url"`
-	ChannelID  string `mapstructure:"channel_id"`
-	Username   string `mapstructure:"username"`
-	AvatarURL  string `mapstructure:"avatar_url"`
+	Enabled        bool   `mapstructure:"enabled"`
+	WebhookURL     string `mapstructure:"webhook_url"`
+	WebhookURLFile string `mapstructure:"webhook_url_file"`
+	ChannelID      string `mapstructure:"channel_id"`
+	Username       string `mapstructure:"username"`
+	AvatarURL      string `mapstructure:"avatar_url"`
 }
 
 // WebhookConfig holds generic webhook settings
 type WebhookConfig struct {
 	Enabled     bool              `mapstructure:"enabled"`
 	URL         string            `mapstructure:"url"`
+	URLFile     string            `mapstructure:"url_file"`
 	Headers     map[string]string `mapstructure:"headers"`
 	ContentType string            `mapstructure:"content_type"`
 	Method      string            `mapstructure:"method"`
 	Timeout     time.Duration     `mapstructure:"timeout"`
 }
 
+// PushoverConfig holds Pushover settings
+type PushoverConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Token     string `mapstructure:"token"` // API token of the application
+	TokenFile string `mapstructure:"token_file"`
+	User      string `mapstructure:"user"`   // user or group key
+	Device    string `mapstructure:"device"` // empty for all of the user's devices
+}
+
+// NtfyConfig holds ntfy settings
+type NtfyConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Server    string `mapstructure:"server"` // https://ntfy.sh or a self-hosted server
+	Topic     string `mapstructure:"topic"`
+	Token     string `mapstructure:"token"` // access token for protected topics
+	TokenFile string `mapstructure:"token_file"`
+}
+
+// MinecraftChatConfig posts update messages into the game chat over RCON
+type MinecraftChatConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Prefix  string `mapstructure:"prefix"` // shown in gold before every message
+
+	// RCON is server.rcon, copied when loading so that the notifier can reach the server
+	RCON RCONConfig `mapstructure:"-"`
+}
+
+// WebConfig holds settings for the web UI and REST API
+type WebConfig struct {
+	Listen       string `mapstructure:"listen"`
+	APIToken     string `mapstructure:"api_token"` // empty disables /api/v1
+	APITokenFile string `mapstructure:"api_token_file"`
+
+	// WebhookSecret authenticates POST /hooks/:action, which queues a check, update or
+	// backup for CI, chat bots and monitoring; empty disables the endpoint
+	WebhookSecret     string `mapstructure:"webhook_secret"`
+	WebhookSecretFile string `mapstructure:"webhook_secret_file"`
+	TLSCert           string `mapstructure:"tls_cert"` // serve HTTPS with this certificate and tls_key
+	TLSKey            string `mapstructure:"tls_key"`
+	StaticDir         string `mapstructure:"static_dir"` // serve /static from here instead of the embedded assets
+	PublicURL         string `mapstructure:"public_url"` // address of the web UI used in notification links
+
+	// GRPCListen serves the gRPC control API, which mirrors /api/v1 and takes api_token,
+	// on this address; empty disables it
+	GRPCListen string `mapstructure:"grpc_listen"`
+}
+
 // ServerConfig holds server-specific configuration
 type ServerConfig struct {
-	Name            string        `mapstructure:"name"`
-	Port            int           `mapstructure:"port"`
-	MaxPlayers      int           `mapstructure:"max_players"`
-	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
-	StartCommand    string        `mapstructure:"start_command"`
-	StopCommand     string        `mapstructure:"stop_command"`
+	Name            string            `mapstructure:"name"`        // motd written by server bootstrap
+	Port            int               `mapstructure:"port"`        // server-port written by server bootstrap
+	MaxPlayers      int               `mapstructure:"max_players"` // max-players written by server bootstrap
+	ShutdownTimeout time.Duration     `mapstructure:"shutdown_timeout"`
+	StartCommand    string            `mapstructure:"start_command"`
+	StopCommand     string            `mapstructure:"stop_command"`
+	Mode            string            `mapstructure:"mode"` // process (run java), docker, systemd, pterodactyl or kubernetes
+	Process         ProcessConfig     `mapstructure:"process"`
+	Docker          DockerConfig      `mapstructure:"docker"`
+	Systemd         SystemdConfig     `mapstructure:"systemd"`
+	Pterodactyl     PterodactylConfig `mapstructure:"pterodactyl"`
+	Kubernetes      KubernetesConfig  `mapstructure:"kubernetes"`
+	RCON            RCONConfig        `mapstructure:"rcon"`
+	Countdown       CountdownConfig   `mapstructure:"countdown"`
+
+	MaintenanceMode MaintenanceModeConfig `mapstructure:"maintenance_mode"`
+	Monitor         MonitorConfig         `mapstructure:"monitor"`
+	HealthCheck     HealthCheckConfig     `mapstructure:"health_check"`
+
+	// PostUpdateTasks run in order once the health check saw the server start after an update
+	PostUpdateTasks []PostUpdateTaskConfig `mapstructure:"post_update_tasks"`
+}
+
+// DefaultTaskTimeout is how long a post-update task waits for its wait_for line when it
+// sets no timeout
+const DefaultTaskTimeout = 10 * time.Minute
+
+// PostUpdateTaskConfig is a console command run after an update, e.g. a Chunky
+// pregeneration or a datapack reload
+type PostUpdateTaskConfig struct {
+	Name      string        `mapstructure:"name"`
+	Command   string        `mapstructure:"command"`    // console command, without the leading slash
+	WaitFor   string        `mapstructure:"wait_for"`   // regular expression of the log line that ends the task
+	Timeout   time.Duration `mapstructure:"timeout"`    // how long to wait for wait_for; 0 for DefaultTaskTimeout
+	OnFailure string        `mapstructure:"on_failure"` // continue (default), stop the remaining tasks, or fail the update
+}
+
+// Label names the task in logs and results, falling back to its command
+func (t PostUpdateTaskConfig) Label() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Command
+}
+
+// WaitTimeout is how long the task may take
+func (t PostUpdateTaskConfig) WaitTimeout() time.Duration {
+	if t.Timeout > 0 {
+		return t.Timeout
+	}
+	return DefaultTaskTimeout
+}
+
+// HealthCheckConfig restarts the server after every update and follows its log until it
+// logs "Done", so that an update that makes it crash-loop is rolled back. Only modes
+// whose server log can be followed support it: process (from the web UI), docker and systemd.
+type HealthCheckConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Timeout    time.Duration `mapstructure:"timeout"`     // how long the server may take to start
+	MaxCrashes int           `mapstructure:"max_crashes"` // crashes before it starts that make a crash loop
+	Rollback   bool          `mapstructure:"rollback"`    // roll back the update when it crash-loops
+}
+
+// MonitorConfig makes the daemon sample the server's CPU, memory and TPS and send an alert
+// when a threshold is crossed. A threshold of 0 is not checked.
+type MonitorConfig struct {
+	Interval      time.Duration `mapstructure:"interval"`        // 0 disables monitoring
+	MaxCPUPercent float64       `mapstructure:"max_cpu_percent"` // of one core
+	MaxMemory     string        `mapstructure:"max_memory"`      // e.g. "8GB", see ParseSize
+	MinTPS        float64       `mapstructure:"min_tps"`
+}
+
+// MaxMemoryBytes returns max_memory in bytes, or 0 for no threshold
+func (m MonitorConfig) MaxMemoryBytes() int64 {
+	size, _ := ParseSize(m.MaxMemory)
+	return size
+}
+
+// RCONConfig is the server's remote console, used to warn players before a shutdown
+type RCONConfig struct {
+	Address      string `mapstructure:"address"`       // host:port, rcon.port in server.properties
+	Password     string `mapstructure:"password"`      // rcon.password in server.properties
+	PasswordFile string `mapstructure:"password_file"` // read password from this file
+}
+
+// CountdownConfig controls the warnings broadcast before the server is stopped.
+// Messages may contain {time}, {minutes} and {seconds}.
+type CountdownConfig struct {
+	Schedule      []time.Duration `mapstructure:"schedule"`       // time left at each warning, e.g. 5m, 1m, 10s
+	Message       string          `mapstructure:"message"`        // warning template
+	FinalMessage  string          `mapstructure:"final_message"`  // sent right before stopping
+	CancelMessage string          `mapstructure:"cancel_message"` // sent when the countdown is interrupted
+	Channel       string          `mapstructure:"channel"`        // chat, title, actionbar or bossbar
+}
+
+// MaintenanceModeConfig controls the stand-in that answers players on the server port
+// while an update runs and the server is down. MOTD may contain {version} and {eta}.
+type MaintenanceModeConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	MOTD    string        `mapstructure:"motd"` // shown in the server list and to joining players
+	ETA     time.Duration `mapstructure:"eta"`  // expected downtime, for {eta}
+	Port    int           `mapstructure:"port"` // 0 uses server-port from server.properties
+}
+
+// KubernetesConfig configures kubernetes mode, where the updater runs as an init or sidecar
+// container next to the server
+type KubernetesConfig struct {
+	Namespace       string `mapstructure:"namespace"`         // defaults to the pod's own namespace
+	Deployment      string `mapstructure:"deployment"`        // Deployment running the server
+	ReadyFile       string `mapstructure:"ready_file"`        // written once server_path holds a complete install
+	RestartOnUpdate bool   `mapstructure:"restart_on_update"` // rollout restart the Deployment after an update
+}
+
+// PterodactylConfig selects the panel server controlled in pterodactyl mode
+type PterodactylConfig struct {
+	PanelURL   string `mapstructure:"panel_url"`    // e.g. https://panel.example.com
+	APIKey     string `mapstructure:"api_key"`      // client API key (ptlc_...)
+	APIKeyFile string `mapstructure:"api_key_file"` // read api_key from this file
+	ServerID   string `mapstructure:"server_id"`    // server identifier or UUID
+}
+
+// ProcessConfig sandboxes the server started in process mode
+type ProcessConfig struct {
+	User       string   `mapstructure:"user"`        // "user[:group]" the server runs as; needs root, Unix only
+	WorkingDir string   `mapstructure:"working_dir"` // directory the server runs in; defaults to server_path
+	Umask      string   `mapstructure:"umask"`       // octal umask, e.g. "0027"; empty keeps the updater's, Unix only
+	Env        []string `mapstructure:"env"`         // NAME to pass on, or NAME=value to set; empty passes everything
+}
+
+// SystemdConfig selects the unit controlled in systemd mode
+type SystemdConfig struct {
+	Unit string `mapstructure:"unit"` // e.g. minecraft.service
+	User bool   `mapstructure:"user"` // use the user's service manager (systemctl --user)
+}
+
+// DockerConfig selects the container controlled in docker mode
+type DockerConfig struct {
+	Host      string `mapstructure:"host"`      // unix:// socket or tcp:// address of the Docker daemon
+	Container string `mapstructure:"container"` // container name or ID
+	DataDir   string `mapstructure:"data_dir"`  // server directory inside the container, /data for itzg/minecraft-server
 }
 
 // BackupConfig holds backup-specific configuration
@@ -76,6 +542,39 @@ type BackupConfig struct {
 	RetentionDays int  `mapstructure:"retention_days"`
 	Compression   bool `mapstructure:"compression"`
 	Incremental   bool `mapstructure:"incremental"`
+
+	// Count and grandfather-father-son retention, kept on top of retention_days
+	KeepLast    int `mapstructure:"keep_last"`    // the newest N backups
+	KeepDaily   int `mapstructure:"keep_daily"`   // the newest backup of each of the last N days
+	KeepWeekly  int `mapstructure:"keep_weekly"`  // the newest backup of each of the last N weeks
+	KeepMonthly int `mapstructure:"keep_monthly"` // the newest backup of each of the last N months
+
+	// RestoreOwner is the "user[:group]" restored files are given when running as root;
+	// empty restores the owner recorded in the backup
+	RestoreOwner string `mapstructure:"restore_owner"`
+
+	// Snapshot takes backups as hard-linked copies or btrfs/ZFS snapshots: hardlink,
+	// btrfs, zfs or auto; empty archives or copies them as compression says
+	Snapshot string `mapstructure:"snapshot"`
+
+	Encryption BackupEncryptionConfig `mapstructure:"encryption"`
+}
+
+// BackupEncryptionConfig encrypts backup archives in the age format, with a passphrase or
+// to age public keys
+type BackupEncryptionConfig struct {
+	Passphrase     string `mapstructure:"passphrase"`
+	PassphraseFile string `mapstructure:"passphrase_file"` // read passphrase from this file
+
+	// Recipients are age public keys ("age1..."); restoring needs one of the secret keys
+	// in IdentityFile
+	Recipients   []string `mapstructure:"recipients"`
+	IdentityFile string   `mapstructure:"identity_file"`
+}
+
+// Enabled reports whether new backups are encrypted
+func (e BackupEncryptionConfig) Enabled() bool {
+	return e.Passphrase != "" || len(e.Recipients) > 0
 }
 
 // MaintenanceConfig holds maintenance window configuration
@@ -83,88 +582,205 @@ type MaintenanceConfig struct {
 	WindowStart string `mapstructure:"window_start"`
 	WindowEnd   string `mapstructure:"window_end"`
 	Timezone    string `mapstructure:"timezone"`
+
+	// AnnounceBefore is how long before the window opens the daemon checks for an
+	// update and sends update_scheduled when one will be installed; 0 disables it
+	AnnounceBefore time.Duration `mapstructure:"announce_before"`
 }
 
-// LoadConfig loads configuration from file
-func LoadConfig(configPath string) (*Config, error) {
-	v := viper.New()
+// FleetConfig staggers the daemons of a fleet that share a schedule, so that they do not
+// all call the API and download at once
+type FleetConfig struct {
+	// Jitter delays every scheduled run by a random duration of up to this long
+	Jitter time.Duration `mapstructure:"jitter"`
 
-	// Set default values
-	setDefaults(v)
+	// Lock lets one daemon of the fleet run at a time: "file" for LockFile on a shared
+	// filesystem, "redis" for LockKey on RedisURL, or empty for no lock
+	Lock     string        `mapstructure:"lock"`
+	LockFile string        `mapstructure:"lock_file"`
+	RedisURL string        `mapstructure:"redis_url"` // redis://[user:password@]host[:port][/db], rediss:// for TLS
+	LockKey  string        `mapstructure:"lock_key"`
+	LockTTL  time.Duration `mapstructure:"lock_ttl"` // how long the lock of a daemon that died blocks the others
+}
 
-	// Set config file path
-	if configPath == "" {
-		configPath = getDefaultConfigPath()
+// Validate checks the configuration for missing or invalid values
+func Validate(config *Config) error {
+	// Validate API key
+	if !config.HasAPIAccess() {
+		return fmt.Errorf("api_key is required")
+	}
+	if err := validateAPI(&config.API); err != nil {
+		return err
 	}
 
-	v.SetConfigFile(configPath)
-	v.SetConfigType("toml")
+	// Validate modpack ID
+	if config.ModpackID <= 0 {
+		return fmt.Errorf("modpack_id must be greater than 0")
+	}
 
-	// Read environment variables
-	v.AutomaticEnv()
+	switch config.ModpackProvider {
+	case "", "curseforge", "ftb":
+	default:
+		return fmt.Errorf("modpack_provider must be one of: curseforge, ftb")
+	}
 
-	// Read config file
-	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			return nil, fmt.Errorf("config file not found: %s", configPath)
+	// Validate tracked mods
+	seen := make(map[string]bool, len(config.Mods))
+	for _, m := range config.Mods {
+		switch m.ProviderName() {
+		case "curseforge":
+			if m.ID <= 0 {
+				return fmt.Errorf("mods: id must be greater than 0")
+			}
+		case "modrinth":
+			if m.Project == "" {
+				return fmt.Errorf("mods: project is required for modrinth mods")
+			}
+		case "generic":
+			if m.Project == "" {
+				return fmt.Errorf("mods: project is required for generic mods, it names the artifact")
+			}
+			if m.URL == "" {
+				return fmt.Errorf("mods: url is required for generic mod %s", m.Project)
+			}
+			if m.VersionPath != "" && m.VersionURL == "" {
+				return fmt.Errorf("mods: version_path needs version_url for generic mod %s", m.Project)
+			}
+		default:
+			return fmt.Errorf("mods: provider must be one of: curseforge, modrinth, generic")
+		}
+		if m.Path != "" && (filepath.IsAbs(m.Path) || strings.HasPrefix(filepath.Clean(m.Path), "..")) {
+			return fmt.Errorf("mods: path of %s must stay inside server_path", m.Key())
 		}
-		return nil, fmt.Errorf("error reading config file: %w", err)
+		switch m.Channel {
+		case "", "stable", "release", "beta", "alpha":
+		default:
+			return fmt.Errorf("mods: channel of %s must be one of: stable, beta, alpha", m.Key())
+		}
+		if m.Versions != "" {
+			if m.Pin != "" {
+				return fmt.Errorf("mods: pin and versions of %s cannot be combined", m.Key())
+			}
+			if _, err := version.ParseConstraint(m.Versions); err != nil {
+				return fmt.Errorf("mods: versions of %s: %w", m.Key(), err)
+			}
+		}
+		if seen[m.Key()] {
+			return fmt.Errorf("mods: %s is listed more than once", m.Key())
+		}
+		seen[m.Key()] = true
 	}
 
-	var config Config
-	if err := v.Unmarshal(&config); err != nil {
-		return nil, fmt.Errorf("error unmarshaling config: %w", err)
+	for _, p := range config.Preserve {
+		if filepath.IsAbs(p) || strings.HasPrefix(filepath.Clean(p), "..") {
+			return fmt.Errorf("preserve: %s must stay inside server_path", p)
+		}
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("preserve: invalid pattern %s: %w", p, err)
+		}
 	}
 
-	// Validate configuration
-	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+	switch config.ServerJar.Type {
+	case "", "vanilla", "fabric", "forge", "neoforge":
+	default:
+		return fmt.Errorf("server_jar.type must be one of: vanilla, fabric, forge, neoforge")
+	}
+	if config.ServerJar.Type == "vanilla" && config.ServerJar.LoaderVersion != "" {
+		return fmt.Errorf("server_jar.loader_version does not apply to vanilla servers")
 	}
 
-	return &config, nil
-}
-
-// setDefaults sets default configuration values
-func setDefaults(v *viper.Viper) {
-	// API defaults
-	v.SetDefault("api_key", "")
-
-	// Modpack defaults
-	v.SetDefault("modpack_id", 0)
-	v.SetDefault("game_version", "1.20.1")
-
-	// Server defaults
-	v.SetDefault("server_path", "./server")
-	v.SetDefault("backup_path", "./backups")
-	v.SetDefault("server_jar_name", "server.jar")
-
-	// Update defaults
-	v.SetDefault("auto_update", false)
-	v.SetDefault("update_channel", "stable")
-
-	// Logging defaults
-	v.SetDefault("log_level", "info")
-	v.SetDefault("log_file", "")
-
-	// Notification defaults
-	v.SetDefault("notifications.discord.enabled", false)
-	v.SetDefault("notifications.discord.username", "CurseForge Auto-Updater")
-	v.SetDefault("notifications.webhook.enabled", false)
-	v.SetDefault("notifications.webhook.method", "POST")
-	v.SetDefault("notifications.webhook.content_type", "application/json")
-	v.SetDefault("notifications.webhook.timeout", "30s")
-}
+	switch config.Server.Mode {
+	case "", "process":
+		if err := validateProcess(config.Server.Process); err != nil {
+			return err
+		}
+	case "docker":
+		if config.Server.Docker.Container == "" {
+			return fmt.Errorf("server.docker.container is required when server.mode is docker")
+		}
+	case "systemd":
+		if config.Server.Systemd.Unit == "" {
+			return fmt.Errorf("server.systemd.unit is required when server.mode is systemd")
+		}
+	case "pterodactyl":
+		p := config.Server.Pterodactyl
+		if p.PanelURL == "" || p.APIKey == "" || p.ServerID == "" {
+			return fmt.Errorf("server.pterodactyl panel_url, api_key and server_id are required when server.mode is pterodactyl")
+		}
+		if u, err := url.Parse(p.PanelURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("server.pterodactyl.panel_url must be an http:// or https:// URL")
+		}
+	case "kubernetes":
+		if config.Server.Kubernetes.RestartOnUpdate && config.Server.Kubernetes.Deployment == "" {
+			return fmt.Errorf("server.kubernetes.deployment is required when restart_on_update is enabled")
+		}
+	default:
+		return fmt.Errorf("server.mode must be one of: process, docker, systemd, pterodactyl, kubernetes")
+	}
 
-// validateConfig validates the configuration
-func validateConfig(config *Config) error {
-	// Validate API key
-	if config.APIKey == "" {
-		return fmt.Errorf("api_key is required")
+	if config.Server.RCON.Address != "" {
+		if _, _, err := net.SplitHostPort(config.Server.RCON.Address); err != nil {
+			return fmt.Errorf("server.rcon.address must be host:port: %w", err)
+		}
+	}
+	for _, d := range config.Server.Countdown.Schedule {
+		if d <= 0 {
+			return fmt.Errorf("server.countdown.schedule entries must be positive")
+		}
+	}
+	switch config.Server.Countdown.Channel {
+	case "", "chat", "title", "actionbar", "bossbar":
+	default:
+		return fmt.Errorf("server.countdown.channel must be one of: chat, title, actionbar, bossbar")
+	}
+	if config.Server.MaintenanceMode.ETA < 0 {
+		return fmt.Errorf("server.maintenance_mode.eta must not be negative")
+	}
+	if p := config.Server.MaintenanceMode.Port; p < 0 || p > 65535 {
+		return fmt.Errorf("server.maintenance_mode.port must be between 0 and 65535")
+	}
+	if m := config.Server.Monitor; m.Interval < 0 || m.MaxCPUPercent < 0 || m.MinTPS < 0 {
+		return fmt.Errorf("server.monitor interval and thresholds must not be negative")
+	}
+	if _, err := ParseSize(config.Server.Monitor.MaxMemory); err != nil {
+		return fmt.Errorf("server.monitor max_memory is invalid: %w", err)
+	}
+	if h := config.Server.HealthCheck; h.Enabled {
+		if h.Timeout <= 0 || h.MaxCrashes < 1 {
+			return fmt.Errorf("server.health_check needs a positive timeout and max_crashes")
+		}
+		if config.Server.Mode == "pterodactyl" || config.Server.Mode == "kubernetes" {
+			return fmt.Errorf("server.health_check needs server.mode process, docker or systemd to follow the server log")
+		}
+	}
+	for _, t := range config.Server.PostUpdateTasks {
+		if strings.TrimSpace(t.Command) == "" {
+			return fmt.Errorf("server.post_update_tasks: command is required")
+		}
+		if !config.Server.HealthCheck.Enabled {
+			return fmt.Errorf("server.post_update_tasks need server.health_check.enabled, they run once the server has started")
+		}
+		if config.Server.Mode != "" && config.Server.Mode != "process" && config.Server.RCON.Address == "" {
+			return fmt.Errorf("server.post_update_tasks need server.rcon.address to reach the server console")
+		}
+		if _, err := regexp.Compile(t.WaitFor); err != nil {
+			return fmt.Errorf("server.post_update_tasks: wait_for of %s is invalid: %w", t.Label(), err)
+		}
+		if t.Timeout < 0 {
+			return fmt.Errorf("server.post_update_tasks: timeout of %s must not be negative", t.Label())
+		}
+		switch t.OnFailure {
+		case "", "continue", "stop", "fail":
+		default:
+			return fmt.Errorf("server.post_update_tasks: on_failure must be one of: continue, stop, fail")
+		}
 	}
 
-	// Validate modpack ID
-	if config.ModpackID <= 0 {
-		return fmt.Errorf("modpack_id must be greater than 0")
+	if config.Plugins.Timeout < 0 {
+		return fmt.Errorf("plugins.timeout must not be negative")
+	}
+	if err := validateInstances(config); err != nil {
+		return err
 	}
 
 	// Validate paths
@@ -189,6 +805,98 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("update_channel must be one of: stable, beta, alpha")
 	}
 
+	// Validate logging
+	switch config.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return fmt.Errorf("log_level must be one of: debug, info, warn, error")
+	}
+	if config.LogFormat != "text" && config.LogFormat != "json" {
+		return fmt.Errorf("log_format must be one of: text, json")
+	}
+
+	// Validate schedule
+	if config.CheckInterval < time.Minute {
+		return fmt.Errorf("check_interval must be at least 1m")
+	}
+	if (config.Maintenance.WindowStart == "") != (config.Maintenance.WindowEnd == "") {
+		return fmt.Errorf("maintenance window_start and window_end must be set together")
+	}
+	if config.Maintenance.AnnounceBefore < 0 {
+		return fmt.Errorf("maintenance announce_before must not be negative")
+	}
+	if err := validateFleet(&config.Fleet); err != nil {
+		return err
+	}
+	if config.Cache.TTL < 0 {
+		return fmt.Errorf("cache ttl must not be negative")
+	}
+	if err := validateHTTP(&config.HTTP); err != nil {
+		return err
+	}
+	if _, err := ParseRate(config.Download.MaxRate); err != nil {
+		return fmt.Errorf("download max_rate is invalid: %w", err)
+	}
+	if config.Download.KeepVersions < 0 {
+		return fmt.Errorf("download keep_versions must not be negative")
+	}
+	if _, err := ParseSize(config.Download.CacheMaxSize); err != nil {
+		return fmt.Errorf("download cache_max_size is invalid: %w", err)
+	}
+	if config.ReleasePolicy.MinAge < 0 {
+		return fmt.Errorf("release_policy min_age must not be negative")
+	}
+	if err := validateUpdatePolicy(config); err != nil {
+		return err
+	}
+	if config.Approval.ExpireAfter < 0 || config.Approval.RemindEvery < 0 {
+		return fmt.Errorf("approval expire_after and remind_every must not be negative")
+	}
+	if config.Backup.RetentionDays < 0 {
+		return fmt.Errorf("backup retention_days must not be negative")
+	}
+	if b := config.Backup; b.KeepLast < 0 || b.KeepDaily < 0 || b.KeepWeekly < 0 || b.KeepMonthly < 0 {
+		return fmt.Errorf("backup keep_last, keep_daily, keep_weekly and keep_monthly must not be negative")
+	}
+	switch config.Backup.Snapshot {
+	case "", "hardlink", "btrfs", "zfs", "auto":
+	default:
+		return fmt.Errorf("backup snapshot must be hardlink, btrfs, zfs or auto, got %q", config.Backup.Snapshot)
+	}
+	if owner := config.Backup.RestoreOwner; owner != "" {
+		if name, group, hasGroup := strings.Cut(owner, ":"); name == "" || hasGroup && (group == "" || strings.Contains(group, ":")) {
+			return fmt.Errorf("backup restore_owner must be a user or user:group, got %q", owner)
+		}
+	}
+	if e := config.Backup.Encryption; e.Enabled() {
+		if e.Passphrase != "" && len(e.Recipients) > 0 {
+			return fmt.Errorf("backup encryption takes a passphrase or recipients, not both")
+		}
+		for _, r := range e.Recipients {
+			if _, err := age.ParseX25519Recipient(r); err != nil {
+				return fmt.Errorf("backup encryption recipients: %w", err)
+			}
+		}
+		if !config.Backup.Compression || config.Backup.Snapshot != "" {
+			return fmt.Errorf("backup encryption needs compression = true and no snapshot, as only archives are encrypted")
+		}
+	}
+	if (config.Web.TLSCert == "") != (config.Web.TLSKey == "") {
+		return fmt.Errorf("web tls_cert and tls_key must be set together")
+	}
+	if config.Web.PublicURL != "" {
+		if u, err := url.Parse(config.Web.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("web public_url must be an http or https URL")
+		}
+	}
+	if config.Web.GRPCListen != "" && config.Web.APIToken == "" {
+		return fmt.Errorf("web grpc_listen needs api_token, which authenticates its clients")
+	}
+
+	if r := config.Notifications.Retry; r.Attempts < 0 || r.BreakerFailures < 0 || r.Backoff < 0 || r.MaxBackoff < 0 || r.BreakerCooldown < 0 {
+		return fmt.Errorf("notifications retry settings must not be negative")
+	}
+
 	// Validate Discord config if enabled
 	if config.Notifications.Discord.Enabled {
 		if config.Notifications.Discord.WebhookURL == "" {
@@ -203,58 +911,447 @@ func validateConfig(config *Config) error {
 		}
 	}
 
+	if p := config.Notifications.Pushover; p.Enabled && (p.Token == "" || p.User == "") {
+		return fmt.Errorf("pushover token and user are required when pushover notifications are enabled")
+	}
+	if config.Notifications.Minecraft.Enabled && config.Server.RCON.Address == "" {
+		return fmt.Errorf("minecraft notifications need server.rcon.address to reach the game chat")
+	}
+	if n := config.Notifications.Ntfy; n.Enabled {
+		if n.Topic == "" {
+			return fmt.Errorf("ntfy topic is required when ntfy notifications are enabled")
+		}
+		if u, err := url.Parse(n.Server); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("ntfy server must be an http or https URL")
+		}
+	}
+	if err := validateEventSink(&config.EventSink); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// getDefaultConfigPath returns the default configuration file path
-func getDefaultConfigPath() string {
-	// Look for config file in current directory first
-	configFiles := []string{
-		"config.toml",
-		"curseforge-autoupdate.toml",
-		".curseforge-autoupdate.toml",
+// validateEventSink checks the URL, subject and events of the event sink
+func validateEventSink(cfg *EventSinkConfig) error {
+	var schemes []string
+	switch cfg.Type {
+	case "":
+		return nil
+	case "redis":
+		schemes = []string{"redis", "rediss"}
+	case "nats":
+		schemes = []string{"nats", "tls"}
+	default:
+		return fmt.Errorf("event_sink type must be one of: redis, nats")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || !slices.Contains(schemes, u.Scheme) || u.Host == "" {
+		return fmt.Errorf("event_sink url must be a %s URL with a host", strings.Join(schemes, " or "))
 	}
+	if cfg.Subject == "" {
+		return fmt.Errorf("event_sink subject is required")
+	}
+	if len(cfg.Events) == 0 {
+		return fmt.Errorf("event_sink events must list at least one event type")
+	}
+	return nil
+}
 
-	for _, file := range configFiles {
-		if filesystem.FileExists(file) {
-			return file
+// validateProcess checks the server.process settings
+func validateProcess(cfg ProcessConfig) error {
+	if name, group, hasGroup := strings.Cut(cfg.User, ":"); cfg.User != "" && (name == "" || hasGroup && (group == "" || strings.Contains(group, ":"))) {
+		return fmt.Errorf("server.process.user must be a user or user:group, got %q", cfg.User)
+	}
+	if cfg.Umask != "" {
+		if umask, err := strconv.ParseUint(cfg.Umask, 8, 32); err != nil || umask > 0o777 {
+			return fmt.Errorf("server.process.umask must be an octal mask such as 0027, got %q", cfg.Umask)
+		}
+	}
+	for _, env := range cfg.Env {
+		if name, _, _ := strings.Cut(env, "="); name == "" {
+			return fmt.Errorf("server.process.env entries must be NAME or NAME=value, got %q", env)
+		}
+	}
+	return nil
+}
+
+// validateUpdatePolicy checks the update_policy rules
+func validateUpdatePolicy(config *Config) error {
+	p := config.UpdatePolicy
+	for _, releaseType := range p.ReleaseTypes {
+		switch releaseType {
+		case "release", "beta", "alpha":
+		default:
+			return fmt.Errorf("update_policy release_types must be release, beta or alpha, got %q", releaseType)
 		}
 	}
+	if p.MinFileAge < 0 || p.MaxUpdatesPerWeek < 0 {
+		return fmt.Errorf("update_policy min_file_age and max_updates_per_week must not be negative")
+	}
+	for _, dates := range p.BlackoutDates {
+		if _, _, err := ParseDateRange(dates); err != nil {
+			return fmt.Errorf("update_policy blackout_dates: %w", err)
+		}
+	}
+	for i, rule := range p.HoldRules {
+		if rule.FileName == "" && rule.Changelog == "" {
+			return fmt.Errorf("update_policy hold_rules[%d] needs file_name or changelog", i)
+		}
+		for key, pattern := range map[string]string{"file_name": rule.FileName, "changelog": rule.Changelog} {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("update_policy hold_rules[%d] %s is invalid: %w", i, key, err)
+			}
+		}
+	}
+	if p.RequireEmptyServer && config.Server.RCON.Address == "" {
+		return fmt.Errorf("update_policy require_empty_server needs server.rcon to count the players online")
+	}
+	return nil
+}
+
+// validateFleet checks the jitter and the lock settings
+func validateFleet(cfg *FleetConfig) error {
+	if cfg.Jitter < 0 {
+		return fmt.Errorf("fleet jitter must not be negative")
+	}
+	switch cfg.Lock {
+	case "":
+		return nil
+	case "file":
+		if cfg.LockFile == "" {
+			return fmt.Errorf("fleet lock_file is required for the file lock")
+		}
+	case "redis":
+		if cfg.RedisURL == "" {
+			return fmt.Errorf("fleet redis_url is required for the redis lock")
+		}
+		u, err := url.Parse(cfg.RedisURL)
+		if err != nil {
+			return fmt.Errorf("fleet redis_url is invalid: %w", err)
+		}
+		if u.Scheme != "redis" && u.Scheme != "rediss" {
+			return fmt.Errorf("fleet redis_url must use redis or rediss")
+		}
+		if u.Host == "" {
+			return fmt.Errorf("fleet redis_url must include a host")
+		}
+		if cfg.LockKey == "" {
+			return fmt.Errorf("fleet lock_key is required for the redis lock")
+		}
+	default:
+		return fmt.Errorf("fleet lock must be one of: file, redis")
+	}
+	if cfg.LockTTL < 10*time.Second {
+		return fmt.Errorf("fleet lock_ttl must be at least 10s")
+	}
+	return nil
+}
+
+// validateHTTP checks the proxy URL, CA bundle and timeouts
+func validateHTTP(cfg *HTTPConfig) error {
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("http proxy_url is invalid: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("http proxy_url must use http, https or socks5")
+		}
+		if u.Host == "" {
+			return fmt.Errorf("http proxy_url must include a host")
+		}
+	}
+	if cfg.CAFile != "" && !filesystem.FileExists(cfg.CAFile) {
+		return fmt.Errorf("http ca_file not found: %s", cfg.CAFile)
+	}
+	if cfg.Timeout < 0 || cfg.ConnectTimeout < 0 {
+		return fmt.Errorf("http timeouts must not be negative")
+	}
+	return nil
+}
 
-	// If not found, return default name
-	return "config.toml"
+// validateAPI checks the API endpoint settings
+func validateAPI(cfg *APIConfig) error {
+	if cfg.Keyless && cfg.BaseURL == "" {
+		return fmt.Errorf("api keyless needs api base_url, a proxy that adds the API key")
+	}
+	for key, value := range map[string]string{"base_url": cfg.BaseURL, "download_host": cfg.DownloadHost} {
+		if value == "" {
+			continue
+		}
+		u, err := url.Parse(value)
+		if err != nil {
+			return fmt.Errorf("api %s is invalid: %w", key, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("api %s must be an http or https URL with a host", key)
+		}
+	}
+	return nil
 }
 
 // SaveConfig saves configuration to file
 func SaveConfig(config *Config, configPath string) error {
+	return writeSettings(settings(config), configPath)
+}
+
+// SaveChanges writes the settings in which config differs from old to the config file
+// at configPath, leaving the rest of the file as it is. Values that came from the
+// environment or flags are not written unless they were changed.
+func SaveChanges(old, config *Config, configPath string) error {
+	before, after := settings(old), settings(config)
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	for _, key := range after.AllKeys() {
+		if !reflect.DeepEqual(before.Get(key), after.Get(key)) {
+			v.Set(key, after.Get(key))
+		}
+	}
+	return writeSettings(v, configPath)
+}
+
+// settings returns config in the shape of the config file
+func settings(config *Config) *viper.Viper {
 	v := viper.New()
 
 	// Set values from config struct
-	v.Set("api_key", config.APIKey)
+	// Secrets read from a *_file stay in that file
+	v.Set("api_key", secretValue(config.APIKey, config.APIKeyFile))
+	v.Set("api_key_file", config.APIKeyFile)
+	v.Set("api.base_url", config.API.BaseURL)
+	v.Set("api.keyless", config.API.Keyless)
+	v.Set("api.download_host", config.API.DownloadHost)
+	v.Set("cache.ttl", config.Cache.TTL.String())
+	v.Set("cache.dir", config.Cache.Dir)
+	v.Set("http.proxy_url", config.HTTP.ProxyURL)
+	v.Set("http.ca_file", config.HTTP.CAFile)
+	v.Set("http.insecure_skip_verify", config.HTTP.InsecureSkipVerify)
+	v.Set("http.timeout", config.HTTP.Timeout.String())
+	v.Set("http.connect_timeout", config.HTTP.ConnectTimeout.String())
+	v.Set("download.max_rate", config.Download.MaxRate)
+	v.Set("download.keep_versions", config.Download.KeepVersions)
+	v.Set("download.cache_dir", config.Download.CacheDir)
+	v.Set("download.cache_max_size", config.Download.CacheMaxSize)
 	v.Set("modpack_id", config.ModpackID)
+	v.Set("modpack_provider", config.ModpackProvider)
 	v.Set("game_version", config.GameVersion)
+	mods := make([]map[string]interface{}, 0, len(config.Mods))
+	for _, m := range config.Mods {
+		mod := map[string]interface{}{"name": m.Name}
+		if m.ID > 0 {
+			mod["id"] = m.ID
+		}
+		for key, value := range map[string]string{
+			"project": m.Project, "provider": m.Provider, "loader": m.Loader, "path": m.Path, "channel": m.Channel,
+			"url": m.URL, "version_url": m.VersionURL, "version_path": m.VersionPath, "file_name": m.FileName,
+			"pin": m.Pin, "versions": m.Versions,
+		} {
+			if value != "" {
+				mod[key] = value
+			}
+		}
+		if len(m.IgnoreVersions) > 0 {
+			mod["ignore_versions"] = m.IgnoreVersions
+		}
+		mods = append(mods, mod)
+	}
+	v.Set("mods", mods)
+	v.Set("server.mode", config.Server.Mode)
+	v.Set("server.shutdown_timeout", config.Server.ShutdownTimeout.String())
+	v.Set("server.name", config.Server.Name)
+	v.Set("server.port", config.Server.Port)
+	v.Set("server.max_players", config.Server.MaxPlayers)
+	v.Set("server.docker.host", config.Server.Docker.Host)
+	v.Set("server.docker.container", config.Server.Docker.Container)
+	v.Set("server.docker.data_dir", config.Server.Docker.DataDir)
+	v.Set("server.process.user", config.Server.Process.User)
+	v.Set("server.process.working_dir", config.Server.Process.WorkingDir)
+	v.Set("server.process.umask", config.Server.Process.Umask)
+	v.Set("server.process.env", config.Server.Process.Env)
+	v.Set("server.systemd.unit", config.Server.Systemd.Unit)
+	v.Set("server.systemd.user", config.Server.Systemd.User)
+	v.Set("server.pterodactyl.panel_url", config.Server.Pterodactyl.PanelURL)
+	v.Set("server.pterodactyl.api_key", secretValue(config.Server.Pterodactyl.APIKey, config.Server.Pterodactyl.APIKeyFile))
+	v.Set("server.pterodactyl.api_key_file", config.Server.Pterodactyl.APIKeyFile)
+	v.Set("server.pterodactyl.server_id", config.Server.Pterodactyl.ServerID)
+	v.Set("server.kubernetes.namespace", config.Server.Kubernetes.Namespace)
+	v.Set("server.kubernetes.deployment", config.Server.Kubernetes.Deployment)
+	v.Set("server.kubernetes.ready_file", config.Server.Kubernetes.ReadyFile)
+	v.Set("server.kubernetes.restart_on_update", config.Server.Kubernetes.RestartOnUpdate)
+	v.Set("server.rcon.address", config.Server.RCON.Address)
+	v.Set("server.rcon.password", secretValue(config.Server.RCON.Password, config.Server.RCON.PasswordFile))
+	v.Set("server.rcon.password_file", config.Server.RCON.PasswordFile)
+	schedule := make([]string, 0, len(config.Server.Countdown.Schedule))
+	for _, d := range config.Server.Countdown.Schedule {
+		schedule = append(schedule, d.String())
+	}
+	v.Set("server.countdown.schedule", schedule)
+	v.Set("server.countdown.message", config.Server.Countdown.Message)
+	v.Set("server.countdown.final_message", config.Server.Countdown.FinalMessage)
+	v.Set("server.countdown.cancel_message", config.Server.Countdown.CancelMessage)
+	v.Set("server.countdown.channel", config.Server.Countdown.Channel)
+	v.Set("server.maintenance_mode.enabled", config.Server.MaintenanceMode.Enabled)
+	v.Set("server.maintenance_mode.motd", config.Server.MaintenanceMode.MOTD)
+	v.Set("server.maintenance_mode.eta", config.Server.MaintenanceMode.ETA.String())
+	v.Set("server.maintenance_mode.port", config.Server.MaintenanceMode.Port)
+	v.Set("server.monitor.interval", config.Server.Monitor.Interval.String())
+	v.Set("server.monitor.max_cpu_percent", config.Server.Monitor.MaxCPUPercent)
+	v.Set("server.monitor.max_memory", config.Server.Monitor.MaxMemory)
+	v.Set("server.monitor.min_tps", config.Server.Monitor.MinTPS)
+	v.Set("server.health_check.enabled", config.Server.HealthCheck.Enabled)
+	v.Set("server.health_check.timeout", config.Server.HealthCheck.Timeout.String())
+	v.Set("server.health_check.max_crashes", config.Server.HealthCheck.MaxCrashes)
+	v.Set("server.health_check.rollback", config.Server.HealthCheck.Rollback)
+	tasks := make([]map[string]interface{}, 0, len(config.Server.PostUpdateTasks))
+	for _, t := range config.Server.PostUpdateTasks {
+		task := map[string]interface{}{"command": t.Command}
+		for key, value := range map[string]string{"name": t.Name, "wait_for": t.WaitFor, "on_failure": t.OnFailure} {
+			if value != "" {
+				task[key] = value
+			}
+		}
+		if t.Timeout != 0 {
+			task["timeout"] = t.Timeout.String()
+		}
+		tasks = append(tasks, task)
+	}
+	v.Set("server.post_update_tasks", tasks)
+	v.Set("plugins.dir", config.Plugins.Dir)
+	v.Set("plugins.timeout", config.Plugins.Timeout.String())
+	if len(config.Plugins.Stages) > 0 {
+		v.Set("plugins.stages", config.Plugins.Stages)
+	}
+	if len(config.Servers) > 0 {
+		v.Set("servers", instanceSettings(config.Servers))
+	}
 	v.Set("server_path", config.ServerPath)
 	v.Set("backup_path", config.BackupPath)
 	v.Set("server_jar_name", config.ServerJarName)
-	v.Set("auto_update", config.AutoUpdate)
+	v.Set("preserve", config.Preserve)
+	v.Set("server_jar.type", config.ServerJar.Type)
+	v.Set("server_jar.minecraft_version", config.ServerJar.MinecraftVersion)
+	v.Set("server_jar.loader_version", config.ServerJar.LoaderVersion)
+	v.Set("server_jar.java", config.ServerJar.Java)
+	v.Set("download_path", config.DownloadPath)
+	v.Set("manual_download_path", config.ManualDownloadPath)
+	v.Set("data_dir", config.DataDir)
 	v.Set("update_channel", config.UpdateChannel)
+	v.Set("mod_changelogs", config.ModChangelogs)
+	v.Set("differential_sync", config.DifferentialSync)
+	v.Set("release_policy.min_age", config.ReleasePolicy.MinAge.String())
+	v.Set("release_policy.stable_mods", config.ReleasePolicy.StableMods)
+	v.Set("update_policy.auto_update", config.UpdatePolicy.AutoUpdate)
+	v.Set("update_policy.release_types", config.UpdatePolicy.ReleaseTypes)
+	v.Set("update_policy.min_file_age", config.UpdatePolicy.MinFileAge.String())
+	v.Set("update_policy.game_versions", config.UpdatePolicy.GameVersions)
+	v.Set("update_policy.blackout_dates", config.UpdatePolicy.BlackoutDates)
+	v.Set("update_policy.max_updates_per_week", config.UpdatePolicy.MaxUpdatesPerWeek)
+	v.Set("update_policy.require_empty_server", config.UpdatePolicy.RequireEmptyServer)
+	v.Set("update_policy.confirm_minecraft_upgrades", config.UpdatePolicy.ConfirmMinecraftUpgrades)
+	holdRules := make([]map[string]interface{}, 0, len(config.UpdatePolicy.HoldRules))
+	for _, r := range config.UpdatePolicy.HoldRules {
+		rule := map[string]interface{}{}
+		for key, value := range map[string]string{"name": r.Name, "file_name": r.FileName, "changelog": r.Changelog} {
+			if value != "" {
+				rule[key] = value
+			}
+		}
+		holdRules = append(holdRules, rule)
+	}
+	v.Set("update_policy.hold_rules", holdRules)
+	v.Set("approval.required", config.Approval.Required)
+	v.Set("approval.expire_after", config.Approval.ExpireAfter.String())
+	v.Set("approval.remind_every", config.Approval.RemindEvery.String())
+	v.Set("check_interval", config.CheckInterval.String())
+	v.Set("maintenance.window_start", config.Maintenance.WindowStart)
+	v.Set("maintenance.window_end", config.Maintenance.WindowEnd)
+	v.Set("maintenance.timezone", config.Maintenance.Timezone)
+	v.Set("maintenance.announce_before", config.Maintenance.AnnounceBefore.String())
+	v.Set("fleet.jitter", config.Fleet.Jitter.String())
+	v.Set("fleet.lock", config.Fleet.Lock)
+	v.Set("fleet.lock_file", config.Fleet.LockFile)
+	v.Set("fleet.redis_url", config.Fleet.RedisURL)
+	v.Set("fleet.lock_key", config.Fleet.LockKey)
+	v.Set("fleet.lock_ttl", config.Fleet.LockTTL.String())
+	v.Set("backup.retention_days", config.Backup.RetentionDays)
+	v.Set("backup.compression", config.Backup.Compression)
+	v.Set("backup.incremental", config.Backup.Incremental)
+	v.Set("backup.keep_last", config.Backup.KeepLast)
+	v.Set("backup.keep_daily", config.Backup.KeepDaily)
+	v.Set("backup.keep_weekly", config.Backup.KeepWeekly)
+	v.Set("backup.keep_monthly", config.Backup.KeepMonthly)
+	v.Set("backup.restore_owner", config.Backup.RestoreOwner)
+	v.Set("backup.snapshot", config.Backup.Snapshot)
+	v.Set("backup.encryption.passphrase", secretValue(config.Backup.Encryption.Passphrase, config.Backup.Encryption.PassphraseFile))
+	v.Set("backup.encryption.passphrase_file", config.Backup.Encryption.PassphraseFile)
+	v.Set("backup.encryption.recipients", config.Backup.Encryption.Recipients)
+	v.Set("backup.encryption.identity_file", config.Backup.Encryption.IdentityFile)
+	v.Set("web.listen", config.Web.Listen)
+	v.Set("web.api_token", secretValue(config.Web.APIToken, config.Web.APITokenFile))
+	v.Set("web.api_token_file", config.Web.APITokenFile)
+	v.Set("web.webhook_secret", secretValue(config.Web.WebhookSecret, config.Web.WebhookSecretFile))
+	v.Set("web.webhook_secret_file", config.Web.WebhookSecretFile)
+	v.Set("web.tls_cert", config.Web.TLSCert)
+	v.Set("web.tls_key", config.Web.TLSKey)
+	v.Set("web.static_dir", config.Web.StaticDir)
+	v.Set("web.public_url", config.Web.PublicURL)
+	v.Set("web.grpc_listen", config.Web.GRPCListen)
 	v.Set("log_level", config.LogLevel)
+	v.Set("log_format", config.LogFormat)
 	v.Set("log_file", config.LogFile)
 
 	// Set notification config
 	v.Set("notifications.discord.enabled", config.Notifications.Discord.Enabled)
-	v.Set("notifications.discord.webhook_url", config.Notifications.Discord.WebhookURL)
+	v.Set("notifications.discord.webhook_url", secretValue(config.Notifications.Discord.WebhookURL, config.Notifications.Discord.WebhookURLFile))
+	v.Set("notifications.discord.webhook_url_file", config.Notifications.Discord.WebhookURLFile)
 	v.Set("notifications.discord.channel_id", config.Notifications.Discord.ChannelID)
 	v.Set("notifications.discord.username", config.Notifications.Discord.Username)
 	v.Set("notifications.discord.avatar_url", config.Notifications.Discord.AvatarURL)
 
 	v.Set("notifications.webhook.enabled", config.Notifications.Webhook.Enabled)
-	v.Set("notifications.webhook.url", config.Notifications.Webhook.URL)
+	v.Set("notifications.webhook.url", secretValue(config.Notifications.Webhook.URL, config.Notifications.Webhook.URLFile))
+	v.Set("notifications.webhook.url_file", config.Notifications.Webhook.URLFile)
 	v.Set("notifications.webhook.headers", config.Notifications.Webhook.Headers)
 	v.Set("notifications.webhook.content_type", config.Notifications.Webhook.ContentType)
 	v.Set("notifications.webhook.method", config.Notifications.Webhook.Method)
-	v.Set("notifications.webhook.timeout", config.Notifications.Webhook.Timeout)
+	v.Set("notifications.webhook.timeout", config.Notifications.Webhook.Timeout.String())
 
+	v.Set("notifications.pushover.enabled", config.Notifications.Pushover.Enabled)
+	v.Set("notifications.pushover.token", secretValue(config.Notifications.Pushover.Token, config.Notifications.Pushover.TokenFile))
+	v.Set("notifications.pushover.token_file", config.Notifications.Pushover.TokenFile)
+	v.Set("notifications.pushover.user", config.Notifications.Pushover.User)
+	v.Set("notifications.pushover.device", config.Notifications.Pushover.Device)
+
+	v.Set("notifications.ntfy.enabled", config.Notifications.Ntfy.Enabled)
+	v.Set("notifications.ntfy.server", config.Notifications.Ntfy.Server)
+	v.Set("notifications.ntfy.topic", config.Notifications.Ntfy.Topic)
+	v.Set("notifications.ntfy.token", secretValue(config.Notifications.Ntfy.Token, config.Notifications.Ntfy.TokenFile))
+	v.Set("notifications.ntfy.token_file", config.Notifications.Ntfy.TokenFile)
+
+	v.Set("notifications.minecraft.enabled", config.Notifications.Minecraft.Enabled)
+	v.Set("notifications.minecraft.prefix", config.Notifications.Minecraft.Prefix)
+
+	v.Set("notifications.retry.attempts", config.Notifications.Retry.Attempts)
+	v.Set("notifications.retry.backoff", config.Notifications.Retry.Backoff.String())
+	v.Set("notifications.retry.max_backoff", config.Notifications.Retry.MaxBackoff.String())
+	v.Set("notifications.retry.breaker_failures", config.Notifications.Retry.BreakerFailures)
+	v.Set("notifications.retry.breaker_cooldown", config.Notifications.Retry.BreakerCooldown.String())
+
+	v.Set("event_sink.type", config.EventSink.Type)
+	v.Set("event_sink.url", config.EventSink.URL)
+	v.Set("event_sink.subject", config.EventSink.Subject)
+	v.Set("event_sink.events", config.EventSink.Events)
+	return v
+}
+
+// writeSettings writes the settings of v to the config file at configPath
+func writeSettings(v *viper.Viper, configPath string) error {
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(configPath), 0750); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
@@ -267,3 +1364,11 @@ func SaveConfig(config *Config, configPath string) error {
 
 	return nil
 }
+
+// secretValue returns the secret to write to the config file, or nothing when it is read from file
+func secretValue(value, file string) string {
+	if file != "" {
+		return ""
+	}
+	return value
+}
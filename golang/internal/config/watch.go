@@ -0,0 +1,77 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the burst of events editors produce when saving a file
+const reloadDebounce = 500 * time.Millisecond
+
+// ReloadFunc receives the result of a reload; cfg is nil when err is set
+type ReloadFunc func(cfg *Config, err error)
+
+// Watcher reloads the configuration when the config file changes or Reload is called
+type Watcher struct {
+	opts     Options
+	onReload ReloadFunc
+}
+
+// NewWatcher creates a watcher that loads configuration with opts
+func NewWatcher(opts Options, onReload ReloadFunc) *Watcher {
+	return &Watcher{opts: opts, onReload: onReload}
+}
+
+// Reload loads and validates the configuration and reports the result
+func (w *Watcher) Reload() {
+	cfg, err := Load(w.opts)
+	if err == nil {
+		if verr := Validate(cfg); verr != nil {
+			cfg, err = nil, fmt.Errorf("config validation failed: %w", verr)
+		}
+	}
+	w.onReload(cfg, err)
+}
+
+// Run watches file and reloads after it changes, until the context is cancelled.
+// The parent directory is watched so that editors replacing the file are handled.
+func (w *Watcher) Run(ctx context.Context, file string) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer fw.Close()
+
+	file = filepath.Clean(file)
+	if err := fw.Add(filepath.Dir(file)); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", file, err)
+	}
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != file || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				continue
+			}
+			pending = time.After(reloadDebounce)
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			w.onReload(nil, fmt.Errorf("file watcher: %w", err))
+		case <-pending:
+			pending = nil
+			w.Reload()
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWatcherReloadsOnChange(t *testing.T) {
+	path := writeConfig(t, "config.toml", "api_key = \"k\"\nmodpack_id = 1\ncheck_interval = \"1h\"\n")
+
+	type reload struct {
+		cfg *Config
+		err error
+	}
+	results := make(chan reload, 4)
+	w := NewWatcher(Options{Path: path}, func(cfg *Config, err error) {
+		results <- reload{cfg, err}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx, path)
+	time.Sleep(100 * time.Millisecond) // let the watcher start
+
+	tests := []struct {
+		name     string
+		content  string
+		wantErr  bool
+		interval time.Duration
+	}{
+		{"valid change", "api_key = \"k\"\nmodpack_id = 1\ncheck_interval = \"30m\"\n", false, 30 * time.Minute},
+		{"invalid change", "api_key = \"k\"\nmodpack_id = 1\ncheck_interval = \"1s\"\n", true, 0},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case r := <-results:
+			if (r.err != nil) != tt.wantErr {
+				t.Fatalf("%s: err = %v, wantErr %t", tt.name, r.err, tt.wantErr)
+			}
+			if !tt.wantErr && r.cfg.CheckInterval != tt.interval {
+				t.Errorf("%s: CheckInterval = %s, want %s", tt.name, r.cfg.CheckInterval, tt.interval)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: no reload after writing the config", tt.name)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package downloads
+
+import (
+	"crypto/sha1" //nolint:gosec // CurseForge and Modrinth publish SHA-1 hashes
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+)
+
+// sha1Hex matches the hashes files are kept under in a Cache
+var sha1Hex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Cache keeps downloaded files by their SHA-1 hash, so that every server instance and
+// every reinstall can reuse them instead of downloading them again. Files are stored as
+// <dir>/<first two hex digits>/<hash>; their modification time records when they were
+// last used. A nil Cache downloads every file.
+type Cache struct {
+	dir     string
+	maxSize int64 // 0 for no limit
+}
+
+// CacheEntry is one file in a Cache
+type CacheEntry struct {
+	Hash     string
+	Size     int64
+	LastUsed time.Time
+}
+
+// NewCache returns the cache in dir holding up to maxSize bytes, or nil when dir is empty
+func NewCache(dir string, maxSize int64) *Cache {
+	if dir == "" {
+		return nil
+	}
+	return &Cache{dir: dir, maxSize: maxSize}
+}
+
+// Dir returns the directory the cache is kept in
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Fetch writes the file with the given SHA-1 hash to w. A cached copy is used when there
+// is one; otherwise download writes the file, which is passed on to w as it arrives and
+// added to the cache once its hash matches. Without a hash the file is only downloaded.
+// hit reports whether the cached copy was used.
+func (c *Cache) Fetch(hash string, w io.Writer, download func(io.Writer) error) (hit bool, err error) {
+	hash = strings.ToLower(hash)
+	if c == nil || !sha1Hex.MatchString(hash) {
+		return false, download(w)
+	}
+	path := c.path(hash)
+	if f, err := os.Open(path); err == nil { // #nosec G304 -- path is inside the cache directory
+		defer f.Close()
+		now := time.Now()
+		_ = os.Chtimes(path, now, now)
+		_, err = io.Copy(w, f)
+		return true, err
+	}
+
+	if err := filesystem.EnsureDir(filepath.Dir(path)); err != nil {
+		return false, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download_*")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temporary cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	h := sha1.New() //nolint:gosec // CurseForge and Modrinth publish SHA-1 hashes
+	if err := download(io.MultiWriter(w, tmp, h)); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != hash {
+		return false, fmt.Errorf("sha1 is %s, expected %s", got, hash)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return false, fmt.Errorf("failed to add download to the cache: %w", err)
+	}
+	if c.maxSize > 0 {
+		if _, err := c.Prune(c.maxSize, false); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// List returns the cached files, least recently used first
+func (c *Cache) List() ([]CacheEntry, error) {
+	var entries []CacheEntry
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == c.dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !sha1Hex.MatchString(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, CacheEntry{Hash: d.Name(), Size: info.Size(), LastUsed: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list download cache %s: %w", c.dir, err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].LastUsed.Equal(entries[j].LastUsed) {
+			return entries[i].LastUsed.Before(entries[j].LastUsed)
+		}
+		return entries[i].Hash < entries[j].Hash
+	})
+	return entries, nil
+}
+
+// Prune removes the least recently used files until the cache holds at most maxSize
+// bytes, and returns them. With dryRun nothing is removed, but the files that would be
+// are still returned.
+func (c *Cache) Prune(maxSize int64, dryRun bool) ([]CacheEntry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	var pruned []CacheEntry
+	for _, e := range entries {
+		if total <= maxSize {
+			break
+		}
+		if !dryRun {
+			if err := os.Remove(c.path(e.Hash)); err != nil && !os.IsNotExist(err) {
+				return pruned, fmt.Errorf("failed to remove %s from the download cache: %w", e.Hash, err)
+			}
+		}
+		total -= e.Size
+		pruned = append(pruned, e)
+	}
+	return pruned, nil
+}
+
+func (c *Cache) path(hash string) string {
+	return filepath.Join(c.dir, hash[:2], hash)
+}
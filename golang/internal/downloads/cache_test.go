@@ -0,0 +1,109 @@
+package downloads
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint:gosec // CurseForge and Modrinth publish SHA-1 hashes
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sha1Of(s string) string {
+	sum := sha1.Sum([]byte(s)) //nolint:gosec // CurseForge and Modrinth publish SHA-1 hashes
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCacheFetch(t *testing.T) {
+	cache := NewCache(t.TempDir(), 0)
+	downloads := 0
+	download := func(content string) func(io.Writer) error {
+		return func(w io.Writer) error {
+			downloads++
+			_, err := io.WriteString(w, content)
+			return err
+		}
+	}
+
+	for i, wantHit := range []bool{false, true} {
+		var buf bytes.Buffer
+		hit, err := cache.Fetch(strings.ToUpper(sha1Of("jar")), &buf, download("jar"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hit != wantHit || buf.String() != "jar" {
+			t.Errorf("fetch %d: hit %v, got %q", i, hit, buf.String())
+		}
+	}
+	if downloads != 1 {
+		t.Errorf("downloaded %d times, want 1", downloads)
+	}
+
+	// A download that does not match its hash is not kept
+	if _, err := cache.Fetch(sha1Of("other"), io.Discard, download("corrupt")); err == nil || !strings.Contains(err.Error(), "sha1 is") {
+		t.Fatalf("expected a hash mismatch, got %v", err)
+	}
+	// Without a hash the file is only downloaded
+	if hit, err := cache.Fetch("", io.Discard, download("unknown")); hit || err != nil {
+		t.Fatalf("fetch without hash: hit %v, %v", hit, err)
+	}
+	if entries, err := cache.List(); err != nil || len(entries) != 1 {
+		t.Fatalf("cache holds %+v, %v; want only the jar", entries, err)
+	}
+
+	// A nil cache downloads every time
+	var none *Cache
+	if hit, err := none.Fetch(sha1Of("jar"), io.Discard, download("jar")); hit || err != nil {
+		t.Fatalf("nil cache: hit %v, %v", hit, err)
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(dir, 0)
+	base := time.Now().Add(-time.Hour)
+	for i, content := range []string{"oldest", "middle", "newest"} {
+		if _, err := cache.Fetch(sha1Of(content), io.Discard, func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		used := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(cache.path(sha1Of(content)), used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruned, err := cache.Prune(12, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 1 || pruned[0].Hash != sha1Of("oldest") {
+		t.Fatalf("dry run pruned %+v, want the oldest file", pruned)
+	}
+	if _, err := os.Stat(filepath.Join(dir, sha1Of("oldest")[:2], sha1Of("oldest"))); err != nil {
+		t.Errorf("dry run removed the oldest file: %v", err)
+	}
+
+	// Using a file makes it the most recent
+	if _, err := cache.Fetch(sha1Of("oldest"), io.Discard, nil); err != nil {
+		t.Fatal(err)
+	}
+	if pruned, err = cache.Prune(12, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 1 || pruned[0].Hash != sha1Of("middle") {
+		t.Fatalf("pruned %+v, want the middle file", pruned)
+	}
+	entries, err := cache.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Hash != sha1Of("newest") {
+		t.Errorf("cache holds %+v", entries)
+	}
+}
@@ -0,0 +1,116 @@
+package downloads
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+)
+
+// MetadataFileName is the name of the download metadata file kept in the download directory
+const MetadataFileName = "download_metadata.json"
+
+// Record describes one downloaded file in download_metadata.json
+type Record struct {
+	FileName     string `json:"fileName"`
+	FileDate     string `json:"fileDate"`
+	DownloadedAt string `json:"downloadedAt"`
+	FileLength   int64  `json:"fileLength"`
+	Hash         string `json:"hash"`
+	DisplayName  string `json:"displayName"`
+	ModID        int    `json:"modId,omitempty"` // missing in records written by older versions
+}
+
+// Metadata maps file IDs (as strings) to download records
+type Metadata map[string]Record
+
+// LoadMetadata reads the download metadata from dir; a missing file yields empty metadata
+func LoadMetadata(dir string) (Metadata, error) {
+	path := filepath.Join(dir, MetadataFileName)
+
+	// #nosec G304 -- path is constructed from the configured download directory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Metadata{}, nil
+		}
+		return nil, fmt.Errorf("failed to read download metadata %s: %w", path, err)
+	}
+
+	metadata := Metadata{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse download metadata %s: %w", path, err)
+	}
+
+	return metadata, nil
+}
+
+// SaveMetadata writes the download metadata to dir atomically
+func SaveMetadata(dir string, metadata Metadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal download metadata: %w", err)
+	}
+	path := filepath.Join(dir, MetadataFileName)
+	if err := filesystem.SafeWriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write download metadata %s: %w", path, err)
+	}
+	return nil
+}
+
+// NewRecord builds a metadata record for a downloaded API file
+func NewRecord(file *api.ModFile, downloadedAt time.Time) Record {
+	return Record{
+		FileName:     file.FileName,
+		FileDate:     file.FileDate.Format(time.RFC3339Nano),
+		DownloadedAt: downloadedAt.Format(time.RFC3339),
+		FileLength:   file.FileLength,
+		Hash:         SHA1(file),
+		DisplayName:  file.DisplayName,
+		ModID:        file.ModID,
+	}
+}
+
+// Add records a downloaded file under its file ID
+func (m Metadata) Add(fileID int, rec Record) {
+	m[strconv.Itoa(fileID)] = rec
+}
+
+// SHA1 returns the SHA-1 hash reported by the API for a file, if any
+func SHA1(file *api.ModFile) string {
+	for _, hash := range file.Hashes {
+		if hash.Algo == api.HashAlgoSHA1 {
+			return hash.Value
+		}
+	}
+	return ""
+}
+
+// Latest returns the most recent download by file date, using the file ID as tiebreaker
+func (m Metadata) Latest() (int, Record, bool) {
+	var (
+		bestID   int
+		bestRec  Record
+		bestDate time.Time
+		found    bool
+	)
+
+	for key, rec := range m {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339Nano, rec.FileDate)
+
+		if !found || date.After(bestDate) || (date.Equal(bestDate) && id > bestID) {
+			bestID, bestRec, bestDate, found = id, rec, date, true
+		}
+	}
+
+	return bestID, bestRec, found
+}
@@ -0,0 +1,87 @@
+package downloads
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Pruned is a download removed by Prune
+type Pruned struct {
+	FileID int
+	Record Record
+}
+
+// Prune removes the downloads in dir superseded by newer versions of the same mod, keeping
+// the keep newest of every mod by file date, and drops their records from the metadata.
+// Records of older versions that do not say which mod they belong to are grouped together.
+// Nothing is removed when keep is not positive; with dryRun nothing is removed either, but
+// the downloads that would be are still returned.
+func Prune(dir string, keep int, dryRun bool) ([]Pruned, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	metadata, err := LoadMetadata(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type download struct {
+		id   int
+		rec  Record
+		date time.Time
+	}
+	byMod := map[int][]download{}
+	for key, rec := range metadata {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339Nano, rec.FileDate)
+		byMod[rec.ModID] = append(byMod[rec.ModID], download{id: id, rec: rec, date: date})
+	}
+
+	var pruned []Pruned
+	kept := map[string]bool{} // file names still referenced by a kept record
+	for _, list := range byMod {
+		// Newest first, with the same tiebreaker as Latest
+		sort.Slice(list, func(i, j int) bool {
+			if !list[i].date.Equal(list[j].date) {
+				return list[i].date.After(list[j].date)
+			}
+			return list[i].id > list[j].id
+		})
+		for i, d := range list {
+			if i < keep {
+				kept[filepath.Base(d.rec.FileName)] = true
+				continue
+			}
+			pruned = append(pruned, Pruned{FileID: d.id, Record: d.rec})
+		}
+	}
+	sort.Slice(pruned, func(i, j int) bool { return pruned[i].FileID < pruned[j].FileID })
+	if dryRun || len(pruned) == 0 {
+		return pruned, nil
+	}
+
+	// Records of files already removed are dropped even when a later removal fails
+	var removeErr error
+	for i, p := range pruned {
+		name := filepath.Base(p.Record.FileName)
+		if !kept[name] {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+				removeErr = fmt.Errorf("failed to remove download %s: %w", name, err)
+				pruned = pruned[:i]
+				break
+			}
+		}
+		delete(metadata, strconv.Itoa(p.FileID))
+	}
+	if err := SaveMetadata(dir, metadata); err != nil {
+		return pruned, err
+	}
+	return pruned, removeErr
+}
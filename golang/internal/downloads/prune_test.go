@@ -0,0 +1,92 @@
+package downloads
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	metadata := Metadata{}
+	add := func(id, modID, day int, name string) {
+		metadata.Add(id, Record{FileName: name, FileDate: base.AddDate(0, 0, day).Format(time.RFC3339Nano), ModID: modID})
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add(1, 100, 1, "pack-1.zip")
+	add(2, 100, 2, "pack-2.zip")
+	add(3, 100, 3, "pack-3.zip")
+	add(4, 200, 1, "other-1.zip")
+	add(5, 0, 1, "legacy.zip")
+	if err := SaveMetadata(dir, metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, err := Prune(dir, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 1 || pruned[0].FileID != 1 {
+		t.Fatalf("dry run pruned %+v, want file 1", pruned)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pack-1.zip")); err != nil {
+		t.Errorf("dry run removed pack-1.zip: %v", err)
+	}
+
+	pruned, err = Prune(dir, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, p := range pruned {
+		ids = append(ids, p.FileID)
+	}
+	if fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("pruned %v, want [1 2]", ids)
+	}
+	for name, want := range map[string]bool{"pack-1.zip": false, "pack-2.zip": false, "pack-3.zip": true, "other-1.zip": true, "legacy.zip": true} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s exists = %t, want %t", name, err == nil, want)
+		}
+	}
+	loaded, err := LoadMetadata(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 3 {
+		t.Errorf("metadata has %d records after pruning, want 3", len(loaded))
+	}
+}
+
+func TestPruneKeepsSharedFile(t *testing.T) {
+	dir := t.TempDir()
+	metadata := Metadata{}
+	// The same file name recorded twice, e.g. after a file was re-uploaded under a new ID
+	metadata.Add(1, Record{FileName: "pack.zip", FileDate: "2024-01-01T00:00:00Z", ModID: 100})
+	metadata.Add(2, Record{FileName: "pack.zip", FileDate: "2024-01-02T00:00:00Z", ModID: 100})
+	if err := SaveMetadata(dir, metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pack.zip"), []byte("zip"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Prune(dir, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pack.zip")); err != nil {
+		t.Errorf("pack.zip of the kept record was removed: %v", err)
+	}
+}
+
+func TestPruneDisabled(t *testing.T) {
+	pruned, err := Prune(t.TempDir(), 0, false)
+	if err != nil || pruned != nil {
+		t.Errorf("Prune(keep=0) = %v, %v; want nothing", pruned, err)
+	}
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+)
+
+// Event represents something that happened inside the updater
+type Event struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// Handler is called for every event a subscriber is interested in
+type Handler func(Event)
+
+// subscription holds a handler and the event types it listens to
+type subscription struct {
+	handler Handler
+	types   map[string]bool
+}
+
+// Bus dispatches events to subscribers synchronously, in subscription order
+type Bus struct {
+	clock  clock.Clock
+	mu     sync.RWMutex
+	subs   map[int]*subscription
+	order  []int
+	nextID int
+}
+
+// NewBus creates a new event bus using the given clock for event timestamps
+func NewBus(c clock.Clock) *Bus {
+	if c == nil {
+		c = clock.Real()
+	}
+	return &Bus{
+		clock: c,
+		subs:  make(map[int]*subscription),
+	}
+}
+
+// Subscribe registers a handler for the given event types (all types if none given)
+// and returns a function that removes the subscription
+func (b *Bus) Subscribe(handler Handler, types ...string) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &subscription{handler: handler}
+	if len(types) > 0 {
+		sub.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = sub
+	b.order = append(b.order, id)
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+		for i, existing := range b.order {
+			if existing == id {
+				b.order = append(b.order[:i], b.order[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Publish sends an event to all interested subscribers
+func (b *Bus) Publish(eventType string, data map[string]interface{}) {
+	event := Event{
+		Type: eventType,
+		Time: b.clock.Now(),
+		Data: data,
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.order))
+	for _, id := range b.order {
+		sub := b.subs[id]
+		if sub.types == nil || sub.types[eventType] {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
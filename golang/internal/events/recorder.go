@@ -0,0 +1,99 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Recorder subscribes to a bus and keeps every event it sees.
+// It is intended as a test harness for code that publishes events.
+type Recorder struct {
+	mu          sync.Mutex
+	events      []Event
+	notify      chan struct{}
+	unsubscribe func()
+}
+
+// NewRecorder creates a recorder listening for the given event types (all types if none given)
+func NewRecorder(bus *Bus, types ...string) *Recorder {
+	r := &Recorder{
+		notify: make(chan struct{}, 1),
+	}
+	r.unsubscribe = bus.Subscribe(r.record, types...)
+	return r
+}
+
+// record stores an event and wakes any goroutine waiting in WaitFor
+func (r *Recorder) record(event Event) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Events returns a copy of all recorded events
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make([]Event, len(r.events))
+	copy(events, r.events)
+	return events
+}
+
+// Types returns the types of all recorded events in order
+func (r *Recorder) Types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	types := make([]string, len(r.events))
+	for i, event := range r.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+// Count returns how many events of the given type were recorded
+func (r *Recorder) Count(eventType string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, event := range r.events {
+		if event.Type == eventType {
+			count++
+		}
+	}
+	return count
+}
+
+// WaitFor blocks until n events of the given type have been recorded or the real-time timeout expires
+func (r *Recorder) WaitFor(eventType string, n int, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		if r.Count(eventType) >= n {
+			return true
+		}
+		select {
+		case <-r.notify:
+		case <-deadline:
+			return r.Count(eventType) >= n
+		}
+	}
+}
+
+// Reset discards all recorded events
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = nil
+}
+
+// Close stops recording new events
+func (r *Recorder) Close() {
+	r.unsubscribe()
+}
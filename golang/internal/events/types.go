@@ -0,0 +1,35 @@
+package events
+
+// Types of the events that subsystems react to. Publishers may name them in their own
+// package, such as updater.EventUpdateFinished; subscribers use these so that they need
+// not import the publisher.
+const (
+	// UpdateAvailable is published when a scheduled check finds a newer file; data has
+	// installed_version and latest_version
+	UpdateAvailable = "update_available"
+	// UpdateProgress reports the backup, download and install phases of an update
+	UpdateProgress = "update_progress"
+	// UpdateFinished is published after an update; data has from_version, to_version,
+	// skipped, duration and mods, backup when a pre-update backup was made, and
+	// client_version, client_url and client_download_url when a server pack with a
+	// matching client file was installed
+	UpdateFinished = "update_finished"
+	// UpdateFailed is published when an update fails; data has to_version and error
+	UpdateFailed = "update_failed"
+
+	// BackupCreated is published after a backup is made; data has name and size
+	BackupCreated = "backup_created"
+
+	// ServerStarted and ServerStopped follow the server process the web UI runs;
+	// ServerStopped has the error it exited with, if any
+	ServerStarted = "server_started"
+	ServerStopped = "server_stopped"
+	// ServerDegraded is published when the server crosses a server.monitor threshold,
+	// with the problems; ServerRecovered once it is back within all of them
+	ServerDegraded  = "server_degraded"
+	ServerRecovered = "server_recovered"
+
+	// Action is a state-changing action of an operator for the audit log; data has
+	// the fields of an audit entry
+	Action = "action"
+)
@@ -0,0 +1,166 @@
+// Package eventsink publishes the lifecycle events of the updater, such as installed
+// updates and new backups, as JSON to a Redis pub/sub channel or a NATS subject, so that
+// operators can build their own automation around them
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/redis/go-redis/v9"
+)
+
+// queueSize is how many events may wait to be published before new ones are dropped
+const queueSize = 64
+
+// publishTimeout bounds publishing one event
+const publishTimeout = 10 * time.Second
+
+// publisher sends a payload to a channel or subject
+type publisher interface {
+	publish(ctx context.Context, subject string, payload []byte) error
+}
+
+// Message is the JSON published for an event
+type Message struct {
+	Type      string                 `json:"type"`
+	Time      time.Time              `json:"time"`
+	Server    string                 `json:"server,omitempty"` // the [[servers]] entry, empty for the top-level server
+	ModpackID int                    `json:"modpack_id"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Sink publishes events from a bus. The bus delivers events synchronously, so they are
+// queued and published in the background, never holding up an update.
+type Sink struct {
+	pub       publisher
+	subject   string
+	events    []string
+	server    string
+	modpackID int
+	logger    *slog.Logger
+
+	queue  chan Message
+	done   chan struct{}
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewFromConfig creates the sink set up in cfg.EventSink, or returns nil when none is
+// configured
+func NewFromConfig(cfg *config.Config, logger *slog.Logger) (*Sink, error) {
+	var pub publisher
+	switch cfg.EventSink.Type {
+	case "":
+		return nil, nil
+	case "redis":
+		opts, err := redis.ParseURL(cfg.EventSink.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis url: %w", err)
+		}
+		pub = redisPublisher{redis.NewClient(opts)}
+	case "nats":
+		p, err := newNATSPublisher(cfg.EventSink.URL)
+		if err != nil {
+			return nil, err
+		}
+		pub = p
+	default:
+		return nil, fmt.Errorf("unknown event sink %q", cfg.EventSink.Type)
+	}
+	return newSink(pub, cfg, logger), nil
+}
+
+// newSink starts a sink that publishes with pub
+func newSink(pub publisher, cfg *config.Config, logger *slog.Logger) *Sink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	s := &Sink{
+		pub:       pub,
+		subject:   cfg.EventSink.Subject,
+		events:    cfg.EventSink.Events,
+		server:    cfg.InstanceName,
+		modpackID: cfg.ModpackID,
+		logger:    logger,
+		queue:     make(chan Message, queueSize),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Subscribe publishes the configured event types of bus until the returned function is
+// called
+func (s *Sink) Subscribe(bus *events.Bus) func() {
+	return bus.Subscribe(func(e events.Event) {
+		s.enqueue(Message{Type: e.Type, Time: e.Time, Server: s.server, ModpackID: s.modpackID, Data: e.Data})
+	}, s.events...)
+}
+
+// enqueue queues msg for publishing, dropping it when the sink is closed or behind
+func (s *Sink) enqueue(msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- msg:
+	default:
+		s.logger.Warn("event sink is behind, dropping event", "event", msg.Type)
+	}
+}
+
+// Close publishes the events still queued, stops the sink and closes its connection
+func (s *Sink) Close() {
+	s.mu.Lock()
+	closing := !s.closed
+	if closing {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+	if c, ok := s.pub.(io.Closer); ok && closing {
+		_ = c.Close()
+	}
+}
+
+// run publishes queued events until the sink is closed
+func (s *Sink) run() {
+	defer close(s.done)
+	for msg := range s.queue {
+		subject := strings.ReplaceAll(s.subject, "{event}", msg.Type)
+		payload, err := json.Marshal(msg)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+			err = s.pub.publish(ctx, subject, payload)
+			cancel()
+		}
+		if err != nil {
+			s.logger.Warn("failed to publish event", "event", msg.Type, "subject", subject, "error", err)
+		}
+	}
+}
+
+// redisPublisher publishes to Redis pub/sub channels
+type redisPublisher struct {
+	client *redis.Client
+}
+
+func (p redisPublisher) publish(ctx context.Context, channel string, payload []byte) error {
+	return p.client.Publish(ctx, channel, payload).Err()
+}
+
+func (p redisPublisher) Close() error {
+	return p.client.Close()
+}
@@ -0,0 +1,125 @@
+package eventsink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// published is a message a fake server received
+type published struct {
+	subject string
+	message Message
+}
+
+// fakeRedis runs a Redis server and sends what is published on it to out
+func fakeRedis(t *testing.T, out chan<- published) string {
+	m := miniredis.RunT(t)
+	sub := m.NewSubscriber()
+	sub.Psubscribe("*")
+	go func() {
+		for p := range sub.Pmessages() {
+			var msg Message
+			_ = json.Unmarshal([]byte(p.Message), &msg)
+			out <- published{p.Channel, msg}
+		}
+	}()
+	return m.Addr()
+}
+
+// fakeNATS runs a NATS server that takes the token secret and sends what is published on
+// it to out
+func fakeNATS(t *testing.T, out chan<- published) string {
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, Authorization: "secret", NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Start()
+	t.Cleanup(srv.Shutdown)
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server did not start")
+	}
+
+	conn, err := nats.Connect(srv.ClientURL(), nats.Token("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(conn.Close)
+	if _, err := conn.Subscribe(">", func(m *nats.Msg) {
+		var msg Message
+		_ = json.Unmarshal(m.Data, &msg)
+		out <- published{m.Subject, msg}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return srv.Addr().String()
+}
+
+// testConfig sets up an event sink of sinkType at url
+func testConfig(sinkType, url string) *config.Config {
+	cfg := config.GetDefaultConfig()
+	cfg.ModpackID = 925200
+	cfg.InstanceName = "survival"
+	cfg.EventSink = config.EventSinkConfig{Type: sinkType, URL: url, Subject: "mc.{event}", Events: []string{events.UpdateFinished, events.BackupCreated}}
+	return cfg
+}
+
+func TestSink(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		serve func(*testing.T, chan<- published) string
+		url   string
+	}{
+		{"redis", fakeRedis, "redis://%s"},
+		{"nats", fakeNATS, "nats://secret@%s"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			out := make(chan published, 4)
+			sink, err := NewFromConfig(testConfig(tt.name, fmt.Sprintf(tt.url, tt.serve(t, out))), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			bus := events.NewBus(nil)
+			unsubscribe := sink.Subscribe(bus)
+			bus.Publish(events.UpdateProgress, map[string]interface{}{"phase": "download"})
+			bus.Publish(events.UpdateFinished, map[string]interface{}{"to_version": "1.2.0"})
+			unsubscribe()
+			sink.Close()
+
+			// Events are published in order, so update_progress would have come first
+			var got published
+			select {
+			case got = <-out:
+			case <-time.After(5 * time.Second):
+				t.Fatal("nothing published")
+			}
+			msg := got.message
+			if got.subject != "mc.update_finished" || msg.Type != events.UpdateFinished || msg.Server != "survival" ||
+				msg.ModpackID != 925200 || msg.Data["to_version"] != "1.2.0" {
+				t.Errorf("published %s %+v", got.subject, msg)
+			}
+		})
+	}
+}
+
+func TestNATSAuthFailure(t *testing.T) {
+	p, err := newNATSPublisher("nats://wrong@" + fakeNATS(t, make(chan published, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := p.publish(t.Context(), "mc.test", []byte("{}")); !errors.Is(err, nats.ErrAuthorization) {
+		t.Errorf("publish with a wrong token = %v", err)
+	}
+}
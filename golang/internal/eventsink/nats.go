@@ -0,0 +1,54 @@
+package eventsink
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsPublisher publishes to NATS subjects. It connects on the first event, after which
+// the client reconnects on its own.
+type natsPublisher struct {
+	url  string
+	conn *nats.Conn
+}
+
+// newNATSPublisher takes a nats://[user:password@]host[:port] or nats://token@host URL,
+// or tls:// for TLS
+func newNATSPublisher(rawURL string) (*natsPublisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nats url: %w", err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("nats url must use nats or tls, got %q", u.Scheme)
+	}
+	return &natsPublisher{url: rawURL}, nil
+}
+
+func (p *natsPublisher) publish(ctx context.Context, subject string, payload []byte) error {
+	if p.conn == nil {
+		conn, err := nats.Connect(p.url, nats.Name("curseforge-autoupdater"), nats.MaxReconnects(-1))
+		if err != nil {
+			return fmt.Errorf("failed to connect to nats: %w", err)
+		}
+		p.conn = conn
+	}
+	if err := p.conn.Publish(subject, payload); err != nil {
+		return fmt.Errorf("failed to publish to nats: %w", err)
+	}
+	// The server answers the flush once it has the message, so errors show up here
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to publish to nats: %w", err)
+	}
+	return nil
+}
+
+func (p *natsPublisher) Close() error {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// fileBackend keeps the lock in a file that only exists while a daemon holds it. The file
+// is created with O_EXCL, which also works on NFS and SMB shares where flock does not.
+type fileBackend struct {
+	path string
+
+	// seenStale is called after an expired lock was read, for tests
+	seenStale func()
+}
+
+// fileLock is the content of the lock file
+type fileLock struct {
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+func (b *fileBackend) acquire(ctx context.Context, owner string, ttl time.Duration) (string, bool, error) {
+	data, err := json.Marshal(fileLock{Owner: owner, Expires: time.Now().Add(ttl)})
+	if err != nil {
+		return "", false, err
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(b.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644) // #nosec G304 -- path comes from the config
+		if err == nil {
+			_, err = f.Write(data)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				_ = os.Remove(b.path)
+				return "", false, fmt.Errorf("failed to write lock file: %w", err)
+			}
+			return owner, true, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", false, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		seen, err := b.snapshot()
+		if errors.Is(err, fs.ErrNotExist) {
+			// Released in the meantime
+			continue
+		}
+		if err != nil {
+			return "", false, err
+		}
+		var held fileLock
+		if err := json.Unmarshal(seen.data, &held); err == nil && time.Now().Before(held.Expires) {
+			return held.Owner, false, nil
+		} else if err != nil && time.Since(seen.modTime) < ttl {
+			// Unreadable: still being written, unless the holder died doing so
+			return "", false, nil
+		}
+		// Expired: the holder died without releasing it
+		if b.seenStale != nil {
+			b.seenStale()
+		}
+		if err := b.takeOver(seen, ttl); err != nil {
+			return "", false, err
+		}
+	}
+	return "", false, nil
+}
+
+func (b *fileBackend) renew(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	held, err := b.read()
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && held.Owner != owner) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	data, err := json.Marshal(fileLock{Owner: owner, Expires: time.Now().Add(ttl)})
+	if err != nil {
+		return false, err
+	}
+	// Replaced in one step so that the others never read a partial file
+	tmp := b.path + "." + owner
+	if err := os.WriteFile(tmp, data, 0o644); err != nil { // #nosec G306 -- holds no secrets
+		return false, fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		_ = os.Remove(tmp)
+		return false, fmt.Errorf("failed to replace lock file: %w", err)
+	}
+	return true, nil
+}
+
+func (b *fileBackend) release(ctx context.Context, owner string) error {
+	held, err := b.read()
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && held.Owner != owner) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(b.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
+
+// lockSnapshot is the lock file as one daemon saw it
+type lockSnapshot struct {
+	data    []byte
+	modTime time.Time
+}
+
+// snapshot reads the lock file with its modification time
+func (b *fileBackend) snapshot() (lockSnapshot, error) {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return lockSnapshot{}, err
+	}
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return lockSnapshot{}, err
+	}
+	return lockSnapshot{data: data, modTime: info.ModTime()}, nil
+}
+
+// takeOver removes the expired lock seen, unless another daemon is already removing it.
+// Daemons that saw the same expired lock would otherwise race: one removes it and creates
+// its own, then the other removes that fresh lock and both hold it. So only the daemon
+// that creates the claim file named after the expired lock removes it, and only while the
+// lock file is still what it saw. A claim left behind by a daemon that died taking over
+// is passed by once it is older than the ttl.
+func (b *fileBackend) takeOver(seen lockSnapshot, ttl time.Duration) error {
+	h := fnv.New64a()
+	h.Write(seen.data)
+	prefix := fmt.Sprintf("%s.takeover-%x-%d", b.path, h.Sum64(), seen.modTime.UnixNano())
+	var claims []string
+	defer func() {
+		for _, claim := range claims {
+			_ = os.Remove(claim)
+		}
+	}()
+	for n := 0; ; n++ {
+		claim := fmt.Sprintf("%s.%d", prefix, n)
+		f, err := os.OpenFile(claim, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644) // #nosec G304 -- next to the lock file from the config
+		if err == nil {
+			_ = f.Close()
+			claims = append(claims, claim)
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to claim stale lock file: %w", err)
+		}
+		if info, err := os.Stat(claim); err != nil || time.Since(info.ModTime()) < ttl {
+			// Another daemon is taking over
+			return nil
+		}
+		claims = append(claims, claim)
+	}
+
+	current, err := b.snapshot()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current.data, seen.data) || !current.modTime.Equal(seen.modTime) {
+		// Renewed, or already replaced by a daemon that took over before us
+		return nil
+	}
+	if err := os.Remove(b.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale lock file: %w", err)
+	}
+	return nil
+}
+
+// read returns the current content of the lock file
+func (b *fileBackend) read() (fileLock, error) {
+	var held fileLock
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return held, err
+	}
+	if err := json.Unmarshal(data, &held); err != nil {
+		return held, fmt.Errorf("failed to parse lock file %s: %w", b.path, err)
+	}
+	return held, nil
+}
@@ -0,0 +1,141 @@
+// Package fleet lets the daemons of many servers that share a schedule take turns: a lock
+// that only one of them holds at a time, kept in a file on a shared filesystem or in Redis
+package fleet
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// pollInterval is how often a daemon waiting for the lock tries again
+const pollInterval = 5 * time.Second
+
+// backend stores the lock. A lock expires ttl after it was taken or last renewed, so that
+// a daemon that died does not block the others forever.
+type backend interface {
+	// acquire takes the lock for owner, or returns who holds it
+	acquire(ctx context.Context, owner string, ttl time.Duration) (holder string, ok bool, err error)
+	// renew extends the lock of owner, reporting false when owner lost it
+	renew(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	// release frees the lock if owner holds it
+	release(ctx context.Context, owner string) error
+}
+
+// Lock is a lock shared by the daemons of a fleet
+type Lock struct {
+	backend backend
+	owner   string
+	ttl     time.Duration
+	poll    time.Duration
+	logger  *slog.Logger
+}
+
+// NewFromConfig creates the lock set up in cfg, or returns nil when no lock is configured
+func NewFromConfig(cfg config.FleetConfig, logger *slog.Logger) (*Lock, error) {
+	var b backend
+	switch cfg.Lock {
+	case "":
+		return nil, nil
+	case "file":
+		b = &fileBackend{path: cfg.LockFile}
+	case "redis":
+		r, err := newRedisBackend(cfg.RedisURL, cfg.LockKey)
+		if err != nil {
+			return nil, err
+		}
+		b = r
+	default:
+		return nil, fmt.Errorf("unknown fleet lock %q", cfg.Lock)
+	}
+	return newLock(b, cfg.LockTTL, logger), nil
+}
+
+// newLock creates a lock on b owned by this process
+func newLock(b backend, ttl time.Duration, logger *slog.Logger) *Lock {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Lock{backend: b, owner: owner(), ttl: ttl, poll: pollInterval, logger: logger}
+}
+
+// owner names this process to the other daemons, e.g. mc-01-1234-9f86d081
+func owner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// Lock waits until the lock is free and takes it, renewing it in the background until
+// the returned function releases it
+func (l *Lock) Lock(ctx context.Context) (func(), error) {
+	waiting := false
+	for {
+		holder, ok, err := l.backend.acquire(ctx, l.owner, l.ttl)
+		if err != nil {
+			// A connection cut short by ctx fails with its own error, at times just before
+			// ctx reports that it is done
+			if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+				<-ctx.Done()
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if !waiting {
+			l.logger.Info("waiting for the fleet lock", "holder", holder)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.poll):
+		}
+	}
+
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if ok, err := l.backend.renew(ctx, l.owner, l.ttl); err != nil {
+					l.logger.Warn("failed to renew the fleet lock", "error", err)
+				} else if !ok {
+					l.logger.Warn("lost the fleet lock, another daemon may run at the same time")
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-stopped
+			// Released even when ctx is done, so that the others need not wait for the ttl
+			if err := l.backend.release(context.WithoutCancel(ctx), l.owner); err != nil {
+				l.logger.Warn("failed to release the fleet lock", "error", err)
+			}
+		})
+	}, nil
+}
@@ -0,0 +1,188 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testLock creates a lock on b that polls quickly
+func testLock(b backend) *Lock {
+	l := newLock(b, time.Minute, nil)
+	l.poll = 10 * time.Millisecond
+	return l
+}
+
+// lockTurns takes a and then b, checking that b waits until a is released
+func lockTurns(t *testing.T, a, b *Lock) {
+	t.Helper()
+	unlockA, err := a.Lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	taken := make(chan func())
+	go func() {
+		unlock, err := b.Lock(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		taken <- unlock
+	}()
+	select {
+	case <-taken:
+		t.Fatal("second daemon took the lock while the first held it")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlockA()
+	select {
+	case unlockB := <-taken:
+		unlockB()
+	case <-time.After(time.Second):
+		t.Fatal("second daemon did not get the lock after it was released")
+	}
+
+	// Waiting ends with the context
+	unlockA, _ = a.Lock(context.Background())
+	defer unlockA()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := b.Lock(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Lock with a held lock = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.lock")
+	lockTurns(t, testLock(&fileBackend{path: path}), testLock(&fileBackend{path: path}))
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestFileLockTakesOverStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.lock")
+	data, _ := json.Marshal(fileLock{Owner: "crashed", Expires: time.Now().Add(-time.Minute)})
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &fileBackend{path: path}
+	if holder, ok, err := b.acquire(context.Background(), "me", time.Minute); err != nil || !ok || holder != "me" {
+		t.Fatalf("acquire over an expired lock = %q, %v, %v", holder, ok, err)
+	}
+	if ok, err := b.renew(context.Background(), "other", time.Minute); err != nil || ok {
+		t.Errorf("renew by another owner = %v, %v; want false", ok, err)
+	}
+	if ok, err := b.renew(context.Background(), "me", time.Minute); err != nil || !ok {
+		t.Errorf("renew = %v, %v; want true", ok, err)
+	}
+	if err := b.release(context.Background(), "other"); err != nil {
+		t.Fatal(err)
+	}
+	if held, err := b.read(); err != nil || held.Owner != "me" {
+		t.Errorf("lock after release by another owner = %+v, %v", held, err)
+	}
+}
+
+func TestFileLockStaleTakeoverIsExclusive(t *testing.T) {
+	dir := t.TempDir()
+	for round := 0; round < 50; round++ {
+		path := filepath.Join(dir, fmt.Sprintf("fleet-%d.lock", round))
+		data, _ := json.Marshal(fileLock{Owner: "crashed", Expires: time.Now().Add(-time.Minute)})
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		const daemons = 8
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var holders []string
+		start := make(chan struct{})
+		for i := 0; i < daemons; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				owner := fmt.Sprintf("daemon-%d", i)
+				<-start
+				// Gives the others time to read the expired lock as well
+				b := &fileBackend{path: path, seenStale: func() { time.Sleep(5 * time.Millisecond) }}
+				_, ok, err := b.acquire(context.Background(), owner, time.Minute)
+				if err != nil {
+					t.Error(err)
+				}
+				if ok {
+					mu.Lock()
+					holders = append(holders, owner)
+					mu.Unlock()
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		if len(holders) != 1 {
+			t.Fatalf("round %d: %d daemons took the expired lock: %v", round, len(holders), holders)
+		}
+		if held, err := (&fileBackend{path: path}).read(); err != nil || held.Owner != holders[0] {
+			t.Fatalf("round %d: lock file = %+v, %v; want it held by %s", round, held, err, holders[0])
+		}
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "*.takeover-*")); len(leftovers) != 0 {
+		t.Errorf("claim files left behind: %v", leftovers)
+	}
+}
+
+func TestRedisLock(t *testing.T) {
+	m := miniredis.RunT(t)
+	m.RequireAuth("secret")
+
+	newBackend := func(password string) *redisBackend {
+		b, err := newRedisBackend(fmt.Sprintf("redis://:%s@%s/2", password, m.Addr()), "cfu")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	lockTurns(t, testLock(newBackend("secret")), testLock(newBackend("secret")))
+
+	b := newBackend("secret")
+	if _, ok, err := b.acquire(context.Background(), "me", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire = %v, %v", ok, err)
+	}
+	if holder, err := m.DB(2).Get("cfu"); err != nil || holder != "me" || m.DB(2).TTL("cfu") != time.Minute {
+		t.Errorf("key in database 2 = %q, ttl %s, %v", holder, m.DB(2).TTL("cfu"), err)
+	}
+	if ok, err := b.renew(context.Background(), "me", 2*time.Minute); err != nil || !ok || m.DB(2).TTL("cfu") != 2*time.Minute {
+		t.Errorf("renew = %v, %v, ttl %s; want true", ok, err, m.DB(2).TTL("cfu"))
+	}
+	if holder, ok, err := b.acquire(context.Background(), "other", time.Minute); err != nil || ok || holder != "me" {
+		t.Errorf("acquire of a held lock = %q, %v, %v; want the holder", holder, ok, err)
+	}
+	if err := b.release(context.Background(), "other"); err != nil || !m.DB(2).Exists("cfu") {
+		t.Errorf("release by another owner = %v, or it removed the lock", err)
+	}
+
+	// An expired lock goes to the next daemon
+	m.FastForward(3 * time.Minute)
+	if _, ok, err := b.acquire(context.Background(), "other", time.Minute); err != nil || !ok {
+		t.Errorf("acquire of an expired lock = %v, %v", ok, err)
+	}
+	if ok, err := b.renew(context.Background(), "me", time.Minute); err != nil || ok {
+		t.Errorf("renew of a lost lock = %v, %v; want false", ok, err)
+	}
+
+	_, _, err := newBackend("wrong").acquire(context.Background(), "me", time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("acquire with a wrong password = %v", err)
+	}
+}
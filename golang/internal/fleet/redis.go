@@ -0,0 +1,63 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Scripts that change the lock only while owner (ARGV[1]) still holds it
+var (
+	renewScript   = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`)
+	releaseScript = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`)
+)
+
+// redisBackend keeps the lock in a Redis key holding the owner, which expires on its own
+type redisBackend struct {
+	client *redis.Client
+	key    string
+}
+
+// newRedisBackend keeps the lock in key on the server at rawURL, a
+// redis://[user:password@]host[:port][/db] URL or rediss:// for TLS
+func newRedisBackend(rawURL, key string) (*redisBackend, error) {
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+	return &redisBackend{client: redis.NewClient(opts), key: key}, nil
+}
+
+func (b *redisBackend) acquire(ctx context.Context, owner string, ttl time.Duration) (string, bool, error) {
+	ok, err := b.client.SetNX(ctx, b.key, owner, ttl).Result()
+	if err != nil {
+		return "", false, fmt.Errorf("redis SET failed: %w", err)
+	}
+	if ok {
+		return owner, true, nil
+	}
+	// Held by another daemon, unless it expired in the meantime
+	holder, err := b.client.Get(ctx, b.key).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", false, fmt.Errorf("redis GET failed: %w", err)
+	}
+	return holder, false, nil
+}
+
+func (b *redisBackend) renew(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	n, err := renewScript.Run(ctx, b.client, []string{b.key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("redis renew failed: %w", err)
+	}
+	return n == 1, nil
+}
+
+func (b *redisBackend) release(ctx context.Context, owner string) error {
+	if err := releaseScript.Run(ctx, b.client, []string{b.key}, owner).Err(); err != nil {
+		return fmt.Errorf("redis release failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package health
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+// Check and report statuses, from best to worst
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// Thresholds for the disk space check
+const (
+	lowDiskSpace      = 1 << 30   // 1 GiB
+	criticalDiskSpace = 100 << 20 // 100 MiB
+)
+
+// apiCacheTTL limits how often health probes hit the CurseForge API
+const apiCacheTTL = time.Minute
+
+// Check is the result of a single health check
+type Check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Report is the combined health of the updater
+type Report struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+	Checks    []Check   `json:"checks"`
+}
+
+// Healthy reports whether the service can serve traffic (ok or degraded)
+func (r *Report) Healthy() bool {
+	return r.Status != StatusFail
+}
+
+// Checker gathers health information from the config, API, state and server
+type Checker struct {
+	cfg    *config.Config
+	client *api.Client
+	store  *state.Store
+	clock  clock.Clock
+	dial   func(address string) error
+	disk   func(path string) (*filesystem.DiskSpace, error)
+
+	mu        sync.Mutex
+	apiErr    error
+	apiTested time.Time
+}
+
+// NewChecker creates a checker for the given configuration
+func NewChecker(cfg *config.Config) *Checker {
+	return &Checker{
+		cfg:    cfg,
+		client: api.NewClientFromConfig(cfg),
+		store:  state.NewStore(filepath.Join(cfg.DataDir, state.FileName)),
+		clock:  clock.Real(),
+		dial: func(address string) error {
+			conn, err := net.DialTimeout("tcp", address, time.Second)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+		disk: filesystem.GetDiskSpace,
+	}
+}
+
+// SetClock replaces the clock used for timestamps and staleness checks
+func (c *Checker) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+// Run performs all checks; the report status is the worst check status
+func (c *Checker) Run() *Report {
+	report := &Report{Status: StatusOK, Timestamp: c.clock.Now()}
+	for _, check := range []Check{
+		c.checkConfig(),
+		c.checkAPI(),
+		c.checkLastCheck(),
+		c.checkServer(),
+		c.checkDisk(),
+	} {
+		report.Checks = append(report.Checks, check)
+		report.Status = worst(report.Status, check.Status)
+	}
+	return report
+}
+
+// worst returns the more severe of two statuses
+func worst(a, b string) string {
+	rank := map[string]int{StatusOK: 0, StatusDegraded: 1, StatusFail: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// checkConfig verifies that the loaded config, from a file or the environment, is valid
+func (c *Checker) checkConfig() Check {
+	if err := config.Validate(c.cfg); err != nil {
+		return Check{Name: "config", Status: StatusFail, Message: err.Error()}
+	}
+	source := c.cfg.File
+	if source == "" {
+		source = "environment"
+	}
+	return Check{Name: "config", Status: StatusOK, Message: "loaded from " + source}
+}
+
+// checkAPI verifies that the CurseForge API accepts the key; results are cached
+func (c *Checker) checkAPI() Check {
+	if !c.cfg.HasAPIAccess() {
+		return Check{Name: "api", Status: StatusFail, Message: "api_key is not set"}
+	}
+
+	c.mu.Lock()
+	now := c.clock.Now()
+	if c.apiTested.IsZero() || now.Sub(c.apiTested) >= apiCacheTTL {
+		c.apiErr = c.client.ValidateAPIKey()
+		c.apiTested = now
+	}
+	err := c.apiErr
+	c.mu.Unlock()
+
+	if err != nil {
+		// An API outage does not stop the web UI from working
+		return Check{Name: "api", Status: StatusDegraded, Message: err.Error()}
+	}
+	return Check{Name: "api", Status: StatusOK, Message: "CurseForge API reachable"}
+}
+
+// checkLastCheck reports when updates were last checked for
+func (c *Checker) checkLastCheck() Check {
+	st, err := c.store.Load()
+	if err != nil {
+		return Check{Name: "last_check", Status: StatusDegraded, Message: err.Error()}
+	}
+	if st.LastCheckAt.IsZero() {
+		return Check{Name: "last_check", Status: StatusDegraded, Message: "no update check recorded yet"}
+	}
+
+	age := c.clock.Now().Sub(st.LastCheckAt)
+	msg := fmt.Sprintf("%s (%s ago)", st.LastCheckAt.Format(time.RFC3339), age.Round(time.Second))
+	if c.cfg.CheckInterval > 0 && age > 2*c.cfg.CheckInterval {
+		return Check{Name: "last_check", Status: StatusDegraded, Message: msg + ", overdue"}
+	}
+	return Check{Name: "last_check", Status: StatusOK, Message: msg}
+}
+
+// checkServer reports whether the Minecraft server accepts connections on its port
+func (c *Checker) checkServer() Check {
+	if !filesystem.DirExists(c.cfg.ServerPath) {
+		return Check{Name: "server", Status: StatusDegraded, Message: "server directory not found: " + c.cfg.ServerPath}
+	}
+
+	port := server.ServerPort(c.cfg.ServerPath)
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	if err := c.dial(address); err != nil {
+		return Check{Name: "server", Status: StatusDegraded, Message: "stopped: nothing listening on " + address}
+	}
+	return Check{Name: "server", Status: StatusOK, Message: "running on " + address}
+}
+
+// checkDisk reports the free space where backups are written
+func (c *Checker) checkDisk() Check {
+	space, err := c.disk(c.cfg.BackupPath)
+	if err != nil {
+		return Check{Name: "disk", Status: StatusDegraded, Message: err.Error()}
+	}
+
+	msg := fmt.Sprintf("%s free of %s in %s", filesystem.FormatBytes(int64(space.Free)), filesystem.FormatBytes(int64(space.Total)), c.cfg.BackupPath)
+	switch {
+	case space.Free < criticalDiskSpace:
+		return Check{Name: "disk", Status: StatusFail, Message: msg}
+	case space.Free < lowDiskSpace:
+		return Check{Name: "disk", Status: StatusDegraded, Message: msg}
+	}
+	return Check{Name: "disk", Status: StatusOK, Message: msg}
+}
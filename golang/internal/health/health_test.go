@@ -0,0 +1,80 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+func TestCheckerRun(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		apiKey      string
+		apiStatus   int
+		lastCheck   time.Time
+		serverUp    bool
+		freeSpace   uint64
+		wantStatus  string
+		wantHealthy bool
+	}{
+		{"all good", "key", http.StatusOK, now.Add(-time.Minute), true, 50 << 30, StatusOK, true},
+		{"server stopped", "key", http.StatusOK, now.Add(-time.Minute), false, 50 << 30, StatusDegraded, true},
+		{"check overdue", "key", http.StatusOK, now.Add(-3 * time.Hour), true, 50 << 30, StatusDegraded, true},
+		{"api key rejected", "key", http.StatusForbidden, now.Add(-time.Minute), true, 50 << 30, StatusDegraded, true},
+		{"low disk space", "key", http.StatusOK, now.Add(-time.Minute), true, 500 << 20, StatusDegraded, true},
+		{"disk full", "key", http.StatusOK, now.Add(-time.Minute), true, 10 << 20, StatusFail, false},
+		{"invalid config", "", http.StatusOK, now.Add(-time.Minute), true, 50 << 30, StatusFail, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.apiStatus)
+			}))
+			defer api.Close()
+
+			dir := t.TempDir()
+			cfg := config.GetDefaultConfig()
+			cfg.APIKey = tt.apiKey
+			cfg.ModpackID = 1
+			cfg.ServerPath = dir
+			cfg.BackupPath = dir
+			cfg.DataDir = dir
+
+			store := state.NewStore(filepath.Join(dir, state.FileName))
+			if err := store.Save(&state.State{LastCheckAt: tt.lastCheck}); err != nil {
+				t.Fatal(err)
+			}
+
+			c := NewChecker(cfg)
+			c.SetClock(clock.NewFake(now))
+			c.client.BaseURL = api.URL
+			c.dial = func(string) error {
+				if tt.serverUp {
+					return nil
+				}
+				return errors.New("connection refused")
+			}
+			c.disk = func(string) (*filesystem.DiskSpace, error) {
+				return &filesystem.DiskSpace{Total: 100 << 30, Free: tt.freeSpace}, nil
+			}
+
+			report := c.Run()
+			if report.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s: %+v", report.Status, tt.wantStatus, report.Checks)
+			}
+			if report.Healthy() != tt.wantHealthy {
+				t.Errorf("Healthy() = %t, want %t", report.Healthy(), tt.wantHealthy)
+			}
+		})
+	}
+}
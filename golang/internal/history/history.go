@@ -0,0 +1,152 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+)
+
+// FileName is the default name of the history file inside the data directory
+const FileName = "history.jsonl"
+
+// Results recorded for an update attempt
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+	ResultSkipped = "skipped"
+)
+
+// Entry records one update attempt
+type Entry struct {
+	Timestamp   time.Time     `json:"timestamp"`
+	ModID       int           `json:"mod_id"`
+	FromFileID  int           `json:"from_file_id"`
+	FromVersion string        `json:"from_version"`
+	ToFileID    int           `json:"to_file_id"`
+	ToVersion   string        `json:"to_version"`
+	Result      string        `json:"result"`
+	Duration    time.Duration `json:"duration_ns"`
+	Backup      string        `json:"backup,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Mods        []ModChange   `json:"mods,omitempty"`
+}
+
+// ModChange is a mod that a modpack update added, removed or moved to another file
+type ModChange struct {
+	ProjectID   int    `json:"project_id"`
+	Name        string `json:"name"`
+	FromVersion string `json:"from_version,omitempty"` // empty when the mod was added
+	ToVersion   string `json:"to_version,omitempty"`   // empty when the mod was removed
+	Changelog   string `json:"changelog,omitempty"`    // excerpt of the new file's changelog
+}
+
+// Filter selects history entries; zero values match everything
+type Filter struct {
+	Result string
+	Limit  int
+}
+
+// Log is an append-only JSON lines file of update attempts
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLog creates a history log backed by the given file
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the location of the history file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append adds an entry to the end of the log
+func (l *Log) Append(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	if err := filesystem.EnsureDir(filepath.Dir(l.path)); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	// #nosec G304 -- path comes from configuration
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file %s: %w", l.path, err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	return f.Close()
+}
+
+// List returns matching entries, newest first; a missing file yields no entries
+func (l *Log) List(filter Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// #nosec G304 -- path comes from configuration
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, nil
+		}
+		return nil, fmt.Errorf("failed to open history file %s: %w", l.path, err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse history file %s line %d: %w", l.path, line, err)
+		}
+		if filter.Result != "" && entry.Result != filter.Result {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file %s: %w", l.path, err)
+	}
+
+	// Reverse so the newest attempt comes first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return entries, nil
+}
+
+// ValidResult reports whether result is a known result name
+func ValidResult(result string) bool {
+	switch result {
+	case ResultSuccess, ResultFailed, ResultSkipped:
+		return true
+	default:
+		return false
+	}
+}
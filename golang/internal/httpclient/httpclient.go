@@ -0,0 +1,106 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// warnInsecure makes sure the skip-verify warning is logged once per process
+var warnInsecure sync.Once
+
+// New returns a client for API calls and notifications; cfg.Timeout bounds the whole request
+func New(cfg config.HTTPConfig) *http.Client {
+	return &http.Client{
+		Transport: Transport(cfg),
+		Timeout:   cfg.Timeout,
+	}
+}
+
+// NewDownload returns a client for large downloads. The body may take as long as it
+// needs; cfg.Timeout only bounds the wait for the response headers.
+func NewDownload(cfg config.HTTPConfig) *http.Client {
+	return &http.Client{
+		Transport: Transport(cfg),
+	}
+}
+
+// Transport builds a transport with the configured proxy, CA bundle and timeouts. When the
+// settings cannot be applied every request fails with the reason instead of silently
+// falling back to a direct or less verified connection.
+func Transport(cfg config.HTTPConfig) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return failingTransport{fmt.Errorf("invalid http proxy_url: %w", err)}
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if cfg.ConnectTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: cfg.ConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = cfg.ConnectTimeout
+	}
+	if cfg.Timeout > 0 {
+		transport.ResponseHeaderTimeout = cfg.Timeout
+	}
+
+	if cfg.CAFile != "" || cfg.InsecureSkipVerify {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if cfg.CAFile != "" {
+			pool, err := loadCertPool(cfg.CAFile)
+			if err != nil {
+				return failingTransport{err}
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if cfg.InsecureSkipVerify {
+			warnInsecure.Do(func() {
+				slog.Warn("TLS CERTIFICATE VERIFICATION IS DISABLED (http.insecure_skip_verify); connections can be intercepted, only use this for testing")
+			})
+			tlsConfig.InsecureSkipVerify = true //nolint:gosec // explicitly requested in the config
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return transport
+}
+
+// loadCertPool returns the system roots plus the certificates in file
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read http ca_file: %w", err)
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("http ca_file %s contains no PEM certificates", file)
+	}
+	return pool, nil
+}
+
+// failingTransport fails every request with err
+type failingTransport struct {
+	err error
+}
+
+func (t failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, t.err
+}
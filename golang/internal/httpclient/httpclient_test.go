@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func TestProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_, _ = io.WriteString(w, "via proxy")
+	}))
+	defer proxy.Close()
+
+	client := New(config.HTTPConfig{ProxyURL: proxy.URL, Timeout: 5 * time.Second})
+	resp, err := client.Get("http://api.example.invalid/v1/mods/1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+	if proxied != "http://api.example.invalid/v1/mods/1" {
+		t.Fatalf("proxy saw %q", proxied)
+	}
+}
+
+func TestCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	if _, err := New(config.HTTPConfig{}).Get(srv.URL); err == nil {
+		t.Fatal("self-signed server accepted without ca_file")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := New(config.HTTPConfig{CAFile: caFile}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get with ca_file: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = New(config.HTTPConfig{InsecureSkipVerify: true}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get with insecure_skip_verify: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestInvalidCAFileFailsRequests(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := New(config.HTTPConfig{CAFile: caFile}).Get("https://api.curseforge.com/v1")
+	if err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+		t.Fatalf("err = %v, want the ca_file error", err)
+	}
+}
@@ -0,0 +1,119 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// buckets holds one token bucket per rate, so that every download client built from the
+// same config shares the limit instead of each getting it in full
+var (
+	bucketsMu sync.Mutex
+	buckets   = map[int64]*bucket{}
+)
+
+// ForDownloads returns NewDownload(cfg.HTTP) limited to cfg.Download.max_rate. All clients
+// with the same limit share it, including downloads that run at the same time.
+func ForDownloads(cfg *config.Config) *http.Client {
+	client := NewDownload(cfg.HTTP)
+	rate := cfg.Download.MaxRateBytes()
+	if rate <= 0 {
+		return client
+	}
+	bucketsMu.Lock()
+	b, ok := buckets[rate]
+	if !ok {
+		b = newBucket(rate, clock.Real())
+		buckets[rate] = b
+	}
+	bucketsMu.Unlock()
+	client.Transport = &limitedTransport{next: client.Transport, bucket: b}
+	return client
+}
+
+// bucket is a token bucket that refills at rate bytes per second and holds at most one
+// second worth of tokens
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+	clock  clock.Clock
+}
+
+// newBucket creates a full bucket for rate bytes per second
+func newBucket(rate int64, c clock.Clock) *bucket {
+	return &bucket{rate: float64(rate), tokens: float64(rate), last: c.Now(), clock: c}
+}
+
+// take removes n tokens and sleeps until the bucket is no longer in debt. Tokens are taken
+// for data already read, so a read never waits for more than its own size allows.
+func (b *bucket) take(n int) {
+	b.mu.Lock()
+	now := b.clock.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	debt := b.tokens
+	b.mu.Unlock()
+
+	if debt < 0 {
+		b.clock.Sleep(time.Duration(-debt / b.rate * float64(time.Second)))
+	}
+}
+
+// maxChunk bounds single reads so that the rate stays even at low limits
+func (b *bucket) maxChunk() int {
+	return max(1, min(32<<10, int(b.rate/10)))
+}
+
+// reader wraps r so that reading from it takes tokens from b
+func (b *bucket) reader(r io.Reader) io.Reader {
+	return &limitedReader{r: r, bucket: b}
+}
+
+// limitedReader reads from r no faster than its bucket allows
+type limitedReader struct {
+	r      io.Reader
+	bucket *bucket
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if chunk := l.bucket.maxChunk(); len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := l.r.Read(p)
+	if n > 0 {
+		l.bucket.take(n)
+	}
+	return n, err
+}
+
+// limitedTransport limits the response bodies of next to the rate of bucket
+type limitedTransport struct {
+	next   http.RoundTripper
+	bucket *bucket
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = limitedBody{Reader: t.bucket.reader(resp.Body), Closer: resp.Body}
+	return resp, nil
+}
+
+// limitedBody is a rate-limited response body that still closes the original one
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
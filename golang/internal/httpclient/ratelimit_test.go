@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// steppingClock advances its time by every Sleep instead of blocking
+type steppingClock struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func (c *steppingClock) Now() time.Time { return c.now }
+
+func (c *steppingClock) After(d time.Duration) <-chan time.Time {
+	c.Sleep(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *steppingClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.slept += d
+}
+
+func TestBucketLimitsRate(t *testing.T) {
+	clk := &steppingClock{now: time.Now()}
+	b := newBucket(1000, clk)
+
+	data := bytes.Repeat([]byte("x"), 5000)
+	got, err := io.ReadAll(b.reader(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("data changed while limiting")
+	}
+	// The first second worth of data is in the bucket already
+	if clk.slept < 3900*time.Millisecond || clk.slept > 4100*time.Millisecond {
+		t.Errorf("slept %s reading 5000 bytes at 1000 B/s, want about 4s", clk.slept)
+	}
+}
+
+func TestForDownloads(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Repeat("y", 100))
+	}))
+	defer srv.Close()
+
+	unlimited := ForDownloads(&config.Config{})
+	if _, ok := unlimited.Transport.(*limitedTransport); ok {
+		t.Error("no max_rate should not limit downloads")
+	}
+
+	cfg := &config.Config{Download: config.DownloadConfig{MaxRate: "10MB/s"}}
+	client := ForDownloads(cfg)
+	transport, ok := client.Transport.(*limitedTransport)
+	if !ok {
+		t.Fatal("max_rate should limit downloads")
+	}
+	if other := ForDownloads(cfg).Transport.(*limitedTransport); other.bucket != transport.bucket {
+		t.Error("clients with the same max_rate should share a bucket")
+	}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || len(body) != 100 {
+		t.Errorf("read %d bytes, %v", len(body), err)
+	}
+}
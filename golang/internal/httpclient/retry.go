@@ -0,0 +1,219 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// ErrCircuitOpen is returned for requests to an endpoint that failed too often in a row
+// and is skipped until its cooldown ends
+var ErrCircuitOpen = errors.New("endpoint failed repeatedly, skipped until its cooldown ends")
+
+// EndpointStats counts the requests to one host
+type EndpointStats struct {
+	Host      string    `json:"host"`
+	Requests  int64     `json:"requests"` // requests made by callers, not counting retries
+	Retries   int64     `json:"retries"`
+	Failures  int64     `json:"failures"` // requests that failed after all their tries
+	Skipped   int64     `json:"skipped"`  // requests refused while the circuit was open
+	Open      bool      `json:"open"`
+	OpenUntil time.Time `json:"open_until,omitempty"`
+}
+
+// endpoint is the circuit and the counters of one host
+type endpoint struct {
+	stats    EndpointStats
+	failures int  // failed requests in a row
+	probing  bool // a request is testing whether the host recovered
+}
+
+// Retrier retries failed requests with exponential backoff and opens a circuit per host
+// after too many failures in a row. Its state outlives the clients it wraps, so that a
+// config reload does not forget an endpoint that is down.
+type Retrier struct {
+	mu        sync.Mutex
+	cfg       config.RetryConfig
+	endpoints map[string]*endpoint
+	clock     clock.Clock
+}
+
+// NewRetrier creates a Retrier with the given policy
+func NewRetrier(cfg config.RetryConfig) *Retrier {
+	return &Retrier{cfg: cfg, endpoints: map[string]*endpoint{}, clock: clock.Real()}
+}
+
+// SetPolicy changes the policy; the circuits and counters are kept
+func (r *Retrier) SetPolicy(cfg config.RetryConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cfg = cfg
+}
+
+// Client returns a copy of client whose requests go through r; nil stands for a plain
+// client. A client that already goes through r is returned as it is.
+func (r *Retrier) Client(client *http.Client) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+	if t, ok := client.Transport.(*retryTransport); ok && t.retrier == r {
+		return client
+	}
+	wrapped := *client
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	wrapped.Transport = &retryTransport{next: next, retrier: r}
+	return &wrapped
+}
+
+// Stats returns the counters of every host requested so far, sorted by host
+func (r *Retrier) Stats() []EndpointStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make([]EndpointStats, 0, len(r.endpoints))
+	for _, e := range r.endpoints {
+		s := e.stats
+		s.Open = !s.OpenUntil.IsZero()
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Host < stats[j].Host })
+	return stats
+}
+
+// admit counts a request to host and reports whether it may be sent. Once the cooldown
+// of an open circuit has passed, a single request is let through to probe the host.
+func (r *Retrier) admit(host string) (config.RetryConfig, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.endpoints[host]
+	if !ok {
+		e = &endpoint{stats: EndpointStats{Host: host}}
+		r.endpoints[host] = e
+	}
+	e.stats.Requests++
+	if !e.stats.OpenUntil.IsZero() {
+		if e.probing || r.clock.Now().Before(e.stats.OpenUntil) {
+			e.stats.Skipped++
+			return r.cfg, false
+		}
+		e.probing = true
+	}
+	return r.cfg, true
+}
+
+// retried counts a retry to host
+func (r *Retrier) retried(host string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoints[host].stats.Retries++
+}
+
+// done records the outcome of a request to host and opens or closes its circuit
+func (r *Retrier) done(host string, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := r.endpoints[host]
+	e.probing = false
+	if !failed {
+		if !e.stats.OpenUntil.IsZero() {
+			slog.Info("notification endpoint recovered", "host", host)
+		}
+		e.failures = 0
+		e.stats.OpenUntil = time.Time{}
+		return
+	}
+	e.stats.Failures++
+	e.failures++
+	if r.cfg.BreakerFailures > 0 && e.failures >= r.cfg.BreakerFailures {
+		e.stats.OpenUntil = r.clock.Now().Add(r.cfg.BreakerCooldown)
+		slog.Warn("notification endpoint keeps failing, skipping it", "host", host, "failures", e.failures, "cooldown", r.cfg.BreakerCooldown)
+	}
+}
+
+// retryTransport sends requests through next under the policy of retrier
+type retryTransport struct {
+	next    http.RoundTripper
+	retrier *Retrier
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	cfg, ok := t.retrier.admit(host)
+	if !ok {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+	}
+
+	attempts := max(1, cfg.Attempts)
+	// A body can only be sent again when the request can recreate it
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		attempts = 1
+	}
+	wait := cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			body, err := req.GetBody()
+			if err != nil {
+				t.retrier.done(host, true)
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		if !retryable(resp, err) || attempt >= attempts || req.Context().Err() != nil {
+			t.retrier.done(host, err != nil || retryable(resp, nil))
+			return resp, err
+		}
+
+		delay := wait
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				delay = after
+			}
+			resp.Body.Close()
+		}
+		if cfg.MaxBackoff > 0 {
+			delay = min(delay, cfg.MaxBackoff)
+		}
+		t.retrier.retried(host)
+		select {
+		case <-t.retrier.clock.After(delay):
+		case <-req.Context().Done():
+			t.retrier.done(host, true)
+			return nil, req.Context().Err()
+		}
+		wait *= 2
+	}
+}
+
+// retryable reports whether a request that ended with resp or err may succeed when it
+// is sent again: connection errors, rate limits and server errors
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// retryAfter returns the wait in seconds asked for by the Retry-After header of resp
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
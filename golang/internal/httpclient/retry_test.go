@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func TestRetrierRetries(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	clk := &steppingClock{now: time.Now()}
+	r := NewRetrier(config.RetryConfig{Attempts: 3, Backoff: time.Second, MaxBackoff: time.Minute})
+	r.clock = clk
+	resp, err := r.Client(nil).Post(srv.URL, "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status %d after retries", resp.StatusCode)
+	}
+	if len(bodies) != 3 || bodies[2] != "hello" {
+		t.Errorf("server got %q, want the body three times", bodies)
+	}
+	if clk.slept != 3*time.Second {
+		t.Errorf("waited %s, want 1s + 2s", clk.slept)
+	}
+	stats := r.Stats()
+	if len(stats) != 1 || stats[0].Requests != 1 || stats[0].Retries != 2 || stats[0].Failures != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestRetrierOpensCircuit(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	clk := &steppingClock{now: time.Now()}
+	r := NewRetrier(config.RetryConfig{Attempts: 1, BreakerFailures: 2, BreakerCooldown: time.Minute})
+	r.clock = clk
+	client := r.Client(nil)
+	get := func() error {
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	for range 2 {
+		if err := get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("third request: %v, want ErrCircuitOpen", err)
+	}
+	if hits != 2 {
+		t.Errorf("server hit %d times while the circuit was open", hits)
+	}
+
+	// After the cooldown one request probes the endpoint again
+	clk.now = clk.now.Add(time.Minute)
+	if err := get(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if hits != 3 {
+		t.Errorf("server hit %d times, want the probe to go through", hits)
+	}
+	stats := r.Stats()
+	if !stats[0].Open || stats[0].Skipped != 1 || stats[0].Failures != 3 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if r.Client(client) != client {
+		t.Error("a client of the retrier was wrapped again")
+	}
+}
@@ -0,0 +1,382 @@
+// Package jobs runs long operations started from the web UI and the REST API, such as
+// updates, backups and restores, one at a time in the background. Their state, progress
+// and log are kept in a JSON file in the data directory, so that they can still be
+// looked up after a restart.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+)
+
+// FileName is the default name of the jobs file inside the data directory
+const FileName = "jobs.json"
+
+// States of a job
+const (
+	StateQueued    = "queued"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCanceled  = "canceled"
+)
+
+const (
+	// maxLogLines is how many log lines a job keeps; older lines are dropped
+	maxLogLines = 500
+	// maxFinished is how many finished jobs are kept
+	maxFinished = 50
+	// logSaveInterval is how often log lines of a running job are saved at most; state
+	// changes are saved at once
+	logSaveInterval = 2 * time.Second
+)
+
+var (
+	// ErrNotFound is returned for an unknown job ID
+	ErrNotFound = errors.New("no such job")
+	// ErrFinished is returned when canceling a job that has already finished
+	ErrFinished = errors.New("job has already finished")
+)
+
+// Job is one operation run by a Queue
+type Job struct {
+	ID       string          `json:"id"`
+	Kind     string          `json:"kind"` // update, backup or restore
+	Target   string          `json:"target,omitempty"`
+	Actor    string          `json:"actor,omitempty"`
+	State    string          `json:"state"`
+	Phase    string          `json:"phase,omitempty"`
+	Progress int             `json:"progress"` // 0 to 100
+	Created  time.Time       `json:"created"`
+	Started  *time.Time      `json:"started,omitempty"`
+	Finished *time.Time      `json:"finished,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+	Log      []string        `json:"log"`
+}
+
+// Done reports whether the job has finished, one way or another
+func (j *Job) Done() bool {
+	return j.State != StateQueued && j.State != StateRunning
+}
+
+// clone returns a copy of j that does not share its log
+func (j *Job) clone() Job {
+	c := *j
+	c.Log = append([]string{}, j.Log...)
+	if j.Started != nil {
+		started := *j.Started
+		c.Started = &started
+	}
+	if j.Finished != nil {
+		finished := *j.Finished
+		c.Finished = &finished
+	}
+	return c
+}
+
+// Func runs a job. It should stop when ctx is canceled, which is how a running job is
+// canceled, and returns the result to store with the job.
+type Func func(ctx context.Context, run *Run) (interface{}, error)
+
+// Queue runs jobs one at a time, oldest first
+type Queue struct {
+	path string
+
+	mu      sync.Mutex
+	jobs    []*Job // newest first
+	funcs   map[string]Func
+	cancels map[string]context.CancelFunc
+	wake    chan struct{}
+	saved   time.Time // when the jobs file was last written
+	redact  func(string) string
+}
+
+// Open loads the jobs kept in path. Jobs that were queued or running when the process
+// stopped are marked failed, as they cannot be resumed.
+func Open(path string) (*Queue, error) {
+	jobs, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	q := &Queue{
+		path:    path,
+		funcs:   make(map[string]Func),
+		cancels: make(map[string]context.CancelFunc),
+		wake:    make(chan struct{}, 1),
+		redact:  func(s string) string { return s },
+	}
+	interrupted := false
+	for i := range jobs {
+		job := &jobs[i]
+		if !job.Done() {
+			job.State = StateFailed
+			job.Error = "interrupted by a restart"
+			job.Finished = now()
+			interrupted = true
+		}
+		q.jobs = append(q.jobs, job)
+	}
+	if interrupted {
+		if err := q.save(); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// SetRedact sets the function log lines and errors of jobs pass through before they are
+// stored, so that secrets such as webhook URLs are not kept or served with them
+func (q *Queue) SetRedact(redact func(string) string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.redact = redact
+}
+
+// Load reads the jobs kept in path, newest first; a missing file yields no jobs
+func Load(path string) ([]Job, error) {
+	// #nosec G304 -- path comes from configuration
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Job{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jobs file %s: %w", path, err)
+	}
+	jobs := []Job{}
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse jobs file %s: %w", path, err)
+	}
+	return jobs, nil
+}
+
+// Find returns the job with id from jobs, which may also be a unique prefix of it
+func Find(jobs []Job, id string) (Job, error) {
+	var found []Job
+	for _, job := range jobs {
+		if job.ID == id {
+			return job, nil
+		}
+		if len(id) >= 4 && len(job.ID) > len(id) && job.ID[:len(id)] == id {
+			found = append(found, job)
+		}
+	}
+	if len(found) == 1 {
+		return found[0], nil
+	}
+	return Job{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// Submit queues fn as a job of kind on target, started by actor, and returns it
+func (q *Queue) Submit(kind, target, actor string, fn Func) Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job := &Job{
+		ID:      newID(),
+		Kind:    kind,
+		Target:  target,
+		Actor:   actor,
+		State:   StateQueued,
+		Created: time.Now(),
+		Log:     []string{},
+	}
+	q.jobs = append([]*Job{job}, q.jobs...)
+	q.funcs[job.ID] = fn
+	q.prune()
+	q.persist()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job.clone()
+}
+
+// Run runs the queued jobs until ctx is canceled, which also cancels the running job
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		job, fn, jobCtx := q.next(ctx)
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+			}
+			continue
+		}
+		run := &Run{q: q, job: job}
+		result, err := fn(jobCtx, run)
+		q.finish(jobCtx, job, result, err)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// next marks the oldest queued job running and returns it with its func and context
+func (q *Queue) next(ctx context.Context) (*Job, Func, context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := len(q.jobs) - 1; i >= 0; i-- {
+		job := q.jobs[i]
+		if job.State != StateQueued {
+			continue
+		}
+		fn := q.funcs[job.ID]
+		delete(q.funcs, job.ID)
+		jobCtx, cancel := context.WithCancel(ctx)
+		q.cancels[job.ID] = cancel
+		job.State = StateRunning
+		job.Started = now()
+		q.persist()
+		return job, fn, jobCtx
+	}
+	return nil, nil, nil
+}
+
+// finish records the outcome of a job
+func (q *Queue) finish(ctx context.Context, job *Job, result interface{}, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	canceled := ctx.Err() != nil
+	q.cancels[job.ID]()
+	delete(q.cancels, job.ID)
+	job.Finished = now()
+	switch {
+	case err != nil && canceled:
+		job.State = StateCanceled
+		job.Error = q.redact(err.Error())
+	case err != nil:
+		job.State = StateFailed
+		job.Error = q.redact(err.Error())
+	default:
+		job.State = StateSucceeded
+		job.Progress = 100
+	}
+	if result != nil {
+		if data, err := json.Marshal(result); err == nil {
+			job.Result = data
+		}
+	}
+	q.persist()
+}
+
+// Get returns the job with id, or a unique prefix of it
+func (q *Queue) Get(id string) (Job, error) {
+	return Find(q.List(), id)
+}
+
+// List returns all kept jobs, newest first
+func (q *Queue) List() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, job.clone())
+	}
+	return jobs
+}
+
+// Cancel cancels a queued job at once, and asks a running one to stop
+func (q *Queue) Cancel(id string) (Job, error) {
+	found, err := q.Get(id)
+	if err != nil {
+		return Job{}, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.jobs {
+		if job.ID != found.ID {
+			continue
+		}
+		switch job.State {
+		case StateQueued:
+			delete(q.funcs, job.ID)
+			job.State = StateCanceled
+			job.Finished = now()
+			q.persist()
+		case StateRunning:
+			q.cancels[job.ID]()
+			job.Log = appendLog(job.Log, "cancel requested")
+			q.persist()
+		default:
+			return job.clone(), ErrFinished
+		}
+		return job.clone(), nil
+	}
+	return Job{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// prune drops the oldest finished jobs beyond maxFinished; q.mu must be held
+func (q *Queue) prune() {
+	finished := 0
+	kept := q.jobs[:0]
+	for _, job := range q.jobs {
+		if job.Done() {
+			finished++
+			if finished > maxFinished {
+				continue
+			}
+		}
+		kept = append(kept, job)
+	}
+	q.jobs = kept
+}
+
+// persist saves the jobs. A job that cannot be saved still runs, and the next change
+// tries again; q.mu must be held.
+func (q *Queue) persist() {
+	if err := q.save(); err != nil {
+		slog.Warn("failed to save jobs", "error", err)
+	}
+}
+
+// save writes the jobs file; q.mu must be held
+func (q *Queue) save() error {
+	data, err := json.MarshalIndent(q.jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal jobs: %w", err)
+	}
+	if err := filesystem.SafeWriteFile(q.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write jobs file %s: %w", q.path, err)
+	}
+	q.saved = time.Now()
+	return nil
+}
+
+// now returns the current time for Started and Finished
+func now() *time.Time {
+	t := time.Now()
+	return &t
+}
+
+// newID returns a random job ID
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// appendLog adds a timestamped line to log, dropping the oldest beyond maxLogLines
+func appendLog(log []string, line string) []string {
+	log = append(log, time.Now().Format("15:04:05")+" "+line)
+	if len(log) > maxLogLines {
+		log = log[len(log)-maxLogLines:]
+	}
+	return log
+}
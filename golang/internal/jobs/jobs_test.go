@@ -0,0 +1,246 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/logging"
+)
+
+// waitFor polls q until the job with id is in state
+func waitFor(t *testing.T, q *Queue, id, state string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := q.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State == state {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is %s, want %s", id, job.State, state)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", FileName)
+	q, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Nothing runs before Run, so the first job blocks the queue until released
+	release := make(chan struct{})
+	first := q.Submit("update", "mod 1", "api 10.0.0.1", func(ctx context.Context, run *Run) (interface{}, error) {
+		run.Progress("download", 40)
+		run.Logger(logging.Discard()).Info("downloading", "file", "pack.zip")
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return map[string]string{"to_version": "2.0"}, nil
+	})
+	queued := q.Submit("backup", "", "api", func(ctx context.Context, run *Run) (interface{}, error) {
+		t.Error("a canceled job ran")
+		return nil, nil
+	})
+	failing := q.Submit("restore", "b.zip", "api", func(ctx context.Context, run *Run) (interface{}, error) {
+		return nil, errors.New("stop the server before restoring a backup")
+	})
+	if first.State != StateQueued || first.ID == "" {
+		t.Fatalf("submitted job = %+v", first)
+	}
+	go q.Run(ctx)
+
+	running := waitFor(t, q, first.ID, StateRunning)
+	if running.Phase != "download" || running.Progress != 40 {
+		t.Errorf("progress = %s %d%%", running.Phase, running.Progress)
+	}
+	if job, err := q.Cancel(queued.ID); err != nil || job.State != StateCanceled {
+		t.Fatalf("Cancel of a queued job = %+v, %v", job, err)
+	}
+	close(release)
+
+	done := waitFor(t, q, first.ID, StateSucceeded)
+	if done.Progress != 100 || string(done.Result) != `{"to_version":"2.0"}` {
+		t.Errorf("finished job = %d%%, %s", done.Progress, done.Result)
+	}
+	if len(done.Log) != 1 || !strings.HasSuffix(done.Log[0], " downloading file=pack.zip") {
+		t.Errorf("log = %q", done.Log)
+	}
+	if job := waitFor(t, q, failing.ID, StateFailed); job.Error != "stop the server before restoring a backup" {
+		t.Errorf("error = %q", job.Error)
+	}
+	if _, err := q.Cancel(first.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("Cancel of a finished job = %v", err)
+	}
+	if _, err := q.Get(first.ID[:6]); err != nil {
+		t.Errorf("Get by prefix: %v", err)
+	}
+	if _, err := q.Get("nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of an unknown job = %v", err)
+	}
+
+	jobs, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var states []string
+	for _, job := range jobs {
+		states = append(states, job.State)
+	}
+	if got := strings.Join(states, ","); got != "failed,canceled,succeeded" {
+		t.Errorf("saved states, newest first = %s", got)
+	}
+}
+
+func TestQueueCancelRunning(t *testing.T) {
+	q, err := Open(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	job := q.Submit("update", "", "", func(ctx context.Context, run *Run) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	waitFor(t, q, job.ID, StateRunning)
+	if _, err := q.Cancel(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if job := waitFor(t, q, job.ID, StateCanceled); len(job.Log) != 1 || !strings.HasSuffix(job.Log[0], "cancel requested") {
+		t.Errorf("log = %q", job.Log)
+	}
+}
+
+func TestOpenMarksInterruptedJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	q, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Never run: the process "stops" with the job still queued
+	job := q.Submit("update", "", "", func(ctx context.Context, run *Run) (interface{}, error) { return nil, nil })
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reopened.Get(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.State != StateFailed || got.Error != "interrupted by a restart" || got.Finished == nil {
+		t.Errorf("job after a restart = %+v", got)
+	}
+}
+
+func TestRunLoggerKeepsLevel(t *testing.T) {
+	q, err := Open(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := &Run{q: q, job: &Job{}}
+	var b strings.Builder
+	logger := run.Logger(slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelWarn}))).With("run_id", "abc")
+	logger.Debug("hidden")
+	logger.Info("backing up")
+	logger.Warn("disk almost full", "free", "1GB")
+
+	if len(run.job.Log) != 2 || !strings.HasSuffix(run.job.Log[1], " WARN disk almost full free=1GB") {
+		t.Errorf("job log = %q", run.job.Log)
+	}
+	if strings.Contains(b.String(), "backing up") || !strings.Contains(b.String(), "run_id=abc") {
+		t.Errorf("logger wrote %q", b.String())
+	}
+}
+
+func TestRunLogSavesInBatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	q, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	logged, release := make(chan struct{}), make(chan struct{})
+	job := q.Submit("update", "", "", func(ctx context.Context, run *Run) (interface{}, error) {
+		for i := 0; i < 100; i++ {
+			run.Log("extracting")
+		}
+		close(logged)
+		<-release
+		return nil, nil
+	})
+	<-logged
+
+	// Starting the job was saved just now, so the lines wait for the next save
+	saved, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Find(saved, job.ID); err != nil || got.State != StateRunning || len(got.Log) != 0 {
+		t.Errorf("saved while running = %s with %d lines, %v", got.State, len(got.Log), err)
+	}
+
+	close(release)
+	waitFor(t, q, job.ID, StateSucceeded)
+	saved, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Find(saved, job.ID); err != nil || len(got.Log) != 100 {
+		t.Errorf("saved when finished = %d lines, %v", len(got.Log), err)
+	}
+}
+
+func TestJobLogIsRedacted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	q, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const hook = "https://discord.example/api/webhooks/1/token"
+	q.SetRedact(logging.NewRedactWriter(nil, hook).Redact)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	job := q.Submit("update", "", "", func(ctx context.Context, run *Run) (interface{}, error) {
+		run.Logger(logging.Discard()).Warn("notification failed", "url", hook)
+		return nil, errors.New("post " + hook + ": 404")
+	})
+	waitFor(t, q, job.ID, StateFailed)
+
+	saved, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Find(saved, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Log) != 1 || strings.Contains(got.Log[0], hook) || !strings.Contains(got.Log[0], "url="+logging.Redacted) {
+		t.Errorf("saved log = %q", got.Log)
+	}
+	if got.Error != "post "+logging.Redacted+": 404" {
+		t.Errorf("saved error = %q", got.Error)
+	}
+}
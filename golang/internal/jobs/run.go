@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Run is handed to a running Func to report its progress and log
+type Run struct {
+	q   *Queue
+	job *Job
+}
+
+// ID returns the ID of the job
+func (r *Run) ID() string {
+	return r.job.ID
+}
+
+// Progress sets the phase of the job and how far it is, from 0 to 100. It is saved with
+// the next batch of log lines or state change.
+func (r *Run) Progress(phase string, percent int) {
+	r.q.mu.Lock()
+	defer r.q.mu.Unlock()
+	r.job.Phase = phase
+	r.job.Progress = min(max(percent, 0), 100)
+}
+
+// Log adds a line to the log of the job, with secrets redacted as set with SetRedact.
+// Lines are saved at most every logSaveInterval, and at the latest when the job finishes.
+func (r *Run) Log(line string) {
+	r.q.mu.Lock()
+	defer r.q.mu.Unlock()
+	r.job.Log = appendLog(r.job.Log, r.q.redact(line))
+	if time.Since(r.q.saved) >= logSaveInterval {
+		r.q.persist()
+	}
+}
+
+// Logger returns logger extended to also add its messages at info level and above to the
+// log of the job, with their own attributes but not those of logger
+func (r *Run) Logger(logger *slog.Logger) *slog.Logger {
+	return slog.New(&logHandler{next: logger.Handler(), run: r})
+}
+
+// logHandler passes records on to next and adds them to the log of a job
+type logHandler struct {
+	next slog.Handler
+	run  *Run
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo || h.next.Enabled(ctx, level)
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelInfo {
+		var b strings.Builder
+		if r.Level >= slog.LevelWarn {
+			b.WriteString(r.Level.String() + " ")
+		}
+		b.WriteString(r.Message)
+		r.Attrs(func(a slog.Attr) bool {
+			fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+			return true
+		})
+		h.run.Log(b.String())
+	}
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{next: h.next.WithAttrs(attrs), run: h.run}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{next: h.next.WithGroup(name), run: h.run}
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Redacted replaces secret values in log output
+const Redacted = "[REDACTED]"
+
+// minSecretLength keeps short values such as "x" from redacting unrelated text
+const minSecretLength = 4
+
+// RedactWriter replaces secret values in everything written through it
+type RedactWriter struct {
+	mu      sync.RWMutex
+	out     io.Writer
+	secrets [][]byte
+}
+
+// NewRedactWriter creates a writer that redacts secrets before writing to out
+func NewRedactWriter(out io.Writer, secrets ...string) *RedactWriter {
+	w := &RedactWriter{out: out}
+	w.SetSecrets(secrets...)
+	return w
+}
+
+// SetSecrets replaces the set of values to redact, e.g. after a config reload
+func (w *RedactWriter) SetSecrets(secrets ...string) {
+	var list [][]byte
+	for _, s := range secrets {
+		if len(s) >= minSecretLength {
+			list = append(list, []byte(s))
+		}
+	}
+	// Longest first so a secret containing another is redacted whole
+	sort.Slice(list, func(i, j int) bool { return len(list[i]) > len(list[j]) })
+
+	w.mu.Lock()
+	w.secrets = list
+	w.mu.Unlock()
+}
+
+// SetOutput changes the underlying writer, e.g. to add log_file
+func (w *RedactWriter) SetOutput(out io.Writer) {
+	w.mu.Lock()
+	w.out = out
+	w.mu.Unlock()
+}
+
+// Write redacts p and writes it to the underlying writer.
+// It reports len(p) on success so callers are unaware of the rewrite.
+func (w *RedactWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if _, err := w.out.Write(w.redact(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Redact returns s with the secrets of w replaced, for text that is kept elsewhere than
+// the log, such as the log of a job
+func (w *RedactWriter) Redact(s string) string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return string(w.redact([]byte(s)))
+}
+
+// redact replaces the secrets in p; w.mu must be held
+func (w *RedactWriter) redact(p []byte) []byte {
+	for _, secret := range w.secrets {
+		if bytes.Contains(p, secret) {
+			p = bytes.ReplaceAll(p, secret, []byte(Redacted))
+		}
+	}
+	return p
+}
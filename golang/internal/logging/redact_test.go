@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRedactWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []string
+		input   string
+		want    string
+	}{
+		{"no secrets", nil, "api key abcd", "api key abcd"},
+		{"single", []string{"s3cr3t-key"}, "using s3cr3t-key twice: s3cr3t-key", "using [REDACTED] twice: [REDACTED]"},
+		{"longest first", []string{"hook", "https://discord.example/hook"}, "post https://discord.example/hook", "post [REDACTED]"},
+		{"short values ignored", []string{"x"}, "x marks the spot", "x marks the spot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewRedactWriter(&buf, tt.secrets...)
+			n, err := w.Write([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if n != len(tt.input) {
+				t.Errorf("Write returned %d, want %d", n, len(tt.input))
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+			if got := w.Redact(tt.input); got != tt.want {
+				t.Errorf("Redact = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
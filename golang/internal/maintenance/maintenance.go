@@ -0,0 +1,258 @@
+// Package maintenance answers Minecraft clients on the server port while the real server
+// is down, so that the server list shows a message instead of a timeout and joining
+// players are told why they cannot connect
+package maintenance
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handshake states requested by the client
+const (
+	stateStatus = 1
+	stateLogin  = 2
+)
+
+// maxPacketLength bounds the packets read from clients; handshakes and pings are tiny
+const maxPacketLength = 2048
+
+// connTimeout limits how long one client may take to ping or log in
+const connTimeout = 10 * time.Second
+
+// versionName is shown instead of the ping bars, because the protocol never matches
+const versionName = "Maintenance"
+
+// Responder answers server list pings with a message of the day and disconnects players
+// who try to join with the same message
+type Responder struct {
+	listener net.Listener
+	status   []byte // status response JSON
+	kick     []byte // login disconnect reason JSON
+	logger   *slog.Logger
+
+	wg sync.WaitGroup
+}
+
+// Listen starts answering on address, e.g. ":25565", with motd. Minecraft formatting
+// codes (§e) may be used in motd.
+func Listen(address, motd string, logger *slog.Logger) (*Responder, error) {
+	status, err := json.Marshal(map[string]interface{}{
+		"version":     map[string]interface{}{"name": versionName, "protocol": -1},
+		"players":     map[string]int{"max": 0, "online": 0},
+		"description": map[string]string{"text": motd},
+	})
+	if err != nil {
+		return nil, err
+	}
+	kick, err := json.Marshal(map[string]string{"text": motd})
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("maintenance: %w", err)
+	}
+
+	r := &Responder{listener: listener, status: status, kick: kick, logger: logger}
+	r.wg.Add(1)
+	go r.serve()
+	return r, nil
+}
+
+// Addr returns the address the responder listens on
+func (r *Responder) Addr() net.Addr {
+	return r.listener.Addr()
+}
+
+// Close stops listening and waits for the clients being answered, freeing the port for
+// the real server
+func (r *Responder) Close() error {
+	err := r.listener.Close()
+	r.wg.Wait()
+	return err
+}
+
+// serve accepts clients until the listener is closed
+func (r *Responder) serve() {
+	defer r.wg.Done()
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				r.logger.Warn("maintenance responder stopped", "error", err)
+			}
+			return
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			defer conn.Close()
+			if err := r.handle(conn); err != nil && !errors.Is(err, io.EOF) {
+				r.logger.Debug("maintenance client failed", "remote", conn.RemoteAddr(), "error", err)
+			}
+		}()
+	}
+}
+
+// handle answers one client: the status and ping requests of the server list, or a login
+// with a disconnect
+func (r *Responder) handle(conn net.Conn) error {
+	if err := conn.SetDeadline(time.Now().Add(connTimeout)); err != nil {
+		return err
+	}
+	in := bufio.NewReader(conn)
+
+	id, data, err := readPacket(in)
+	if err != nil {
+		return err
+	}
+	if id != 0 {
+		return fmt.Errorf("expected a handshake, got packet %#x", id)
+	}
+	next, err := handshakeState(data)
+	if err != nil {
+		return err
+	}
+
+	switch next {
+	case stateLogin:
+		r.logger.Info("player turned away during maintenance", "remote", conn.RemoteAddr())
+		return writePacket(conn, 0, appendString(nil, r.kick))
+	case stateStatus:
+		for {
+			id, data, err := readPacket(in)
+			if err != nil {
+				return err
+			}
+			switch id {
+			case 0: // status request
+				if err := writePacket(conn, 0, appendString(nil, r.status)); err != nil {
+					return err
+				}
+			case 1: // ping, answered with the same payload
+				return writePacket(conn, 1, data)
+			default:
+				return fmt.Errorf("unexpected status packet %#x", id)
+			}
+		}
+	}
+	return fmt.Errorf("unsupported handshake state %d", next)
+}
+
+// handshakeState returns the state a handshake asks for, skipping the protocol version,
+// server address and port
+func handshakeState(data []byte) (int32, error) {
+	rest := data
+	var err error
+	if _, rest, err = readVarInt(rest); err != nil {
+		return 0, err
+	}
+	length, rest, err := readVarInt(rest)
+	if err != nil {
+		return 0, err
+	}
+	if length < 0 || int(length)+2 > len(rest) {
+		return 0, errors.New("malformed handshake")
+	}
+	rest = rest[length+2:]
+	state, _, err := readVarInt(rest)
+	return state, err
+}
+
+// readPacket reads one uncompressed packet and returns its ID and data
+func readPacket(r io.ByteReader) (int32, []byte, error) {
+	length, err := readVarIntFrom(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length <= 0 || length > maxPacketLength {
+		return 0, nil, fmt.Errorf("packet length %d out of range", length)
+	}
+	packet := make([]byte, length)
+	for i := range packet {
+		if packet[i], err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+	}
+	id, data, err := readVarInt(packet)
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, data, nil
+}
+
+// writePacket writes data as an uncompressed packet with the given ID
+func writePacket(w io.Writer, id int32, data []byte) error {
+	body := append(appendVarInt(nil, id), data...)
+	_, err := w.Write(append(appendVarInt(nil, int32(len(body))), body...))
+	return err
+}
+
+// appendString appends s as a length-prefixed UTF-8 string
+func appendString(b, s []byte) []byte {
+	return append(appendVarInt(b, int32(len(s))), s...)
+}
+
+// appendVarInt appends v in the protocol's variable-length encoding
+func appendVarInt(b []byte, v int32) []byte {
+	return binary.AppendUvarint(b, uint64(uint32(v)))
+}
+
+// readVarInt decodes a VarInt from the start of b and returns the rest
+func readVarInt(b []byte) (int32, []byte, error) {
+	v, n := binary.Uvarint(b)
+	if n <= 0 || n > 5 {
+		return 0, nil, errors.New("malformed VarInt")
+	}
+	return int32(uint32(v)), b[n:], nil
+}
+
+// readVarIntFrom decodes a VarInt from r
+func readVarIntFrom(r io.ByteReader) (int32, error) {
+	var v uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint32(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(v), nil
+		}
+	}
+	return 0, errors.New("VarInt is too long")
+}
+
+// FormatMOTD replaces {version} and {eta} in template, e.g. "Updating to {version}, back
+// in ~{eta}"
+func FormatMOTD(template, version string, eta time.Duration) string {
+	if version == "" {
+		version = "a new version"
+	}
+	return strings.NewReplacer("{version}", version, "{eta}", formatETA(eta)).Replace(template)
+}
+
+// formatETA rounds eta up to whole minutes, e.g. "10 min" or "1 h 30 min"
+func formatETA(eta time.Duration) string {
+	minutes := int((eta + time.Minute - 1) / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%d min", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d h", minutes/60)
+	}
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
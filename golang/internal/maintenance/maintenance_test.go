@@ -0,0 +1,136 @@
+package maintenance
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+// handshake builds a handshake packet asking for state
+func handshake(state int32) []byte {
+	data := appendVarInt(nil, 767)
+	data = appendString(data, []byte("localhost"))
+	data = append(data, 0x63, 0xdd)
+	data = appendVarInt(data, state)
+	return data
+}
+
+func dial(t *testing.T, r *Responder, state int32) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", r.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := writePacket(conn, 0, handshake(state)); err != nil {
+		t.Fatal(err)
+	}
+	return conn, bufio.NewReader(conn)
+}
+
+// readString reads a packet with the given ID holding one string
+func readString(t *testing.T, in *bufio.Reader, want int32) string {
+	t.Helper()
+	id, data, err := readPacket(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != want {
+		t.Fatalf("packet ID = %#x, want %#x", id, want)
+	}
+	length, rest, err := readVarInt(data)
+	if err != nil || int(length) != len(rest) {
+		t.Fatalf("malformed string in %q", data)
+	}
+	return string(rest)
+}
+
+func TestResponder(t *testing.T) {
+	motd := "Updating to 1.2, back in ~10 min"
+	r, err := Listen("127.0.0.1:0", motd, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("status and ping", func(t *testing.T) {
+		conn, in := dial(t, r, stateStatus)
+		if err := writePacket(conn, 0, nil); err != nil {
+			t.Fatal(err)
+		}
+		var status struct {
+			Version struct {
+				Name     string `json:"name"`
+				Protocol int    `json:"protocol"`
+			} `json:"version"`
+			Description struct {
+				Text string `json:"text"`
+			} `json:"description"`
+		}
+		if err := json.Unmarshal([]byte(readString(t, in, 0)), &status); err != nil {
+			t.Fatal(err)
+		}
+		if status.Description.Text != motd || status.Version.Name != versionName || status.Version.Protocol != -1 {
+			t.Errorf("status = %+v", status)
+		}
+
+		payload := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+		if err := writePacket(conn, 1, payload); err != nil {
+			t.Fatal(err)
+		}
+		id, data, err := readPacket(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 1 || string(data) != string(payload) {
+			t.Errorf("pong = %#x %v, want 0x1 %v", id, data, payload)
+		}
+	})
+
+	t.Run("login", func(t *testing.T) {
+		_, in := dial(t, r, stateLogin)
+		var reason struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal([]byte(readString(t, in, 0)), &reason); err != nil {
+			t.Fatal(err)
+		}
+		if reason.Text != motd {
+			t.Errorf("disconnect reason = %q, want %q", reason.Text, motd)
+		}
+	})
+
+	addr := r.Addr().String()
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The port must be free again for the real server
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("port still in use after Close: %v", err)
+	}
+	l.Close()
+}
+
+func TestFormatMOTD(t *testing.T) {
+	tests := []struct {
+		version string
+		eta     time.Duration
+		want    string
+	}{
+		{"1.2", 10 * time.Minute, "Updating to 1.2, back in ~10 min"},
+		{"1.2", 30 * time.Second, "Updating to 1.2, back in ~1 min"},
+		{"1.2", 90 * time.Minute, "Updating to 1.2, back in ~1 h 30 min"},
+		{"1.2", 2 * time.Hour, "Updating to 1.2, back in ~2 h"},
+		{"", 10 * time.Minute, "Updating to a new version, back in ~10 min"},
+	}
+	for _, tt := range tests {
+		if got := FormatMOTD("Updating to {version}, back in ~{eta}", tt.version, tt.eta); got != tt.want {
+			t.Errorf("FormatMOTD(%q, %v) = %q, want %q", tt.version, tt.eta, got, tt.want)
+		}
+	}
+}
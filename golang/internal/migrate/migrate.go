@@ -0,0 +1,338 @@
+// Package migrate bundles the config and tracking data of the updater into one zip
+// archive, so that a server can be moved to a new host and carry on where it left off:
+// the state store, update history, preserved pack files and download metadata of every
+// server, and the shared audit log. Backups, downloads and the server itself are not
+// included; they are copied with the server directory.
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/archive"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/audit"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/downloads"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+	"github.com/klauspost/compress/zip"
+)
+
+// FormatVersion is the version of the bundle layout written by Export
+const FormatVersion = 1
+
+// ManifestName is the entry describing the bundle
+const ManifestName = "manifest.json"
+
+// Directories of a bundle. Without [[servers]] the data of the one server sits at the top,
+// otherwise under servers/<name>/.
+const (
+	dataDir      = "data/"
+	downloadsDir = "downloads/"
+	serversDir   = "servers/"
+)
+
+// ErrExists is returned by Restore when the bundle would overwrite existing data
+var ErrExists = errors.New("data already exists")
+
+// Manifest describes what a bundle holds
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	Created       time.Time `json:"created"`
+	// Secrets reports whether the config kept its secrets
+	Secrets bool `json:"secrets"`
+	// Config is the entry name of the config file, whose extension is its format
+	Config  string   `json:"config"`
+	Servers []string `json:"servers,omitempty"`
+	Files   []string `json:"files"`
+}
+
+// Options changes what Export bundles
+type Options struct {
+	// Secrets keeps the API key, tokens and passwords in the bundled config. Secrets read
+	// from a *_file setting are never bundled; only the path is.
+	Secrets bool
+}
+
+// Export writes a bundle of cfg, as loaded with every [[servers]] entry, to w
+func Export(cfg *config.Config, w io.Writer, opts Options) (*Manifest, error) {
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		Created:       time.Now().UTC(),
+		Secrets:       opts.Secrets,
+		Config:        "config" + configExt(cfg.File),
+		Servers:       cfg.InstanceNames(),
+	}
+
+	zw := zip.NewWriter(w)
+	write := func(name string, data []byte, modified time.Time) error {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified}
+		header.SetMode(0600)
+		f, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return fmt.Errorf("failed to bundle %s: %w", name, err)
+		}
+		return nil
+	}
+	add := func(name string, data []byte, modified time.Time) error {
+		manifest.Files = append(manifest.Files, name)
+		return write(name, data, modified)
+	}
+	addFile := func(name, src string) error {
+		info, err := os.Stat(src)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// #nosec G304 -- src is a data file of the configured directories
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		return add(name, data, info.ModTime())
+	}
+
+	saved := cfg
+	if !opts.Secrets {
+		saved = cfg.WithoutSecrets()
+	}
+	data, err := configFile(saved, manifest.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err := add(manifest.Config, data, manifest.Created); err != nil {
+		return nil, err
+	}
+	if err := addFile(audit.FileName, filepath.Join(cfg.DataDir, audit.FileName)); err != nil {
+		return nil, err
+	}
+
+	for _, inst := range cfg.Instances() {
+		prefix := instancePrefix(cfg, inst)
+		for _, name := range []string{state.FileName, history.FileName} {
+			if err := addFile(prefix+dataDir+name, filepath.Join(inst.DataDir, name)); err != nil {
+				return nil, err
+			}
+		}
+		packFiles := filepath.Join(inst.DataDir, updater.PackFilesDir)
+		err := filepath.WalkDir(packFiles, func(p string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && p == packFiles {
+				return filepath.SkipDir
+			}
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(inst.DataDir, p)
+			if err != nil {
+				return err
+			}
+			return addFile(prefix+dataDir+filepath.ToSlash(rel), p)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to bundle %s: %w", packFiles, err)
+		}
+		if err := addFile(prefix+downloadsDir+downloads.MetadataFileName, filepath.Join(inst.DownloadPath, downloads.MetadataFileName)); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err = json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := write(ManifestName, data, manifest.Created); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return manifest, nil
+}
+
+// configExt returns the extension of the config file at path, or .toml when it has none
+// that SaveConfig can write
+func configExt(path string) string {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml", ".yaml", ".yml", ".json":
+		return ext
+	}
+	return ".toml"
+}
+
+// configFile renders cfg the way SaveConfig writes it to a file called name
+func configFile(cfg *config.Config, name string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "export-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, name)
+	if err := config.SaveConfig(cfg, path); err != nil {
+		return nil, err
+	}
+	// #nosec G304 -- path is in a directory made above
+	return os.ReadFile(path)
+}
+
+// instancePrefix returns where the data of inst sits in a bundle of cfg
+func instancePrefix(cfg, inst *config.Config) string {
+	if !cfg.HasInstances() {
+		return ""
+	}
+	return serversDir + inst.InstanceName + "/"
+}
+
+// Bundle is an open bundle written by Export
+type Bundle struct {
+	Manifest Manifest
+	reader   *zip.ReadCloser
+	files    map[string]*zip.File
+}
+
+// Open opens the bundle at path and reads its manifest
+func Open(path string) (*Bundle, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	b := &Bundle{reader: reader, files: make(map[string]*zip.File)}
+	for _, f := range reader.File {
+		if err := archive.CheckEntry(f); err != nil {
+			_ = reader.Close()
+			return nil, err
+		}
+		b.files[f.Name] = f
+	}
+
+	data, err := b.read(ManifestName)
+	if err == nil {
+		err = json.Unmarshal(data, &b.Manifest)
+	}
+	if err != nil {
+		_ = reader.Close()
+		return nil, fmt.Errorf("%s is not a state bundle: %w", path, err)
+	}
+	if b.Manifest.FormatVersion != FormatVersion {
+		_ = reader.Close()
+		return nil, fmt.Errorf("bundle format %d is not supported, this version reads format %d", b.Manifest.FormatVersion, FormatVersion)
+	}
+	return b, nil
+}
+
+// Close closes the bundle
+func (b *Bundle) Close() error {
+	return b.reader.Close()
+}
+
+// read returns the contents of the entry called name
+func (b *Bundle) read(name string) ([]byte, error) {
+	f, ok := b.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing", name)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// WriteConfig writes the bundled config to path, which must have the same extension
+func (b *Bundle) WriteConfig(path string) error {
+	if want := filepath.Ext(b.Manifest.Config); !strings.EqualFold(filepath.Ext(path), want) {
+		return fmt.Errorf("the bundle holds a %s config, which cannot be written to %s", want, path)
+	}
+	data, err := b.read(b.Manifest.Config)
+	if err != nil {
+		return err
+	}
+	if err := filesystem.SafeWriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// Result lists what Restore did
+type Result struct {
+	// Restored are the paths written
+	Restored []string
+	// Skipped are the bundle entries with nowhere to go, such as the data of a server
+	// missing from cfg
+	Skipped []string
+}
+
+// Restore writes the bundled data into the directories of cfg, matching [[servers]] by
+// name. Unless force is set, nothing is written when a file would be overwritten.
+func (b *Bundle) Restore(cfg *config.Config, force bool) (*Result, error) {
+	targets := map[string]string{}
+	var result Result
+	for _, name := range b.Manifest.Files {
+		if name == b.Manifest.Config {
+			continue
+		}
+		if _, ok := b.files[name]; !ok {
+			return nil, fmt.Errorf("bundle is incomplete: %s is missing", name)
+		}
+		target := b.target(cfg, name)
+		if target == "" {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		targets[name] = target
+		if _, err := os.Stat(target); err == nil && !force {
+			return nil, fmt.Errorf("%w: %s", ErrExists, target)
+		}
+	}
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := b.read(name)
+		if err != nil {
+			return &result, err
+		}
+		if err := filesystem.SafeWriteFile(targets[name], data, 0600); err != nil {
+			return &result, fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		result.Restored = append(result.Restored, targets[name])
+	}
+	return &result, nil
+}
+
+// target returns where the entry called name is restored with cfg, or "" when it has no
+// place there
+func (b *Bundle) target(cfg *config.Config, name string) string {
+	if name == audit.FileName {
+		return filepath.Join(cfg.DataDir, audit.FileName)
+	}
+	for _, inst := range cfg.Instances() {
+		prefix := instancePrefix(cfg, inst)
+		if rest, ok := strings.CutPrefix(name, prefix+dataDir); ok && rest != "" {
+			return filepath.Join(inst.DataDir, filepath.FromSlash(rest))
+		}
+		if rest, ok := strings.CutPrefix(name, prefix+downloadsDir); ok && rest == downloads.MetadataFileName {
+			return filepath.Join(inst.DownloadPath, rest)
+		}
+	}
+	return ""
+}
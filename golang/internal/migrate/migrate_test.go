@@ -0,0 +1,184 @@
+package migrate
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// loadConfig writes a config in its own directory and loads it from there, so that its
+// relative paths stay inside the test
+func loadConfig(t *testing.T, dir, content string) *config.Config {
+	t.Helper()
+	t.Chdir(dir)
+	writeTestFile(t, filepath.Join(dir, "config.toml"), content)
+	cfg, err := config.Load(config.Options{Path: "config.toml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func exportBundle(t *testing.T, cfg *config.Config, opts Options) (string, *Manifest) {
+	t.Helper()
+	var buf bytes.Buffer
+	manifest, err := Export(cfg, &buf, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "state.zip")
+	writeTestFile(t, path, buf.String())
+	return path, manifest
+}
+
+func TestExportImport(t *testing.T) {
+	const settings = `
+api_key = "secret-key"
+modpack_id = 1
+data_dir = "data"
+download_path = "downloads"
+`
+	oldHost := t.TempDir()
+	writeTestFile(t, filepath.Join(oldHost, "data", "state.json"), `{"installed_file_id": 42}`)
+	writeTestFile(t, filepath.Join(oldHost, "data", "history.jsonl"), "{}\n")
+	writeTestFile(t, filepath.Join(oldHost, "data", "audit.jsonl"), "{}\n")
+	writeTestFile(t, filepath.Join(oldHost, "data", "pack-files", "config", "a.cfg"), "a=1\n")
+	writeTestFile(t, filepath.Join(oldHost, "downloads", "download_metadata.json"), "{}")
+	cfg := loadConfig(t, oldHost, settings)
+
+	path, manifest := exportBundle(t, cfg, Options{})
+	want := []string{"config.toml", "audit.jsonl", "data/state.json", "data/history.jsonl", "data/pack-files/config/a.cfg", "downloads/download_metadata.json"}
+	if strings.Join(manifest.Files, " ") != strings.Join(want, " ") {
+		t.Errorf("bundled %v, want %v", manifest.Files, want)
+	}
+
+	newHost := t.TempDir()
+	t.Chdir(newHost)
+	bundle, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bundle.Close()
+	if bundle.Manifest.Secrets {
+		t.Error("manifest reports secrets")
+	}
+	if err := bundle.WriteConfig("config.yaml"); err == nil {
+		t.Error("wrote a TOML config to a .yaml file")
+	}
+	if err := bundle.WriteConfig("config.toml"); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := config.Load(config.Options{Path: "config.toml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.APIKey != "" || imported.ModpackID != 1 {
+		t.Errorf("imported config has api_key %q, modpack_id %d", imported.APIKey, imported.ModpackID)
+	}
+
+	result, err := bundle.Restore(imported, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Restored) != 5 || len(result.Skipped) != 0 {
+		t.Errorf("Restore = %+v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(newHost, "data", "pack-files", "config", "a.cfg")); string(data) != "a=1\n" {
+		t.Errorf("pack file = %q", data)
+	}
+
+	// Importing again would overwrite the state
+	if _, err := bundle.Restore(imported, false); !errors.Is(err, ErrExists) {
+		t.Errorf("second Restore: %v, want ErrExists", err)
+	}
+	if _, err := bundle.Restore(imported, true); err != nil {
+		t.Errorf("forced Restore: %v", err)
+	}
+
+	withSecrets, _ := exportBundle(t, cfg, Options{Secrets: true})
+	bundle, err = Open(withSecrets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bundle.Close()
+	data, err := bundle.read(bundle.Manifest.Config)
+	if err != nil || !strings.Contains(string(data), "secret-key") {
+		t.Errorf("config exported with secrets lacks the API key: %v", err)
+	}
+}
+
+func TestExportImportServers(t *testing.T) {
+	oldHost := t.TempDir()
+	writeTestFile(t, filepath.Join(oldHost, "data", "alpha", "state.json"), `{"installed_file_id": 1}`)
+	writeTestFile(t, filepath.Join(oldHost, "data", "beta", "state.json"), `{"installed_file_id": 2}`)
+	writeTestFile(t, filepath.Join(oldHost, "downloads", "beta", "download_metadata.json"), "{}")
+	cfg := loadConfig(t, oldHost, `
+data_dir = "data"
+download_path = "downloads"
+
+[[servers]]
+name = "alpha"
+modpack_id = 1
+
+[[servers]]
+name = "beta"
+modpack_id = 2
+`)
+	path, manifest := exportBundle(t, cfg, Options{})
+	if strings.Join(manifest.Servers, ",") != "alpha,beta" {
+		t.Errorf("servers = %v", manifest.Servers)
+	}
+
+	// The new host only runs beta, under another data directory
+	newHost := t.TempDir()
+	imported := loadConfig(t, newHost, `
+data_dir = "state"
+download_path = "downloads"
+
+[[servers]]
+name = "beta"
+modpack_id = 2
+data_dir = "beta-data"
+`)
+	bundle, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bundle.Close()
+	result, err := bundle.Restore(imported, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(result.Skipped, ",") != "servers/alpha/data/state.json" {
+		t.Errorf("skipped %v", result.Skipped)
+	}
+	if data, _ := os.ReadFile(filepath.Join(newHost, "beta-data", "state.json")); string(data) != `{"installed_file_id": 2}` {
+		t.Errorf("beta state = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(newHost, "downloads", "beta", "download_metadata.json")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenRejectsOtherArchives(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-bundle.zip")
+	writeTestFile(t, path, "PK\x05\x06"+strings.Repeat("\x00", 18))
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "not a state bundle") {
+		t.Errorf("Open: %v", err)
+	}
+}
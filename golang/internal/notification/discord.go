@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
 )
 
 // DiscordNotifier handles Discord webhook notifications
@@ -105,7 +107,7 @@ func (d *DiscordNotifier) SendEmbed(embed DiscordEmbed) error {
 }
 
 // SendUpdateNotification sends a modpack update notification
-func (d *DiscordNotifier) SendUpdateNotification(modpackName, currentVersion, newVersion, changelog string) error {
+func (d *DiscordNotifier) SendUpdateNotification(modpackName, currentVersion, newVersion, changelog, policy string) error {
 	embed := DiscordEmbed{
 		Title:       fmt.Sprintf("🔄 Modpack Update Available: %s", modpackName),
 		Description: fmt.Sprintf("A new version of **%s** is available!", modpackName),
@@ -133,6 +135,14 @@ func (d *DiscordNotifier) SendUpdateNotification(modpackName, currentVersion, ne
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
+	if policy != "" {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   "Update Policy",
+			Value:  truncateString(policy, 1024),
+			Inline: false,
+		})
+	}
+
 	if changelog != "" {
 		embed.Fields = append(embed.Fields, DiscordEmbedField{
 			Name:   "Changelog",
@@ -171,8 +181,42 @@ func (d *DiscordNotifier) SendUpdateStartNotification(modpackName, version strin
 	return d.SendEmbed(embed)
 }
 
-// SendUpdateSuccessNotification sends a notification when update succeeds
-func (d *DiscordNotifier) SendUpdateSuccessNotification(modpackName, version string, duration time.Duration) error {
+// SendUpdateScheduledNotification announces an update at the next maintenance window;
+// Discord shows the time in each reader's own timezone
+func (d *DiscordNotifier) SendUpdateScheduledNotification(modpackName, currentVersion, newVersion string, at time.Time) error {
+	embed := DiscordEmbed{
+		Title:       fmt.Sprintf("📅 Update Scheduled: %s", modpackName),
+		Description: fmt.Sprintf("**%s** will be installed <t:%d:R>. The server will be temporarily unavailable.", newVersion, at.Unix()),
+		Color:       ColorInfo,
+		Fields: []DiscordEmbedField{
+			{
+				Name:   "Current Version",
+				Value:  currentVersion,
+				Inline: true,
+			},
+			{
+				Name:   "New Version",
+				Value:  newVersion,
+				Inline: true,
+			},
+			{
+				Name:   "Scheduled For",
+				Value:  fmt.Sprintf("<t:%d:F>", at.Unix()),
+				Inline: true,
+			},
+		},
+		Footer: &DiscordEmbedFooter{
+			Text: "CurseForge Auto-Updater",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	return d.SendEmbed(embed)
+}
+
+// SendUpdateSuccessNotification sends a notification when update succeeds, with a digest
+// of the mods that changed and a link to the client file players need
+func (d *DiscordNotifier) SendUpdateSuccessNotification(modpackName, version string, duration time.Duration, mods []history.ModChange, client *ClientPack) error {
 	embed := DiscordEmbed{
 		Title:       fmt.Sprintf("✅ Update Completed: %s", modpackName),
 		Description: fmt.Sprintf("**%s** has been successfully updated to version **%s**", modpackName, version),
@@ -199,6 +243,22 @@ func (d *DiscordNotifier) SendUpdateSuccessNotification(modpackName, version str
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
+	if len(mods) > 0 {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:  fmt.Sprintf("Mod Changes (%d)", len(mods)),
+			Value: ModDigest(mods, 1024),
+		})
+	}
+	if client != nil {
+		value := fmt.Sprintf("[%s](%s)", client.Version, client.PageURL)
+		if client.DownloadURL != "" {
+			value += fmt.Sprintf(" · [Download](%s)", client.DownloadURL)
+		}
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:  "Client Pack",
+			Value: "Players need " + value,
+		})
+	}
 
 	return d.SendEmbed(embed)
 }
@@ -230,6 +290,59 @@ func (d *DiscordNotifier) SendUpdateFailureNotification(modpackName, version str
 	return d.SendEmbed(embed)
 }
 
+// SendApprovalNotification asks for, reminds of or reports the expiry of an approval.
+// Webhook messages cannot carry buttons, so the decision is made through the links.
+func (d *DiscordNotifier) SendApprovalNotification(event string, req ApprovalRequest) error {
+	embed := DiscordEmbed{
+		Title:       fmt.Sprintf("🛂 Update Waiting for Approval: %s", req.ModpackName),
+		Description: fmt.Sprintf("**%s** will only be installed once it is approved.", req.NewVersion),
+		Color:       ColorUpdate,
+		Fields: []DiscordEmbedField{
+			{
+				Name:   "Current Version",
+				Value:  req.CurrentVersion,
+				Inline: true,
+			},
+			{
+				Name:   "New Version",
+				Value:  req.NewVersion,
+				Inline: true,
+			},
+		},
+		Footer: &DiscordEmbedFooter{
+			Text: "CurseForge Auto-Updater",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	switch event {
+	case EventApprovalReminder:
+		embed.Title = fmt.Sprintf("⏰ Still Waiting for Approval: %s", req.ModpackName)
+	case EventApprovalExpired:
+		embed.Title = fmt.Sprintf("⌛ Approval Expired: %s", req.ModpackName)
+		embed.Description = fmt.Sprintf("Nobody approved **%s** in time, so it will not be installed.", req.NewVersion)
+		embed.Color = ColorWarning
+		return d.SendEmbed(embed)
+	}
+
+	if !req.ExpiresAt.IsZero() {
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   "Expires",
+			Value:  fmt.Sprintf("<t:%d:R>", req.ExpiresAt.Unix()),
+			Inline: true,
+		})
+	}
+	decide := "Run `approval approve` or `approval deny` on the server."
+	if req.ApproveURL != "" {
+		decide = fmt.Sprintf("[✅ Approve](%s) · [⛔ Deny](%s)", req.ApproveURL, req.DenyURL)
+	}
+	embed.Fields = append(embed.Fields, DiscordEmbedField{
+		Name:   "Decide",
+		Value:  decide,
+		Inline: false,
+	})
+	return d.SendEmbed(embed)
+}
+
 // SendBackupNotification sends a backup notification
 func (d *DiscordNotifier) SendBackupNotification(action, backupName string, size int64) error {
 	var title, description string
@@ -300,6 +413,12 @@ func (d *DiscordNotifier) SendServerStatusNotification(status, message string) e
 	case "offline":
 		title = "🔴 Server Offline"
 		color = ColorError
+	case "degraded":
+		title = "🟠 Server Under Strain"
+		color = ColorWarning
+	case "recovered":
+		title = "🟢 Server Recovered"
+		color = ColorSuccess
 	default:
 		title = "ℹ️ Server Status"
 		color = ColorInfo
@@ -389,3 +508,30 @@ func (d *DiscordNotifier) TestConnection() error {
 
 	return d.SendEmbed(testEmbed)
 }
+
+// ModDigest lists changed mods one per line, with the first line of each changelog, cut
+// off at maxLength characters
+func ModDigest(mods []history.ModChange, maxLength int) string {
+	var b strings.Builder
+	for i, m := range mods {
+		var line string
+		switch {
+		case m.FromVersion == "":
+			line = fmt.Sprintf("➕ **%s** %s", m.Name, m.ToVersion)
+		case m.ToVersion == "":
+			line = fmt.Sprintf("➖ **%s**", m.Name)
+		default:
+			line = fmt.Sprintf("🔄 **%s** %s → %s", m.Name, m.FromVersion, m.ToVersion)
+		}
+		if first, _, _ := strings.Cut(m.Changelog, "\n"); first != "" {
+			line += ": " + truncateString(first, 100)
+		}
+		more := fmt.Sprintf("…and %d more", len(mods)-i)
+		if b.Len()+len(line)+1 > maxLength-len(more)-1 {
+			b.WriteString(more)
+			break
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,60 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+)
+
+// Subscribe sends notifications for the events published on bus until the returned
+// function is called: available, installed and failed updates, new backups and the
+// server crossing or returning within its monitor thresholds. name names the modpack in
+// the messages. sent is told the outcome of each notification, named by its event, e.g.
+// to log it. Notifications are sent while the event is published, as the bus delivers it.
+func (m *Manager) Subscribe(bus *events.Bus, name func() string, sent func(event string, err error)) func() {
+	return bus.Subscribe(func(e events.Event) {
+		if ok, err := m.notify(e, name()); ok {
+			sent(e.Type, err)
+		}
+	}, events.UpdateAvailable, events.UpdateFinished, events.UpdateFailed, events.BackupCreated,
+		events.ServerDegraded, events.ServerRecovered)
+}
+
+// notify sends the notification for e and reports whether there was one to send
+func (m *Manager) notify(e events.Event, name string) (bool, error) {
+	switch e.Type {
+	case events.UpdateAvailable:
+		return true, m.SendUpdateNotification(name, text(e, "installed_version"), text(e, "latest_version"), "", text(e, "policy"))
+	case events.UpdateFinished:
+		if skipped, _ := e.Data["skipped"].(bool); skipped {
+			return false, nil
+		}
+		duration, _ := time.ParseDuration(text(e, "duration"))
+		mods, _ := e.Data["mods"].([]history.ModChange)
+		var client *ClientPack
+		if v := text(e, "client_version"); v != "" {
+			client = &ClientPack{Version: v, PageURL: text(e, "client_url"), DownloadURL: text(e, "client_download_url")}
+		}
+		return true, m.SendUpdateSuccessNotification(name, text(e, "to_version"), duration, mods, client)
+	case events.UpdateFailed:
+		return true, m.SendUpdateFailureNotification(name, text(e, "to_version"), text(e, "error"))
+	case events.BackupCreated:
+		size, _ := e.Data["size"].(int64)
+		return true, m.SendBackupNotification("created", text(e, "name"), size)
+	case events.ServerDegraded:
+		problems, _ := e.Data["problems"].([]string)
+		return true, m.SendServerStatusNotification("degraded", fmt.Sprintf("%s: %s", name, strings.Join(problems, "; ")))
+	case events.ServerRecovered:
+		return true, m.SendServerStatusNotification("recovered", fmt.Sprintf("%s: resources are back within the thresholds", name))
+	}
+	return false, nil
+}
+
+// text returns a string field of the event data, or "" if it has none
+func text(e events.Event, key string) string {
+	s, _ := e.Data[key].(string)
+	return s
+}
@@ -0,0 +1,100 @@
+package notification
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+)
+
+func TestManagerSubscribe(t *testing.T) {
+	var titles, bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		titles = append(titles, r.Header.Get("Title"))
+		bodies = append(bodies, string(data))
+	}))
+	defer srv.Close()
+
+	m := NewManager(&config.NotificationConfig{Ntfy: config.NtfyConfig{Enabled: true, Server: srv.URL, Topic: "mc"}})
+	bus := events.NewBus(nil)
+	var sent []string
+	unsubscribe := m.Subscribe(bus, func() string { return "Mod 1" }, func(event string, err error) {
+		if err != nil {
+			t.Errorf("%s: %v", event, err)
+		}
+		sent = append(sent, event)
+	})
+
+	bus.Publish("check_completed", map[string]interface{}{"update_available": true})
+	bus.Publish(events.UpdateFinished, map[string]interface{}{"skipped": true})
+	bus.Publish(events.UpdateFinished, map[string]interface{}{"to_version": "2.0", "duration": "1m30s", "skipped": false})
+	bus.Publish(events.ServerDegraded, map[string]interface{}{"problems": []string{"TPS 12.0 below 18"}})
+	unsubscribe()
+	bus.Publish(events.UpdateFailed, map[string]interface{}{"to_version": "2.1", "error": "download failed"})
+
+	if len(sent) != 2 || sent[0] != events.UpdateFinished || sent[1] != events.ServerDegraded {
+		t.Fatalf("sent %v, want the update and the degraded server only", sent)
+	}
+	if titles[0] != "Update completed: Mod 1" || bodies[0] != "Now on 2.0, took 1m30s" {
+		t.Errorf("update notification = %q: %q", titles[0], bodies[0])
+	}
+	if bodies[1] != "Mod 1: TPS 12.0 below 18" {
+		t.Errorf("server notification = %q", bodies[1])
+	}
+}
+
+func TestManagerSubscribeUpdatePolicy(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer srv.Close()
+
+	m := NewManager(&config.NotificationConfig{Ntfy: config.NtfyConfig{Enabled: true, Server: srv.URL, Topic: "mc"}})
+	bus := events.NewBus(nil)
+	defer m.Subscribe(bus, func() string { return "Mod 1" }, func(string, error) {})()
+
+	bus.Publish(events.UpdateAvailable, map[string]interface{}{
+		"installed_version": "1.0", "latest_version": "1.1", "policy": "hold back: beta is not in release_types release",
+	})
+	if body != "1.0 → 1.1\nPolicy: hold back: beta is not in release_types release" {
+		t.Errorf("update notification = %q, want the policy decision in it", body)
+	}
+}
+
+func TestManagerSubscribeClientPack(t *testing.T) {
+	var got DiscordWebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	m := NewManager(&config.NotificationConfig{Discord: config.DiscordConfig{Enabled: true, WebhookURL: srv.URL}})
+	bus := events.NewBus(nil)
+	defer m.Subscribe(bus, func() string { return "Mod 1" }, func(event string, err error) {
+		if err != nil {
+			t.Errorf("%s: %v", event, err)
+		}
+	})()
+
+	bus.Publish(events.UpdateFinished, map[string]interface{}{
+		"to_version": "2.0", "duration": "1m30s", "skipped": false,
+		"client_version": "Pack 2.0", "client_url": "https://www.curseforge.com/minecraft/modpacks/pack/files/200",
+		"client_download_url": "https://edge.forgecdn.net/files/200/pack.zip",
+	})
+	if len(got.Embeds) != 1 {
+		t.Fatalf("got %d embeds, want 1", len(got.Embeds))
+	}
+	fields := got.Embeds[0].Fields
+	want := "Players need [Pack 2.0](https://www.curseforge.com/minecraft/modpacks/pack/files/200) · [Download](https://edge.forgecdn.net/files/200/pack.zip)"
+	if last := fields[len(fields)-1]; last.Name != "Client Pack" || last.Value != want {
+		t.Errorf("last field = %+v, want the client pack link %q", last, want)
+	}
+}
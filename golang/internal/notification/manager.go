@@ -2,18 +2,25 @@ package notification
 
 import (
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
 	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
 )
 
 // Manager handles all notification channels
 type Manager struct {
-	discord *DiscordNotifier
-	webhook *WebhookNotifier
-	enabled bool
-	mu      sync.RWMutex
+	discord   *DiscordNotifier
+	webhook   *WebhookNotifier
+	pushers   []pusher // Pushover and ntfy
+	minecraft *MinecraftNotifier
+	enabled   bool
+	client    *http.Client // shared by the notifiers when set, see SetHTTPClient
+	retrier   *httpclient.Retrier
+	mu        sync.RWMutex
 }
 
 // NewManager creates a new notification manager
@@ -29,13 +36,40 @@ func NewManager(config *config.NotificationConfig) *Manager {
 		webhook = NewWebhookNotifier(&config.Webhook)
 	}
 
-	enabled := config.Discord.Enabled || config.Webhook.Enabled
+	pushers := newPushers(config)
+	minecraft := newMinecraft(config)
+	enabled := config.Discord.Enabled || config.Webhook.Enabled || len(pushers) > 0 || minecraft != nil
 
-	return &Manager{
-		discord: discord,
-		webhook: webhook,
-		enabled: enabled,
+	m := &Manager{
+		discord:   discord,
+		webhook:   webhook,
+		pushers:   pushers,
+		minecraft: minecraft,
+		enabled:   enabled,
+		retrier:   httpclient.NewRetrier(config.Retry),
 	}
+	m.applyClient()
+	return m
+}
+
+// newPushers creates the enabled phone push notifiers
+func newPushers(config *config.NotificationConfig) []pusher {
+	var pushers []pusher
+	if config.Pushover.Enabled {
+		pushers = append(pushers, NewPushoverNotifier(&config.Pushover))
+	}
+	if config.Ntfy.Enabled {
+		pushers = append(pushers, NewNtfyNotifier(&config.Ntfy))
+	}
+	return pushers
+}
+
+// newMinecraft creates the game chat notifier when it is enabled
+func newMinecraft(config *config.NotificationConfig) *MinecraftNotifier {
+	if !config.Minecraft.Enabled {
+		return nil
+	}
+	return NewMinecraftNotifier(&config.Minecraft)
 }
 
 // IsEnabled returns whether notifications are enabled
@@ -45,28 +79,79 @@ func (m *Manager) IsEnabled() bool {
 	return m.enabled
 }
 
+// channels returns a consistent snapshot of the configured notifiers
+func (m *Manager) channels() (*DiscordNotifier, *WebhookNotifier, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.discord, m.webhook, m.enabled
+}
+
+// pusherList returns a snapshot of the phone push notifiers
+func (m *Manager) pusherList() []pusher {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.pushers
+}
+
+// gameChat returns the game chat notifier, nil when it is disabled
+func (m *Manager) gameChat() *MinecraftNotifier {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.minecraft
+}
+
+// chat runs send with the game chat notifier, if any, and returns its error
+func (m *Manager) chat(send func(n *MinecraftNotifier) error) []error {
+	n := m.gameChat()
+	if n == nil {
+		return nil
+	}
+	if err := send(n); err != nil {
+		return []error{fmt.Errorf("minecraft: %w", err)}
+	}
+	return nil
+}
+
+// push sends msg to the phone push services and returns their errors
+func (m *Manager) push(msg PushMessage) []error {
+	var errors []error
+	for _, p := range m.pusherList() {
+		if err := p.Push(msg); err != nil {
+			errors = append(errors, fmt.Errorf("%s: %w", p.Name(), err))
+		}
+	}
+	return errors
+}
+
 // SendMessage sends a simple message to all enabled channels
 func (m *Manager) SendMessage(message string) error {
-	if !m.IsEnabled() {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
 		return nil
 	}
 
 	var errors []error
 
 	// Send to Discord
-	if m.discord != nil {
-		if err := m.discord.SendMessage(message); err != nil {
+	if discord != nil {
+		if err := discord.SendMessage(message); err != nil {
 			errors = append(errors, fmt.Errorf("Discord: %w", err))
 		}
 	}
 
 	// Send to webhook
-	if m.webhook != nil {
-		if err := m.webhook.SendNotification("message", message, nil); err != nil {
+	if webhook != nil {
+		if err := webhook.SendNotification("message", message, nil); err != nil {
 			errors = append(errors, fmt.Errorf("Webhook: %w", err))
 		}
 	}
 
+	// Send to phone push services
+	errors = append(errors, m.push(PushMessage{Title: "CurseForge Auto-Updater", Message: message, Priority: PriorityLow})...)
+
+	// Send to the game chat
+	errors = append(errors, m.chat(func(n *MinecraftNotifier) error { return n.SendMessage(message) })...)
+
 	if len(errors) > 0 {
 		return fmt.Errorf("notification errors: %v", errors)
 	}
@@ -74,28 +159,46 @@ func (m *Manager) SendMessage(message string) error {
 	return nil
 }
 
-// SendUpdateNotification sends an update notification to all enabled channels
-func (m *Manager) SendUpdateNotification(modpackName, currentVersion, newVersion, changelog string) error {
-	if !m.IsEnabled() {
+// SendUpdateNotification sends an update notification to all enabled channels. policy
+// is the update_policy decision on installing it, if any.
+func (m *Manager) SendUpdateNotification(modpackName, currentVersion, newVersion, changelog, policy string) error {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
 		return nil
 	}
 
 	var errors []error
 
 	// Send to Discord
-	if m.discord != nil {
-		if err := m.discord.SendUpdateNotification(modpackName, currentVersion, newVersion, changelog); err != nil {
+	if discord != nil {
+		if err := discord.SendUpdateNotification(modpackName, currentVersion, newVersion, changelog, policy); err != nil {
 			errors = append(errors, fmt.Errorf("Discord: %w", err))
 		}
 	}
 
 	// Send to webhook
-	if m.webhook != nil {
-		if err := m.webhook.SendUpdateNotification(modpackName, currentVersion, newVersion, changelog); err != nil {
+	if webhook != nil {
+		if err := webhook.SendUpdateNotification(modpackName, currentVersion, newVersion, changelog, policy); err != nil {
 			errors = append(errors, fmt.Errorf("Webhook: %w", err))
 		}
 	}
 
+	// Send to phone push services
+	message := fmt.Sprintf("%s → %s", currentVersion, newVersion)
+	if policy != "" {
+		message += "\nPolicy: " + policy
+	}
+	errors = append(errors, m.push(PushMessage{
+		Title:   fmt.Sprintf("Update available: %s", modpackName),
+		Message: message,
+		Tags:    []string{"arrow_up"},
+	})...)
+
+	// Send to the game chat
+	errors = append(errors, m.chat(func(n *MinecraftNotifier) error {
+		return n.SendUpdateNotification(modpackName, currentVersion, newVersion)
+	})...)
+
 	if len(errors) > 0 {
 		return fmt.Errorf("notification errors: %v", errors)
 	}
@@ -105,26 +208,40 @@ func (m *Manager) SendUpdateNotification(modpackName, currentVersion, newVersion
 
 // SendUpdateStartNotification sends a notification when update starts
 func (m *Manager) SendUpdateStartNotification(modpackName, version string) error {
-	if !m.IsEnabled() {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
 		return nil
 	}
 
 	var errors []error
 
 	// Send to Discord
-	if m.discord != nil {
-		if err := m.discord.SendUpdateStartNotification(modpackName, version); err != nil {
+	if discord != nil {
+		if err := discord.SendUpdateStartNotification(modpackName, version); err != nil {
 			errors = append(errors, fmt.Errorf("Discord: %w", err))
 		}
 	}
 
 	// Send to webhook
-	if m.webhook != nil {
-		if err := m.webhook.SendUpdateStartNotification(modpackName, version); err != nil {
+	if webhook != nil {
+		if err := webhook.SendUpdateStartNotification(modpackName, version); err != nil {
 			errors = append(errors, fmt.Errorf("Webhook: %w", err))
 		}
 	}
 
+	// Send to phone push services
+	errors = append(errors, m.push(PushMessage{
+		Title:    fmt.Sprintf("Updating: %s", modpackName),
+		Message:  fmt.Sprintf("Installing %s; the server is temporarily unavailable", version),
+		Priority: PriorityLow,
+		Tags:     []string{"gear"},
+	})...)
+
+	// Send to the game chat
+	errors = append(errors, m.chat(func(n *MinecraftNotifier) error {
+		return n.SendUpdateStartNotification(version)
+	})...)
+
 	if len(errors) > 0 {
 		return fmt.Errorf("notification errors: %v", errors)
 	}
@@ -132,28 +249,54 @@ func (m *Manager) SendUpdateStartNotification(modpackName, version string) error
 	return nil
 }
 
-// SendUpdateSuccessNotification sends a notification when update succeeds
-func (m *Manager) SendUpdateSuccessNotification(modpackName, version string, duration time.Duration) error {
-	if !m.IsEnabled() {
+// ClientPack is the client file players install to join after a server pack update
+type ClientPack struct {
+	Version     string `json:"version"`
+	PageURL     string `json:"page_url"`
+	DownloadURL string `json:"download_url,omitempty"` // empty when the file cannot be downloaded directly
+}
+
+// SendUpdateSuccessNotification sends a notification when update succeeds; mods lists the
+// mods that changed with it and client, if not nil, the client file players need
+func (m *Manager) SendUpdateSuccessNotification(modpackName, version string, duration time.Duration, mods []history.ModChange, client *ClientPack) error {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
 		return nil
 	}
 
 	var errors []error
 
 	// Send to Discord
-	if m.discord != nil {
-		if err := m.discord.SendUpdateSuccessNotification(modpackName, version, duration); err != nil {
+	if discord != nil {
+		if err := discord.SendUpdateSuccessNotification(modpackName, version, duration, mods, client); err != nil {
 			errors = append(errors, fmt.Errorf("Discord: %w", err))
 		}
 	}
 
 	// Send to webhook
-	if m.webhook != nil {
-		if err := m.webhook.SendUpdateSuccessNotification(modpackName, version, duration); err != nil {
+	if webhook != nil {
+		if err := webhook.SendUpdateSuccessNotification(modpackName, version, duration, mods, client); err != nil {
 			errors = append(errors, fmt.Errorf("Webhook: %w", err))
 		}
 	}
 
+	// Send to phone push services
+	msg := PushMessage{
+		Title:   fmt.Sprintf("Update completed: %s", modpackName),
+		Message: fmt.Sprintf("Now on %s, took %s", version, duration.Round(time.Second)),
+		Tags:    []string{"white_check_mark"},
+	}
+	if client != nil {
+		msg.Message += fmt.Sprintf("; players need client %s", client.Version)
+		msg.URL = client.PageURL
+	}
+	errors = append(errors, m.push(msg)...)
+
+	// Send to the game chat
+	errors = append(errors, m.chat(func(n *MinecraftNotifier) error {
+		return n.SendUpdateSuccessNotification(version)
+	})...)
+
 	if len(errors) > 0 {
 		return fmt.Errorf("notification errors: %v", errors)
 	}
@@ -163,26 +306,134 @@ func (m *Manager) SendUpdateSuccessNotification(modpackName, version string, dur
 
 // SendUpdateFailureNotification sends a notification when update fails
 func (m *Manager) SendUpdateFailureNotification(modpackName, version string, errorMsg string) error {
-	if !m.IsEnabled() {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
 		return nil
 	}
 
 	var errors []error
 
 	// Send to Discord
-	if m.discord != nil {
-		if err := m.discord.SendUpdateFailureNotification(modpackName, version, errorMsg); err != nil {
+	if discord != nil {
+		if err := discord.SendUpdateFailureNotification(modpackName, version, errorMsg); err != nil {
 			errors = append(errors, fmt.Errorf("Discord: %w", err))
 		}
 	}
 
 	// Send to webhook
-	if m.webhook != nil {
-		if err := m.webhook.SendUpdateFailureNotification(modpackName, version, errorMsg); err != nil {
+	if webhook != nil {
+		if err := webhook.SendUpdateFailureNotification(modpackName, version, errorMsg); err != nil {
 			errors = append(errors, fmt.Errorf("Webhook: %w", err))
 		}
 	}
 
+	// Send to phone push services
+	errors = append(errors, m.push(PushMessage{
+		Title:    fmt.Sprintf("Update failed: %s", modpackName),
+		Message:  fmt.Sprintf("%s: %s", version, errorMsg),
+		Priority: PriorityHigh,
+		Tags:     []string{"x"},
+	})...)
+
+	// Send to the game chat
+	errors = append(errors, m.chat(func(n *MinecraftNotifier) error {
+		return n.SendUpdateFailureNotification(version)
+	})...)
+
+	if len(errors) > 0 {
+		return fmt.Errorf("notification errors: %v", errors)
+	}
+
+	return nil
+}
+
+// SendUpdateScheduledNotification announces an update that will be installed at the
+// start of the next maintenance window
+func (m *Manager) SendUpdateScheduledNotification(modpackName, currentVersion, newVersion string, at time.Time) error {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
+		return nil
+	}
+
+	var errors []error
+
+	// Send to Discord
+	if discord != nil {
+		if err := discord.SendUpdateScheduledNotification(modpackName, currentVersion, newVersion, at); err != nil {
+			errors = append(errors, fmt.Errorf("Discord: %w", err))
+		}
+	}
+
+	// Send to webhook
+	if webhook != nil {
+		if err := webhook.SendUpdateScheduledNotification(modpackName, currentVersion, newVersion, at); err != nil {
+			errors = append(errors, fmt.Errorf("Webhook: %w", err))
+		}
+	}
+
+	// Send to phone push services
+	errors = append(errors, m.push(PushMessage{
+		Title:   fmt.Sprintf("Update scheduled: %s", modpackName),
+		Message: fmt.Sprintf("%s → %s at %s", currentVersion, newVersion, at.Format("2006-01-02 15:04 MST")),
+		Tags:    []string{"calendar"},
+	})...)
+
+	// Send to the game chat
+	errors = append(errors, m.chat(func(n *MinecraftNotifier) error {
+		return n.SendUpdateScheduledNotification(newVersion, at)
+	})...)
+
+	if len(errors) > 0 {
+		return fmt.Errorf("notification errors: %v", errors)
+	}
+
+	return nil
+}
+
+// Events of SendApprovalNotification
+const (
+	EventApprovalRequested = "approval_requested"
+	EventApprovalReminder  = "approval_reminder"
+	EventApprovalExpired   = "approval_expired"
+)
+
+// ApprovalRequest describes an update that waits for an operator's approval
+type ApprovalRequest struct {
+	ModpackName    string
+	CurrentVersion string
+	NewVersion     string
+	ExpiresAt      time.Time // zero when the request never expires
+	ApproveURL     string    // empty when web.public_url is not set
+	DenyURL        string
+}
+
+// SendApprovalNotification sends an approval request, reminder or expiry to all enabled
+// channels
+func (m *Manager) SendApprovalNotification(event string, req ApprovalRequest) error {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
+		return nil
+	}
+
+	var errors []error
+
+	// Send to Discord
+	if discord != nil {
+		if err := discord.SendApprovalNotification(event, req); err != nil {
+			errors = append(errors, fmt.Errorf("Discord: %w", err))
+		}
+	}
+
+	// Send to webhook
+	if webhook != nil {
+		if err := webhook.SendApprovalNotification(event, req); err != nil {
+			errors = append(errors, fmt.Errorf("Webhook: %w", err))
+		}
+	}
+
+	// Send to phone push services
+	errors = append(errors, m.push(approvalPush(event, req))...)
+
 	if len(errors) > 0 {
 		return fmt.Errorf("notification errors: %v", errors)
 	}
@@ -192,26 +443,35 @@ func (m *Manager) SendUpdateFailureNotification(modpackName, version string, err
 
 // SendBackupNotification sends a backup notification
 func (m *Manager) SendBackupNotification(action, backupName string, size int64) error {
-	if !m.IsEnabled() {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
 		return nil
 	}
 
 	var errors []error
 
 	// Send to Discord
-	if m.discord != nil {
-		if err := m.discord.SendBackupNotification(action, backupName, size); err != nil {
+	if discord != nil {
+		if err := discord.SendBackupNotification(action, backupName, size); err != nil {
 			errors = append(errors, fmt.Errorf("Discord: %w", err))
 		}
 	}
 
 	// Send to webhook
-	if m.webhook != nil {
-		if err := m.webhook.SendBackupNotification(action, backupName, size); err != nil {
+	if webhook != nil {
+		if err := webhook.SendBackupNotification(action, backupName, size); err != nil {
 			errors = append(errors, fmt.Errorf("Webhook: %w", err))
 		}
 	}
 
+	// Send to phone push services
+	errors = append(errors, m.push(PushMessage{
+		Title:    fmt.Sprintf("Backup %s", action),
+		Message:  backupName,
+		Priority: PriorityLow,
+		Tags:     []string{"floppy_disk"},
+	})...)
+
 	if len(errors) > 0 {
 		return fmt.Errorf("notification errors: %v", errors)
 	}
@@ -221,26 +481,30 @@ func (m *Manager) SendBackupNotification(action, backupName string, size int64)
 
 // SendServerStatusNotification sends a server status notification
 func (m *Manager) SendServerStatusNotification(status, message string) error {
-	if !m.IsEnabled() {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
 		return nil
 	}
 
 	var errors []error
 
 	// Send to Discord
-	if m.discord != nil {
-		if err := m.discord.SendServerStatusNotification(status, message); err != nil {
+	if discord != nil {
+		if err := discord.SendServerStatusNotification(status, message); err != nil {
 			errors = append(errors, fmt.Errorf("Discord: %w", err))
 		}
 	}
 
 	// Send to webhook
-	if m.webhook != nil {
-		if err := m.webhook.SendServerStatusNotification(status, message); err != nil {
+	if webhook != nil {
+		if err := webhook.SendServerStatusNotification(status, message); err != nil {
 			errors = append(errors, fmt.Errorf("Webhook: %w", err))
 		}
 	}
 
+	// Send to phone push services
+	errors = append(errors, m.push(serverStatusPush(status, message))...)
+
 	if len(errors) > 0 {
 		return fmt.Errorf("notification errors: %v", errors)
 	}
@@ -250,26 +514,41 @@ func (m *Manager) SendServerStatusNotification(status, message string) error {
 
 // TestConnections tests all notification channels
 func (m *Manager) TestConnections() error {
-	if !m.IsEnabled() {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
 		return fmt.Errorf("notifications are not enabled")
 	}
 
 	var errors []error
 
 	// Test Discord
-	if m.discord != nil {
-		if err := m.discord.TestConnection(); err != nil {
+	if discord != nil {
+		if err := discord.TestConnection(); err != nil {
 			errors = append(errors, fmt.Errorf("Discord test failed: %w", err))
 		}
 	}
 
 	// Test webhook
-	if m.webhook != nil {
-		if err := m.webhook.TestConnection(); err != nil {
+	if webhook != nil {
+		if err := webhook.TestConnection(); err != nil {
 			errors = append(errors, fmt.Errorf("Webhook test failed: %w", err))
 		}
 	}
 
+	// Test phone push services
+	for _, p := range m.pusherList() {
+		if err := p.TestConnection(); err != nil {
+			errors = append(errors, fmt.Errorf("%s test failed: %w", p.Name(), err))
+		}
+	}
+
+	// Test the game chat
+	if n := m.gameChat(); n != nil {
+		if err := n.TestConnection(); err != nil {
+			errors = append(errors, fmt.Errorf("minecraft test failed: %w", err))
+		}
+	}
+
 	if len(errors) > 0 {
 		return fmt.Errorf("notification test errors: %v", errors)
 	}
@@ -310,13 +589,19 @@ func (m *Manager) UpdateConfig(config *config.NotificationConfig) {
 		m.webhook = nil
 	}
 
+	m.pushers = newPushers(config)
+	m.minecraft = newMinecraft(config)
+	m.retrier.SetPolicy(config.Retry)
+
 	// Update enabled status
-	m.enabled = config.Discord.Enabled || config.Webhook.Enabled
+	m.enabled = config.Discord.Enabled || config.Webhook.Enabled || len(m.pushers) > 0 || m.minecraft != nil
+	m.applyClient()
 }
 
 // SendCustomNotification sends a custom notification to specific channels
 func (m *Manager) SendCustomNotification(message string, channels []string) error {
-	if !m.IsEnabled() {
+	discord, webhook, enabled := m.channels()
+	if !enabled {
 		return nil
 	}
 
@@ -325,17 +610,28 @@ func (m *Manager) SendCustomNotification(message string, channels []string) erro
 	for _, channel := range channels {
 		switch channel {
 		case "discord":
-			if m.discord != nil {
-				if err := m.discord.SendMessage(message); err != nil {
+			if discord != nil {
+				if err := discord.SendMessage(message); err != nil {
 					errors = append(errors, fmt.Errorf("Discord: %w", err))
 				}
 			}
 		case "webhook":
-			if m.webhook != nil {
-				if err := m.webhook.SendNotification("custom", message, nil); err != nil {
+			if webhook != nil {
+				if err := webhook.SendNotification("custom", message, nil); err != nil {
 					errors = append(errors, fmt.Errorf("Webhook: %w", err))
 				}
 			}
+		case "pushover", "ntfy":
+			for _, p := range m.pusherList() {
+				if p.Name() != channel {
+					continue
+				}
+				if err := p.Push(PushMessage{Title: "CurseForge Auto-Updater", Message: message}); err != nil {
+					errors = append(errors, fmt.Errorf("%s: %w", p.Name(), err))
+				}
+			}
+		case "minecraft":
+			errors = append(errors, m.chat(func(n *MinecraftNotifier) error { return n.SendMessage(message) })...)
 		default:
 			errors = append(errors, fmt.Errorf("unknown channel: %s", channel))
 		}
@@ -363,6 +659,14 @@ func (m *Manager) GetEnabledChannels() []string {
 		channels = append(channels, "webhook")
 	}
 
+	for _, p := range m.pushers {
+		channels = append(channels, p.Name())
+	}
+
+	if m.minecraft != nil {
+		channels = append(channels, "minecraft")
+	}
+
 	return channels
 }
 
@@ -375,6 +679,12 @@ func (m *Manager) GetStatus() map[string]bool {
 
 	status["discord"] = m.discord != nil
 	status["webhook"] = m.webhook != nil
+	status["pushover"] = false
+	status["ntfy"] = false
+	for _, p := range m.pushers {
+		status[p.Name()] = true
+	}
+	status["minecraft"] = m.minecraft != nil
 	status["enabled"] = m.enabled
 
 	return status
@@ -388,6 +698,8 @@ func (m *Manager) Disable() {
 	m.enabled = false
 	m.discord = nil
 	m.webhook = nil
+	m.pushers = nil
+	m.minecraft = nil
 }
 
 // Enable enables notifications with the given configuration
@@ -403,5 +715,84 @@ func (m *Manager) Enable(config *config.NotificationConfig) {
 		m.webhook = NewWebhookNotifier(&config.Webhook)
 	}
 
-	m.enabled = config.Discord.Enabled || config.Webhook.Enabled
+	m.pushers = newPushers(config)
+	m.minecraft = newMinecraft(config)
+	m.retrier.SetPolicy(config.Retry)
+	m.enabled = config.Discord.Enabled || config.Webhook.Enabled || len(m.pushers) > 0 || m.minecraft != nil
+	m.applyClient()
+}
+
+// SetHTTPClient makes every notifier send through client, e.g. one built from the
+// proxy and TLS settings; the webhook keeps its own timeout when one is configured.
+// Requests are retried on top of client according to notifications.retry.
+func (m *Manager) SetHTTPClient(client *http.Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.client = client
+	m.applyClient()
+}
+
+// EndpointStats returns the request, retry and failure counts of every endpoint the
+// notifiers sent to, and whether it is skipped after failing repeatedly
+func (m *Manager) EndpointStats() []httpclient.EndpointStats {
+	return m.retrier.Stats()
+}
+
+// applyClient hands the shared client, wrapped by the retrier, to the current notifiers;
+// callers hold m.mu
+func (m *Manager) applyClient() {
+	// Without a shared client the notifiers keep their own, with their default timeouts
+	client := func(own *http.Client) *http.Client {
+		if m.client != nil {
+			return m.retrier.Client(m.client)
+		}
+		return m.retrier.Client(own)
+	}
+	if m.discord != nil {
+		m.discord.client = client(m.discord.client)
+	}
+	if m.webhook != nil {
+		webhookClient := *client(m.webhook.client)
+		if m.webhook.config.Timeout > 0 {
+			webhookClient.Timeout = m.webhook.config.Timeout
+		}
+		m.webhook.client = &webhookClient
+	}
+	for _, p := range m.pushers {
+		p.setClient(client(nil))
+	}
+}
+
+// approvalPush is the push message of an approval event; tapping it opens the approve link
+func approvalPush(event string, req ApprovalRequest) PushMessage {
+	msg := PushMessage{
+		Title:    fmt.Sprintf("Update waiting for approval: %s", req.ModpackName),
+		Message:  fmt.Sprintf("%s → %s", req.CurrentVersion, req.NewVersion),
+		Priority: PriorityHigh,
+		URL:      req.ApproveURL,
+		Tags:     []string{"passport_control"},
+	}
+	switch event {
+	case EventApprovalReminder:
+		msg.Title = fmt.Sprintf("Still waiting for approval: %s", req.ModpackName)
+	case EventApprovalExpired:
+		msg.Title = fmt.Sprintf("Approval expired: %s", req.ModpackName)
+		msg.Message = fmt.Sprintf("Nobody approved %s in time, so it will not be installed", req.NewVersion)
+		msg.Priority, msg.URL, msg.Tags = PriorityNormal, "", []string{"hourglass"}
+	}
+	return msg
+}
+
+// serverStatusPush is the push message of a server status change; a server going offline
+// is urgent
+func serverStatusPush(status, message string) PushMessage {
+	msg := PushMessage{Title: fmt.Sprintf("Server %s", status), Message: message, Priority: PriorityLow}
+	switch status {
+	case "offline":
+		msg.Priority, msg.Tags = PriorityHigh, []string{"red_circle"}
+	case "degraded":
+		msg.Priority, msg.Tags = PriorityNormal, []string{"warning"}
+	}
+	return msg
 }
@@ -0,0 +1,132 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/rcon"
+)
+
+// minecraftTimeout limits connecting to the server and running the tellraw command
+const minecraftTimeout = 10 * time.Second
+
+// Colors of game chat messages
+const (
+	chatInfo    = "yellow"
+	chatSuccess = "green"
+	chatFailure = "red"
+)
+
+// MinecraftNotifier posts update messages into the game chat of the server with tellraw
+// over RCON, so players online see them without any other service
+type MinecraftNotifier struct {
+	config *config.MinecraftChatConfig
+	send   func(command string) error // runs a console command, replaced in tests
+	now    func() time.Time
+}
+
+// NewMinecraftNotifier creates a new game chat notifier
+func NewMinecraftNotifier(config *config.MinecraftChatConfig) *MinecraftNotifier {
+	n := &MinecraftNotifier{config: config, now: time.Now}
+	n.send = n.command
+	return n
+}
+
+// SendUpdateNotification tells players that a new pack version is available
+func (n *MinecraftNotifier) SendUpdateNotification(modpackName, currentVersion, newVersion string) error {
+	return n.say(fmt.Sprintf("%s %s is available (running %s)", modpackName, newVersion, currentVersion), chatInfo)
+}
+
+// SendUpdateScheduledNotification tells players when the update will be installed
+func (n *MinecraftNotifier) SendUpdateScheduledNotification(newVersion string, at time.Time) error {
+	return n.say(fmt.Sprintf("Pack update to %s installing %s", newVersion, chatWhen(at, n.now())), chatInfo)
+}
+
+// SendUpdateStartNotification warns players that the update is being installed
+func (n *MinecraftNotifier) SendUpdateStartNotification(version string) error {
+	return n.say(fmt.Sprintf("Installing pack update %s, the server will restart shortly", version), chatInfo)
+}
+
+// SendUpdateSuccessNotification tells players the update was installed
+func (n *MinecraftNotifier) SendUpdateSuccessNotification(version string) error {
+	return n.say(fmt.Sprintf("Pack updated to %s", version), chatSuccess)
+}
+
+// SendUpdateFailureNotification tells players the update failed and the server stays as it is
+func (n *MinecraftNotifier) SendUpdateFailureNotification(version string) error {
+	if version == "" {
+		return n.say("Pack update failed, the server stays on its current version", chatFailure)
+	}
+	return n.say(fmt.Sprintf("Pack update to %s failed, the server stays on its current version", version), chatFailure)
+}
+
+// SendMessage posts a plain message
+func (n *MinecraftNotifier) SendMessage(message string) error {
+	return n.say(message, chatInfo)
+}
+
+// TestConnection posts a test message. Unlike the other messages it fails when the
+// server is not running.
+func (n *MinecraftNotifier) TestConnection() error {
+	return n.send(n.tellraw("Test notification: game chat notifications are working.", chatSuccess))
+}
+
+// say posts message to every player. A server that refuses the connection is stopped,
+// so nobody is online to read it and that is not an error.
+func (n *MinecraftNotifier) say(message, color string) error {
+	err := n.send(n.tellraw(message, color))
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return nil
+	}
+	return err
+}
+
+// tellraw builds the command that shows message after the gold prefix
+func (n *MinecraftNotifier) tellraw(message, color string) string {
+	type component struct {
+		Text  string `json:"text"`
+		Color string `json:"color,omitempty"`
+	}
+	parts := []any{""}
+	if n.config.Prefix != "" {
+		parts = append(parts, component{Text: n.config.Prefix + " ", Color: "gold"})
+	}
+	parts = append(parts, component{Text: message, Color: color})
+	text, _ := json.Marshal(parts)
+	return "tellraw @a " + string(text)
+}
+
+// command runs command over a new RCON connection
+func (n *MinecraftNotifier) command(command string) error {
+	client, err := rcon.Dial(n.config.RCON.Address, n.config.RCON.Password, minecraftTimeout)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	_, err = client.Command(command)
+	return err
+}
+
+// chatWhen describes at relative to now the way players would say it, e.g.
+// "tonight at 02:00" or "on Sat 14 Mar at 04:00"
+func chatWhen(at, now time.Time) string {
+	at = at.In(now.Location())
+	clock := at.Format("15:04")
+	day := func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()) }
+	switch days := int(day(at).Sub(day(now)).Hours()+12) / 24; {
+	case days <= 0 && at.Hour() >= 18:
+		return "tonight at " + clock
+	case days <= 0:
+		return "today at " + clock
+	case days == 1 && at.Hour() < 6:
+		return "tonight at " + clock
+	case days == 1:
+		return "tomorrow at " + clock
+	default:
+		return "on " + at.Format("Mon 2 Jan") + " at " + clock
+	}
+}
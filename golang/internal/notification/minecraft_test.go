@@ -0,0 +1,75 @@
+package notification
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func TestMinecraftChat(t *testing.T) {
+	var got []string
+	n := NewMinecraftNotifier(&config.MinecraftChatConfig{Enabled: true, Prefix: "[Updates]"})
+	n.send = func(command string) error {
+		got = append(got, command)
+		return nil
+	}
+	n.now = func() time.Time { return time.Date(2024, 6, 1, 21, 0, 0, 0, time.UTC) }
+	m := &Manager{minecraft: n, enabled: true}
+
+	if err := m.SendUpdateScheduledNotification("Pack", "1.20", "1.21", time.Date(2024, 6, 2, 2, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SendUpdateFailureNotification("Pack", "1.21", "download failed"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`tellraw @a ["",{"text":"[Updates] ","color":"gold"},{"text":"Pack update to 1.21 installing tonight at 02:00","color":"yellow"}]`,
+		`tellraw @a ["",{"text":"[Updates] ","color":"gold"},{"text":"Pack update to 1.21 failed, the server stays on its current version","color":"red"}]`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("sent %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("command %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMinecraftChatServerStopped(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := ln.Addr().String()
+	ln.Close()
+
+	cfg := &config.MinecraftChatConfig{Enabled: true, RCON: config.RCONConfig{Address: address}}
+	m := NewManager(&config.NotificationConfig{Minecraft: *cfg})
+	if err := m.SendUpdateSuccessNotification("Pack", "1.21", time.Minute, nil, nil); err != nil {
+		t.Errorf("message to a stopped server: %v", err)
+	}
+	if err := m.TestConnections(); err == nil {
+		t.Error("TestConnections succeeded without a server")
+	}
+}
+
+func TestChatWhen(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC) // a Saturday
+	for _, tc := range []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2024, 6, 1, 14, 30, 0, 0, time.UTC), "today at 14:30"},
+		{time.Date(2024, 6, 1, 22, 0, 0, 0, time.UTC), "tonight at 22:00"},
+		{time.Date(2024, 6, 2, 3, 0, 0, 0, time.UTC), "tonight at 03:00"},
+		{time.Date(2024, 6, 2, 9, 0, 0, 0, time.UTC), "tomorrow at 09:00"},
+		{time.Date(2024, 6, 4, 2, 0, 0, 0, time.UTC), "on Tue 4 Jun at 02:00"},
+	} {
+		if got := chatWhen(tc.at, now); got != tc.want {
+			t.Errorf("chatWhen(%s) = %q, want %q", tc.at, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package notification
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// PushoverURL is the Pushover message endpoint
+const PushoverURL = "https://api.pushover.net/1/messages.json"
+
+// Priorities of a push message; phone services map them to their own levels
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+// PushMessage is a notification for phone push services: a short title and text
+type PushMessage struct {
+	Title    string
+	Message  string
+	Priority int
+	URL      string // opened when the notification is tapped, optional
+	Tags     []string
+}
+
+// pusher sends push messages to one service
+type pusher interface {
+	Name() string
+	Push(msg PushMessage) error
+	TestConnection() error
+	setClient(client *http.Client)
+}
+
+// PushoverNotifier sends notifications through Pushover
+type PushoverNotifier struct {
+	config   *config.PushoverConfig
+	client   *http.Client
+	endpoint string
+}
+
+// NewPushoverNotifier creates a new Pushover notifier
+func NewPushoverNotifier(config *config.PushoverConfig) *PushoverNotifier {
+	return &PushoverNotifier{config: config, client: &http.Client{}, endpoint: PushoverURL}
+}
+
+// Name returns the channel name
+func (p *PushoverNotifier) Name() string {
+	return "pushover"
+}
+
+// Push sends msg to the configured user
+func (p *PushoverNotifier) Push(msg PushMessage) error {
+	form := url.Values{
+		"token":    {p.config.Token},
+		"user":     {p.config.User},
+		"title":    {msg.Title},
+		"message":  {msg.Message},
+		"priority": {strconv.Itoa(msg.Priority)},
+	}
+	if p.config.Device != "" {
+		form.Set("device", p.config.Device)
+	}
+	if msg.URL != "" {
+		form.Set("url", msg.URL)
+	}
+	resp, err := p.client.PostForm(p.endpoint, form)
+	if err != nil {
+		return fmt.Errorf("failed to send Pushover message: %w", err)
+	}
+	return checkPushResponse("Pushover", resp)
+}
+
+// TestConnection sends a test message
+func (p *PushoverNotifier) TestConnection() error {
+	return p.Push(testPushMessage())
+}
+
+func (p *PushoverNotifier) setClient(client *http.Client) {
+	p.client = client
+}
+
+// NtfyNotifier publishes notifications to an ntfy topic
+type NtfyNotifier struct {
+	config *config.NtfyConfig
+	client *http.Client
+}
+
+// NewNtfyNotifier creates a new ntfy notifier
+func NewNtfyNotifier(config *config.NtfyConfig) *NtfyNotifier {
+	return &NtfyNotifier{config: config, client: &http.Client{}}
+}
+
+// Name returns the channel name
+func (n *NtfyNotifier) Name() string {
+	return "ntfy"
+}
+
+// Push publishes msg to the configured topic
+func (n *NtfyNotifier) Push(msg PushMessage) error {
+	topic := strings.TrimRight(n.config.Server, "/") + "/" + url.PathEscape(n.config.Topic)
+	req, err := http.NewRequest(http.MethodPost, topic, strings.NewReader(msg.Message))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+	// Headers are ASCII, so other titles are sent RFC 2047 encoded, which ntfy decodes.
+	// ntfy priorities run from 1 (min) to 5 (max) with 3 as the default.
+	req.Header.Set("Title", mime.QEncoding.Encode("utf-8", msg.Title))
+	req.Header.Set("Priority", strconv.Itoa(3+msg.Priority))
+	if len(msg.Tags) > 0 {
+		req.Header.Set("Tags", strings.Join(msg.Tags, ","))
+	}
+	if msg.URL != "" {
+		req.Header.Set("Click", msg.URL)
+	}
+	if n.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.config.Token)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send ntfy message: %w", err)
+	}
+	return checkPushResponse("ntfy", resp)
+}
+
+// TestConnection sends a test message
+func (n *NtfyNotifier) TestConnection() error {
+	return n.Push(testPushMessage())
+}
+
+func (n *NtfyNotifier) setClient(client *http.Client) {
+	n.client = client
+}
+
+// checkPushResponse closes resp and turns an error status into an error with the
+// start of the body, which names the problem for both services
+func checkPushResponse(service string, resp *http.Response) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s returned status code %d: %s", service, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// testPushMessage is sent by TestConnection
+func testPushMessage() PushMessage {
+	return PushMessage{
+		Title:    "CurseForge Auto-Updater",
+		Message:  "Test notification: push notifications are working.",
+		Priority: PriorityLow,
+		Tags:     []string{"white_check_mark"},
+	}
+}
@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func TestNtfyPush(t *testing.T) {
+	var got *http.Request
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+	}))
+	defer srv.Close()
+
+	m := NewManager(&config.NotificationConfig{Ntfy: config.NtfyConfig{Enabled: true, Server: srv.URL + "/", Topic: "mc-server", Token: "tk"}})
+	if err := m.SendUpdateFailureNotification("Mod 1", "2.0", "download failed"); err != nil {
+		t.Fatal(err)
+	}
+	if got.URL.Path != "/mc-server" {
+		t.Errorf("published to %s, want /mc-server", got.URL.Path)
+	}
+	if body != "2.0: download failed" {
+		t.Errorf("body = %q", body)
+	}
+	for header, want := range map[string]string{
+		"Title":         "Update failed: Mod 1",
+		"Priority":      "4",
+		"Tags":          "x",
+		"Authorization": "Bearer tk",
+	} {
+		if v := got.Header.Get(header); v != want {
+			t.Errorf("%s = %q, want %q", header, v, want)
+		}
+	}
+}
+
+func TestPushoverPush(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		got = r
+		if r.PostForm.Get("token") != "app" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"token":"invalid","status":0}`))
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &config.PushoverConfig{Enabled: true, Token: "app", User: "usr"}
+	p := NewPushoverNotifier(cfg)
+	p.endpoint = srv.URL
+	m := &Manager{pushers: []pusher{p}, enabled: true}
+	if err := m.SendUpdateSuccessNotification("Mod 1", "2.0", 90*time.Second, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	for field, want := range map[string]string{
+		"user":     "usr",
+		"title":    "Update completed: Mod 1",
+		"message":  "Now on 2.0, took 1m30s",
+		"priority": "0",
+	} {
+		if v := got.PostForm.Get(field); v != want {
+			t.Errorf("%s = %q, want %q", field, v, want)
+		}
+	}
+
+	cfg.Token = "wrong"
+	if err := p.TestConnection(); err == nil {
+		t.Fatal("expected an error for a rejected token")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
 )
 
 // WebhookNotifier handles generic webhook notifications
@@ -52,12 +53,13 @@ func (w *WebhookNotifier) SendNotification(event, message string, data map[strin
 }
 
 // SendUpdateNotification sends an update notification via webhook
-func (w *WebhookNotifier) SendUpdateNotification(modpackName, currentVersion, newVersion, changelog string) error {
+func (w *WebhookNotifier) SendUpdateNotification(modpackName, currentVersion, newVersion, changelog, policy string) error {
 	data := map[string]interface{}{
 		"modpack_name":    modpackName,
 		"current_version": currentVersion,
 		"new_version":     newVersion,
 		"changelog":       changelog,
+		"policy":          policy,
 	}
 
 	message := fmt.Sprintf("Modpack update available: %s (%s -> %s)", modpackName, currentVersion, newVersion)
@@ -75,13 +77,32 @@ func (w *WebhookNotifier) SendUpdateStartNotification(modpackName, version strin
 	return w.SendNotification("update_started", message, data)
 }
 
+// SendUpdateScheduledNotification announces an update at the next maintenance window
+func (w *WebhookNotifier) SendUpdateScheduledNotification(modpackName, currentVersion, newVersion string, at time.Time) error {
+	data := map[string]interface{}{
+		"modpack_name":    modpackName,
+		"current_version": currentVersion,
+		"new_version":     newVersion,
+		"scheduled_at":    at.Format(time.RFC3339),
+	}
+
+	message := fmt.Sprintf("Update scheduled: %s to version %s at %s", modpackName, newVersion, at.Format("2006-01-02 15:04 MST"))
+	return w.SendNotification("update_scheduled", message, data)
+}
+
 // SendUpdateSuccessNotification sends a notification when update succeeds
-func (w *WebhookNotifier) SendUpdateSuccessNotification(modpackName, version string, duration time.Duration) error {
+func (w *WebhookNotifier) SendUpdateSuccessNotification(modpackName, version string, duration time.Duration, mods []history.ModChange, client *ClientPack) error {
 	data := map[string]interface{}{
 		"modpack_name": modpackName,
 		"version":      version,
 		"duration":     duration.String(),
 	}
+	if len(mods) > 0 {
+		data["mods"] = mods
+	}
+	if client != nil {
+		data["client_pack"] = client
+	}
 
 	message := fmt.Sprintf("Update completed successfully: %s updated to version %s", modpackName, version)
 	return w.SendNotification("update_success", message, data)
@@ -99,6 +120,28 @@ func (w *WebhookNotifier) SendUpdateFailureNotification(modpackName, version str
 	return w.SendNotification("update_failed", message, data)
 }
 
+// SendApprovalNotification asks for, reminds of or reports the expiry of an approval
+func (w *WebhookNotifier) SendApprovalNotification(event string, req ApprovalRequest) error {
+	data := map[string]interface{}{
+		"modpack_name":    req.ModpackName,
+		"current_version": req.CurrentVersion,
+		"new_version":     req.NewVersion,
+	}
+	if !req.ExpiresAt.IsZero() {
+		data["expires_at"] = req.ExpiresAt.Format(time.RFC3339)
+	}
+	if req.ApproveURL != "" {
+		data["approve_url"] = req.ApproveURL
+		data["deny_url"] = req.DenyURL
+	}
+
+	message := fmt.Sprintf("Update waiting for approval: %s (%s -> %s)", req.ModpackName, req.CurrentVersion, req.NewVersion)
+	if event == EventApprovalExpired {
+		message = fmt.Sprintf("Approval expired, update not installed: %s (%s -> %s)", req.ModpackName, req.CurrentVersion, req.NewVersion)
+	}
+	return w.SendNotification(event, message, data)
+}
+
 // SendBackupNotification sends a backup notification
 func (w *WebhookNotifier) SendBackupNotification(action, backupName string, size int64) error {
 	data := map[string]interface{}{
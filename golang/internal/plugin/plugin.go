@@ -0,0 +1,237 @@
+// Package plugin runs external programs as custom update steps. Plugins are executables in
+// the plugins directory that read one JSON Request on stdin and write one JSON Response on
+// stdout; see the README for the protocol.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// ProtocolVersion is sent with every request so plugins can reject versions they do not know
+const ProtocolVersion = 1
+
+// Stages at which plugins run. A plugin that fails at a Blocking stage aborts the update.
+const (
+	StageDescribe     = "describe" // asks the plugin for its name and stages
+	StagePreUpdate    = "pre_update"
+	StagePostBackup   = "post_backup"
+	StagePostInstall  = "post_install"
+	StagePostUpdate   = "post_update"
+	StageUpdateFailed = "update_failed"
+)
+
+// Stages lists the stages plugins can subscribe to, in the order they run
+var Stages = []string{StagePreUpdate, StagePostBackup, StagePostInstall, StagePostUpdate, StageUpdateFailed}
+
+// describeTimeout bounds the describe handshake, which should not do any work
+const describeTimeout = 10 * time.Second
+
+// Blocking reports whether a failure at stage aborts the update
+func Blocking(stage string) bool {
+	return stage == StagePreUpdate || stage == StagePostBackup
+}
+
+// Request is written to the plugin's stdin
+type Request struct {
+	Protocol    int    `json:"protocol"`
+	Stage       string `json:"stage"`
+	ModID       int    `json:"mod_id,omitempty"`
+	FromVersion string `json:"from_version,omitempty"`
+	ToVersion   string `json:"to_version,omitempty"`
+	FromFileID  int    `json:"from_file_id,omitempty"`
+	ToFileID    int    `json:"to_file_id,omitempty"`
+	ServerPath  string `json:"server_path,omitempty"`
+	Backup      string `json:"backup,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Response is read from the plugin's stdout
+type Response struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	// Name and Stages answer the describe request
+	Name   string   `json:"name,omitempty"`
+	Stages []string `json:"stages,omitempty"`
+}
+
+// Plugin is a discovered plugin executable
+type Plugin struct {
+	Name   string
+	Path   string
+	Stages []string
+}
+
+// Runner discovers plugins in a directory and runs them at each stage
+type Runner struct {
+	cfg    config.PluginsConfig
+	logger *slog.Logger
+
+	once    sync.Once
+	plugins []Plugin
+	err     error
+}
+
+// NewRunner creates a runner for the plugins in cfg.Dir
+func NewRunner(cfg config.PluginsConfig, logger *slog.Logger) *Runner {
+	return &Runner{cfg: cfg, logger: logger}
+}
+
+// Plugins discovers the plugins on first use. A missing directory means no plugins.
+func (r *Runner) Plugins() ([]Plugin, error) {
+	r.once.Do(func() {
+		r.plugins, r.err = r.discover()
+	})
+	return r.plugins, r.err
+}
+
+// discover describes every executable in the plugins directory, applying the stage
+// overrides from the config
+func (r *Runner) discover() ([]Plugin, error) {
+	if r.cfg.Dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(r.cfg.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	var plugins []Plugin
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !executable(entry.Name(), info.Mode()) {
+			continue
+		}
+
+		p := Plugin{Path: filepath.Join(r.cfg.Dir, entry.Name())}
+		ctx, cancel := context.WithTimeout(context.Background(), describeTimeout)
+		resp, err := r.exec(ctx, p.Path, Request{Protocol: ProtocolVersion, Stage: StageDescribe})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: describe failed: %w", entry.Name(), err)
+		}
+		p.Name = resp.Name
+		if p.Name == "" {
+			p.Name = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		}
+		p.Stages = resp.Stages
+		if stages, ok := r.cfg.Stages[p.Name]; ok {
+			p.Stages = stages
+		}
+		for _, stage := range p.Stages {
+			if !slices.Contains(Stages, stage) {
+				return nil, fmt.Errorf("plugin %s: unknown stage %q", p.Name, stage)
+			}
+		}
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, nil
+}
+
+// Run runs every plugin subscribed to req.Stage in name order. All plugins run even when one
+// fails; their errors are joined.
+func (r *Runner) Run(ctx context.Context, req Request) error {
+	plugins, err := r.Plugins()
+	if err != nil {
+		return err
+	}
+	req.Protocol = ProtocolVersion
+
+	var errs []error
+	for _, p := range plugins {
+		if !slices.Contains(p.Stages, req.Stage) {
+			continue
+		}
+		runCtx, cancel := ctx, context.CancelFunc(func() {})
+		if r.cfg.Timeout > 0 {
+			runCtx, cancel = context.WithTimeout(ctx, r.cfg.Timeout)
+		}
+		started := time.Now()
+		resp, err := r.exec(runCtx, p.Path, req)
+		cancel()
+		if err != nil {
+			r.logger.Error("plugin failed", "plugin", p.Name, "stage", req.Stage, "error", err)
+			errs = append(errs, fmt.Errorf("plugin %s: %w", p.Name, err))
+			continue
+		}
+		r.logger.Info("plugin finished", "plugin", p.Name, "stage", req.Stage, "message", resp.Message,
+			"duration", time.Since(started).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
+
+// exec runs one plugin with req on stdin and decodes its response. Anything the plugin
+// writes to stderr is logged.
+func (r *Runner) exec(ctx context.Context, path string, req Request) (*Response, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// #nosec G204 -- plugins are executables the operator placed in the plugins directory
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	if stderr.Len() > 0 {
+		r.logger.Debug("plugin output", "plugin", filepath.Base(path), "stderr", strings.TrimSpace(stderr.String()))
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out")
+	}
+
+	var resp Response
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &resp); err != nil {
+		if runErr != nil {
+			return nil, runErr
+		}
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	switch {
+	case !resp.OK && resp.Error != "":
+		return nil, errors.New(resp.Error)
+	case !resp.OK:
+		return nil, fmt.Errorf("plugin reported failure")
+	case runErr != nil:
+		return nil, runErr
+	}
+	return &resp, nil
+}
+
+// executable reports whether a directory entry can be run as a plugin
+func executable(name string, mode os.FileMode) bool {
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".exe", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+	return mode.IsRegular() && mode&0o111 != 0
+}
@@ -0,0 +1,82 @@
+package plugin
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// writePlugin writes a shell script plugin that answers describe with stages and appends
+// every other request to log
+func writePlugin(t *testing.T, dir, name, stages, response string) {
+	t.Helper()
+	script := `#!/bin/sh
+read -r req
+case "$req" in
+*'"stage":"describe"'*) echo '{"ok":true,"stages":[` + stages + `]}' ;;
+*) echo "$req" >> "` + filepath.Join(dir, name+".log") + `"; echo '` + response + `' ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	dir := t.TempDir()
+	writePlugin(t, dir, "purge-cdn", `"post_update"`, `{"ok":true,"message":"purged"}`)
+	writePlugin(t, dir, "check-players", `"pre_update"`, `{"ok":false,"error":"players online"}`)
+	writePlugin(t, dir, "restart-pod", `"post_update"`, `{"ok":true}`)
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a plugin"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRunner(config.PluginsConfig{
+		Dir:    dir,
+		Stages: map[string][]string{"restart-pod": {StagePostInstall}},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	plugins, err := r.Plugins()
+	if err != nil {
+		t.Fatalf("Plugins: %v", err)
+	}
+	if len(plugins) != 3 || plugins[0].Name != "check-players" || plugins[2].Stages[0] != StagePostInstall {
+		t.Fatalf("unexpected plugins %+v", plugins)
+	}
+
+	err = r.Run(context.Background(), Request{Stage: StagePreUpdate, ToVersion: "1.1.0"})
+	if err == nil || !strings.Contains(err.Error(), "check-players: players online") {
+		t.Fatalf("pre_update err = %v, want the plugin's error", err)
+	}
+	if err := r.Run(context.Background(), Request{Stage: StagePostUpdate, ToVersion: "1.1.0"}); err != nil {
+		t.Fatalf("post_update: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "purge-cdn.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"protocol":1,"stage":"post_update"`) || !strings.Contains(string(data), `"to_version":"1.1.0"`) {
+		t.Fatalf("unexpected request %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "restart-pod.log")); !os.IsNotExist(err) {
+		t.Fatal("restart-pod ran at post_update although the config moved it to post_install")
+	}
+}
+
+func TestRunnerMissingDir(t *testing.T) {
+	r := NewRunner(config.PluginsConfig{Dir: filepath.Join(t.TempDir(), "missing")}, slog.Default())
+	if err := r.Run(context.Background(), Request{Stage: StagePreUpdate}); err != nil {
+		t.Fatalf("Run without a plugins directory: %v", err)
+	}
+}
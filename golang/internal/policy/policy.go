@@ -0,0 +1,276 @@
+// Package policy decides whether the daemon installs an update it found by itself, from
+// the rules of update_policy. Every decision carries the outcome of each rule that is
+// set, so that it can be logged and sent with the update notification.
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// Week is the period max_updates_per_week counts installs over
+const Week = 7 * 24 * time.Hour
+
+// maxMatch is the longest matched text a Hold reports, in runes
+const maxMatch = 80
+
+// Candidate is the update a policy decides on
+type Candidate struct {
+	Version      string
+	FileName     string
+	ReleaseType  string // release, beta or alpha
+	Published    time.Time
+	GameVersions []string
+
+	// Changelog is only needed when a hold rule looks at it, see NeedsChangelog
+	Changelog    string
+	ChangelogErr error // why the changelog could not be read
+
+	// MinecraftUpgrade describes the move to a newer Minecraft version, e.g.
+	// "Minecraft 1.20.1 -> 1.21.1"; empty when the version stays the same
+	MinecraftUpgrade string
+}
+
+// CandidateFor describes file as a candidate. Its changelog is read with changelog only
+// when one of rules looks at it.
+func CandidateFor(file *api.ModFile, rules []config.HoldRule, changelog func(*api.ModFile) (string, error)) Candidate {
+	c := Candidate{
+		Version:      file.DisplayName,
+		FileName:     file.FileName,
+		ReleaseType:  api.ReleaseTypeName(file.ReleaseType),
+		Published:    file.FileDate,
+		GameVersions: file.GameVersions,
+	}
+	if NeedsChangelog(rules) {
+		c.Changelog, c.ChangelogErr = changelog(file)
+	}
+	return c
+}
+
+// Facts are what the rules look at besides the candidate
+type Facts struct {
+	Now            time.Time // blackout dates are matched in its location
+	RecentInstalls int       // updates installed in the Week before Now
+	PlayersOnline  int       // only read with require_empty_server
+	PlayersErr     error     // why the players online could not be counted
+}
+
+// Check is the outcome of one rule
+type Check struct {
+	Rule   string `json:"rule"` // the update_policy key
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason"`
+}
+
+// Decision is the outcome of Evaluate
+type Decision struct {
+	Install bool    `json:"install"`
+	Checks  []Check `json:"checks"`
+}
+
+// Reasons returns the reasons of the rules that held the update back, or of every rule
+// when it may be installed
+func (d Decision) Reasons() []string {
+	var reasons []string
+	for _, c := range d.Checks {
+		if d.Install || !c.Passed {
+			reasons = append(reasons, c.Reason)
+		}
+	}
+	return reasons
+}
+
+// String summarizes the decision for logs and notifications
+func (d Decision) String() string {
+	verdict := "hold back"
+	if d.Install {
+		verdict = "install"
+	}
+	if reasons := d.Reasons(); len(reasons) > 0 {
+		return verdict + ": " + strings.Join(reasons, "; ")
+	}
+	return verdict
+}
+
+// Evaluate applies the rules of p to c. The update is installed only when auto_update is
+// on and every other rule that is set allows it.
+func Evaluate(p config.UpdatePolicyConfig, c Candidate, f Facts) Decision {
+	var d Decision
+	add := func(rule string, passed bool, format string, args ...interface{}) {
+		d.Checks = append(d.Checks, Check{Rule: rule, Passed: passed, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if p.AutoUpdate {
+		add("auto_update", true, "auto_update is on")
+	} else {
+		add("auto_update", false, "auto_update is off")
+	}
+	if len(p.ReleaseTypes) > 0 {
+		allowed := slices.Contains(p.ReleaseTypes, c.ReleaseType)
+		add("release_types", allowed, "%s is %s release_types %s", c.ReleaseType, inOrNot(allowed), strings.Join(p.ReleaseTypes, ", "))
+	}
+	if p.MinFileAge > 0 {
+		if c.Published.IsZero() {
+			add("min_file_age", false, "the publish date is unknown, min_file_age is %s", p.MinFileAge)
+		} else {
+			age := f.Now.Sub(c.Published).Truncate(time.Minute)
+			add("min_file_age", age >= p.MinFileAge, "published %s ago, min_file_age is %s", age, p.MinFileAge)
+		}
+	}
+	if len(p.GameVersions) > 0 {
+		supported := supports(c.GameVersions, p.GameVersions)
+		add("game_versions", supported, "supports %s, which is %s game_versions %s",
+			orNone(strings.Join(c.GameVersions, ", ")), inOrNot(supported), strings.Join(p.GameVersions, ", "))
+	}
+	if len(p.BlackoutDates) > 0 {
+		if dates := blackout(p.BlackoutDates, f.Now); dates != "" {
+			add("blackout_dates", false, "%s is in the blackout dates %s", f.Now.Format(time.DateOnly), dates)
+		} else {
+			add("blackout_dates", true, "%s is outside the blackout dates", f.Now.Format(time.DateOnly))
+		}
+	}
+	if p.MaxUpdatesPerWeek > 0 {
+		add("max_updates_per_week", f.RecentInstalls < p.MaxUpdatesPerWeek, "%d of max_updates_per_week %d installed in the last 7 days",
+			f.RecentInstalls, p.MaxUpdatesPerWeek)
+	}
+	if len(p.HoldRules) > 0 {
+		switch hold := MatchHoldRules(p.HoldRules, c); {
+		case hold != nil:
+			add("hold_rules", false, "%s, held for manual review", hold)
+		case c.ChangelogErr != nil && NeedsChangelog(p.HoldRules):
+			add("hold_rules", false, "the changelog for hold_rules could not be read: %v", c.ChangelogErr)
+		default:
+			add("hold_rules", true, "no hold rule matched")
+		}
+	}
+	if c.MinecraftUpgrade != "" {
+		if p.ConfirmMinecraftUpgrades {
+			add("confirm_minecraft_upgrades", false, "%s needs confirming, worlds cannot be opened by the old version again", c.MinecraftUpgrade)
+		} else {
+			add("confirm_minecraft_upgrades", true, "%s, worlds cannot be opened by the old version again", c.MinecraftUpgrade)
+		}
+	}
+	if p.RequireEmptyServer {
+		switch {
+		case f.PlayersErr != nil:
+			add("require_empty_server", false, "players online unknown: %v", f.PlayersErr)
+		case f.PlayersOnline > 0:
+			add("require_empty_server", false, "%d players online, require_empty_server is on", f.PlayersOnline)
+		default:
+			add("require_empty_server", true, "nobody is online")
+		}
+	}
+
+	d.Install = true
+	for _, check := range d.Checks {
+		d.Install = d.Install && check.Passed
+	}
+	return d
+}
+
+// Hold is a hold rule that matched an update
+type Hold struct {
+	Rule  string `json:"rule"`  // its name, or its pattern when it has none
+	Field string `json:"field"` // file_name or changelog
+	Match string `json:"match"` // the text the pattern matched, shortened
+}
+
+// String describes the match for logs and notifications
+func (h Hold) String() string {
+	return fmt.Sprintf("hold rule %q matched %q in the %s", h.Rule, h.Match, strings.ReplaceAll(h.Field, "_", " "))
+}
+
+// MatchHoldRules returns the first of rules that matches c, or nil when none does.
+// File name patterns are matched against the display name and the file name.
+func MatchHoldRules(rules []config.HoldRule, c Candidate) *Hold {
+	for _, rule := range rules {
+		fields := []struct {
+			key, pattern string
+			texts        []string
+		}{
+			{"file_name", rule.FileName, []string{c.Version, c.FileName}},
+			{"changelog", rule.Changelog, []string{c.Changelog}},
+		}
+		for _, field := range fields {
+			if field.pattern == "" {
+				continue
+			}
+			// Patterns were checked by config.Validate
+			re, err := regexp.Compile(field.pattern)
+			if err != nil {
+				continue
+			}
+			for _, text := range field.texts {
+				if loc := re.FindStringIndex(text); text != "" && loc != nil {
+					name := rule.Name
+					if name == "" {
+						name = field.pattern
+					}
+					return &Hold{Rule: name, Field: field.key, Match: shorten(text[loc[0]:loc[1]])}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// NeedsChangelog reports whether any of rules looks at the changelog
+func NeedsChangelog(rules []config.HoldRule) bool {
+	return slices.ContainsFunc(rules, func(r config.HoldRule) bool { return r.Changelog != "" })
+}
+
+// shorten cuts s to maxMatch runes and puts it on one line
+func shorten(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > maxMatch {
+		return string(r[:maxMatch]) + "…"
+	}
+	return s
+}
+
+// supports reports whether any of versions is allowed by one of allowed; "1.20" also
+// allows 1.20.x
+func supports(versions, allowed []string) bool {
+	for _, v := range versions {
+		for _, a := range allowed {
+			if strings.EqualFold(v, a) || strings.HasPrefix(v, a+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// blackout returns the entry of dates that now falls on, or "" when there is none
+func blackout(dates []string, now time.Time) string {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for _, entry := range dates {
+		from, to, err := config.ParseDateRange(entry)
+		if err == nil && !day.Before(from) && !day.After(to) {
+			return entry
+		}
+	}
+	return ""
+}
+
+// inOrNot words whether a value is in a list
+func inOrNot(in bool) string {
+	if in {
+		return "in"
+	}
+	return "not in"
+}
+
+// orNone returns s, or "none" when it is empty
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, 12, 24, 3, 0, 0, 0, time.UTC)
+	candidate := Candidate{
+		Version:      "1.2.0",
+		ReleaseType:  "beta",
+		Published:    now.Add(-36 * time.Hour),
+		GameVersions: []string{"1.20.1", "Forge"},
+	}
+	on := config.UpdatePolicyConfig{AutoUpdate: true}
+
+	tests := []struct {
+		name   string
+		policy func(p *config.UpdatePolicyConfig)
+		facts  Facts
+		want   bool
+		reason string
+	}{
+		{"auto_update off", func(p *config.UpdatePolicyConfig) { p.AutoUpdate = false }, Facts{}, false, "auto_update is off"},
+		{"no rules", func(p *config.UpdatePolicyConfig) {}, Facts{}, true, "auto_update is on"},
+		{"release type", func(p *config.UpdatePolicyConfig) { p.ReleaseTypes = []string{"release"} }, Facts{}, false, "beta is not in release_types release"},
+		{"too new", func(p *config.UpdatePolicyConfig) { p.MinFileAge = 48 * time.Hour }, Facts{}, false, "published 36h0m0s ago"},
+		{"old enough", func(p *config.UpdatePolicyConfig) { p.MinFileAge = 24 * time.Hour }, Facts{}, true, "published 36h0m0s ago"},
+		{"game version prefix", func(p *config.UpdatePolicyConfig) { p.GameVersions = []string{"1.20"} }, Facts{}, true, "which is in game_versions"},
+		{"game version", func(p *config.UpdatePolicyConfig) { p.GameVersions = []string{"1.21.1"} }, Facts{}, false, "not in game_versions"},
+		{"blackout", func(p *config.UpdatePolicyConfig) { p.BlackoutDates = []string{"2024-07-01", "2024-12-20..2025-01-02"} }, Facts{}, false, "in the blackout dates 2024-12-20..2025-01-02"},
+		{"weekly limit", func(p *config.UpdatePolicyConfig) { p.MaxUpdatesPerWeek = 2 }, Facts{RecentInstalls: 2}, false, "2 of max_updates_per_week 2"},
+		{"players online", func(p *config.UpdatePolicyConfig) { p.RequireEmptyServer = true }, Facts{PlayersOnline: 3}, false, "3 players online"},
+		{"players unknown", func(p *config.UpdatePolicyConfig) { p.RequireEmptyServer = true }, Facts{PlayersErr: errors.New("connection refused")}, false, "connection refused"},
+		{"empty server", func(p *config.UpdatePolicyConfig) { p.RequireEmptyServer = true }, Facts{}, true, "nobody is online"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := on
+			tt.policy(&p)
+			facts := tt.facts
+			facts.Now = now
+			d := Evaluate(p, candidate, facts)
+			if d.Install != tt.want || !strings.Contains(d.String(), tt.reason) {
+				t.Errorf("Evaluate = %s, want install %v with %q", d, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestDecisionReasons(t *testing.T) {
+	p := config.UpdatePolicyConfig{AutoUpdate: true, ReleaseTypes: []string{"release"}, MaxUpdatesPerWeek: 1}
+	d := Evaluate(p, Candidate{ReleaseType: "alpha"}, Facts{Now: time.Now()})
+	if got := d.Reasons(); len(got) != 1 || !strings.HasPrefix(got[0], "alpha") {
+		t.Errorf("Reasons = %v, want only the release type that held the update back", got)
+	}
+	if !strings.HasPrefix(d.String(), "hold back: ") {
+		t.Errorf("String = %q", d)
+	}
+}
+
+func TestMatchHoldRules(t *testing.T) {
+	rules := []config.HoldRule{
+		{Name: "client-only hotfix", FileName: "hotfix-client-only"},
+		{Changelog: "(?i)requires (a )?world reset"},
+	}
+	tests := []struct {
+		name      string
+		candidate Candidate
+		want      *Hold
+	}{
+		{"no match", Candidate{Version: "Pack 1.2.0", Changelog: "Fixed crashes"}, nil},
+		{"display name", Candidate{Version: "Pack 1.2.1-hotfix-client-only"}, &Hold{Rule: "client-only hotfix", Field: "file_name", Match: "hotfix-client-only"}},
+		{"file name", Candidate{Version: "Pack 1.2.1", FileName: "pack-1.2.1-hotfix-client-only.zip"}, &Hold{Rule: "client-only hotfix", Field: "file_name", Match: "hotfix-client-only"}},
+		{"changelog without a name", Candidate{Changelog: "New biomes!\nThis update REQUIRES A WORLD RESET."}, &Hold{Rule: "(?i)requires (a )?world reset", Field: "changelog", Match: "REQUIRES A WORLD RESET"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchHoldRules(rules, tt.candidate)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("MatchHoldRules = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateHoldRules(t *testing.T) {
+	p := config.UpdatePolicyConfig{AutoUpdate: true, HoldRules: []config.HoldRule{{Name: "world reset", Changelog: "world reset"}}}
+
+	d := Evaluate(p, Candidate{Changelog: "needs a world reset"}, Facts{Now: time.Now()})
+	if d.Install || !strings.Contains(d.String(), `hold rule "world reset" matched "world reset" in the changelog, held for manual review`) {
+		t.Errorf("Evaluate = %s", d)
+	}
+	d = Evaluate(p, Candidate{ChangelogErr: errors.New("status 500")}, Facts{Now: time.Now()})
+	if d.Install || !strings.Contains(d.String(), "status 500") {
+		t.Errorf("Evaluate without the changelog = %s, want it held", d)
+	}
+	if d = Evaluate(p, Candidate{Changelog: "bug fixes"}, Facts{Now: time.Now()}); !d.Install {
+		t.Errorf("Evaluate = %s, want install", d)
+	}
+}
+
+func TestEvaluateMinecraftUpgrade(t *testing.T) {
+	c := Candidate{MinecraftUpgrade: "Minecraft 1.20.1 -> 1.21.1"}
+	p := config.UpdatePolicyConfig{AutoUpdate: true}
+
+	if d := Evaluate(p, c, Facts{Now: time.Now()}); !d.Install || !strings.Contains(d.String(), "Minecraft 1.20.1 -> 1.21.1, worlds cannot be opened") {
+		t.Errorf("Evaluate = %s, want install with a warning", d)
+	}
+	p.ConfirmMinecraftUpgrades = true
+	if d := Evaluate(p, c, Facts{Now: time.Now()}); d.Install || !strings.Contains(d.String(), "needs confirming") {
+		t.Errorf("Evaluate with confirm_minecraft_upgrades = %s, want it held", d)
+	}
+	if d := Evaluate(p, Candidate{}, Facts{Now: time.Now()}); !d.Install || len(d.Checks) != 1 {
+		t.Errorf("Evaluate without an upgrade = %s, want only auto_update checked", d)
+	}
+}
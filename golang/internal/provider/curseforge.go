@@ -0,0 +1,187 @@
+package provider
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/downloads"
+)
+
+// CurseForge serves projects from the CurseForge API
+type CurseForge struct {
+	client api.CurseForge
+}
+
+// NewCurseForge creates a CurseForge provider using the API client settings from cfg
+func NewCurseForge(cfg *config.Config) *CurseForge {
+	return &CurseForge{client: api.NewClientFromConfig(cfg)}
+}
+
+// NewCurseForgeWithClient creates a CurseForge provider around an existing client
+func NewCurseForgeWithClient(client api.CurseForge) *CurseForge {
+	return &CurseForge{client: client}
+}
+
+// Name returns "curseforge"
+func (c *CurseForge) Name() string {
+	return NameCurseForge
+}
+
+// GetProject returns the mod with the given numeric ID
+func (c *CurseForge) GetProject(id string) (*Project, error) {
+	modID, err := projectID(id)
+	if err != nil {
+		return nil, err
+	}
+	mod, err := c.client.GetMod(modID)
+	if err != nil {
+		return nil, err
+	}
+	return &Project{
+		ID:   strconv.Itoa(mod.ID),
+		Slug: mod.Slug,
+		Name: mod.Name,
+		URL:  mod.Links.WebsiteURL,
+	}, nil
+}
+
+// GetVersions returns the files of a mod matching filter, newest first
+func (c *CurseForge) GetVersions(id string, filter Filter) ([]Version, error) {
+	modID, err := projectID(id)
+	if err != nil {
+		return nil, err
+	}
+	files, err := c.client.GetAllModFiles(modID, filter.GameVersion, curseForgeLoader(filter.Loader))
+	if err != nil {
+		return nil, err
+	}
+
+	files = api.ReleasedFiles(files)
+	versions := make([]Version, 0, len(files))
+	for i := range files {
+		v := curseForgeVersion(&files[i])
+		if !ChannelAllowed(v.Channel, filter.Channel) {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// NewestVersions looks up the newest file of every release type and loader of many mods
+// in two requests, one for the mods and one for their files
+func (c *CurseForge) NewestVersions(ids []string, gameVersion string) (map[string][]Version, error) {
+	modIDs := make([]int, 0, len(ids))
+	for _, id := range ids {
+		modID, err := projectID(id)
+		if err != nil {
+			return nil, err
+		}
+		modIDs = append(modIDs, modID)
+	}
+	mods, err := c.client.GetMods(modIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileIDs []int
+	seen := map[int]bool{}
+	for _, mod := range mods {
+		for _, index := range mod.LatestFilesIndexes {
+			if (gameVersion != "" && index.GameVersion != gameVersion) || seen[index.FileID] {
+				continue
+			}
+			seen[index.FileID] = true
+			fileIDs = append(fileIDs, index.FileID)
+		}
+	}
+	versions := map[string][]Version{}
+	if len(fileIDs) == 0 {
+		return versions, nil
+	}
+	files, err := c.client.GetFiles(fileIDs)
+	if err != nil {
+		return nil, err
+	}
+	files = api.ReleasedFiles(files)
+	for i := range files {
+		v := curseForgeVersion(&files[i])
+		versions[v.ProjectID] = append(versions[v.ProjectID], v)
+	}
+	return versions, nil
+}
+
+// Download writes the file of v to w, looking up the download URL when the listing had none
+func (c *CurseForge) Download(v *Version, w io.Writer) error {
+	url := v.DownloadURL
+	if url == "" {
+		modID, err := projectID(v.ProjectID)
+		if err != nil {
+			return err
+		}
+		fileID, err := strconv.Atoi(v.ID)
+		if err != nil {
+			return fmt.Errorf("invalid CurseForge file ID %q", v.ID)
+		}
+		if url, err = c.client.GetModFileDownloadURL(modID, fileID); err != nil {
+			return err
+		}
+	}
+	return c.client.DownloadFile(url, w)
+}
+
+// curseForgeVersion converts an API file into a Version
+func curseForgeVersion(file *api.ModFile) Version {
+	var gameVersions, loaders []string
+	for _, gv := range file.GameVersions {
+		// CurseForge lists loaders among the game versions
+		switch strings.ToLower(gv) {
+		case "forge", "neoforge", "fabric", "quilt":
+			loaders = append(loaders, strings.ToLower(gv))
+		default:
+			gameVersions = append(gameVersions, gv)
+		}
+	}
+	return Version{
+		ID:           strconv.Itoa(file.ID),
+		ProjectID:    strconv.Itoa(file.ModID),
+		Name:         file.DisplayName,
+		FileName:     file.FileName,
+		DownloadURL:  file.DownloadURL,
+		Size:         file.FileLength,
+		SHA1:         downloads.SHA1(file),
+		Channel:      api.ReleaseTypeName(file.ReleaseType),
+		Published:    file.FileDate,
+		GameVersions: gameVersions,
+		Loaders:      loaders,
+	}
+}
+
+// curseForgeLoader maps a loader name to its CurseForge mod loader type
+func curseForgeLoader(loader string) int {
+	switch strings.ToLower(loader) {
+	case "forge":
+		return api.ModLoaderTypeForge
+	case "fabric":
+		return api.ModLoaderTypeFabric
+	case "quilt":
+		return api.ModLoaderTypeQuilt
+	case "neoforge":
+		return api.ModLoaderTypeNeoForge
+	default:
+		return api.ModLoaderTypeAny
+	}
+}
+
+// projectID parses a numeric CurseForge project ID
+func projectID(id string) (int, error) {
+	modID, err := strconv.Atoi(id)
+	if err != nil || modID <= 0 {
+		return 0, fmt.Errorf("invalid CurseForge project ID %q", id)
+	}
+	return modID, nil
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+)
+
+func TestCurseForgeNewestVersions(t *testing.T) {
+	var requests int
+	var fileIDs map[string][]int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/mods":
+			_ = json.NewEncoder(w).Encode(api.APIResponse[[]api.ModInfo]{Data: []api.ModInfo{
+				{ID: 1, LatestFilesIndexes: []api.FileIndex{
+					{GameVersion: "1.20.1", FileID: 11, ReleaseType: api.ReleaseTypeRelease},
+					{GameVersion: "1.20.1", FileID: 12, ReleaseType: api.ReleaseTypeBeta},
+					{GameVersion: "1.19.2", FileID: 10, ReleaseType: api.ReleaseTypeRelease},
+				}},
+				{ID: 2, LatestFilesIndexes: []api.FileIndex{
+					{GameVersion: "1.20.1", FileID: 21, ReleaseType: api.ReleaseTypeRelease},
+				}},
+			}})
+		case "/mods/files":
+			_ = json.NewDecoder(r.Body).Decode(&fileIDs)
+			day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+			_ = json.NewEncoder(w).Encode(api.APIResponse[[]api.ModFile]{Data: []api.ModFile{
+				{ID: 11, ModID: 1, FileDate: day(1), ReleaseType: api.ReleaseTypeRelease, GameVersions: []string{"1.20.1", "Forge"}},
+				{ID: 12, ModID: 1, FileDate: day(3), ReleaseType: api.ReleaseTypeBeta, GameVersions: []string{"1.20.1", "Forge"}},
+				{ID: 21, ModID: 2, FileDate: day(2), ReleaseType: api.ReleaseTypeRelease, GameVersions: []string{"1.20.1", "Fabric"}},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	client := api.NewClient("key")
+	client.BaseURL = srv.URL
+
+	versions, err := NewCurseForgeWithClient(client).NewestVersions([]string{"1", "2"}, "1.20.1")
+	if err != nil {
+		t.Fatalf("NewestVersions: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+	if got := fileIDs["fileIds"]; len(got) != 3 {
+		t.Errorf("requested files %v, want the three for 1.20.1", got)
+	}
+	if v := versions["1"]; len(v) != 2 || v[0].ID != "12" || v[0].Channel != ChannelBeta || v[1].ID != "11" {
+		t.Errorf("versions of mod 1 = %+v, want the beta, then the release", v)
+	}
+	if v := versions["2"]; len(v) != 1 || v[0].Loaders[0] != "fabric" {
+		t.Errorf("versions of mod 2 = %+v", v)
+	}
+}
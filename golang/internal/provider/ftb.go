@@ -0,0 +1,258 @@
+package provider
+
+import (
+	"crypto/sha1" //nolint:gosec // FTB publishes SHA-1 hashes
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
+	"github.com/klauspost/compress/zip"
+)
+
+// FTBBaseURL is the public Feed The Beast modpacks API
+const FTBBaseURL = "https://api.modpacks.ch/public"
+
+// FTB serves modpacks from the Feed The Beast API. A version is a list of files rather
+// than one archive, so Download packs the server files into a zip that installs like a
+// CurseForge server pack.
+type FTB struct {
+	BaseURL        string
+	UserAgent      string
+	HTTPClient     *http.Client
+	DownloadClient *http.Client
+	// CurseForge resolves files that FTB only references by CurseForge project and file ID
+	CurseForge *api.Client
+}
+
+// NewFTB creates an FTB provider using the HTTP settings from cfg
+func NewFTB(cfg *config.Config) *FTB {
+	return &FTB{
+		BaseURL:        FTBBaseURL,
+		UserAgent:      "damianko135/curseforge-autoupdater",
+		HTTPClient:     httpclient.New(cfg.HTTP),
+		DownloadClient: httpclient.ForDownloads(cfg),
+		CurseForge:     api.NewClientFromConfig(cfg),
+	}
+}
+
+// ftbPack is the subset of an FTB modpack used here
+type ftbPack struct {
+	ID       int          `json:"id"`
+	Name     string       `json:"name"`
+	Slug     string       `json:"slug"`
+	Versions []ftbVersion `json:"versions"`
+}
+
+// ftbVersion is one version of an FTB modpack; Files is only set by the version endpoint
+type ftbVersion struct {
+	ID      int         `json:"id"`
+	Name    string      `json:"name"`
+	Type    string      `json:"type"` // Release, Beta or Alpha
+	Updated int64       `json:"updated"`
+	Targets []ftbTarget `json:"targets"`
+	Files   []ftbFile   `json:"files"`
+}
+
+// ftbTarget is the game or mod loader a version is built for
+type ftbTarget struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"` // game or modloader
+	Version string `json:"version"`
+}
+
+// ftbFile is one file of an FTB modpack version
+type ftbFile struct {
+	Path       string `json:"path"`
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	SHA1       string `json:"sha1"`
+	Size       int64  `json:"size"`
+	ClientOnly bool   `json:"clientonly"`
+	CurseForge *struct {
+		Project int `json:"project"`
+		File    int `json:"file"`
+	} `json:"curseforge"`
+}
+
+// Name returns "ftb"
+func (f *FTB) Name() string {
+	return NameFTB
+}
+
+// GetProject returns the modpack with the given numeric ID
+func (f *FTB) GetProject(id string) (*Project, error) {
+	var pack ftbPack
+	if err := f.get("/modpack/"+id, &pack); err != nil {
+		return nil, err
+	}
+	return &Project{
+		ID:   strconv.Itoa(pack.ID),
+		Slug: pack.Slug,
+		Name: pack.Name,
+		URL:  fmt.Sprintf("https://www.feed-the-beast.com/modpacks/%d", pack.ID),
+	}, nil
+}
+
+// GetVersions returns the versions of a modpack matching filter, newest first
+func (f *FTB) GetVersions(id string, filter Filter) ([]Version, error) {
+	var pack ftbPack
+	if err := f.get("/modpack/"+id, &pack); err != nil {
+		return nil, err
+	}
+
+	versions := make([]Version, 0, len(pack.Versions))
+	for _, fv := range pack.Versions {
+		v := Version{
+			ID:        strconv.Itoa(fv.ID),
+			ProjectID: strconv.Itoa(pack.ID),
+			Name:      fv.Name,
+			FileName:  fmt.Sprintf("%s-%s-server.zip", sanitizeFileName(pack.Name), sanitizeFileName(fv.Name)),
+			Channel:   strings.ToLower(fv.Type),
+			Published: time.Unix(fv.Updated, 0).UTC(),
+		}
+		for _, t := range fv.Targets {
+			if t.Type == "game" {
+				v.GameVersions = append(v.GameVersions, t.Version)
+			} else if t.Type == "modloader" {
+				v.Loaders = append(v.Loaders, t.Name)
+			}
+		}
+		if !ChannelAllowed(v.Channel, filter.Channel) || !matches(v.GameVersions, filter.GameVersion) || !matches(v.Loaders, filter.Loader) {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Published.After(versions[j].Published)
+	})
+	return versions, nil
+}
+
+// Download writes a zip of the server files of v to w, checking each file's SHA-1 hash
+func (f *FTB) Download(v *Version, w io.Writer) error {
+	var full ftbVersion
+	if err := f.get(fmt.Sprintf("/modpack/%s/%s", v.ProjectID, v.ID), &full); err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	for _, file := range full.Files {
+		if file.ClientOnly {
+			continue
+		}
+		name := path.Clean(path.Join(strings.TrimPrefix(file.Path, "./"), file.Name))
+		if name == "." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return fmt.Errorf("FTB file %q has an unsafe path", path.Join(file.Path, file.Name))
+		}
+		// A shared top-level folder is stripped on install, so the layout survives even
+		// when every file is in mods/
+		entry, err := zw.Create("server/" + name)
+		if err != nil {
+			return err
+		}
+		if err := f.fetch(file, entry); err != nil {
+			return fmt.Errorf("failed to download %s: %w", name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// fetch writes one pack file to w
+func (f *FTB) fetch(file ftbFile, w io.Writer) error {
+	url := file.URL
+	if url == "" && file.CurseForge != nil && f.CurseForge != nil {
+		var err error
+		if url, err = f.CurseForge.GetModFileDownloadURL(file.CurseForge.Project, file.CurseForge.File); err != nil {
+			return err
+		}
+	}
+	if url == "" {
+		return fmt.Errorf("no download URL")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create download request: %w", err)
+	}
+	req.Header.Set("User-Agent", f.UserAgent)
+	resp, err := f.DownloadClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	h := sha1.New() //nolint:gosec // FTB publishes SHA-1 hashes
+	if _, err := io.Copy(io.MultiWriter(w, h), resp.Body); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); file.SHA1 != "" && !strings.EqualFold(got, file.SHA1) {
+		return fmt.Errorf("sha1 is %s, expected %s", got, file.SHA1)
+	}
+	return nil
+}
+
+// get fetches path from the API and decodes the JSON response into out
+func (f *FTB) get(p string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, f.BaseURL+p, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", f.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("FTB modpack not found: %s", p)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("FTB request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// matches reports whether want is empty or listed in values, ignoring case
+func matches(values []string, want string) bool {
+	if want == "" {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeFileName keeps letters, digits, dots and dashes
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
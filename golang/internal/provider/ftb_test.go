@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/klauspost/compress/zip"
+)
+
+func TestFTBDownloadBuildsServerZip(t *testing.T) {
+	sum := func(s string) string {
+		h := sha1.Sum([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/modpack/100":
+			_ = json.NewEncoder(w).Encode(ftbPack{ID: 100, Name: "FTB Test", Versions: []ftbVersion{
+				{ID: 1, Name: "1.0.0", Type: "Release", Updated: 1700000000, Targets: []ftbTarget{{Name: "minecraft", Type: "game", Version: "1.20.1"}}},
+				{ID: 2, Name: "1.1.0", Type: "Release", Updated: 1710000000, Targets: []ftbTarget{{Name: "minecraft", Type: "game", Version: "1.20.1"}}},
+				{ID: 3, Name: "2.0.0", Type: "Release", Updated: 1720000000, Targets: []ftbTarget{{Name: "minecraft", Type: "game", Version: "1.21"}}},
+				{ID: 4, Name: "1.2.0-beta", Type: "Beta", Updated: 1715000000, Targets: []ftbTarget{{Name: "minecraft", Type: "game", Version: "1.20.1"}}},
+			}})
+		case "/modpack/100/2":
+			_ = json.NewEncoder(w).Encode(ftbVersion{ID: 2, Files: []ftbFile{
+				{Path: "./mods/", Name: "a.jar", URL: srv.URL + "/files/a", SHA1: sum("mod a")},
+				{Path: "./config/", Name: "a.toml", URL: srv.URL + "/files/cfg", SHA1: sum("cfg")},
+				{Path: "./mods/", Name: "shaders.jar", URL: srv.URL + "/files/client", ClientOnly: true},
+			}})
+		case "/files/a":
+			_, _ = io.WriteString(w, "mod a")
+		case "/files/cfg":
+			_, _ = io.WriteString(w, "cfg")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	f := &FTB{BaseURL: srv.URL, UserAgent: "test", HTTPClient: srv.Client(), DownloadClient: srv.Client()}
+	latest, err := Latest(f, "100", Filter{GameVersion: "1.20.1", Channel: "stable"})
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if latest.ID != "2" || latest.FileName != "FTB_Test-1.1.0-server.zip" {
+		t.Fatalf("latest = %+v, want version 2", latest)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Download(latest, &buf); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range zr.File {
+		names = append(names, file.Name)
+	}
+	if len(names) != 2 || names[0] != "server/mods/a.jar" || names[1] != "server/config/a.toml" {
+		t.Fatalf("zip entries = %v, want the two server files", names)
+	}
+}
@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
+)
+
+// GenericSource describes an artifact that is not on a mod hosting site
+type GenericSource struct {
+	// URL downloads the artifact; "{version}" is replaced with the latest version
+	URL string
+	// VersionURL returns the latest version, as plain text or as JSON read with VersionPath.
+	// When empty the ETag or Last-Modified header of URL serves as the version.
+	VersionURL string
+	// VersionPath selects the version in a JSON response, e.g. "0.version" or "builds.-1.id";
+	// negative indexes count from the end of an array
+	VersionPath string
+	// FileName is the name to install the artifact as, with "{version}" replaced; defaults
+	// to the last element of the URL path
+	FileName string
+}
+
+// Generic tracks a single artifact by URL; project IDs are ignored
+type Generic struct {
+	Source         GenericSource
+	UserAgent      string
+	HTTPClient     *http.Client
+	DownloadClient *http.Client
+}
+
+// NewGeneric creates a generic provider for source using the HTTP settings from cfg
+func NewGeneric(cfg *config.Config, source GenericSource) *Generic {
+	return &Generic{
+		Source:         source,
+		UserAgent:      "damianko135/curseforge-autoupdater",
+		HTTPClient:     httpclient.New(cfg.HTTP),
+		DownloadClient: httpclient.ForDownloads(cfg),
+	}
+}
+
+// Name returns "generic"
+func (g *Generic) Name() string {
+	return NameGeneric
+}
+
+// GetProject describes the artifact; id is only used as its name
+func (g *Generic) GetProject(id string) (*Project, error) {
+	return &Project{ID: id, Name: id, URL: g.Source.URL}, nil
+}
+
+// GetVersions returns the latest version only; the filter does not apply to plain URLs
+func (g *Generic) GetVersions(id string, filter Filter) ([]Version, error) {
+	version, err := g.latestVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest version of %s: %w", id, err)
+	}
+	if version == "" {
+		return nil, nil
+	}
+
+	url := strings.ReplaceAll(g.Source.URL, "{version}", version)
+	fileName := strings.ReplaceAll(g.Source.FileName, "{version}", version)
+	if fileName == "" {
+		fileName = path.Base(strings.SplitN(url, "?", 2)[0])
+	}
+	return []Version{{
+		ID:          version,
+		ProjectID:   id,
+		Name:        version,
+		FileName:    fileName,
+		DownloadURL: url,
+		Channel:     ChannelRelease,
+	}}, nil
+}
+
+// Download writes the artifact of v to w
+func (g *Generic) Download(v *Version, w io.Writer) error {
+	resp, err := g.do(g.DownloadClient, http.MethodGet, v.DownloadURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to write downloaded data: %w", err)
+	}
+	return nil
+}
+
+// latestVersion reads the version from VersionURL, or from the headers of URL
+func (g *Generic) latestVersion() (string, error) {
+	if g.Source.VersionURL == "" {
+		resp, err := g.do(g.HTTPClient, http.MethodHead, g.Source.URL)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		if etag := strings.Trim(resp.Header.Get("ETag"), `W/"`); etag != "" {
+			return etag, nil
+		}
+		return resp.Header.Get("Last-Modified"), nil
+	}
+
+	resp, err := g.do(g.HTTPClient, http.MethodGet, g.Source.VersionURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if g.Source.VersionPath == "" {
+		return strings.TrimSpace(string(body)), nil
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "", fmt.Errorf("failed to decode version response: %w", err)
+	}
+	return lookupPath(doc, g.Source.VersionPath)
+}
+
+// do sends a request and fails on anything but 200
+func (g *Generic) do(client *http.Client, method, url string) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", g.UserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s failed with status %d", method, url, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// lookupPath follows a dotted path of object keys and array indexes through doc and
+// returns the value it ends at as a string
+func lookupPath(doc interface{}, p string) (string, error) {
+	current := doc
+	for _, part := range strings.Split(p, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[part]
+			if !ok {
+				return "", fmt.Errorf("version_path %q: key %q not found", p, part)
+			}
+			current = value
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil {
+				return "", fmt.Errorf("version_path %q: %q is not an array index", p, part)
+			}
+			if i < 0 {
+				i += len(node)
+			}
+			if i < 0 || i >= len(node) {
+				return "", fmt.Errorf("version_path %q: index %s out of range", p, part)
+			}
+			current = node[i]
+		default:
+			return "", fmt.Errorf("version_path %q: cannot descend into %q", p, part)
+		}
+	}
+
+	switch v := current.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("version_path %q does not point at a string or number", p)
+	}
+}
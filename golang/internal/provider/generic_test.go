@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGenericVersions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/versions":
+			_, _ = w.Write([]byte(`[{"version":"0.16.2","stable":true},{"version":"0.16.1","stable":true}]`))
+		case "/builds/latest":
+			_, _ = w.Write([]byte(`{"version":"2.4.0","builds":[{"build":400},{"build":412}]}`))
+		case "/nightly.txt":
+			_, _ = w.Write([]byte("build-77\n"))
+		case "/geyser.jar":
+			w.Header().Set("ETag", `W/"abc123"`)
+			_, _ = w.Write([]byte("jar"))
+		case "/loader/0.16.2.jar":
+			_, _ = w.Write([]byte("loader"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		source       GenericSource
+		wantVersion  string
+		wantFileName string
+	}{
+		{"json array", GenericSource{URL: srv.URL + "/loader/{version}.jar", VersionURL: srv.URL + "/versions", VersionPath: "0.version", FileName: "fabric-loader-{version}.jar"}, "0.16.2", "fabric-loader-0.16.2.jar"},
+		{"negative index", GenericSource{URL: srv.URL + "/geyser.jar", VersionURL: srv.URL + "/builds/latest", VersionPath: "builds.-1.build"}, "412", "geyser.jar"},
+		{"plain text", GenericSource{URL: srv.URL + "/geyser.jar?build={version}", VersionURL: srv.URL + "/nightly.txt"}, "build-77", "geyser.jar"},
+		{"etag", GenericSource{URL: srv.URL + "/geyser.jar"}, "abc123", "geyser.jar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Generic{Source: tt.source, UserAgent: "test", HTTPClient: srv.Client(), DownloadClient: srv.Client()}
+			latest, err := Latest(g, "artifact", Filter{})
+			if err != nil {
+				t.Fatalf("Latest: %v", err)
+			}
+			if latest.ID != tt.wantVersion || latest.FileName != tt.wantFileName {
+				t.Fatalf("latest = %+v, want version %q and file %q", latest, tt.wantVersion, tt.wantFileName)
+			}
+		})
+	}
+
+	g := &Generic{Source: tests[0].source, UserAgent: "test", HTTPClient: srv.Client(), DownloadClient: srv.Client()}
+	latest, err := Latest(g, "fabric-loader", Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := g.Download(latest, &buf); err != nil || buf.String() != "loader" {
+		t.Fatalf("Download = %q, %v", buf.String(), err)
+	}
+
+	g.Source.VersionPath = "0.missing"
+	if _, err := Latest(g, "fabric-loader", Filter{}); err == nil {
+		t.Fatal("expected an error for a missing version_path key")
+	}
+}
@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
+)
+
+// ModrinthBaseURL is the Modrinth API v2 endpoint
+const ModrinthBaseURL = "https://api.modrinth.com/v2"
+
+// Modrinth serves projects from the Modrinth API, which needs no API key
+type Modrinth struct {
+	BaseURL        string
+	UserAgent      string // Modrinth asks every client to identify itself
+	HTTPClient     *http.Client
+	DownloadClient *http.Client
+}
+
+// NewModrinth creates a Modrinth provider using the HTTP settings from cfg
+func NewModrinth(cfg *config.Config) *Modrinth {
+	return &Modrinth{
+		BaseURL:        ModrinthBaseURL,
+		UserAgent:      "damianko135/curseforge-autoupdater",
+		HTTPClient:     httpclient.New(cfg.HTTP),
+		DownloadClient: httpclient.ForDownloads(cfg),
+	}
+}
+
+// modrinthProject is the subset of a Modrinth project used here
+type modrinthProject struct {
+	ID          string `json:"id"`
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	ProjectType string `json:"project_type"`
+}
+
+// modrinthVersion is the subset of a Modrinth version used here
+type modrinthVersion struct {
+	ID            string         `json:"id"`
+	ProjectID     string         `json:"project_id"`
+	Name          string         `json:"name"`
+	VersionNumber string         `json:"version_number"`
+	VersionType   string         `json:"version_type"`
+	DatePublished time.Time      `json:"date_published"`
+	GameVersions  []string       `json:"game_versions"`
+	Loaders       []string       `json:"loaders"`
+	Files         []modrinthFile `json:"files"`
+}
+
+// modrinthFile is one file of a Modrinth version
+type modrinthFile struct {
+	URL      string            `json:"url"`
+	Filename string            `json:"filename"`
+	Primary  bool              `json:"primary"`
+	Size     int64             `json:"size"`
+	Hashes   map[string]string `json:"hashes"`
+}
+
+// Name returns "modrinth"
+func (m *Modrinth) Name() string {
+	return NameModrinth
+}
+
+// GetProject returns the project with the given ID or slug
+func (m *Modrinth) GetProject(id string) (*Project, error) {
+	var p modrinthProject
+	if err := m.get("/project/"+url.PathEscape(id), nil, &p); err != nil {
+		return nil, err
+	}
+	projectType := p.ProjectType
+	if projectType == "" {
+		projectType = "mod"
+	}
+	return &Project{
+		ID:   p.ID,
+		Slug: p.Slug,
+		Name: p.Title,
+		URL:  fmt.Sprintf("https://modrinth.com/%s/%s", projectType, p.Slug),
+	}, nil
+}
+
+// GetVersions returns the versions of a project matching filter, newest first
+func (m *Modrinth) GetVersions(id string, filter Filter) ([]Version, error) {
+	query := url.Values{}
+	if filter.GameVersion != "" {
+		query.Set("game_versions", fmt.Sprintf("[%q]", filter.GameVersion))
+	}
+	if filter.Loader != "" {
+		query.Set("loaders", fmt.Sprintf("[%q]", strings.ToLower(filter.Loader)))
+	}
+
+	var list []modrinthVersion
+	if err := m.get("/project/"+url.PathEscape(id)+"/version", query, &list); err != nil {
+		return nil, err
+	}
+
+	versions := make([]Version, 0, len(list))
+	for _, mv := range list {
+		file := primaryFile(mv.Files)
+		if file == nil || !ChannelAllowed(mv.VersionType, filter.Channel) {
+			continue
+		}
+		name := mv.VersionNumber
+		if name == "" {
+			name = mv.Name
+		}
+		versions = append(versions, Version{
+			ID:           mv.ID,
+			ProjectID:    mv.ProjectID,
+			Name:         name,
+			FileName:     file.Filename,
+			DownloadURL:  file.URL,
+			Size:         file.Size,
+			SHA1:         file.Hashes["sha1"],
+			Channel:      mv.VersionType,
+			Published:    mv.DatePublished,
+			GameVersions: mv.GameVersions,
+			Loaders:      mv.Loaders,
+		})
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Published.After(versions[j].Published)
+	})
+	return versions, nil
+}
+
+// Download writes the file of v to w
+func (m *Modrinth) Download(v *Version, w io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet, v.DownloadURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create download request: %w", err)
+	}
+	req.Header.Set("User-Agent", m.UserAgent)
+
+	resp, err := m.DownloadClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to write downloaded data: %w", err)
+	}
+	return nil
+}
+
+// get fetches path from the API and decodes the JSON response into out
+func (m *Modrinth) get(path string, query url.Values, out interface{}) error {
+	u := m.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", m.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("modrinth project not found: %s", path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("modrinth request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// primaryFile returns the file marked primary, or the first one
+func primaryFile(files []modrinthFile) *modrinthFile {
+	for i := range files {
+		if files[i].Primary {
+			return &files[i]
+		}
+	}
+	if len(files) > 0 {
+		return &files[0]
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestModrinthVersions(t *testing.T) {
+	var query string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			t.Error("request without User-Agent")
+		}
+		switch r.URL.Path {
+		case "/project/sodium":
+			_ = json.NewEncoder(w).Encode(modrinthProject{ID: "AANobbMI", Slug: "sodium", Title: "Sodium", ProjectType: "mod"})
+		case "/project/sodium/version":
+			query = r.URL.RawQuery
+			_ = json.NewEncoder(w).Encode([]modrinthVersion{
+				{ID: "old", VersionNumber: "0.5.0", VersionType: "release", DatePublished: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					Files: []modrinthFile{{URL: srv.URL + "/file/old", Filename: "sodium-0.5.0.jar", Primary: true}}},
+				{ID: "beta", VersionNumber: "0.6.0-beta", VersionType: "beta", DatePublished: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+					Files: []modrinthFile{{URL: srv.URL + "/file/beta", Filename: "sodium-0.6.0-beta.jar", Primary: true}}},
+				{ID: "new", VersionNumber: "0.5.1", VersionType: "release", DatePublished: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+					Files: []modrinthFile{
+						{URL: srv.URL + "/file/sources", Filename: "sodium-0.5.1-sources.jar"},
+						{URL: srv.URL + "/file/new", Filename: "sodium-0.5.1.jar", Primary: true},
+					}},
+			})
+		case "/file/new":
+			_, _ = w.Write([]byte("jar"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	m := &Modrinth{BaseURL: srv.URL, UserAgent: "test", HTTPClient: srv.Client(), DownloadClient: srv.Client()}
+
+	project, err := m.GetProject("sodium")
+	if err != nil {
+		t.Fatalf("GetProject: %v", err)
+	}
+	if project.ID != "AANobbMI" || project.URL != "https://modrinth.com/mod/sodium" {
+		t.Fatalf("unexpected project %+v", project)
+	}
+
+	latest, err := Latest(m, "sodium", Filter{GameVersion: "1.20.1", Loader: "Fabric", Channel: "stable"})
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if latest.ID != "new" || latest.FileName != "sodium-0.5.1.jar" {
+		t.Fatalf("latest = %+v, want the newest release", latest)
+	}
+	if query != `game_versions=%5B%221.20.1%22%5D&loaders=%5B%22fabric%22%5D` {
+		t.Fatalf("query = %s", query)
+	}
+
+	var buf bytes.Buffer
+	if err := m.Download(latest, &buf); err != nil || buf.String() != "jar" {
+		t.Fatalf("Download = %q, %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/version"
+)
+
+// Policy decides which versions of a project may be installed, holding back pre-releases,
+// versions that are too new to trust and versions the operator ruled out
+type Policy struct {
+	Channel string        // the least stable channel to accept, see ChannelAllowed
+	MinAge  time.Duration // only versions published at least this long ago
+	Now     time.Time
+
+	Pin         string              // only the version with this ID, whatever its channel or age
+	Range       *version.Constraint // only versions whose number is in this range
+	Ignore      []string            // versions never installed, by ID, name or number
+	GameVersion string              // skipped when reading numbers from names, see version.ModVersion
+}
+
+// Held is a version newer than the selected one that the policy skipped
+type Held struct {
+	Version Version
+	Reason  string // e.g. "beta, the channel is release"
+}
+
+// Reason returns why v may not be installed, or "" when it may
+func (p Policy) Reason(v Version) string {
+	if p.Pin != "" {
+		if v.ID == p.Pin {
+			return ""
+		}
+		return "pinned to " + p.Pin
+	}
+	number := version.ModVersion(v.Name, p.GameVersion)
+	for _, ignored := range p.Ignore {
+		if ignored == v.ID || ignored == v.Name || (number != "" && ignored == number) {
+			return "listed in ignore_versions"
+		}
+	}
+	if p.Range != nil && !p.Range.Match(number) {
+		return fmt.Sprintf("outside versions %s", p.Range)
+	}
+	if !ChannelAllowed(v.Channel, p.Channel) {
+		return fmt.Sprintf("%s, the channel is %s", v.Channel, p.Channel)
+	}
+	if p.MinAge > 0 && !v.Published.IsZero() {
+		if age := p.Now.Sub(v.Published); age < p.MinAge {
+			return fmt.Sprintf("published %s ago, min_age is %s", shortDuration(age), shortDuration(p.MinAge))
+		}
+	}
+	return ""
+}
+
+// Select returns the first of versions, newest first, that the policy allows, and the
+// newer versions it held back. The version is nil when the policy allows none of them.
+func (p Policy) Select(versions []Version) (*Version, []Held) {
+	var held []Held
+	for i := range versions {
+		reason := p.Reason(versions[i])
+		if reason == "" {
+			return &versions[i], held
+		}
+		held = append(held, Held{Version: versions[i], Reason: reason})
+	}
+	return nil, held
+}
+
+// LatestAllowed returns the newest version of a project that matches filter and policy,
+// with the newer versions the policy held back. filter.Channel is ignored in favour of
+// policy.Channel, so that skipped pre-releases are reported too.
+func LatestAllowed(p Provider, id string, filter Filter, policy Policy) (*Version, []Held, error) {
+	filter.Channel = ""
+	versions, err := p.GetVersions(id, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return SelectLatest(p, id, versions, policy)
+}
+
+// SelectLatest is LatestAllowed for versions of project id on p that were already fetched
+func SelectLatest(p Provider, id string, versions []Version, policy Policy) (*Version, []Held, error) {
+	v, held := policy.Select(versions)
+	if v == nil {
+		if len(held) > 0 {
+			return nil, held, fmt.Errorf("%s project %s: %w, newest is %s: %s", p.Name(), id, ErrNoVersion, held[0].Version.Name, held[0].Reason)
+		}
+		return nil, nil, fmt.Errorf("%s project %s: %w", p.Name(), id, ErrNoVersion)
+	}
+	return v, held, nil
+}
+
+// shortDuration formats d to the minute, e.g. "5h12m" or "48h"
+func shortDuration(d time.Duration) string {
+	s := d.Round(time.Minute).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
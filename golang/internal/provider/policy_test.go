@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/version"
+)
+
+// staticProvider returns the same versions for every project
+type staticProvider []Version
+
+func (s staticProvider) Name() string                           { return "static" }
+func (s staticProvider) GetProject(id string) (*Project, error) { return &Project{ID: id}, nil }
+func (s staticProvider) Download(v *Version, w io.Writer) error { return nil }
+func (s staticProvider) GetVersions(id string, filter Filter) ([]Version, error) {
+	var out []Version
+	for _, v := range s {
+		if ChannelAllowed(v.Channel, filter.Channel) {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func TestPolicySelect(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	versions := []Version{
+		{ID: "4", Name: "1.4", Channel: ChannelRelease, Published: now.Add(-5 * time.Hour)},
+		{ID: "3", Name: "1.3-beta", Channel: ChannelBeta, Published: now.Add(-72 * time.Hour)},
+		{ID: "2", Name: "1.2", Channel: ChannelRelease, Published: now.Add(-96 * time.Hour)},
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   string
+		held   []string
+	}{
+		{"no policy", Policy{Now: now}, "4", nil},
+		{"stable", Policy{Channel: "stable", Now: now}, "4", nil},
+		{"min age", Policy{MinAge: 48 * time.Hour, Now: now}, "3", []string{"published 5h ago, min_age is 48h"}},
+		{"min age and stable", Policy{Channel: "stable", MinAge: 48 * time.Hour, Now: now}, "2", []string{
+			"published 5h ago, min_age is 48h",
+			"beta, the channel is stable",
+		}},
+		{"pin ignores channel and age", Policy{Channel: "stable", MinAge: 100 * time.Hour, Pin: "3", Now: now}, "3", []string{
+			"pinned to 3",
+		}},
+		{"ignore", Policy{Ignore: []string{"1.4"}, Now: now}, "3", []string{"listed in ignore_versions"}},
+		{"range", Policy{Range: mustConstraint(t, ">=1.2, <1.3"), Now: now}, "2", []string{
+			"outside versions >=1.2, <1.3",
+			"outside versions >=1.2, <1.3",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, held := tt.policy.Select(versions)
+			if v == nil || v.ID != tt.want {
+				t.Fatalf("Select = %+v, want version %s", v, tt.want)
+			}
+			if len(held) != len(tt.held) {
+				t.Fatalf("held = %+v, want %v", held, tt.held)
+			}
+			for i, h := range held {
+				if h.Reason != tt.held[i] {
+					t.Errorf("held[%d].Reason = %q, want %q", i, h.Reason, tt.held[i])
+				}
+			}
+		})
+	}
+}
+
+func mustConstraint(t *testing.T, s string) *version.Constraint {
+	t.Helper()
+	c, err := version.ParseConstraint(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestLatestAllowed(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	p := staticProvider{
+		{ID: "2", Name: "2.0-alpha", Channel: ChannelAlpha, Published: now.Add(-time.Hour)},
+		{ID: "1", Name: "1.0", Channel: ChannelRelease, Published: now.Add(-90 * time.Minute)},
+	}
+
+	// Pre-releases are reported even though the provider could filter them out
+	v, held, err := LatestAllowed(p, "x", Filter{Channel: ChannelAlpha}, Policy{Channel: ChannelRelease, Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != "1" || len(held) != 1 || held[0].Version.ID != "2" {
+		t.Fatalf("LatestAllowed = %+v, held %+v", v, held)
+	}
+
+	_, held, err = LatestAllowed(p, "x", Filter{}, Policy{MinAge: 2 * time.Hour, Now: now})
+	if !errors.Is(err, ErrNoVersion) || len(held) != 2 {
+		t.Fatalf("LatestAllowed = %v, held %+v, want ErrNoVersion", err, held)
+	}
+	if !strings.Contains(err.Error(), "published 1h ago, min_age is 2h") {
+		t.Errorf("error %q does not give the reason", err)
+	}
+}
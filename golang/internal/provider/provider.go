@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// Provider names accepted in the provider field of a [[mods]] entry and in modpack_provider
+const (
+	NameCurseForge = "curseforge"
+	NameModrinth   = "modrinth"
+	NameFTB        = "ftb"
+	NameGeneric    = "generic"
+)
+
+// Release channels, from most to least stable
+const (
+	ChannelRelease = "release"
+	ChannelBeta    = "beta"
+	ChannelAlpha   = "alpha"
+)
+
+// ErrNoVersion is returned by Latest when no version matches the filter
+var ErrNoVersion = errors.New("no matching version")
+
+// Project is a mod or modpack as listed by a provider
+type Project struct {
+	ID   string
+	Slug string
+	Name string
+	URL  string // project page for humans
+}
+
+// Version is one downloadable release of a project
+type Version struct {
+	ID           string
+	ProjectID    string
+	Name         string
+	FileName     string
+	DownloadURL  string
+	Size         int64
+	SHA1         string // empty when the provider does not publish it
+	Channel      string // release, beta or alpha
+	Published    time.Time
+	GameVersions []string
+	Loaders      []string
+}
+
+// Filter narrows the versions returned by GetVersions; empty fields match everything
+type Filter struct {
+	GameVersion string
+	Loader      string // forge, neoforge, fabric, quilt
+	Channel     string // the least stable channel to accept, see ChannelAllowed
+}
+
+// Provider looks up and downloads projects from one mod hosting site
+type Provider interface {
+	// Name returns the provider name used in the config
+	Name() string
+	// GetProject returns the project with the given ID or slug
+	GetProject(id string) (*Project, error)
+	// GetVersions returns the versions of a project matching filter, newest first
+	GetVersions(id string, filter Filter) ([]Version, error)
+	// Download writes the file of version v to w
+	Download(v *Version, w io.Writer) error
+}
+
+// Batcher is implemented by providers that can look up many projects in a few requests
+type Batcher interface {
+	// NewestVersions returns the newest version of every channel and loader of each project
+	// for gameVersion, keyed by project ID. Unknown projects are left out.
+	NewestVersions(ids []string, gameVersion string) (map[string][]Version, error)
+}
+
+// New returns the provider called name, configured from cfg
+func New(name string, cfg *config.Config) (Provider, error) {
+	switch name {
+	case "", NameCurseForge:
+		return NewCurseForge(cfg), nil
+	case NameModrinth:
+		return NewModrinth(cfg), nil
+	case NameFTB:
+		return NewFTB(cfg), nil
+	case NameGeneric:
+		return nil, fmt.Errorf("the generic provider is configured per mod, use NewGeneric")
+	default:
+		return nil, fmt.Errorf("unknown provider %q", name)
+	}
+}
+
+// Latest returns the newest version of a project that matches filter
+func Latest(p Provider, id string, filter Filter) (*Version, error) {
+	versions, err := p.GetVersions(id, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%s project %s: %w", p.Name(), id, ErrNoVersion)
+	}
+	return &versions[0], nil
+}
+
+// ChannelAllowed reports whether a version on channel may be installed when tracking
+// wanted; an empty wanted accepts every channel
+func ChannelAllowed(channel, wanted string) bool {
+	rank := map[string]int{ChannelRelease: 1, ChannelBeta: 2, ChannelAlpha: 3, "stable": 1}
+	if wanted == "" {
+		return true
+	}
+	return rank[channel] <= rank[wanted]
+}
@@ -0,0 +1,142 @@
+// Package rcon implements the Source RCON protocol spoken by Minecraft servers with
+// enable-rcon=true in server.properties
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Packet types
+const (
+	typeResponse = 0
+	typeCommand  = 2
+	typeAuth     = 3
+)
+
+// maxCommandLength is the longest command body a Minecraft server accepts
+const maxCommandLength = 1446
+
+// ErrAuth is returned when the server rejects the password
+var ErrAuth = errors.New("rcon: authentication failed")
+
+// Client is an authenticated RCON connection
+type Client struct {
+	conn    net.Conn
+	timeout time.Duration
+
+	mu     sync.Mutex
+	nextID int32
+}
+
+// Dial connects to address (host:port) and authenticates with password. timeout limits
+// connecting and every later command.
+func Dial(address, password string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("rcon: %w", err)
+	}
+	c := &Client{conn: conn, timeout: timeout, nextID: 1}
+	if err := c.auth(password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Command runs a console command and returns its output
+func (c *Client) Command(command string) (string, error) {
+	if len(command) > maxCommandLength {
+		return "", fmt.Errorf("rcon: command is longer than %d bytes", maxCommandLength)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, err := c.send(typeCommand, command)
+	if err != nil {
+		return "", err
+	}
+	respID, _, body, err := c.read()
+	if err != nil {
+		return "", err
+	}
+	if respID != id {
+		return "", fmt.Errorf("rcon: response for request %d, expected %d", respID, id)
+	}
+	return body, nil
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// auth logs in; servers answer with the request ID, or -1 for a wrong password
+func (c *Client) auth(password string) error {
+	id, err := c.send(typeAuth, password)
+	if err != nil {
+		return err
+	}
+	for {
+		respID, typ, _, err := c.read()
+		if err != nil {
+			return err
+		}
+		// Some servers send an empty response value before the auth response
+		if typ != typeCommand {
+			continue
+		}
+		if respID != id {
+			return ErrAuth
+		}
+		return nil
+	}
+}
+
+// send writes one packet and returns its request ID
+func (c *Client) send(typ int32, body string) (int32, error) {
+	id := c.nextID
+	c.nextID++
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, int32(4+4+len(body)+2))
+	_ = binary.Write(&buf, binary.LittleEndian, id)
+	_ = binary.Write(&buf, binary.LittleEndian, typ)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+
+	if c.timeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return 0, fmt.Errorf("rcon: %w", err)
+	}
+	return id, nil
+}
+
+// read reads one packet
+func (c *Client) read() (id, typ int32, body string, err error) {
+	if c.timeout > 0 {
+		_ = c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+	var size int32
+	if err := binary.Read(c.conn, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", fmt.Errorf("rcon: %w", err)
+	}
+	if size < 10 || size > 1<<16 {
+		return 0, 0, "", fmt.Errorf("rcon: invalid packet size %d", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, data); err != nil {
+		return 0, 0, "", fmt.Errorf("rcon: %w", err)
+	}
+	id = int32(binary.LittleEndian.Uint32(data[0:4]))
+	typ = int32(binary.LittleEndian.Uint32(data[4:8]))
+	return id, typ, string(bytes.TrimRight(data[8:], "\x00")), nil
+}
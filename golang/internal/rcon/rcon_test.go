@@ -0,0 +1,94 @@
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeServer accepts one connection and answers like a Minecraft server
+func fakeServer(t *testing.T, password string, commands chan<- string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for {
+					var size, id, typ int32
+					if binary.Read(conn, binary.LittleEndian, &size) != nil {
+						return
+					}
+					data := make([]byte, size)
+					if _, err := io.ReadFull(conn, data); err != nil {
+						return
+					}
+					id = int32(binary.LittleEndian.Uint32(data[0:4]))
+					typ = int32(binary.LittleEndian.Uint32(data[4:8]))
+					body := string(bytes.TrimRight(data[8:], "\x00"))
+
+					switch typ {
+					case typeAuth:
+						if body != password {
+							id = -1
+						}
+						write(conn, id, typeCommand, "")
+					case typeCommand:
+						commands <- body
+						write(conn, id, typeResponse, "ran "+body)
+					}
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func write(w io.Writer, id, typ int32, body string) {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, int32(10+len(body)))
+	_ = binary.Write(&buf, binary.LittleEndian, id)
+	_ = binary.Write(&buf, binary.LittleEndian, typ)
+	buf.WriteString(body + "\x00\x00")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func TestClient(t *testing.T) {
+	commands := make(chan string, 2)
+	addr := fakeServer(t, "secret", commands)
+
+	if _, err := Dial(addr, "wrong", time.Second); !errors.Is(err, ErrAuth) {
+		t.Fatalf("Dial with a wrong password err = %v", err)
+	}
+
+	c, err := Dial(addr, "secret", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for _, cmd := range []string{"say hello", "list"} {
+		out, err := c.Command(cmd)
+		if err != nil || out != "ran "+cmd {
+			t.Fatalf("Command(%q) = %q, %v", cmd, out, err)
+		}
+		if got := <-commands; got != cmd {
+			t.Fatalf("server received %q, want %q", got, cmd)
+		}
+	}
+	if _, err := c.Command(string(make([]byte, maxCommandLength+1))); err == nil {
+		t.Fatal("expected an overly long command to be rejected")
+	}
+}
@@ -0,0 +1,264 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+)
+
+// Event types published by the scheduler
+const (
+	EventRunStarted  = "scheduled_run_started"
+	EventRunFinished = "scheduled_run_finished"
+	EventRunFailed   = "scheduled_run_failed"
+)
+
+// Task is a unit of work executed by the scheduler
+type Task func(ctx context.Context) error
+
+// AnnounceFunc is told ahead of time about a run that waits for the maintenance window
+type AnnounceFunc func(ctx context.Context, at time.Time)
+
+// Locker makes runs wait for their turn, e.g. with the other daemons of a fleet.
+// Lock blocks until the lock is held or ctx is done and returns the function that
+// releases it.
+type Locker interface {
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+// Scheduler runs a task periodically, optionally restricted to a maintenance window
+type Scheduler struct {
+	clock    clock.Clock
+	bus      *events.Bus
+	interval time.Duration
+	window   *Window
+	task     Task
+	announce AnnounceFunc
+	ahead    time.Duration // how long before a run announce is called
+	jitter   time.Duration // longest random delay of a run, see SetJitter
+	random   func(n int64) int64
+	lock     Locker
+	changed  chan struct{}
+	mu       sync.Mutex
+}
+
+// New creates a scheduler that runs task every interval
+func New(c clock.Clock, interval time.Duration, task Task) *Scheduler {
+	if c == nil {
+		c = clock.Real()
+	}
+	return &Scheduler{
+		clock:    c,
+		interval: interval,
+		task:     task,
+		random:   rand.Int64N,
+		changed:  make(chan struct{}, 1),
+	}
+}
+
+// SetWindow restricts runs to the given maintenance window (nil removes the restriction)
+func (s *Scheduler) SetWindow(w *Window) {
+	s.mu.Lock()
+	s.window = w
+	s.mu.Unlock()
+	s.notifyChanged()
+}
+
+// SetInterval changes the time between runs; a running scheduler reschedules immediately
+func (s *Scheduler) SetInterval(interval time.Duration) {
+	s.mu.Lock()
+	s.interval = interval
+	s.mu.Unlock()
+	s.notifyChanged()
+}
+
+// SetAnnouncement calls announce ahead of each run that waits for the maintenance
+// window to open, or right away when the window opens sooner than that. Runs inside
+// an open window are not announced; ahead 0 turns announcements off.
+func (s *Scheduler) SetAnnouncement(ahead time.Duration, announce AnnounceFunc) {
+	s.mu.Lock()
+	s.ahead, s.announce = ahead, announce
+	s.mu.Unlock()
+	s.notifyChanged()
+}
+
+// SetJitter delays every run by a random duration of up to jitter, drawn anew after each
+// run, so that daemons sharing a schedule do not all run at once. Inside a maintenance
+// window the delay is cut short to end before the window closes; 0 turns jitter off.
+func (s *Scheduler) SetJitter(jitter time.Duration) {
+	s.mu.Lock()
+	s.jitter = jitter
+	s.mu.Unlock()
+	s.notifyChanged()
+}
+
+// SetLock makes every run take lock first, waiting while another holder has it (nil
+// removes the lock)
+func (s *Scheduler) SetLock(lock Locker) {
+	s.mu.Lock()
+	s.lock = lock
+	s.mu.Unlock()
+}
+
+// delay draws the random delay of the next run, see SetJitter
+func (s *Scheduler) delay() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jitter <= 0 {
+		return 0
+	}
+	return time.Duration(s.random(int64(s.jitter)))
+}
+
+// jittered delays the run at t by delay, wrapping the delay around inside the time left in
+// the maintenance window so that the run stays inside it
+func (s *Scheduler) jittered(t time.Time, delay time.Duration) time.Time {
+	if delay <= 0 {
+		return t
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.window != nil && s.window.Contains(t) {
+		if left := s.window.Remaining(t); delay >= left {
+			delay %= left
+		}
+	}
+	return t.Add(delay)
+}
+
+// announcement returns when to announce the run at next, or the zero time when it is not
+// announced
+func (s *Scheduler) announcement(now, next time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.announce == nil || s.ahead <= 0 || s.window == nil || s.window.Contains(now) || !next.After(now) {
+		return time.Time{}
+	}
+	if at := next.Add(-s.ahead); at.After(now) {
+		return at
+	}
+	return now
+}
+
+// notifyChanged wakes Run so it recomputes the next run time
+func (s *Scheduler) notifyChanged() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// SetBus sets the event bus that run events are published to
+func (s *Scheduler) SetBus(bus *events.Bus) {
+	s.bus = bus
+}
+
+// NextRun returns when the next run is due after the given time
+func (s *Scheduler) NextRun(after time.Time) time.Time {
+	s.mu.Lock()
+	interval := s.interval
+	s.mu.Unlock()
+	return s.align(after.Add(interval))
+}
+
+// align moves t into the maintenance window, if one is set
+func (s *Scheduler) align(t time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.window != nil {
+		return s.window.Next(t)
+	}
+	return t
+}
+
+// Run executes the task on schedule until the context is cancelled
+func (s *Scheduler) Run(ctx context.Context) error {
+	last := s.clock.Now()
+	var announced time.Time // the run that was announced last
+	delay := s.delay()
+	for {
+		now := s.clock.Now()
+		next := s.NextRun(last)
+		if next.Before(now) {
+			// A shorter interval made the run overdue
+			next = s.align(now)
+		}
+		next = s.jittered(next, delay)
+		wake := next
+		announceAt := s.announcement(now, next)
+		announcing := !announceAt.IsZero() && !next.Equal(announced)
+		if announcing {
+			wake = announceAt
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.changed:
+			// Interval or window changed; recompute from the last run
+			continue
+		case <-s.clock.After(wake.Sub(now)):
+		}
+
+		if announcing {
+			s.mu.Lock()
+			announce := s.announce
+			s.mu.Unlock()
+			announce(ctx, next)
+			announced = next
+			continue
+		}
+		s.RunOnce(ctx)
+		last = s.clock.Now()
+		delay = s.delay()
+	}
+}
+
+// RunOnce executes the task immediately, once the lock is held if one is set, and
+// publishes the result
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	s.mu.Lock()
+	lock := s.lock
+	s.mu.Unlock()
+	if lock != nil {
+		unlock, err := lock.Lock(ctx)
+		if err != nil {
+			err = fmt.Errorf("failed to take the lock: %w", err)
+			s.publish(EventRunFailed, map[string]interface{}{"started": s.clock.Now(), "duration": "0s", "error": err.Error()})
+			return err
+		}
+		defer unlock()
+	}
+
+	started := s.clock.Now()
+	s.publish(EventRunStarted, map[string]interface{}{
+		"started": started,
+	})
+
+	err := s.task(ctx)
+	data := map[string]interface{}{
+		"started":  started,
+		"duration": s.clock.Now().Sub(started).String(),
+	}
+
+	if err != nil {
+		data["error"] = err.Error()
+		s.publish(EventRunFailed, data)
+		return err
+	}
+
+	s.publish(EventRunFinished, data)
+	return nil
+}
+
+// publish sends an event if a bus is configured
+func (s *Scheduler) publish(eventType string, data map[string]interface{}) {
+	if s.bus != nil {
+		s.bus.Publish(eventType, data)
+	}
+}
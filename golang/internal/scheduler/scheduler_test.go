@@ -0,0 +1,331 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+)
+
+func TestWindowContains(t *testing.T) {
+	cases := []struct {
+		start, end string
+		at         string
+		want       bool
+	}{
+		{"02:00", "04:00", "02:00", true},
+		{"02:00", "04:00", "03:59", true},
+		{"02:00", "04:00", "04:00", false},
+		{"02:00", "04:00", "01:59", false},
+		{"22:00", "02:00", "23:30", true},
+		{"22:00", "02:00", "01:00", true},
+		{"22:00", "02:00", "12:00", false},
+	}
+	for _, c := range cases {
+		w, err := ParseWindow(c.start, c.end, "UTC")
+		if err != nil {
+			t.Fatalf("ParseWindow(%q, %q): %v", c.start, c.end, err)
+		}
+		at, _ := time.Parse("15:04", c.at)
+		at = time.Date(2025, 1, 1, at.Hour(), at.Minute(), 0, 0, time.UTC)
+		if got := w.Contains(at); got != c.want {
+			t.Errorf("%s.Contains(%s) = %v, want %v", w, c.at, got, c.want)
+		}
+	}
+}
+
+func TestWindowNext(t *testing.T) {
+	w, err := ParseWindow("02:00", "04:00", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		from, want time.Time
+	}{
+		{time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 1, 1, 5, 0, 0, 0, time.UTC), time.Date(2025, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		if got := w.Next(c.from); !got.Equal(c.want) {
+			t.Errorf("Next(%s) = %s, want %s", c.from, got, c.want)
+		}
+	}
+}
+
+func TestWindowRemaining(t *testing.T) {
+	cases := []struct {
+		start, end string
+		at         string
+		want       time.Duration
+	}{
+		{"02:00", "04:00", "02:00", 2 * time.Hour},
+		{"02:00", "04:00", "03:45", 15 * time.Minute},
+		{"22:00", "02:00", "23:30", 150 * time.Minute},
+		{"22:00", "02:00", "01:00", time.Hour},
+	}
+	for _, c := range cases {
+		w, err := ParseWindow(c.start, c.end, "UTC")
+		if err != nil {
+			t.Fatalf("ParseWindow(%q, %q): %v", c.start, c.end, err)
+		}
+		at, _ := time.Parse("15:04", c.at)
+		at = time.Date(2025, 1, 1, at.Hour(), at.Minute(), 0, 0, time.UTC)
+		if got := w.Remaining(at); got != c.want {
+			t.Errorf("%s.Remaining(%s) = %s, want %s", w, c.at, got, c.want)
+		}
+	}
+}
+
+func TestSchedulerRunsInsideWindow(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	bus := events.NewBus(fake)
+	rec := events.NewRecorder(bus)
+	defer rec.Close()
+
+	window, err := ParseWindow("02:00", "04:00", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var runs []time.Time
+	s := New(fake, time.Hour, func(ctx context.Context) error {
+		runs = append(runs, fake.Now())
+		return nil
+	})
+	s.SetWindow(window)
+	s.SetBus(bus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	// 00:00 + 1h = 01:00 is outside the window, so the first run waits for 02:00
+	fake.BlockUntil(1)
+	fake.Advance(2 * time.Hour)
+	if !rec.WaitFor(EventRunFinished, 1, time.Second) {
+		t.Fatal("expected first scheduled run at 02:00")
+	}
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Hour)
+	if !rec.WaitFor(EventRunFinished, 2, time.Second) {
+		t.Fatal("expected second scheduled run at 03:00")
+	}
+
+	// 03:00 + 1h = 04:00 is the (exclusive) window end, so the next run is tomorrow
+	fake.BlockUntil(1)
+	fake.Advance(20 * time.Hour)
+	if rec.Count(EventRunFinished) != 2 {
+		t.Fatalf("expected no run before next window, got %d runs", rec.Count(EventRunFinished))
+	}
+	fake.Advance(3 * time.Hour)
+	if !rec.WaitFor(EventRunFinished, 3, time.Second) {
+		t.Fatal("expected third scheduled run on the next day")
+	}
+
+	want := []time.Time{
+		time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 1, 3, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 2, 2, 0, 0, 0, time.UTC),
+	}
+	for i, w := range want {
+		if !runs[i].Equal(w) {
+			t.Errorf("run %d at %s, want %s", i, runs[i], w)
+		}
+	}
+}
+
+func TestSchedulerPublishesFailures(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	bus := events.NewBus(fake)
+	rec := events.NewRecorder(bus, EventRunFailed)
+	defer rec.Close()
+
+	s := New(fake, time.Minute, func(ctx context.Context) error {
+		return errors.New("api unreachable")
+	})
+	s.SetBus(bus)
+
+	if err := s.RunOnce(context.Background()); err == nil {
+		t.Fatal("expected task error to be returned")
+	}
+
+	got := rec.Events()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 failure event, got %d", len(got))
+	}
+	if got[0].Data["error"] != "api unreachable" {
+		t.Errorf("unexpected error payload: %v", got[0].Data["error"])
+	}
+}
+
+func TestSchedulerAnnouncesWindowRuns(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	bus := events.NewBus(fake)
+	rec := events.NewRecorder(bus)
+	defer rec.Close()
+
+	window, err := ParseWindow("02:00", "04:00", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(fake, time.Hour, func(ctx context.Context) error { return nil })
+	s.SetWindow(window)
+	s.SetBus(bus)
+	announced := make(chan [2]time.Time, 4)
+	s.SetAnnouncement(30*time.Minute, func(ctx context.Context, at time.Time) {
+		announced <- [2]time.Time{fake.Now(), at}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	// The 02:00 run is announced at 01:30
+	fake.BlockUntil(1)
+	fake.Advance(90 * time.Minute)
+	select {
+	case got := <-announced:
+		want := [2]time.Time{start.Add(90 * time.Minute), start.Add(2 * time.Hour)}
+		if got != want {
+			t.Fatalf("announced %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an announcement at 01:30")
+	}
+
+	fake.BlockUntil(1)
+	fake.Advance(30 * time.Minute)
+	if !rec.WaitFor(EventRunFinished, 1, time.Second) {
+		t.Fatal("expected the run at 02:00")
+	}
+
+	// The 03:00 run is inside the open window and not announced
+	fake.BlockUntil(1)
+	fake.Advance(time.Hour)
+	if !rec.WaitFor(EventRunFinished, 2, time.Second) {
+		t.Fatal("expected the run at 03:00")
+	}
+	select {
+	case got := <-announced:
+		t.Fatalf("unexpected announcement %v", got)
+	default:
+	}
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	bus := events.NewBus(fake)
+	rec := events.NewRecorder(bus)
+	defer rec.Close()
+
+	window, err := ParseWindow("02:00", "04:00", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var runs []time.Time
+	s := New(fake, time.Hour, func(ctx context.Context) error {
+		runs = append(runs, fake.Now())
+		return nil
+	})
+	s.SetWindow(window)
+	s.SetBus(bus)
+	s.SetJitter(2 * time.Hour)
+	delays := []time.Duration{30 * time.Minute, 100 * time.Minute}
+	s.random = func(n int64) int64 {
+		if len(delays) == 0 {
+			return 0
+		}
+		d := delays[0]
+		delays = delays[1:]
+		return int64(d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	// The 02:00 run is delayed by 30m
+	fake.BlockUntil(1)
+	fake.Advance(150 * time.Minute)
+	if !rec.WaitFor(EventRunFinished, 1, time.Second) {
+		t.Fatal("expected the first run at 02:30")
+	}
+
+	// The 03:30 run is 30m before the window closes, so 100m wrap around to 10m
+	fake.BlockUntil(1)
+	fake.Advance(70 * time.Minute)
+	if !rec.WaitFor(EventRunFinished, 2, time.Second) {
+		t.Fatal("expected the second run at 03:40")
+	}
+
+	want := []time.Time{
+		time.Date(2025, 1, 1, 2, 30, 0, 0, time.UTC),
+		time.Date(2025, 1, 1, 3, 40, 0, 0, time.UTC),
+	}
+	for i, w := range want {
+		if !runs[i].Equal(w) {
+			t.Errorf("run %d at %s, want %s", i, runs[i], w)
+		}
+	}
+}
+
+// fakeLock is a Locker that fails with err or counts how often it is taken and released
+type fakeLock struct {
+	err              error
+	locked, unlocked int
+}
+
+func (l *fakeLock) Lock(ctx context.Context) (func(), error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	l.locked++
+	return func() { l.unlocked++ }, nil
+}
+
+func TestSchedulerTakesLock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	bus := events.NewBus(fake)
+	rec := events.NewRecorder(bus, EventRunFailed)
+	defer rec.Close()
+
+	var held bool
+	lock := &fakeLock{}
+	s := New(fake, time.Minute, func(ctx context.Context) error {
+		held = lock.locked == 1 && lock.unlocked == 0
+		return nil
+	})
+	s.SetBus(bus)
+	s.SetLock(lock)
+
+	if err := s.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !held || lock.unlocked != 1 {
+		t.Errorf("task ran with the lock held = %v, unlocked %d times; want true, 1", held, lock.unlocked)
+	}
+
+	lock.err = errors.New("redis unreachable")
+	held = false
+	if err := s.RunOnce(context.Background()); err == nil {
+		t.Fatal("expected the lock error to be returned")
+	}
+	if held || lock.locked != 1 {
+		t.Error("task ran without the lock")
+	}
+	if got := rec.Events(); len(got) != 1 || got[0].Data["error"] != "failed to take the lock: redis unreachable" {
+		t.Errorf("failure events = %v", got)
+	}
+}
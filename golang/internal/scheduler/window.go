@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// Window represents a daily maintenance window, e.g. 02:00-04:00 UTC.
+// Windows where End is before Start wrap past midnight.
+type Window struct {
+	Start    time.Duration // offset from midnight
+	End      time.Duration // offset from midnight
+	Location *time.Location
+}
+
+// ParseWindow parses HH:MM start/end times in the given timezone
+func ParseWindow(start, end, timezone string) (*Window, error) {
+	startOffset, err := parseClockTime(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window start: %w", err)
+	}
+
+	endOffset, err := parseClockTime(end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window end: %w", err)
+	}
+
+	if startOffset == endOffset {
+		return nil, fmt.Errorf("maintenance window start and end must differ")
+	}
+
+	loc := time.UTC
+	if timezone != "" {
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %s: %w", timezone, err)
+		}
+	}
+
+	return &Window{
+		Start:    startOffset,
+		End:      endOffset,
+		Location: loc,
+	}, nil
+}
+
+// WindowFromConfig builds a window from the maintenance configuration.
+// It returns nil (no restriction) when no window is configured.
+func WindowFromConfig(cfg *config.MaintenanceConfig) (*Window, error) {
+	if cfg == nil || (cfg.WindowStart == "" && cfg.WindowEnd == "") {
+		return nil, nil
+	}
+	return ParseWindow(cfg.WindowStart, cfg.WindowEnd, cfg.Timezone)
+}
+
+// parseClockTime parses an HH:MM string into an offset from midnight
+func parseClockTime(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether t falls inside the window (start inclusive, end exclusive)
+func (w *Window) Contains(t time.Time) bool {
+	local := t.In(w.Location)
+	offset := time.Duration(local.Hour())*time.Hour +
+		time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second +
+		time.Duration(local.Nanosecond())
+
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	// Window wraps past midnight
+	return offset >= w.Start || offset < w.End
+}
+
+// Remaining returns how long the window stays open after t, which must be inside it
+func (w *Window) Remaining(t time.Time) time.Duration {
+	local := t.In(w.Location)
+	offset := time.Duration(local.Hour())*time.Hour +
+		time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second +
+		time.Duration(local.Nanosecond())
+	if offset >= w.End {
+		// Window wraps past midnight
+		return 24*time.Hour - offset + w.End
+	}
+	return w.End - offset
+}
+
+// Next returns t itself if it is inside the window, otherwise the next window start after t
+func (w *Window) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+
+	local := t.In(w.Location)
+	start := w.startOn(local.Year(), local.Month(), local.Day())
+	if start.After(t) {
+		return start
+	}
+	return w.startOn(local.Year(), local.Month(), local.Day()+1)
+}
+
+// startOn returns the window start on the given calendar day in the window's timezone
+func (w *Window) startOn(year int, month time.Month, day int) time.Time {
+	hours := int(w.Start / time.Hour)
+	minutes := int((w.Start % time.Hour) / time.Minute)
+	return time.Date(year, month, day, hours, minutes, 0, 0, w.Location)
+}
+
+// String returns the window in HH:MM-HH:MM TZ format
+func (w *Window) String() string {
+	format := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int((d%time.Hour)/time.Minute))
+	}
+	return fmt.Sprintf("%s-%s %s", format(w.Start), format(w.End), w.Location)
+}
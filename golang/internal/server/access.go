@@ -0,0 +1,270 @@
+package server
+
+import (
+	"crypto/md5" // #nosec G501 -- offline UUIDs are defined as name-based MD5 UUIDs
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Files the server keeps its access lists in
+const (
+	WhitelistFile = "whitelist.json"
+	OpsFile       = "ops.json"
+)
+
+// defaultOpLevel is op-permission-level when server.properties does not set it
+const defaultOpLevel = 4
+
+// mojangProfileURL looks up the profile of a player name; a variable for tests
+var mojangProfileURL = "https://api.mojang.com/users/profiles/minecraft/"
+
+// playerName matches Minecraft player names, which also keeps them safe to pass to
+// console commands
+var playerName = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+var (
+	// ErrInvalidPlayerName is returned for names Minecraft does not allow
+	ErrInvalidPlayerName = errors.New("invalid player name")
+	// ErrPlayerNotFound is returned when Mojang has no player with the name
+	ErrPlayerNotFound = errors.New("no Minecraft player with that name")
+)
+
+// Player is an entry of whitelist.json
+type Player struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// Op is an entry of ops.json
+type Op struct {
+	UUID                string `json:"uuid"`
+	Name                string `json:"name"`
+	Level               int    `json:"level"`
+	BypassesPlayerLimit bool   `json:"bypassesPlayerLimit"`
+}
+
+// Access changes the whitelist and the operators of a server. While the server runs the
+// changes are made through its console, so that they apply at once and the server does not
+// overwrite them; otherwise whitelist.json and ops.json are edited directly.
+type Access struct {
+	serverPath string
+	console    Commander // nil while the server is stopped
+	client     *http.Client
+}
+
+// NewAccess manages the access lists in serverPath. Pass the server's console when it is
+// running, or nil. client looks up the UUIDs of players added to the files.
+func NewAccess(serverPath string, console Commander, client *http.Client) *Access {
+	return &Access{serverPath: serverPath, console: console, client: client}
+}
+
+// Live reports whether changes go through the server console
+func (a *Access) Live() bool {
+	return a.console != nil
+}
+
+// Whitelist returns the whitelisted players
+func (a *Access) Whitelist() ([]Player, error) {
+	players := []Player{}
+	return players, readAccessFile(a.serverPath, WhitelistFile, &players)
+}
+
+// Ops returns the operators
+func (a *Access) Ops() ([]Op, error) {
+	ops := []Op{}
+	return ops, readAccessFile(a.serverPath, OpsFile, &ops)
+}
+
+// AddToWhitelist lets name join while the whitelist is on
+func (a *Access) AddToWhitelist(name string) error {
+	if err := checkPlayerName(name); err != nil {
+		return err
+	}
+	if a.console != nil {
+		return a.console.SendCommand("whitelist add " + name)
+	}
+	players, err := a.Whitelist()
+	if err != nil {
+		return err
+	}
+	for _, p := range players {
+		if strings.EqualFold(p.Name, name) {
+			return nil
+		}
+	}
+	player, err := a.lookup(name)
+	if err != nil {
+		return err
+	}
+	return writeAccessFile(a.serverPath, WhitelistFile, append(players, player))
+}
+
+// RemoveFromWhitelist takes name off the whitelist
+func (a *Access) RemoveFromWhitelist(name string) error {
+	if err := checkPlayerName(name); err != nil {
+		return err
+	}
+	if a.console != nil {
+		return a.console.SendCommand("whitelist remove " + name)
+	}
+	players, err := a.Whitelist()
+	if err != nil {
+		return err
+	}
+	kept := players[:0]
+	for _, p := range players {
+		if !strings.EqualFold(p.Name, name) {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(players) {
+		return nil
+	}
+	return writeAccessFile(a.serverPath, WhitelistFile, kept)
+}
+
+// AddOp makes name an operator at the server's op-permission-level
+func (a *Access) AddOp(name string) error {
+	if err := checkPlayerName(name); err != nil {
+		return err
+	}
+	if a.console != nil {
+		return a.console.SendCommand("op " + name)
+	}
+	ops, err := a.Ops()
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if strings.EqualFold(op.Name, name) {
+			return nil
+		}
+	}
+	player, err := a.lookup(name)
+	if err != nil {
+		return err
+	}
+	level := defaultOpLevel
+	if props, err := LoadProperties(a.serverPath); err == nil {
+		level = props.Int("op-permission-level", defaultOpLevel)
+	}
+	return writeAccessFile(a.serverPath, OpsFile, append(ops, Op{UUID: player.UUID, Name: player.Name, Level: level}))
+}
+
+// RemoveOp takes the operator status from name
+func (a *Access) RemoveOp(name string) error {
+	if err := checkPlayerName(name); err != nil {
+		return err
+	}
+	if a.console != nil {
+		return a.console.SendCommand("deop " + name)
+	}
+	ops, err := a.Ops()
+	if err != nil {
+		return err
+	}
+	kept := ops[:0]
+	for _, op := range ops {
+		if !strings.EqualFold(op.Name, name) {
+			kept = append(kept, op)
+		}
+	}
+	if len(kept) == len(ops) {
+		return nil
+	}
+	return writeAccessFile(a.serverPath, OpsFile, kept)
+}
+
+// lookup finds the UUID the server would use for name: the Mojang account in online mode,
+// or the name-based offline UUID otherwise
+func (a *Access) lookup(name string) (Player, error) {
+	if props, err := LoadProperties(a.serverPath); err == nil && !props.OnlineMode() {
+		return Player{UUID: OfflineUUID(name), Name: name}, nil
+	}
+
+	resp, err := a.client.Get(mojangProfileURL + url.PathEscape(name))
+	if err != nil {
+		return Player{}, fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNoContent:
+		return Player{}, fmt.Errorf("%s: %w", name, ErrPlayerNotFound)
+	case resp.StatusCode != http.StatusOK:
+		return Player{}, fmt.Errorf("failed to look up %s: %s", name, resp.Status)
+	}
+	var profile struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return Player{}, fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+	uuid, err := dashUUID(profile.ID)
+	if err != nil {
+		return Player{}, fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+	return Player{UUID: uuid, Name: profile.Name}, nil
+}
+
+// OfflineUUID is the UUID an offline-mode server gives name
+func OfflineUUID(name string) string {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name)) // #nosec G401 -- not used for security
+	sum[6] = sum[6]&0x0f | 0x30                     // version 3
+	sum[8] = sum[8]&0x3f | 0x80                     // RFC 4122 variant
+	id, _ := dashUUID(hex.EncodeToString(sum[:]))
+	return id
+}
+
+// dashUUID formats 32 hex digits the way the server files do
+func dashUUID(id string) (string, error) {
+	if len(id) != 32 {
+		return "", fmt.Errorf("invalid UUID %q", id)
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", fmt.Errorf("invalid UUID %q", id)
+	}
+	return strings.ToLower(id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:]), nil
+}
+
+func checkPlayerName(name string) error {
+	if !playerName.MatchString(name) {
+		return fmt.Errorf("%w %q: use 1 to 16 letters, digits or underscores", ErrInvalidPlayerName, name)
+	}
+	return nil
+}
+
+// readAccessFile decodes one of the JSON access lists; a missing file is an empty list
+func readAccessFile(serverPath, name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(serverPath, name)) // #nosec G304 -- fixed name in the server directory
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeAccessFile writes an access list indented like the server does
+func writeAccessFile(serverPath, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(serverPath, name), append(data, '\n'), 0o644); err != nil { // #nosec G306 -- read by the server
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
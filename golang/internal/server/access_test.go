@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordingConsole remembers the commands sent to it
+type recordingConsole struct {
+	commands []string
+}
+
+func (r *recordingConsole) SendCommand(command string) error {
+	r.commands = append(r.commands, command)
+	return nil
+}
+
+func TestOfflineUUID(t *testing.T) {
+	if got := OfflineUUID("Notch"); got != "b50ad385-829d-3141-a216-7e7d7539ba7f" {
+		t.Errorf("OfflineUUID(Notch) = %s", got)
+	}
+}
+
+func TestAccessFiles(t *testing.T) {
+	mojang := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(filepath.Base(r.URL.Path), "steve") {
+			_, _ = w.Write([]byte(`{"id":"8667BA71B85A4004AF54457A9734EED7","name":"Steve"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer mojang.Close()
+	defer func(old string) { mojangProfileURL = old }(mojangProfileURL)
+	mojangProfileURL = mojang.URL + "/"
+
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, PropertiesFile), []byte("op-permission-level=3\n"), 0o644)
+	a := NewAccess(dir, nil, mojang.Client())
+
+	if players, err := a.Whitelist(); err != nil || len(players) != 0 {
+		t.Fatalf("whitelist without whitelist.json = %v, %v", players, err)
+	}
+	if err := a.AddToWhitelist("steve"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AddToWhitelist("Steve"); err != nil { // already whitelisted
+		t.Fatal(err)
+	}
+	players, _ := a.Whitelist()
+	if want := []Player{{UUID: "8667ba71-b85a-4004-af54-457a9734eed7", Name: "Steve"}}; !reflect.DeepEqual(players, want) {
+		t.Errorf("whitelist = %v, want %v", players, want)
+	}
+	if err := a.AddToWhitelist("nobody"); err == nil || !strings.Contains(err.Error(), ErrPlayerNotFound.Error()) {
+		t.Errorf("unknown player: %v", err)
+	}
+	if err := a.AddToWhitelist("bad name; stop"); err == nil {
+		t.Error("invalid name accepted")
+	}
+
+	if err := a.AddOp("Steve"); err != nil {
+		t.Fatal(err)
+	}
+	ops, _ := a.Ops()
+	if len(ops) != 1 || ops[0].Level != 3 || ops[0].UUID != players[0].UUID {
+		t.Errorf("ops = %v", ops)
+	}
+
+	if err := a.RemoveFromWhitelist("STEVE"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RemoveOp("steve"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, WhitelistFile))
+	if string(data) != "[]\n" {
+		t.Errorf("whitelist.json = %q", data)
+	}
+	if ops, _ := a.Ops(); len(ops) != 0 {
+		t.Errorf("ops after removal = %v", ops)
+	}
+}
+
+func TestAccessOfflineMode(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, PropertiesFile), []byte("online-mode=false\n"), 0o644)
+	// No client: offline servers never ask Mojang
+	a := NewAccess(dir, nil, nil)
+	if err := a.AddToWhitelist("Notch"); err != nil {
+		t.Fatal(err)
+	}
+	if players, _ := a.Whitelist(); len(players) != 1 || players[0].UUID != OfflineUUID("Notch") {
+		t.Errorf("whitelist = %v", players)
+	}
+}
+
+func TestAccessConsole(t *testing.T) {
+	console := &recordingConsole{}
+	a := NewAccess(t.TempDir(), console, nil)
+	_ = a.AddToWhitelist("Steve")
+	_ = a.RemoveFromWhitelist("Steve")
+	_ = a.AddOp("Alex")
+	_ = a.RemoveOp("Alex")
+	want := []string{"whitelist add Steve", "whitelist remove Steve", "op Alex", "deop Alex"}
+	if !reflect.DeepEqual(console.commands, want) {
+		t.Errorf("commands = %q, want %q", console.commands, want)
+	}
+}
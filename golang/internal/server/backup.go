@@ -1,15 +1,23 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/archive"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
 	"github.com/klauspost/compress/zip"
 )
 
@@ -18,7 +26,27 @@ type BackupManager struct {
 	serverPath  string
 	backupPath  string
 	compression bool
-	retention   int // days
+	retention   RetentionPolicy
+	clock       clock.Clock
+	logger      *slog.Logger
+	dryRun      bool
+	// ctx stops backups and restores once it is done, see SetContext
+	ctx context.Context
+	// restoreOwner is the "user[:group]" restored files are given, see SetRestoreOwner
+	restoreOwner string
+	// snapshot is the method backups are taken with, see SetSnapshot
+	snapshot string
+	// trigger and version are recorded in the index, see SetTrigger and SetVersionSource
+	trigger string
+	version func() string
+	// encryption encrypts new archives and decrypts old ones, see SetEncryption
+	encryption config.BackupEncryptionConfig
+
+	// scryptWorkFactor overrides the cost of passphrase encryption in tests
+	scryptWorkFactor int
+
+	// run executes a snapshot command and returns its combined output; replaced in tests
+	run func(name string, args ...string) ([]byte, error)
 }
 
 // NewBackupManager creates a new backup manager
@@ -27,7 +55,14 @@ func NewBackupManager(serverPath, backupPath string, compression bool, retention
 		serverPath:  serverPath,
 		backupPath:  backupPath,
 		compression: compression,
-		retention:   retention,
+		retention:   RetentionPolicy{Days: retention},
+		clock:       clock.Real(),
+		logger:      slog.Default(),
+		ctx:         context.Background(),
+		run: func(name string, args ...string) ([]byte, error) {
+			// #nosec G204 -- only btrfs and zfs are run, with paths from the config
+			return exec.Command(name, args...).CombinedOutput()
+		},
 	}
 }
 
@@ -38,19 +73,29 @@ type BackupInfo struct {
 	Size         int64
 	Created      time.Time
 	IsCompressed bool
+	Encrypted    bool   // an age encrypted zip archive, see SetEncryption
 	Type         string // full, incremental, pre-update, etc.
+	Protected    bool   // kept by pruning and DeleteBackup, see ProtectBackup
+
+	// Recorded in the index when the backup was made; empty for older backups
+	Trigger  string        // what made the backup, see SetTrigger
+	Version  string        // the modpack version installed at the time
+	Checksum string        // "sha256:" and the hex digest, see ValidateBackup
+	Duration time.Duration // how long making the backup took
 }
 
 // CreateBackup creates a new backup
 func (bm *BackupManager) CreateBackup(name string, backupType string) (*BackupInfo, error) {
-	// Ensure backup directory exists
-	if err := filesystem.EnsureDir(bm.backupPath); err != nil {
-		return nil, fmt.Errorf("failed to create backup directory: %w", err)
-	}
+	return bm.createBackup(name, backupType, nil)
+}
+
+// createBackup archives dirs, relative to the server path, or the whole server when dirs is nil
+func (bm *BackupManager) createBackup(name string, backupType string, dirs []string) (*BackupInfo, error) {
+	started := bm.clock.Now()
 
 	// Generate backup name if not provided
 	if name == "" {
-		name = fmt.Sprintf("backup_%s", time.Now().Format("20060102_150405"))
+		name = fmt.Sprintf("backup_%s", bm.clock.Now().Format("20060102_150405"))
 	}
 
 	// Add type suffix if provided
@@ -58,56 +103,201 @@ func (bm *BackupManager) CreateBackup(name string, backupType string) (*BackupIn
 		name = fmt.Sprintf("%s_%s", name, backupType)
 	}
 
-	var backupFilePath string
-	var err error
+	backupFilePath := filepath.Join(bm.backupPath, name)
+	if bm.dryRun {
+		if bm.compression && bm.snapshot == "" {
+			backupFilePath += bm.archiveSuffix()
+		}
+		return bm.planBackup(name, backupType, backupFilePath, dirs)
+	}
 
+	// Ensure backup directory exists
+	if err := filesystem.EnsureDir(bm.backupPath); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	if bm.snapshot != "" {
+		method, err := bm.snapshotBackup(backupFilePath, dirs)
+		if err == nil {
+			return bm.backupCreated(name, backupType, backupFilePath, method, started)
+		}
+		bm.logger.Warn("snapshot backup failed, creating a regular backup instead", "backup", name, "snapshot", method, "error", err)
+		if err := os.RemoveAll(backupFilePath); err != nil {
+			return nil, fmt.Errorf("failed to remove the partial snapshot: %w", err)
+		}
+	}
+
+	var err error
 	if bm.compression {
-		backupFilePath = filepath.Join(bm.backupPath, name+".zip")
-		err = bm.createCompressedBackup(backupFilePath)
+		backupFilePath += bm.archiveSuffix()
+		err = bm.createCompressedBackup(backupFilePath, dirs)
 	} else {
-		backupFilePath = filepath.Join(bm.backupPath, name)
-		err = bm.createUncompressedBackup(backupFilePath)
+		err = bm.createUncompressedBackup(backupFilePath, dirs)
 	}
 
 	if err != nil {
+		if removeErr := bm.removeBackup(backupFilePath); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			bm.logger.Warn("failed to remove partial backup", "backup", name, "error", removeErr)
+		}
 		return nil, fmt.Errorf("failed to create backup: %w", err)
 	}
+	return bm.backupCreated(name, backupType, backupFilePath, "", started)
+}
 
-	// Get backup size
+// backupCreated records the backup just written to backupFilePath, taken as a snapshot
+// when snapshot names the method, in the index and describes it. A backup that cannot be
+// recorded is removed again, so that the index never misses a backup it made.
+func (bm *BackupManager) backupCreated(name, backupType, backupFilePath, snapshot string, started time.Time) (*BackupInfo, error) {
+	backup, err := bm.describeBackup(name, backupType, backupFilePath, started)
+	if err == nil {
+		err = bm.recordBackup(indexEntry{
+			Name:     filepath.Base(backupFilePath),
+			Type:     backup.Type,
+			Trigger:  backup.Trigger,
+			Version:  backup.Version,
+			Created:  backup.Created,
+			Size:     backup.Size,
+			Checksum: backup.Checksum,
+			Duration: backup.Duration,
+			Snapshot: snapshot,
+		})
+	}
+	if err != nil {
+		if removeErr := bm.removeBackup(backupFilePath); removeErr != nil {
+			bm.logger.Warn("failed to remove unrecorded backup", "backup", name, "error", removeErr)
+		}
+		return nil, err
+	}
+	bm.logger.Info("backup created", "backup", name, "type", backupType, "size", backup.Size, "snapshot", snapshot, "duration", backup.Duration)
+	return backup, nil
+}
+
+// describeBackup sizes and checksums the backup at backupFilePath
+func (bm *BackupManager) describeBackup(name, backupType, backupFilePath string, started time.Time) (*BackupInfo, error) {
 	size, err := bm.getBackupSize(backupFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get backup size: %w", err)
 	}
+	checksum, err := backupChecksum(backupFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum backup: %w", err)
+	}
+	var version string
+	if bm.version != nil {
+		version = bm.version()
+	}
+	created := bm.clock.Now()
+	return &BackupInfo{
+		Name:         name,
+		Path:         backupFilePath,
+		Size:         size,
+		Created:      created,
+		IsCompressed: isArchive(backupFilePath),
+		Encrypted:    isEncrypted(backupFilePath),
+		Type:         backupType,
+		Trigger:      bm.trigger,
+		Version:      version,
+		Checksum:     checksum,
+		Duration:     created.Sub(started),
+	}, nil
+}
 
+// planBackup describes the backup createBackup would write in dry-run mode; its size is
+// that of the files to archive, before compression
+func (bm *BackupManager) planBackup(name, backupType, backupFilePath string, dirs []string) (*BackupInfo, error) {
+	var size int64
+	for _, root := range bm.backupRoots(dirs) {
+		n, err := filesystem.GetDirSize(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to size %s: %w", root, err)
+		}
+		size += n
+	}
+	bm.logger.Info("dry run: would create backup", "backup", name, "type", backupType, "path", backupFilePath, "size", size)
 	return &BackupInfo{
 		Name:         name,
 		Path:         backupFilePath,
 		Size:         size,
-		Created:      time.Now(),
+		Created:      bm.clock.Now(),
 		IsCompressed: bm.compression,
+		Encrypted:    isEncrypted(backupFilePath),
 		Type:         backupType,
 	}, nil
 }
 
-// createCompressedBackup creates a compressed backup
-func (bm *BackupManager) createCompressedBackup(backupPath string) error {
+// archiveSuffix is the extension of zip backups
+func (bm *BackupManager) archiveSuffix() string {
+	if bm.encryption.Enabled() {
+		return ".zip" + EncryptedSuffix
+	}
+	return ".zip"
+}
+
+// createCompressedBackup creates a compressed backup of dirs, or the whole server when
+// dirs is nil, encrypting it when encryption is set
+func (bm *BackupManager) createCompressedBackup(backupPath string, dirs []string) (err error) {
 	// Create zip file
 	// #nosec G304 -- backupPath is constructed internally
 	zipFile, err := os.Create(backupPath)
 	if err != nil {
 		return fmt.Errorf("failed to create backup file: %w", err)
 	}
-	defer zipFile.Close()
+	defer func() {
+		if closeErr := zipFile.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write backup file: %w", closeErr)
+		}
+	}()
+
+	var out io.Writer = zipFile
+	var encrypted io.WriteCloser
+	if bm.encryption.Enabled() {
+		if encrypted, err = bm.encrypt(zipFile); err != nil {
+			return err
+		}
+		out = encrypted
+	}
 
 	// Create zip writer
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	zipWriter := zip.NewWriter(out)
 
 	// Walk through server directory and add files to zip
-	err = filepath.Walk(bm.serverPath, func(path string, info os.FileInfo, walkErr error) error {
+	for _, root := range bm.backupRoots(dirs) {
+		if err := bm.addToZip(zipWriter, root); err != nil {
+			return fmt.Errorf("backup zip creation failed: %w", err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if encrypted != nil {
+		if err := encrypted.Close(); err != nil {
+			return fmt.Errorf("failed to write backup file: %w", err)
+		}
+	}
+	return nil
+}
+
+// backupRoots returns the absolute directories to archive
+func (bm *BackupManager) backupRoots(dirs []string) []string {
+	if dirs == nil {
+		return []string{bm.serverPath}
+	}
+	roots := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		roots = append(roots, filepath.Join(bm.serverPath, dir))
+	}
+	return roots
+}
+
+// addToZip adds root and everything below it, named relative to the server path
+func (bm *BackupManager) addToZip(zipWriter *zip.Writer, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, walkErr error) error {
 		if walkErr != nil {
 			return fmt.Errorf("walk error at %s: %w", path, walkErr)
 		}
+		if err := bm.ctx.Err(); err != nil {
+			return err
+		}
 
 		// Skip certain files/directories
 		if bm.shouldSkipFile(path, info) {
@@ -123,13 +313,19 @@ func (bm *BackupManager) createCompressedBackup(backupPath string) error {
 			return fmt.Errorf("failed to get relative path for %s: %w", path, relErr)
 		}
 
+		// Record the permissions and owner, so that a restore can put them back
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return fmt.Errorf("failed to create zip header for %s: %w", relPath, err)
+		}
+		if uid, gid, ok := filesystem.Owner(info); ok {
+			header.Extra = archive.OwnerField(uid, gid)
+		}
+
 		if info.IsDir() {
 			// Create directory entry
-			header := &zip.FileHeader{
-				Name:     relPath + "/",
-				Method:   zip.Store,
-				Modified: info.ModTime(),
-			}
+			header.Name = filepath.ToSlash(relPath) + "/"
+			header.Method = zip.Store
 			_, err := zipWriter.CreateHeader(header)
 			if err != nil {
 				return fmt.Errorf("failed to create zip dir header for %s: %w", relPath, err)
@@ -138,11 +334,8 @@ func (bm *BackupManager) createCompressedBackup(backupPath string) error {
 		}
 
 		// Create file entry
-		header := &zip.FileHeader{
-			Name:     relPath,
-			Method:   zip.Deflate,
-			Modified: info.ModTime(),
-		}
+		header.Name = filepath.ToSlash(relPath)
+		header.Method = zip.Deflate
 		writer, err := zipWriter.CreateHeader(header)
 		if err != nil {
 			return fmt.Errorf("failed to create zip file header for %s: %w", relPath, err)
@@ -161,18 +354,39 @@ func (bm *BackupManager) createCompressedBackup(backupPath string) error {
 		}
 		return nil
 	})
+}
 
-	if err != nil {
-		return fmt.Errorf("backup zip creation failed: %w", err)
+// createUncompressedBackup creates an uncompressed backup of dirs, or the whole server when dirs is nil
+func (bm *BackupManager) createUncompressedBackup(backupPath string, dirs []string) error {
+	// The copies keep the permissions of the server files, and as root also their owner
+	var owner ownerFunc
+	if os.Geteuid() == 0 {
+		owner = sameOwner
+	}
+	if dirs == nil {
+		if err := bm.ctx.Err(); err != nil {
+			return err
+		}
+		if err := filesystem.CopyDir(bm.serverPath, backupPath); err != nil {
+			return err
+		}
+		return copyAttributes(bm.serverPath, backupPath, owner)
+	}
+	for _, dir := range dirs {
+		if err := bm.ctx.Err(); err != nil {
+			return err
+		}
+		src, dst := filepath.Join(bm.serverPath, dir), filepath.Join(backupPath, dir)
+		if err := filesystem.CopyDir(src, dst); err != nil {
+			return err
+		}
+		if err := copyAttributes(src, dst, owner); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// createUncompressedBackup creates an uncompressed backup
-func (bm *BackupManager) createUncompressedBackup(backupPath string) error {
-	return filesystem.CopyDir(bm.serverPath, backupPath)
-}
-
 // shouldSkipFile determines if a file should be skipped during backup
 func (bm *BackupManager) shouldSkipFile(path string, info os.FileInfo) bool {
 	// Skip lock files
@@ -217,7 +431,9 @@ func (bm *BackupManager) getBackupSize(backupPath string) (int64, error) {
 	return 0, fmt.Errorf("getBackupSize: path does not exist: %q", backupPath)
 }
 
-// ListBackups lists all available backups
+// ListBackups lists all available backups, newest first. Backups are described by the
+// index; those made before it, or copied into the backup directory by hand, are described
+// from their names and modification times instead.
 func (bm *BackupManager) ListBackups() ([]BackupInfo, error) {
 	if !filesystem.DirExists(bm.backupPath) {
 		return []BackupInfo{}, nil
@@ -227,43 +443,40 @@ func (bm *BackupManager) ListBackups() ([]BackupInfo, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read backup directory: %w", err)
 	}
+	protected, err := bm.protectedBackups()
+	if err != nil {
+		return nil, err
+	}
+	indexed := bm.indexedBackups()
 
 	var backups []BackupInfo
 	for _, entry := range entries {
-		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".zip") {
-			backupPath := filepath.Join(bm.backupPath, entry.Name())
-			var createdTime time.Time
-			size, err := bm.getBackupSize(backupPath)
-			if err != nil {
-				size = 0
-			}
-			if filesystem.FileExists(backupPath) || filesystem.DirExists(backupPath) {
-				info, err := os.Stat(backupPath)
-				if err == nil {
-					createdTime = info.ModTime()
-				}
-			}
-			backupInfo := BackupInfo{
-				Name:         entry.Name(),
-				Path:         backupPath,
-				Size:         size,
-				Created:      createdTime,
-				IsCompressed: strings.HasSuffix(entry.Name(), ".zip"),
-			}
-
-			// Try to determine backup type from name
-			if strings.Contains(entry.Name(), "_pre_update") {
-				backupInfo.Type = "pre-update"
-			} else if strings.Contains(entry.Name(), "_post_update") {
-				backupInfo.Type = "post-update"
-			} else if strings.Contains(entry.Name(), "_manual") {
-				backupInfo.Type = "manual"
-			} else {
-				backupInfo.Type = "automatic"
+		backupPath := filepath.Join(bm.backupPath, entry.Name())
+		// ZFS snapshots are listed through a symlink, see SetSnapshot
+		isLink := entry.Type()&os.ModeSymlink != 0 && filesystem.DirExists(backupPath)
+		if !entry.IsDir() && !isLink && !isArchive(entry.Name()) {
+			continue
+		}
+		var backupInfo BackupInfo
+		if e, ok := indexed[entry.Name()]; ok {
+			backupInfo = BackupInfo{
+				Size:     e.Size,
+				Created:  e.Created,
+				Type:     e.Type,
+				Trigger:  e.Trigger,
+				Version:  e.Version,
+				Checksum: e.Checksum,
+				Duration: e.Duration,
 			}
-
-			backups = append(backups, backupInfo)
+		} else {
+			backupInfo = bm.unindexedBackup(entry.Name(), backupPath)
 		}
+		backupInfo.Name = entry.Name()
+		backupInfo.Path = backupPath
+		backupInfo.IsCompressed = isArchive(entry.Name())
+		backupInfo.Encrypted = isEncrypted(entry.Name())
+		backupInfo.Protected = slices.Contains(protected, entry.Name())
+		backups = append(backups, backupInfo)
 	}
 
 	// Sort by creation time (newest first)
@@ -274,108 +487,43 @@ func (bm *BackupManager) ListBackups() ([]BackupInfo, error) {
 	return backups, nil
 }
 
-// RestoreBackup restores a backup
-func (bm *BackupManager) RestoreBackup(backupName string) error {
-	// Find backup
-	backups, err := bm.ListBackups()
-	if err != nil {
-		return fmt.Errorf("failed to list backups: %w", err)
-	}
-
-	var targetBackup *BackupInfo
-	for _, backup := range backups {
-		if backup.Name == backupName {
-			targetBackup = &backup
-			break
-		}
-	}
-
-	if targetBackup == nil {
-		return fmt.Errorf("backup not found: %s", backupName)
-	}
-
-	// Create temporary restore directory
-	tempDir := filepath.Join(bm.backupPath, "temp_restore_"+time.Now().Format("20060102_150405"))
-	if err := filesystem.EnsureDir(tempDir); err != nil {
-		return fmt.Errorf("failed to create temp restore directory: %w", err)
-	}
-	// Clean up tempDir after restore, log error if any
-	defer func() {
-		if err := filesystem.RemoveDir(tempDir); err != nil {
-			fmt.Fprintf(os.Stderr, "[WARN] failed to remove temp restore dir %s: %v\n", tempDir, err)
-		}
-	}()
-
-	// Extract/copy backup to temp directory
-	if targetBackup.IsCompressed {
-		if err := bm.extractBackup(targetBackup.Path, tempDir); err != nil {
-			return fmt.Errorf("failed to extract backup: %w", err)
-		}
+// unindexedBackup describes a backup missing from the index by its name and modification time
+func (bm *BackupManager) unindexedBackup(name, backupPath string) BackupInfo {
+	var backupInfo BackupInfo
+	if size, err := bm.getBackupSize(backupPath); err == nil {
+		backupInfo.Size = size
+	}
+	if info, err := os.Stat(backupPath); err == nil {
+		backupInfo.Created = info.ModTime()
+	}
+
+	// Try to determine backup type from name
+	if strings.HasSuffix(strings.TrimSuffix(strings.TrimSuffix(name, EncryptedSuffix), ".zip"), "_"+BackupTypeWorld) {
+		backupInfo.Type = BackupTypeWorld
+	} else if strings.HasPrefix(name, "pre_restore_") {
+		backupInfo.Type = BackupTypePreRestore
+	} else if strings.Contains(name, "_pre_update") {
+		backupInfo.Type = "pre-update"
+	} else if strings.Contains(name, "_post_update") {
+		backupInfo.Type = "post-update"
+	} else if strings.Contains(name, "_manual") {
+		backupInfo.Type = "manual"
 	} else {
-		if err := filesystem.CopyDir(targetBackup.Path, tempDir); err != nil {
-			return fmt.Errorf("failed to copy backup: %w", err)
-		}
-	}
-
-	// Remove current server directory
-	if err := filesystem.RemoveDir(bm.serverPath); err != nil {
-		return fmt.Errorf("failed to remove current server directory: %w", err)
-	}
-
-	// Move temp directory to server path
-	if err := filesystem.MoveFile(tempDir, bm.serverPath); err != nil {
-		return fmt.Errorf("failed to restore backup: %w", err)
+		backupInfo.Type = "automatic"
 	}
-
-	return nil
+	return backupInfo
 }
 
-// extractBackup extracts a compressed backup
-func (bm *BackupManager) extractBackup(backupPath, targetPath string) error {
-	// Open zip file
-	reader, err := zip.OpenReader(backupPath)
-	if err != nil {
-		return fmt.Errorf("failed to open backup file: %w", err)
-	}
-	defer reader.Close()
-
-	// Extract files
-	for _, file := range reader.File {
-		filePath := filepath.Join(targetPath, file.Name)
-
-		if file.FileInfo().IsDir() {
-			// Create directory
-			if err := filesystem.EnsureDir(filePath); err != nil {
-				return fmt.Errorf("failed to create directory %s: %w", filePath, err)
-			}
-			continue
-		}
-
-		// Create file
-		if err := filesystem.EnsureDir(filepath.Dir(filePath)); err != nil {
-			return fmt.Errorf("failed to create parent directory for %s: %w", filePath, err)
-		}
-
-		fileReader, err := file.Open()
-		if err != nil {
-			return fmt.Errorf("failed to open file in backup: %w", err)
-		}
-		defer fileReader.Close()
-
-		// #nosec G304 -- filePath is constructed internally
-		outFile, err := os.Create(filePath)
-		if err != nil {
-			return fmt.Errorf("failed to create output file %s: %w", filePath, err)
-		}
-		defer outFile.Close()
-
-		_, err = io.Copy(outFile, fileReader)
-		if err != nil {
-			return fmt.Errorf("failed to copy file content: %w", err)
-		}
-	}
+// RestoreBackup restores a backup, taking a pre-restore snapshot of the files it replaces
+func (bm *BackupManager) RestoreBackup(backupName string) error {
+	_, err := bm.Restore(backupName, RestoreOptions{Snapshot: true})
+	return err
+}
 
-	return nil
+// extractBackup extracts a compressed backup, giving the files the owner that owner picks
+func (bm *BackupManager) extractBackup(backupPath, targetPath string, owner ownerFunc) error {
+	_, err := archive.ExtractZip(backupPath, targetPath, archive.Options{Owner: zipOwner(owner)})
+	return err
 }
 
 // DeleteBackup deletes a backup
@@ -385,6 +533,22 @@ func (bm *BackupManager) DeleteBackup(backupName string) error {
 	if !filesystem.FileExists(backupPath) && !filesystem.DirExists(backupPath) {
 		return fmt.Errorf("backup not found: %s", backupName)
 	}
+	if protected, err := bm.protectedBackups(); err != nil {
+		return err
+	} else if slices.Contains(protected, backupName) {
+		return fmt.Errorf("backup %s is protected; unprotect it first", backupName)
+	}
+	if err := bm.removeBackup(backupPath); err != nil {
+		return err
+	}
+	return bm.forgetBackup(backupName)
+}
+
+// removeBackup deletes the snapshot, directory or archive at backupPath
+func (bm *BackupManager) removeBackup(backupPath string) error {
+	if snapshot, err := bm.deleteSnapshot(backupPath); snapshot {
+		return err
+	}
 
 	if filesystem.DirExists(backupPath) {
 		return filesystem.RemoveDir(backupPath)
@@ -395,26 +559,41 @@ func (bm *BackupManager) DeleteBackup(backupName string) error {
 
 // CleanupOldBackups removes old backups based on retention policy
 func (bm *BackupManager) CleanupOldBackups() error {
-	if bm.retention <= 0 {
-		return nil // No retention policy
+	_, err := bm.PruneBackups()
+	return err
+}
+
+// PruneBackups removes the backups the retention policy does not keep and returns them.
+// Protected backups are never removed.
+func (bm *BackupManager) PruneBackups() ([]BackupInfo, error) {
+	if bm.retention.IsZero() {
+		return nil, nil // No retention policy
 	}
 
 	backups, err := bm.ListBackups()
 	if err != nil {
-		return fmt.Errorf("failed to list backups: %w", err)
+		return nil, fmt.Errorf("failed to list backups: %w", err)
 	}
 
-	cutoffTime := time.Now().AddDate(0, 0, -bm.retention)
+	keep := bm.retention.Keep(backups, bm.clock.Now())
 
+	var pruned []BackupInfo
 	for _, backup := range backups {
-		if backup.Created.Before(cutoffTime) {
+		if keep[backup.Name] || backup.Protected {
+			continue
+		}
+		if bm.dryRun {
+			bm.logger.Info("dry run: would remove old backup", "backup", backup.Name, "created", backup.Created)
+		} else {
 			if err := bm.DeleteBackup(backup.Name); err != nil {
-				return fmt.Errorf("failed to delete old backup %s: %w", backup.Name, err)
+				return pruned, fmt.Errorf("failed to delete old backup %s: %w", backup.Name, err)
 			}
+			bm.logger.Info("old backup removed", "backup", backup.Name, "created", backup.Created)
 		}
+		pruned = append(pruned, backup)
 	}
 
-	return nil
+	return pruned, nil
 }
 
 // GetBackupInfo gets information about a specific backup
@@ -433,7 +612,8 @@ func (bm *BackupManager) GetBackupInfo(backupName string) (*BackupInfo, error) {
 	return nil, fmt.Errorf("backup not found: %s", backupName)
 }
 
-// ValidateBackup validates a backup file
+// ValidateBackup validates a backup file, and that it still matches the checksum
+// recorded in the index
 func (bm *BackupManager) ValidateBackup(backupName string) error {
 	backup, err := bm.GetBackupInfo(backupName)
 	if err != nil {
@@ -444,26 +624,40 @@ func (bm *BackupManager) ValidateBackup(backupName string) error {
 	if !filesystem.FileExists(backup.Path) && !filesystem.DirExists(backup.Path) {
 		return fmt.Errorf("backup file does not exist: %s", backup.Path)
 	}
+	if err := verifyChecksum(backup); err != nil {
+		return err
+	}
 
-	// If compressed, try to open the zip file
+	// If compressed, try to open the zip file; encrypted ones are decrypted first
 	if backup.IsCompressed {
-		reader, err := zip.OpenReader(backup.Path)
+		path, done, err := bm.openArchive(backup)
+		if err != nil {
+			return err
+		}
+		defer done()
+		reader, err := zip.OpenReader(path)
 		if err != nil {
 			return fmt.Errorf("failed to open backup zip file: %w", err)
 		}
 		defer reader.Close()
 
-		// Check if zip contains expected files
-		hasServerProperties := false
+		// Check if zip contains expected files; world backups have no server.properties
+		expected := "server.properties"
+		if backup.Type == BackupTypeWorld {
+			expected = "level.dat"
+		}
+		found := false
 		for _, file := range reader.File {
-			if filepath.Base(file.Name) == "server.properties" {
-				hasServerProperties = true
-				break
+			if err := archive.CheckEntry(file); err != nil {
+				return fmt.Errorf("backup appears to be invalid: %w", err)
+			}
+			if filepath.Base(file.Name) == expected {
+				found = true
 			}
 		}
 
-		if !hasServerProperties {
-			return fmt.Errorf("backup appears to be invalid: missing server.properties")
+		if !found {
+			return fmt.Errorf("backup appears to be invalid: missing %s", expected)
 		}
 	}
 
@@ -481,22 +675,22 @@ func (bm *BackupManager) GetBackupSpace() (int64, error) {
 
 // CreatePreUpdateBackup creates a backup before updating
 func (bm *BackupManager) CreatePreUpdateBackup(version string) (*BackupInfo, error) {
-	name := fmt.Sprintf("pre_update_%s_%s", version, time.Now().Format("20060102_150405"))
+	name := fmt.Sprintf("pre_update_%s_%s", version, bm.clock.Now().Format("20060102_150405"))
 	return bm.CreateBackup(name, "pre-update")
 }
 
 // CreatePostUpdateBackup creates a backup after updating
 func (bm *BackupManager) CreatePostUpdateBackup(version string) (*BackupInfo, error) {
-	name := fmt.Sprintf("post_update_%s_%s", version, time.Now().Format("20060102_150405"))
+	name := fmt.Sprintf("post_update_%s_%s", version, bm.clock.Now().Format("20060102_150405"))
 	return bm.CreateBackup(name, "post-update")
 }
 
 // CreateManualBackup creates a manual backup
 func (bm *BackupManager) CreateManualBackup(name string) (*BackupInfo, error) {
 	if name == "" {
-		name = fmt.Sprintf("manual_%s", time.Now().Format("20060102_150405"))
+		name = fmt.Sprintf("manual_%s", bm.clock.Now().Format("20060102_150405"))
 	} else {
-		name = fmt.Sprintf("manual_%s_%s", name, time.Now().Format("20060102_150405"))
+		name = fmt.Sprintf("manual_%s_%s", name, bm.clock.Now().Format("20060102_150405"))
 	}
 	return bm.CreateBackup(name, "manual")
 }
@@ -515,9 +709,32 @@ func (bm *BackupManager) GetLatestBackup() (*BackupInfo, error) {
 	return &backups[0], nil
 }
 
-// UpdateRetentionPolicy updates the retention policy
+// UpdateRetentionPolicy changes how many days of backups the retention policy keeps
 func (bm *BackupManager) UpdateRetentionPolicy(days int) {
-	bm.retention = days
+	bm.retention.Days = days
+}
+
+// SetClock replaces the clock used for backup names and retention decisions
+func (bm *BackupManager) SetClock(c clock.Clock) {
+	bm.clock = c
+}
+
+// SetLogger replaces the logger used for backup events
+func (bm *BackupManager) SetLogger(logger *slog.Logger) {
+	bm.logger = logger
+}
+
+// SetContext makes backups and restores stop with the error of ctx once it is done. A
+// backup stopped this way is removed, and a restore is no longer stopped once it starts
+// replacing the server files.
+func (bm *BackupManager) SetContext(ctx context.Context) {
+	bm.ctx = ctx
+}
+
+// SetDryRun makes the manager log the backups it would create and remove instead of
+// writing to or deleting from the backup directory
+func (bm *BackupManager) SetDryRun(enabled bool) {
+	bm.dryRun = enabled
 }
 
 // EnableCompression enables or disables compression
@@ -0,0 +1,408 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/archive"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/klauspost/compress/zip"
+)
+
+func TestCleanupOldBackupsUsesClock(t *testing.T) {
+	serverDir := t.TempDir()
+	backupDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(serverDir, "server.properties"), []byte("motd=test\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := clock.NewFake(time.Now())
+	bm := NewBackupManager(serverDir, backupDir, true, 7)
+	bm.SetClock(fake)
+
+	if _, err := bm.CreateManualBackup("retention"); err != nil {
+		t.Fatalf("CreateManualBackup: %v", err)
+	}
+
+	// Six days later the backup is still within the retention period
+	fake.Advance(6 * 24 * time.Hour)
+	if err := bm.CleanupOldBackups(); err != nil {
+		t.Fatalf("CleanupOldBackups: %v", err)
+	}
+	backups, err := bm.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("expected backup to be retained, got %d backups", len(backups))
+	}
+
+	// Eight days later it has expired
+	fake.Advance(2 * 24 * time.Hour)
+	if err := bm.CleanupOldBackups(); err != nil {
+		t.Fatalf("CleanupOldBackups: %v", err)
+	}
+	backups, err = bm.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 0 {
+		t.Fatalf("expected backup to be removed, got %d backups", len(backups))
+	}
+}
+
+func TestBackupDryRun(t *testing.T) {
+	serverDir := t.TempDir()
+	backupDir := t.TempDir()
+	writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=test\n")
+
+	fake := clock.NewFake(time.Now())
+	bm := NewBackupManager(serverDir, backupDir, true, 7)
+	bm.SetClock(fake)
+	if _, err := bm.CreateManualBackup("old"); err != nil {
+		t.Fatal(err)
+	}
+	fake.Advance(8 * 24 * time.Hour)
+
+	bm.SetDryRun(true)
+	planned, err := bm.CreateManualBackup("new")
+	if err != nil {
+		t.Fatalf("CreateManualBackup: %v", err)
+	}
+	if planned.Size != int64(len("motd=test\n")) || filepath.Dir(planned.Path) != backupDir {
+		t.Errorf("planned backup = %+v", planned)
+	}
+	if _, err := os.Stat(planned.Path); !os.IsNotExist(err) {
+		t.Error("dry run should not write the backup")
+	}
+
+	pruned, err := bm.PruneBackups()
+	if err != nil {
+		t.Fatalf("PruneBackups: %v", err)
+	}
+	if len(pruned) != 1 {
+		t.Fatalf("pruned %d backups, want 1", len(pruned))
+	}
+	if backups, _ := bm.ListBackups(); len(backups) != 1 {
+		t.Errorf("dry run should keep the expired backup, have %d", len(backups))
+	}
+
+	bm.SetDryRun(false)
+	if pruned, err := bm.PruneBackups(); err != nil || len(pruned) != 1 {
+		t.Fatalf("PruneBackups() = %d, %v", len(pruned), err)
+	}
+	if backups, _ := bm.ListBackups(); len(backups) != 0 {
+		t.Errorf("expired backup should be removed, have %d", len(backups))
+	}
+}
+
+func TestWorldBackup(t *testing.T) {
+	for _, compression := range []bool{true, false} {
+		t.Run(fmt.Sprintf("compression=%t", compression), func(t *testing.T) {
+			serverDir := t.TempDir()
+			for name, content := range map[string]string{
+				"server.properties":            "level-name=survival\n",
+				"survival/level.dat":           "v1",
+				"survival_nether/DIM-1/region": "nether",
+				"mods/a.jar":                   "mod",
+			} {
+				writeTestFile(t, filepath.Join(serverDir, name), content)
+			}
+
+			bm := NewBackupManager(serverDir, t.TempDir(), compression, 0)
+			backup, err := bm.CreateWorldBackup("")
+			if err != nil {
+				t.Fatalf("CreateWorldBackup: %v", err)
+			}
+			name := filepath.Base(backup.Path)
+			info, err := bm.GetBackupInfo(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Type != BackupTypeWorld {
+				t.Fatalf("Type = %q, want %q", info.Type, BackupTypeWorld)
+			}
+			if err := bm.ValidateBackup(name); err != nil {
+				t.Fatalf("ValidateBackup: %v", err)
+			}
+
+			// Restoring brings the world back without touching the rest of the server
+			writeTestFile(t, filepath.Join(serverDir, "survival/level.dat"), "v2")
+			writeTestFile(t, filepath.Join(serverDir, "mods/a.jar"), "newer mod")
+			if err := bm.RestoreBackup(name); err != nil {
+				t.Fatalf("RestoreBackup: %v", err)
+			}
+			for path, want := range map[string]string{
+				"survival/level.dat":           "v1",
+				"survival_nether/DIM-1/region": "nether",
+				"mods/a.jar":                   "newer mod",
+			} {
+				got, err := os.ReadFile(filepath.Join(serverDir, path))
+				if err != nil || string(got) != want {
+					t.Errorf("%s = %q, %v; want %q", path, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	for _, compression := range []bool{true, false} {
+		t.Run(fmt.Sprintf("compression=%v", compression), func(t *testing.T) {
+			serverDir := filepath.Join(t.TempDir(), "server")
+			writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=old\n")
+			writeTestFile(t, filepath.Join(serverDir, "mods/a.jar"), "a")
+
+			bm := NewBackupManager(serverDir, t.TempDir(), compression, 0)
+			backup, err := bm.CreateManualBackup("before")
+			if err != nil {
+				t.Fatalf("CreateManualBackup: %v", err)
+			}
+			name := filepath.Base(backup.Path)
+
+			writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=new\n")
+			writeTestFile(t, filepath.Join(serverDir, "mods/b.jar"), "b")
+
+			plan, err := bm.PlanRestore(name)
+			if err != nil {
+				t.Fatalf("PlanRestore: %v", err)
+			}
+			if fmt.Sprint(plan.Added, plan.Changed, plan.Removed) != fmt.Sprint([]string(nil), []string{"server.properties"}, []string{filepath.Join("mods", "b.jar")}) {
+				t.Errorf("plan = added %v, changed %v, removed %v", plan.Added, plan.Changed, plan.Removed)
+			}
+
+			result, err := bm.Restore(name, RestoreOptions{Snapshot: true})
+			if err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if got, _ := os.ReadFile(filepath.Join(serverDir, "server.properties")); string(got) != "motd=old\n" {
+				t.Errorf("server.properties = %q after restore", got)
+			}
+			if _, err := os.Stat(filepath.Join(serverDir, "mods/b.jar")); !os.IsNotExist(err) {
+				t.Errorf("mods/b.jar still exists after restore: %v", err)
+			}
+
+			// The snapshot holds the files the restore replaced
+			snapshot, err := bm.GetBackupInfo(result.Snapshot)
+			if err != nil {
+				t.Fatalf("snapshot %q: %v", result.Snapshot, err)
+			}
+			if snapshot.Type != BackupTypePreRestore {
+				t.Errorf("snapshot type = %q, want %q", snapshot.Type, BackupTypePreRestore)
+			}
+			if plan, err := bm.PlanRestore(result.Snapshot); err != nil || len(plan.Added) != 1 || len(plan.Changed) != 1 {
+				t.Errorf("snapshot plan = %+v, %v", plan, err)
+			}
+		})
+	}
+}
+
+func TestCanceledBackupAndRestore(t *testing.T) {
+	for _, compression := range []bool{true, false} {
+		t.Run(fmt.Sprintf("compression=%v", compression), func(t *testing.T) {
+			serverDir := filepath.Join(t.TempDir(), "server")
+			writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=old\n")
+			bm := NewBackupManager(serverDir, t.TempDir(), compression, 0)
+			backup, err := bm.CreateManualBackup("before")
+			if err != nil {
+				t.Fatalf("CreateManualBackup: %v", err)
+			}
+			writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=new\n")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			bm.SetContext(ctx)
+			if _, err := bm.CreateManualBackup("canceled"); !errors.Is(err, context.Canceled) {
+				t.Errorf("CreateManualBackup = %v, want context.Canceled", err)
+			}
+			if _, err := bm.Restore(filepath.Base(backup.Path), RestoreOptions{Snapshot: true}); !errors.Is(err, context.Canceled) {
+				t.Errorf("Restore = %v, want context.Canceled", err)
+			}
+			if got, _ := os.ReadFile(filepath.Join(serverDir, "server.properties")); string(got) != "motd=new\n" {
+				t.Errorf("server.properties = %q after a canceled restore", got)
+			}
+			entries, err := os.ReadDir(bm.backupPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if strings.Contains(e.Name(), "canceled") || strings.Contains(e.Name(), "pre_restore") {
+					t.Errorf("%s left in the backup directory", e.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreKeepsPermissionsAndOwner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no Unix permissions")
+	}
+	for _, compression := range []bool{true, false} {
+		t.Run(fmt.Sprintf("compression=%v", compression), func(t *testing.T) {
+			serverDir := filepath.Join(t.TempDir(), "server")
+			writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=old\n")
+			writeTestFile(t, filepath.Join(serverDir, "run.sh"), "#!/bin/sh\n")
+			if err := os.Chmod(filepath.Join(serverDir, "run.sh"), 0o750); err != nil {
+				t.Fatal(err)
+			}
+			root := os.Geteuid() == 0
+			if root {
+				if err := os.Chown(filepath.Join(serverDir, "run.sh"), 1234, 1234); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			bm := NewBackupManager(serverDir, t.TempDir(), compression, 0)
+			backup, err := bm.CreateManualBackup("perms")
+			if err != nil {
+				t.Fatalf("CreateManualBackup: %v", err)
+			}
+			name := filepath.Base(backup.Path)
+			if _, err := bm.Restore(name, RestoreOptions{}); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			assertMode(t, filepath.Join(serverDir, "run.sh"), 0o750)
+			assertMode(t, filepath.Join(serverDir, "server.properties"), 0o600)
+			if !root {
+				return
+			}
+			assertOwner(t, filepath.Join(serverDir, "run.sh"), 1234, 1234)
+
+			// restore_owner gives every restored file to one user
+			bm.SetRestoreOwner("2000:3000")
+			if _, err := bm.Restore(name, RestoreOptions{}); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			for _, path := range []string{serverDir, filepath.Join(serverDir, "run.sh"), filepath.Join(serverDir, "server.properties")} {
+				assertOwner(t, path, 2000, 3000)
+			}
+		})
+	}
+}
+
+func TestLookupOwner(t *testing.T) {
+	for owner, want := range map[string]string{
+		"1000":      "1000:1000",
+		"1000:50":   "1000:50",
+		"root":      "0:0",
+		"0:root":    "0:0",
+		":1000":     "error",
+		"1000:":     "error",
+		"no-such-u": "error",
+	} {
+		uid, gid, err := LookupOwner(owner)
+		got := fmt.Sprintf("%d:%d", uid, gid)
+		if err != nil {
+			got = "error"
+		}
+		if got != want {
+			t.Errorf("LookupOwner(%q) = %s, want %s", owner, got, want)
+		}
+	}
+}
+
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != want {
+		t.Errorf("%s mode = %v, want %v", filepath.Base(path), got, want)
+	}
+}
+
+func assertOwner(t *testing.T, path string, uid, gid int) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotUID, gotGID, _ := filesystem.Owner(info); gotUID != uid || gotGID != gid {
+		t.Errorf("%s owner = %d:%d, want %d:%d", filepath.Base(path), gotUID, gotGID, uid, gid)
+	}
+}
+
+func TestRestoreFailedExtractionKeepsServer(t *testing.T) {
+	serverDir := t.TempDir()
+	backupDir := t.TempDir()
+	writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=live\n")
+	writeTestFile(t, filepath.Join(backupDir, "manual_broken.zip"), "not a zip")
+
+	bm := NewBackupManager(serverDir, backupDir, true, 0)
+	if _, err := bm.Restore("manual_broken.zip", RestoreOptions{Snapshot: true}); err == nil {
+		t.Fatal("Restore of a corrupt backup succeeded")
+	}
+	if got, err := os.ReadFile(filepath.Join(serverDir, "server.properties")); err != nil || string(got) != "motd=live\n" {
+		t.Errorf("server.properties = %q, %v after failed restore", got, err)
+	}
+	backups, err := bm.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Errorf("got %d backups, want no snapshot for a failed restore", len(backups))
+	}
+}
+
+func TestRestoreRejectsUnsafeBackup(t *testing.T) {
+	root := t.TempDir()
+	serverDir := filepath.Join(root, "server")
+	backupDir := filepath.Join(root, "backups")
+	writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=live\n")
+
+	// A crafted backup whose entry climbs out of the restore staging directory
+	if err := os.MkdirAll(backupDir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(backupDir, "manual_evil.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"server.properties", "../evil.txt"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte("evil"))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	bm := NewBackupManager(serverDir, backupDir, true, 0)
+	_, err = bm.Restore("manual_evil.zip", RestoreOptions{Snapshot: true})
+	if !errors.Is(err, archive.ErrUnsafePath) {
+		t.Fatalf("expected ErrUnsafePath, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("evil.txt was written next to the server: %v", err)
+	}
+	if got, err := os.ReadFile(filepath.Join(serverDir, "server.properties")); err != nil || string(got) != "motd=live\n" {
+		t.Errorf("server.properties = %q, %v after a rejected restore", got, err)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// EULAFile is where the server records that the Minecraft EULA was accepted
+const EULAFile = "eula.txt"
+
+// EULAURL is the Minecraft End User License Agreement
+const EULAURL = "https://aka.ms/MinecraftEULA"
+
+// EULAAccepted reports whether eula.txt in serverPath accepts the EULA
+func EULAAccepted(serverPath string) bool {
+	data, err := os.ReadFile(filepath.Join(serverPath, EULAFile)) // #nosec G304 -- fixed name in the server directory
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok && strings.TrimSpace(key) == "eula" {
+			return strings.EqualFold(strings.TrimSpace(value), "true")
+		}
+	}
+	return false
+}
+
+// AcceptEULA writes an eula.txt to serverPath that accepts the EULA. Only call it when the
+// operator explicitly agreed to it.
+func AcceptEULA(serverPath string, now time.Time) error {
+	data := fmt.Sprintf("#By changing the setting below to TRUE you are indicating your agreement to our EULA (%s).\n#%s\neula=true\n",
+		EULAURL, now.UTC().Format(time.RFC1123))
+	if err := os.WriteFile(filepath.Join(serverPath, EULAFile), []byte(data), 0o644); err != nil { // #nosec G306 -- read by the server
+		return fmt.Errorf("failed to write %s: %w", EULAFile, err)
+	}
+	return nil
+}
+
+// BootstrapProperties returns the server.properties values that follow from cfg: the
+// port, the player limit, server.name as the motd, and RCON when server.rcon is set.
+// Values that are not configured are left to the server's defaults.
+func BootstrapProperties(cfg *config.Config) map[string]string {
+	values := map[string]string{}
+	if cfg.Server.Port > 0 {
+		values["server-port"] = strconv.Itoa(cfg.Server.Port)
+	}
+	if cfg.Server.MaxPlayers > 0 {
+		values["max-players"] = strconv.Itoa(cfg.Server.MaxPlayers)
+	}
+	if cfg.Server.Name != "" {
+		values["motd"] = cfg.Server.Name
+	}
+	if rcon := cfg.Server.RCON; rcon.Address != "" {
+		if _, port, err := net.SplitHostPort(rcon.Address); err == nil {
+			values["enable-rcon"] = "true"
+			values["rcon.port"] = port
+			values["rcon.password"] = rcon.Password
+		}
+	}
+	return values
+}
+
+// SetProperties sets values in server.properties in serverPath, creating it when missing.
+// The order, comments and other values of an existing file are kept; keys it does not
+// have yet are appended in sorted order. Nothing is written when a value is invalid.
+func SetProperties(serverPath string, values map[string]string) error {
+	props, err := LoadProperties(serverPath)
+	if errors.Is(err, os.ErrNotExist) {
+		props, err = &Properties{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		props.Set(key, values[key])
+	}
+	if err := props.Validate(); err != nil {
+		return fmt.Errorf("invalid %s: %w", PropertiesFile, err)
+	}
+	return props.Save(serverPath)
+}
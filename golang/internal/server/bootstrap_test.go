@@ -0,0 +1,70 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func TestAcceptEULA(t *testing.T) {
+	dir := t.TempDir()
+	if EULAAccepted(dir) {
+		t.Fatal("EULA accepted without eula.txt")
+	}
+	_ = os.WriteFile(filepath.Join(dir, EULAFile), []byte("#comment\neula=false\n"), 0o644)
+	if EULAAccepted(dir) {
+		t.Fatal("EULA accepted with eula=false")
+	}
+	if err := AcceptEULA(dir, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !EULAAccepted(dir) {
+		t.Fatal("EULA not accepted after AcceptEULA")
+	}
+}
+
+func TestSetProperties(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.properties")
+	_ = os.WriteFile(path, []byte("#Minecraft server properties\nmotd=A Minecraft Server\nserver-port=25565\nlevel-name=world\n"), 0o644)
+
+	cfg := &config.Config{}
+	cfg.Server.Name = "§6ATM9 Survival"
+	cfg.Server.Port = 25570
+	cfg.Server.MaxPlayers = 40
+	cfg.Server.RCON.Address = "127.0.0.1:25575"
+	cfg.Server.RCON.Password = "secret"
+	if err := SetProperties(dir, BootstrapProperties(cfg)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#Minecraft server properties\n" +
+		"motd=\\u00A76ATM9 Survival\n" +
+		"server-port=25570\n" +
+		"level-name=world\n" +
+		"enable-rcon=true\n" +
+		"max-players=40\n" +
+		"rcon.password=secret\n" +
+		"rcon.port=25575\n"
+	if string(data) != want {
+		t.Errorf("server.properties =\n%s\nwant\n%s", data, want)
+	}
+	if ServerPort(dir) != 25570 {
+		t.Errorf("ServerPort = %d, want 25570", ServerPort(dir))
+	}
+
+	// Without server.properties one is created with only the configured values
+	fresh := t.TempDir()
+	if err := SetProperties(fresh, BootstrapProperties(&config.Config{Server: config.ServerConfig{MaxPlayers: 10}})); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(fresh, "server.properties")); string(data) != "max-players=10\n" {
+		t.Errorf("new server.properties = %q", data)
+	}
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// commandSettle is how long the console must stay quiet after a line of output for the
+	// output of a command to be complete
+	commandSettle = 250 * time.Millisecond
+	// commandQueueSize is how many commands may wait for their turn
+	commandQueueSize = 64
+	// commandOutputBuffer is how many lines of output a command may fall behind on
+	commandOutputBuffer = 256
+	// commandWriteTimeout is how long a command without a timeout of its own may wait for
+	// a server that does not read its console
+	commandWriteTimeout = 10 * time.Second
+)
+
+// CommandResult is the outcome of a console command run with Command
+type CommandResult struct {
+	Command string
+	// Output are the lines the server logged after the command, without their [stdout] or
+	// [stderr] prefix. The console has no replies of its own, so lines logged at the same
+	// time for other reasons, such as chat, are included.
+	Output []string
+	Err    error
+}
+
+// consoleCommand is a command waiting in the queue of runCommands
+type consoleCommand struct {
+	command string
+	timeout time.Duration
+	result  chan CommandResult
+}
+
+// Command queues command for the console of the server and returns the channel its result
+// is sent on. Commands are written one at a time in the order they were queued. With a
+// timeout, the lines the server logs until it has been quiet for a moment, or until the
+// timeout, are collected as the output; with 0 the result is sent once the command is
+// written. A server that does not take a command in time has its console closed, and
+// later commands fail at once.
+func (s *MinecraftServer) Command(command string, timeout time.Duration) <-chan CommandResult {
+	c := consoleCommand{command: command, timeout: timeout, result: make(chan CommandResult, 1)}
+
+	// Commands are queued under the read lock, and monitorProcess marks the server stopped
+	// under the write lock, so nothing is queued once runCommands drains the queue
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.isRunning {
+		c.result <- CommandResult{Command: command, Err: ErrNotRunning}
+		return c.result
+	}
+	select {
+	case s.commands <- c:
+	default:
+		c.result <- CommandResult{Command: command, Err: fmt.Errorf("%d console commands are already waiting", commandQueueSize)}
+	}
+	return c.result
+}
+
+// runCommands writes the queued commands to stdin one at a time until stopped is closed,
+// then answers the commands left in the queue
+func (s *MinecraftServer) runCommands(stdin io.Writer, queue chan consoleCommand, stopped <-chan struct{}) {
+	console := newConsoleWriter(stdin)
+	defer console.close()
+	for {
+		select {
+		case c := <-queue:
+			c.result <- s.runCommand(console, c, stopped)
+		case <-stopped:
+			for {
+				select {
+				case c := <-queue:
+					c.result <- CommandResult{Command: c.command, Err: ErrNotRunning}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// consoleWriter owns the stdin of the server, so that a write that never finishes cannot
+// be overtaken by the next command
+type consoleWriter struct {
+	lines   chan string
+	written chan error
+	stdin   io.Writer
+	// wedged is the error of every command after a write did not finish
+	wedged error
+}
+
+// newConsoleWriter starts the goroutine that writes the lines of w to stdin
+func newConsoleWriter(stdin io.Writer) *consoleWriter {
+	w := &consoleWriter{lines: make(chan string), written: make(chan error, 1), stdin: stdin}
+	go func() {
+		for line := range w.lines {
+			_, err := io.WriteString(stdin, line)
+			w.written <- err
+		}
+	}()
+	return w
+}
+
+// write writes command to the console, giving up after timeout or once stopped is closed.
+// A server that stops reading its console fills the pipe; stdin is then closed, so the
+// command is not run later on, and every later command fails at once.
+func (w *consoleWriter) write(command string, timeout time.Duration, stopped <-chan struct{}) error {
+	if w.wedged != nil {
+		return w.wedged
+	}
+	w.lines <- command + "\n"
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	select {
+	case err := <-w.written:
+		if err != nil {
+			return fmt.Errorf("failed to write command to stdin: %w", err)
+		}
+		return nil
+	case <-deadline.C:
+		w.wedged = fmt.Errorf("console stopped taking commands")
+		if closer, ok := w.stdin.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		return fmt.Errorf("console did not take the command within %s", timeout)
+	case <-stopped:
+		w.wedged = ErrNotRunning
+		return ErrNotRunning
+	}
+}
+
+// close ends the goroutine of w once its last write is done
+func (w *consoleWriter) close() {
+	close(w.lines)
+}
+
+// runCommand writes c to the console and collects its output. The write gives up with
+// the timeout of c, or commandWriteTimeout without one.
+func (s *MinecraftServer) runCommand(console *consoleWriter, c consoleCommand, stopped <-chan struct{}) CommandResult {
+	result := CommandResult{Command: c.command}
+	select {
+	case <-stopped:
+		result.Err = ErrNotRunning
+		return result
+	default:
+	}
+
+	var lines <-chan string
+	if c.timeout > 0 {
+		var cancel func()
+		_, lines, cancel = s.SubscribeLogs(commandOutputBuffer)
+		defer cancel()
+	}
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = commandWriteTimeout
+	}
+	start := time.Now()
+	if err := console.write(c.command, timeout, stopped); err != nil {
+		result.Err = err
+		return result
+	}
+	if c.timeout <= 0 {
+		return result
+	}
+
+	deadline := time.NewTimer(c.timeout - time.Since(start))
+	defer deadline.Stop()
+	var settled <-chan time.Time
+	for {
+		select {
+		case line := <-lines:
+			if _, rest, ok := strings.Cut(line, "] "); ok && strings.HasPrefix(line, "[std") {
+				line = rest
+			}
+			result.Output = append(result.Output, line)
+			settled = time.After(commandSettle)
+		case <-settled:
+			return result
+		case <-deadline.C:
+			return result
+		case <-stopped:
+			return result
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// Server control modes, selected with server.mode
+const (
+	ModeProcess     = "process"
+	ModeDocker      = "docker"
+	ModeSystemd     = "systemd"
+	ModePterodactyl = "pterodactyl"
+	ModeKubernetes  = "kubernetes"
+)
+
+// Controller starts and stops the Minecraft server, whether it is a child process, a
+// Docker container, a systemd unit, a server on a Pterodactyl panel or a Kubernetes Deployment
+type Controller interface {
+	Start() error
+	Stop(timeout time.Duration) error
+	Restart(timeout time.Duration) error
+	IsRunning() bool
+	GetUptime() time.Duration
+}
+
+// LogStreamer is implemented by controllers whose server log can be read outside of the
+// process that started it
+type LogStreamer interface {
+	Logs(ctx context.Context, w io.Writer, tail int, follow bool) error
+}
+
+// NewController returns the controller for server.mode in cfg
+func NewController(cfg *config.Config) (Controller, error) {
+	switch cfg.Server.Mode {
+	case "", ModeProcess:
+		s := NewMinecraftServer(cfg.ServerPath, cfg.ServerJarName)
+		s.SetJava(cfg.ServerJar.Java)
+		s.SetSandbox(cfg.Server.Process)
+		return s, nil
+	case ModeDocker:
+		return NewDockerServer(cfg.Server.Docker.Host, cfg.Server.Docker.Container, cfg.Server.Docker.DataDir)
+	case ModeSystemd:
+		return NewSystemdServer(cfg.Server.Systemd.Unit, cfg.Server.Systemd.User), nil
+	case ModePterodactyl:
+		return NewPterodactylServer(cfg.Server.Pterodactyl, cfg.HTTP), nil
+	case ModeKubernetes:
+		if cfg.Server.Kubernetes.Deployment == "" {
+			return nil, fmt.Errorf("server.kubernetes.deployment is not set")
+		}
+		return NewKubernetesServer(cfg.Server.Kubernetes.Namespace, cfg.Server.Kubernetes.Deployment)
+	default:
+		return nil, fmt.Errorf("unknown server.mode %q", cfg.Server.Mode)
+	}
+}
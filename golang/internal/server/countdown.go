@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/rcon"
+)
+
+// countdownBossbar is the ID of the boss bar shown by the bossbar channel
+const countdownBossbar = "curseforge_autoupdater:countdown"
+
+// rconTimeout limits connecting to RCON and each command
+const rconTimeout = 10 * time.Second
+
+// Commander runs console commands on the server
+type Commander interface {
+	SendCommand(command string) error
+}
+
+// RCONCommander sends console commands over RCON, connecting for every command so that
+// server restarts in between do not matter
+type RCONCommander struct {
+	cfg config.RCONConfig
+}
+
+// NewRCONCommander sends commands to the server's remote console
+func NewRCONCommander(cfg config.RCONConfig) *RCONCommander {
+	return &RCONCommander{cfg: cfg}
+}
+
+// SendCommand runs command on the server
+func (r *RCONCommander) SendCommand(command string) error {
+	c, err := rcon.Dial(r.cfg.Address, r.cfg.Password, rconTimeout)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	_, err = c.Command(command)
+	return err
+}
+
+// Ping connects and logs in without running a command, to find out whether the server
+// is up and accepts the password
+func (r *RCONCommander) Ping() error {
+	c, err := rcon.Dial(r.cfg.Address, r.cfg.Password, rconTimeout)
+	if err != nil {
+		return err
+	}
+	return c.Close()
+}
+
+// listPlayers matches the player count in the output of list: "There are 2 of a max of 20
+// players online: ..." and, on older servers and Bukkit, "There are 2/20 players online:"
+var listPlayers = regexp.MustCompile(`There are (\d+)(?: of a max| ?/)`)
+
+// OnlinePlayers asks the server how many players are online
+func (r *RCONCommander) OnlinePlayers() (int, error) {
+	c, err := rcon.Dial(r.cfg.Address, r.cfg.Password, rconTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	out, err := c.Command("list")
+	if err != nil {
+		return 0, err
+	}
+	return parsePlayers(out)
+}
+
+// parsePlayers reads the player count from the output of list
+func parsePlayers(out string) (int, error) {
+	m := listPlayers.FindStringSubmatch(formatCodes.ReplaceAllString(out, ""))
+	if m == nil {
+		return 0, fmt.Errorf("unexpected output of list: %q", out)
+	}
+	return strconv.Atoi(m[1])
+}
+
+// Countdown warns players on a schedule before the server goes down
+type Countdown struct {
+	cfg    config.CountdownConfig
+	target Commander
+	clock  clock.Clock
+}
+
+// NewCountdown broadcasts the warnings in cfg through target
+func NewCountdown(cfg config.CountdownConfig, target Commander) *Countdown {
+	return &Countdown{cfg: cfg, target: target, clock: clock.Real()}
+}
+
+// SetClock replaces the clock used for waiting
+func (c *Countdown) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+// Run broadcasts a warning at every point of the schedule, waits until the time is up and
+// sends the final message. It returns ctx.Err() when ctx is cancelled while waiting, after
+// telling players that the shutdown was called off.
+func (c *Countdown) Run(ctx context.Context) error {
+	schedule := append([]time.Duration(nil), c.cfg.Schedule...)
+	sort.Slice(schedule, func(i, j int) bool { return schedule[i] > schedule[j] })
+	if len(schedule) == 0 {
+		return c.broadcast(c.cfg.FinalMessage, 0)
+	}
+
+	total := schedule[0]
+	if c.cfg.Channel == "bossbar" {
+		if err := c.startBossbar(total); err != nil {
+			return err
+		}
+		defer func() { _ = c.target.SendCommand("bossbar remove " + countdownBossbar) }()
+	}
+
+	left := total
+	for i, at := range schedule {
+		if err := c.wait(ctx, left-at); err != nil {
+			return err
+		}
+		left = at
+		if err := c.broadcast(c.cfg.Message, left); err != nil {
+			return fmt.Errorf("failed to broadcast countdown message: %w", err)
+		}
+		if i == len(schedule)-1 {
+			if err := c.wait(ctx, left); err != nil {
+				return err
+			}
+		}
+	}
+	return c.broadcast(c.cfg.FinalMessage, 0)
+}
+
+// wait sleeps for d unless ctx is cancelled first
+func (c *Countdown) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		_ = c.broadcast(c.cfg.CancelMessage, 0)
+		return ctx.Err()
+	case <-c.clock.After(d):
+		return nil
+	}
+}
+
+// broadcast sends template, with its placeholders filled in for the time left, on the
+// configured channel
+func (c *Countdown) broadcast(template string, left time.Duration) error {
+	if template == "" {
+		return nil
+	}
+	msg := strings.NewReplacer(
+		"{time}", formatLeft(left),
+		"{minutes}", strconv.Itoa(int(left.Minutes())),
+		"{seconds}", strconv.Itoa(int(left.Seconds())),
+	).Replace(template)
+	text, _ := json.Marshal(map[string]string{"text": msg, "color": "yellow"})
+
+	switch c.cfg.Channel {
+	case "title":
+		return c.target.SendCommand("title @a title " + string(text))
+	case "actionbar":
+		return c.target.SendCommand("title @a actionbar " + string(text))
+	case "bossbar":
+		if err := c.target.SendCommand("bossbar set " + countdownBossbar + " name " + string(text)); err != nil {
+			return err
+		}
+		return c.target.SendCommand(fmt.Sprintf("bossbar set %s value %d", countdownBossbar, int(left.Seconds())))
+	default:
+		return c.target.SendCommand("say " + msg)
+	}
+}
+
+// startBossbar creates the boss bar and shows it to every player
+func (c *Countdown) startBossbar(total time.Duration) error {
+	for _, cmd := range []string{
+		`bossbar add ` + countdownBossbar + ` {"text":""}`,
+		"bossbar set " + countdownBossbar + " color yellow",
+		fmt.Sprintf("bossbar set %s max %d", countdownBossbar, int(total.Seconds())),
+		"bossbar set " + countdownBossbar + " players @a",
+	} {
+		if err := c.target.SendCommand(cmd); err != nil {
+			return fmt.Errorf("failed to set up countdown boss bar: %w", err)
+		}
+	}
+	return nil
+}
+
+// formatLeft renders a countdown step as "5 minutes", "1 minute" or "30 seconds"
+func formatLeft(d time.Duration) string {
+	unit, n := "second", int(d.Round(time.Second).Seconds())
+	if d >= time.Minute && d%time.Minute == 0 {
+		unit, n = "minute", int(d.Minutes())
+	}
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
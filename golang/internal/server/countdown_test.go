@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// recordingCommander collects the console commands it is sent
+type recordingCommander struct {
+	mu       sync.Mutex
+	commands []string
+}
+
+func (r *recordingCommander) SendCommand(command string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, command)
+	return nil
+}
+
+func (r *recordingCommander) sent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.commands...)
+}
+
+func TestCountdown(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	target := &recordingCommander{}
+	c := NewCountdown(config.CountdownConfig{
+		Schedule:     []time.Duration{30 * time.Second, 2 * time.Minute, time.Minute},
+		Message:      "Restarting in {time} ({seconds}s)",
+		FinalMessage: "Restarting now",
+	}, target)
+	c.SetClock(fake)
+
+	done := make(chan error, 1)
+	go func() { done <- c.Run(context.Background()) }()
+	for _, step := range []time.Duration{time.Minute, 30 * time.Second, 30 * time.Second} {
+		fake.BlockUntil(1)
+		fake.Advance(step)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"say Restarting in 2 minutes (120s)",
+		"say Restarting in 1 minute (60s)",
+		"say Restarting in 30 seconds (30s)",
+		"say Restarting now",
+	}
+	if got := target.sent(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+}
+
+func TestCountdownBossbarCancel(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	target := &recordingCommander{}
+	c := NewCountdown(config.CountdownConfig{
+		Schedule:      []time.Duration{time.Minute, 10 * time.Second},
+		Message:       "{time}",
+		CancelMessage: "Restart cancelled",
+		Channel:       "bossbar",
+	}, target)
+	c.SetClock(fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+	fake.BlockUntil(1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run err = %v, want context.Canceled", err)
+	}
+
+	got := strings.Join(target.sent(), "\n")
+	for _, want := range []string{
+		"bossbar set curseforge_autoupdater:countdown max 60",
+		`bossbar set curseforge_autoupdater:countdown name {"color":"yellow","text":"1 minute"}`,
+		"bossbar set curseforge_autoupdater:countdown value 60",
+		`bossbar set curseforge_autoupdater:countdown name {"color":"yellow","text":"Restart cancelled"}`,
+		"bossbar remove curseforge_autoupdater:countdown",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("commands do not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestParsePlayers(t *testing.T) {
+	for _, tc := range []struct {
+		out  string
+		want int
+		ok   bool
+	}{
+		{"There are 2 of a max of 20 players online: Alex, Steve", 2, true},
+		{"There are 0 of a max 20 players online:", 0, true},
+		{"§6There are §c3§6/§c20§6 players online:", 3, true},
+		{"Unknown or incomplete command, see below for error", 0, false},
+	} {
+		got, err := parsePlayers(tc.out)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("parsePlayers(%q) = %d, %v; want %d, ok %v", tc.out, got, err, tc.want, tc.ok)
+		}
+	}
+}
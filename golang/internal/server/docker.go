@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDockerHost is the Docker socket used when no host is configured
+const DefaultDockerHost = "unix:///var/run/docker.sock"
+
+// DockerServer controls a Minecraft server running in a Docker container, e.g.
+// itzg/minecraft-server, through the Docker Engine API
+type DockerServer struct {
+	container string
+	dataDir   string
+	baseURL   string
+	client    *http.Client
+}
+
+// containerInfo is the part of GET /containers/{id}/json that is used
+type containerInfo struct {
+	State struct {
+		Running   bool      `json:"Running"`
+		StartedAt time.Time `json:"StartedAt"`
+	} `json:"State"`
+	Config struct {
+		Tty bool `json:"Tty"`
+	} `json:"Config"`
+	Mounts []struct {
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+	} `json:"Mounts"`
+}
+
+// NewDockerServer controls container through the Docker daemon at host, a unix:// socket
+// or tcp:// address. dataDir is the server directory inside the container.
+func NewDockerServer(host, container, dataDir string) (*DockerServer, error) {
+	if host == "" {
+		host = DefaultDockerHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	d := &DockerServer{container: container, dataDir: dataDir}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		d.baseURL = "http://docker"
+		d.client = &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}}
+	case "tcp", "http":
+		d.baseURL = "http://" + u.Host
+		d.client = &http.Client{}
+	default:
+		return nil, fmt.Errorf("unsupported docker host %q: use unix:// or tcp://", host)
+	}
+	return d, nil
+}
+
+// Start starts the container
+func (d *DockerServer) Start() error {
+	resp, err := d.do(context.Background(), http.MethodPost, "/start", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return fmt.Errorf("server is already running")
+	}
+	return nil
+}
+
+// Stop stops the container, giving the server timeout to shut down before it is killed
+func (d *DockerServer) Stop(timeout time.Duration) error {
+	resp, err := d.do(context.Background(), http.MethodPost, "/stop", timeoutQuery(timeout))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return fmt.Errorf("server is not running")
+	}
+	return nil
+}
+
+// Restart stops and starts the container
+func (d *DockerServer) Restart(timeout time.Duration) error {
+	resp, err := d.do(context.Background(), http.MethodPost, "/restart", timeoutQuery(timeout))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// IsRunning reports whether the container is running; errors count as not running
+func (d *DockerServer) IsRunning() bool {
+	info, err := d.inspect()
+	return err == nil && info.State.Running
+}
+
+// GetUptime returns how long the container has been running
+func (d *DockerServer) GetUptime() time.Duration {
+	info, err := d.inspect()
+	if err != nil || !info.State.Running {
+		return 0
+	}
+	return time.Since(info.State.StartedAt)
+}
+
+// HostPath returns the host directory mounted at the container's data directory, or an
+// empty string when it is not a bind mount or volume visible on the host
+func (d *DockerServer) HostPath() (string, error) {
+	info, err := d.inspect()
+	if err != nil {
+		return "", err
+	}
+	for _, m := range info.Mounts {
+		if filepath.Clean(m.Destination) == filepath.Clean(d.dataDir) {
+			return m.Source, nil
+		}
+	}
+	return "", nil
+}
+
+// Logs copies the last tail lines of the container log to w, and with follow keeps
+// streaming until ctx is cancelled
+func (d *DockerServer) Logs(ctx context.Context, w io.Writer, tail int, follow bool) error {
+	info, err := d.inspect()
+	if err != nil {
+		return err
+	}
+	query := url.Values{
+		"stdout": {"1"},
+		"stderr": {"1"},
+		"tail":   {strconv.Itoa(tail)},
+		"follow": {strconv.FormatBool(follow)},
+	}
+	resp, err := d.do(ctx, http.MethodGet, "/logs", query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if info.Config.Tty {
+		_, err = io.Copy(w, resp.Body)
+	} else {
+		err = demuxLogs(w, resp.Body)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// demuxLogs strips the 8-byte frame headers Docker puts in front of each chunk of output
+// of containers without a TTY
+func demuxLogs(w io.Writer, r io.Reader) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+	}
+}
+
+// inspect returns the current state of the container
+func (d *DockerServer) inspect() (*containerInfo, error) {
+	resp, err := d.do(context.Background(), http.MethodGet, "/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var info containerInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode container info: %w", err)
+	}
+	return &info, nil
+}
+
+// do sends a request for the container; 304 is returned to the caller, other non-2xx
+// statuses are turned into the error message from the Docker daemon
+func (d *DockerServer) do(ctx context.Context, method, path string, query url.Values) (*http.Response, error) {
+	u := d.baseURL + "/containers/" + url.PathEscape(d.container) + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker request failed: %w", err)
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotModified {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return nil, fmt.Errorf("docker: %s", strings.TrimSpace(apiErr.Message))
+	}
+	return resp, nil
+}
+
+// timeoutQuery converts a stop timeout into the t parameter in whole seconds
+func timeoutQuery(timeout time.Duration) url.Values {
+	if timeout <= 0 {
+		return nil
+	}
+	return url.Values{"t": {strconv.Itoa(int(timeout.Seconds()))}}
+}
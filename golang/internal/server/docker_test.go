@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logFrame encodes one chunk of container output the way Docker does without a TTY
+func logFrame(stream byte, data string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	return append(header, data...)
+}
+
+func TestDockerServer(t *testing.T) {
+	running := false
+	var stopQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /containers/mc/json":
+			info := map[string]interface{}{
+				"State":  map[string]interface{}{"Running": running, "StartedAt": time.Now().Add(-time.Minute)},
+				"Config": map[string]interface{}{"Tty": false},
+				"Mounts": []map[string]string{{"Source": "/srv/minecraft", "Destination": "/data"}},
+			}
+			_ = json.NewEncoder(w).Encode(info)
+		case "POST /containers/mc/start":
+			if running {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			running = true
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /containers/mc/stop":
+			stopQuery = r.URL.RawQuery
+			running = false
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /containers/mc/logs":
+			_, _ = w.Write(logFrame(1, "[Server thread/INFO]: Done (4.2s)!\n"))
+			_, _ = w.Write(logFrame(2, "warning\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such container: ` + strings.TrimPrefix(r.URL.Path, "/containers/") + `"}`))
+		}
+	}))
+	defer srv.Close()
+
+	d, err := NewDockerServer("tcp://"+strings.TrimPrefix(srv.URL, "http://"), "mc", "/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ Controller = d
+
+	if d.IsRunning() || d.GetUptime() != 0 {
+		t.Fatal("container should start out stopped")
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if !d.IsRunning() || d.GetUptime() < time.Minute {
+		t.Fatalf("container should be running for about a minute, got %s", d.GetUptime())
+	}
+	if err := d.Start(); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Fatalf("second Start err = %v", err)
+	}
+	if err := d.Stop(30 * time.Second); err != nil || stopQuery != "t=30" {
+		t.Fatalf("Stop = %v with query %q", err, stopQuery)
+	}
+
+	hostPath, err := d.HostPath()
+	if err != nil || hostPath != "/srv/minecraft" {
+		t.Fatalf("HostPath = %q, %v", hostPath, err)
+	}
+
+	var logs bytes.Buffer
+	if err := d.Logs(context.Background(), &logs, 10, false); err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	if logs.String() != "[Server thread/INFO]: Done (4.2s)!\nwarning\n" {
+		t.Fatalf("Logs = %q", logs.String())
+	}
+
+	missing, _ := NewDockerServer("tcp://"+strings.TrimPrefix(srv.URL, "http://"), "other", "/data")
+	if err := missing.Start(); err == nil || !strings.Contains(err.Error(), "No such container") {
+		t.Fatalf("Start of a missing container err = %v", err)
+	}
+}
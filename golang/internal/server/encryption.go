@@ -0,0 +1,130 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// EncryptedSuffix follows ".zip" in the names of encrypted backups
+const EncryptedSuffix = ".age"
+
+// SetEncryption encrypts new zip backups with the passphrase or to the recipients of cfg,
+// and decrypts encrypted backups with its passphrase or identity file when they are
+// restored or validated. Backups that are not archives are never encrypted.
+func (bm *BackupManager) SetEncryption(cfg config.BackupEncryptionConfig) {
+	bm.encryption = cfg
+}
+
+// isArchive reports whether a backup file name is that of a zip backup, encrypted or not
+func isArchive(name string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(name, EncryptedSuffix), ".zip")
+}
+
+// isEncrypted reports whether a backup file name is that of an encrypted zip backup
+func isEncrypted(name string) bool {
+	return strings.HasSuffix(name, ".zip"+EncryptedSuffix)
+}
+
+// encrypt returns a writer that encrypts what it is given into dst; it must be closed
+// before dst
+func (bm *BackupManager) encrypt(dst io.Writer) (io.WriteCloser, error) {
+	var recipients []age.Recipient
+	if bm.encryption.Passphrase != "" {
+		r, err := age.NewScryptRecipient(bm.encryption.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		if bm.scryptWorkFactor > 0 {
+			r.SetWorkFactor(bm.scryptWorkFactor)
+		}
+		recipients = append(recipients, r)
+	}
+	for _, key := range bm.encryption.Recipients {
+		r, err := age.ParseX25519Recipient(key)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+	w, err := age.Encrypt(dst, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt backup: %w", err)
+	}
+	return w, nil
+}
+
+// identities returns what decrypts the encrypted backup named name
+func (bm *BackupManager) identities(name string) ([]age.Identity, error) {
+	var ids []age.Identity
+	if bm.encryption.Passphrase != "" {
+		id, err := age.NewScryptIdentity(bm.encryption.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if path := bm.encryption.IdentityFile; path != "" {
+		// #nosec G304 -- path comes from configuration
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup identity_file: %w", err)
+		}
+		defer f.Close()
+		keys, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup identity_file %s: %w", path, err)
+		}
+		ids = append(ids, keys...)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("backup %s is encrypted; set backup.encryption passphrase or identity_file to decrypt it", name)
+	}
+	return ids, nil
+}
+
+// openArchive returns the path of the zip archive of a compressed backup. An encrypted
+// backup is decrypted to a temporary file first, which done removes.
+func (bm *BackupManager) openArchive(backup *BackupInfo) (path string, done func(), err error) {
+	if !backup.Encrypted {
+		return backup.Path, func() {}, nil
+	}
+	ids, err := bm.identities(backup.Name)
+	if err != nil {
+		return "", nil, err
+	}
+	// #nosec G304 -- the path is a backup in the backup directory
+	src, err := os.Open(backup.Path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer src.Close()
+	plain, err := age.Decrypt(src, ids...)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decrypt backup %s: %w", backup.Name, err)
+	}
+
+	tmp, err := os.CreateTemp("", "backup-*.zip")
+	if err != nil {
+		return "", nil, err
+	}
+	done = func() {
+		if err := os.Remove(tmp.Name()); err != nil && !os.IsNotExist(err) {
+			bm.logger.Warn("failed to remove decrypted backup", "path", tmp.Name(), "error", err)
+		}
+	}
+	if _, err := io.Copy(tmp, plain); err != nil {
+		_ = tmp.Close()
+		done()
+		return "", nil, fmt.Errorf("failed to decrypt backup %s: %w", backup.Name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		done()
+		return "", nil, err
+	}
+	return tmp.Name(), done, nil
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func TestEncryptedBackups(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityFile := filepath.Join(t.TempDir(), "key.txt")
+	writeTestFile(t, identityFile, "# backup key\n"+id.String()+"\n")
+
+	tests := []struct {
+		name string
+		cfg  config.BackupEncryptionConfig
+	}{
+		{"passphrase", config.BackupEncryptionConfig{Passphrase: "correct horse"}},
+		{"recipients", config.BackupEncryptionConfig{Recipients: []string{id.Recipient().String()}, IdentityFile: identityFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverDir := filepath.Join(t.TempDir(), "server")
+			writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=secret\n")
+			bm := NewBackupManager(serverDir, t.TempDir(), true, 0)
+			bm.SetEncryption(tt.cfg)
+			bm.scryptWorkFactor = 10
+
+			backup, err := bm.CreateBackup("enc", "manual")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !backup.Encrypted || !strings.HasSuffix(backup.Path, ".zip.age") {
+				t.Fatalf("backup %s is not encrypted", backup.Path)
+			}
+			data, err := os.ReadFile(backup.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(data, []byte("age-encryption.org/v1\n")) || bytes.Contains(data, []byte("server.properties")) {
+				t.Error("backup is not an age file, or file names are readable")
+			}
+
+			name := filepath.Base(backup.Path)
+			if backups, err := bm.ListBackups(); err != nil || len(backups) != 1 || !backups[0].Encrypted || !backups[0].IsCompressed {
+				t.Errorf("ListBackups = %+v, %v", backups, err)
+			}
+			if err := bm.ValidateBackup(name); err != nil {
+				t.Errorf("ValidateBackup: %v", err)
+			}
+			writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=changed\n")
+			if plan, err := bm.PlanRestore(name); err != nil || len(plan.Changed) != 1 {
+				t.Errorf("PlanRestore = %+v, %v", plan, err)
+			}
+			if _, err := bm.Restore(name, RestoreOptions{}); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if got, _ := os.ReadFile(filepath.Join(serverDir, "server.properties")); string(got) != "motd=secret\n" {
+				t.Errorf("restored server.properties = %q", got)
+			}
+
+			// Without the key the backup cannot be restored
+			bm.SetEncryption(config.BackupEncryptionConfig{})
+			if _, err := bm.Restore(name, RestoreOptions{}); err == nil || !strings.Contains(err.Error(), "encrypted") {
+				t.Errorf("Restore without a key: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+)
+
+// IndexFile records the metadata of the backups in the backup directory
+const IndexFile = "index.json"
+
+// Triggers recorded with a backup, see SetTrigger
+const (
+	TriggerCLI    = "cli"    // the backup commands
+	TriggerWeb    = "web"    // the web UI and its API
+	TriggerUpdate = "update" // an update or rollback
+)
+
+// indexMu serializes changes to the index files of every manager in the process; the web
+// UI creates a manager per request
+var indexMu sync.Mutex
+
+// backupIndex is the content of IndexFile
+type backupIndex struct {
+	Backups []indexEntry `json:"backups"`
+}
+
+// indexEntry is the metadata of one backup, keyed by its file name
+type indexEntry struct {
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Trigger  string        `json:"trigger,omitempty"`
+	Version  string        `json:"version,omitempty"`
+	Created  time.Time     `json:"created"`
+	Size     int64         `json:"size"`
+	Checksum string        `json:"checksum"`
+	Duration time.Duration `json:"duration_ns"`
+	Snapshot string        `json:"snapshot,omitempty"`
+}
+
+// SetTrigger records what makes the backups, one of the Trigger constants, in the index
+func (bm *BackupManager) SetTrigger(trigger string) {
+	bm.trigger = trigger
+}
+
+// SetVersionSource records the installed modpack version that version returns with each
+// backup, such as state.Store.InstalledVersion
+func (bm *BackupManager) SetVersionSource(version func() string) {
+	bm.version = version
+}
+
+// recordBackup adds entry to the index, replacing a previous entry of the same name
+func (bm *BackupManager) recordBackup(entry indexEntry) error {
+	return bm.updateIndex(func(index *backupIndex) {
+		index.Backups = slices.DeleteFunc(index.Backups, func(e indexEntry) bool { return e.Name == entry.Name })
+		index.Backups = append(index.Backups, entry)
+	})
+}
+
+// forgetBackup removes a backup from the index
+func (bm *BackupManager) forgetBackup(name string) error {
+	return bm.updateIndex(func(index *backupIndex) {
+		index.Backups = slices.DeleteFunc(index.Backups, func(e indexEntry) bool { return e.Name == name })
+	})
+}
+
+// updateIndex reads the index, applies fn and writes it back atomically. Entries of
+// backups that no longer exist, say because one was deleted by hand, are dropped.
+func (bm *BackupManager) updateIndex(fn func(index *backupIndex)) error {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	index, err := bm.readIndex()
+	if err != nil {
+		return err
+	}
+	fn(index)
+	index.Backups = slices.DeleteFunc(index.Backups, func(e indexEntry) bool {
+		_, err := os.Lstat(filepath.Join(bm.backupPath, e.Name))
+		return os.IsNotExist(err)
+	})
+	slices.SortFunc(index.Backups, func(a, b indexEntry) int { return a.Created.Compare(b.Created) })
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := filesystem.SafeWriteFile(filepath.Join(bm.backupPath, IndexFile), data, 0o600); err != nil {
+		return fmt.Errorf("failed to save backup index: %w", err)
+	}
+	return nil
+}
+
+// readIndex reads the index; a missing index is empty
+func (bm *BackupManager) readIndex() (*backupIndex, error) {
+	index := &backupIndex{}
+	data, err := os.ReadFile(filepath.Join(bm.backupPath, IndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup index: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to read backup index: %w", err)
+	}
+	return index, nil
+}
+
+// indexedBackups returns the index entries by name. An unreadable index is logged and
+// treated as empty, so that the backups are still listed from their names.
+func (bm *BackupManager) indexedBackups() map[string]indexEntry {
+	index, err := bm.readIndex()
+	if err != nil {
+		bm.logger.Warn("describing backups from their names", "error", err)
+		return nil
+	}
+	entries := make(map[string]indexEntry, len(index.Backups))
+	for _, e := range index.Backups {
+		entries[e.Name] = e
+	}
+	return entries
+}
+
+// backupChecksum returns the SHA-256 of a zip backup, or for a directory that of the
+// sorted paths, sizes and SHA-256 sums of its files
+func backupChecksum(path string) (string, error) {
+	if !filesystem.DirExists(path) {
+		// #nosec G304 -- path is a backup in the backup directory
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	hashes, err := filesystem.HashDir(context.Background(), path, func() hash.Hash { return sha256.New() })
+	if err != nil {
+		return "", err
+	}
+	paths := make([]string, 0, len(hashes))
+	for rel := range hashes {
+		paths = append(paths, rel)
+	}
+	slices.Sort(paths)
+	h := sha256.New()
+	for _, rel := range paths {
+		fmt.Fprintf(h, "%s\x00%d\x00%x\n", filepath.ToSlash(rel), hashes[rel].Size, hashes[rel].Sum)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyChecksum checks a backup against the checksum recorded in the index; backups
+// without one are not checked
+func verifyChecksum(backup *BackupInfo) error {
+	if backup.Checksum == "" {
+		return nil
+	}
+	sum, err := backupChecksum(backup.Path)
+	if err != nil {
+		return fmt.Errorf("failed to checksum backup %s: %w", backup.Name, err)
+	}
+	if sum != backup.Checksum {
+		return fmt.Errorf("backup %s has changed since it was made: checksum %s, recorded %s", backup.Name, strings.TrimPrefix(sum, "sha256:"), strings.TrimPrefix(backup.Checksum, "sha256:"))
+	}
+	return nil
+}
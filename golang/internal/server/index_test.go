@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+)
+
+func TestBackupIndex(t *testing.T) {
+	serverDir := t.TempDir()
+	backupDir := t.TempDir()
+	writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=test\n")
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	bm := NewBackupManager(serverDir, backupDir, true, 0)
+	bm.SetClock(fake)
+	bm.SetTrigger(TriggerCLI)
+	bm.SetVersionSource(func() string { return "1.2.3" })
+
+	backup, err := bm.CreateBackup("indexed", "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup.Trigger != TriggerCLI || backup.Version != "1.2.3" || !strings.HasPrefix(backup.Checksum, "sha256:") {
+		t.Errorf("created backup = %+v", backup)
+	}
+	name := filepath.Base(backup.Path)
+
+	// A backup from before the index, whose name says nothing of how it was made, is
+	// described from its name
+	writeTestFile(t, filepath.Join(backupDir, "old_pre_update.zip"), "zip")
+	old := fake.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(backupDir, "old_pre_update.zip"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	// The index, not the modification time, decides when a backup was made
+	if err := os.Chtimes(backup.Path, old.Add(-time.Hour), old.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := bm.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("listed %d backups, want 2", len(backups))
+	}
+	if b := backups[0]; b.Name != name || !b.Created.Equal(fake.Now()) || b.Type != "manual" || b.Trigger != TriggerCLI || b.Version != "1.2.3" || b.Checksum != backup.Checksum {
+		t.Errorf("indexed backup = %+v", b)
+	}
+	if b := backups[1]; b.Name != "old_pre_update.zip" || b.Type != "pre-update" || b.Checksum != "" {
+		t.Errorf("unindexed backup = %+v", b)
+	}
+
+	// A changed backup is refused
+	if err := bm.ValidateBackup(name); err != nil {
+		t.Errorf("ValidateBackup: %v", err)
+	}
+	f, err := os.OpenFile(backup.Path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("corrupt")
+	_ = f.Close()
+	if err := bm.ValidateBackup(name); err == nil || !strings.Contains(err.Error(), "changed since") {
+		t.Errorf("ValidateBackup of a changed backup: %v", err)
+	}
+	if _, err := bm.Restore(name, RestoreOptions{}); err == nil || !strings.Contains(err.Error(), "changed since") {
+		t.Errorf("Restore of a changed backup: %v", err)
+	}
+
+	if err := bm.DeleteBackup(name); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(backupDir, IndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index backupIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Backups) != 0 {
+		t.Errorf("index after deleting = %+v", index.Backups)
+	}
+}
+
+func TestBackupChecksumOfDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "a.txt"), "a")
+	writeTestFile(t, filepath.Join(dir, "world/level.dat"), "level")
+	before, err := backupChecksum(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := backupChecksum(dir); again != before {
+		t.Errorf("checksum changed from %s to %s", before, again)
+	}
+	writeTestFile(t, filepath.Join(dir, "world/level.dat"), "changed")
+	if after, _ := backupChecksum(dir); after == before {
+		t.Error("checksum did not change with a file")
+	}
+}
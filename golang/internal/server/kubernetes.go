@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir holds the token, CA certificate and namespace mounted into every pod
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubernetesServer controls the Deployment running the server through the Kubernetes API,
+// using the service account of the pod the updater runs in
+type KubernetesServer struct {
+	baseURL    string
+	tokenFile  string
+	namespace  string
+	deployment string
+	client     *http.Client
+}
+
+// deploymentStatus is the part of a Deployment that is used
+type deploymentStatus struct {
+	Spec struct {
+		Replicas *int `json:"replicas"`
+	} `json:"spec"`
+	Status struct {
+		ReadyReplicas int `json:"readyReplicas"`
+		Conditions    []struct {
+			Type               string    `json:"type"`
+			Status             string    `json:"status"`
+			LastTransitionTime time.Time `json:"lastTransitionTime"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// NewKubernetesServer controls deployment in namespace, or in the pod's own namespace when
+// namespace is empty. It only works inside a cluster.
+func NewKubernetesServer(namespace, deployment string) (*KubernetesServer, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running inside a Kubernetes cluster: KUBERNETES_SERVICE_HOST is not set")
+	}
+	if namespace == "" {
+		data, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the pod namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cluster CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid cluster CA certificate")
+	}
+
+	return &KubernetesServer{
+		baseURL:    "https://" + net.JoinHostPort(host, port),
+		tokenFile:  filepath.Join(serviceAccountDir, "token"),
+		namespace:  namespace,
+		deployment: deployment,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			},
+		},
+	}, nil
+}
+
+// Start scales the Deployment up to one replica
+func (k *KubernetesServer) Start() error {
+	d, err := k.get()
+	if err != nil {
+		return err
+	}
+	if d.Spec.Replicas == nil || *d.Spec.Replicas > 0 {
+		return fmt.Errorf("server is already running")
+	}
+	return k.patch("/scale", "application/merge-patch+json", map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": 1},
+	})
+}
+
+// Stop scales the Deployment down to zero replicas; the pod's terminationGracePeriodSeconds
+// decides how long the server gets to shut down
+func (k *KubernetesServer) Stop(timeout time.Duration) error {
+	d, err := k.get()
+	if err != nil {
+		return err
+	}
+	if d.Spec.Replicas != nil && *d.Spec.Replicas == 0 {
+		return fmt.Errorf("server is not running")
+	}
+	return k.patch("/scale", "application/merge-patch+json", map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": 0},
+	})
+}
+
+// Restart triggers a rollout restart, the same way kubectl rollout restart does
+func (k *KubernetesServer) Restart(timeout time.Duration) error {
+	return k.patch("", "application/strategic-merge-patch+json", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						"kubectl.kubernetes.io/restartedAt": time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+}
+
+// IsRunning reports whether the Deployment has a ready replica
+func (k *KubernetesServer) IsRunning() bool {
+	d, err := k.get()
+	return err == nil && d.Status.ReadyReplicas > 0
+}
+
+// GetUptime returns how long the Deployment has been available
+func (k *KubernetesServer) GetUptime() time.Duration {
+	d, err := k.get()
+	if err != nil || d.Status.ReadyReplicas == 0 {
+		return 0
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type == "Available" && c.Status == "True" {
+			return time.Since(c.LastTransitionTime)
+		}
+	}
+	return 0
+}
+
+// get fetches the Deployment
+func (k *KubernetesServer) get() (*deploymentStatus, error) {
+	resp, err := k.do(http.MethodGet, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var d deploymentStatus
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("failed to decode deployment: %w", err)
+	}
+	return &d, nil
+}
+
+// patch sends a patch of the given content type to the Deployment or one of its subresources
+func (k *KubernetesServer) patch(subresource, contentType string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := k.do(http.MethodPatch, subresource, contentType, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends a request for the Deployment with the service account token and turns API
+// errors into Go errors
+func (k *KubernetesServer) do(method, subresource, contentType string, body io.Reader) (*http.Response, error) {
+	u := k.baseURL + "/apis/apps/v1/namespaces/" + url.PathEscape(k.namespace) +
+		"/deployments/" + url.PathEscape(k.deployment) + subresource
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// The token is read on every request because projected tokens are rotated
+	token, err := os.ReadFile(k.tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes request failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return nil, fmt.Errorf("kubernetes: %s", apiErr.Message)
+	}
+	return resp, nil
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKubernetesServer(t *testing.T) {
+	replicas, ready := 0, 0
+	var patches []string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sa-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Unauthorized"}`))
+			return
+		}
+		const deployment = "/apis/apps/v1/namespaces/games/deployments/minecraft"
+		switch r.Method + " " + r.URL.Path {
+		case "GET " + deployment:
+			d := map[string]interface{}{
+				"spec": map[string]interface{}{"replicas": replicas},
+				"status": map[string]interface{}{
+					"readyReplicas": ready,
+					"conditions": []map[string]interface{}{
+						{"type": "Available", "status": "True", "lastTransitionTime": time.Now().Add(-time.Hour)},
+					},
+				},
+			}
+			_ = json.NewEncoder(w).Encode(d)
+		case "PATCH " + deployment + "/scale":
+			var body struct {
+				Spec struct {
+					Replicas int `json:"replicas"`
+				} `json:"spec"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			replicas, ready = body.Spec.Replicas, body.Spec.Replicas
+			patches = append(patches, "scale="+r.Header.Get("Content-Type"))
+		case "PATCH " + deployment:
+			data, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(data), "kubectl.kubernetes.io/restartedAt") {
+				t.Errorf("restart patch = %s", data)
+			}
+			patches = append(patches, "restart="+r.Header.Get("Content-Type"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"deployments.apps \"other\" not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	// Fake the service account mount and environment of a pod
+	dir := t.TempDir()
+	serviceAccountDir = dir
+	defer func() { serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount" }()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	for name, data := range map[string]string{"token": "sa-token\n", "namespace": "games", "ca.crt": string(ca)} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	u, _ := url.Parse(srv.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+	t.Setenv("KUBERNETES_SERVICE_HOST", host)
+	t.Setenv("KUBERNETES_SERVICE_PORT", port)
+
+	k, err := NewKubernetesServer("", "minecraft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ Controller = k
+
+	if k.IsRunning() || k.GetUptime() != 0 {
+		t.Fatal("deployment should start out scaled down")
+	}
+	if err := k.Stop(time.Minute); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Fatalf("Stop of a scaled down deployment err = %v", err)
+	}
+	if err := k.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if !k.IsRunning() || k.GetUptime() < time.Hour {
+		t.Fatalf("deployment should be available for about an hour, got %s", k.GetUptime())
+	}
+	if err := k.Restart(time.Minute); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	if err := k.Stop(time.Minute); err != nil || k.IsRunning() {
+		t.Fatalf("Stop = %v", err)
+	}
+	want := []string{
+		"scale=application/merge-patch+json",
+		"restart=application/strategic-merge-patch+json",
+		"scale=application/merge-patch+json",
+	}
+	if strings.Join(patches, ",") != strings.Join(want, ",") {
+		t.Fatalf("patches = %v, want %v", patches, want)
+	}
+
+	missing, _ := NewKubernetesServer("games", "other")
+	if err := missing.Restart(0); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("Restart of a missing deployment err = %v", err)
+	}
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	if _, err := NewKubernetesServer("", "minecraft"); err == nil {
+		t.Fatal("NewKubernetesServer should fail outside a cluster")
+	}
+}
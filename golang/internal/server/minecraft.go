@@ -2,6 +2,8 @@ package server
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -11,19 +13,33 @@ import (
 	"time"
 
 	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
 )
 
+// consoleHistory is how many recent log lines SubscribeLogs replays
+const consoleHistory = 200
+
 // MinecraftServer represents a Minecraft server instance
 type MinecraftServer struct {
 	serverPath string
 	jarName    string
+	java       string
+	sandbox    config.ProcessConfig
+	bus        *events.Bus
 	process    *exec.Cmd
+	commands   chan consoleCommand
 	isRunning  bool
 	mu         sync.RWMutex
 	stopChan   chan struct{}
 	logChan    chan string
 	errorChan  chan error
 	startTime  time.Time
+
+	// subscribers get every log line; recent keeps the last consoleHistory of them
+	logMu       sync.Mutex
+	subscribers map[chan string]struct{}
+	recent      []string
 }
 
 // NewMinecraftServer creates a new Minecraft server instance
@@ -37,62 +53,107 @@ func NewMinecraftServer(serverPath, jarName string) *MinecraftServer {
 	}
 }
 
-// Start starts the Minecraft server
+// SetJava sets the java binary that runs the server jar, server_jar.java; a bare name not
+// found on PATH is looked up in JAVA_HOME. It is not used for start scripts.
+func (s *MinecraftServer) SetJava(java string) {
+	s.java = java
+}
+
+// SetSandbox sets the user, working directory, umask and environment the server runs
+// with, server.process
+func (s *MinecraftServer) SetSandbox(cfg config.ProcessConfig) {
+	s.sandbox = cfg
+}
+
+// SetBus sets the event bus that the server starting and stopping is published to
+func (s *MinecraftServer) SetBus(bus *events.Bus) {
+	s.bus = bus
+}
+
+// publish sends an event if a bus is configured
+func (s *MinecraftServer) publish(eventType string, data map[string]interface{}) {
+	if s.bus != nil {
+		s.bus.Publish(eventType, data)
+	}
+}
+
+// Start starts the Minecraft server. A server_jar_name ending in .bat, .cmd or .sh is the
+// start script Forge and NeoForge installers write, and is run instead of java.
 func (s *MinecraftServer) Start() error {
+	process, stopped, err := s.start()
+	if err != nil {
+		return err
+	}
+	// Outside the lock, so that subscribers may look at the server, and before the
+	// process is watched, so that the start is published before the stop
+	s.publish(events.ServerStarted, map[string]interface{}{"pid": process.Process.Pid})
+	go s.monitorProcess(process, stopped)
+	return nil
+}
+
+// start starts the process and returns it with the channel closed once it has exited
+func (s *MinecraftServer) start() (*exec.Cmd, chan struct{}, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.isRunning {
-		return fmt.Errorf("server is already running")
+		return nil, nil, fmt.Errorf("server is already running")
 	}
 
 	// Check if server JAR exists
 	jarPath := filepath.Join(s.serverPath, s.jarName)
 	if !filesystem.FileExists(jarPath) {
-		return fmt.Errorf("server JAR not found: %s", jarPath)
+		return nil, nil, fmt.Errorf("server JAR not found: %s", jarPath)
 	}
 
 	// Check if server directory exists
 	if !filesystem.DirExists(s.serverPath) {
-		return fmt.Errorf("server directory not found: %s", s.serverPath)
+		return nil, nil, fmt.Errorf("server directory not found: %s", s.serverPath)
 	}
 
-	// Create start command
-	args := []string{
-		"-Xmx2G",
-		"-Xms1G",
-		"-jar",
-		s.jarName,
-		"nogui",
+	process, err := s.command()
+	if err != nil {
+		return nil, nil, err
 	}
-
-	// #nosec G204 -- args are validated elsewhere
-	s.process = exec.Command("java", args...)
+	s.process = process
 	s.process.Dir = s.serverPath
+	if s.sandbox.WorkingDir != "" {
+		s.process.Dir = s.sandbox.WorkingDir
+	}
+	s.process.Env = environment(s.sandbox.Env)
+	// The server gets its own process group, so that a force stop reaches the java a start
+	// script runs, and Ctrl+C only stops it through the console
+	isolate(s.process)
+	if err := sandbox(s.process, s.sandbox); err != nil {
+		return nil, nil, err
+	}
 
 	// Set up pipes for stdout and stderr
 	stdout, err := s.process.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
+		return nil, nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
 	stderr, err := s.process.StderrPipe()
 	if err != nil {
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
+		return nil, nil, fmt.Errorf("failed to create stderr pipe: %w", err)
 	}
 
-	if _, err := s.process.StdinPipe(); err != nil {
-		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	// stdin is only written by runCommands, which takes the commands in turn
+	stdin, err := s.process.StdinPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create stdin pipe: %w", err)
 	}
-	// Do not assign stdin to s.process.Stdin (types are incompatible)
 
 	// Start the process
 	if err := s.process.Start(); err != nil {
-		return fmt.Errorf("failed to start server: %w", err)
+		return nil, nil, fmt.Errorf("failed to start server: %w", err)
 	}
 
 	s.isRunning = true
 	s.startTime = time.Now()
+	s.stopChan = make(chan struct{})
+	s.commands = make(chan consoleCommand, commandQueueSize)
 
 	// Start log monitoring goroutines
 	if file, ok := stdout.(*os.File); ok {
@@ -102,90 +163,112 @@ func (s *MinecraftServer) Start() error {
 		go s.monitorOutput(file, "stderr")
 	}
 
-	// Start process monitoring
-	go s.monitorProcess()
-
-	// No need to store stdin again; already available via s.process.Stdin
-	return nil
+	go s.runCommands(stdin, s.commands, s.stopChan)
+	return s.process, s.stopChan, nil
 }
 
 // Stop stops the Minecraft server gracefully
 func (s *MinecraftServer) Stop(timeout time.Duration) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.isRunning {
-		return fmt.Errorf("server is not running")
+		s.mu.Unlock()
+		return ErrNotRunning
 	}
+	process, stopped := s.process.Process, s.stopChan
+	s.mu.Unlock()
 
-	// Send stop command
-	if err := s.sendCommand("stop"); err != nil {
-		return fmt.Errorf("failed to send stop command: %w", err)
+	// Send stop command, after the commands queued before it
+	result := <-s.Command("stop", 0)
+	switch {
+	case errors.Is(result.Err, ErrNotRunning):
+		// It stopped on its own in the meantime
+		<-stopped
+		return nil
+	case result.Err != nil:
+		return fmt.Errorf("failed to send stop command: %w", result.Err)
 	}
 
-	// Wait for graceful shutdown with timeout
-	done := make(chan error, 1)
-	go func() {
-		done <- s.process.Wait()
-	}()
-
+	// Wait for graceful shutdown with timeout; monitorProcess reaps the process
 	select {
-	case err := <-done:
-		s.isRunning = false
-		if err != nil {
-			return fmt.Errorf("server stopped with error: %w", err)
-		}
+	case <-stopped:
 		return nil
 	case <-time.After(timeout):
 		// Force kill if timeout reached
-		if err := s.process.Process.Kill(); err != nil {
+		if err := killProcessTree(process); err != nil {
 			return fmt.Errorf("failed to kill server process: %w", err)
 		}
-		s.isRunning = false
+		<-stopped
 		return fmt.Errorf("server did not stop gracefully within timeout, killed")
 	}
 }
 
-// IsRunning returns whether the server is currently running
-func (s *MinecraftServer) IsRunning() bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.isRunning
+// command returns the command that starts the server: the start script, or java -jar.
+// Either is given by its absolute path, as server.process.working_dir may be elsewhere.
+func (s *MinecraftServer) command() (*exec.Cmd, error) {
+	path, err := filepath.Abs(filepath.Join(s.serverPath, s.jarName))
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(s.jarName)) {
+	case ".bat", ".cmd", ".sh":
+		return scriptCommand(path, "nogui")
+	}
+	// #nosec G204 -- java comes from the config file
+	return exec.Command(findJava(s.java), "-Xmx2G", "-Xms1G", "-jar", path, "nogui"), nil
 }
 
-// SendCommand sends a command to the server
-func (s *MinecraftServer) SendCommand(command string) error {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	if !s.isRunning {
-		return fmt.Errorf("server is not running")
+// environment returns the environment of the server for server.process.env, or nil to
+// pass on the whole environment of the updater
+func environment(env []string) []string {
+	if len(env) == 0 {
+		return nil
 	}
-
-	return s.sendCommand(command)
+	out := []string{}
+	for _, e := range env {
+		if strings.Contains(e, "=") {
+			out = append(out, e)
+		} else if value, ok := os.LookupEnv(e); ok {
+			out = append(out, e+"="+value)
+		}
+	}
+	return out
 }
 
-// sendCommand sends a command to the server (internal method)
-func (s *MinecraftServer) sendCommand(command string) error {
-	if s.process == nil || s.process.Stdin == nil {
-		return fmt.Errorf("server process or stdin is not available")
+// findJava returns java, or for a bare name that is not on PATH the binary of that name in
+// JAVA_HOME when there is one
+func findJava(java string) string {
+	if java == "" {
+		java = "java"
 	}
-
-	// Use the original stdin pipe for writing commands
-	if s.process == nil {
-		return fmt.Errorf("server process is not available")
+	if strings.ContainsAny(java, `/\`) {
+		return java
+	}
+	if _, err := exec.LookPath(java); err == nil {
+		return java
 	}
-	// Try to get the stdin pipe from the process
-	if stdin, err := s.process.StdinPipe(); err == nil {
-		defer stdin.Close()
-		_, err := fmt.Fprintf(stdin, "%s\n", command)
-		if err != nil {
-			return fmt.Errorf("failed to write command to stdin: %w", err)
+	if home := os.Getenv("JAVA_HOME"); home != "" {
+		name := java
+		if filepath.Ext(name) == "" {
+			name += exeSuffix
+		}
+		if path := filepath.Join(home, "bin", name); filesystem.FileExists(path) {
+			return path
 		}
-		return nil
-	} else {
-		return fmt.Errorf("stdin pipe is not available: %w", err)
 	}
+	return java
+}
+
+// IsRunning returns whether the server is currently running
+func (s *MinecraftServer) IsRunning() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.isRunning
+}
+
+// SendCommand writes command to the console of the server once the commands queued
+// before it are done, without waiting for its output; see Command
+func (s *MinecraftServer) SendCommand(command string) error {
+	return (<-s.Command(command, 0)).Err
 }
 
 // GetUptime returns the server uptime
@@ -210,12 +293,48 @@ func (s *MinecraftServer) GetErrorChannel() <-chan error {
 	return s.errorChan
 }
 
+// SubscribeLogs returns the most recent log lines and a channel that receives every line
+// logged from now on. Lines are dropped when the subscriber falls buffer lines behind.
+// cancel must be called once the lines are no longer read.
+func (s *MinecraftServer) SubscribeLogs(buffer int) (history []string, lines <-chan string, cancel func()) {
+	ch := make(chan string, buffer)
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	if s.subscribers == nil {
+		s.subscribers = map[chan string]struct{}{}
+	}
+	s.subscribers[ch] = struct{}{}
+	history = append([]string(nil), s.recent...)
+	return history, ch, func() {
+		s.logMu.Lock()
+		defer s.logMu.Unlock()
+		delete(s.subscribers, ch)
+	}
+}
+
+// publishLog records line for SubscribeLogs and passes it to every subscriber
+func (s *MinecraftServer) publishLog(line string) {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	s.recent = append(s.recent, line)
+	if len(s.recent) > consoleHistory {
+		s.recent = s.recent[len(s.recent)-consoleHistory:]
+	}
+	for ch := range s.subscribers {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
 // monitorOutput monitors server output
 func (s *MinecraftServer) monitorOutput(pipe *os.File, source string) {
 	scanner := bufio.NewScanner(pipe)
 	for scanner.Scan() {
 		line := scanner.Text()
 		logEntry := fmt.Sprintf("[%s] %s", source, line)
+		s.publishLog(logEntry)
 
 		select {
 		case s.logChan <- logEntry:
@@ -234,27 +353,33 @@ func (s *MinecraftServer) monitorOutput(pipe *os.File, source string) {
 	}
 }
 
-// monitorProcess monitors the server process
-func (s *MinecraftServer) monitorProcess() {
-	err := s.process.Wait()
+// monitorProcess waits for process to exit and closes stopped
+func (s *MinecraftServer) monitorProcess(process *exec.Cmd, stopped chan struct{}) {
+	err := process.Wait()
 
 	s.mu.Lock()
 	s.isRunning = false
 	s.mu.Unlock()
 
+	data := map[string]interface{}{}
 	if err != nil {
 		select {
 		case s.errorChan <- fmt.Errorf("server process exited with error: %w", err):
 		default:
 		}
+		data["error"] = err.Error()
 	}
 
-	close(s.stopChan)
+	close(stopped)
+	s.publish(events.ServerStopped, data)
 }
 
 // WaitForShutdown waits for the server to shut down
 func (s *MinecraftServer) WaitForShutdown() {
-	<-s.stopChan
+	s.mu.RLock()
+	stopped := s.stopChan
+	s.mu.RUnlock()
+	<-stopped
 }
 
 // GetServerInfo returns basic server information
@@ -322,38 +447,16 @@ func (s *MinecraftServer) GetOnlinePlayers() error {
 	return s.SendCommand("list")
 }
 
-// NotifyPlayersBeforeShutdown notifies players before server shutdown
-func (s *MinecraftServer) NotifyPlayersBeforeShutdown(countdown int) error {
-	messages := []string{
-		"Server will be shutting down for maintenance in %d minutes",
-		"Server will be shutting down in %d minutes",
-		"Server shutdown in %d minutes",
-	}
-
-	for i := countdown; i > 0; i-- {
-		var message string
-		if i <= len(messages) {
-			message = fmt.Sprintf(messages[i-1], i)
-		} else {
-			message = fmt.Sprintf("Server will be shutting down in %d minutes", i)
-		}
-
-		if err := s.BroadcastMessage(message); err != nil {
-			return fmt.Errorf("failed to broadcast countdown message: %w", err)
-		}
-
-		if i > 1 {
-			time.Sleep(1 * time.Minute)
-		}
-	}
-
-	return s.BroadcastMessage("Server is shutting down now for maintenance")
+// NotifyPlayersBeforeShutdown broadcasts the countdown in cfg through the server console;
+// cancelling ctx stops it early
+func (s *MinecraftServer) NotifyPlayersBeforeShutdown(ctx context.Context, cfg config.CountdownConfig) error {
+	return NewCountdown(cfg, s).Run(ctx)
 }
 
 // CheckServerHealth checks if the server is healthy
 func (s *MinecraftServer) CheckServerHealth() error {
 	if !s.IsRunning() {
-		return fmt.Errorf("server is not running")
+		return ErrNotRunning
 	}
 
 	// Check if server directory exists
@@ -372,73 +475,30 @@ func (s *MinecraftServer) CheckServerHealth() error {
 
 // GetServerProperties reads server properties
 func (s *MinecraftServer) GetServerProperties() (map[string]string, error) {
-	propertiesPath := filepath.Join(s.serverPath, "server.properties")
+	return ReadServerProperties(s.serverPath)
+}
 
-	// #nosec G304 -- propertiesPath is constructed internally
-	file, err := os.Open(propertiesPath)
+// ReadServerProperties reads server.properties from a server directory
+func ReadServerProperties(serverPath string) (map[string]string, error) {
+	props, err := LoadProperties(serverPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open server.properties: %w", err)
-	}
-	defer file.Close()
-
-	properties := make(map[string]string)
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip comments and empty lines
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Parse key=value pairs
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			properties[key] = value
-		}
+		return nil, err
 	}
+	return props.Map(), nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading server.properties: %w", err)
+// ServerPort returns server-port from server.properties in serverPath, or DefaultPort
+func ServerPort(serverPath string) int {
+	if props, err := LoadProperties(serverPath); err == nil {
+		return props.Port()
 	}
-
-	return properties, nil
+	return DefaultPort
 }
 
-// UpdateServerProperties updates server properties
+// UpdateServerProperties sets properties in server.properties, keeping the order and
+// comments of the file. Nothing is written when a value is invalid.
 func (s *MinecraftServer) UpdateServerProperties(properties map[string]string) error {
-	propertiesPath := filepath.Join(s.serverPath, "server.properties")
-
-	// Read existing properties
-	existing, err := s.GetServerProperties()
-	if err != nil {
-		existing = make(map[string]string)
-	}
-
-	// Merge with new properties
-	for key, value := range properties {
-		existing[key] = value
-	}
-
-	// Write back to file
-	// #nosec G304 -- propertiesPath is constructed internally
-	file, err := os.Create(propertiesPath)
-	if err != nil {
-		return fmt.Errorf("failed to create server.properties: %w", err)
-	}
-	defer file.Close()
-
-	for key, value := range existing {
-		_, err := fmt.Fprintf(file, "%s=%s\n", key, value)
-		if err != nil {
-			return fmt.Errorf("failed to write property %s: %w", key, err)
-		}
-	}
-
-	return nil
+	return SetProperties(s.serverPath, properties)
 }
 
 // Restart restarts the server
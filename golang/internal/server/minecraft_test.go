@@ -0,0 +1,307 @@
+//go:build unix
+
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+)
+
+// fakeJava puts a java on PATH that echoes console commands and exits on "stop"
+func fakeJava(t *testing.T) {
+	t.Helper()
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho started\nwhile read -r line; do\n  echo \"got $line\"\n  [ \"$line\" = stop ] && exit 0\ndone\n"
+	if err := os.WriteFile(filepath.Join(bin, "java"), []byte(script), 0o755); err != nil { // #nosec G306 -- test executable
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// nextLine waits for the next line on lines
+func nextLine(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a log line")
+		return ""
+	}
+}
+
+func TestMinecraftServerConsole(t *testing.T) {
+	fakeJava(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "server.jar"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewMinecraftServer(dir, "server.jar")
+	bus := events.NewBus(nil)
+	rec := events.NewRecorder(bus)
+	s.SetBus(bus)
+	_, lines, cancel := s.SubscribeLogs(16)
+	defer cancel()
+
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if line := nextLine(t, lines); line != "[stdout] started" {
+		t.Fatalf("first line = %q", line)
+	}
+	if err := s.SendCommand("list"); err != nil {
+		t.Fatal(err)
+	}
+	if line := nextLine(t, lines); line != "[stdout] got list" {
+		t.Fatalf("reply = %q", line)
+	}
+
+	// A late subscriber sees what was logged before it subscribed
+	history, _, cancelLate := s.SubscribeLogs(1)
+	cancelLate()
+	if strings.Join(history, "|") != "[stdout] started|[stdout] got list" {
+		t.Errorf("history = %q", history)
+	}
+
+	if err := s.Stop(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !rec.WaitFor(events.ServerStopped, 1, 5*time.Second) {
+		t.Fatal("no server_stopped event")
+	}
+	if types := strings.Join(rec.Types(), ","); types != "server_started,server_stopped" {
+		t.Errorf("events = %s", types)
+	}
+	if _, failed := rec.Events()[1].Data["error"]; failed {
+		t.Errorf("a clean stop published an error: %v", rec.Events()[1].Data)
+	}
+}
+
+func TestMinecraftServerCommandQueue(t *testing.T) {
+	fakeJava(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "server.jar"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewMinecraftServer(dir, "server.jar")
+	if res := <-s.Command("list", time.Second); !errors.Is(res.Err, ErrNotRunning) {
+		t.Fatalf("Command before Start = %v", res.Err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	res := <-s.Command("list", 5*time.Second)
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	// The startup line may still be logged before the reply
+	if len(res.Output) == 0 || res.Output[len(res.Output)-1] != "got list" {
+		t.Errorf("output = %q", res.Output)
+	}
+
+	// Commands queued together run in order, each with its own output
+	results := make([]<-chan CommandResult, 5)
+	for i := range results {
+		results[i] = s.Command(fmt.Sprintf("say %d", i), 5*time.Second)
+	}
+	for i, ch := range results {
+		res := <-ch
+		if want := fmt.Sprintf("got say %d", i); res.Err != nil || strings.Join(res.Output, "|") != want {
+			t.Errorf("result %d = %q, %v, want %q", i, res.Output, res.Err, want)
+		}
+	}
+
+	if err := s.Stop(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if res := <-s.Command("list", time.Second); !errors.Is(res.Err, ErrNotRunning) {
+		t.Errorf("Command after Stop = %v", res.Err)
+	}
+	if err := s.SendCommand("list"); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("SendCommand after Stop = %v", err)
+	}
+}
+
+func TestRunCommandUnreadConsole(t *testing.T) {
+	// Nothing reads the pipe until the end, so every write blocks
+	console, stdin := io.Pipe()
+	s := &MinecraftServer{}
+	w := newConsoleWriter(stdin)
+	defer w.close()
+
+	start := time.Now()
+	res := s.runCommand(w, consoleCommand{command: "list", timeout: 50 * time.Millisecond}, make(chan struct{}))
+	if res.Err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("write to an unread console = %v after %s", res.Err, time.Since(start))
+	}
+	// The next command fails at once instead of a second write next to the first
+	if res := s.runCommand(w, consoleCommand{command: "stop"}, make(chan struct{})); res.Err == nil {
+		t.Error("command after a blocked write succeeded")
+	}
+	// Stdin was closed, so neither command reaches the server later on
+	if written, err := io.ReadAll(console); err != nil || len(written) != 0 {
+		t.Errorf("console got %q, %v", written, err)
+	}
+
+	_, stdin = io.Pipe()
+	defer func() { _ = stdin.Close() }()
+	w = newConsoleWriter(stdin)
+	defer w.close()
+	stopped := make(chan struct{})
+	time.AfterFunc(50*time.Millisecond, func() { close(stopped) })
+	if res := s.runCommand(w, consoleCommand{command: "stop"}, stopped); !errors.Is(res.Err, ErrNotRunning) {
+		t.Errorf("write when the server stops = %v, want ErrNotRunning", res.Err)
+	}
+}
+
+func TestMinecraftServerStartScript(t *testing.T) {
+	dir := t.TempDir()
+	// Like the run.sh of Forge, the script runs a child without exec; this one ignores stop
+	script := "#!/bin/sh\nsleep 60 &\necho $! > child.pid\necho started \"$@\"\nwait\n"
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte(script), 0o755); err != nil { // #nosec G306 -- test executable
+		t.Fatal(err)
+	}
+	s := NewMinecraftServer(dir, "run.sh")
+	_, lines, cancel := s.SubscribeLogs(16)
+	defer cancel()
+
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if line := nextLine(t, lines); line != "[stdout] started nogui" {
+		t.Fatalf("first line = %q", line)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "child.pid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Stop(200 * time.Millisecond); err == nil || !strings.Contains(err.Error(), "killed") {
+		t.Fatalf("Stop = %v, want the server killed", err)
+	}
+	if s.IsRunning() {
+		t.Error("server still running after Stop")
+	}
+	// The child of the script went with it; it may linger as a zombie until reaped
+	deadline := time.Now().Add(5 * time.Second)
+	for syscall.Kill(child, 0) == nil && !zombie(child) {
+		if time.Now().After(deadline) {
+			_ = syscall.Kill(child, syscall.SIGKILL)
+			t.Fatal("the child of the start script survived the force stop")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// zombie reports whether pid has exited but not been reaped, where /proc tells
+func zombie(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+func TestFindJava(t *testing.T) {
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, "bin"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "bin", "java"), nil, 0o755); err != nil { // #nosec G306 -- test executable
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("JAVA_HOME", home)
+
+	if got := findJava("java"); got != filepath.Join(home, "bin", "java") {
+		t.Errorf("findJava(java) = %q, want the one in JAVA_HOME", got)
+	}
+	if got := findJava("/opt/jdk/bin/java"); got != "/opt/jdk/bin/java" {
+		t.Errorf("findJava with a path = %q", got)
+	}
+	if got := findJava("java17"); got != "java17" {
+		t.Errorf("findJava(java17) = %q, want it unchanged", got)
+	}
+
+	fakeJava(t)
+	if got := findJava(""); got != "java" {
+		t.Errorf("findJava with java on PATH = %q", got)
+	}
+}
+
+func TestMinecraftServerSandbox(t *testing.T) {
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$(umask) $(pwd) ${KEEP-unset} ${DROP-unset} ${SET} $(id -u) $HOME\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte(script), 0o755); err != nil { // #nosec G306 -- test executable
+		t.Fatal(err)
+	}
+	workDir := t.TempDir()
+	t.Setenv("KEEP", "kept")
+	t.Setenv("DROP", "dropped")
+
+	run := func(cfg config.ProcessConfig) (string, error) {
+		s := NewMinecraftServer(dir, "run.sh")
+		s.SetSandbox(cfg)
+		_, lines, cancel := s.SubscribeLogs(16)
+		defer cancel()
+		if err := s.Start(); err != nil {
+			return "", err
+		}
+		line := nextLine(t, lines)
+		s.WaitForShutdown()
+		return line, nil
+	}
+
+	cfg := config.ProcessConfig{WorkingDir: workDir, Umask: "027", Env: []string{"PATH", "KEEP", "SET=set", "MISSING"}}
+	line, err := run(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("[stdout] 0027 %s kept unset set %d", workDir, os.Getuid())
+	if !strings.HasPrefix(line, want) {
+		t.Errorf("server saw %q, want %q", line, want)
+	}
+
+	cfg.User = "nobody"
+	if os.Geteuid() != 0 {
+		if _, err := run(cfg); err == nil || !strings.Contains(err.Error(), "root") {
+			t.Errorf("Start as another user without root: %v", err)
+		}
+		return
+	}
+	nobody, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skip("no nobody user")
+	}
+	// nobody needs to reach the script and the working directory
+	for _, d := range []string{dir, filepath.Dir(dir), workDir, filepath.Dir(workDir)} {
+		if err := os.Chmod(d, 0o755); err != nil { // #nosec G302 -- test directories
+			t.Fatal(err)
+		}
+	}
+	if line, err = run(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf(" %s %s", nobody.Uid, nobody.HomeDir); !strings.HasSuffix(line, want) {
+		t.Errorf("server saw %q, want it to end in %q", line, want)
+	}
+}
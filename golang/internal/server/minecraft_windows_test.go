@@ -0,0 +1,97 @@
+package server
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nextServerLine waits for the next line on lines
+func nextServerLine(t *testing.T, lines <-chan string) string {
+	t.Helper()
+	select {
+	case line := <-lines:
+		return strings.TrimSpace(line)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for a log line")
+		return ""
+	}
+}
+
+func TestMinecraftServerWindows(t *testing.T) {
+	dir := t.TempDir()
+	// Like the run.bat of Forge; it echoes console commands and exits on "stop"
+	script := "@echo off\r\necho started %*\r\n:loop\r\nset \"line=\"\r\nset /p line=\r\nif \"%line%\"==\"stop\" exit /b 0\r\necho got %line%\r\ngoto loop\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "run.bat"), []byte(script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewMinecraftServer(dir, "run.bat")
+	_, lines, cancel := s.SubscribeLogs(16)
+	defer cancel()
+
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if line := nextServerLine(t, lines); line != "[stdout] started nogui" {
+		t.Fatalf("first line = %q", line)
+	}
+	if err := s.SendCommand("list"); err != nil {
+		t.Fatal(err)
+	}
+	if line := nextServerLine(t, lines); line != "[stdout] got list" {
+		t.Fatalf("reply = %q", line)
+	}
+	if err := s.Stop(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMinecraftServerWindowsForceStop(t *testing.T) {
+	dir := t.TempDir()
+	// The script ignores stop and runs a child that taskkill has to find
+	script := "@echo off\r\necho started\r\nping -n 120 127.0.0.1 >nul\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "run.cmd"), []byte(script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewMinecraftServer(dir, "run.cmd")
+	_, lines, cancel := s.SubscribeLogs(16)
+	defer cancel()
+
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	nextServerLine(t, lines)
+	pid := s.process.Process.Pid
+	if err := s.Stop(500 * time.Millisecond); err == nil || !strings.Contains(err.Error(), "killed") {
+		t.Fatalf("Stop = %v, want the server killed", err)
+	}
+	if s.IsRunning() {
+		t.Error("server still running after Stop")
+	}
+	query := "@(Get-CimInstance Win32_Process -Filter 'ParentProcessId=" + strconv.Itoa(pid) + "').Count"
+	out, err := exec.Command("powershell", "-NoProfile", "-Command", query).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.TrimSpace(string(out)); n != "0" {
+		t.Errorf("%s children of the start script survived the force stop", n)
+	}
+}
+
+func TestStartScriptsOnWindows(t *testing.T) {
+	if _, err := scriptCommand(`C:\server\run.sh`); err == nil {
+		t.Error("a shell script was accepted")
+	}
+	script := filepath.Join(t.TempDir(), "run.bat")
+	if err := os.WriteFile(script, []byte("@echo off\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd, err := scriptCommand(script, "nogui")
+	if err != nil || len(cmd.Args) != 2 || cmd.Args[1] != "nogui" {
+		t.Errorf("scriptCommand = %v, %v", cmd, err)
+	}
+}
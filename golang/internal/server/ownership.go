@@ -0,0 +1,139 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/archive"
+	"github.com/klauspost/compress/zip"
+)
+
+// ownerFunc picks the owner of a restored file from the one recorded in the backup;
+// recorded is false for backups made without ownership
+type ownerFunc func(uid, gid int, recorded bool) (int, int, bool)
+
+// sameOwner keeps the recorded owner
+func sameOwner(uid, gid int, recorded bool) (int, int, bool) {
+	return uid, gid, recorded
+}
+
+// SetRestoreOwner makes restores, when running as root, give every restored file to
+// owner instead of the owner recorded in the backup: a user name or ID, optionally
+// followed by ":" and a group name or ID, e.g. "minecraft" or "1000:1000"
+func (bm *BackupManager) SetRestoreOwner(owner string) {
+	bm.restoreOwner = owner
+}
+
+// restoredOwner returns who owns restored files, or nil to leave them to the current
+// user. Only root can give files away, so ownership is restored only when running as root.
+func (bm *BackupManager) restoredOwner() (ownerFunc, error) {
+	if os.Geteuid() != 0 {
+		if bm.restoreOwner != "" {
+			bm.logger.Warn("restore_owner is only applied when running as root", "owner", bm.restoreOwner)
+		}
+		return nil, nil
+	}
+	if bm.restoreOwner == "" {
+		return sameOwner, nil
+	}
+	uid, gid, err := LookupOwner(bm.restoreOwner)
+	if err != nil {
+		return nil, err
+	}
+	return func(int, int, bool) (int, int, bool) { return uid, gid, true }, nil
+}
+
+// LookupOwner resolves a "user[:group]" owner, see SetRestoreOwner. Without a group the
+// user's primary group is used.
+func LookupOwner(owner string) (uid, gid int, err error) {
+	name, group, hasGroup := strings.Cut(owner, ":")
+	if name == "" || hasGroup && group == "" {
+		return 0, 0, fmt.Errorf("invalid owner %q, expected user or user:group", owner)
+	}
+
+	gid = -1
+	if uid, err = strconv.Atoi(name); err != nil {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return 0, 0, fmt.Errorf("unknown user %q: %w", name, err)
+		}
+		uid, _ = strconv.Atoi(u.Uid)
+		gid, _ = strconv.Atoi(u.Gid)
+	} else if u, err := user.LookupId(name); err == nil {
+		gid, _ = strconv.Atoi(u.Gid)
+	}
+
+	switch {
+	case hasGroup:
+		if gid, err = strconv.Atoi(group); err != nil {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return 0, 0, fmt.Errorf("unknown group %q: %w", group, err)
+			}
+			gid, _ = strconv.Atoi(g.Gid)
+		}
+	case gid < 0:
+		// A numeric user unknown to this system, e.g. one only the container knows
+		gid = uid
+	}
+	return uid, gid, nil
+}
+
+// zipOwner applies owner to the ownership recorded in a zip backup
+func zipOwner(owner ownerFunc) func(*zip.File) (int, int, bool) {
+	if owner == nil {
+		return nil
+	}
+	return func(file *zip.File) (int, int, bool) {
+		return owner(archive.Owner(file))
+	}
+}
+
+// copyAttributes gives every file below dst, a copy of src, the permissions of its
+// original and, unless owner is nil, the owner it picks from the original's
+func copyAttributes(src, dst string, owner ownerFunc) error {
+	if resolved, err := filepath.EvalSymlinks(src); err == nil {
+		src = resolved
+	}
+	return filepath.Walk(dst, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dst, path)
+		if err != nil {
+			return err
+		}
+		orig, err := os.Lstat(filepath.Join(src, rel))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if orig.Mode()&os.ModeSymlink == 0 {
+			perm := orig.Mode().Perm() | 0o200
+			if orig.IsDir() {
+				perm |= 0o700
+			}
+			if err := os.Chmod(path, perm); err != nil {
+				return err
+			}
+		}
+		if owner == nil {
+			return nil
+		}
+		uid, gid, ok := owner(filesystem.Owner(orig))
+		if !ok {
+			return nil
+		}
+		if err := os.Lchown(path, uid, gid); err != nil {
+			return fmt.Errorf("failed to set the owner of %s: %w", path, err)
+		}
+		return nil
+	})
+}
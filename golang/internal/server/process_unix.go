@@ -0,0 +1,87 @@
+//go:build unix
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// exeSuffix is the extension of executables
+const exeSuffix = ""
+
+// scriptCommand returns the command that runs the start script at path with sh
+func scriptCommand(path string, args ...string) (*exec.Cmd, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".sh") {
+		return nil, fmt.Errorf("%s is a Windows start script, set server_jar_name to run.sh instead", filepath.Base(path))
+	}
+	// #nosec G204 -- the script is the configured server_jar_name
+	return exec.Command("/bin/sh", append([]string{path}, args...)...), nil
+}
+
+// isolate makes cmd start in a process group of its own
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessTree kills the process group led by p, so that the java a start script runs
+// goes with it
+func killProcessTree(p *os.Process) error {
+	if err := syscall.Kill(-p.Pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+// sandbox applies the umask and user of cfg to cmd, after isolate
+func sandbox(cmd *exec.Cmd, cfg config.ProcessConfig) error {
+	if cfg.Umask != "" {
+		umask, err := strconv.ParseUint(cfg.Umask, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid server.process.umask %q", cfg.Umask)
+		}
+		// A child cannot be given a umask of its own, so sh sets it and becomes the server
+		cmd.Args = append([]string{"/bin/sh", "-c", fmt.Sprintf(`umask %04o && exec "$0" "$@"`, umask), cmd.Path}, cmd.Args[1:]...)
+		cmd.Path = "/bin/sh"
+	}
+	if cfg.User == "" {
+		return nil
+	}
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("server.process.user needs the updater to run as root")
+	}
+	uid, gid, err := LookupOwner(cfg.User)
+	if err != nil {
+		return err
+	}
+	cred := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)} // #nosec G115 -- IDs come from the user database
+	env := cmd.Env
+	if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+		groups, _ := u.GroupIds()
+		for _, g := range groups {
+			if id, err := strconv.ParseUint(g, 10, 32); err == nil {
+				cred.Groups = append(cred.Groups, uint32(id))
+			}
+		}
+		home := []string{"HOME=" + u.HomeDir, "USER=" + u.Username, "LOGNAME=" + u.Username}
+		if env == nil {
+			// The updater's own HOME and USER would win over the user's otherwise
+			env = append(os.Environ(), home...)
+		} else {
+			// Later entries win, so values set in server.process.env are kept
+			env = append(home, env...)
+		}
+	}
+	cmd.Env = env
+	cmd.SysProcAttr.Credential = cred
+	return nil
+}
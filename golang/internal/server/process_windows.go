@@ -0,0 +1,63 @@
+//go:build windows
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"golang.org/x/sys/windows"
+)
+
+// exeSuffix is the extension of executables
+const exeSuffix = ".exe"
+
+// scriptCommand returns the command that runs the start script at path; Windows runs
+// batch files with cmd.exe
+func scriptCommand(path string, args ...string) (*exec.Cmd, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".bat", ".cmd":
+		// #nosec G204 -- the script is the configured server_jar_name
+		return exec.Command(path, args...), nil
+	}
+	return nil, fmt.Errorf("%s is a shell script, set server_jar_name to run.bat instead", filepath.Base(path))
+}
+
+// isolate makes cmd start in a process group of its own, without a console window when
+// the updater runs as a service
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.CREATE_NO_WINDOW,
+	}
+}
+
+// killProcessTree kills p and every process it started with taskkill, since killing
+// cmd.exe alone leaves the java of a start script running
+func killProcessTree(p *os.Process) error {
+	// #nosec G204 -- the PID is a number
+	out, err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(p.Pid)).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	// Without taskkill, at least the process itself goes
+	if err := p.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("taskkill failed: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// sandbox rejects the settings of cfg that Windows has no equivalent for; the working
+// directory and environment are applied by Start
+func sandbox(_ *exec.Cmd, cfg config.ProcessConfig) error {
+	if cfg.User != "" || cfg.Umask != "" {
+		return fmt.Errorf("server.process.user and umask are not supported on Windows")
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// PropertiesFile is the name of the server's settings file
+const PropertiesFile = "server.properties"
+
+// Defaults the server uses for properties that are not set
+const (
+	DefaultPort       = 25565
+	DefaultRCONPort   = 25575
+	DefaultMaxPlayers = 20
+)
+
+// Properties is a server.properties file. Its lines are kept in order with their comments,
+// so that saving it only changes the values that were set. Values are unescaped.
+type Properties struct {
+	lines []propertyLine
+}
+
+// propertyLine is one line of the file; comments and blank lines have no key
+type propertyLine struct {
+	raw   string // the line as read, written back while the value is unchanged
+	key   string
+	value string
+}
+
+// ParseProperties reads the key=value (or key:value) lines of data. Line continuations
+// are not supported; Minecraft never writes them.
+func ParseProperties(data []byte) *Properties {
+	p := &Properties{}
+	text := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if text == "" {
+		return p
+	}
+	for _, raw := range strings.Split(text, "\n") {
+		line := propertyLine{raw: raw}
+		trimmed := strings.TrimLeft(raw, " \t\f")
+		if trimmed != "" && trimmed[0] != '#' && trimmed[0] != '!' {
+			key, value := splitProperty(trimmed)
+			line.key, line.value = unescapeProperty(key), unescapeProperty(value)
+		}
+		p.lines = append(p.lines, line)
+	}
+	return p
+}
+
+// splitProperty splits a line at the first unescaped '=', ':' or whitespace
+func splitProperty(line string) (key, value string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':', ' ', '\t', '\f':
+			key, value = line[:i], strings.TrimLeft(line[i:], " \t\f")
+			if value != "" && (value[0] == '=' || value[0] == ':') {
+				value = strings.TrimLeft(value[1:], " \t\f")
+			}
+			return key, value
+		}
+	}
+	return line, ""
+}
+
+// LoadProperties reads server.properties in serverPath. The error wraps os.ErrNotExist
+// when the file is missing.
+func LoadProperties(serverPath string) (*Properties, error) {
+	data, err := os.ReadFile(filepath.Join(serverPath, PropertiesFile)) // #nosec G304 -- fixed name in the server directory
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", PropertiesFile, err)
+	}
+	return ParseProperties(data), nil
+}
+
+// Bytes returns the file with the values that were set
+func (p *Properties) Bytes() []byte {
+	var b strings.Builder
+	for _, line := range p.lines {
+		b.WriteString(line.raw)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+// Save writes the file to serverPath
+func (p *Properties) Save(serverPath string) error {
+	if err := os.WriteFile(filepath.Join(serverPath, PropertiesFile), p.Bytes(), 0o644); err != nil { // #nosec G306 -- read by the server
+		return fmt.Errorf("failed to write %s: %w", PropertiesFile, err)
+	}
+	return nil
+}
+
+// Get returns the value of key and whether it is set
+func (p *Properties) Get(key string) (string, bool) {
+	for i := len(p.lines) - 1; i >= 0; i-- {
+		// Like Java, the last of duplicate keys wins
+		if p.lines[i].key == key {
+			return p.lines[i].value, true
+		}
+	}
+	return "", false
+}
+
+// String returns the value of key, or def when it is not set
+func (p *Properties) String(key, def string) string {
+	if v, ok := p.Get(key); ok {
+		return v
+	}
+	return def
+}
+
+// Int returns the value of key, or def when it is not set or not a number
+func (p *Properties) Int(key string, def int) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(p.String(key, ""))); err == nil {
+		return n
+	}
+	return def
+}
+
+// Bool returns the value of key, or def when it is not set or not true or false
+func (p *Properties) Bool(key string, def bool) bool {
+	if b, err := parsePropertyBool(p.String(key, "")); err == nil {
+		return b
+	}
+	return def
+}
+
+// Set sets key to value in place, or appends it when the file does not have it yet
+func (p *Properties) Set(key, value string) {
+	raw := escapePropertyKey(key) + "=" + escapeProperty(value)
+	found := false
+	for i := range p.lines {
+		if p.lines[i].key == key {
+			if p.lines[i].value != value {
+				p.lines[i].raw, p.lines[i].value = raw, value
+			}
+			found = true
+		}
+	}
+	if !found {
+		p.lines = append(p.lines, propertyLine{raw: raw, key: key, value: value})
+	}
+}
+
+// SetInt sets key to n
+func (p *Properties) SetInt(key string, n int) {
+	p.Set(key, strconv.Itoa(n))
+}
+
+// SetBool sets key to true or false
+func (p *Properties) SetBool(key string, b bool) {
+	p.Set(key, strconv.FormatBool(b))
+}
+
+// Keys returns the keys in the order of the file
+func (p *Properties) Keys() []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, line := range p.lines {
+		if line.key != "" && !seen[line.key] {
+			seen[line.key] = true
+			keys = append(keys, line.key)
+		}
+	}
+	return keys
+}
+
+// Map returns every value by key
+func (p *Properties) Map() map[string]string {
+	m := make(map[string]string, len(p.lines))
+	for _, line := range p.lines {
+		if line.key != "" {
+			m[line.key] = line.value
+		}
+	}
+	return m
+}
+
+// Port is the port the server listens on
+func (p *Properties) Port() int { return p.Int("server-port", DefaultPort) }
+
+// LevelName is the world folder
+func (p *Properties) LevelName() string { return p.String("level-name", defaultLevelName) }
+
+// MOTD is the message in the server list
+func (p *Properties) MOTD() string { return p.String("motd", "A Minecraft Server") }
+
+// MaxPlayers is how many players can be online at once
+func (p *Properties) MaxPlayers() int { return p.Int("max-players", DefaultMaxPlayers) }
+
+// OnlineMode reports whether players are authenticated with Mojang
+func (p *Properties) OnlineMode() bool { return p.Bool("online-mode", true) }
+
+// Whitelist reports whether only whitelisted players can join
+func (p *Properties) Whitelist() bool { return p.Bool("white-list", false) }
+
+// RCONEnabled reports whether the server has a remote console
+func (p *Properties) RCONEnabled() bool { return p.Bool("enable-rcon", false) }
+
+// RCONPort is the port of the remote console
+func (p *Properties) RCONPort() int { return p.Int("rcon.port", DefaultRCONPort) }
+
+// RCONPassword is the password of the remote console
+func (p *Properties) RCONPassword() string { return p.String("rcon.password", "") }
+
+// propertyRule checks the value of one property
+type propertyRule func(value string) error
+
+func boolRule(value string) error {
+	_, err := parsePropertyBool(value)
+	return err
+}
+
+func intRule(lo, hi int) propertyRule {
+	return func(value string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("must be a whole number")
+		}
+		if n < lo || n > hi {
+			if hi == math.MaxInt32 {
+				return fmt.Errorf("must be at least %d", lo)
+			}
+			return fmt.Errorf("must be between %d and %d", lo, hi)
+		}
+		return nil
+	}
+}
+
+// enumRule accepts one of values, or its index as older servers wrote it
+func enumRule(values ...string) propertyRule {
+	return func(value string) error {
+		v := strings.ToLower(strings.TrimSpace(value))
+		for i, allowed := range values {
+			if v == allowed || v == strconv.Itoa(i) {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(values, ", "))
+	}
+}
+
+var portRule = intRule(1, 65535)
+
+// propertyRules are the checks for the vanilla properties that have a type; the rest,
+// e.g. motd, level-seed or mod-specific keys, take any value
+var propertyRules = map[string]propertyRule{
+	"server-port":                       portRule,
+	"query.port":                        portRule,
+	"rcon.port":                         portRule,
+	"max-players":                       intRule(0, math.MaxInt32),
+	"view-distance":                     intRule(2, 32),
+	"simulation-distance":               intRule(2, 32),
+	"spawn-protection":                  intRule(0, math.MaxInt32),
+	"op-permission-level":               intRule(0, 4),
+	"function-permission-level":         intRule(1, 4),
+	"max-world-size":                    intRule(1, 29999984),
+	"entity-broadcast-range-percentage": intRule(10, 1000),
+	"network-compression-threshold":     intRule(-1, math.MaxInt32),
+	"max-tick-time":                     intRule(-1, math.MaxInt32),
+	"player-idle-timeout":               intRule(0, math.MaxInt32),
+	"rate-limit":                        intRule(0, math.MaxInt32),
+	"difficulty":                        enumRule("peaceful", "easy", "normal", "hard"),
+	"gamemode":                          enumRule("survival", "creative", "adventure", "spectator"),
+}
+
+func init() {
+	for _, key := range []string{
+		"accepts-transfers", "allow-flight", "allow-nether", "broadcast-console-to-ops",
+		"broadcast-rcon-to-ops", "enable-command-block", "enable-jmx-monitoring", "enable-query",
+		"enable-rcon", "enable-status", "enforce-secure-profile", "enforce-whitelist",
+		"force-gamemode", "generate-structures", "hardcore", "hide-online-players", "log-ips",
+		"online-mode", "prevent-proxy-connections", "pvp", "require-resource-pack",
+		"spawn-animals", "spawn-monsters", "spawn-npcs", "sync-chunk-writes",
+		"use-native-transport", "white-list",
+	} {
+		propertyRules[key] = boolRule
+	}
+}
+
+// Validate checks the values of the known properties and returns every problem
+func (p *Properties) Validate() error {
+	var errs []error
+	keys := p.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		rule := propertyRules[key]
+		if rule == nil {
+			continue
+		}
+		value, _ := p.Get(key)
+		// An empty value makes the server use its default
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		if err := rule(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s %w, got %q", key, err, value))
+		}
+	}
+	if p.RCONEnabled() && p.RCONPassword() == "" {
+		errs = append(errs, fmt.Errorf("enable-rcon needs an rcon.password, the server does not start RCON without one"))
+	}
+	return errors.Join(errs...)
+}
+
+// parsePropertyBool accepts true and false in any case, unlike Java, which reads every
+// other value as false
+func parsePropertyBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("must be true or false")
+}
+
+// unescapeProperty resolves the escapes of a Java properties file
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	var surrogate rune
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				b.WriteString(`\u`)
+				continue
+			}
+			n, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				b.WriteString(`\u`)
+				continue
+			}
+			i += 4
+			r := rune(n)
+			switch {
+			case utf16.IsSurrogate(r) && surrogate == 0:
+				surrogate = r
+				continue
+			case surrogate != 0:
+				r = utf16.DecodeRune(surrogate, r)
+				surrogate = 0
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// escapePropertyKey escapes a key, in which separators must be escaped too
+func escapePropertyKey(key string) string {
+	return strings.NewReplacer("=", `\=`, ":", `\:`, " ", `\ `).Replace(escapeProperty(key))
+}
+
+// escapeProperty escapes a value the way Java properties files expect, with characters
+// outside ASCII, e.g. the § of color codes, as \uXXXX
+func escapeProperty(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r > 0xFFFF:
+			// Outside the Basic Multilingual Plane, as a UTF-16 surrogate pair
+			hi, lo := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, `\u%04X\u%04X`, hi, lo)
+		case r > 0x7E:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
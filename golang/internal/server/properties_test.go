@@ -0,0 +1,85 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleProperties = `#Minecraft server properties
+#Mon Jan 01 12:00:00 UTC 2024
+motd=§6Survival\: season 2
+server-port = 25570
+level-name=world
+white-list=true
+# set by the admin
+max-players=40
+emoji=😀
+`
+
+func TestParseProperties(t *testing.T) {
+	p := ParseProperties([]byte(sampleProperties))
+	if got := p.MOTD(); got != "§6Survival: season 2" {
+		t.Errorf("motd = %q", got)
+	}
+	if got, _ := p.Get("emoji"); got != "😀" {
+		t.Errorf("emoji = %q", got)
+	}
+	if p.Port() != 25570 || p.MaxPlayers() != 40 || !p.Whitelist() || p.LevelName() != "world" {
+		t.Errorf("port %d, max players %d, whitelist %v, level %q", p.Port(), p.MaxPlayers(), p.Whitelist(), p.LevelName())
+	}
+	// Unset values fall back to the server's defaults
+	if !p.OnlineMode() || p.RCONEnabled() || p.RCONPort() != DefaultRCONPort {
+		t.Errorf("online mode %v, rcon %v on %d", p.OnlineMode(), p.RCONEnabled(), p.RCONPort())
+	}
+	if keys := strings.Join(p.Keys(), ","); keys != "motd,server-port,level-name,white-list,max-players,emoji" {
+		t.Errorf("keys = %s", keys)
+	}
+	// Unchanged files are written back byte for byte
+	if string(p.Bytes()) != sampleProperties {
+		t.Errorf("round trip changed the file:\n%s", p.Bytes())
+	}
+}
+
+func TestPropertiesSet(t *testing.T) {
+	p := ParseProperties([]byte(sampleProperties))
+	p.SetInt("max-players", 60)
+	p.SetBool("enable-rcon", true)
+	p.Set("motd", "§6Survival: season 3")
+	p.Set("level-name", "world") // unchanged, so the line is kept as is
+
+	want := strings.NewReplacer(
+		`max-players=40`, `max-players=60`,
+		`motd=§6Survival\: season 2`, `motd=\u00A76Survival: season 3`,
+	).Replace(sampleProperties) + "enable-rcon=true\n"
+	if got := string(p.Bytes()); got != want {
+		t.Errorf("file =\n%s\nwant\n%s", got, want)
+	}
+	if again := ParseProperties(p.Bytes()); again.MOTD() != "§6Survival: season 3" || again.MaxPlayers() != 60 {
+		t.Errorf("reparsed motd %q, max players %d", again.MOTD(), again.MaxPlayers())
+	}
+}
+
+func TestPropertiesValidate(t *testing.T) {
+	if err := ParseProperties([]byte(sampleProperties)).Validate(); err != nil {
+		t.Fatalf("valid file: %v", err)
+	}
+	// Empty values and older numeric enums are accepted
+	if err := ParseProperties([]byte("max-players=\ndifficulty=2\ngamemode=creative\n")).Validate(); err != nil {
+		t.Fatalf("valid file: %v", err)
+	}
+
+	err := ParseProperties([]byte("server-port=70000\nonline-mode=yes\ndifficulty=nightmare\nenable-rcon=true\n")).Validate()
+	if err == nil {
+		t.Fatal("expected invalid values to be reported")
+	}
+	for _, want := range []string{
+		`server-port must be between 1 and 65535, got "70000"`,
+		`online-mode must be true or false, got "yes"`,
+		`difficulty must be one of peaceful, easy, normal, hard, got "nightmare"`,
+		"enable-rcon needs an rcon.password",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
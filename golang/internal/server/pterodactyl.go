@@ -0,0 +1,193 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
+)
+
+// pterodactylPollInterval is how often the server state is polled while waiting for a stop
+const pterodactylPollInterval = 2 * time.Second
+
+// PterodactylServer controls a server on a Pterodactyl or Pelican panel through the client
+// API, and uploads installed files to it
+type PterodactylServer struct {
+	baseURL      string
+	apiKey       string
+	serverID     string
+	client       *http.Client
+	uploadClient *http.Client
+	pollInterval time.Duration
+}
+
+// pterodactylResources is the part of GET /api/client/servers/{id}/resources that is used
+type pterodactylResources struct {
+	Attributes struct {
+		CurrentState string `json:"current_state"`
+		Resources    struct {
+			Uptime      int64   `json:"uptime"` // milliseconds
+			CPUAbsolute float64 `json:"cpu_absolute"`
+			MemoryBytes uint64  `json:"memory_bytes"`
+		} `json:"resources"`
+	} `json:"attributes"`
+}
+
+// NewPterodactylServer controls the server in cfg, sending requests with the HTTP settings
+// in httpCfg
+func NewPterodactylServer(cfg config.PterodactylConfig, httpCfg config.HTTPConfig) *PterodactylServer {
+	return &PterodactylServer{
+		baseURL:      strings.TrimSuffix(cfg.PanelURL, "/") + "/api/client/servers/" + url.PathEscape(cfg.ServerID),
+		apiKey:       cfg.APIKey,
+		serverID:     cfg.ServerID,
+		client:       httpclient.New(httpCfg),
+		uploadClient: httpclient.NewDownload(httpCfg),
+		pollInterval: pterodactylPollInterval,
+	}
+}
+
+// Start sends the start power action
+func (p *PterodactylServer) Start() error {
+	if p.IsRunning() {
+		return fmt.Errorf("server is already running")
+	}
+	return p.power("start")
+}
+
+// Stop sends the stop power action and kills the server when it is still up after timeout
+func (p *PterodactylServer) Stop(timeout time.Duration) error {
+	if !p.IsRunning() {
+		return fmt.Errorf("server is not running")
+	}
+	if err := p.power("stop"); err != nil {
+		return err
+	}
+	if timeout <= 0 {
+		return nil
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if state, err := p.state(); err == nil && state == "offline" {
+			return nil
+		}
+		time.Sleep(p.pollInterval)
+	}
+	return p.power("kill")
+}
+
+// Restart sends the restart power action
+func (p *PterodactylServer) Restart(timeout time.Duration) error {
+	return p.power("restart")
+}
+
+// IsRunning reports whether the panel shows the server as starting or running
+func (p *PterodactylServer) IsRunning() bool {
+	state, err := p.state()
+	return err == nil && (state == "running" || state == "starting")
+}
+
+// GetUptime returns the uptime reported by the panel
+func (p *PterodactylServer) GetUptime() time.Duration {
+	res, err := p.resources()
+	if err != nil || res.Attributes.CurrentState == "offline" {
+		return 0
+	}
+	return time.Duration(res.Attributes.Resources.Uptime) * time.Millisecond
+}
+
+// Upload writes files, given relative to localDir with forward slashes, to the same paths
+// on the panel server
+func (p *PterodactylServer) Upload(localDir string, files []string) error {
+	for _, name := range files {
+		// #nosec G304 -- files were just installed into localDir by the updater
+		f, err := os.Open(filepath.Join(localDir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		resp, err := p.do(p.uploadClient, http.MethodPost, "/files/write?file="+url.QueryEscape(path.Join("/", name)), f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %w", name, err)
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+// state returns the panel's current_state: offline, starting, running or stopping
+func (p *PterodactylServer) state() (string, error) {
+	res, err := p.resources()
+	if err != nil {
+		return "", err
+	}
+	return res.Attributes.CurrentState, nil
+}
+
+// resources fetches the live state of the server
+func (p *PterodactylServer) resources() (*pterodactylResources, error) {
+	resp, err := p.do(p.client, http.MethodGet, "/resources", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var res pterodactylResources
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decode server resources: %w", err)
+	}
+	return &res, nil
+}
+
+// power sends a power signal: start, stop, restart or kill
+func (p *PterodactylServer) power(signal string) error {
+	body, err := json.Marshal(map[string]string{"signal": signal})
+	if err != nil {
+		return err
+	}
+	resp, err := p.do(p.client, http.MethodPost, "/power", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send %s: %w", signal, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends an authenticated request and turns panel errors into Go errors
+func (p *PterodactylServer) do(client *http.Client, method, endpoint string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, p.baseURL+endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if strings.HasSuffix(endpoint, "/power") {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("panel request failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Errors []struct {
+				Detail string `json:"detail"`
+			} `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if len(apiErr.Errors) > 0 && apiErr.Errors[0].Detail != "" {
+			return nil, fmt.Errorf("panel: %s", apiErr.Errors[0].Detail)
+		}
+		return nil, fmt.Errorf("panel: %s %s failed with status %d", method, endpoint, resp.StatusCode)
+	}
+	return resp, nil
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func TestPterodactylServer(t *testing.T) {
+	state := "offline"
+	var signals []string
+	uploads := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ptlc_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errors":[{"detail":"Unauthenticated."}]}`))
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/client/servers/1a7ce997/resources":
+			_, _ = w.Write([]byte(`{"attributes":{"current_state":"` + state + `","resources":{"uptime":90000}}}`))
+		case "POST /api/client/servers/1a7ce997/power":
+			var body struct{ Signal string }
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			signals = append(signals, body.Signal)
+			switch body.Signal {
+			case "start":
+				state = "running"
+			case "kill":
+				state = "offline"
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /api/client/servers/1a7ce997/files/write":
+			data, _ := io.ReadAll(r.Body)
+			uploads[r.URL.Query().Get("file")] = string(data)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewPterodactylServer(config.PterodactylConfig{PanelURL: srv.URL + "/", APIKey: "ptlc_test", ServerID: "1a7ce997"}, config.HTTPConfig{})
+	p.pollInterval = time.Millisecond
+	var _ Controller = p
+
+	if err := p.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if !p.IsRunning() || p.GetUptime() != 90*time.Second {
+		t.Fatalf("expected a running server with 90s uptime, got %s", p.GetUptime())
+	}
+	// The server ignores stop, so it is killed once the timeout passes
+	if err := p.Stop(10 * time.Millisecond); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if strings.Join(signals, ",") != "start,stop,kill" {
+		t.Fatalf("signals = %v", signals)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "mods"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "mods", "a.jar"), []byte("v2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Upload(dir, []string{"mods/a.jar"}); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if uploads["/mods/a.jar"] != "v2" {
+		t.Fatalf("uploads = %v", uploads)
+	}
+
+	p.apiKey = "wrong"
+	if err := p.Restart(0); err == nil || !strings.Contains(err.Error(), "Unauthenticated.") {
+		t.Fatalf("Restart err = %v, want the panel's error", err)
+	}
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/rcon"
+)
+
+// cpuSampleInterval is how long CPU time is measured for a CPU usage sample
+const cpuSampleInterval = 500 * time.Millisecond
+
+// clockTicks is USER_HZ, the unit of the CPU times in /proc, which is 100 on every
+// Linux platform
+const clockTicks = 100
+
+// procRoot is where the proc filesystem is mounted; replaced in tests
+var procRoot = "/proc"
+
+// ErrNotRunning is returned when resources are sampled, or console commands sent, while
+// the server is stopped
+var ErrNotRunning = errors.New("server is not running")
+
+// Resources is a sample of what the server uses
+type Resources struct {
+	CPUPercent       float64 `json:"cpu_percent"` // of one core, so a busy server can exceed 100
+	MemoryBytes      uint64  `json:"memory_bytes"`
+	MemoryLimitBytes uint64  `json:"memory_limit_bytes,omitempty"` // 0 when there is no known limit
+	TPS              float64 `json:"tps,omitempty"`                // ticks per second over RCON, 0 when unknown
+}
+
+// ResourceReporter is implemented by controllers that can sample the CPU and memory
+// usage of the server
+type ResourceReporter interface {
+	Resources() (*Resources, error)
+}
+
+// SampleResources samples the CPU and memory of the server through c, when it can report
+// them, and its TPS when server.rcon is set. c may be nil to only query the TPS. A TPS
+// that cannot be read is left at 0.
+func SampleResources(cfg *config.Config, c Controller) (*Resources, error) {
+	reporter, ok := c.(ResourceReporter)
+	if !ok && cfg.Server.RCON.Address == "" {
+		return nil, fmt.Errorf("server.mode %q cannot report resources and server.rcon is not set", cfg.Server.Mode)
+	}
+	res := &Resources{}
+	if ok {
+		sample, err := reporter.Resources()
+		if err != nil {
+			return nil, err
+		}
+		res = sample
+	}
+	if cfg.Server.RCON.Address != "" {
+		if tps, err := NewRCONCommander(cfg.Server.RCON).TPS(); err == nil {
+			res.TPS = tps
+		}
+	}
+	return res, nil
+}
+
+// tpsCommands are tried in order until one reports the TPS: Forge, NeoForge and the
+// spark profiler
+var tpsCommands = []string{"forge tps", "neoforge tps", "spark tps"}
+
+var (
+	// formatCodes are the § color and style codes in console output
+	formatCodes = regexp.MustCompile(`§.`)
+	// Forge: "Overall: Mean tick time: 2.503 ms. Mean TPS: 20.000"
+	// NeoForge: "Overall: 20.000 TPS (2.503 ms/tick)"
+	forgeTPS = regexp.MustCompile(`Overall:.*?(?:Mean TPS: ([\d.]+)|([\d.]+) TPS)`)
+	// spark: "[⚡] TPS from last 5s, 10s, 1m, 5m, 15m:\n[⚡]  *20.0, 20.0, ..."
+	sparkTPS = regexp.MustCompile(`TPS from last[^:]*:[^\d*]*\*?([\d.]+)`)
+)
+
+// TPS asks the server for its ticks per second with the first of tpsCommands it knows
+func (r *RCONCommander) TPS() (float64, error) {
+	c, err := rcon.Dial(r.cfg.Address, r.cfg.Password, rconTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	for _, command := range tpsCommands {
+		out, err := c.Command(command)
+		if err != nil {
+			return 0, err
+		}
+		if tps, ok := parseTPS(out); ok {
+			return tps, nil
+		}
+	}
+	return 0, fmt.Errorf("the server knows none of %s", strings.Join(tpsCommands, ", "))
+}
+
+// parseTPS finds the overall TPS in the output of one of tpsCommands
+func parseTPS(out string) (float64, bool) {
+	out = formatCodes.ReplaceAllString(out, "")
+	var value string
+	if m := forgeTPS.FindStringSubmatch(out); m != nil {
+		value = m[1] + m[2]
+	} else if m := sparkTPS.FindStringSubmatch(out); m != nil {
+		value = m[1]
+	}
+	tps, err := strconv.ParseFloat(value, 64)
+	return tps, err == nil
+}
+
+// Resources samples the server process and the processes it started, e.g. java when the
+// start command is a script
+func (s *MinecraftServer) Resources() (*Resources, error) {
+	s.mu.RLock()
+	running, process := s.isRunning, s.process
+	s.mu.RUnlock()
+	if !running || process == nil || process.Process == nil {
+		return nil, ErrNotRunning
+	}
+	return processResources(process.Process.Pid)
+}
+
+// Resources samples the main process of the unit and the processes it started
+func (s *SystemdServer) Resources() (*Resources, error) {
+	out, err := s.run(context.Background(), "systemctl", s.args("show", s.unit, "--property=MainPID", "--value")...)
+	if err != nil {
+		return nil, fmt.Errorf("systemctl show %s: %w", s.unit, err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil || pid == 0 {
+		return nil, ErrNotRunning
+	}
+	return processResources(pid)
+}
+
+// processResources measures the CPU usage of pid and its descendants over
+// cpuSampleInterval and returns it with their resident memory
+func processResources(pid int) (*Resources, error) {
+	before, _, err := processTreeUsage(pid)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	time.Sleep(cpuSampleInterval)
+	after, rss, err := processTreeUsage(pid)
+	if err != nil {
+		return nil, err
+	}
+	cpu := float64(after-before) / clockTicks / time.Since(start).Seconds() * 100
+	return &Resources{CPUPercent: max(0, cpu), MemoryBytes: rss}, nil
+}
+
+// processTreeUsage returns the CPU time in clock ticks and the resident memory in bytes of
+// pid and all of its descendants
+func processTreeUsage(pid int) (ticks, rss uint64, err error) {
+	stat, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, ErrNotRunning
+		}
+		return 0, 0, fmt.Errorf("failed to read process stats: %w", err)
+	}
+	// The command name in parentheses may contain spaces, so fields are counted after it
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	if len(fields) < 22 {
+		return 0, 0, fmt.Errorf("unexpected format of %s/%d/stat", procRoot, pid)
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	pages, _ := strconv.ParseUint(fields[21], 10, 64)
+	ticks, rss = utime+stime, pages*uint64(os.Getpagesize())
+
+	children, _ := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "task", strconv.Itoa(pid), "children"))
+	for _, field := range strings.Fields(string(children)) {
+		child, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		// A child that exits in between is simply not counted
+		if t, r, err := processTreeUsage(child); err == nil {
+			ticks, rss = ticks+t, rss+r
+		}
+	}
+	return ticks, rss, nil
+}
+
+// dockerStats is the part of GET /containers/{id}/stats that is used
+type dockerStats struct {
+	CPUStats    dockerCPUStats `json:"cpu_stats"`
+	PreCPUStats dockerCPUStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+}
+
+type dockerCPUStats struct {
+	CPUUsage struct {
+		TotalUsage uint64 `json:"total_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint64 `json:"online_cpus"`
+}
+
+// Resources reads the container's stats, computed the way `docker stats` does
+func (d *DockerServer) Resources() (*Resources, error) {
+	if !d.IsRunning() {
+		return nil, ErrNotRunning
+	}
+	resp, err := d.do(context.Background(), http.MethodGet, "/stats", url.Values{"stream": {"false"}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var stats dockerStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode container stats: %w", err)
+	}
+
+	res := &Resources{MemoryBytes: stats.MemoryStats.Usage, MemoryLimitBytes: stats.MemoryStats.Limit}
+	// Page cache is reclaimable, so it is not counted: inactive_file on cgroup v2, cache on v1
+	if cache, ok := stats.MemoryStats.Stats["inactive_file"]; ok && cache < res.MemoryBytes {
+		res.MemoryBytes -= cache
+	} else if cache, ok := stats.MemoryStats.Stats["cache"]; ok && cache < res.MemoryBytes {
+		res.MemoryBytes -= cache
+	}
+	cpu := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	system := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpu > 0 && system > 0 {
+		res.CPUPercent = cpu / system * float64(max(1, stats.CPUStats.OnlineCPUs)) * 100
+	}
+	return res, nil
+}
+
+// Resources reads the usage the panel reports for the server
+func (p *PterodactylServer) Resources() (*Resources, error) {
+	res, err := p.resources()
+	if err != nil {
+		return nil, err
+	}
+	if res.Attributes.CurrentState == "offline" {
+		return nil, ErrNotRunning
+	}
+	r := res.Attributes.Resources
+	return &Resources{CPUPercent: r.CPUAbsolute, MemoryBytes: r.MemoryBytes}, nil
+}
+
+// Exceeds describes every threshold of cfg that r crosses, or returns nil when it is
+// within all of them
+func (r *Resources) Exceeds(cfg config.MonitorConfig) []string {
+	var problems []string
+	if cfg.MaxCPUPercent > 0 && r.CPUPercent > cfg.MaxCPUPercent {
+		problems = append(problems, fmt.Sprintf("CPU at %.0f%%, above %.0f%%", r.CPUPercent, cfg.MaxCPUPercent))
+	}
+	if limit := cfg.MaxMemoryBytes(); limit > 0 && r.MemoryBytes > uint64(limit) {
+		problems = append(problems, fmt.Sprintf("memory at %s, above %s", filesystem.FormatBytes(int64(r.MemoryBytes)), filesystem.FormatBytes(limit)))
+	}
+	if cfg.MinTPS > 0 && r.TPS > 0 && r.TPS < cfg.MinTPS {
+		problems = append(problems, fmt.Sprintf("TPS at %.1f, below %.1f", r.TPS, cfg.MinTPS))
+	}
+	return problems
+}
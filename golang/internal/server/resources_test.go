@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func TestParseTPS(t *testing.T) {
+	for _, tc := range []struct {
+		out  string
+		want float64
+		ok   bool
+	}{
+		{"Dim 0 (overworld): Mean tick time: 2.1 ms. Mean TPS: 20.000\nOverall: Mean tick time: 2.503 ms. Mean TPS: 19.500", 19.5, true},
+		{"Overall: 18.250 TPS (4.100 ms/tick)", 18.25, true},
+		{"§8[§e⚡§8] §7TPS from last 5s, 10s, 1m, 5m, 15m:\n§8[§e⚡§8]  §a*20.0, §a20.0, §a19.9, §a19.9, §a19.8", 20, true},
+		{"Unknown or incomplete command, see below for error", 0, false},
+	} {
+		got, ok := parseTPS(tc.out)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("parseTPS(%q) = %v, %v; want %v, %v", tc.out, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestProcessTreeUsage(t *testing.T) {
+	root := t.TempDir()
+	procRoot = root
+	defer func() { procRoot = "/proc" }()
+
+	write := func(pid, stat, children string) {
+		dir := filepath.Join(root, pid, "task", pid)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.WriteFile(filepath.Join(root, pid, "stat"), []byte(stat), 0o644)
+		_ = os.WriteFile(filepath.Join(dir, "children"), []byte(children), 0o644)
+	}
+	// utime, stime and rss are fields 14, 15 and 24
+	write("10", "10 (start script.sh) S 1 10 10 0 -1 0 0 0 0 0 5 5 0 0 20 0 1 0 100 0 100", "11 ")
+	write("11", "11 (java) S 10 10 10 0 -1 0 0 0 0 0 300 100 0 0 20 0 40 0 100 0 2000", "")
+
+	ticks, rss, err := processTreeUsage(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticks != 410 {
+		t.Errorf("ticks = %d, want 410", ticks)
+	}
+	if want := uint64(2100 * os.Getpagesize()); rss != want {
+		t.Errorf("rss = %d, want %d", rss, want)
+	}
+	if _, _, err := processTreeUsage(99); err != ErrNotRunning {
+		t.Errorf("missing process: %v, want ErrNotRunning", err)
+	}
+}
+
+func TestDockerResources(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/mc/json":
+			_, _ = w.Write([]byte(`{"State":{"Running":true}}`))
+		case "/containers/mc/stats":
+			_, _ = w.Write([]byte(`{
+				"cpu_stats": {"cpu_usage": {"total_usage": 3000}, "system_cpu_usage": 20000, "online_cpus": 4},
+				"precpu_stats": {"cpu_usage": {"total_usage": 1000}, "system_cpu_usage": 10000},
+				"memory_stats": {"usage": 5000, "limit": 8000, "stats": {"inactive_file": 1000}}
+			}`))
+		}
+	}))
+	defer srv.Close()
+
+	d, err := NewDockerServer("tcp://"+strings.TrimPrefix(srv.URL, "http://"), "mc", "/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := d.Resources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CPUPercent != 80 || res.MemoryBytes != 4000 || res.MemoryLimitBytes != 8000 {
+		t.Errorf("resources = %+v, want 80%% CPU and 4000 of 8000 bytes", res)
+	}
+}
+
+func TestResourcesExceeds(t *testing.T) {
+	cfg := config.MonitorConfig{MaxCPUPercent: 300, MaxMemory: "1KB", MinTPS: 15}
+	if p := (&Resources{CPUPercent: 120, MemoryBytes: 512, TPS: 20}).Exceeds(cfg); p != nil {
+		t.Errorf("healthy server reported %q", p)
+	}
+	p := (&Resources{CPUPercent: 350, MemoryBytes: 2048, TPS: 12.5}).Exceeds(cfg)
+	if len(p) != 3 || p[2] != "TPS at 12.5, below 15.0" {
+		t.Errorf("problems = %q", p)
+	}
+	// An unknown TPS is not below the threshold
+	if p := (&Resources{}).Exceeds(cfg); p != nil {
+		t.Errorf("empty sample reported %q", p)
+	}
+}
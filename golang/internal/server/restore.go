@@ -0,0 +1,320 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/klauspost/compress/zip"
+)
+
+// BackupTypePreRestore marks the snapshot of the whole server taken before a full restore
+const BackupTypePreRestore = "pre-restore"
+
+// RestoreOptions controls how a backup is restored
+type RestoreOptions struct {
+	// Snapshot backs up the files about to be replaced first, so a restore can be undone
+	Snapshot bool
+}
+
+// RestoreResult describes a completed restore
+type RestoreResult struct {
+	Backup   string
+	Snapshot string // file name of the pre-restore snapshot, empty when none was taken
+}
+
+// RestorePlan lists what restoring a backup would change, relative to the server path
+type RestorePlan struct {
+	Backup  string
+	Type    string
+	Added   []string // in the backup but not on the server
+	Changed []string // on both, with different content
+	Removed []string // on the server but not in the backup, deleted by the restore
+}
+
+// fileSum identifies the content of a file without keeping it
+type fileSum struct {
+	size int64
+	crc  uint32
+}
+
+// Restore replaces the server files with a backup. The backup is first extracted next
+// to the server directory and only then swapped in, so a failed extraction leaves the
+// server untouched. World backups only replace the world folders they contain. Restored
+// files get back their recorded permissions and, when running as root, their owner or the
+// one set with SetRestoreOwner. A backup that no longer matches its recorded checksum is
+// not restored, and encrypted backups are decrypted first, see SetEncryption.
+func (bm *BackupManager) Restore(backupName string, opts RestoreOptions) (*RestoreResult, error) {
+	backup, err := bm.GetBackupInfo(backupName)
+	if err != nil {
+		return nil, err
+	}
+	if backup.Type != BackupTypeWorld && bm.backupInsideServer() {
+		return nil, fmt.Errorf("backup path %s is inside the server directory; a full restore would replace it", bm.backupPath)
+	}
+	if err := verifyChecksum(backup); err != nil {
+		return nil, err
+	}
+
+	// Stage next to the server directory so the swap is a rename on the same filesystem
+	staging := filepath.Clean(bm.serverPath) + ".restoring"
+	if err := filesystem.RemoveDir(staging); err != nil {
+		return nil, err
+	}
+	if err := filesystem.EnsureDir(staging); err != nil {
+		return nil, fmt.Errorf("failed to create restore staging directory: %w", err)
+	}
+	defer func() {
+		if err := filesystem.RemoveDir(staging); err != nil {
+			bm.logger.Warn("failed to remove restore staging directory", "path", staging, "error", err)
+		}
+	}()
+
+	owner, err := bm.restoredOwner()
+	if err != nil {
+		return nil, err
+	}
+	if backup.IsCompressed {
+		var path string
+		var done func()
+		if path, done, err = bm.openArchive(backup); err == nil {
+			err = bm.extractBackup(path, staging, owner)
+			done()
+		}
+	} else if err = filesystem.CopyDir(backup.Path, staging); err == nil {
+		err = copyAttributes(backup.Path, staging, owner)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract backup: %w", err)
+	}
+	if err := bm.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Full restores swap the whole directory, world restores each world folder
+	var dirs []string
+	if backup.Type == BackupTypeWorld {
+		if dirs, err = topLevelDirs(staging); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &RestoreResult{Backup: backupName}
+	if opts.Snapshot && filesystem.DirExists(bm.serverPath) {
+		snapshot, err := bm.createSnapshot(dirs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pre-restore snapshot: %w", err)
+		}
+		if snapshot != nil {
+			result.Snapshot = filepath.Base(snapshot.Path)
+		}
+	}
+
+	// The last point to stop at: from here on the server files are replaced
+	if err := bm.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if dirs == nil {
+		err = bm.swapDir(staging, bm.serverPath)
+	} else {
+		for _, dir := range dirs {
+			if err = bm.swapDir(filepath.Join(staging, dir), filepath.Join(bm.serverPath, dir)); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bm.logger.Info("backup restored", "backup", backupName, "type", backup.Type, "server_path", bm.serverPath, "snapshot", result.Snapshot)
+	return result, nil
+}
+
+// createSnapshot backs up dirs, or the whole server when dirs is nil, before they are replaced.
+// A snapshot of world folders is itself a world backup so restoring it leaves the rest alone.
+// It returns nil when none of the world folders exist yet.
+func (bm *BackupManager) createSnapshot(dirs []string) (*BackupInfo, error) {
+	name := fmt.Sprintf("pre_restore_%s", bm.clock.Now().Format("20060102_150405"))
+	if dirs == nil {
+		return bm.createBackup(name, BackupTypePreRestore, nil)
+	}
+
+	var existing []string
+	for _, dir := range dirs {
+		if filesystem.DirExists(filepath.Join(bm.serverPath, dir)) {
+			existing = append(existing, dir)
+		}
+	}
+	if len(existing) == 0 {
+		return nil, nil
+	}
+	return bm.createBackup(name, BackupTypeWorld, existing)
+}
+
+// swapDir replaces dst with src using two renames; if the second fails dst is put back
+func (bm *BackupManager) swapDir(src, dst string) error {
+	if err := filesystem.EnsureDir(filepath.Dir(dst)); err != nil {
+		return err
+	}
+	previous := dst + ".previous"
+	if err := filesystem.RemoveDir(previous); err != nil {
+		return err
+	}
+
+	hadDst := filesystem.DirExists(dst)
+	if hadDst {
+		if err := os.Rename(dst, previous); err != nil {
+			return fmt.Errorf("failed to move %s aside: %w", dst, err)
+		}
+	}
+	if err := os.Rename(src, dst); err != nil {
+		if hadDst {
+			if undoErr := os.Rename(previous, dst); undoErr != nil {
+				return fmt.Errorf("failed to move restored files into %s: %w (the previous files are in %s)", dst, err, previous)
+			}
+		}
+		return fmt.Errorf("failed to move restored files into %s: %w", dst, err)
+	}
+
+	if hadDst {
+		if err := filesystem.RemoveDir(previous); err != nil {
+			bm.logger.Warn("failed to remove replaced files", "path", previous, "error", err)
+		}
+	}
+	return nil
+}
+
+// PlanRestore reports what restoring a backup would add, change and remove without
+// touching the server
+func (bm *BackupManager) PlanRestore(backupName string) (*RestorePlan, error) {
+	backup, err := bm.GetBackupInfo(backupName)
+	if err != nil {
+		return nil, err
+	}
+
+	var incoming map[string]fileSum
+	if backup.IsCompressed {
+		var path string
+		var done func()
+		if path, done, err = bm.openArchive(backup); err == nil {
+			incoming, err = zipSums(path)
+			done()
+		}
+	} else {
+		incoming, err = dirSums(backup.Path, "")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup %s: %w", backupName, err)
+	}
+
+	// World restores only touch the world folders in the backup
+	current := map[string]fileSum{}
+	if backup.Type == BackupTypeWorld {
+		seen := map[string]bool{}
+		for path := range incoming {
+			dir, _, _ := strings.Cut(filepath.ToSlash(path), "/")
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			sums, err := dirSums(filepath.Join(bm.serverPath, dir), dir)
+			if err != nil {
+				return nil, err
+			}
+			for p, sum := range sums {
+				current[p] = sum
+			}
+		}
+	} else if current, err = dirSums(bm.serverPath, ""); err != nil {
+		return nil, err
+	}
+
+	plan := &RestorePlan{Backup: backupName, Type: backup.Type}
+	for path, sum := range incoming {
+		live, ok := current[path]
+		switch {
+		case !ok:
+			plan.Added = append(plan.Added, path)
+		case live != sum:
+			plan.Changed = append(plan.Changed, path)
+		}
+	}
+	for path := range current {
+		if _, ok := incoming[path]; !ok {
+			plan.Removed = append(plan.Removed, path)
+		}
+	}
+	sort.Strings(plan.Added)
+	sort.Strings(plan.Changed)
+	sort.Strings(plan.Removed)
+	return plan, nil
+}
+
+// zipSums returns the size and checksum of every file in a zip backup
+func zipSums(path string) (map[string]fileSum, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	sums := make(map[string]fileSum, len(reader.File))
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		sums[filepath.Clean(filepath.FromSlash(file.Name))] = fileSum{size: int64(file.UncompressedSize64), crc: file.CRC32}
+	}
+	return sums, nil
+}
+
+// dirSums returns the size and checksum of every file below root, keyed by prefix joined
+// with the path relative to root; a missing root has no files
+func dirSums(root, prefix string) (map[string]fileSum, error) {
+	hashes, err := filesystem.HashDir(context.Background(), root, func() hash.Hash { return crc32.NewIEEE() })
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[string]fileSum, len(hashes))
+	for rel, h := range hashes {
+		sums[filepath.Join(prefix, rel)] = fileSum{size: h.Size, crc: binary.BigEndian.Uint32(h.Sum)}
+	}
+	return sums, nil
+}
+
+// backupInsideServer reports whether the backup directory lives below the server directory
+func (bm *BackupManager) backupInsideServer() bool {
+	server, err := filepath.Abs(bm.serverPath)
+	if err != nil {
+		return false
+	}
+	backups, err := filepath.Abs(bm.backupPath)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(server, backups)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// topLevelDirs lists the directories directly inside dir
+func topLevelDirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read restored backup: %w", err)
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// ProtectedFile lists the protected backups in the backup directory
+const ProtectedFile = "protected.json"
+
+// RetentionPolicy decides which backups PruneBackups keeps. A backup is kept when any
+// rule keeps it; rules set to 0 keep nothing, and a policy without rules keeps everything.
+// Protected backups are always kept.
+type RetentionPolicy struct {
+	Days        int // keep the backups of the last Days days
+	KeepLast    int // keep the newest KeepLast backups
+	KeepDaily   int // keep the newest backup of each of the last KeepDaily days
+	KeepWeekly  int // keep the newest backup of each of the last KeepWeekly weeks
+	KeepMonthly int // keep the newest backup of each of the last KeepMonthly months
+}
+
+// NewRetentionPolicy returns the retention policy of the backup settings
+func NewRetentionPolicy(cfg config.BackupConfig) RetentionPolicy {
+	return RetentionPolicy{
+		Days:        cfg.RetentionDays,
+		KeepLast:    cfg.KeepLast,
+		KeepDaily:   cfg.KeepDaily,
+		KeepWeekly:  cfg.KeepWeekly,
+		KeepMonthly: cfg.KeepMonthly,
+	}
+}
+
+// IsZero reports whether the policy has no rules, and so keeps every backup
+func (p RetentionPolicy) IsZero() bool {
+	return p == RetentionPolicy{}
+}
+
+// Keep returns the names of the backups the policy keeps at now. backups are sorted newest
+// first, as ListBackups returns them; protected backups are not kept by Keep itself.
+func (p RetentionPolicy) Keep(backups []BackupInfo, now time.Time) map[string]bool {
+	keep := map[string]bool{}
+	if p.IsZero() {
+		for _, b := range backups {
+			keep[b.Name] = true
+		}
+		return keep
+	}
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weekday := (int(day.Weekday()) + 6) % 7 // days since Monday
+	tiers := []struct {
+		count  int
+		cutoff time.Time
+		period func(t time.Time) string
+	}{
+		{p.KeepDaily, day.AddDate(0, 0, 1-p.KeepDaily), func(t time.Time) string { return t.Format("2006-01-02") }},
+		{p.KeepWeekly, day.AddDate(0, 0, -weekday-7*(p.KeepWeekly-1)), func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{p.KeepMonthly, time.Date(now.Year(), now.Month()-time.Month(p.KeepMonthly-1), 1, 0, 0, 0, 0, now.Location()), func(t time.Time) string {
+			return t.Format("2006-01")
+		}},
+	}
+
+	for i, b := range backups {
+		created := b.Created.In(now.Location())
+		if i < p.KeepLast || p.Days > 0 && !created.Before(now.AddDate(0, 0, -p.Days)) {
+			keep[b.Name] = true
+		}
+	}
+	for _, tier := range tiers {
+		if tier.count <= 0 {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, b := range backups {
+			created := b.Created.In(now.Location())
+			if created.Before(tier.cutoff) {
+				continue
+			}
+			// The first backup of a period is its newest
+			if period := tier.period(created); !seen[period] {
+				seen[period] = true
+				keep[b.Name] = true
+			}
+		}
+	}
+	return keep
+}
+
+// SetRetention replaces the retention policy PruneBackups applies
+func (bm *BackupManager) SetRetention(policy RetentionPolicy) {
+	bm.retention = policy
+}
+
+// ProtectBackup marks a backup as protected, so that pruning and DeleteBackup keep it
+func (bm *BackupManager) ProtectBackup(backupName string) error {
+	if _, err := bm.GetBackupInfo(backupName); err != nil {
+		return err
+	}
+	return bm.setProtected(backupName, true)
+}
+
+// UnprotectBackup lifts the protection of a backup
+func (bm *BackupManager) UnprotectBackup(backupName string) error {
+	return bm.setProtected(backupName, false)
+}
+
+// setProtected adds backupName to or removes it from the protected list
+func (bm *BackupManager) setProtected(backupName string, protected bool) error {
+	names, err := bm.protectedBackups()
+	if err != nil {
+		return err
+	}
+	i := slices.Index(names, backupName)
+	switch {
+	case protected && i < 0:
+		names = append(names, backupName)
+		slices.Sort(names)
+	case !protected && i >= 0:
+		names = slices.Delete(names, i, i+1)
+	default:
+		return nil
+	}
+	data, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := filesystem.SafeWriteFile(filepath.Join(bm.backupPath, ProtectedFile), data, 0o600); err != nil {
+		return fmt.Errorf("failed to save protected backups: %w", err)
+	}
+	bm.logger.Info("backup protection changed", "backup", backupName, "protected", protected)
+	return nil
+}
+
+// protectedBackups reads the names of the protected backups
+func (bm *BackupManager) protectedBackups() ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(bm.backupPath, ProtectedFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read protected backups: %w", err)
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("failed to read protected backups: %w", err)
+	}
+	return names, nil
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+)
+
+func TestRetentionPolicyKeep(t *testing.T) {
+	// Wednesday 2024-05-15, with a backup every day at 03:00 and a second one on some days
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	var backups []BackupInfo
+	for day := 0; day < 120; day++ {
+		created := time.Date(2024, 5, 15-day, 3, 0, 0, 0, time.UTC)
+		backups = append(backups, BackupInfo{Name: created.Format("2006-01-02"), Created: created})
+		if day%10 == 0 {
+			backups = append(backups, BackupInfo{Name: created.Format("2006-01-02") + "_early", Created: created.Add(-time.Hour)})
+		}
+	}
+
+	tests := []struct {
+		policy RetentionPolicy
+		want   string
+	}{
+		{RetentionPolicy{KeepLast: 3}, "2024-05-14 2024-05-15 2024-05-15_early"},
+		{RetentionPolicy{Days: 2}, "2024-05-14 2024-05-15 2024-05-15_early"},
+		// The newest backup of each day, not the one made earlier that day
+		{RetentionPolicy{KeepDaily: 2}, "2024-05-14 2024-05-15"},
+		// The newest backup of this week and the last, which ended on Sunday 2024-05-12
+		{RetentionPolicy{KeepWeekly: 2}, "2024-05-12 2024-05-15"},
+		{RetentionPolicy{KeepMonthly: 3}, "2024-03-31 2024-04-30 2024-05-15"},
+		{RetentionPolicy{KeepLast: 1, KeepWeekly: 1, KeepMonthly: 2}, "2024-04-30 2024-05-15"},
+	}
+	for _, tt := range tests {
+		var kept []string
+		for name := range tt.policy.Keep(backups, now) {
+			kept = append(kept, name)
+		}
+		sort.Strings(kept)
+		if got := strings.Join(kept, " "); got != tt.want {
+			t.Errorf("%+v keeps %s, want %s", tt.policy, got, tt.want)
+		}
+	}
+
+	if keep := (RetentionPolicy{}).Keep(backups, now); len(keep) != len(backups) {
+		t.Errorf("an empty policy keeps %d of %d backups", len(keep), len(backups))
+	}
+}
+
+func TestProtectedBackupsAreKept(t *testing.T) {
+	serverDir := t.TempDir()
+	writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=test\n")
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	bm := NewBackupManager(serverDir, t.TempDir(), true, 0)
+	bm.SetClock(fake)
+	bm.SetRetention(RetentionPolicy{KeepLast: 1})
+
+	var names []string
+	for i := range 3 {
+		b, err := bm.CreateBackup(fmt.Sprintf("b%d", i), "manual")
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, filepath.Base(b.Path))
+		fake.Advance(time.Hour)
+	}
+	if err := bm.ProtectBackup(names[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := bm.ProtectBackup("missing.zip"); err == nil {
+		t.Error("protected a backup that does not exist")
+	}
+	if err := bm.DeleteBackup(names[0]); err == nil || !strings.Contains(err.Error(), "protected") {
+		t.Errorf("deleting a protected backup: %v", err)
+	}
+
+	pruned, err := bm.PruneBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 1 || pruned[0].Name != names[1] {
+		t.Errorf("pruned %+v, want only %s", pruned, names[1])
+	}
+	backups, _ := bm.ListBackups()
+	if len(backups) != 2 || backups[0].Name != names[2] || !backups[1].Protected {
+		t.Errorf("backups after pruning = %+v", backups)
+	}
+
+	if err := bm.UnprotectBackup(names[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := bm.DeleteBackup(names[0]); err != nil {
+		t.Errorf("deleting an unprotected backup: %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+)
+
+// Snapshot methods for backup.snapshot
+const (
+	SnapshotHardlink = "hardlink" // a directory sharing unchanged files with the previous one
+	SnapshotBtrfs    = "btrfs"    // a read-only snapshot of the server's btrfs subvolume
+	SnapshotZFS      = "zfs"      // a snapshot of the server's ZFS dataset
+	SnapshotAuto     = "auto"     // btrfs or zfs when the server is on one, hardlink otherwise
+)
+
+// zfsSnapshotDir is where ZFS shows the snapshots of a dataset below its mountpoint
+const zfsSnapshotDir = ".zfs/snapshot"
+
+// SetSnapshot makes backups snapshots taken with method, one of the Snapshot constants,
+// instead of zip archives or copies; empty turns snapshots off. btrfs and zfs only take
+// full backups, world backups then use hardlink. When a snapshot cannot be taken, the
+// backup is archived or copied as configured.
+func (bm *BackupManager) SetSnapshot(method string) {
+	bm.snapshot = method
+}
+
+// snapshotBackup takes a snapshot of dirs, or of the whole server when dirs is nil, at
+// path and returns the method it used
+func (bm *BackupManager) snapshotBackup(path string, dirs []string) (string, error) {
+	method := bm.snapshot
+	if dirs != nil && method != SnapshotHardlink {
+		method = SnapshotHardlink
+	} else if method == SnapshotAuto {
+		method = bm.detectSnapshot()
+	}
+
+	switch method {
+	case SnapshotHardlink:
+		return method, bm.linkBackup(path, dirs)
+	case SnapshotBtrfs:
+		if out, err := bm.run("btrfs", "subvolume", "snapshot", "-r", bm.serverPath, path); err != nil {
+			return method, commandError("btrfs subvolume snapshot", out, err)
+		}
+		return method, nil
+	case SnapshotZFS:
+		return method, bm.zfsSnapshot(path)
+	}
+	return method, fmt.Errorf("unknown snapshot method %q", method)
+}
+
+// detectSnapshot picks the snapshot method for the file system the server is on
+func (bm *BackupManager) detectSnapshot() string {
+	if _, err := bm.run("btrfs", "subvolume", "show", bm.serverPath); err == nil {
+		return SnapshotBtrfs
+	}
+	if dataset, _ := bm.zfsDataset(bm.serverPath); dataset != "" {
+		return SnapshotZFS
+	}
+	return SnapshotHardlink
+}
+
+// zfsSnapshot snapshots the dataset mounted at the server path and links path to it, so
+// that the snapshot is listed, restored and deleted like other backups
+func (bm *BackupManager) zfsSnapshot(path string) error {
+	dataset, mountpoint := bm.zfsDataset(bm.serverPath)
+	if dataset == "" {
+		return fmt.Errorf("%s is not the mountpoint of a ZFS dataset", bm.serverPath)
+	}
+	name := filepath.Base(path)
+	if out, err := bm.run("zfs", "snapshot", dataset+"@"+name); err != nil {
+		return commandError("zfs snapshot", out, err)
+	}
+	if err := os.Symlink(filepath.Join(mountpoint, filepath.FromSlash(zfsSnapshotDir), name), path); err != nil {
+		_, _ = bm.run("zfs", "destroy", dataset+"@"+name)
+		return fmt.Errorf("failed to link the ZFS snapshot: %w", err)
+	}
+	return nil
+}
+
+// zfsDataset returns the ZFS dataset mounted at dir and its mountpoint, or empty strings
+// when there is none or ZFS is not installed
+func (bm *BackupManager) zfsDataset(dir string) (dataset, mountpoint string) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", ""
+	}
+	out, err := bm.run("zfs", "list", "-H", "-o", "name,mountpoint", "-t", "filesystem")
+	if err != nil {
+		return "", ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		name, mount, ok := strings.Cut(scanner.Text(), "\t")
+		if ok && filepath.Clean(mount) == abs {
+			return name, mount
+		}
+	}
+	return "", ""
+}
+
+// deleteSnapshot removes the btrfs or ZFS snapshot behind the backup at path. It reports
+// false when path is neither, so that it is deleted like any other backup.
+func (bm *BackupManager) deleteSnapshot(path string) (bool, error) {
+	if target, err := os.Readlink(path); err == nil {
+		mountpoint, name, ok := strings.Cut(filepath.ToSlash(target), "/"+zfsSnapshotDir+"/")
+		if !ok {
+			return false, nil
+		}
+		dataset, _ := bm.zfsDataset(filepath.FromSlash(mountpoint))
+		if dataset == "" {
+			return true, fmt.Errorf("no ZFS dataset is mounted at %s", mountpoint)
+		}
+		if out, err := bm.run("zfs", "destroy", dataset+"@"+name); err != nil {
+			return true, commandError("zfs destroy", out, err)
+		}
+		return true, filesystem.RemoveFile(path)
+	}
+	if !filesystem.DirExists(path) {
+		return false, nil
+	}
+	// Read-only btrfs snapshots cannot be emptied, only deleted as a whole
+	if _, err := bm.run("btrfs", "subvolume", "show", path); err != nil {
+		return false, nil
+	}
+	if out, err := bm.run("btrfs", "subvolume", "delete", path); err != nil {
+		return true, commandError("btrfs subvolume delete", out, err)
+	}
+	return true, nil
+}
+
+// linkBackup copies dirs, or the whole server when dirs is nil, to path like an
+// uncompressed backup, except that files unchanged since the newest uncompressed backup
+// are hard links to its copy, the way rsync --link-dest does. Links are never made to
+// the server's own files, which the server changes in place.
+func (bm *BackupManager) linkBackup(path string, dirs []string) error {
+	previous := bm.previousCopy()
+	var owner ownerFunc
+	if os.Geteuid() == 0 {
+		owner = sameOwner
+	}
+
+	var linked, copied int
+	for _, root := range bm.backupRoots(dirs) {
+		err := filepath.Walk(root, func(src string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(bm.serverPath, src)
+			if err != nil {
+				return err
+			}
+			dst := filepath.Join(path, rel)
+			if info.IsDir() {
+				return filesystem.EnsureDir(dst)
+			}
+			if previous != "" && unchanged(info, filepath.Join(previous, rel)) {
+				if err := os.Link(filepath.Join(previous, rel), dst); err == nil {
+					linked++
+					return nil
+				}
+			}
+			if err := filesystem.CopyFile(src, dst); err != nil {
+				return err
+			}
+			copied++
+			// The next backup compares modification times to find unchanged files
+			mtime := info.ModTime()
+			return os.Chtimes(dst, mtime, mtime)
+		})
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(bm.serverPath, root)
+		if err != nil {
+			return err
+		}
+		if err := copyAttributes(root, filepath.Join(path, rel), owner); err != nil {
+			return err
+		}
+	}
+	if previous != "" {
+		previous = filepath.Base(previous)
+	}
+	bm.logger.Info("hard-linked unchanged files", "backup", filepath.Base(path), "previous", previous, "linked", linked, "copied", copied)
+	return nil
+}
+
+// previousCopy returns the newest uncompressed backup that is a plain directory, or empty
+// when there is none
+func (bm *BackupManager) previousCopy() string {
+	backups, err := bm.ListBackups()
+	if err != nil {
+		return ""
+	}
+	for _, b := range backups {
+		if info, err := os.Lstat(b.Path); err == nil && info.IsDir() {
+			return b.Path
+		}
+	}
+	return ""
+}
+
+// unchanged reports whether the backed up copy at previous has the size, modification
+// time, permissions and owner of the server file described by info
+func unchanged(info os.FileInfo, previous string) bool {
+	prev, err := os.Lstat(previous)
+	if err != nil || !prev.Mode().IsRegular() || !info.Mode().IsRegular() {
+		return false
+	}
+	if prev.Size() != info.Size() || !prev.ModTime().Equal(info.ModTime()) || prev.Mode().Perm()|0o200 != info.Mode().Perm()|0o200 {
+		return false
+	}
+	uid, gid, _ := filesystem.Owner(info)
+	prevUID, prevGID, _ := filesystem.Owner(prev)
+	return os.Geteuid() != 0 || uid == prevUID && gid == prevGID
+}
+
+// commandError describes a failed snapshot command with its output
+func commandError(command string, out []byte, err error) error {
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("%s failed: %w: %s", command, err, msg)
+	}
+	return fmt.Errorf("%s failed: %w", command, err)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+)
+
+func TestHardlinkSnapshot(t *testing.T) {
+	serverDir := filepath.Join(t.TempDir(), "server")
+	writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=old\n")
+	writeTestFile(t, filepath.Join(serverDir, "world/level.dat"), "level")
+
+	bm := NewBackupManager(serverDir, t.TempDir(), true, 0)
+	bm.SetSnapshot(SnapshotHardlink)
+	first, err := bm.CreateBackup("first", "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.IsCompressed || !filesystem.DirExists(first.Path) {
+		t.Fatalf("snapshot backup %s is not a directory", first.Path)
+	}
+
+	writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=new\n")
+	second, err := bm.CreateBackup("second", "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameFile(t, filepath.Join(first.Path, "world/level.dat"), filepath.Join(second.Path, "world/level.dat")) {
+		t.Error("unchanged world/level.dat was copied instead of linked")
+	}
+	if sameFile(t, filepath.Join(first.Path, "server.properties"), filepath.Join(second.Path, "server.properties")) {
+		t.Error("changed server.properties was linked to the previous backup")
+	}
+
+	// Changing the server does not change the snapshots
+	if err := os.WriteFile(filepath.Join(serverDir, "world/level.dat"), []byte("changed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bm.Restore(filepath.Base(first.Path), RestoreOptions{}); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	for name, want := range map[string]string{"server.properties": "motd=old\n", "world/level.dat": "level"} {
+		if got, _ := os.ReadFile(filepath.Join(serverDir, name)); string(got) != want {
+			t.Errorf("%s = %q after restore, want %q", name, got, want)
+		}
+	}
+	// Restored files are copies, not links into the backups
+	if sameFile(t, filepath.Join(serverDir, "world/level.dat"), filepath.Join(second.Path, "world/level.dat")) {
+		t.Error("restored world/level.dat is linked to the backup")
+	}
+}
+
+func TestSnapshotCommands(t *testing.T) {
+	serverDir := filepath.Join(t.TempDir(), "server")
+	writeTestFile(t, filepath.Join(serverDir, "server.properties"), "motd=hi\n")
+
+	t.Run("btrfs", func(t *testing.T) {
+		bm := NewBackupManager(serverDir, t.TempDir(), true, 0)
+		bm.SetSnapshot(SnapshotAuto)
+		var ran []string
+		bm.run = func(name string, args ...string) ([]byte, error) {
+			ran = append(ran, name+" "+strings.Join(args[:2], " "))
+			if args[1] == "snapshot" {
+				// Stand in for the snapshot btrfs would create
+				return nil, os.MkdirAll(args[len(args)-1], 0o750)
+			}
+			return nil, nil
+		}
+		backup, err := bm.CreateBackup("snap", "manual")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bm.DeleteBackup(filepath.Base(backup.Path)); err != nil {
+			t.Fatal(err)
+		}
+		want := "btrfs subvolume show,btrfs subvolume snapshot,btrfs subvolume show,btrfs subvolume delete"
+		if got := strings.Join(ran, ","); got != want {
+			t.Errorf("ran %s, want %s", got, want)
+		}
+	})
+
+	t.Run("zfs", func(t *testing.T) {
+		mountpoint := t.TempDir()
+		bm := NewBackupManager(mountpoint, t.TempDir(), true, 0)
+		bm.SetSnapshot(SnapshotZFS)
+		var ran []string
+		bm.run = func(name string, args ...string) ([]byte, error) {
+			switch args[0] {
+			case "list":
+				return []byte("tank\t/tank\ntank/mc\t" + mountpoint + "\n"), nil
+			case "snapshot":
+				// Stand in for the snapshot ZFS would show below the mountpoint
+				_, snap, _ := strings.Cut(args[1], "@")
+				writeTestFile(t, filepath.Join(mountpoint, ".zfs", "snapshot", snap, "server.properties"), "motd=hi\n")
+			}
+			ran = append(ran, name+" "+strings.Join(args, " "))
+			return nil, nil
+		}
+		backup, err := bm.CreateBackup("snap", "manual")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if backups, err := bm.ListBackups(); err != nil || len(backups) != 1 || backups[0].Size != int64(len("motd=hi\n")) {
+			t.Errorf("backups = %+v, %v", backups, err)
+		}
+		if err := bm.DeleteBackup(filepath.Base(backup.Path)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Lstat(backup.Path); !os.IsNotExist(err) {
+			t.Errorf("snapshot link still exists: %v", err)
+		}
+		want := "zfs snapshot tank/mc@snap_manual,zfs destroy tank/mc@snap_manual"
+		if got := strings.Join(ran, ","); got != want {
+			t.Errorf("ran %s, want %s", got, want)
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		bm := NewBackupManager(serverDir, t.TempDir(), true, 0)
+		bm.SetSnapshot(SnapshotBtrfs)
+		bm.run = func(string, ...string) ([]byte, error) {
+			return []byte("ERROR: not a btrfs filesystem"), errors.New("exit status 1")
+		}
+		backup, err := bm.CreateBackup("snap", "manual")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !backup.IsCompressed || !strings.HasSuffix(backup.Path, ".zip") {
+			t.Errorf("fallback backup %s is not a zip archive", backup.Path)
+		}
+	})
+}
+
+func sameFile(t *testing.T, a, b string) bool {
+	t.Helper()
+	ia, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ib, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return os.SameFile(ia, ib)
+}
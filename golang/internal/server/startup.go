@@ -0,0 +1,171 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxStartupErrors is how many error lines a StartupReport keeps
+const maxStartupErrors = 10
+
+// logDrain is how long FollowLog still reads the output of a process that exited
+const logDrain = 2 * time.Second
+
+// Log signatures. Done and crash lines are anchored right after the "]: " that ends the log
+// prefix, or at the start of an unprefixed line, and chat lines are skipped, so that players
+// cannot fake them.
+var (
+	// "[Server thread/INFO]: Done (12.345s)! For help, type "help""
+	doneLine = regexp.MustCompile(`\]: Done \((\d+(?:[.,]\d+)?)s\)!`)
+	// A crash ends a start attempt: a saved crash report (vanilla and Forge or NeoForge mod
+	// loading), Fabric refusing to load incompatible mods, or the JVM itself crashing.
+	// Process mode puts "[stdout] " or "[stderr] " in front of every line.
+	crashLine = regexp.MustCompile(`\]: (?:This crash report has been saved to|Crash report saved to):? *(.*)|\]: Incompatible mods found!|^(?:\[\w+\] )?# A fatal error has been detected by the Java Runtime Environment`)
+	// Errors that usually precede a crash and explain it
+	errorLine = regexp.MustCompile(`(?i)mod loading has failed|failed to load mod|ModLoadingException|LoadingFailedException|missing or unsupported mandatory dependencies|requires .+ of .+, which is missing|^(?:\[\w+\] )?Description: `)
+	// "[Server thread/INFO]: <Steve> hello" and "[Server thread/INFO]: [Not Secure] <Steve> hello"
+	chatLine = regexp.MustCompile(`\]: (?:\[Not Secure\] )?<[^>]+> `)
+)
+
+// StartupReport is what the server logged while it started
+type StartupReport struct {
+	Done         bool          `json:"done"`                      // it logged "Done" and accepts players
+	StartupTime  time.Duration `json:"startup_time_ns,omitempty"` // as reported in the Done line
+	Crashes      int           `json:"crashes"`                   // start attempts that crashed
+	CrashReports []string      `json:"crash_reports,omitempty"`
+	Errors       []string      `json:"errors,omitempty"` // mod loading errors and crash lines, at most maxStartupErrors
+}
+
+// Analyze records a log line in the report and reports whether it was a crash
+func (r *StartupReport) Analyze(line string) (crashed bool) {
+	if chatLine.MatchString(line) {
+		return false
+	}
+	if m := doneLine.FindStringSubmatch(line); m != nil {
+		r.Done = true
+		if secs, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", "."), 64); err == nil {
+			r.StartupTime = time.Duration(secs * float64(time.Second))
+		}
+		return false
+	}
+	if m := crashLine.FindStringSubmatch(line); m != nil {
+		r.Crashes++
+		if path := strings.TrimSpace(m[1]); path != "" {
+			r.CrashReports = append(r.CrashReports, path)
+		}
+		r.addError(line)
+		return true
+	}
+	if errorLine.MatchString(line) {
+		r.addError(line)
+	}
+	return false
+}
+
+func (r *StartupReport) addError(line string) {
+	if len(r.Errors) < maxStartupErrors {
+		r.Errors = append(r.Errors, strings.TrimSpace(line))
+	}
+}
+
+// Failed reports whether the server crashed without ever finishing its startup
+func (r *StartupReport) Failed() bool {
+	return r.Crashes > 0 && !r.Done
+}
+
+// Summary describes the crashes for a notification: the last error and crash report
+func (r *StartupReport) Summary() string {
+	parts := []string{fmt.Sprintf("crashed %d time(s) before it finished starting", r.Crashes)}
+	if n := len(r.Errors); n > 0 {
+		parts = append(parts, r.Errors[n-1])
+	}
+	if n := len(r.CrashReports); n > 0 {
+		parts = append(parts, "crash report "+r.CrashReports[n-1])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// WatchStartup analyzes lines until the server logs "Done", crashes maxCrashes times, the
+// lines end or ctx ends, whichever comes first
+func WatchStartup(ctx context.Context, lines <-chan string, maxCrashes int) *StartupReport {
+	report := &StartupReport{}
+	for {
+		select {
+		case <-ctx.Done():
+			return report
+		case line, ok := <-lines:
+			if !ok {
+				return report
+			}
+			if report.Analyze(line) && report.Crashes >= maxCrashes {
+				return report
+			}
+			if report.Done {
+				return report
+			}
+		}
+	}
+}
+
+// FollowLog returns every line the server logs from now on, until ctx ends or the log does,
+// e.g. because the process or container stopped. c must be a *MinecraftServer or a LogStreamer.
+func FollowLog(ctx context.Context, c Controller) (<-chan string, error) {
+	lines := make(chan string, 64)
+	if s, ok := c.(*MinecraftServer); ok {
+		s.mu.RLock()
+		stopped := s.stopChan
+		s.mu.RUnlock()
+		_, logs, cancel := s.SubscribeLogs(consoleHistory)
+		var drained <-chan time.Time
+		go func() {
+			defer close(lines)
+			defer cancel()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-stopped:
+					// The output is read on its own, so its last lines may still be coming in
+					stopped, drained = nil, time.After(logDrain)
+				case <-drained:
+					return
+				case line := <-logs:
+					select {
+					case lines <- line:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+		return lines, nil
+	}
+
+	streamer, ok := c.(LogStreamer)
+	if !ok {
+		return nil, fmt.Errorf("the server log of %T cannot be followed", c)
+	}
+	r, w := io.Pipe()
+	go func() {
+		_ = w.CloseWithError(streamer.Logs(ctx, w, 0, true))
+	}()
+	go func() {
+		defer close(lines)
+		defer r.Close()
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return lines, nil
+}
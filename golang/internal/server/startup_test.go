@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStartupReportAnalyze(t *testing.T) {
+	for _, tc := range []struct {
+		line    string
+		crashed bool
+		done    bool
+		errors  int
+	}{
+		{`[12:00:00] [Server thread/INFO]: Done (12.345s)! For help, type "help"`, false, true, 0},
+		{`[stdout] [12:00:00] [Server thread/INFO] [minecraft/DedicatedServer]: Done (7,5s)! For help, type "help"`, false, true, 0},
+		{"[12:00:00] [Server thread/ERROR]: This crash report has been saved to: /srv/crash-reports/crash-1-server.txt", true, false, 1},
+		{"[12:00:00] [main/ERROR]: Incompatible mods found!", true, false, 1},
+		{"[stdout] # A fatal error has been detected by the Java Runtime Environment:", true, false, 1},
+		{"[12:00:00] [main/ERROR] [net.minecraftforge.fml.ModLoader/LOADING]: Failed to load mod create", false, false, 1},
+		{"Description: Mod loading error has occurred", false, false, 1},
+		// Players cannot fake a start or a crash in chat
+		{`[12:00:00] [Server thread/INFO]: <Steve> ]: Done (1.0s)!`, false, false, 0},
+		{"[12:00:00] [Server thread/INFO]: [Not Secure] <Steve> ]: Incompatible mods found!", false, false, 0},
+		{"[12:00:00] [Server thread/INFO]: Steve joined the game", false, false, 0},
+	} {
+		r := &StartupReport{}
+		if crashed := r.Analyze(tc.line); crashed != tc.crashed || r.Done != tc.done || len(r.Errors) != tc.errors {
+			t.Errorf("Analyze(%q) = %v, done %v, %d errors; want %v, %v, %d", tc.line, crashed, r.Done, len(r.Errors), tc.crashed, tc.done, tc.errors)
+		}
+	}
+
+	r := &StartupReport{}
+	r.Analyze(`[12:00:00] [Server thread/INFO]: Done (12.345s)! For help, type "help"`)
+	if r.StartupTime != 12345*time.Millisecond {
+		t.Errorf("startup time = %s, want 12.345s", r.StartupTime)
+	}
+}
+
+func TestWatchStartup(t *testing.T) {
+	lines := make(chan string, 8)
+	lines <- "[main/FATAL] [ServerModLoader/]: Crash report saved to ./crash-reports/crash-1-fml.txt"
+	lines <- "[main/FATAL] [ServerModLoader/]: Crash report saved to ./crash-reports/crash-2-fml.txt"
+	lines <- `[Server thread/INFO]: Done (3.0s)! For help, type "help"`
+	r := WatchStartup(context.Background(), lines, 2)
+	if !r.Failed() || r.Crashes != 2 || len(r.CrashReports) != 2 {
+		t.Fatalf("report = %+v, want a crash loop stopped at the second crash", r)
+	}
+	if want := "crashed 2 time(s) before it finished starting; [main/FATAL] [ServerModLoader/]: Crash report saved to ./crash-reports/crash-2-fml.txt; crash report ./crash-reports/crash-2-fml.txt"; r.Summary() != want {
+		t.Errorf("summary = %q", r.Summary())
+	}
+
+	// A crash followed by a start is healthy
+	r = WatchStartup(context.Background(), lines, 2)
+	if r.Failed() || !r.Done {
+		t.Fatalf("report = %+v, want done", r)
+	}
+
+	// Without lines the watch ends with ctx
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if r := WatchStartup(ctx, make(chan string), 2); r.Done || r.Failed() {
+		t.Fatalf("report = %+v, want nothing", r)
+	}
+}
+
+func TestFollowLogMinecraftServer(t *testing.T) {
+	s := NewMinecraftServer(t.TempDir(), "server.jar")
+	s.publishLog("[stdout] logged before")
+	lines, err := FollowLog(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.publishLog("[stdout] logged after")
+	if line := <-lines; line != "[stdout] logged after" {
+		t.Fatalf("line = %q, want only lines logged from now on", line)
+	}
+
+	// The lines end a moment after the process exited
+	close(s.stopChan)
+	s.publishLog("[stdout] last words")
+	if line := <-lines; line != "[stdout] last words" {
+		t.Fatalf("line = %q, want the output that was still coming in", line)
+	}
+	select {
+	case _, ok := <-lines:
+		if ok {
+			t.Fatal("expected no more lines")
+		}
+	case <-time.After(2 * logDrain):
+		t.Fatal("lines did not end after the process exited")
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SystemdServer controls a Minecraft server that runs as a systemd unit, through systemctl
+// and journalctl
+type SystemdServer struct {
+	unit string
+	user bool
+
+	// run executes a command and returns its combined output; replaced in tests
+	run func(ctx context.Context, name string, args ...string) ([]byte, error)
+	// stream executes a command with its output going to w
+	stream func(ctx context.Context, w io.Writer, name string, args ...string) error
+}
+
+// NewSystemdServer controls unit, in the user's service manager when user is set
+func NewSystemdServer(unit string, user bool) *SystemdServer {
+	return &SystemdServer{
+		unit: unit,
+		user: user,
+		run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			// #nosec G204 -- only systemctl and journalctl are run, with the unit from the config
+			return exec.CommandContext(ctx, name, args...).CombinedOutput()
+		},
+		stream: func(ctx context.Context, w io.Writer, name string, args ...string) error {
+			// #nosec G204 -- only journalctl is run, with the unit from the config
+			cmd := exec.CommandContext(ctx, name, args...)
+			cmd.Stdout = w
+			cmd.Stderr = w
+			return cmd.Run()
+		},
+	}
+}
+
+// Start starts the unit
+func (s *SystemdServer) Start() error {
+	if s.IsRunning() {
+		return fmt.Errorf("server is already running")
+	}
+	return s.systemctl(context.Background(), "start")
+}
+
+// Stop stops the unit; systemd kills it after the unit's TimeoutStopSec, and the command is
+// abandoned after timeout
+func (s *SystemdServer) Stop(timeout time.Duration) error {
+	if !s.IsRunning() {
+		return fmt.Errorf("server is not running")
+	}
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+	return s.systemctl(ctx, "stop")
+}
+
+// Restart restarts the unit
+func (s *SystemdServer) Restart(timeout time.Duration) error {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+	return s.systemctl(ctx, "restart")
+}
+
+// IsRunning reports whether the unit is active
+func (s *SystemdServer) IsRunning() bool {
+	out, _ := s.run(context.Background(), "systemctl", s.args("is-active", s.unit)...)
+	return strings.TrimSpace(string(out)) == "active"
+}
+
+// GetUptime returns how long the unit has been active
+func (s *SystemdServer) GetUptime() time.Duration {
+	if !s.IsRunning() {
+		return 0
+	}
+	out, err := s.run(context.Background(), "systemctl",
+		s.args("show", s.unit, "--property=ActiveEnterTimestamp", "--value", "--timestamp=unix")...)
+	if err != nil {
+		return 0
+	}
+	secs, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(string(out)), "@"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Since(time.Unix(secs, 0))
+}
+
+// Logs copies the last tail lines of the unit's journal to w, and with follow keeps
+// streaming until ctx is cancelled
+func (s *SystemdServer) Logs(ctx context.Context, w io.Writer, tail int, follow bool) error {
+	args := []string{"--unit", s.unit, "--lines", strconv.Itoa(tail), "--output", "cat", "--no-pager"}
+	if s.user {
+		args = append([]string{"--user"}, args...)
+	}
+	if follow {
+		args = append(args, "--follow")
+	}
+	err := s.stream(ctx, w, "journalctl", args...)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// systemctl runs an action on the unit and includes systemctl's output in errors
+func (s *SystemdServer) systemctl(ctx context.Context, action string) error {
+	out, err := s.run(ctx, "systemctl", s.args(action, s.unit)...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("systemctl %s %s: %s", action, s.unit, msg)
+		}
+		return fmt.Errorf("systemctl %s %s: %w", action, s.unit, err)
+	}
+	return nil
+}
+
+// args prepends --user when the unit belongs to the user's service manager
+func (s *SystemdServer) args(args ...string) []string {
+	if s.user {
+		return append([]string{"--user"}, args...)
+	}
+	return args
+}
+
+// withTimeout returns a context that ends after timeout, or never when it is not positive
+func withTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
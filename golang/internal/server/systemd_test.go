@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSystemdServer(t *testing.T) {
+	active := false
+	started := time.Now().Add(-time.Hour)
+	var calls []string
+	s := NewSystemdServer("minecraft.service", true)
+	s.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		call := name + " " + strings.Join(args, " ")
+		calls = append(calls, call)
+		switch {
+		case strings.Contains(call, "is-active"):
+			if active {
+				return []byte("active\n"), nil
+			}
+			return []byte("inactive\n"), errors.New("exit status 3")
+		case strings.Contains(call, "show"):
+			return []byte("@" + strconv.FormatInt(started.Unix(), 10) + "\n"), nil
+		case strings.Contains(call, " start "):
+			active = true
+		case strings.Contains(call, " stop "):
+			active = false
+		}
+		return nil, nil
+	}
+	var logArgs string
+	s.stream = func(ctx context.Context, w io.Writer, name string, args ...string) error {
+		logArgs = name + " " + strings.Join(args, " ")
+		_, err := fmt.Fprintln(w, "Done (4.2s)!")
+		return err
+	}
+
+	if s.IsRunning() || s.GetUptime() != 0 {
+		t.Fatal("unit should start out inactive")
+	}
+	if err := s.Stop(time.Second); err == nil {
+		t.Fatal("expected Stop of an inactive unit to fail")
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if !s.IsRunning() || s.GetUptime() < time.Hour {
+		t.Fatalf("unit should be active for an hour, got %s", s.GetUptime())
+	}
+	if err := s.Start(); err == nil {
+		t.Fatal("expected Start of an active unit to fail")
+	}
+	if err := s.Stop(time.Second); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if !strings.Contains(strings.Join(calls, "\n"), "systemctl --user start minecraft.service") {
+		t.Fatalf("unexpected systemctl calls %q", calls)
+	}
+
+	var logs strings.Builder
+	if err := s.Logs(context.Background(), &logs, 50, true); err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	if logArgs != "journalctl --user --unit minecraft.service --lines 50 --output cat --no-pager --follow" || logs.String() != "Done (4.2s)!\n" {
+		t.Fatalf("Logs ran %q and printed %q", logArgs, logs.String())
+	}
+
+	s.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte("Failed to restart minecraft.service: Unit minecraft.service not found.\n"), errors.New("exit status 5")
+	}
+	if err := s.Restart(time.Second); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("Restart err = %v, want systemctl's message", err)
+	}
+}
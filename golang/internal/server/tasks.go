@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// TaskResult is the outcome of one post-update task
+type TaskResult struct {
+	Name     string        `json:"name"`
+	Command  string        `json:"command"`
+	Duration time.Duration `json:"duration_ns"`
+	Skipped  bool          `json:"skipped,omitempty"` // not run because an earlier task failed with on_failure stop or fail
+	Error    string        `json:"error,omitempty"`
+}
+
+// RunTask sends the task's command to console. With wait_for it then follows the log of c
+// until a line matches, which fails after the task's timeout. Chat lines never match.
+func RunTask(ctx context.Context, console Commander, c Controller, task config.PostUpdateTaskConfig) error {
+	if task.WaitFor == "" {
+		return console.SendCommand(task.Command)
+	}
+	pattern, err := regexp.Compile(task.WaitFor)
+	if err != nil {
+		return fmt.Errorf("invalid wait_for: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, task.WaitTimeout())
+	defer cancel()
+	// Follow the log before sending the command, so that a quick answer is not missed
+	lines, err := FollowLog(ctx, c)
+	if err != nil {
+		return err
+	}
+	if err := console.SendCommand(task.Command); err != nil {
+		return err
+	}
+	for line := range lines {
+		if !chatLine.MatchString(line) && pattern.MatchString(line) {
+			return nil
+		}
+	}
+	if ctx.Err() == nil {
+		return fmt.Errorf("the server log ended before a line matched %q", task.WaitFor)
+	}
+	return fmt.Errorf("no log line matched %q within %s", task.WaitFor, task.WaitTimeout())
+}
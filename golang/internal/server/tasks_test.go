@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+// consoleServer answers console commands with scripted log lines
+type consoleServer struct {
+	replies map[string][]string
+	log     chan string
+	sent    []string
+}
+
+func newConsoleServer(replies map[string][]string) *consoleServer {
+	return &consoleServer{replies: replies, log: make(chan string, 16)}
+}
+
+func (s *consoleServer) Start() error                { return nil }
+func (s *consoleServer) Stop(time.Duration) error    { return nil }
+func (s *consoleServer) Restart(time.Duration) error { return nil }
+func (s *consoleServer) IsRunning() bool             { return true }
+func (s *consoleServer) GetUptime() time.Duration    { return 0 }
+
+func (s *consoleServer) SendCommand(command string) error {
+	s.sent = append(s.sent, command)
+	for _, line := range s.replies[command] {
+		s.log <- line
+	}
+	return nil
+}
+
+func (s *consoleServer) Logs(ctx context.Context, w io.Writer, tail int, follow bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line := <-s.log:
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+func TestRunTask(t *testing.T) {
+	s := newConsoleServer(map[string][]string{
+		"chunky start": {
+			"[12:00:00] [Server thread/INFO]: <Steve> Task finished for minecraft:overworld",
+			"[12:00:01] [Server thread/INFO]: [Chunky] Task running for minecraft:overworld. Processed: 50%",
+			"[12:00:02] [Server thread/INFO]: [Chunky] Task finished for minecraft:overworld. Processed: 1024 chunks",
+		},
+	})
+	task := config.PostUpdateTaskConfig{Command: "chunky start", WaitFor: `\[Chunky\] Task finished for minecraft:overworld`, Timeout: 5 * time.Second}
+	if err := RunTask(context.Background(), s, s, task); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without wait_for the command is only sent
+	if err := RunTask(context.Background(), s, s, config.PostUpdateTaskConfig{Command: "reload"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(s.sent, ","); got != "chunky start,reload" {
+		t.Errorf("sent %s", got)
+	}
+
+	// A player saying the line does not end the task
+	s = newConsoleServer(map[string][]string{"reload": {"[12:00:00] [Server thread/INFO]: <Steve> Reloaded"}})
+	err := RunTask(context.Background(), s, s, config.PostUpdateTaskConfig{Command: "reload", WaitFor: "Reloaded", Timeout: 100 * time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), "no log line matched") {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+}
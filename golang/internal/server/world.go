@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+)
+
+// BackupTypeWorld marks backups that only contain the world folders
+const BackupTypeWorld = "world"
+
+// defaultLevelName is the world folder used when server.properties does not set level-name
+const defaultLevelName = "world"
+
+// WorldDirs returns the world folders of a server, relative to serverPath: the
+// level-name from server.properties plus the separate nether and end folders that
+// Bukkit-based servers keep next to it. Vanilla and Forge store their dimensions
+// inside the level folder.
+func WorldDirs(serverPath string) ([]string, error) {
+	level := defaultLevelName
+	if props, err := LoadProperties(serverPath); err == nil && props.LevelName() != "" {
+		level = props.LevelName()
+	}
+	if filepath.IsAbs(level) || !filepath.IsLocal(level) {
+		return nil, fmt.Errorf("level-name %q is outside the server directory", level)
+	}
+	if !filesystem.DirExists(filepath.Join(serverPath, level)) {
+		return nil, fmt.Errorf("world folder not found: %s", filepath.Join(serverPath, level))
+	}
+
+	dirs := []string{level}
+	for _, dimension := range []string{level + "_nether", level + "_the_end"} {
+		if filesystem.DirExists(filepath.Join(serverPath, dimension)) {
+			dirs = append(dirs, dimension)
+		}
+	}
+	return dirs, nil
+}
+
+// CreateWorldBackup backs up only the world folders, which is much smaller and
+// faster than a full backup
+func (bm *BackupManager) CreateWorldBackup(name string) (*BackupInfo, error) {
+	dirs, err := WorldDirs(bm.serverPath)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = fmt.Sprintf("world_%s", bm.clock.Now().Format("20060102_150405"))
+	} else {
+		name = fmt.Sprintf("world_%s_%s", name, bm.clock.Now().Format("20060102_150405"))
+	}
+	return bm.createBackup(name, BackupTypeWorld, dirs)
+}
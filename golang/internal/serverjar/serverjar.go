@@ -0,0 +1,304 @@
+// Package serverjar keeps the server software itself (vanilla, Fabric, Forge or NeoForge)
+// at the configured version
+package serverjar
+
+import (
+	"crypto/sha1" //nolint:gosec // Mojang publishes SHA-1 hashes
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/httpclient"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+// Server types
+const (
+	TypeVanilla  = "vanilla"
+	TypeFabric   = "fabric"
+	TypeForge    = "forge"
+	TypeNeoForge = "neoforge"
+)
+
+// Release is one installable version of the server software
+type Release struct {
+	Type             string
+	MinecraftVersion string
+	LoaderVersion    string
+	URL              string
+	SHA1             string // empty when the source publishes no hash
+	FileName         string // the server jar, or the installer when Installer is set
+	Installer        bool   // FileName must be run with --installServer
+
+	// jarPrefix is how the jar an installer leaves behind starts, e.g. "forge-1.20.1-47.2.0"
+	jarPrefix string
+}
+
+// String describes the release, e.g. "forge 1.20.1-47.2.0"
+func (r *Release) String() string {
+	if r.LoaderVersion == "" {
+		return r.Type + " " + r.MinecraftVersion
+	}
+	return r.Type + " " + r.MinecraftVersion + "-" + r.LoaderVersion
+}
+
+// Status compares the installed server software with the configured release
+type Status struct {
+	Installed       *state.ServerJarState // nil when not installed by the updater
+	Latest          *Release
+	UpdateAvailable bool
+}
+
+// Result describes a finished `server-jar update`
+type Result struct {
+	Status
+	JarName string // jar to start the server with; empty when the installer left only run scripts
+	Skipped bool
+}
+
+// InstallerFunc runs a Forge or NeoForge installer in dir
+type InstallerFunc func(java, installer, dir string) error
+
+// Updater installs the server software configured under [server_jar]
+type Updater struct {
+	ManifestURL        string
+	FabricMetaURL      string
+	ForgePromotionsURL string
+	ForgeMavenURL      string
+	NeoForgeMavenURL   string
+	UserAgent          string
+
+	cfg            *config.Config
+	client         *http.Client
+	downloadClient *http.Client
+	store          *state.Store
+	runInstaller   InstallerFunc
+	clock          clock.Clock
+	logger         *slog.Logger
+}
+
+// New creates an updater for the server in cfg
+func New(cfg *config.Config, logger *slog.Logger) *Updater {
+	return &Updater{
+		ManifestURL:        DefaultManifestURL,
+		FabricMetaURL:      DefaultFabricMetaURL,
+		ForgePromotionsURL: DefaultForgePromotionsURL,
+		ForgeMavenURL:      DefaultForgeMavenURL,
+		NeoForgeMavenURL:   DefaultNeoForgeMavenURL,
+		UserAgent:          "damianko135/curseforge-autoupdater",
+		cfg:                cfg,
+		client:             httpclient.New(cfg.HTTP),
+		downloadClient:     httpclient.ForDownloads(cfg),
+		store:              state.NewStore(filepath.Join(cfg.DataDir, state.FileName)),
+		runInstaller:       runInstaller,
+		clock:              clock.Real(),
+		logger:             logger,
+	}
+}
+
+// SetHTTPClient replaces the clients used for metadata and downloads
+func (u *Updater) SetHTTPClient(client *http.Client) {
+	u.client = client
+	u.downloadClient = client
+}
+
+// SetInstaller replaces how Forge and NeoForge installers are run
+func (u *Updater) SetInstaller(fn InstallerFunc) {
+	u.runInstaller = fn
+}
+
+// SetClock replaces the clock used for timestamps
+func (u *Updater) SetClock(c clock.Clock) {
+	u.clock = c
+}
+
+// Latest resolves the configured server type and versions to a release
+func (u *Updater) Latest() (*Release, error) {
+	mc, err := u.minecraftVersion()
+	if err != nil {
+		return nil, err
+	}
+	switch u.cfg.ServerJar.Type {
+	case TypeVanilla:
+		return u.vanilla(mc)
+	case TypeFabric:
+		return u.fabric(mc)
+	case TypeForge:
+		return u.forge(mc)
+	case TypeNeoForge:
+		return u.neoForge(mc)
+	case "":
+		return nil, fmt.Errorf("server_jar.type is not set")
+	default:
+		return nil, fmt.Errorf("unknown server_jar.type %q", u.cfg.ServerJar.Type)
+	}
+}
+
+// Check compares the installed server software with the configured release
+func (u *Updater) Check() (*Status, error) {
+	st, err := u.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	latest, err := u.Latest()
+	if err != nil {
+		return nil, err
+	}
+	installed := st.ServerJar
+	return &Status{
+		Installed: installed,
+		Latest:    latest,
+		UpdateAvailable: installed == nil || installed.Type != latest.Type ||
+			installed.MinecraftVersion != latest.MinecraftVersion || installed.LoaderVersion != latest.LoaderVersion,
+	}, nil
+}
+
+// Update installs the configured release unless it is already installed or force is set.
+// The jar of the previous release is removed once the new one is in place.
+func (u *Updater) Update(force bool) (*Result, error) {
+	status, err := u.Check()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Status: *status}
+	if !status.UpdateAvailable && !force {
+		result.Skipped = true
+		result.JarName = status.Installed.JarName
+		return result, nil
+	}
+	if err := filesystem.EnsureDir(u.cfg.ServerPath); err != nil {
+		return nil, fmt.Errorf("failed to create server directory: %w", err)
+	}
+
+	latest := status.Latest
+	if latest.Installer {
+		result.JarName, err = u.install(latest)
+	} else {
+		result.JarName, err = u.download(latest, u.cfg.ServerPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if old := status.Installed; old != nil && old.JarName != "" && old.JarName != result.JarName {
+		if err := os.Remove(filepath.Join(u.cfg.ServerPath, old.JarName)); err != nil && !os.IsNotExist(err) {
+			u.logger.Warn("failed to remove previous server jar", "file", old.JarName, "error", err)
+		}
+	}
+
+	installed := &state.ServerJarState{
+		Type:             latest.Type,
+		MinecraftVersion: latest.MinecraftVersion,
+		LoaderVersion:    latest.LoaderVersion,
+		JarName:          result.JarName,
+		InstalledAt:      u.clock.Now(),
+	}
+	if _, err := u.store.Update(func(st *state.State) error {
+		st.ServerJar = installed
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	result.Installed = installed
+	result.UpdateAvailable = false
+	u.logger.Info("server jar updated", "release", latest.String(), "jar", result.JarName)
+	return result, nil
+}
+
+// download saves the file of r into dir, checking its SHA-1 hash when one is published
+func (u *Updater) download(r *Release, dir string) (string, error) {
+	u.logger.Info("downloading server software", "release", r.String(), "url", r.URL)
+	resp, err := u.get(u.downloadClient, r.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	tmp, err := os.CreateTemp(dir, ".download_*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary download file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha1.New() //nolint:gosec // Mojang publishes SHA-1 hashes
+	if _, err := io.Copy(io.MultiWriter(tmp, h), resp.Body); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("failed to download %s: %w", r.FileName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close temporary download file: %w", err)
+	}
+	if r.SHA1 != "" {
+		if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, r.SHA1) {
+			return "", fmt.Errorf("downloaded %s has sha1 %s, expected %s", r.FileName, got, r.SHA1)
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, r.FileName)); err != nil {
+		return "", fmt.Errorf("failed to move download into place: %w", err)
+	}
+	return r.FileName, nil
+}
+
+// install downloads and runs a Forge or NeoForge installer in the server directory and
+// returns the jar it produced. Newer versions only produce run scripts, in which case the
+// returned name is empty.
+func (u *Updater) install(r *Release) (string, error) {
+	if err := filesystem.EnsureDir(u.cfg.DownloadPath); err != nil {
+		return "", fmt.Errorf("failed to create download directory: %w", err)
+	}
+	name, err := u.download(r, u.cfg.DownloadPath)
+	if err != nil {
+		return "", err
+	}
+	installer := filepath.Join(u.cfg.DownloadPath, name)
+	defer os.Remove(installer)
+
+	java := u.cfg.ServerJar.Java
+	if java == "" {
+		java = "java"
+	}
+	u.logger.Info("running installer", "release", r.String(), "java", java)
+	if err := u.runInstaller(java, installer, u.cfg.ServerPath); err != nil {
+		return "", fmt.Errorf("%s installer failed: %w", r.Type, err)
+	}
+	_ = os.Remove(filepath.Join(u.cfg.ServerPath, filepath.Base(installer)+".log"))
+
+	matches, err := filepath.Glob(filepath.Join(u.cfg.ServerPath, r.jarPrefix+"*.jar"))
+	if err != nil {
+		return "", err
+	}
+	for _, m := range matches {
+		if !strings.HasSuffix(m, "-installer.jar") {
+			return filepath.Base(m), nil
+		}
+	}
+	u.logger.Warn("installer produced no server jar, start the server with the generated run script", "release", r.String())
+	return "", nil
+}
+
+// runInstaller runs `java -jar installer --installServer` in dir
+func runInstaller(java, installer, dir string) error {
+	abs, err := filepath.Abs(installer)
+	if err != nil {
+		return err
+	}
+	// #nosec G204 -- java comes from the config file
+	cmd := exec.Command(java, "-jar", abs, "--installServer")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		return fmt.Errorf("%w: %s", err, lines[len(lines)-1])
+	}
+	return nil
+}
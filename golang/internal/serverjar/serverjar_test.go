@@ -0,0 +1,140 @@
+package serverjar
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	jar := []byte("vanilla server")
+	sum := sha1.Sum(jar)
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/manifest.json":
+			_, _ = w.Write([]byte(`{"latest":{"release":"1.21.1"},"versions":[
+				{"id":"1.21.1","type":"release","url":"` + srv.URL + `/1.21.1.json"},
+				{"id":"1.20.1","type":"release","url":"` + srv.URL + `/1.20.1.json"}]}`))
+		case "/1.21.1.json", "/1.20.1.json":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"downloads": map[string]interface{}{
+				"server": map[string]interface{}{"url": srv.URL + "/server.jar", "sha1": hex.EncodeToString(sum[:])},
+			}})
+		case "/server.jar":
+			_, _ = w.Write(jar)
+		case "/fabric/versions/loader/1.20.1":
+			_, _ = w.Write([]byte(`[{"loader":{"version":"0.16.3-beta","stable":false}},{"loader":{"version":"0.16.2","stable":true}}]`))
+		case "/fabric/versions/installer":
+			_, _ = w.Write([]byte(`[{"version":"1.0.1","stable":true}]`))
+		case "/fabric/versions/loader/1.20.1/0.16.2/1.0.1/server/jar":
+			_, _ = w.Write([]byte("fabric launcher"))
+		case "/forge/promotions.json":
+			_, _ = w.Write([]byte(`{"promos":{"1.20.1-latest":"47.3.0","1.20.1-recommended":"47.2.0"}}`))
+		case "/forge/1.20.1-47.2.0/forge-1.20.1-47.2.0-installer.jar":
+			_, _ = w.Write([]byte("forge installer"))
+		case "/neoforge/maven-metadata.xml":
+			_, _ = w.Write([]byte(`<metadata><versioning><versions>
+				<version>20.4.80-beta</version><version>21.1.50</version><version>21.1.51</version><version>21.1.52-beta</version>
+				</versions></versioning></metadata>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestUpdater(t *testing.T, srv *httptest.Server, jar config.ServerJarConfig) *Updater {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		GameVersion:   "1.20.1",
+		UpdateChannel: "stable",
+		ServerPath:    filepath.Join(dir, "server"),
+		DownloadPath:  filepath.Join(dir, "downloads"),
+		DataDir:       filepath.Join(dir, "data"),
+		ServerJar:     jar,
+	}
+	u := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	u.ManifestURL = srv.URL + "/manifest.json"
+	u.FabricMetaURL = srv.URL + "/fabric"
+	u.ForgePromotionsURL = srv.URL + "/forge/promotions.json"
+	u.ForgeMavenURL = srv.URL + "/forge"
+	u.NeoForgeMavenURL = srv.URL + "/neoforge"
+	u.SetHTTPClient(srv.Client())
+	return u
+}
+
+func TestLatest(t *testing.T) {
+	srv := newTestServer(t)
+	tests := []struct {
+		name string
+		jar  config.ServerJarConfig
+		want string
+	}{
+		{"vanilla latest", config.ServerJarConfig{Type: TypeVanilla, MinecraftVersion: "latest"}, "vanilla 1.21.1"},
+		{"fabric stable loader", config.ServerJarConfig{Type: TypeFabric}, "fabric 1.20.1-0.16.2"},
+		{"forge recommended", config.ServerJarConfig{Type: TypeForge}, "forge 1.20.1-47.2.0"},
+		{"forge pinned", config.ServerJarConfig{Type: TypeForge, LoaderVersion: "47.1.0"}, "forge 1.20.1-47.1.0"},
+		{"neoforge stable", config.ServerJarConfig{Type: TypeNeoForge, MinecraftVersion: "1.21.1"}, "neoforge 1.21.1-21.1.51"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latest, err := newTestUpdater(t, srv, tt.jar).Latest()
+			if err != nil {
+				t.Fatalf("Latest: %v", err)
+			}
+			if latest.String() != tt.want {
+				t.Fatalf("Latest = %s, want %s", latest, tt.want)
+			}
+		})
+	}
+
+	if _, err := newTestUpdater(t, srv, config.ServerJarConfig{Type: TypeNeoForge}).Latest(); err == nil {
+		t.Fatal("expected an error for a game version without NeoForge builds")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	srv := newTestServer(t)
+	u := newTestUpdater(t, srv, config.ServerJarConfig{Type: TypeVanilla})
+
+	res, err := u.Update(false)
+	if err != nil {
+		t.Fatalf("vanilla update: %v", err)
+	}
+	if res.JarName != "minecraft_server.1.20.1.jar" {
+		t.Fatalf("JarName = %q", res.JarName)
+	}
+	if res, err = u.Update(false); err != nil || !res.Skipped {
+		t.Fatalf("expected second update to be skipped, got %+v, %v", res, err)
+	}
+
+	// Switching to Forge runs the installer and replaces the vanilla jar
+	u.cfg.ServerJar = config.ServerJarConfig{Type: TypeForge}
+	var ran string
+	u.SetInstaller(func(java, installer, dir string) error {
+		ran = java + " " + filepath.Base(installer)
+		return os.WriteFile(filepath.Join(dir, "forge-1.20.1-47.2.0-shim.jar"), []byte("forge"), 0600)
+	})
+	if res, err = u.Update(false); err != nil {
+		t.Fatalf("forge update: %v", err)
+	}
+	if ran != "java forge-1.20.1-47.2.0-installer.jar" || res.JarName != "forge-1.20.1-47.2.0-shim.jar" {
+		t.Fatalf("installer %q produced %q", ran, res.JarName)
+	}
+	if _, err := os.Stat(filepath.Join(u.cfg.ServerPath, "minecraft_server.1.20.1.jar")); !os.IsNotExist(err) {
+		t.Fatalf("previous jar was not removed: %v", err)
+	}
+	if status, err := u.Check(); err != nil || status.UpdateAvailable || status.Installed.LoaderVersion != "47.2.0" {
+		t.Fatalf("unexpected status after forge update: %+v, %v", status, err)
+	}
+}
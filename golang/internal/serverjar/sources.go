@@ -0,0 +1,290 @@
+package serverjar
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Default metadata endpoints
+const (
+	DefaultManifestURL        = "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json"
+	DefaultFabricMetaURL      = "https://meta.fabricmc.net/v2"
+	DefaultForgePromotionsURL = "https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json"
+	DefaultForgeMavenURL      = "https://maven.minecraftforge.net/net/minecraftforge/forge"
+	DefaultNeoForgeMavenURL   = "https://maven.neoforged.net/releases/net/neoforged/neoforge"
+)
+
+// versionManifest is Mojang's list of game versions
+type versionManifest struct {
+	Latest struct {
+		Release  string `json:"release"`
+		Snapshot string `json:"snapshot"`
+	} `json:"latest"`
+	Versions []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"versions"`
+}
+
+// versionInfo is the part of a game version's metadata that describes the server jar
+type versionInfo struct {
+	Downloads struct {
+		Server *struct {
+			SHA1 string `json:"sha1"`
+			Size int64  `json:"size"`
+			URL  string `json:"url"`
+		} `json:"server"`
+	} `json:"downloads"`
+}
+
+// fabricLoader is one entry of the Fabric loader list for a game version
+type fabricLoader struct {
+	Loader struct {
+		Version string `json:"version"`
+		Stable  bool   `json:"stable"`
+	} `json:"loader"`
+}
+
+// fabricInstaller is one entry of the Fabric installer list
+type fabricInstaller struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+}
+
+// forgePromotions maps "<game version>-latest|recommended" to Forge versions
+type forgePromotions struct {
+	Promos map[string]string `json:"promos"`
+}
+
+// mavenMetadata is a Maven maven-metadata.xml file
+type mavenMetadata struct {
+	Versions []string `xml:"versioning>versions>version"`
+}
+
+// minecraftVersion resolves the configured game version, looking up "latest" in the manifest
+func (u *Updater) minecraftVersion() (string, error) {
+	version := u.cfg.ServerJar.MinecraftVersion
+	if version == "" {
+		version = u.cfg.GameVersion
+	}
+	if version != "latest" {
+		return version, nil
+	}
+
+	var manifest versionManifest
+	if err := u.getJSON(u.ManifestURL, &manifest); err != nil {
+		return "", fmt.Errorf("failed to get version manifest: %w", err)
+	}
+	return manifest.Latest.Release, nil
+}
+
+// vanilla returns Mojang's server jar for the game version
+func (u *Updater) vanilla(mc string) (*Release, error) {
+	var manifest versionManifest
+	if err := u.getJSON(u.ManifestURL, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to get version manifest: %w", err)
+	}
+	for _, v := range manifest.Versions {
+		if v.ID != mc {
+			continue
+		}
+		var info versionInfo
+		if err := u.getJSON(v.URL, &info); err != nil {
+			return nil, fmt.Errorf("failed to get metadata of %s: %w", mc, err)
+		}
+		if info.Downloads.Server == nil {
+			return nil, fmt.Errorf("minecraft %s has no server jar", mc)
+		}
+		return &Release{
+			Type:             TypeVanilla,
+			MinecraftVersion: mc,
+			URL:              info.Downloads.Server.URL,
+			SHA1:             info.Downloads.Server.SHA1,
+			FileName:         "minecraft_server." + mc + ".jar",
+		}, nil
+	}
+	return nil, fmt.Errorf("minecraft version %s not found in the version manifest", mc)
+}
+
+// fabric returns the Fabric server launcher for the game version; it downloads the game
+// and loader libraries itself on first start
+func (u *Updater) fabric(mc string) (*Release, error) {
+	loader := u.cfg.ServerJar.LoaderVersion
+	if loader == "" {
+		var loaders []fabricLoader
+		if err := u.getJSON(u.FabricMetaURL+"/versions/loader/"+mc, &loaders); err != nil {
+			return nil, fmt.Errorf("failed to get Fabric loaders for %s: %w", mc, err)
+		}
+		for _, l := range loaders {
+			if l.Loader.Stable || u.cfg.UpdateChannel != "stable" {
+				loader = l.Loader.Version
+				break
+			}
+		}
+		if loader == "" {
+			return nil, fmt.Errorf("no Fabric loader found for minecraft %s", mc)
+		}
+	}
+
+	var installers []fabricInstaller
+	if err := u.getJSON(u.FabricMetaURL+"/versions/installer", &installers); err != nil {
+		return nil, fmt.Errorf("failed to get Fabric installers: %w", err)
+	}
+	installer := ""
+	for _, i := range installers {
+		if i.Stable {
+			installer = i.Version
+			break
+		}
+	}
+	if installer == "" {
+		return nil, fmt.Errorf("no stable Fabric installer found")
+	}
+
+	return &Release{
+		Type:             TypeFabric,
+		MinecraftVersion: mc,
+		LoaderVersion:    loader,
+		URL:              fmt.Sprintf("%s/versions/loader/%s/%s/%s/server/jar", u.FabricMetaURL, mc, loader, installer),
+		FileName:         fmt.Sprintf("fabric-server-mc.%s-loader.%s-launcher.%s.jar", mc, loader, installer),
+	}, nil
+}
+
+// forge returns the Forge installer, picking the recommended version on the stable channel
+func (u *Updater) forge(mc string) (*Release, error) {
+	version := u.cfg.ServerJar.LoaderVersion
+	if version == "" {
+		var promotions forgePromotions
+		if err := u.getJSON(u.ForgePromotionsURL, &promotions); err != nil {
+			return nil, fmt.Errorf("failed to get Forge promotions: %w", err)
+		}
+		if u.cfg.UpdateChannel == "stable" {
+			version = promotions.Promos[mc+"-recommended"]
+		}
+		if version == "" {
+			version = promotions.Promos[mc+"-latest"]
+		}
+		if version == "" {
+			return nil, fmt.Errorf("no Forge version found for minecraft %s", mc)
+		}
+	}
+
+	full := mc + "-" + version
+	return &Release{
+		Type:             TypeForge,
+		MinecraftVersion: mc,
+		LoaderVersion:    version,
+		URL:              fmt.Sprintf("%s/%s/forge-%s-installer.jar", u.ForgeMavenURL, full, full),
+		FileName:         "forge-" + full + "-installer.jar",
+		Installer:        true,
+		jarPrefix:        "forge-" + full,
+	}, nil
+}
+
+// neoForge returns the NeoForge installer; NeoForge versions start with the game version
+// without its leading "1.", e.g. 21.1.x for 1.21.1
+func (u *Updater) neoForge(mc string) (*Release, error) {
+	prefix, err := neoForgePrefix(mc)
+	if err != nil {
+		return nil, err
+	}
+
+	version := u.cfg.ServerJar.LoaderVersion
+	if version == "" {
+		var metadata mavenMetadata
+		if err := u.getXML(u.NeoForgeMavenURL+"/maven-metadata.xml", &metadata); err != nil {
+			return nil, fmt.Errorf("failed to get NeoForge versions: %w", err)
+		}
+		// Versions are listed oldest first; betas only count off the stable channel or
+		// when nothing stable exists
+		var latest string
+		for _, v := range metadata.Versions {
+			if !strings.HasPrefix(v, prefix) {
+				continue
+			}
+			latest = v
+			if !strings.Contains(v, "-beta") {
+				version = v
+			}
+		}
+		if version == "" || u.cfg.UpdateChannel != "stable" {
+			version = latest
+		}
+		if version == "" {
+			return nil, fmt.Errorf("no NeoForge version found for minecraft %s", mc)
+		}
+	}
+	if !strings.HasPrefix(version, prefix) {
+		return nil, fmt.Errorf("NeoForge %s is not for minecraft %s", version, mc)
+	}
+
+	return &Release{
+		Type:             TypeNeoForge,
+		MinecraftVersion: mc,
+		LoaderVersion:    version,
+		URL:              fmt.Sprintf("%s/%s/neoforge-%s-installer.jar", u.NeoForgeMavenURL, version, version),
+		FileName:         "neoforge-" + version + "-installer.jar",
+		Installer:        true,
+		jarPrefix:        "neoforge-" + version,
+	}, nil
+}
+
+// neoForgePrefix returns the NeoForge version prefix for a game version, e.g. "21.0." for 1.21
+func neoForgePrefix(mc string) (string, error) {
+	parts := strings.Split(mc, ".")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "1" {
+		return "", fmt.Errorf("NeoForge does not support minecraft %s", mc)
+	}
+	if len(parts) == 2 {
+		parts = append(parts, "0")
+	}
+	return parts[1] + "." + parts[2] + ".", nil
+}
+
+// getJSON decodes the JSON response of a GET request into v
+func (u *Updater) getJSON(url string, v interface{}) error {
+	resp, err := u.get(u.client, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", url, err)
+	}
+	return nil
+}
+
+// getXML decodes the XML response of a GET request into v
+func (u *Updater) getXML(url string, v interface{}) error {
+	resp, err := u.get(u.client, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := xml.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", url, err)
+	}
+	return nil
+}
+
+// get sends a GET request and fails on anything but 200
+func (u *Updater) get(client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", u.UserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s failed with status %d", url, resp.StatusCode)
+	}
+	return resp, nil
+}
@@ -0,0 +1,94 @@
+//go:build darwin
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Render returns the property list written by Install
+func Render(def Definition) (string, error) {
+	return LaunchdPlist(def), nil
+}
+
+// Install writes a launchd property list for def and loads it
+func Install(def Definition) (*Status, error) {
+	path, err := plistPath(def)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%s already exists, uninstall the service first", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(LaunchdPlist(def)), 0o644); err != nil { // #nosec G306 -- read by launchd
+		return nil, fmt.Errorf("failed to write property list: %w", err)
+	}
+	if err := run("launchctl", "load", "-w", path); err != nil {
+		return nil, err
+	}
+	return Query(def)
+}
+
+// Uninstall unloads the job and removes its property list
+func Uninstall(def Definition) error {
+	path, err := plistPath(def)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("%s is not installed", path)
+	}
+	if err := run("launchctl", "unload", "-w", path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove property list: %w", err)
+	}
+	return nil
+}
+
+// Query reports whether the property list exists and the job has a running process
+func Query(def Definition) (*Status, error) {
+	path, err := plistPath(def)
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{Path: path}
+	if _, err := os.Stat(path); err != nil {
+		return st, nil
+	}
+	st.Installed = true
+	out, err := runCommand("launchctl", "list", def.Name)
+	st.Running = err == nil && strings.Contains(string(out), `"PID" =`)
+	return st, nil
+}
+
+// Managed is always false: launchd starts the daemon like any other process
+func Managed() bool {
+	return false
+}
+
+// Run runs the daemon; launchd needs no special handling
+func Run(ctx context.Context, run func(ctx context.Context) error) error {
+	return run(ctx)
+}
+
+// plistPath is where the property list for def is written: a launch agent of the user,
+// or a system-wide launch daemon
+func plistPath(def Definition) (string, error) {
+	if !def.User {
+		return filepath.Join("/Library/LaunchDaemons", def.Name+".plist"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", def.Name+".plist"), nil
+}
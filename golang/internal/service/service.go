@@ -0,0 +1,124 @@
+// Package service registers the daemon with the operating system's service manager:
+// systemd on Linux, launchd on macOS and the service control manager on Windows
+package service
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"os/exec"
+	"strings"
+)
+
+// DefaultName is the service name used when none is given
+const DefaultName = "curseforge-autoupdater"
+
+// ErrUnsupported is returned on platforms without a supported service manager
+var ErrUnsupported = errors.New("services are only supported on Linux (systemd), macOS (launchd) and Windows")
+
+// Definition describes the service to install
+type Definition struct {
+	Name        string   // systemd unit, launchd label or Windows service name
+	Description string   // shown by the service manager
+	Executable  string   // absolute path of the CLI binary
+	Args        []string // arguments after the executable, ending in "daemon"
+	WorkingDir  string   // directory relative paths in the config are resolved against
+	LogFile     string   // launchd only: where stdout and stderr go
+	User        bool     // systemd user unit or launchd agent instead of a system-wide service
+}
+
+// Status is the state of the service
+type Status struct {
+	Installed bool
+	Running   bool
+	Path      string // unit file, plist or Windows service name
+}
+
+// runCommand runs a service manager command and returns its combined output; replaced in tests
+var runCommand = func(name string, args ...string) ([]byte, error) {
+	// #nosec G204 -- only systemctl and launchctl are run, with the service name
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// run runs a service manager command, including its output in the error
+func run(name string, args ...string) error {
+	out, err := runCommand(name, args...)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// SystemdUnit renders def as a systemd unit file
+func SystemdUnit(def Definition) string {
+	wantedBy := "multi-user.target"
+	if def.User {
+		wantedBy = "default.target"
+	}
+	execStart := make([]string, 0, len(def.Args)+1)
+	for _, arg := range append([]string{def.Executable}, def.Args...) {
+		execStart = append(execStart, systemdQuote(arg))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Unit]\nDescription=%s\nWants=network-online.target\nAfter=network-online.target\n\n", def.Description)
+	b.WriteString("[Service]\nType=simple\n")
+	if def.WorkingDir != "" {
+		fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdQuote(def.WorkingDir))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(execStart, " "))
+	b.WriteString("ExecReload=/bin/kill -HUP $MAINPID\nRestart=on-failure\nRestartSec=30s\n\n")
+	fmt.Fprintf(&b, "[Install]\nWantedBy=%s\n", wantedBy)
+	return b.String()
+}
+
+// systemdQuote quotes s for a systemd unit when it contains spaces or quotes, and escapes
+// the % specifier character
+func systemdQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// LaunchdPlist renders def as a launchd property list
+func LaunchdPlist(def Definition) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", html.EscapeString(def.Name))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{def.Executable}, def.Args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", html.EscapeString(arg))
+	}
+	b.WriteString("\t</array>\n")
+	if def.WorkingDir != "" {
+		fmt.Fprintf(&b, "\t<key>WorkingDirectory</key>\n\t<string>%s</string>\n", html.EscapeString(def.WorkingDir))
+	}
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	// Restart after crashes, but not after a clean exit
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	if def.LogFile != "" {
+		fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", html.EscapeString(def.LogFile))
+		fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", html.EscapeString(def.LogFile))
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// WindowsCommandLine renders the command line the Windows service runs, quoted like
+// the service control manager expects
+func WindowsCommandLine(def Definition) string {
+	parts := make([]string, 0, len(def.Args)+1)
+	for _, arg := range append([]string{def.Executable}, def.Args...) {
+		if strings.ContainsAny(arg, " \t\"") {
+			arg = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func testDefinition() Definition {
+	return Definition{
+		Name:        "cfu",
+		Description: "CurseForge modpack auto-updater",
+		Executable:  "/opt/cfu/cfu",
+		Args:        []string{"--config", "/srv/my server/config.toml", "daemon"},
+		WorkingDir:  "/srv/my server",
+		LogFile:     "/srv/my server/data/daemon.log",
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	unit := SystemdUnit(testDefinition())
+	for _, want := range []string{
+		"Description=CurseForge modpack auto-updater\n",
+		`WorkingDirectory="/srv/my server"` + "\n",
+		`ExecStart=/opt/cfu/cfu --config "/srv/my server/config.toml" daemon` + "\n",
+		"Restart=on-failure\n",
+		"WantedBy=multi-user.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit is missing %q:\n%s", want, unit)
+		}
+	}
+
+	def := testDefinition()
+	def.User = true
+	if unit := SystemdUnit(def); !strings.Contains(unit, "WantedBy=default.target\n") {
+		t.Errorf("user unit should be wanted by default.target:\n%s", unit)
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := map[string]string{
+		"/opt/cfu":       "/opt/cfu",
+		"/srv/my server": `"/srv/my server"`,
+		`say "hi"`:       `"say \"hi\""`,
+		"100%":           "100%%",
+	}
+	for in, want := range tests {
+		if got := systemdQuote(in); got != want {
+			t.Errorf("systemdQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	def := testDefinition()
+	def.Args = append(def.Args, "--server", "a&b")
+	plist := LaunchdPlist(def)
+	for _, want := range []string{
+		"<key>Label</key>\n\t<string>cfu</string>",
+		"<string>/opt/cfu/cfu</string>\n\t\t<string>--config</string>",
+		"<string>a&amp;b</string>",
+		"<key>WorkingDirectory</key>\n\t<string>/srv/my server</string>",
+		"<key>StandardErrorPath</key>\n\t<string>/srv/my server/data/daemon.log</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("plist is missing %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestWindowsCommandLine(t *testing.T) {
+	def := Definition{Executable: `C:\Program Files\cfu\cfu.exe`, Args: []string{"--config", `D:\mc\config.toml`, "daemon"}}
+	want := `"C:\Program Files\cfu\cfu.exe" --config D:\mc\config.toml daemon`
+	if got := WindowsCommandLine(def); got != want {
+		t.Errorf("WindowsCommandLine() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,118 @@
+//go:build linux
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// unitDir returns the directory systemd loads units from; replaced in tests
+var unitDir = func(user bool) (string, error) {
+	if !user {
+		return "/etc/systemd/system", nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "systemd", "user"), nil
+}
+
+// Render returns the unit file written by Install
+func Render(def Definition) (string, error) {
+	return SystemdUnit(def), nil
+}
+
+// Install writes a systemd unit for def, then enables and starts it
+func Install(def Definition) (*Status, error) {
+	path, err := unitPath(def)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%s already exists, uninstall the service first", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(SystemdUnit(def)), 0o644); err != nil { // #nosec G306 -- read by systemd
+		return nil, fmt.Errorf("failed to write unit file: %w", err)
+	}
+	if err := run("systemctl", systemctlArgs(def, "daemon-reload")...); err != nil {
+		return nil, err
+	}
+	if err := run("systemctl", systemctlArgs(def, "enable", "--now", unitName(def))...); err != nil {
+		return nil, err
+	}
+	return Query(def)
+}
+
+// Uninstall stops and disables the unit and removes its file
+func Uninstall(def Definition) error {
+	path, err := unitPath(def)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("%s is not installed", path)
+	}
+	if err := run("systemctl", systemctlArgs(def, "disable", "--now", unitName(def))...); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove unit file: %w", err)
+	}
+	return run("systemctl", systemctlArgs(def, "daemon-reload")...)
+}
+
+// Query reports whether the unit file exists and the unit is active
+func Query(def Definition) (*Status, error) {
+	path, err := unitPath(def)
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{Path: path}
+	if _, err := os.Stat(path); err != nil {
+		return st, nil
+	}
+	st.Installed = true
+	out, _ := runCommand("systemctl", systemctlArgs(def, "is-active", unitName(def))...)
+	st.Running = strings.TrimSpace(string(out)) == "active"
+	return st, nil
+}
+
+// Managed is always false: systemd starts the daemon like any other process
+func Managed() bool {
+	return false
+}
+
+// Run runs the daemon; systemd needs no special handling
+func Run(ctx context.Context, run func(ctx context.Context) error) error {
+	return run(ctx)
+}
+
+// unitName is the unit name for def
+func unitName(def Definition) string {
+	return def.Name + ".service"
+}
+
+// unitPath is where the unit file for def is written
+func unitPath(def Definition) (string, error) {
+	dir, err := unitDir(def.User)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the systemd unit directory: %w", err)
+	}
+	return filepath.Join(dir, unitName(def)), nil
+}
+
+// systemctlArgs prepends --user for user units
+func systemctlArgs(def Definition, args ...string) []string {
+	if def.User {
+		return append([]string{"--user"}, args...)
+	}
+	return args
+}
@@ -0,0 +1,72 @@
+//go:build linux
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSystemdInstallUninstall(t *testing.T) {
+	dir := t.TempDir()
+	oldDir, oldRun := unitDir, runCommand
+	t.Cleanup(func() { unitDir, runCommand = oldDir, oldRun })
+	unitDir = func(user bool) (string, error) { return dir, nil }
+
+	var commands []string
+	active := false
+	runCommand = func(name string, args ...string) ([]byte, error) {
+		cmd := name + " " + strings.Join(args, " ")
+		commands = append(commands, cmd)
+		switch {
+		case strings.Contains(cmd, "enable --now"):
+			active = true
+		case strings.Contains(cmd, "disable --now"):
+			active = false
+		case strings.Contains(cmd, "is-active") && active:
+			return []byte("active\n"), nil
+		}
+		return []byte("inactive\n"), nil
+	}
+
+	def := testDefinition()
+	def.User = true
+	st, err := Install(def)
+	if err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	path := filepath.Join(dir, "cfu.service")
+	if !st.Installed || !st.Running || st.Path != path {
+		t.Errorf("status after install = %+v", st)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != SystemdUnit(def) {
+		t.Errorf("unit file not written: %v", err)
+	}
+	if _, err := Install(def); err == nil {
+		t.Error("installing twice should fail")
+	}
+
+	if err := Uninstall(def); err != nil {
+		t.Fatalf("Uninstall() error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("unit file should be removed")
+	}
+	st, err = Query(def)
+	if err != nil || st.Installed || st.Running {
+		t.Errorf("status after uninstall = %+v, %v", st, err)
+	}
+
+	want := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable --now cfu.service",
+		"systemctl --user is-active cfu.service",
+		"systemctl --user disable --now cfu.service",
+		"systemctl --user daemon-reload",
+	}
+	if strings.Join(commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !linux && !darwin && !windows
+
+package service
+
+import "context"
+
+// Render is not supported on this platform
+func Render(def Definition) (string, error) {
+	return "", ErrUnsupported
+}
+
+// Install is not supported on this platform
+func Install(def Definition) (*Status, error) {
+	return nil, ErrUnsupported
+}
+
+// Uninstall is not supported on this platform
+func Uninstall(def Definition) error {
+	return ErrUnsupported
+}
+
+// Query is not supported on this platform
+func Query(def Definition) (*Status, error) {
+	return nil, ErrUnsupported
+}
+
+// Managed is always false on this platform
+func Managed() bool {
+	return false
+}
+
+// Run runs the daemon
+func Run(ctx context.Context, run func(ctx context.Context) error) error {
+	return run(ctx)
+}
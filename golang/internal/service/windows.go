@@ -0,0 +1,155 @@
+//go:build windows
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Render describes the service created by Install
+func Render(def Definition) (string, error) {
+	return fmt.Sprintf("Service name: %s\nDisplay name: %s\nCommand line: %s\nStart type:   automatic, restarted 30s after a failure\n",
+		def.Name, def.Description, WindowsCommandLine(def)), nil
+}
+
+// Install creates an automatically started Windows service for def and starts it
+func Install(def Definition) (*Status, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the service control manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(def.Name); err == nil {
+		s.Close()
+		return nil, fmt.Errorf("service %s already exists, uninstall it first", def.Name)
+	}
+	s, err := m.CreateService(def.Name, def.Executable, mgr.Config{
+		DisplayName: def.Description,
+		Description: def.Description,
+		StartType:   mgr.StartAutomatic,
+	}, def.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service: %w", err)
+	}
+	defer s.Close()
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 30 * time.Second}}, uint32((24 * time.Hour).Seconds())); err != nil {
+		return nil, fmt.Errorf("failed to set recovery actions: %w", err)
+	}
+	if err := s.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start service: %w", err)
+	}
+	return Query(def)
+}
+
+// Uninstall stops and deletes the service
+func Uninstall(def Definition) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service control manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(def.Name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", def.Name)
+	}
+	defer s.Close()
+	if status, err := s.Control(svc.Stop); err == nil {
+		for deadline := time.Now().Add(30 * time.Second); status.State != svc.Stopped && time.Now().Before(deadline); {
+			time.Sleep(500 * time.Millisecond)
+			if status, err = s.Query(); err != nil {
+				break
+			}
+		}
+	}
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+	return nil
+}
+
+// Query reports whether the service exists and is running
+func Query(def Definition) (*Status, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the service control manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	st := &Status{Path: def.Name}
+	s, err := m.OpenService(def.Name)
+	if err != nil {
+		return st, nil
+	}
+	defer s.Close()
+	st.Installed = true
+	status, err := s.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service: %w", err)
+	}
+	st.Running = status.State == svc.Running
+	return st, nil
+}
+
+// Managed reports whether the process was started by the service control manager, which
+// starts services in the system directory rather than next to the config file
+func Managed() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+// Run runs the daemon, reporting to the service control manager when started by it, which
+// cancels ctx when the service is stopped
+func Run(ctx context.Context, run func(ctx context.Context) error) error {
+	if !Managed() {
+		return run(ctx)
+	}
+	h := &handler{ctx: ctx, run: run}
+	if err := svc.Run(DefaultName, h); err != nil {
+		return err
+	}
+	return h.err
+}
+
+// handler adapts the daemon to the service control manager
+type handler struct {
+	ctx context.Context
+	run func(ctx context.Context) error
+	err error
+}
+
+// Execute runs the daemon until it stops or the service is stopped
+func (h *handler) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+	ctx, cancel := context.WithCancel(h.ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- h.run(ctx) }()
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case err := <-done:
+			h.err = err
+			changes <- svc.Status{State: svc.StopPending}
+			if err != nil {
+				return true, 1
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				changes <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				cancel()
+			}
+		}
+	}
+}
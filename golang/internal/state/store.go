@@ -0,0 +1,176 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+)
+
+// FileName is the default name of the state file inside the data directory
+const FileName = "state.json"
+
+// State tracks what is installed on the server and when it last changed
+type State struct {
+	ModID             int       `json:"mod_id"`
+	InstalledFileID   int       `json:"installed_file_id"`
+	InstalledVersion  string    `json:"installed_version"`
+	InstalledFileDate time.Time `json:"installed_file_date"`
+	InstalledAt       time.Time `json:"installed_at"`
+	PreviousFileID    int       `json:"previous_file_id"`
+	PreviousVersion   string    `json:"previous_version"`
+	PreviousFileDate  time.Time `json:"previous_file_date"`
+	LastBackup        string    `json:"last_backup"`
+	LastBackupAt      time.Time `json:"last_backup_at"`
+	LastCheckAt       time.Time `json:"last_check_at"`
+	LatestFileID      int       `json:"latest_file_id"`
+	LatestVersion     string    `json:"latest_version"`
+	LastUpdateAt      time.Time `json:"last_update_at"`
+
+	// InstalledGameVersion is the newest Minecraft version the installed file is for;
+	// empty for installs recorded before it was tracked
+	InstalledGameVersion string `json:"installed_game_version,omitempty"`
+	PreviousGameVersion  string `json:"previous_game_version,omitempty"`
+
+	// Mods records the installed version of each tracked mod by its config key
+	Mods map[string]ModState `json:"mods,omitempty"`
+
+	// ServerJar records the server software installed by `server-jar update`
+	ServerJar *ServerJarState `json:"server_jar,omitempty"`
+
+	// Approval is the latest update that needed an operator's approval, see approval.Advance
+	Approval *Approval `json:"approval,omitempty"`
+}
+
+// Approval is an update waiting for, or given, an operator's decision
+type Approval struct {
+	FileID      int       `json:"file_id"`
+	Version     string    `json:"version"`
+	FromVersion string    `json:"from_version"`
+	Status      string    `json:"status"` // pending, approved, denied or expired
+	RequestedAt time.Time `json:"requested_at"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"` // zero when it never expires
+	RemindedAt  time.Time `json:"reminded_at,omitempty"`
+	DecidedBy   string    `json:"decided_by,omitempty"`
+	DecidedAt   time.Time `json:"decided_at,omitempty"`
+}
+
+// ModState is the installed version of one tracked mod
+type ModState struct {
+	Provider    string    `json:"provider"`
+	ProjectID   string    `json:"project_id"`
+	VersionID   string    `json:"version_id"`
+	Version     string    `json:"version"`
+	FileName    string    `json:"file_name"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// ServerJarState is the installed server software
+type ServerJarState struct {
+	Type             string    `json:"type"`
+	MinecraftVersion string    `json:"minecraft_version"`
+	LoaderVersion    string    `json:"loader_version"`
+	JarName          string    `json:"jar_name"`
+	InstalledAt      time.Time `json:"installed_at"`
+}
+
+// IsInstalled reports whether a file has been recorded as installed
+func (s *State) IsInstalled() bool {
+	return s.InstalledFileID > 0
+}
+
+// Store persists State as JSON on disk
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a store backed by the given file
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the location of the state file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Exists reports whether the state file has been written yet
+func (s *Store) Exists() bool {
+	return filesystem.FileExists(s.path)
+}
+
+// Load reads the state from disk; a missing file yields an empty state
+func (s *Store) Load() (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Save writes the state to disk atomically
+func (s *Store) Save(st *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save(st)
+}
+
+// InstalledVersion returns the installed modpack version, or empty when none is recorded
+// or the state cannot be read
+func (s *Store) InstalledVersion() string {
+	st, err := s.Load()
+	if err != nil {
+		return ""
+	}
+	return st.InstalledVersion
+}
+
+// Update loads the state, applies fn and saves the result atomically
+func (s *Store) Update(fn func(*State) error) (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(st); err != nil {
+		return nil, err
+	}
+	if err := s.save(st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// load reads the state file; s.mu must be held
+func (s *Store) load() (*State, error) {
+	// #nosec G304 -- path comes from configuration
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, fmt.Errorf("failed to read state file %s: %w", s.path, err)
+	}
+
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", s.path, err)
+	}
+	return &st, nil
+}
+
+// save writes the state file; s.mu must be held
+func (s *Store) save(st *State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	if err := filesystem.SafeWriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", s.path, err)
+	}
+	return nil
+}
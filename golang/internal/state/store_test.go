@@ -0,0 +1,118 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStoreLoadMissing(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "data", FileName))
+	if s.Exists() {
+		t.Error("Exists before the first save")
+	}
+	st, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.IsInstalled() || st.InstalledVersion != "" {
+		t.Errorf("state of a missing file = %+v, want an empty one", st)
+	}
+}
+
+func TestStoreSaveAndLoad(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "data", FileName))
+	installed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := &State{
+		ModID:            1,
+		InstalledFileID:  100,
+		InstalledVersion: "Pack 1.0.0",
+		InstalledAt:      installed,
+		LastBackup:       "pre-update.zip",
+		Mods:             map[string]ModState{"jei": {Provider: "modrinth", Version: "15.2.0"}},
+	}
+	if err := s.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Exists() {
+		t.Error("Exists after a save = false")
+	}
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(s.Path()); err != nil || info.Mode().Perm() != 0o600 {
+			t.Errorf("state file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+		}
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsInstalled() || got.InstalledVersion != want.InstalledVersion || !got.InstalledAt.Equal(installed) ||
+		got.LastBackup != want.LastBackup || got.Mods["jei"].Version != "15.2.0" {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+	if v := s.InstalledVersion(); v != "Pack 1.0.0" {
+		t.Errorf("InstalledVersion = %q", v)
+	}
+}
+
+func TestStoreUpdate(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), FileName))
+	if err := s.Save(&State{InstalledVersion: "1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failing update leaves the file as it was
+	if _, err := s.Update(func(st *State) error {
+		st.InstalledVersion = "2.0.0"
+		return errors.New("install failed")
+	}); err == nil {
+		t.Fatal("Update with a failing fn succeeded")
+	}
+	if v := s.InstalledVersion(); v != "1.0.0" {
+		t.Errorf("InstalledVersion after a failed update = %q, want 1.0.0", v)
+	}
+
+	// Updates run one at a time, so none is lost
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Update(func(st *State) error {
+				st.LatestFileID++
+				return nil
+			}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	st, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.LatestFileID != 20 || st.InstalledVersion != "1.0.0" {
+		t.Errorf("state after concurrent updates = %d, %q; want 20, 1.0.0", st.LatestFileID, st.InstalledVersion)
+	}
+}
+
+func TestStoreCorruptFile(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), FileName))
+	if err := os.WriteFile(s.Path(), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load(); err == nil {
+		t.Error("Load of a corrupt file succeeded")
+	}
+	if _, err := s.Update(func(*State) error { return nil }); err == nil {
+		t.Error("Update of a corrupt file succeeded")
+	}
+	if v := s.InstalledVersion(); v != "" {
+		t.Errorf("InstalledVersion of a corrupt file = %q, want empty", v)
+	}
+}
@@ -0,0 +1,256 @@
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/klauspost/compress/zip"
+)
+
+// maxModChangelogs limits how many changelogs are fetched for one update; further
+// changed mods are still listed
+const maxModChangelogs = 30
+
+// maxChangelogExcerpt is the longest changelog excerpt kept per mod, in runes
+const maxChangelogExcerpt = 500
+
+// packManifest is the part of a CurseForge modpack's manifest.json listing its mods
+type packManifest struct {
+	Minecraft struct {
+		Version    string `json:"version"`
+		ModLoaders []struct {
+			ID string `json:"id"` // e.g. forge-47.2.0
+		} `json:"modLoaders"`
+	} `json:"minecraft"`
+	Files []struct {
+		ProjectID int `json:"projectID"`
+		FileID    int `json:"fileID"`
+	} `json:"files"`
+	Overrides string `json:"overrides"` // folder with the pack's configs and other files
+}
+
+// SetModChangelogs makes updates list the mods that changed between the installed and
+// the new modpack version, with an excerpt of each new file's changelog
+func (u *Updater) SetModChangelogs(enabled bool) {
+	u.modChangelogs = enabled
+}
+
+// Changelog returns the changelog of a modpack file as plain text. It is empty for other
+// pack providers, which do not publish one.
+func (u *Updater) Changelog(file *api.ModFile) (string, error) {
+	if u.pack != nil {
+		return "", nil
+	}
+	return u.client.GetModFileChangelog(u.opts.ModID, file.ID)
+}
+
+// modChanges compares the manifests of two modpack files and looks up the names, versions
+// and changelogs of the mods that differ
+func (u *Updater) modChanges(fromFileID, toFileID int) ([]history.ModChange, error) {
+	from, err := u.manifestFiles(fromFileID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := u.manifestFiles(toFileID)
+	if err != nil {
+		return nil, err
+	}
+
+	type change struct {
+		project, fromFile, toFile int
+	}
+	var changes []change
+	var projectIDs, fileIDs []int
+	for project, file := range to {
+		if from[project] != file {
+			changes = append(changes, change{project: project, fromFile: from[project], toFile: file})
+		}
+	}
+	for project, file := range from {
+		if _, ok := to[project]; !ok {
+			changes = append(changes, change{project: project, fromFile: file})
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	for _, c := range changes {
+		projectIDs = append(projectIDs, c.project)
+		for _, f := range []int{c.fromFile, c.toFile} {
+			if f != 0 {
+				fileIDs = append(fileIDs, f)
+			}
+		}
+	}
+
+	names, files, err := u.lookupMods(projectIDs, fileIDs)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[int]string, len(files))
+	for id, f := range files {
+		versions[id] = f.DisplayName
+	}
+
+	result := make([]history.ModChange, 0, len(changes))
+	for _, c := range changes {
+		mc := history.ModChange{ProjectID: c.project, Name: names[c.project]}
+		if mc.Name == "" {
+			mc.Name = fmt.Sprintf("Project %d", c.project)
+		}
+		if c.fromFile != 0 {
+			mc.FromVersion = versionOrID(versions, c.fromFile)
+		}
+		if c.toFile != 0 {
+			mc.ToVersion = versionOrID(versions, c.toFile)
+		}
+		result = append(result, mc)
+	}
+	sort.Slice(result, func(i, j int) bool { return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name) })
+
+	fetched := 0
+	for i := range result {
+		if result[i].ToVersion == "" || fetched == maxModChangelogs {
+			continue
+		}
+		fetched++
+		toFile := to[result[i].ProjectID]
+		changelog, err := u.client.GetModFileChangelog(result[i].ProjectID, toFile)
+		if err != nil {
+			u.logger.Debug("failed to get mod changelog", "project_id", result[i].ProjectID, "file_id", toFile, "error", err)
+			continue
+		}
+		result[i].Changelog = excerpt(changelog, maxChangelogExcerpt)
+	}
+	return result, nil
+}
+
+// lookupMods returns the names of the projects and the files with the given IDs, keyed by ID
+func (u *Updater) lookupMods(projectIDs, fileIDs []int) (map[int]string, map[int]api.ModFile, error) {
+	names := make(map[int]string, len(projectIDs))
+	mods, err := u.client.GetMods(projectIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up changed mods: %w", err)
+	}
+	for _, m := range mods {
+		names[m.ID] = m.Name
+	}
+	files := make(map[int]api.ModFile, len(fileIDs))
+	list, err := u.client.GetFiles(fileIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up changed mod files: %w", err)
+	}
+	for _, f := range list {
+		files[f.ID] = f
+	}
+	return names, files, nil
+}
+
+// manifestFiles maps the project IDs in a modpack file's manifest.json to their file IDs
+func (u *Updater) manifestFiles(fileID int) (map[int]int, error) {
+	manifest, err := u.manifest(fileID)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[int]int, len(manifest.Files))
+	for _, mf := range manifest.Files {
+		files[mf.ProjectID] = mf.FileID
+	}
+	return files, nil
+}
+
+// manifest downloads a modpack file and reads its manifest.json. Manifests never change,
+// so each is only downloaded once.
+func (u *Updater) manifest(fileID int) (*packManifest, error) {
+	if manifest, ok := u.manifests[fileID]; ok {
+		return manifest, nil
+	}
+	path, err := u.downloadModpack(fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+	manifest, err := readManifest(path, fileID)
+	if err != nil {
+		return nil, err
+	}
+	u.cacheManifest(fileID, manifest)
+	return manifest, nil
+}
+
+// cacheManifest keeps the manifest of a modpack file, see manifest
+func (u *Updater) cacheManifest(fileID int, manifest *packManifest) {
+	if u.manifests == nil {
+		u.manifests = map[int]*packManifest{}
+	}
+	u.manifests[fileID] = manifest
+}
+
+// downloadModpack downloads a modpack file to a temporary file, which the caller removes
+func (u *Updater) downloadModpack(fileID int) (string, error) {
+	url, err := u.client.GetModFileDownloadURL(u.opts.ModID, fileID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get download URL for modpack file %d: %w", fileID, err)
+	}
+	tmp, err := os.CreateTemp("", "modpack-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if err := u.client.DownloadFile(url, tmp); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to download modpack file %d: %w", fileID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// readManifest reads manifest.json from the downloaded modpack file at path
+func readManifest(path string, fileID int) (*packManifest, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("modpack file %d is not a zip: %w", fileID, err)
+	}
+	defer reader.Close()
+	for _, f := range reader.File {
+		if f.Name != "manifest.json" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		var manifest packManifest
+		if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+			return nil, fmt.Errorf("invalid manifest.json in modpack file %d: %w", fileID, err)
+		}
+		return &manifest, nil
+	}
+	return nil, fmt.Errorf("modpack file %d has no manifest.json", fileID)
+}
+
+// versionOrID returns the display name of a file, or its ID when it was not found
+func versionOrID(versions map[int]string, fileID int) string {
+	if v := versions[fileID]; v != "" {
+		return v
+	}
+	return fmt.Sprintf("file %d", fileID)
+}
+
+// excerpt shortens s to at most n runes, ending in an ellipsis when cut
+func excerpt(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
@@ -0,0 +1,102 @@
+package updater
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/klauspost/compress/zip"
+)
+
+// modpackZip builds a client modpack whose manifest maps project IDs to file IDs
+func modpackZip(t *testing.T, files map[int]int) []byte {
+	var manifest packManifest
+	for project, file := range files {
+		manifest.Files = append(manifest.Files, struct {
+			ProjectID int `json:"projectID"`
+			FileID    int `json:"fileID"`
+		}{project, file})
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewEncoder(w).Encode(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestModChanges(t *testing.T) {
+	packs := map[int][]byte{
+		100: modpackZip(t, map[int]int{1: 11, 2: 21, 3: 31}),
+		200: modpackZip(t, map[int]int{1: 11, 2: 22, 4: 41}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mods/1/files/", func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		_, _ = fmt.Sscanf(r.URL.Path, "/mods/1/files/%d/download-url", &id)
+		_ = json.NewEncoder(w).Encode(map[string]string{"data": "http://" + r.Host + fmt.Sprintf("/download/%d", id)})
+	})
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		_, _ = fmt.Sscanf(r.URL.Path, "/download/%d", &id)
+		_, _ = w.Write(packs[id])
+	})
+	mux.HandleFunc("POST /mods", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string][]api.ModInfo{"data": {
+			{ID: 2, Name: "Create"}, {ID: 3, Name: "Old Mod"}, {ID: 4, Name: "applied energistics"},
+		}})
+	})
+	mux.HandleFunc("POST /mods/files", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			FileIDs []int `json:"fileIds"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		var files []api.ModFile
+		for _, id := range body.FileIDs {
+			files = append(files, api.ModFile{ID: id, DisplayName: fmt.Sprintf("v%d", id)})
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]api.ModFile{"data": files})
+	})
+	mux.HandleFunc("/mods/2/files/22/changelog", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"data": "<p>Fixed &amp; improved</p><ul><li>Trains</li></ul>"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	client := api.NewClient("test")
+	client.BaseURL = srv.URL
+	u := New(client, server.NewBackupManager(dir, dir, false, 0), state.NewStore(filepath.Join(dir, state.FileName)), Options{ModID: 1})
+
+	mods, err := u.modChanges(100, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range mods {
+		got = append(got, fmt.Sprintf("%s %q->%q %q", m.Name, m.FromVersion, m.ToVersion, m.Changelog))
+	}
+	want := []string{
+		`applied energistics ""->"v41" ""`,
+		`Create "v21"->"v22" "Fixed & improved\nTrains"`,
+		`Old Mod "v31"->"" ""`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("mod changes =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,54 @@
+package updater
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/provider"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+// Channels are the values of update_channel, from the most to the least stable
+var Channels = []string{"stable", "beta", "alpha"}
+
+// ChannelStatus is the file a release channel would install
+type ChannelStatus struct {
+	Channel         string    `json:"channel"` // stable, beta or alpha
+	FileID          int       `json:"file_id"`
+	Version         string    `json:"version"`
+	Published       time.Time `json:"published"`
+	UpdateAvailable bool      `json:"update_available"` // it would replace the installed file
+	Tracked         bool      `json:"tracked"`          // the channel is update_channel
+}
+
+// releasePolicy is the release policy for channel
+func (u *Updater) releasePolicy(channel string) provider.Policy {
+	return provider.Policy{Channel: channel, MinAge: u.minFileAge, Now: u.clock.Now()}
+}
+
+// channels returns what every release channel would install instead of the installed
+// file in st, from the files found by the last latestFile. Channels that would install
+// nothing are left out.
+func (u *Updater) channels(st *state.State) []ChannelStatus {
+	out := []ChannelStatus{}
+	for _, channel := range Channels {
+		v, _ := u.releasePolicy(channel).Select(u.versions)
+		if v == nil {
+			continue
+		}
+		id, err := strconv.Atoi(v.ID)
+		if err != nil {
+			continue
+		}
+		out = append(out, ChannelStatus{
+			Channel:         channel,
+			FileID:          id,
+			Version:         v.Name,
+			Published:       v.Published,
+			UpdateAvailable: updateAvailable(st, &api.ModFile{ID: id, DisplayName: v.Name, FileDate: v.Published}, u.opts.GameVersion),
+			Tracked:         channel == u.opts.ReleaseChannel,
+		})
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package updater
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+func TestCheckChannels(t *testing.T) {
+	dir := t.TempDir()
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	store := state.NewStore(filepath.Join(dir, state.FileName))
+	u := New(client, nil, store, Options{ModID: 1, ReleaseChannel: "stable", DownloadPath: filepath.Join(dir, "downloads")})
+
+	now := time.Now()
+	cf.latest = api.ModFile{ID: 3, DisplayName: "1.2.0-alpha", ReleaseType: api.ReleaseTypeAlpha, FileDate: now}
+	cf.earlier = []api.ModFile{
+		{ID: 2, DisplayName: "1.1.0-beta", ReleaseType: api.ReleaseTypeBeta, FileDate: now.Add(-time.Hour)},
+		{ID: 1, DisplayName: "1.0.0", ReleaseType: api.ReleaseTypeRelease, FileDate: now.Add(-2 * time.Hour)},
+	}
+	if _, err := store.Update(func(st *state.State) error {
+		st.InstalledFileID, st.InstalledVersion, st.InstalledFileDate = 1, "1.0.0", now.Add(-2*time.Hour)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := u.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.UpdateAvailable {
+		t.Errorf("stable channel has no update, got %+v", res.Latest)
+	}
+	want := []ChannelStatus{
+		{Channel: "stable", FileID: 1, Version: "1.0.0", UpdateAvailable: false, Tracked: true},
+		{Channel: "beta", FileID: 2, Version: "1.1.0-beta", UpdateAvailable: true},
+		{Channel: "alpha", FileID: 3, Version: "1.2.0-alpha", UpdateAvailable: true},
+	}
+	if len(res.Channels) != len(want) {
+		t.Fatalf("Channels = %+v, want %+v", res.Channels, want)
+	}
+	for i, c := range res.Channels {
+		c.Published = time.Time{}
+		if c != want[i] {
+			t.Errorf("Channels[%d] = %+v, want %+v", i, c, want[i])
+		}
+	}
+}
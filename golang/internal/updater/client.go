@@ -0,0 +1,53 @@
+package updater
+
+import (
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+)
+
+// ClientPack is the client file of the modpack that matches an installed server pack, the
+// version players install to join the server
+type ClientPack struct {
+	FileID      int    `json:"file_id"`
+	Version     string `json:"version"`
+	PageURL     string `json:"page_url"`
+	DownloadURL string `json:"download_url,omitempty"` // empty when the author disallows third-party downloads
+}
+
+// clientPack returns the client file that goes with installed, the file installed for
+// latest, or nil when no separate server pack was installed. CurseForge links the two
+// through serverPackFileId on the client file and parentProjectFileId on the server pack.
+// The update has already succeeded, so a failed lookup is only logged.
+func (u *Updater) clientPack(latest, installed *api.ModFile) *ClientPack {
+	if u.pack != nil {
+		return nil
+	}
+	client := latest
+	switch {
+	case latest.IsServerPack:
+		if latest.ParentProjectFileID == 0 {
+			return nil
+		}
+		file, err := u.client.GetModFile(u.opts.ModID, latest.ParentProjectFileID)
+		if err != nil {
+			u.logger.Warn("failed to look up the client pack", "file_id", latest.ParentProjectFileID, "error", err)
+			return nil
+		}
+		client = file
+	case installed.ID == latest.ID:
+		return nil
+	}
+
+	downloadURL := client.DownloadURL
+	if downloadURL == "" {
+		var err error
+		if downloadURL, err = u.client.GetModFileDownloadURL(u.opts.ModID, client.ID); err != nil {
+			u.logger.Debug("client pack has no download URL", "file_id", client.ID, "error", err)
+		}
+	}
+	return &ClientPack{
+		FileID:      client.ID,
+		Version:     client.DisplayName,
+		PageURL:     u.manualDownload(client).ProjectURL,
+		DownloadURL: downloadURL,
+	}
+}
@@ -0,0 +1,60 @@
+package updater
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+func TestUpdateClientPack(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	backups := server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0)
+	backups.SetClock(fake)
+	store := state.NewStore(filepath.Join(dir, "data", state.FileName))
+	u := New(client, backups, store, Options{ModID: 1, ServerPath: serverPath, DownloadPath: filepath.Join(dir, "downloads")})
+	u.SetClock(fake)
+	bus := events.NewBus(nil)
+	var finished map[string]interface{}
+	bus.Subscribe(func(e events.Event) { finished = e.Data }, events.UpdateFinished)
+	u.SetBus(bus)
+
+	cf.publish(t, 100, "1.0.0", fake.Now(), map[string]string{"mods/a.jar": "v1"})
+	res, err := u.Update(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Client != nil {
+		t.Errorf("server pack without a parent file: Client = %+v, want nil", res.Client)
+	}
+
+	fake.Advance(time.Hour)
+	cf.publish(t, 201, "2.0.0", fake.Now(), map[string]string{"mods/a.jar": "v2"})
+	cf.latest.ParentProjectFileID = 200
+	cf.files[200] = api.ModFile{ID: 200, ModID: 1, DisplayName: "Pack 2.0.0", DownloadURL: "https://edge.forgecdn.net/files/200/pack.zip"}
+	if res, err = u.Update(false); err != nil {
+		t.Fatal(err)
+	}
+	want := ClientPack{
+		FileID:      200,
+		Version:     "Pack 2.0.0",
+		PageURL:     "https://www.curseforge.com/minecraft/modpacks/test/files/200",
+		DownloadURL: "https://edge.forgecdn.net/files/200/pack.zip",
+	}
+	if res.Client == nil || *res.Client != want {
+		t.Fatalf("Client = %+v, want %+v", res.Client, want)
+	}
+	if finished["client_version"] != want.Version || finished["client_url"] != want.PageURL || finished["client_download_url"] != want.DownloadURL {
+		t.Errorf("update_finished data = %v, want the client pack", finished)
+	}
+}
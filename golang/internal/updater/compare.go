@@ -0,0 +1,178 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/version"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+)
+
+// ErrCompareUnsupported is returned by Compare for pack providers other than CurseForge,
+// whose files have no manifest.json to compare
+var ErrCompareUnsupported = errors.New("only CurseForge modpack files can be compared")
+
+// PackFile describes one side of a PackComparison
+type PackFile struct {
+	FileID    int    `json:"file_id"`
+	Version   string `json:"version"`
+	Minecraft string `json:"minecraft"`
+	Loaders   string `json:"loaders"` // e.g. forge-47.2.0
+}
+
+// ModDiff is a mod that differs between two modpack files
+type ModDiff struct {
+	ProjectID   int    `json:"project_id"`
+	Name        string `json:"name"`
+	FromFileID  int    `json:"from_file_id,omitempty"` // zero when the mod was added
+	FromVersion string `json:"from_version,omitempty"`
+	ToFileID    int    `json:"to_file_id,omitempty"` // zero when the mod was removed
+	ToVersion   string `json:"to_version,omitempty"`
+}
+
+// PackComparison lists the mods that differ between two modpack files
+type PackComparison struct {
+	From PackFile
+	To   PackFile
+
+	Added      []ModDiff
+	Removed    []ModDiff
+	Upgraded   []ModDiff
+	Downgraded []ModDiff
+	Unchanged  int // mods in the same file in both
+}
+
+// Compare reads the manifest.json of two modpack files and lists the mods the second one
+// adds, removes, and ships in a newer or older file. A server pack is compared by the
+// client file it belongs to, as only that has a manifest. Nothing is installed.
+func (u *Updater) Compare(fromFileID, toFileID int) (*PackComparison, error) {
+	if u.pack != nil {
+		return nil, ErrCompareUnsupported
+	}
+	from, fromManifest, err := u.comparedFile(fromFileID)
+	if err != nil {
+		return nil, err
+	}
+	to, toManifest, err := u.comparedFile(toFileID)
+	if err != nil {
+		return nil, err
+	}
+	result := &PackComparison{
+		From:       from,
+		To:         to,
+		Added:      []ModDiff{},
+		Removed:    []ModDiff{},
+		Upgraded:   []ModDiff{},
+		Downgraded: []ModDiff{},
+	}
+
+	fromFiles, toFiles := map[int]int{}, map[int]int{}
+	for _, mf := range fromManifest.Files {
+		fromFiles[mf.ProjectID] = mf.FileID
+	}
+	for _, mf := range toManifest.Files {
+		toFiles[mf.ProjectID] = mf.FileID
+	}
+	var diffs []ModDiff
+	for project, file := range toFiles {
+		if old := fromFiles[project]; old == file {
+			result.Unchanged++
+		} else {
+			diffs = append(diffs, ModDiff{ProjectID: project, FromFileID: old, ToFileID: file})
+		}
+	}
+	for project, file := range fromFiles {
+		if _, ok := toFiles[project]; !ok {
+			diffs = append(diffs, ModDiff{ProjectID: project, FromFileID: file})
+		}
+	}
+	if len(diffs) == 0 {
+		return result, nil
+	}
+
+	var projectIDs, fileIDs []int
+	for _, d := range diffs {
+		projectIDs = append(projectIDs, d.ProjectID)
+		for _, f := range []int{d.FromFileID, d.ToFileID} {
+			if f != 0 {
+				fileIDs = append(fileIDs, f)
+			}
+		}
+	}
+	names, files, err := u.lookupMods(projectIDs, fileIDs)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[int]string, len(files))
+	for id, f := range files {
+		versions[id] = f.DisplayName
+	}
+	for _, d := range diffs {
+		d.Name = names[d.ProjectID]
+		if d.Name == "" {
+			d.Name = fmt.Sprintf("Project %d", d.ProjectID)
+		}
+		if d.FromFileID != 0 {
+			d.FromVersion = versionOrID(versions, d.FromFileID)
+		}
+		if d.ToFileID != 0 {
+			d.ToVersion = versionOrID(versions, d.ToFileID)
+		}
+		switch {
+		case d.FromFileID == 0:
+			result.Added = append(result.Added, d)
+		case d.ToFileID == 0:
+			result.Removed = append(result.Removed, d)
+		case newerFile(files[d.ToFileID], files[d.FromFileID], d.ToFileID, d.FromFileID, to.Minecraft):
+			result.Upgraded = append(result.Upgraded, d)
+		default:
+			result.Downgraded = append(result.Downgraded, d)
+		}
+	}
+	for _, list := range [][]ModDiff{result.Added, result.Removed, result.Upgraded, result.Downgraded} {
+		sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) })
+	}
+	return result, nil
+}
+
+// comparedFile looks up a modpack file and reads its manifest, going to the client file
+// for a server pack
+func (u *Updater) comparedFile(fileID int) (PackFile, *packManifest, error) {
+	file, err := u.client.GetModFile(u.opts.ModID, fileID)
+	if err != nil {
+		return PackFile{}, nil, fmt.Errorf("failed to get modpack file %d: %w", fileID, err)
+	}
+	if file.IsServerPack {
+		if file.ParentProjectFileID == 0 {
+			return PackFile{}, nil, fmt.Errorf("file %d is a server pack without a client file to compare", fileID)
+		}
+		if file, err = u.client.GetModFile(u.opts.ModID, file.ParentProjectFileID); err != nil {
+			return PackFile{}, nil, fmt.Errorf("failed to get the client file of server pack %d: %w", fileID, err)
+		}
+	}
+	manifest, err := u.manifest(file.ID)
+	if err != nil {
+		return PackFile{}, nil, err
+	}
+	return PackFile{
+		FileID:    file.ID,
+		Version:   file.DisplayName,
+		Minecraft: manifest.Minecraft.Version,
+		Loaders:   loaderIDs(manifest),
+	}, manifest, nil
+}
+
+// newerFile reports whether the mod file a is newer than b, by the versions in their
+// names, skipping the Minecraft version gameVersion, then their dates and then their IDs,
+// as for modpack updates
+func newerFile(a, b api.ModFile, aID, bID int, gameVersion string) bool {
+	if cmp, ok := version.CompareNames(a.DisplayName, b.DisplayName, gameVersion); ok && cmp != 0 {
+		return cmp > 0
+	}
+	if !a.FileDate.Equal(b.FileDate) {
+		return a.FileDate.After(b.FileDate)
+	}
+	return aID > bID
+}
@@ -0,0 +1,95 @@
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+func TestCompare(t *testing.T) {
+	packs := map[int][]byte{
+		100: modpackZip(t, map[int]int{1: 11, 2: 21, 3: 31, 5: 51}),
+		200: modpackZip(t, map[int]int{1: 11, 2: 22, 4: 41, 5: 50}),
+	}
+	versions := map[int]string{21: "Create 0.5.1", 22: "Create 0.6.0", 31: "Old Mod 1.0", 41: "AE2 15.0", 50: "JEI 15.1.0", 51: "JEI 15.2.0"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mods/1/files/", func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		if _, err := fmt.Sscanf(r.URL.Path, "/mods/1/files/%d/download-url", &id); err == nil {
+			_ = json.NewEncoder(w).Encode(map[string]string{"data": "http://" + r.Host + fmt.Sprintf("/download/%d", id)})
+			return
+		}
+		_, _ = fmt.Sscanf(r.URL.Path, "/mods/1/files/%d", &id)
+		file := api.ModFile{ID: id, ModID: 1, DisplayName: fmt.Sprintf("Pack %d", id)}
+		if id == 201 {
+			// The server pack of 200
+			file.IsServerPack, file.ParentProjectFileID = true, 200
+		}
+		_ = json.NewEncoder(w).Encode(map[string]api.ModFile{"data": file})
+	})
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		_, _ = fmt.Sscanf(r.URL.Path, "/download/%d", &id)
+		_, _ = w.Write(packs[id])
+	})
+	mux.HandleFunc("POST /mods", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string][]api.ModInfo{"data": {
+			{ID: 2, Name: "Create"}, {ID: 3, Name: "Old Mod"}, {ID: 4, Name: "Applied Energistics 2"}, {ID: 5, Name: "JEI"},
+		}})
+	})
+	mux.HandleFunc("POST /mods/files", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			FileIDs []int `json:"fileIds"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		var files []api.ModFile
+		for _, id := range body.FileIDs {
+			files = append(files, api.ModFile{ID: id, DisplayName: versions[id]})
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]api.ModFile{"data": files})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	client := api.NewClient("test")
+	client.BaseURL = srv.URL
+	u := New(client, server.NewBackupManager(dir, dir, false, 0), state.NewStore(filepath.Join(dir, state.FileName)), Options{ModID: 1})
+
+	cmp, err := u.Compare(100, 201)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp.From.FileID != 100 || cmp.To.FileID != 200 || cmp.To.Version != "Pack 200" {
+		t.Errorf("From, To = %+v, %+v; want the server pack compared by its client file", cmp.From, cmp.To)
+	}
+	if cmp.Unchanged != 1 {
+		t.Errorf("Unchanged = %d, want 1", cmp.Unchanged)
+	}
+	var got []string
+	for _, group := range []struct {
+		name string
+		mods []ModDiff
+	}{{"added", cmp.Added}, {"removed", cmp.Removed}, {"upgraded", cmp.Upgraded}, {"downgraded", cmp.Downgraded}} {
+		for _, m := range group.mods {
+			got = append(got, fmt.Sprintf("%s %s %q->%q", group.name, m.Name, m.FromVersion, m.ToVersion))
+		}
+	}
+	want := []string{
+		`added Applied Energistics 2 ""->"AE2 15.0"`,
+		`removed Old Mod "Old Mod 1.0"->""`,
+		`upgraded Create "Create 0.5.1"->"Create 0.6.0"`,
+		`downgraded JEI "JEI 15.2.0"->"JEI 15.1.0"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("comparison =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,96 @@
+package updater
+
+import (
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strconv"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/downloads"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/plugin"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/provider"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+// NewFromConfig wires the API client, backup manager, state store and history log
+// from cfg, logging through logger
+func NewFromConfig(cfg *config.Config, logger *slog.Logger) *Updater {
+	client := api.NewClientFromConfig(cfg)
+	client.Logger = logger
+	store := state.NewStore(filepath.Join(cfg.DataDir, state.FileName))
+	backups := server.NewBackupManager(cfg.ServerPath, cfg.BackupPath, cfg.Backup.Compression, cfg.Backup.RetentionDays)
+	backups.SetLogger(logger)
+	backups.SetTrigger(server.TriggerUpdate)
+	backups.SetVersionSource(store.InstalledVersion)
+	backups.SetRestoreOwner(cfg.Backup.RestoreOwner)
+	backups.SetSnapshot(cfg.Backup.Snapshot)
+	backups.SetEncryption(cfg.Backup.Encryption)
+	backups.SetRetention(server.NewRetentionPolicy(cfg.Backup))
+
+	u := New(
+		client,
+		backups,
+		store,
+		Options{
+			ModID:          cfg.ModpackID,
+			GameVersion:    cfg.GameVersion,
+			ReleaseChannel: cfg.UpdateChannel,
+			ServerPath:     cfg.ServerPath,
+			DownloadPath:   cfg.DownloadPath,
+			ManualPath:     cfg.ManualDownloadPath,
+		},
+	)
+	if cfg.ModpackProvider == provider.NameFTB {
+		u.SetPackProvider(provider.NewFTB(cfg))
+	}
+	u.SetHistory(history.NewLog(filepath.Join(cfg.DataDir, history.FileName)))
+	u.SetPlugins(plugin.NewRunner(cfg.Plugins, logger))
+	u.SetPreserve(cfg.Preserve, filepath.Join(cfg.DataDir, PackFilesDir))
+	u.SetModChangelogs(cfg.ModChangelogs)
+	u.SetDifferentialSync(cfg.DifferentialSync)
+	u.SetKeepDownloads(cfg.Download.KeepVersions)
+	u.SetCache(downloads.NewCache(cfg.Download.CacheDir, cfg.Download.CacheMaxSizeBytes()))
+	u.SetMinFileAge(cfg.ReleasePolicy.MinAge)
+	u.SetConfirmMinecraftUpgrades(cfg.UpdatePolicy.ConfirmMinecraftUpgrades)
+	if mm := cfg.Server.MaintenanceMode; mm.Enabled {
+		port := mm.Port
+		if port == 0 {
+			port = server.ServerPort(cfg.ServerPath)
+		}
+		u.SetMaintenanceMode(net.JoinHostPort("", strconv.Itoa(port)), mm.MOTD, mm.ETA)
+	}
+	if cfg.Server.Mode == server.ModePterodactyl {
+		u.SetUploader(server.NewPterodactylServer(cfg.Server.Pterodactyl, cfg.HTTP))
+	}
+	// In process mode only the web UI, which runs the server, can follow its log
+	if cfg.Server.HealthCheck.Enabled && (cfg.Server.Mode == server.ModeDocker || cfg.Server.Mode == server.ModeSystemd) {
+		if c, err := server.NewController(cfg); err != nil {
+			logger.Warn("startup checks after updates are disabled", "error", err)
+		} else {
+			u.SetStartupCheck(c, cfg.Server.ShutdownTimeout, cfg.Server.HealthCheck)
+			if len(cfg.Server.PostUpdateTasks) > 0 && cfg.Server.RCON.Address != "" {
+				u.SetPostUpdateTasks(server.NewRCONCommander(cfg.Server.RCON), cfg.Server.PostUpdateTasks)
+			}
+		}
+	}
+	if cfg.Server.Mode == server.ModeKubernetes {
+		ready := cfg.Server.Kubernetes.ReadyFile
+		if ready == "" {
+			ready = filepath.Join(cfg.ServerPath, DefaultReadyFile)
+		}
+		u.SetReadyFile(ready)
+		if cfg.Server.Kubernetes.RestartOnUpdate {
+			if k, err := server.NewKubernetesServer(cfg.Server.Kubernetes.Namespace, cfg.Server.Kubernetes.Deployment); err != nil {
+				logger.Warn("rollout restarts after updates are disabled", "error", err)
+			} else {
+				u.SetRestarter(k)
+			}
+		}
+	}
+	u.SetLogger(logger)
+	return u
+}
@@ -0,0 +1,186 @@
+package updater
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/checksums"
+)
+
+// PackDiff compares the latest server pack with what is installed in ServerPath
+type PackDiff struct {
+	FromVersion     string
+	ToVersion       string
+	UpdateAvailable bool
+
+	ModsAdded   []string    // jars in the pack's mods folder that the server does not have
+	ModsRemoved []string    // jars in the server's mods folder that the pack no longer ships
+	ModsChanged []ModChange // mods the pack ships in another file than the server has
+
+	FilesAdded   []string // other files the pack adds
+	FilesChanged []string // other files whose content differs from the server's
+	Preserved    []string // changed files that an update keeps or merges, see SetPreserve
+}
+
+// ModChange is a mod that is shipped in a different jar, usually another version
+type ModChange struct {
+	From string
+	To   string
+}
+
+// Diff downloads the latest server pack, unpacks it into a temporary directory and
+// compares it with ServerPath without changing the server
+func (u *Updater) Diff() (*PackDiff, error) {
+	check, err := u.Check()
+	if err != nil {
+		return nil, err
+	}
+	diff := &PackDiff{
+		FromVersion:     check.State.InstalledVersion,
+		ToVersion:       check.Latest.DisplayName,
+		UpdateAvailable: check.UpdateAvailable,
+	}
+
+	file, err := u.installFile(check.Latest)
+	if err != nil {
+		return nil, err
+	}
+	source, err := u.resolveSource(file)
+	if err != nil {
+		return nil, err
+	}
+	downloaded, err := u.download(file, source)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "curseforge-diff-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	files, err := install(downloaded, dir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %w", file.FileName, err)
+	}
+
+	packMods := make(map[string]bool)
+	for _, name := range files {
+		if isModJar(name) {
+			packMods[path.Base(name)] = true
+			continue
+		}
+		same, err := sameContent(filepath.Join(dir, filepath.FromSlash(name)), filepath.Join(u.opts.ServerPath, filepath.FromSlash(name)))
+		switch {
+		case os.IsNotExist(err):
+			diff.FilesAdded = append(diff.FilesAdded, name)
+		case err != nil:
+			return nil, err
+		case !same:
+			diff.FilesChanged = append(diff.FilesChanged, name)
+			if isPreserved(u.preserve, name) {
+				diff.Preserved = append(diff.Preserved, name)
+			}
+		}
+	}
+
+	serverMods := make(map[string]bool)
+	entries, err := os.ReadDir(filepath.Join(u.opts.ServerPath, "mods"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list installed mods: %w", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() && isModJar("mods/"+e.Name()) {
+			serverMods[e.Name()] = true
+		}
+	}
+
+	for name := range packMods {
+		if !serverMods[name] {
+			diff.ModsAdded = append(diff.ModsAdded, name)
+			continue
+		}
+		same, err := sameContent(filepath.Join(dir, "mods", name), filepath.Join(u.opts.ServerPath, "mods", name))
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			diff.ModsChanged = append(diff.ModsChanged, ModChange{From: name, To: name})
+		}
+	}
+	for name := range serverMods {
+		if !packMods[name] {
+			diff.ModsRemoved = append(diff.ModsRemoved, name)
+		}
+	}
+	diff.pairModChanges()
+
+	sort.Strings(diff.FilesAdded)
+	sort.Strings(diff.FilesChanged)
+	sort.Strings(diff.Preserved)
+	sort.Slice(diff.ModsChanged, func(i, j int) bool { return diff.ModsChanged[i].To < diff.ModsChanged[j].To })
+	return diff, nil
+}
+
+// pairModChanges turns a removed and an added jar of the same mod into one change
+func (d *PackDiff) pairModChanges() {
+	added := make(map[string][]string)
+	for _, name := range d.ModsAdded {
+		added[checksums.ModName(name)] = append(added[checksums.ModName(name)], name)
+	}
+	var removed []string
+	paired := make(map[string]bool)
+	sort.Strings(d.ModsRemoved)
+	for _, name := range d.ModsRemoved {
+		candidates := added[checksums.ModName(name)]
+		if len(candidates) != 1 || paired[candidates[0]] {
+			removed = append(removed, name)
+			continue
+		}
+		paired[candidates[0]] = true
+		d.ModsChanged = append(d.ModsChanged, ModChange{From: name, To: candidates[0]})
+	}
+	var stillAdded []string
+	for _, name := range d.ModsAdded {
+		if !paired[name] {
+			stillAdded = append(stillAdded, name)
+		}
+	}
+	sort.Strings(stillAdded)
+	d.ModsAdded, d.ModsRemoved = stillAdded, removed
+}
+
+// isModJar reports whether name, relative to the server with forward slashes, is a jar
+// directly inside the mods folder
+func isModJar(name string) bool {
+	return path.Dir(name) == "mods" && strings.EqualFold(path.Ext(name), ".jar")
+}
+
+// sameContent reports whether the files at a and b hold the same bytes. The error
+// satisfies os.IsNotExist when b does not exist.
+func sameContent(a, b string) (bool, error) {
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+	dataA, err := os.ReadFile(a) // #nosec G304 -- unpacked from the pack
+	if err != nil {
+		return false, err
+	}
+	dataB, err := os.ReadFile(b) // #nosec G304 -- inside the server directory
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(dataA, dataB), nil
+}
@@ -0,0 +1,83 @@
+package updater
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: filepath.Join(dir, "downloads"),
+		})
+	u.SetPreserve([]string{"server.properties"}, "")
+
+	cf.publish(t, 100, "1.0.0", time.Now(), map[string]string{
+		"mods/jei-1.20.1-forge-15.2.0.jar": "jei 15.2",
+		"mods/create-1.20.1-0.5.1.jar":     "create",
+		"mods/oldmod-1.0.jar":              "old",
+		"mods/patched.jar":                 "v1",
+		"config/jei.toml":                  "a",
+		"config/create.toml":               "a",
+		"server.properties":                "motd=Pack",
+	})
+	if _, err := u.Update(false); err != nil {
+		t.Fatal(err)
+	}
+
+	cf.publish(t, 200, "1.1.0", time.Now().Add(time.Hour), map[string]string{
+		"mods/jei-1.20.1-forge-15.3.0.jar": "jei 15.3",
+		"mods/create-1.20.1-0.5.1.jar":     "create",
+		"mods/newmod-2.0.jar":              "new",
+		"mods/patched.jar":                 "v2",
+		"config/jei.toml":                  "b",
+		"config/create.toml":               "a",
+		"config/newmod.toml":               "a",
+		"server.properties":                "motd=Pack 2",
+	})
+	diff, err := u.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff.FromVersion != "1.0.0" || diff.ToVersion != "1.1.0" || !diff.UpdateAvailable {
+		t.Fatalf("unexpected versions %+v", diff)
+	}
+	for name, got := range map[string]interface{}{
+		"mods added":    diff.ModsAdded,
+		"mods removed":  diff.ModsRemoved,
+		"mods changed":  diff.ModsChanged,
+		"files added":   diff.FilesAdded,
+		"files changed": diff.FilesChanged,
+		"preserved":     diff.Preserved,
+	} {
+		want := map[string]string{
+			"mods added":    "[newmod-2.0.jar]",
+			"mods removed":  "[oldmod-1.0.jar]",
+			"mods changed":  "[{jei-1.20.1-forge-15.2.0.jar jei-1.20.1-forge-15.3.0.jar} {patched.jar patched.jar}]",
+			"files added":   "[config/newmod.toml]",
+			"files changed": "[config/jei.toml server.properties]",
+			"preserved":     "[server.properties]",
+		}[name]
+		if fmt.Sprint(got) != want {
+			t.Errorf("%s = %v, want %s", name, got, want)
+		}
+	}
+
+	// The server itself is left alone
+	assertFile(t, filepath.Join(serverPath, "mods", "patched.jar"), "v1")
+	assertFile(t, filepath.Join(serverPath, "config", "jei.toml"), "a")
+}
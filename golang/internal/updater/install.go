@@ -0,0 +1,49 @@
+package updater
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/archive"
+	"github.com/klauspost/compress/zip"
+)
+
+// install unpacks a downloaded server pack into serverPath; non-zip files are copied as-is.
+// It returns the installed files relative to serverPath, with forward slashes. progress
+// may be nil.
+func install(downloaded, serverPath string, progress archive.ProgressFunc) ([]string, error) {
+	if err := filesystem.EnsureDir(serverPath); err != nil {
+		return nil, fmt.Errorf("failed to create server directory: %w", err)
+	}
+	if !strings.EqualFold(filepath.Ext(downloaded), ".zip") {
+		name := filepath.Base(downloaded)
+		return []string{name}, filesystem.CopyFile(downloaded, filepath.Join(serverPath, name))
+	}
+
+	reader, err := zip.OpenReader(downloaded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer reader.Close()
+	return archive.Extract(reader.File, serverPath, archive.Options{Prefix: commonRoot(reader.File), Progress: progress})
+}
+
+// commonRoot returns the single top-level directory shared by all entries (with trailing slash), or ""
+func commonRoot(files []*zip.File) string {
+	root := ""
+	for _, file := range files {
+		idx := strings.Index(file.Name, "/")
+		if idx < 0 {
+			return ""
+		}
+		dir := file.Name[:idx+1]
+		if root == "" {
+			root = dir
+		} else if dir != root {
+			return ""
+		}
+	}
+	return root
+}
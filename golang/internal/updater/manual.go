@@ -0,0 +1,165 @@
+package updater
+
+import (
+	"crypto/sha1" //nolint:gosec // CurseForge publishes SHA-1 hashes
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/provider"
+)
+
+// ManualDownload is a file whose author only allows it to be downloaded from the CurseForge website
+type ManualDownload struct {
+	ModID      int
+	FileID     int
+	FileName   string
+	ProjectURL string
+	Size       int64
+}
+
+// ManualDownloadError lists files that must be downloaded by hand into Dir
+type ManualDownloadError struct {
+	Dir   string
+	Files []ManualDownload
+}
+
+func (e *ManualDownloadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d file(s) must be downloaded manually into %s:", len(e.Files), e.Dir)
+	for _, f := range e.Files {
+		fmt.Fprintf(&b, "\n  %s from %s", f.FileName, f.ProjectURL)
+	}
+	return b.String()
+}
+
+// ManualWaitFunc is called with the files that are missing from dir; returning nil makes
+// the updater look for them again, an error aborts the update
+type ManualWaitFunc func(dir string, files []ManualDownload) error
+
+// SetManualWait makes the updater wait for manual downloads instead of failing right away
+func (u *Updater) SetManualWait(fn ManualWaitFunc) {
+	u.manualWait = fn
+}
+
+// downloadSource is where the file to install comes from: a URL, a verified file on
+// disk, or a version of the pack provider
+type downloadSource struct {
+	url     string
+	local   string
+	version *provider.Version
+}
+
+// resolveSource finds out where file can be fetched from, before anything on disk is changed
+func (u *Updater) resolveSource(file *api.ModFile) (downloadSource, error) {
+	if u.pack != nil {
+		return downloadSource{version: u.packVersion}, nil
+	}
+	if file.DownloadURL != "" {
+		return downloadSource{url: file.DownloadURL}, nil
+	}
+	url, err := u.client.GetModFileDownloadURL(u.opts.ModID, file.ID)
+	if err == nil {
+		return downloadSource{url: url}, nil
+	}
+	if !errors.Is(err, api.ErrDistributionDisallowed) {
+		return downloadSource{}, fmt.Errorf("failed to get download URL for file %d: %w", file.ID, err)
+	}
+
+	for {
+		path, err := u.findManual(file)
+		if err != nil {
+			return downloadSource{}, err
+		}
+		if path != "" {
+			u.logger.Info("using manually downloaded file", "file", path)
+			return downloadSource{local: path}, nil
+		}
+
+		missing := []ManualDownload{u.manualDownload(file)}
+		u.logger.Warn("manual download required", "file_name", file.FileName, "url", missing[0].ProjectURL, "dir", u.opts.ManualPath)
+		if u.manualWait == nil {
+			return downloadSource{}, &ManualDownloadError{Dir: u.opts.ManualPath, Files: missing}
+		}
+		if err := u.manualWait(u.opts.ManualPath, missing); err != nil {
+			return downloadSource{}, err
+		}
+	}
+}
+
+// manualDownload describes file for the user, linking to its page on CurseForge
+func (u *Updater) manualDownload(file *api.ModFile) ManualDownload {
+	projectURL := fmt.Sprintf("https://www.curseforge.com/projects/%d", u.opts.ModID)
+	if mod, err := u.client.GetMod(u.opts.ModID); err == nil && mod.Links.WebsiteURL != "" {
+		projectURL = fmt.Sprintf("%s/files/%d", strings.TrimSuffix(mod.Links.WebsiteURL, "/"), file.ID)
+	}
+	return ManualDownload{
+		ModID:      u.opts.ModID,
+		FileID:     file.ID,
+		FileName:   file.FileName,
+		ProjectURL: projectURL,
+		Size:       file.FileLength,
+	}
+}
+
+// findManual returns the path of file in the manual download folder, or "" when it is not there yet
+func (u *Updater) findManual(file *api.ModFile) (string, error) {
+	if u.opts.ManualPath == "" {
+		return "", nil
+	}
+	path := filepath.Join(u.opts.ManualPath, filepath.Base(file.FileName))
+	if !filesystem.FileExists(path) {
+		return "", nil
+	}
+	if err := verifyFile(path, file); err != nil {
+		return "", fmt.Errorf("manually downloaded %s does not match file %d: %w", path, file.ID, err)
+	}
+	return path, nil
+}
+
+// verifyFile checks path against the SHA-1 hash, or else the fingerprint, that CurseForge lists for file
+func verifyFile(path string, file *api.ModFile) error {
+	f, err := os.Open(path) // #nosec G304 -- path is inside the configured manual download folder
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, hash := range file.Hashes {
+		if hash.Algo != api.HashAlgoSHA1 {
+			continue
+		}
+		h := sha1.New() //nolint:gosec // CurseForge publishes SHA-1 hashes
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, hash.Value) {
+			return fmt.Errorf("sha1 is %s, expected %s", got, hash.Value)
+		}
+		return nil
+	}
+
+	if file.FileFingerprint != 0 {
+		got, err := api.Fingerprint(f)
+		if err != nil {
+			return err
+		}
+		if int64(got) != file.FileFingerprint {
+			return fmt.Errorf("fingerprint is %d, expected %d", got, file.FileFingerprint)
+		}
+		return nil
+	}
+
+	if info, err := f.Stat(); err != nil {
+		return err
+	} else if file.FileLength > 0 && info.Size() != file.FileLength {
+		return fmt.Errorf("size is %d bytes, expected %d", info.Size(), file.FileLength)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/version"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+// ErrMinecraftUpgrade is returned by Update and PlanUpdate for an update to a newer
+// Minecraft version while SetConfirmMinecraftUpgrades is on
+var ErrMinecraftUpgrade = errors.New("the update moves to a newer Minecraft version")
+
+// MinecraftUpgrade is an update that moves the server to a newer Minecraft version. Worlds
+// are converted when the new version first loads them and the old version cannot open
+// them afterwards, so going back needs a backup.
+type MinecraftUpgrade struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// String describes the upgrade for logs and notifications
+func (m MinecraftUpgrade) String() string {
+	return fmt.Sprintf("Minecraft %s -> %s", m.From, m.To)
+}
+
+// SetConfirmMinecraftUpgrades makes Update refuse updates to a newer Minecraft version with
+// ErrMinecraftUpgrade, so that they are only installed on purpose
+func (u *Updater) SetConfirmMinecraftUpgrades(confirm bool) {
+	u.confirmMinecraftUpgrades = confirm
+}
+
+// MinecraftVersion returns the newest Minecraft version file is for, or "" when it lists
+// none. Loaders and environments in the game versions, such as "Forge", are skipped.
+func MinecraftVersion(file *api.ModFile) string {
+	names := append([]string{}, file.GameVersions...)
+	for _, v := range file.SortableGameVersions {
+		names = append(names, v.GameVersionName)
+	}
+
+	var newest string
+	var newestVersion *version.Version
+	for _, name := range names {
+		v, err := version.Parse(name)
+		if err != nil {
+			continue
+		}
+		if newestVersion == nil || v.IsNewer(newestVersion) {
+			newest, newestVersion = name, v
+		}
+	}
+	return newest
+}
+
+// minecraftUpgrade returns the Minecraft version change from the installed file to latest,
+// or nil when it stays the same, goes back or is unknown. Installs recorded before the
+// Minecraft version was tracked look the installed file up on CurseForge.
+func (u *Updater) minecraftUpgrade(st *state.State, latest *api.ModFile) *MinecraftUpgrade {
+	if !st.IsInstalled() {
+		return nil
+	}
+	from := st.InstalledGameVersion
+	if from == "" && u.pack == nil {
+		installed, err := u.client.GetModFile(u.opts.ModID, st.InstalledFileID)
+		if err != nil {
+			u.logger.Debug("cannot tell the Minecraft version of the installed file", "file_id", st.InstalledFileID, "error", err)
+			return nil
+		}
+		from = MinecraftVersion(installed)
+	}
+	to := MinecraftVersion(latest)
+	if from == "" || to == "" {
+		return nil
+	}
+	if cmp, err := version.CompareVersions(to, from); err != nil || cmp <= 0 {
+		return nil
+	}
+	return &MinecraftUpgrade{From: from, To: to}
+}
+
+// confirmMinecraftUpgrade returns ErrMinecraftUpgrade for up while
+// SetConfirmMinecraftUpgrades is on
+func (u *Updater) confirmMinecraftUpgrade(up *MinecraftUpgrade) error {
+	if up == nil || !u.confirmMinecraftUpgrades {
+		return nil
+	}
+	return fmt.Errorf("%w, from %s to %s; worlds loaded by %s cannot be opened by %s again", ErrMinecraftUpgrade, up.From, up.To, up.To, up.From)
+}
@@ -0,0 +1,93 @@
+package updater
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+func TestMinecraftVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		file api.ModFile
+		want string
+	}{
+		{"loaders skipped", api.ModFile{GameVersions: []string{"Forge", "1.20", "1.20.1", "Server"}}, "1.20.1"},
+		{"numeric order", api.ModFile{GameVersions: []string{"1.9.4", "1.10"}}, "1.10"},
+		{"sortable only", api.ModFile{SortableGameVersions: []api.SortableGameVersion{{GameVersionName: "NeoForge"}, {GameVersionName: "1.21.1"}}}, "1.21.1"},
+		{"none", api.ModFile{GameVersions: []string{"Fabric"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := MinecraftVersion(&tt.file); got != tt.want {
+			t.Errorf("%s: MinecraftVersion = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateMinecraftUpgrade(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	backups := server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0)
+	backups.SetClock(fake)
+	store := state.NewStore(filepath.Join(dir, "data", state.FileName))
+	u := New(client, backups, store, Options{ModID: 1, ServerPath: serverPath, DownloadPath: filepath.Join(dir, "downloads")})
+	u.SetClock(fake)
+	u.SetConfirmMinecraftUpgrades(true)
+
+	cf.publish(t, 100, "1.0.0", fake.Now(), map[string]string{"mods/a.jar": "v1"})
+	cf.latest.GameVersions = []string{"1.20.1", "Forge"}
+	if _, err := u.Update(false); err != nil {
+		t.Fatalf("first install: %v", err)
+	}
+
+	fake.Advance(time.Hour)
+	cf.publish(t, 200, "2.0.0", fake.Now(), map[string]string{"mods/a.jar": "v2"})
+	cf.latest.GameVersions = []string{"1.21.1", "Forge"}
+	res, err := u.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MinecraftUpgrade{From: "1.20.1", To: "1.21.1"}
+	if res.MinecraftUpgrade == nil || *res.MinecraftUpgrade != want {
+		t.Fatalf("Check MinecraftUpgrade = %v, want %v", res.MinecraftUpgrade, want)
+	}
+
+	if _, err := u.Update(false); !errors.Is(err, ErrMinecraftUpgrade) {
+		t.Fatalf("Update while confirming Minecraft upgrades = %v, want ErrMinecraftUpgrade", err)
+	}
+	assertFile(t, filepath.Join(serverPath, "mods", "a.jar"), "v1")
+
+	u.SetConfirmMinecraftUpgrades(false)
+	up, err := u.Update(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up.MinecraftUpgrade == nil || *up.MinecraftUpgrade != want {
+		t.Errorf("Update MinecraftUpgrade = %v, want %v", up.MinecraftUpgrade, want)
+	}
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.InstalledGameVersion != "1.21.1" || st.PreviousGameVersion != "1.20.1" {
+		t.Errorf("state game versions = %q, previous %q", st.InstalledGameVersion, st.PreviousGameVersion)
+	}
+
+	rolledBack, err := u.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rolledBack.State.InstalledGameVersion != "1.20.1" {
+		t.Errorf("game version after rollback = %q, want 1.20.1", rolledBack.State.InstalledGameVersion)
+	}
+}
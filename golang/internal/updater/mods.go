@@ -0,0 +1,308 @@
+package updater
+
+import (
+	"crypto/sha1" //nolint:gosec // providers publish SHA-1 hashes
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/version"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/downloads"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/provider"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+// ModUpdater keeps the tracked [[mods]] in the server's mods folder up to date, each
+// from its own provider
+type ModUpdater struct {
+	cfg       *config.Config
+	providers map[string]provider.Provider
+	store     *state.Store
+	modsDir   string
+	cache     *downloads.Cache // nil without download.cache_dir
+	clock     clock.Clock
+	logger    *slog.Logger
+}
+
+// ModStatus compares the installed and latest version of one tracked mod
+type ModStatus struct {
+	Mod             config.ModConfig
+	Installed       *state.ModState // nil when not installed by the updater
+	Latest          *provider.Version
+	UpdateAvailable bool
+	HeldBack        []provider.Held // newer versions skipped for their channel or age, newest first
+	Err             error
+}
+
+// NewModUpdater creates an updater for the tracked mods in cfg
+func NewModUpdater(cfg *config.Config, logger *slog.Logger) *ModUpdater {
+	return &ModUpdater{
+		cfg:       cfg,
+		providers: map[string]provider.Provider{},
+		store:     state.NewStore(filepath.Join(cfg.DataDir, state.FileName)),
+		modsDir:   filepath.Join(cfg.ServerPath, "mods"),
+		cache:     downloads.NewCache(cfg.Download.CacheDir, cfg.Download.CacheMaxSizeBytes()),
+		clock:     clock.Real(),
+		logger:    logger,
+	}
+}
+
+// SetProvider replaces the provider registered under p.Name()
+func (m *ModUpdater) SetProvider(p provider.Provider) {
+	m.providers[p.Name()] = p
+}
+
+// SetClock replaces the clock used for timestamps
+func (m *ModUpdater) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+// provider returns the provider for mod, creating it on first use. Generic mods get a
+// provider of their own, registered under their key.
+func (m *ModUpdater) provider(mod config.ModConfig) (provider.Provider, error) {
+	name := mod.ProviderName()
+	if name == provider.NameGeneric {
+		name = mod.Key()
+	}
+	if p, ok := m.providers[name]; ok {
+		return p, nil
+	}
+
+	var p provider.Provider
+	if mod.ProviderName() == provider.NameGeneric {
+		p = provider.NewGeneric(m.cfg, provider.GenericSource{
+			URL:         mod.URL,
+			VersionURL:  mod.VersionURL,
+			VersionPath: mod.VersionPath,
+			FileName:    mod.FileName,
+		})
+	} else {
+		var err error
+		if p, err = provider.New(name, m.cfg); err != nil {
+			return nil, err
+		}
+	}
+	m.providers[name] = p
+	return p, nil
+}
+
+// dir returns the folder mod is installed into
+func (m *ModUpdater) dir(mod config.ModConfig) string {
+	if mod.Path == "" {
+		return m.modsDir
+	}
+	return filepath.Join(m.cfg.ServerPath, filepath.Clean(mod.Path))
+}
+
+// Check looks up the latest version of every tracked mod; lookup errors are reported per mod
+func (m *ModUpdater) Check() ([]ModStatus, error) {
+	st, err := m.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	newest := m.prefetch()
+	statuses := make([]ModStatus, 0, len(m.cfg.Mods))
+	for _, mod := range m.cfg.Mods {
+		status := ModStatus{Mod: mod}
+		if installed, ok := st.Mods[mod.Key()]; ok {
+			status.Installed = &installed
+		}
+		status.Latest, status.HeldBack, status.Err = m.latest(mod, newest[mod.Key()])
+		if status.Err == nil {
+			status.UpdateAvailable = status.Installed == nil || status.Installed.VersionID != status.Latest.ID
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// prefetch looks up the newest versions of the tracked mods, keyed by mod key, in one
+// batch per provider that supports it. Mods left out are looked up one by one.
+func (m *ModUpdater) prefetch() map[string][]provider.Version {
+	batches := map[provider.Batcher][]config.ModConfig{}
+	for _, mod := range m.cfg.Mods {
+		p, err := m.provider(mod)
+		if err != nil {
+			continue
+		}
+		if b, ok := p.(provider.Batcher); ok {
+			batches[b] = append(batches[b], mod)
+		}
+	}
+
+	newest := map[string][]provider.Version{}
+	for b, mods := range batches {
+		if len(mods) < 2 {
+			continue
+		}
+		ids := make([]string, len(mods))
+		for i, mod := range mods {
+			ids[i] = mod.ProjectID()
+		}
+		versions, err := b.NewestVersions(ids, m.cfg.GameVersion)
+		if err != nil {
+			m.logger.Warn("batch lookup of mods failed, looking them up one by one", "error", err)
+			continue
+		}
+		for _, mod := range mods {
+			for _, v := range versions[mod.ProjectID()] {
+				if mod.Loader == "" || containsFold(v.Loaders, mod.Loader) {
+					newest[mod.Key()] = append(newest[mod.Key()], v)
+				}
+			}
+		}
+	}
+	return newest
+}
+
+// containsFold reports whether values holds s, ignoring case
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// latest returns the newest version of mod for the configured game version that the
+// release policy allows, with the newer versions it held back. newest, when prefetched,
+// are the newest versions of each channel, newest first: when the policy allows the first
+// one it is the answer, otherwise all versions of the mod are looked up.
+func (m *ModUpdater) latest(mod config.ModConfig, newest []provider.Version) (*provider.Version, []provider.Held, error) {
+	p, err := m.provider(mod)
+	if err != nil {
+		return nil, nil, err
+	}
+	policy := provider.Policy{
+		Channel:     m.channel(mod),
+		MinAge:      m.cfg.ReleasePolicy.MinAge,
+		Now:         m.clock.Now(),
+		Pin:         mod.Pin,
+		Ignore:      mod.IgnoreVersions,
+		GameVersion: m.cfg.GameVersion,
+	}
+	if mod.Versions != "" {
+		if policy.Range, err = version.ParseConstraint(mod.Versions); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(newest) > 0 && policy.Reason(newest[0]) == "" {
+		return &newest[0], nil, nil
+	}
+	return provider.LatestAllowed(p, mod.ProjectID(), provider.Filter{
+		GameVersion: m.cfg.GameVersion,
+		Loader:      mod.Loader,
+	}, policy)
+}
+
+// channel returns the least stable channel mod may be installed from: its own, release
+// with release_policy.stable_mods, or update_channel
+func (m *ModUpdater) channel(mod config.ModConfig) string {
+	switch {
+	case mod.Channel != "":
+		return mod.Channel
+	case m.cfg.ReleasePolicy.StableMods:
+		return provider.ChannelRelease
+	}
+	return m.cfg.UpdateChannel
+}
+
+// Update installs the latest version of every mod that has one, replacing the file of the
+// previously installed version. Mods that fail are reported in their status and skipped.
+func (m *ModUpdater) Update() ([]ModStatus, error) {
+	statuses, err := m.Check()
+	if err != nil {
+		return nil, err
+	}
+	for i := range statuses {
+		status := &statuses[i]
+		if status.Err != nil || !status.UpdateAvailable {
+			continue
+		}
+		if status.Err = m.install(status); status.Err != nil {
+			m.logger.Error("mod update failed", "mod", status.Mod.Key(), "error", status.Err)
+		}
+	}
+	return statuses, nil
+}
+
+// install downloads status.Latest into the mods folder and records it in the state
+func (m *ModUpdater) install(status *ModStatus) error {
+	mod, latest := status.Mod, status.Latest
+	p, err := m.provider(mod)
+	if err != nil {
+		return err
+	}
+	dir := m.dir(mod)
+	if err := filesystem.EnsureDir(dir); err != nil {
+		return fmt.Errorf("failed to create mods directory: %w", err)
+	}
+
+	m.logger.Info("downloading mod", "mod", mod.Key(), "version", latest.Name, "file_name", latest.FileName)
+	tmp, err := os.CreateTemp(dir, ".download_*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary download file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha1.New() //nolint:gosec // providers publish SHA-1 hashes
+	hit, err := m.cache.Fetch(latest.SHA1, io.MultiWriter(tmp, h), func(w io.Writer) error {
+		return p.Download(latest, w)
+	})
+	if hit {
+		m.logger.Info("using cached download", "mod", mod.Key(), "file_name", latest.FileName)
+	}
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to download %s: %w", latest.FileName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary download file: %w", err)
+	}
+	if latest.SHA1 != "" {
+		if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, latest.SHA1) {
+			return fmt.Errorf("downloaded %s has sha1 %s, expected %s", latest.FileName, got, latest.SHA1)
+		}
+	}
+
+	target := filepath.Join(dir, filepath.Base(latest.FileName))
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to move download into place: %w", err)
+	}
+	if old := status.Installed; old != nil && old.FileName != "" && old.FileName != filepath.Base(latest.FileName) {
+		if err := os.Remove(filepath.Join(dir, old.FileName)); err != nil && !os.IsNotExist(err) {
+			m.logger.Warn("failed to remove previous mod file", "file", old.FileName, "error", err)
+		}
+	}
+
+	installed := state.ModState{
+		Provider:    mod.ProviderName(),
+		ProjectID:   mod.ProjectID(),
+		VersionID:   latest.ID,
+		Version:     latest.Name,
+		FileName:    filepath.Base(latest.FileName),
+		InstalledAt: m.clock.Now(),
+	}
+	if _, err := m.store.Update(func(st *state.State) error {
+		if st.Mods == nil {
+			st.Mods = map[string]state.ModState{}
+		}
+		st.Mods[mod.Key()] = installed
+		return nil
+	}); err != nil {
+		return err
+	}
+	status.Installed = &installed
+	status.UpdateAvailable = false
+	m.logger.Info("mod updated", "mod", mod.Key(), "version", latest.Name)
+	return nil
+}
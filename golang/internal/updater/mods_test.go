@@ -0,0 +1,189 @@
+package updater
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/provider"
+)
+
+// fakeProvider serves one version per project with the file content set in files
+type fakeProvider struct {
+	name     string
+	versions map[string]provider.Version
+	files    map[string]string
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) GetProject(id string) (*provider.Project, error) {
+	return &provider.Project{ID: id, Name: id}, nil
+}
+
+func (f *fakeProvider) GetVersions(id string, filter provider.Filter) ([]provider.Version, error) {
+	v, ok := f.versions[id]
+	if !ok {
+		return nil, fmt.Errorf("project %s not found", id)
+	}
+	return []provider.Version{v}, nil
+}
+
+func (f *fakeProvider) Download(v *provider.Version, w io.Writer) error {
+	_, err := io.WriteString(w, f.files[v.ID])
+	return err
+}
+
+func TestModUpdaterMixesProviders(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.GetDefaultConfig()
+	cfg.ServerPath = filepath.Join(dir, "server")
+	cfg.DataDir = filepath.Join(dir, "data")
+	cfg.Mods = []config.ModConfig{
+		{ID: 238222, Name: "JEI"},
+		{Project: "sodium", Provider: provider.NameModrinth, Name: "Sodium"},
+	}
+
+	curseforge := &fakeProvider{name: provider.NameCurseForge,
+		versions: map[string]provider.Version{"238222": {ID: "1", Name: "jei-1", FileName: "jei-1.jar"}},
+		files:    map[string]string{"1": "jei v1", "2": "jei v2"},
+	}
+	modrinth := &fakeProvider{name: provider.NameModrinth,
+		versions: map[string]provider.Version{"sodium": {ID: "a", Name: "0.5.0", FileName: "sodium-0.5.0.jar"}},
+		files:    map[string]string{"a": "sodium"},
+	}
+	m := NewModUpdater(cfg, slog.Default())
+	m.SetProvider(curseforge)
+	m.SetProvider(modrinth)
+
+	statuses, err := m.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.Err != nil || s.Installed == nil {
+			t.Fatalf("%s: installed %+v, err %v", s.Mod.Key(), s.Installed, s.Err)
+		}
+	}
+	modsDir := filepath.Join(cfg.ServerPath, "mods")
+	assertFile(t, filepath.Join(modsDir, "jei-1.jar"), "jei v1")
+	assertFile(t, filepath.Join(modsDir, "sodium-0.5.0.jar"), "sodium")
+
+	// A new JEI file replaces the old one; Sodium is left alone
+	curseforge.versions["238222"] = provider.Version{ID: "2", Name: "jei-2", FileName: "jei-2.jar"}
+	statuses, err = m.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[0].UpdateAvailable || statuses[1].UpdateAvailable {
+		t.Fatalf("unexpected update flags %v, %v", statuses[0].UpdateAvailable, statuses[1].UpdateAvailable)
+	}
+	if _, err := m.Update(); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, filepath.Join(modsDir, "jei-2.jar"), "jei v2")
+	if _, err := os.Stat(filepath.Join(modsDir, "jei-1.jar")); !os.IsNotExist(err) {
+		t.Fatalf("old JEI file still present: %v", err)
+	}
+}
+
+func TestModUpdaterStableMods(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.GetDefaultConfig()
+	cfg.ServerPath = filepath.Join(dir, "server")
+	cfg.DataDir = filepath.Join(dir, "data")
+	cfg.UpdateChannel = "beta"
+	cfg.ReleasePolicy.StableMods = true
+	cfg.Mods = []config.ModConfig{
+		{ID: 1, Name: "Stable only"},
+		{ID: 2, Name: "Allows betas", Channel: "beta"},
+	}
+
+	m := NewModUpdater(cfg, slog.Default())
+	m.SetProvider(&fakeProvider{name: provider.NameCurseForge,
+		versions: map[string]provider.Version{
+			"1": {ID: "a", Name: "1.0-beta", Channel: provider.ChannelBeta},
+			"2": {ID: "b", Name: "2.0-beta", Channel: provider.ChannelBeta},
+		},
+	})
+
+	statuses, err := m.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses[0]; s.Err == nil || len(s.HeldBack) != 1 || s.HeldBack[0].Reason != "beta, the channel is release" {
+		t.Errorf("stable mod: err %v, held %+v; want the beta held back", s.Err, s.HeldBack)
+	}
+	if s := statuses[1]; s.Err != nil || s.Latest == nil || s.Latest.ID != "b" {
+		t.Errorf("mod with channel beta: latest %+v, err %v", s.Latest, s.Err)
+	}
+}
+
+// batchProvider is a fakeProvider that also looks up the newest versions in one batch
+type batchProvider struct {
+	fakeProvider
+	newest  map[string][]provider.Version
+	batches int
+	lookups []string
+}
+
+func (b *batchProvider) GetVersions(id string, filter provider.Filter) ([]provider.Version, error) {
+	b.lookups = append(b.lookups, id)
+	return b.fakeProvider.GetVersions(id, filter)
+}
+
+func (b *batchProvider) NewestVersions(ids []string, gameVersion string) (map[string][]provider.Version, error) {
+	b.batches++
+	return b.newest, nil
+}
+
+func TestModUpdaterBatchesLookups(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.GetDefaultConfig()
+	cfg.ServerPath = filepath.Join(dir, "server")
+	cfg.DataDir = filepath.Join(dir, "data")
+	cfg.UpdateChannel = provider.ChannelRelease
+	cfg.Mods = []config.ModConfig{
+		{ID: 1, Name: "Release is newest"},
+		{ID: 2, Name: "Beta is newest"},
+		{ID: 3, Name: "Fabric only", Loader: "fabric"},
+	}
+
+	p := &batchProvider{
+		fakeProvider: fakeProvider{name: provider.NameCurseForge, versions: map[string]provider.Version{
+			"2": {ID: "2-release", Channel: provider.ChannelRelease},
+		}},
+		newest: map[string][]provider.Version{
+			"1": {{ID: "1-release", Channel: provider.ChannelRelease, Loaders: []string{"forge"}}},
+			"2": {
+				{ID: "2-beta", Channel: provider.ChannelBeta},
+				{ID: "2-old", Channel: provider.ChannelRelease},
+			},
+			"3": {
+				{ID: "3-forge", Channel: provider.ChannelRelease, Loaders: []string{"forge"}},
+				{ID: "3-fabric", Channel: provider.ChannelRelease, Loaders: []string{"fabric"}},
+			},
+		},
+	}
+	m := NewModUpdater(cfg, slog.Default())
+	m.SetProvider(p)
+
+	statuses, err := m.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1-release", "2-release", "3-fabric"}
+	for i, s := range statuses {
+		if s.Err != nil || s.Latest == nil || s.Latest.ID != want[i] {
+			t.Errorf("%s: latest %+v, err %v; want %s", s.Mod.Name, s.Latest, s.Err, want[i])
+		}
+	}
+	// Only the mod whose newest version is held back is looked up on its own
+	if p.batches != 1 || len(p.lookups) != 1 || p.lookups[0] != "2" {
+		t.Errorf("batches = %d, lookups = %v; want 1 batch and a lookup of mod 2", p.batches, p.lookups)
+	}
+}
@@ -0,0 +1,206 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/maintenance"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/plugin"
+)
+
+// UpdatePlan describes what Update would do, see PlanUpdate
+type UpdatePlan struct {
+	FromFileID  int
+	FromVersion string
+	ToFileID    int
+	ToVersion   string
+	Skipped     bool     // the latest file is installed and force is not set
+	Steps       []string // what Update would do, in order
+
+	MinecraftUpgrade *MinecraftUpgrade // nil when the Minecraft version stays the same
+}
+
+// PlanUpdate works out what Update(force) would do without downloading, backing up,
+// installing, restarting or recording anything. Every step is also logged.
+func (u *Updater) PlanUpdate(force bool) (*UpdatePlan, error) {
+	latest, _, err := u.latestFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest file: %w", err)
+	}
+	st, err := u.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if !st.IsInstalled() {
+		if err := u.migrateLegacyMetadata(st); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := &UpdatePlan{
+		FromFileID:  st.InstalledFileID,
+		FromVersion: st.InstalledVersion,
+		ToFileID:    latest.ID,
+		ToVersion:   latest.DisplayName,
+	}
+	if !updateAvailable(st, latest, u.opts.GameVersion) && !force {
+		plan.Skipped = true
+		u.logger.Info("dry run: already up to date", "installed_file_id", st.InstalledFileID, "latest_version", latest.DisplayName)
+		return plan, nil
+	}
+	plan.MinecraftUpgrade = u.minecraftUpgrade(st, latest)
+	if err := u.confirmMinecraftUpgrade(plan.MinecraftUpgrade); err != nil {
+		return nil, err
+	}
+
+	file, err := u.installFile(latest)
+	if err != nil {
+		return nil, err
+	}
+	sync := u.syncPlanFor(st.InstalledFileID, latest)
+	var fetch string
+	if sync != nil {
+		defer os.Remove(sync.modpack)
+	} else if fetch, err = u.planSource(file); err != nil {
+		return nil, err
+	}
+
+	step := func(format string, args ...interface{}) {
+		s := fmt.Sprintf(format, args...)
+		plan.Steps = append(plan.Steps, s)
+		u.logger.Info("dry run: would " + s)
+	}
+	plugins := func(stage string) error {
+		names, err := u.pluginsFor(stage)
+		if err != nil {
+			return err
+		}
+		if len(names) > 0 {
+			step("run %s plugins: %s", stage, strings.Join(names, ", "))
+		}
+		return nil
+	}
+
+	if err := plugins(plugin.StagePreUpdate); err != nil {
+		return nil, err
+	}
+	if up := plan.MinecraftUpgrade; up != nil {
+		step("move from Minecraft %s to %s; worlds it loads cannot be opened by %s again", up.From, up.To, up.From)
+	}
+	if u.readyFile != "" {
+		step("remove the ready file %s", u.readyFile)
+	}
+	if u.maintenanceAddr != "" {
+		step("answer players on %s with %q until the update is done, if the server is stopped", u.maintenanceAddr, maintenance.FormatMOTD(u.maintenanceMOTD, latest.DisplayName, u.maintenanceETA))
+	}
+	if filesystem.DirExists(u.opts.ServerPath) {
+		step("back up %s as a pre-update backup", u.opts.ServerPath)
+		if err := plugins(plugin.StagePostBackup); err != nil {
+			return nil, err
+		}
+	}
+	if sync != nil {
+		r := sync.result
+		step("download %d added or changed mods (%d bytes) instead of %s", len(r.Downloaded), r.DownloadBytes, file.FileName)
+		step("delete %d mods from %s and add the new ones", len(r.Removed), filepath.Join(u.opts.ServerPath, modsDir))
+		if len(r.Skipped) > 0 {
+			step("skip %d mods the server does not use: %s", len(r.Skipped), strings.Join(r.Skipped, ", "))
+		}
+		if sync.overrides != "" {
+			step("install the modpack's overrides into the folders the server has")
+		}
+	} else {
+		step("%s into %s", fetch, u.opts.DownloadPath)
+		step("install %s into %s", file.FileName, u.opts.ServerPath)
+	}
+	if len(u.preserve) > 0 {
+		step("keep the local copies of %s", strings.Join(u.preserve, ", "))
+	}
+	if u.uploader != nil {
+		step("upload the installed files to the server")
+	}
+	if err := plugins(plugin.StagePostInstall); err != nil {
+		return nil, err
+	}
+	step("record %s as installed in %s", latest.DisplayName, u.store.Path())
+	if u.keepDownloads > 0 {
+		step("remove all but the %d newest downloads from %s", u.keepDownloads, u.opts.DownloadPath)
+	}
+	if err := plugins(plugin.StagePostUpdate); err != nil {
+		return nil, err
+	}
+	if u.readyFile != "" {
+		step("write the ready file %s", u.readyFile)
+	}
+	if u.restarter != nil {
+		step("restart the server")
+	}
+	if u.startup != nil {
+		action := "fail the update"
+		if u.startupCheck.Rollback {
+			action = "roll back"
+		}
+		step("follow the server log for up to %s and %s if it crashes %d times before it starts", u.startupCheck.Timeout, action, u.startupCheck.MaxCrashes)
+	}
+	if u.startup != nil && u.console != nil {
+		for _, task := range u.tasks {
+			if task.WaitFor != "" {
+				step("run %q in the server console and wait up to %s for %q", task.Command, task.WaitTimeout(), task.WaitFor)
+			} else {
+				step("run %q in the server console", task.Command)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// planSource describes where file would be fetched from, like resolveSource but without
+// waiting for manual downloads
+func (u *Updater) planSource(file *api.ModFile) (string, error) {
+	if u.pack != nil {
+		return fmt.Sprintf("download %s (%d bytes) from %s", file.FileName, file.FileLength, u.pack.Name()), nil
+	}
+	url := file.DownloadURL
+	if url == "" {
+		var err error
+		url, err = u.client.GetModFileDownloadURL(u.opts.ModID, file.ID)
+		if errors.Is(err, api.ErrDistributionDisallowed) {
+			path, err := u.findManual(file)
+			if err != nil {
+				return "", err
+			}
+			if path != "" {
+				return "copy the manually downloaded " + path, nil
+			}
+			return fmt.Sprintf("wait for %s to be downloaded by hand into %s, then copy it", file.FileName, u.opts.ManualPath), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get download URL for file %d: %w", file.ID, err)
+		}
+	}
+	return fmt.Sprintf("download %s (%d bytes) from %s", file.FileName, file.FileLength, url), nil
+}
+
+// pluginsFor returns the names of the plugins subscribed to stage
+func (u *Updater) pluginsFor(stage string) ([]string, error) {
+	if u.plugins == nil {
+		return nil, nil
+	}
+	plugins, err := u.plugins.Plugins()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, p := range plugins {
+		if slices.Contains(p.Stages, stage) {
+			names = append(names, p.Name)
+		}
+	}
+	return names, nil
+}
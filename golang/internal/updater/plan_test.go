@@ -0,0 +1,81 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+func TestPlanUpdate(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	store := state.NewStore(filepath.Join(dir, state.FileName))
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0), store, Options{
+		ModID:        1,
+		ServerPath:   serverPath,
+		DownloadPath: filepath.Join(dir, "downloads"),
+	})
+	u.SetPreserve([]string{"server.properties"}, "")
+
+	cf.publish(t, 100, "1.0.0", time.Now(), map[string]string{"mods/a.jar": "a"})
+	if _, err := u.Update(false); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := u.PlanUpdate(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Skipped || len(plan.Steps) != 0 {
+		t.Errorf("plan for an installed version = %+v, want skipped", plan)
+	}
+
+	cf.publish(t, 200, "1.1.0", time.Now().Add(time.Hour), map[string]string{"mods/a.jar": "a2"})
+	plan, err = u.PlanUpdate(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Skipped || plan.FromVersion != "1.0.0" || plan.ToVersion != "1.1.0" {
+		t.Fatalf("unexpected plan %+v", plan)
+	}
+	steps := strings.Join(plan.Steps, "\n")
+	for _, want := range []string{
+		"back up " + serverPath,
+		"download pack-1.1.0.zip",
+		"install pack-1.1.0.zip into " + serverPath,
+		"keep the local copies of server.properties",
+		"record 1.1.0 as installed",
+	} {
+		if !strings.Contains(steps, want) {
+			t.Errorf("steps are missing %q:\n%s", want, steps)
+		}
+	}
+
+	// Nothing was backed up, downloaded or recorded
+	if _, err := os.Stat(filepath.Join(dir, "backups")); !os.IsNotExist(err) {
+		t.Error("no backup should be created")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "downloads", "pack-1.1.0.zip")); !os.IsNotExist(err) {
+		t.Error("the pack should not be downloaded")
+	}
+	after, err := os.ReadFile(store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Error("the state file should not change")
+	}
+}
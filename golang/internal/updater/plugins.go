@@ -0,0 +1,38 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/plugin"
+)
+
+// SetPlugins runs the given plugins at each stage of an update
+func (u *Updater) SetPlugins(r *plugin.Runner) {
+	u.plugins = r
+}
+
+// runPlugins runs the plugins for stage. Failures at blocking stages are returned so the
+// update stops; at other stages they are only logged by the runner.
+func (u *Updater) runPlugins(stage string, result *UpdateResult, updateErr error) error {
+	if u.plugins == nil {
+		return nil
+	}
+	req := plugin.Request{
+		Stage:       stage,
+		ModID:       u.opts.ModID,
+		FromVersion: result.FromVersion,
+		ToVersion:   result.ToVersion,
+		FromFileID:  result.FromFileID,
+		ToFileID:    result.ToFileID,
+		ServerPath:  u.opts.ServerPath,
+		Backup:      result.Backup,
+	}
+	if updateErr != nil {
+		req.Error = updateErr.Error()
+	}
+	if err := u.plugins.Run(context.Background(), req); err != nil && plugin.Blocking(stage) {
+		return fmt.Errorf("%s plugins failed: %w", stage, err)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+)
+
+// PackFilesDir is the directory inside data_dir holding the pack's copies of preserved
+// properties files
+const PackFilesDir = "pack-files"
+
+// PreserveReport describes how preserved files were put back after an install
+type PreserveReport struct {
+	Restored []string          // files replaced by the pack and restored from before the update
+	Merged   []PropertiesMerge // properties files merged with the pack's copy
+}
+
+// PropertiesMerge is the three-way merge of a local properties file, the copy shipped by
+// the previous pack and the copy shipped by the new one
+type PropertiesMerge struct {
+	File      string
+	FromPack  []string // keys the new pack added or changed, taken over because they were not changed locally
+	Removed   []string // keys the new pack dropped that were not changed locally
+	Conflicts []string // keys where the pack's value differs from a local change; the local value is kept
+}
+
+// SetPreserve keeps files in ServerPath matching patterns across updates. Copies of the
+// properties files shipped by the pack are kept in baseDir to merge the next update against.
+func (u *Updater) SetPreserve(patterns []string, baseDir string) {
+	u.preserve = patterns
+	u.preserveBase = baseDir
+}
+
+// snapshotPreserved reads the files in serverPath matching patterns, keyed by their path
+// relative to serverPath with forward slashes. A pattern matching a directory takes
+// everything inside it.
+func snapshotPreserved(serverPath string, patterns []string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(serverPath, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid preserve pattern %s: %w", pattern, err)
+		}
+		for _, match := range matches {
+			err := filepath.WalkDir(match, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(serverPath, p)
+				if err != nil {
+					return err
+				}
+				data, err := os.ReadFile(p) // #nosec G304 -- found inside the server directory
+				if err != nil {
+					return err
+				}
+				files[filepath.ToSlash(rel)] = data
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to snapshot preserved files: %w", err)
+			}
+		}
+	}
+	return files, nil
+}
+
+// isPreserved reports whether name, or one of the directories containing it, matches patterns
+func isPreserved(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		for candidate := name; candidate != "."; candidate = path.Dir(candidate) {
+			if ok, _ := path.Match(filepath.ToSlash(pattern), candidate); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// restorePreserved puts the snapshot back over the preserved files among installed.
+// Properties files are merged with the pack's copy instead of being replaced.
+func (u *Updater) restorePreserved(snapshot map[string][]byte, installed []string) (*PreserveReport, error) {
+	report := &PreserveReport{}
+	for _, name := range installed {
+		if !isPreserved(u.preserve, name) {
+			continue
+		}
+		target := filepath.Join(u.opts.ServerPath, filepath.FromSlash(name))
+		local, existed := snapshot[name]
+
+		if !strings.EqualFold(path.Ext(name), ".properties") {
+			if !existed {
+				continue
+			}
+			if err := os.WriteFile(target, local, 0o644); err != nil { // #nosec G306 -- read by the server
+				return nil, fmt.Errorf("failed to restore %s: %w", name, err)
+			}
+			report.Restored = append(report.Restored, name)
+			continue
+		}
+
+		pack, err := os.ReadFile(target) // #nosec G304 -- installed inside the server directory
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from the pack: %w", name, err)
+		}
+		if existed {
+			var base []byte
+			if u.preserveBase != "" {
+				// #nosec G304 -- kept by an earlier update inside data_dir
+				base, err = os.ReadFile(filepath.Join(u.preserveBase, filepath.FromSlash(name)))
+				if err != nil && !os.IsNotExist(err) {
+					return nil, fmt.Errorf("failed to read the previous pack's %s: %w", name, err)
+				}
+			}
+			merged, m := mergeProperties(base, local, pack)
+			if err := os.WriteFile(target, merged, 0o644); err != nil { // #nosec G306 -- read by the server
+				return nil, fmt.Errorf("failed to write merged %s: %w", name, err)
+			}
+			m.File = name
+			report.Merged = append(report.Merged, m)
+		}
+		if u.preserveBase != "" {
+			basePath := filepath.Join(u.preserveBase, filepath.FromSlash(name))
+			if err := filesystem.EnsureDir(filepath.Dir(basePath)); err != nil {
+				return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(basePath), err)
+			}
+			if err := os.WriteFile(basePath, pack, 0o600); err != nil {
+				return nil, fmt.Errorf("failed to keep the pack's %s: %w", name, err)
+			}
+		}
+	}
+	sort.Strings(report.Restored)
+	return report, nil
+}
+
+// logPreserved logs what restorePreserved did, warning about merge conflicts
+func (u *Updater) logPreserved(report *PreserveReport) {
+	if len(report.Restored) > 0 {
+		u.logger.Info("preserved files restored", "files", report.Restored)
+	}
+	for _, m := range report.Merged {
+		if len(m.Conflicts) > 0 {
+			u.logger.Warn("kept local values that differ from the new pack", "file", m.File, "keys", m.Conflicts)
+		}
+		u.logger.Info("properties merged", "file", m.File, "from_pack", m.FromPack, "removed", m.Removed, "conflicts", len(m.Conflicts))
+	}
+}
+
+// property is one key=value line of a properties file
+type property struct {
+	key, value string
+}
+
+// parseProperties reads the key=value (or key:value) lines of a properties file in order.
+// Comments, blank lines and line continuations are not kept.
+func parseProperties(data []byte) []property {
+	var props []property
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		idx := strings.IndexAny(line, "=:")
+		if idx < 0 {
+			props = append(props, property{key: line})
+			continue
+		}
+		props = append(props, property{key: strings.TrimSpace(line[:idx]), value: strings.TrimSpace(line[idx+1:])})
+	}
+	return props
+}
+
+// propertyMap indexes props by key
+func propertyMap(props []property) map[string]string {
+	m := make(map[string]string, len(props))
+	for _, p := range props {
+		m[p.key] = p.value
+	}
+	return m
+}
+
+// mergeProperties merges the local copy of a properties file with the new pack's copy,
+// using the previous pack's copy as the common base. Keys the admin did not change follow
+// the pack; local changes win. Without a base every differing key keeps its local value.
+// The result follows the layout of the pack's file, with keys only set locally appended.
+func mergeProperties(base, local, pack []byte) ([]byte, PropertiesMerge) {
+	var m PropertiesMerge
+	baseKnown := base != nil
+	baseProps, localProps, packProps := propertyMap(parseProperties(base)), propertyMap(parseProperties(local)), propertyMap(parseProperties(pack))
+
+	resolved := make(map[string]*string)
+	keys := make(map[string]bool)
+	for _, props := range []map[string]string{baseProps, localProps, packProps} {
+		for k := range props {
+			keys[k] = true
+		}
+	}
+	for k := range keys {
+		b, inBase := baseProps[k]
+		l, inLocal := localProps[k]
+		p, inPack := packProps[k]
+		keep := func() {
+			if inLocal {
+				resolved[k] = &l
+			}
+		}
+		switch {
+		case inLocal == inPack && l == p:
+			keep()
+		case !baseKnown:
+			if inLocal {
+				keep()
+				if inPack {
+					m.Conflicts = append(m.Conflicts, k)
+				}
+			} else {
+				resolved[k] = &p
+				m.FromPack = append(m.FromPack, k)
+			}
+		case inLocal == inBase && l == b:
+			// Not changed locally, so the pack's change goes through
+			if inPack {
+				resolved[k] = &p
+				m.FromPack = append(m.FromPack, k)
+			} else {
+				m.Removed = append(m.Removed, k)
+			}
+		case inPack == inBase && p == b:
+			keep()
+		default:
+			keep()
+			m.Conflicts = append(m.Conflicts, k)
+		}
+	}
+	sort.Strings(m.FromPack)
+	sort.Strings(m.Removed)
+	sort.Strings(m.Conflicts)
+
+	var out bytes.Buffer
+	written := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(pack))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+			out.WriteString(line + "\n")
+			continue
+		}
+		key := trimmed
+		if idx := strings.IndexAny(trimmed, "=:"); idx >= 0 {
+			key = strings.TrimSpace(trimmed[:idx])
+		}
+		value := resolved[key]
+		if value == nil || written[key] {
+			continue
+		}
+		written[key] = true
+		if *value == packProps[key] {
+			out.WriteString(line + "\n")
+		} else {
+			out.WriteString(key + "=" + *value + "\n")
+		}
+	}
+	for _, prop := range parseProperties(local) {
+		if value := resolved[prop.key]; value != nil && !written[prop.key] {
+			written[prop.key] = true
+			out.WriteString(prop.key + "=" + *value + "\n")
+		}
+	}
+	return out.Bytes(), m
+}
@@ -0,0 +1,102 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+func TestMergeProperties(t *testing.T) {
+	base := []byte("#Minecraft server properties\nmotd=A Minecraft Server\nmax-players=20\ndifficulty=easy\nspawn-protection=16\n")
+	local := []byte("motd=My server\nmax-players=20\ndifficulty=easy\nspawn-protection=16\nwhite-list=true\n")
+	pack := []byte("#Minecraft server properties\nmotd=Pack 2.0\nmax-players=40\ndifficulty=normal\nallow-flight=true\n")
+
+	merged, m := mergeProperties(base, local, pack)
+	want := "#Minecraft server properties\nmotd=My server\nmax-players=40\ndifficulty=normal\nallow-flight=true\nwhite-list=true\n"
+	if string(merged) != want {
+		t.Fatalf("merged =\n%s\nwant\n%s", merged, want)
+	}
+	if fmt.Sprint(m.FromPack) != "[allow-flight difficulty max-players]" || fmt.Sprint(m.Removed) != "[spawn-protection]" || fmt.Sprint(m.Conflicts) != "[motd]" {
+		t.Fatalf("unexpected merge report %+v", m)
+	}
+
+	// Without the previous pack's copy, local values win and only new keys are added
+	merged, m = mergeProperties(nil, local, pack)
+	want = "#Minecraft server properties\nmotd=My server\nmax-players=20\ndifficulty=easy\nallow-flight=true\nspawn-protection=16\nwhite-list=true\n"
+	if string(merged) != want {
+		t.Fatalf("merged without base =\n%s\nwant\n%s", merged, want)
+	}
+	if fmt.Sprint(m.FromPack) != "[allow-flight]" || fmt.Sprint(m.Conflicts) != "[difficulty max-players motd]" {
+		t.Fatalf("unexpected merge report without base %+v", m)
+	}
+}
+
+func TestUpdatePreserve(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: filepath.Join(dir, "downloads"),
+		})
+	u.SetPreserve([]string{"server.properties", "ops.json", "config/custom"}, filepath.Join(dir, PackFilesDir))
+
+	cf.publish(t, 100, "1.0.0", time.Now(), map[string]string{
+		"server.properties":    "motd=Pack\ndifficulty=easy\n",
+		"ops.json":             "[]",
+		"config/custom/a.toml": "pack",
+		"config/other/b.toml":  "pack",
+		"mods/a.jar":           "v1",
+	})
+	res, err := u.Update(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Preserved != nil {
+		t.Fatalf("first install has nothing to preserve, got %+v", res.Preserved)
+	}
+
+	for name, content := range map[string]string{
+		"server.properties":    "motd=Mine\ndifficulty=easy\n",
+		"ops.json":             `[{"name":"admin"}]`,
+		"config/custom/a.toml": "mine",
+		"config/other/b.toml":  "mine",
+	} {
+		if err := os.WriteFile(filepath.Join(serverPath, filepath.FromSlash(name)), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cf.publish(t, 200, "1.1.0", time.Now().Add(time.Hour), map[string]string{
+		"server.properties":    "motd=Pack\ndifficulty=hard\n",
+		"ops.json":             "[]",
+		"config/custom/a.toml": "pack 2",
+		"config/other/b.toml":  "pack 2",
+		"mods/a.jar":           "v2",
+	})
+	if res, err = u.Update(false); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, filepath.Join(serverPath, "server.properties"), "motd=Mine\ndifficulty=hard\n")
+	assertFile(t, filepath.Join(serverPath, "ops.json"), `[{"name":"admin"}]`)
+	assertFile(t, filepath.Join(serverPath, "config", "custom", "a.toml"), "mine")
+	assertFile(t, filepath.Join(serverPath, "config", "other", "b.toml"), "pack 2")
+	assertFile(t, filepath.Join(dir, PackFilesDir, "server.properties"), "motd=Pack\ndifficulty=hard\n")
+
+	if res.Preserved == nil || fmt.Sprint(res.Preserved.Restored) != "[config/custom/a.toml ops.json]" {
+		t.Fatalf("unexpected restored files %+v", res.Preserved)
+	}
+	if m := res.Preserved.Merged; len(m) != 1 || fmt.Sprint(m[0].FromPack) != "[difficulty]" || len(m[0].Conflicts) != 0 {
+		t.Fatalf("unexpected merges %+v", m)
+	}
+}
@@ -0,0 +1,79 @@
+package updater
+
+import (
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/archive"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+)
+
+// Event types published by the updater
+const (
+	EventCheckCompleted = "check_completed"
+	EventUpdateStarted  = "update_started"
+	EventUpdateProgress = events.UpdateProgress
+	EventUpdateFinished = events.UpdateFinished
+	EventUpdateFailed   = events.UpdateFailed
+)
+
+// Update phases reported with EventUpdateProgress
+const (
+	PhaseBackup   = "backup"
+	PhaseDownload = "download"
+	PhaseInstall  = "install"
+)
+
+// SetBus sets the event bus that check and update progress is published to
+func (u *Updater) SetBus(bus *events.Bus) {
+	u.bus = bus
+}
+
+// SetDownloadProgress sets a callback for download progress, e.g. a terminal progress bar
+func (u *Updater) SetDownloadProgress(fn api.ProgressFunc) {
+	u.onDownload = fn
+}
+
+// publish sends an event if a bus is configured
+func (u *Updater) publish(eventType string, data map[string]interface{}) {
+	if u.bus != nil {
+		data["mod_id"] = u.opts.ModID
+		u.bus.Publish(eventType, data)
+	}
+}
+
+// progress reports how far the given phase is, from 0 to 100
+func (u *Updater) progress(phase string, percent int) {
+	u.publish(EventUpdateProgress, map[string]interface{}{
+		"phase":   phase,
+		"percent": percent,
+	})
+}
+
+// downloadProgress reports the download phase with byte counts and rate
+func (u *Updater) downloadProgress(p api.Progress) {
+	u.publish(EventUpdateProgress, map[string]interface{}{
+		"phase":            PhaseDownload,
+		"percent":          max(p.Percent(), 0),
+		"downloaded_bytes": p.Downloaded,
+		"total_bytes":      p.Total,
+		"bytes_per_second": int64(p.Rate),
+	})
+	if u.onDownload != nil {
+		u.onDownload(p)
+	}
+}
+
+// installProgress returns a callback reporting the install phase as a server pack is
+// unpacked, publishing only when the percentage changes
+func (u *Updater) installProgress() archive.ProgressFunc {
+	last := -1
+	return func(done, total int64) {
+		if total <= 0 {
+			return
+		}
+		// Leave 100% for the end of the install phase, after preserved files and uploads
+		if percent := int(done * 99 / total); percent != last {
+			last = percent
+			u.progress(PhaseInstall, percent)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/archive"
+)
+
+// modsDir is the server folder the mods of a CurseForge modpack are installed into
+const modsDir = "mods"
+
+// ModSync describes an update that only changed the mods that differ between the
+// installed and the new modpack file, see SetDifferentialSync
+type ModSync struct {
+	Downloaded    []string `json:"downloaded"`        // new jars in mods/
+	Removed       []string `json:"removed"`           // jars deleted from mods/
+	Skipped       []string `json:"skipped,omitempty"` // client-only mods and mods the server does not have
+	Overrides     int      `json:"overrides"`         // files installed from the modpack's overrides
+	DownloadBytes int64    `json:"download_bytes"`
+}
+
+// syncPlan is a differential update worked out before the server is touched
+type syncPlan struct {
+	result    ModSync
+	downloads []api.ModFile // added and changed mods
+	modpack   string        // downloaded new modpack file, for its overrides
+	overrides string        // folder of the overrides in modpack
+}
+
+// SetDifferentialSync makes updates of a CurseForge modpack download only the mods that
+// were added or changed, and delete the removed ones, instead of installing the whole
+// server pack. Updates fall back to the server pack whenever that is not safe.
+func (u *Updater) SetDifferentialSync(enabled bool) {
+	u.differentialSync = enabled
+}
+
+// syncPlanFor returns the differential update from the installed modpack file to latest,
+// or nil when the whole server pack is installed. The caller removes the plan's modpack file.
+func (u *Updater) syncPlanFor(fromFileID int, latest *api.ModFile) *syncPlan {
+	if !u.differentialSync || u.pack != nil || latest.IsServerPack || fromFileID == 0 || fromFileID == latest.ID {
+		return nil
+	}
+	plan, err := u.planSync(fromFileID, latest.ID)
+	if err != nil {
+		u.logger.Info("installing the whole server pack", "reason", err)
+		return nil
+	}
+	return plan
+}
+
+// planSync compares the manifests of the installed and the new modpack file and looks up
+// the mod files to download and delete. It fails when the update has to install the whole
+// server pack instead.
+func (u *Updater) planSync(fromFileID, toFileID int) (*syncPlan, error) {
+	if u.uploader != nil {
+		return nil, errors.New("removed mods cannot be deleted from the panel")
+	}
+	if !filesystem.DirExists(filepath.Join(u.opts.ServerPath, modsDir)) {
+		return nil, errors.New("the server has no mods folder")
+	}
+	from, err := u.manifest(fromFileID)
+	if err != nil {
+		return nil, err
+	}
+	modpack, err := u.downloadModpack(toFileID)
+	if err != nil {
+		return nil, err
+	}
+	plan := &syncPlan{modpack: modpack, result: ModSync{Downloaded: []string{}, Removed: []string{}}}
+	to, err := readManifest(modpack, toFileID)
+	if err != nil {
+		_ = os.Remove(modpack)
+		return nil, err
+	}
+	u.cacheManifest(toFileID, to)
+	if err := u.fillSyncPlan(plan, from, to); err != nil {
+		_ = os.Remove(modpack)
+		return nil, err
+	}
+	return plan, nil
+}
+
+// fillSyncPlan works out which mod files plan downloads and deletes
+func (u *Updater) fillSyncPlan(plan *syncPlan, from, to *packManifest) error {
+	if from.Minecraft.Version != to.Minecraft.Version {
+		return fmt.Errorf("minecraft changes from %s to %s", from.Minecraft.Version, to.Minecraft.Version)
+	}
+	if fromLoaders, toLoaders := loaderIDs(from), loaderIDs(to); fromLoaders != toLoaders {
+		return fmt.Errorf("the mod loader changes from %s to %s", fromLoaders, toLoaders)
+	}
+	plan.overrides = to.Overrides
+
+	fromFiles, toFiles := map[int]int{}, map[int]int{}
+	for _, mf := range from.Files {
+		fromFiles[mf.ProjectID] = mf.FileID
+	}
+	for _, mf := range to.Files {
+		toFiles[mf.ProjectID] = mf.FileID
+	}
+	var fileIDs []int
+	for project, file := range toFiles {
+		if old := fromFiles[project]; old != file {
+			fileIDs = append(fileIDs, file)
+			if old != 0 {
+				fileIDs = append(fileIDs, old)
+			}
+		}
+	}
+	for project, file := range fromFiles {
+		if _, ok := toFiles[project]; !ok {
+			fileIDs = append(fileIDs, file)
+		}
+	}
+	if len(fileIDs) == 0 {
+		return nil
+	}
+	found, err := u.client.GetFiles(fileIDs)
+	if err != nil {
+		return fmt.Errorf("failed to look up changed mod files: %w", err)
+	}
+	files := make(map[int]api.ModFile, len(found))
+	for _, f := range found {
+		files[f.ID] = f
+	}
+
+	for project, fileID := range toFiles {
+		oldID := fromFiles[project]
+		if oldID == fileID {
+			continue
+		}
+		file, ok := files[fileID]
+		if !ok {
+			return fmt.Errorf("mod file %d was not found", fileID)
+		}
+		if oldID != 0 {
+			old, ok := files[oldID]
+			if !ok {
+				return fmt.Errorf("mod file %d was not found", oldID)
+			}
+			// Only replace mods the server has; the server pack leaves out client mods
+			if !u.hasMod(old.FileName) {
+				plan.result.Skipped = append(plan.result.Skipped, file.FileName)
+				continue
+			}
+			plan.result.Removed = append(plan.result.Removed, old.FileName)
+		} else if clientOnly(file) || !strings.EqualFold(path.Ext(file.FileName), ".jar") {
+			plan.result.Skipped = append(plan.result.Skipped, file.FileName)
+			continue
+		}
+		if file.DownloadURL == "" {
+			return fmt.Errorf("%s can only be downloaded from the CurseForge website", file.FileName)
+		}
+		plan.downloads = append(plan.downloads, file)
+		plan.result.Downloaded = append(plan.result.Downloaded, file.FileName)
+		plan.result.DownloadBytes += file.FileLength
+	}
+	for project, fileID := range fromFiles {
+		if _, ok := toFiles[project]; ok {
+			continue
+		}
+		if old, ok := files[fileID]; ok && u.hasMod(old.FileName) {
+			plan.result.Removed = append(plan.result.Removed, old.FileName)
+		}
+	}
+	sort.Slice(plan.downloads, func(i, j int) bool { return plan.downloads[i].FileName < plan.downloads[j].FileName })
+	sort.Strings(plan.result.Downloaded)
+	sort.Strings(plan.result.Removed)
+	sort.Strings(plan.result.Skipped)
+	return nil
+}
+
+// applySync downloads and verifies the plan's mods in the download directory, then
+// swaps them into mods/ and installs the overrides. It returns the installed files
+// relative to ServerPath.
+func (u *Updater) applySync(plan *syncPlan) ([]string, error) {
+	if err := filesystem.EnsureDir(u.opts.DownloadPath); err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(u.opts.DownloadPath, ".sync-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	w := &stagingWriter{}
+	progress := api.NewProgressWriter(&contextWriter{ctx: u.ctx, w: w}, plan.result.DownloadBytes, u.downloadProgress)
+	for i := range plan.downloads {
+		file := &plan.downloads[i]
+		target := filepath.Join(staging, filepath.Base(file.FileName))
+		if err := u.downloadMod(file, target, w, progress); err != nil {
+			return nil, err
+		}
+	}
+	progress.Finish()
+	if err := u.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	u.progress(PhaseInstall, 0)
+	modsPath := filepath.Join(u.opts.ServerPath, modsDir)
+	for _, name := range plan.result.Removed {
+		if err := os.Remove(filepath.Join(modsPath, filepath.Base(name))); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+		u.logger.Info("mod removed", "file", name)
+	}
+	var installed []string
+	for _, file := range plan.downloads {
+		name := filepath.Base(file.FileName)
+		// Copy, since the download directory may be on another file system
+		if err := filesystem.CopyFile(filepath.Join(staging, name), filepath.Join(modsPath, name)); err != nil {
+			return nil, fmt.Errorf("failed to install %s: %w", name, err)
+		}
+		installed = append(installed, modsDir+"/"+name)
+		u.logger.Info("mod installed", "file", name)
+	}
+	overrides, err := u.installOverrides(plan)
+	if err != nil {
+		return nil, err
+	}
+	plan.result.Overrides = len(overrides)
+	return append(installed, overrides...), nil
+}
+
+// downloadMod downloads file to target through w and verifies it
+func (u *Updater) downloadMod(file *api.ModFile, target string, w *stagingWriter, progress *api.ProgressWriter) error {
+	f, err := os.Create(target) // #nosec G304 -- target is inside the staging directory
+	if err != nil {
+		return err
+	}
+	w.f = f
+	err = u.fetch(file.FileName, fileSHA1(file), progress, func(dst io.Writer) error {
+		return u.client.DownloadFile(file.DownloadURL, dst)
+	})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", file.FileName, err)
+	}
+	if err := verifyFile(target, file); err != nil {
+		return fmt.Errorf("downloaded %s does not match CurseForge: %w", file.FileName, err)
+	}
+	return nil
+}
+
+// installOverrides extracts the modpack's overrides into ServerPath. Only files in folders
+// the server already has, or files it already has, are installed, since the client
+// modpack also carries resource packs, shaders and client settings.
+func (u *Updater) installOverrides(plan *syncPlan) ([]string, error) {
+	if plan.overrides == "" {
+		return nil, nil
+	}
+	installed, err := archive.ExtractZip(plan.modpack, u.opts.ServerPath, archive.Options{
+		Prefix: strings.TrimSuffix(plan.overrides, "/") + "/",
+		Filter: func(name string) bool {
+			top, _, nested := strings.Cut(name, "/")
+			if nested {
+				return filesystem.DirExists(filepath.Join(u.opts.ServerPath, top))
+			}
+			return filesystem.FileExists(filepath.Join(u.opts.ServerPath, name))
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to install the modpack's overrides: %w", err)
+	}
+	return installed, nil
+}
+
+// hasMod reports whether the server's mods folder has the jar called name
+func (u *Updater) hasMod(name string) bool {
+	return filesystem.FileExists(filepath.Join(u.opts.ServerPath, modsDir, filepath.Base(name)))
+}
+
+// clientOnly reports whether a mod file is marked as only running on the client
+func clientOnly(file api.ModFile) bool {
+	client, srv := false, false
+	for _, v := range file.GameVersions {
+		switch v {
+		case "Client":
+			client = true
+		case "Server":
+			srv = true
+		}
+	}
+	return client && !srv
+}
+
+// loaderIDs lists the mod loaders of a manifest, e.g. "forge-47.2.0"
+func loaderIDs(m *packManifest) string {
+	ids := make([]string, 0, len(m.Minecraft.ModLoaders))
+	for _, l := range m.Minecraft.ModLoaders {
+		ids = append(ids, l.ID)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// stagingWriter passes writes on to the file being downloaded, so that one progress
+// writer counts all mods of a differential update
+type stagingWriter struct {
+	f *os.File
+}
+
+func (w *stagingWriter) Write(b []byte) (int, error) {
+	return w.f.Write(b)
+}
@@ -0,0 +1,167 @@
+package updater
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint:gosec // CurseForge publishes SHA-1 hashes
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/klauspost/compress/zip"
+)
+
+// syncModpackZip builds a client modpack for loader with the given mods and overrides
+func syncModpackZip(t *testing.T, loader string, files map[int]int, overrides map[string]string) []byte {
+	manifest := map[string]interface{}{
+		"minecraft": map[string]interface{}{
+			"version":    "1.20.1",
+			"modLoaders": []map[string]string{{"id": loader}},
+		},
+		"overrides": "overrides",
+	}
+	var list []map[string]int
+	for project, file := range files {
+		list = append(list, map[string]int{"projectID": project, "fileID": file})
+	}
+	manifest["files"] = list
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewEncoder(w).Encode(manifest); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range overrides {
+		w, err := zw.Create("overrides/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDifferentialSync(t *testing.T) {
+	packs := map[int][]byte{
+		100: syncModpackZip(t, "forge-47.2.0", map[int]int{1: 11, 2: 21, 3: 31, 6: 61}, nil),
+		200: syncModpackZip(t, "forge-47.2.0", map[int]int{1: 11, 2: 22, 4: 41, 5: 51, 6: 62}, map[string]string{
+			"config/create.toml":  "new",
+			"shaderpacks/bsl.zip": "client",
+		}),
+		300: syncModpackZip(t, "neoforge-47.1.0", map[int]int{1: 11}, nil),
+	}
+	names := map[int]string{21: "create-0.5.jar", 22: "create-0.6.jar", 31: "old.jar", 41: "ae2.jar", 51: "jei.jar", 61: "oculus-1.jar", 62: "oculus-2.jar"}
+	var downloaded []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mods/1/files/", func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		_, _ = fmt.Sscanf(r.URL.Path, "/mods/1/files/%d/download-url", &id)
+		_ = json.NewEncoder(w).Encode(map[string]string{"data": "http://" + r.Host + fmt.Sprintf("/download/%d", id)})
+	})
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		_, _ = fmt.Sscanf(r.URL.Path, "/download/%d", &id)
+		_, _ = w.Write(packs[id])
+	})
+	mux.HandleFunc("/jars/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/jars/")
+		downloaded = append(downloaded, name)
+		_, _ = w.Write([]byte(name))
+	})
+	mux.HandleFunc("POST /mods/files", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			FileIDs []int `json:"fileIds"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		var files []api.ModFile
+		for _, id := range body.FileIDs {
+			sum := sha1.Sum([]byte(names[id])) //nolint:gosec // CurseForge publishes SHA-1 hashes
+			f := api.ModFile{
+				ID:          id,
+				FileName:    names[id],
+				FileLength:  int64(len(names[id])),
+				DownloadURL: "http://" + r.Host + "/jars/" + names[id],
+				Hashes:      []api.FileHash{{Value: hex.EncodeToString(sum[:]), Algo: api.HashAlgoSHA1}},
+			}
+			if id == 51 {
+				f.GameVersions = []string{"1.20.1", "Forge", "Client"}
+			}
+			files = append(files, f)
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]api.ModFile{"data": files})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	for _, name := range []string{"mods/a.jar", "mods/create-0.5.jar", "mods/old.jar", "config/create.toml"} {
+		path := filepath.Join(serverPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := api.NewClient("test")
+	client.BaseURL = srv.URL
+	u := New(client, server.NewBackupManager(dir, dir, false, 0), state.NewStore(filepath.Join(dir, state.FileName)),
+		Options{ModID: 1, ServerPath: serverPath, DownloadPath: filepath.Join(dir, "downloads")})
+	u.SetDifferentialSync(true)
+
+	plan := u.syncPlanFor(100, &api.ModFile{ID: 200})
+	if plan == nil {
+		t.Fatal("expected a differential update")
+	}
+	defer os.Remove(plan.modpack)
+	files, err := u.applySync(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := plan.result
+	if got := fmt.Sprint(r.Downloaded, r.Removed, r.Skipped, r.Overrides); got != "[ae2.jar create-0.6.jar] [create-0.5.jar old.jar] [jei.jar oculus-2.jar] 1" {
+		t.Errorf("sync = %s", got)
+	}
+	if got := strings.Join(downloaded, ","); got != "ae2.jar,create-0.6.jar" {
+		t.Errorf("downloaded %s", got)
+	}
+	if got := strings.Join(files, ","); !strings.Contains(got, "mods/ae2.jar") || !strings.HasSuffix(got, "config/create.toml") {
+		t.Errorf("installed %s", got)
+	}
+	entries, _ := os.ReadDir(filepath.Join(serverPath, "mods"))
+	var mods []string
+	for _, e := range entries {
+		mods = append(mods, e.Name())
+	}
+	if got := strings.Join(mods, ","); got != "a.jar,ae2.jar,create-0.6.jar" {
+		t.Errorf("mods = %s", got)
+	}
+	assertFile(t, filepath.Join(serverPath, "config", "create.toml"), "new")
+	if _, err := os.Stat(filepath.Join(serverPath, "shaderpacks")); !os.IsNotExist(err) {
+		t.Errorf("client overrides were installed: %v", err)
+	}
+
+	// A new mod loader needs the server pack
+	if plan := u.syncPlanFor(200, &api.ModFile{ID: 300}); plan != nil {
+		_ = os.Remove(plan.modpack)
+		t.Error("expected the whole server pack when the mod loader changes")
+	}
+}
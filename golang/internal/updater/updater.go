@@ -0,0 +1,990 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/filesystem"
+	"github.com/damianko135/curseforge-autoupdate/golang/helper/version"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/downloads"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/maintenance"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/plugin"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/provider"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+)
+
+// Options configures which mod is tracked and where files are placed
+type Options struct {
+	ModID          int
+	GameVersion    string
+	ReleaseChannel string
+	ServerPath     string
+	DownloadPath   string
+	ManualPath     string // where files that cannot be downloaded automatically are dropped by hand
+}
+
+// Updater checks for, installs and rolls back modpack versions
+type Updater struct {
+	client  api.CurseForge
+	backups *server.BackupManager
+	store   *state.Store
+	history *history.Log
+	opts    Options
+	clock   clock.Clock
+	logger  *slog.Logger
+	bus     *events.Bus
+	// ctx stops an update before it installs anything; see SetContext
+	ctx context.Context
+
+	onDownload api.ProgressFunc
+	manualWait ManualWaitFunc
+	plugins    *plugin.Runner
+	uploader   Uploader
+	restarter  Restarter
+	readyFile  string
+
+	// startup follows the server log after the restart; see SetStartupCheck
+	startup        server.Controller
+	startupCheck   config.HealthCheckConfig
+	restartTimeout time.Duration
+
+	// tasks run in console once the startup check saw the server start; see SetPostUpdateTasks
+	console server.Commander
+	tasks   []config.PostUpdateTaskConfig
+
+	// minFileAge holds back files published more recently; see SetMinFileAge
+	minFileAge time.Duration
+
+	// keepDownloads is how many versions stay in DownloadPath after an update; see SetKeepDownloads
+	keepDownloads int
+	cache         *downloads.Cache // nil without download.cache_dir
+
+	// maintenance answers players on the server port during updates; see SetMaintenanceMode
+	maintenanceAddr string
+	maintenanceMOTD string
+	maintenanceETA  time.Duration
+
+	// preserve lists the files kept across updates; see SetPreserve
+	preserve     []string
+	preserveBase string
+
+	modChangelogs bool
+
+	// differentialSync updates only the changed mods when it can; see SetDifferentialSync
+	differentialSync bool
+	manifests        map[int]*packManifest // by modpack file ID, see manifest
+
+	// confirmMinecraftUpgrades refuses updates to a newer Minecraft version; see SetConfirmMinecraftUpgrades
+	confirmMinecraftUpgrades bool
+
+	// pack serves the modpack when it is not hosted on CurseForge; see SetPackProvider
+	pack        provider.Provider
+	packVersion *provider.Version
+
+	// versions are all files found by the last latestFile, newest first; see channels
+	versions []provider.Version
+}
+
+// New creates an updater
+func New(client api.CurseForge, backups *server.BackupManager, store *state.Store, opts Options) *Updater {
+	return &Updater{
+		client:  client,
+		backups: backups,
+		store:   store,
+		opts:    opts,
+		clock:   clock.Real(),
+		logger:  slog.Default(),
+		ctx:     context.Background(),
+	}
+}
+
+// SetPackProvider makes the updater track the modpack on another provider, e.g. FTB,
+// instead of through the CurseForge client. Its versions must have numeric IDs.
+func (u *Updater) SetPackProvider(p provider.Provider) {
+	u.pack = p
+}
+
+// SetClock replaces the clock used for timestamps
+func (u *Updater) SetClock(c clock.Clock) {
+	u.clock = c
+}
+
+// SetHistory records every update attempt in the given log
+func (u *Updater) SetHistory(log *history.Log) {
+	u.history = log
+}
+
+// Uploader copies installed files to a server that does not run on this machine
+type Uploader interface {
+	// Upload copies files, relative to localDir with forward slashes, to the same paths
+	Upload(localDir string, files []string) error
+}
+
+// SetUploader uploads every installed file after it has been unpacked into ServerPath
+func (u *Updater) SetUploader(up Uploader) {
+	u.uploader = up
+}
+
+// DefaultReadyFile is the ready file inside ServerPath used when none is configured
+const DefaultReadyFile = ".modpack-ready"
+
+// Restarter restarts the server so that it picks up a newly installed version
+type Restarter interface {
+	Restart(timeout time.Duration) error
+}
+
+// SetRestarter restarts the server through r after every update that installed a new version
+func (u *Updater) SetRestarter(r Restarter) {
+	u.restarter = r
+}
+
+// SetStartupCheck restarts the server through c after every update that installed a new
+// version and follows its log until it has started. When it crashes cfg.MaxCrashes times
+// first, or crashes and has not started within cfg.Timeout, the update fails and, with
+// cfg.Rollback, is rolled back. The server gets stopTimeout to shut down. c must be able
+// to follow the log, see server.FollowLog.
+func (u *Updater) SetStartupCheck(c server.Controller, stopTimeout time.Duration, cfg config.HealthCheckConfig) {
+	u.restarter = c
+	u.startup = c
+	u.startupCheck = cfg
+	u.restartTimeout = stopTimeout
+}
+
+// SetPostUpdateTasks runs tasks through console, in order, after every update whose startup
+// check saw the server start. It needs SetStartupCheck; a failed task only fails the update
+// with on_failure = "fail", and never rolls it back.
+func (u *Updater) SetPostUpdateTasks(console server.Commander, tasks []config.PostUpdateTaskConfig) {
+	u.console = console
+	u.tasks = tasks
+}
+
+// SetReadyFile maintains a file at path that exists only while ServerPath holds a complete
+// install, so that a server container sharing the volume can wait for it
+func (u *Updater) SetReadyFile(path string) {
+	u.readyFile = path
+}
+
+// SetKeepDownloads removes all but the keep newest downloads of the modpack after every
+// update; 0 keeps them all
+func (u *Updater) SetKeepDownloads(keep int) {
+	u.keepDownloads = keep
+}
+
+// SetCache makes downloads of files with a known hash go through cache
+func (u *Updater) SetCache(cache *downloads.Cache) {
+	u.cache = cache
+}
+
+// SetMinFileAge only installs files published at least age ago, so that broken releases
+// are usually pulled or fixed before they reach the server; 0 installs them right away
+func (u *Updater) SetMinFileAge(age time.Duration) {
+	u.minFileAge = age
+}
+
+// SetMaintenanceMode answers server list pings and logins on address with motd while an
+// update runs, see maintenance.FormatMOTD. Nothing is started when the server still holds
+// the port.
+func (u *Updater) SetMaintenanceMode(address, motd string, eta time.Duration) {
+	u.maintenanceAddr = address
+	u.maintenanceMOTD = motd
+	u.maintenanceETA = eta
+}
+
+// SetContext makes Update stop with the error of ctx once it is done, including the
+// pre-update backup. An update stops at the latest before it starts installing files,
+// so that it never leaves the server half updated.
+func (u *Updater) SetContext(ctx context.Context) {
+	u.ctx = ctx
+	if u.backups != nil {
+		u.backups.SetContext(ctx)
+	}
+}
+
+// SetLogger replaces the logger used for update progress
+func (u *Updater) SetLogger(logger *slog.Logger) {
+	u.logger = logger
+}
+
+// CheckResult is the outcome of comparing the installed file with the latest one
+type CheckResult struct {
+	State           *state.State
+	Latest          *api.ModFile
+	UpdateAvailable bool
+	HeldBack        []provider.Held // newer files skipped for their channel or age, newest first
+
+	// MinecraftUpgrade is set when the available update moves to a newer Minecraft version
+	MinecraftUpgrade *MinecraftUpgrade
+
+	// Channels is what each release channel would install, tracked or not
+	Channels []ChannelStatus
+}
+
+// UpdateResult describes a completed update
+type UpdateResult struct {
+	FromVersion    string
+	ToVersion      string
+	FromFileID     int
+	ToFileID       int
+	Backup         string
+	DownloadedFile string
+	Duration       time.Duration
+	Skipped        bool
+	Preserved      *PreserveReport       // nil when no preserved file was installed over
+	Mods           []history.ModChange   // mods that changed with the modpack, see SetModChangelogs
+	Startup        *server.StartupReport // nil without SetStartupCheck
+	Tasks          []server.TaskResult   // post-update tasks, see SetPostUpdateTasks
+	Sync           *ModSync              // nil when the whole pack was installed, see SetDifferentialSync
+
+	MinecraftUpgrade *MinecraftUpgrade // nil when the Minecraft version stayed the same
+	Client           *ClientPack       // client file players need, nil unless a server pack was installed
+}
+
+// Check looks up the latest file and compares it with the installed one
+func (u *Updater) Check() (*CheckResult, error) {
+	latest, held, err := u.latestFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest file: %w", err)
+	}
+
+	st, err := u.store.Update(func(st *state.State) error {
+		if !st.IsInstalled() {
+			if err := u.migrateLegacyMetadata(st); err != nil {
+				return err
+			}
+		}
+		st.ModID = u.opts.ModID
+		st.LastCheckAt = u.clock.Now()
+		st.LatestFileID = latest.ID
+		st.LatestVersion = latest.DisplayName
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CheckResult{
+		State:           st,
+		Latest:          latest,
+		UpdateAvailable: updateAvailable(st, latest, u.opts.GameVersion),
+		HeldBack:        held,
+		Channels:        u.channels(st),
+	}
+	if result.UpdateAvailable {
+		result.MinecraftUpgrade = u.minecraftUpgrade(st, latest)
+	}
+	u.logger.Info("checked for updates",
+		"installed_file_id", st.InstalledFileID,
+		"latest_file_id", latest.ID,
+		"latest_version", latest.DisplayName,
+		"update_available", result.UpdateAvailable)
+	if up := result.MinecraftUpgrade; up != nil {
+		u.logger.Warn("the update moves to a newer Minecraft version; worlds it loads cannot be opened by the old version again",
+			"from", up.From, "to", up.To)
+	}
+	u.publish(EventCheckCompleted, map[string]interface{}{
+		"installed_version": st.InstalledVersion,
+		"latest_file_id":    latest.ID,
+		"latest_version":    latest.DisplayName,
+		"update_available":  result.UpdateAvailable,
+	})
+	return result, nil
+}
+
+// latestFile returns the newest file of the modpack for the configured game version that
+// the release policy allows, with the newer files it held back
+func (u *Updater) latestFile() (*api.ModFile, []provider.Held, error) {
+	policy := u.releasePolicy(u.opts.ReleaseChannel)
+	u.versions = nil
+	if u.pack == nil {
+		return u.latestCurseForgeFile(policy)
+	}
+
+	project := strconv.Itoa(u.opts.ModID)
+	versions, err := u.pack.GetVersions(project, provider.Filter{GameVersion: u.opts.GameVersion})
+	if err != nil {
+		return nil, nil, err
+	}
+	u.versions = versions
+	v, held, err := provider.SelectLatest(u.pack, project, versions, policy)
+	u.logHeld(held)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := strconv.Atoi(v.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s version ID %q is not numeric", u.pack.Name(), v.ID)
+	}
+	u.packVersion = v
+	// The rest of the pipeline and the state file work with CurseForge-shaped files
+	return &api.ModFile{
+		ID:           id,
+		ModID:        u.opts.ModID,
+		DisplayName:  v.Name,
+		FileName:     v.FileName,
+		ReleaseType:  api.ReleaseTypeFromChannel(v.Channel),
+		FileDate:     v.Published,
+		FileLength:   v.Size,
+		GameVersions: v.GameVersions,
+		IsServerPack: true,
+	}, held, nil
+}
+
+// latestCurseForgeFile is latestFile for a modpack hosted on CurseForge
+func (u *Updater) latestCurseForgeFile(policy provider.Policy) (*api.ModFile, []provider.Held, error) {
+	files, err := u.client.GetAllModFiles(u.opts.ModID, u.opts.GameVersion, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = api.ReleasedFiles(files)
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("no files found for mod %d", u.opts.ModID)
+	}
+
+	u.versions = make([]provider.Version, len(files))
+	for i := range files {
+		u.versions[i] = provider.Version{
+			ID:        strconv.Itoa(files[i].ID),
+			Name:      files[i].DisplayName,
+			FileName:  files[i].FileName,
+			Channel:   api.ReleaseTypeName(files[i].ReleaseType),
+			Published: files[i].FileDate,
+		}
+	}
+
+	var held []provider.Held
+	defer func() { u.logHeld(held) }()
+	for i, v := range u.versions {
+		if reason := policy.Reason(v); reason != "" {
+			held = append(held, provider.Held{Version: v, Reason: reason})
+			continue
+		}
+		return &files[i], held, nil
+	}
+	return nil, held, fmt.Errorf("no file of mod %d can be installed yet, newest is %s: %s", u.opts.ModID, held[0].Version.Name, held[0].Reason)
+}
+
+// logHeld logs the files the release policy held back
+func (u *Updater) logHeld(held []provider.Held) {
+	for _, h := range held {
+		u.logger.Info("newer file held back", "version", h.Version.Name, "reason", h.Reason)
+	}
+}
+
+// updateAvailable reports whether latest should replace the installed file. Files are
+// ordered by the versions in their display names, so that 1.10.1 is newer than 1.9.9
+// even when 1.9.9 was uploaded later as a backport. When either name holds no version or
+// both hold the same one, the later file date wins, then the higher file ID. An older
+// file than the one installed is never offered, e.g. after a manual upgrade. The Minecraft
+// version in the names is skipped: the installed one, or gameVersion when that is unknown.
+func updateAvailable(st *state.State, latest *api.ModFile, gameVersion string) bool {
+	if !st.IsInstalled() {
+		return true
+	}
+	if st.InstalledFileID == latest.ID {
+		return false
+	}
+	if st.InstalledGameVersion != "" {
+		gameVersion = st.InstalledGameVersion
+	}
+	if cmp, ok := version.CompareNames(latest.DisplayName, st.InstalledVersion, gameVersion); ok && cmp != 0 {
+		return cmp > 0
+	}
+	if !latest.FileDate.Equal(st.InstalledFileDate) {
+		return latest.FileDate.After(st.InstalledFileDate)
+	}
+	return latest.ID > st.InstalledFileID
+}
+
+// migrateLegacyMetadata seeds the state from download_metadata.json written by older versions
+func (u *Updater) migrateLegacyMetadata(st *state.State) error {
+	metadata, err := downloads.LoadMetadata(u.opts.DownloadPath)
+	if err != nil {
+		return err
+	}
+	fileID, rec, ok := metadata.Latest()
+	if !ok {
+		return nil
+	}
+
+	st.InstalledFileID = fileID
+	st.InstalledVersion = rec.DisplayName
+	st.InstalledFileDate, _ = time.Parse(time.RFC3339Nano, rec.FileDate)
+	st.InstalledAt, _ = time.Parse(time.RFC3339, rec.DownloadedAt)
+	return nil
+}
+
+// Update installs the latest file, taking a backup first; force reinstalls even when up to date
+func (u *Updater) Update(force bool) (*UpdateResult, error) {
+	started := u.clock.Now()
+	result := &UpdateResult{}
+
+	err := u.update(result, force)
+	switch {
+	case err != nil:
+		_ = u.runPlugins(plugin.StageUpdateFailed, result, err)
+	case !result.Skipped:
+		_ = u.runPlugins(plugin.StagePostUpdate, result, nil)
+	}
+	if err == nil && u.readyFile != "" {
+		err = writeReadyFile(u.readyFile, result.ToVersion, u.clock.Now())
+	}
+	if err == nil && !result.Skipped && u.restarter != nil {
+		err = u.restart(result)
+	}
+	result.Duration = u.clock.Now().Sub(started)
+
+	if u.history != nil {
+		entry := history.Entry{
+			Timestamp:   started,
+			ModID:       u.opts.ModID,
+			FromFileID:  result.FromFileID,
+			FromVersion: result.FromVersion,
+			ToFileID:    result.ToFileID,
+			ToVersion:   result.ToVersion,
+			Result:      history.ResultSuccess,
+			Duration:    result.Duration,
+			Backup:      result.Backup,
+			Mods:        result.Mods,
+		}
+		switch {
+		case err != nil:
+			entry.Result = history.ResultFailed
+			entry.Error = err.Error()
+		case result.Skipped:
+			entry.Result = history.ResultSkipped
+		}
+		// The update is installed either way; failing to record it must not report it as failed
+		if herr := u.history.Append(entry); herr != nil {
+			u.logger.Warn("failed to record update history", "error", herr)
+		}
+	}
+
+	if err != nil {
+		u.logger.Error("update failed", "to_file_id", result.ToFileID, "duration", result.Duration, "error", err)
+		u.publish(EventUpdateFailed, map[string]interface{}{
+			"to_version": result.ToVersion,
+			"error":      err.Error(),
+		})
+		return nil, err
+	}
+	if !result.Skipped {
+		u.logger.Info("update complete",
+			"from_file_id", result.FromFileID,
+			"to_file_id", result.ToFileID,
+			"to_version", result.ToVersion,
+			"duration", result.Duration)
+	}
+	data := map[string]interface{}{
+		"from_version": result.FromVersion,
+		"to_version":   result.ToVersion,
+		"skipped":      result.Skipped,
+		"duration":     result.Duration.String(),
+		"mods":         result.Mods,
+	}
+	if result.Backup != "" {
+		data["backup"] = result.Backup
+	}
+	if c := result.Client; c != nil {
+		data["client_version"] = c.Version
+		data["client_url"] = c.PageURL
+		data["client_download_url"] = c.DownloadURL
+	}
+	u.publish(EventUpdateFinished, data)
+	return result, nil
+}
+
+// restart restarts the server so that it runs the installed version and, with
+// SetStartupCheck, rolls the update back when the server crash-loops. The update itself
+// succeeded, so a failed restart is only reported.
+func (u *Updater) restart(result *UpdateResult) error {
+	if u.startup != nil && !u.startup.IsRunning() {
+		u.logger.Info("server is stopped, not restarting it after the update")
+		return nil
+	}
+	if err := u.restarter.Restart(u.restartTimeout); err != nil {
+		u.logger.Warn("failed to restart the server after the update", "error", err)
+		return nil
+	}
+	u.logger.Info("server restart triggered")
+	if u.startup == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), u.startupCheck.Timeout)
+	defer cancel()
+	lines, err := server.FollowLog(ctx, u.startup)
+	if err != nil {
+		u.logger.Warn("cannot verify that the server started", "error", err)
+		return nil
+	}
+	report := server.WatchStartup(ctx, lines, u.startupCheck.MaxCrashes)
+	result.Startup = report
+	switch {
+	case report.Done:
+		u.logger.Info("server started", "startup_time", report.StartupTime, "crashes", report.Crashes)
+		return u.runTasks(result)
+	case !report.Failed():
+		u.logger.Warn("server did not finish starting in time", "timeout", u.startupCheck.Timeout)
+		return nil
+	}
+
+	u.logger.Error("server crash-loops after the update", "crashes", report.Crashes, "errors", report.Errors)
+	if !u.startupCheck.Rollback {
+		return fmt.Errorf("server %s after installing %s", report.Summary(), result.ToVersion)
+	}
+	rolledBack, err := u.Rollback()
+	if err != nil {
+		return fmt.Errorf("server %s after installing %s and the rollback failed: %w", report.Summary(), result.ToVersion, err)
+	}
+	if u.readyFile != "" {
+		if err := writeReadyFile(u.readyFile, rolledBack.State.InstalledVersion, u.clock.Now()); err != nil {
+			u.logger.Warn("failed to update the ready file after the rollback", "error", err)
+		}
+	}
+	if err := u.restarter.Restart(u.restartTimeout); err != nil {
+		u.logger.Warn("failed to restart the server after the rollback", "error", err)
+	}
+	return fmt.Errorf("server %s after installing %s, rolled back to backup %s", report.Summary(), result.ToVersion, rolledBack.Backup)
+}
+
+// runTasks runs the post-update tasks in order, recording each in result
+func (u *Updater) runTasks(result *UpdateResult) error {
+	if u.console == nil {
+		return nil
+	}
+	var failed error
+	stopped := false
+	for _, task := range u.tasks {
+		res := server.TaskResult{Name: task.Label(), Command: task.Command, Skipped: stopped}
+		if stopped {
+			result.Tasks = append(result.Tasks, res)
+			continue
+		}
+		u.logger.Info("running post-update task", "task", res.Name, "command", task.Command)
+		started := u.clock.Now()
+		err := server.RunTask(context.Background(), u.console, u.startup, task)
+		res.Duration = u.clock.Now().Sub(started)
+		if err != nil {
+			res.Error = err.Error()
+			u.logger.Error("post-update task failed", "task", res.Name, "on_failure", task.OnFailure, "error", err)
+			switch task.OnFailure {
+			case "stop":
+				stopped = true
+			case "fail":
+				stopped = true
+				failed = fmt.Errorf("post-update task %s failed after installing %s: %w", res.Name, result.ToVersion, err)
+			}
+		} else {
+			u.logger.Info("post-update task finished", "task", res.Name, "duration", res.Duration)
+		}
+		result.Tasks = append(result.Tasks, res)
+	}
+	return failed
+}
+
+// writeReadyFile records the installed version in the ready file
+func writeReadyFile(path, version string, now time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create ready file directory: %w", err)
+	}
+	data := fmt.Sprintf("version=%s\nready_at=%s\n", version, now.UTC().Format(time.RFC3339))
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil { // #nosec G306 -- read by the server container
+		return fmt.Errorf("failed to write ready file: %w", err)
+	}
+	return nil
+}
+
+// update performs the update, filling in result as it progresses
+func (u *Updater) update(result *UpdateResult, force bool) error {
+	check, err := u.Check()
+	if err != nil {
+		return err
+	}
+	st, latest := check.State, check.Latest
+
+	result.FromVersion = st.InstalledVersion
+	result.FromFileID = st.InstalledFileID
+	result.ToVersion = latest.DisplayName
+	result.ToFileID = latest.ID
+	if !check.UpdateAvailable && !force {
+		result.Skipped = true
+		return nil
+	}
+	result.MinecraftUpgrade = check.MinecraftUpgrade
+	if err := u.confirmMinecraftUpgrade(check.MinecraftUpgrade); err != nil {
+		return err
+	}
+	u.publish(EventUpdateStarted, map[string]interface{}{
+		"from_version": result.FromVersion,
+		"to_version":   result.ToVersion,
+	})
+
+	// Find out where the file comes from before touching the server
+	file, err := u.installFile(latest)
+	if err != nil {
+		return err
+	}
+	plan := u.syncPlanFor(result.FromFileID, latest)
+	if plan != nil {
+		defer os.Remove(plan.modpack)
+	}
+	var source downloadSource
+	if plan == nil {
+		if source, err = u.resolveSource(file); err != nil {
+			return err
+		}
+	}
+	if err := u.ctx.Err(); err != nil {
+		return err
+	}
+	if err := u.runPlugins(plugin.StagePreUpdate, result, nil); err != nil {
+		return err
+	}
+	if u.readyFile != "" {
+		if err := os.Remove(u.readyFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove ready file: %w", err)
+		}
+	}
+	if stop := u.startMaintenance(result.ToVersion); stop != nil {
+		defer stop()
+	}
+
+	if filesystem.DirExists(u.opts.ServerPath) {
+		u.progress(PhaseBackup, 0)
+		version := st.InstalledVersion
+		if version == "" {
+			version = "unknown"
+		}
+		backup, err := u.backups.CreatePreUpdateBackup(sanitizeName(version))
+		if err != nil {
+			return fmt.Errorf("failed to create pre-update backup: %w", err)
+		}
+		result.Backup = filepath.Base(backup.Path)
+		u.logger.Info("pre-update backup created", "backup", result.Backup)
+		u.progress(PhaseBackup, 100)
+		if _, err := u.store.Update(func(st *state.State) error {
+			st.LastBackup = result.Backup
+			st.LastBackupAt = backup.Created
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := u.runPlugins(plugin.StagePostBackup, result, nil); err != nil {
+			return err
+		}
+	} else if _, err := u.store.Update(func(st *state.State) error {
+		// A backup from an earlier update would otherwise be restored by Rollback
+		st.LastBackup = ""
+		st.LastBackupAt = time.Time{}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := u.ctx.Err(); err != nil {
+		return err
+	}
+	snapshot, err := snapshotPreserved(u.opts.ServerPath, u.preserve)
+	if err != nil {
+		return err
+	}
+	var files []string
+	if plan != nil {
+		u.logger.Info("syncing mods", "download", len(plan.downloads), "remove", len(plan.result.Removed), "size", plan.result.DownloadBytes)
+		if files, err = u.applySync(plan); err != nil {
+			return err
+		}
+		result.Sync = &plan.result
+	} else {
+		u.logger.Info("downloading", "file_id", file.ID, "file_name", file.FileName, "size", file.FileLength)
+		result.DownloadedFile, err = u.download(file, source)
+		if err != nil {
+			return err
+		}
+		if err := u.ctx.Err(); err != nil {
+			return err
+		}
+		u.logger.Info("installing", "file", result.DownloadedFile, "server_path", u.opts.ServerPath)
+		u.progress(PhaseInstall, 0)
+		files, err = install(result.DownloadedFile, u.opts.ServerPath, u.installProgress())
+		if err != nil {
+			return fmt.Errorf("failed to install %s: %w", file.FileName, err)
+		}
+	}
+	report, err := u.restorePreserved(snapshot, files)
+	if err != nil {
+		return err
+	}
+	if len(report.Restored) > 0 || len(report.Merged) > 0 {
+		result.Preserved = report
+		u.logPreserved(report)
+	}
+	if u.uploader != nil {
+		u.logger.Info("uploading installed files", "files", len(files))
+		if err := u.uploader.Upload(u.opts.ServerPath, files); err != nil {
+			return fmt.Errorf("installed %s locally but failed to upload it: %w", file.FileName, err)
+		}
+	}
+	u.progress(PhaseInstall, 100)
+	_ = u.runPlugins(plugin.StagePostInstall, result, nil)
+
+	now := u.clock.Now()
+	if _, err := u.store.Update(func(st *state.State) error {
+		if st.InstalledFileID != latest.ID {
+			st.PreviousFileID = st.InstalledFileID
+			st.PreviousVersion = st.InstalledVersion
+			st.PreviousFileDate = st.InstalledFileDate
+			st.PreviousGameVersion = st.InstalledGameVersion
+		}
+		st.InstalledFileID = latest.ID
+		st.InstalledVersion = latest.DisplayName
+		st.InstalledFileDate = latest.FileDate
+		st.InstalledGameVersion = MinecraftVersion(latest)
+		st.InstalledAt = now
+		st.LastUpdateAt = now
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	u.pruneDownloads()
+	result.Client = u.clientPack(latest, file)
+
+	// Server packs have no manifest, so compare the modpack files they belong to
+	if u.modChangelogs && u.pack == nil && result.FromFileID != 0 && result.FromFileID != latest.ID {
+		mods, err := u.modChanges(result.FromFileID, latest.ID)
+		if err != nil {
+			u.logger.Warn("failed to collect mod changelogs", "error", err)
+		} else {
+			result.Mods = mods
+			u.logger.Info("mods changed", "count", len(mods))
+		}
+	}
+
+	return nil
+}
+
+// startMaintenance starts answering players on the server port, and returns the function
+// that frees the port again or nil when nothing was started
+func (u *Updater) startMaintenance(version string) func() {
+	if u.maintenanceAddr == "" {
+		return nil
+	}
+	motd := maintenance.FormatMOTD(u.maintenanceMOTD, version, u.maintenanceETA)
+	r, err := maintenance.Listen(u.maintenanceAddr, motd, u.logger)
+	if err != nil {
+		// Usually the server is still running, which is fine
+		u.logger.Info("maintenance mode not started", "address", u.maintenanceAddr, "error", err)
+		return nil
+	}
+	u.logger.Info("maintenance mode started", "address", r.Addr().String(), "motd", motd)
+	return func() {
+		if err := r.Close(); err != nil {
+			u.logger.Warn("failed to stop maintenance mode", "error", err)
+		}
+		u.logger.Info("maintenance mode stopped")
+	}
+}
+
+// pruneDownloads removes the downloads superseded by the one just installed. The update has
+// already succeeded, so a failure is only logged.
+func (u *Updater) pruneDownloads() {
+	if u.keepDownloads <= 0 {
+		return
+	}
+	pruned, err := downloads.Prune(u.opts.DownloadPath, u.keepDownloads, false)
+	for _, p := range pruned {
+		u.logger.Info("old download removed", "file_id", p.FileID, "file_name", p.Record.FileName)
+	}
+	if err != nil {
+		u.logger.Warn("failed to remove old downloads", "error", err)
+	}
+}
+
+// installFile returns the file to install for latest, preferring its server pack
+func (u *Updater) installFile(latest *api.ModFile) (*api.ModFile, error) {
+	if latest.IsServerPack || latest.ServerPackFileID == 0 {
+		return latest, nil
+	}
+	file, err := u.client.GetModFile(u.opts.ModID, latest.ServerPackFileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server pack file %d: %w", latest.ServerPackFileID, err)
+	}
+	return file, nil
+}
+
+// RollbackResult describes a completed rollback
+type RollbackResult struct {
+	Backup   string
+	Snapshot string // pre-restore snapshot of the files the rollback replaced
+	State    *state.State
+}
+
+// PlanRollback reports what Rollback would change on disk without touching anything
+func (u *Updater) PlanRollback() (*server.RestorePlan, error) {
+	st, err := u.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if st.LastBackup == "" {
+		return nil, fmt.Errorf("no pre-update backup recorded in %s", u.store.Path())
+	}
+	return u.backups.PlanRestore(st.LastBackup)
+}
+
+// Rollback restores the backup taken before the last update and reverts the installed version
+func (u *Updater) Rollback() (*RollbackResult, error) {
+	st, err := u.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if st.LastBackup == "" {
+		return nil, fmt.Errorf("no pre-update backup recorded in %s", u.store.Path())
+	}
+	backup := st.LastBackup
+
+	restored, err := u.backups.Restore(backup, server.RestoreOptions{Snapshot: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore backup %s: %w", backup, err)
+	}
+
+	st, err = u.store.Update(func(st *state.State) error {
+		st.InstalledFileID = st.PreviousFileID
+		st.InstalledVersion = st.PreviousVersion
+		st.InstalledFileDate = st.PreviousFileDate
+		st.InstalledGameVersion = st.PreviousGameVersion
+		st.InstalledAt = u.clock.Now()
+		st.PreviousFileID = 0
+		st.PreviousVersion = ""
+		st.PreviousFileDate = time.Time{}
+		st.PreviousGameVersion = ""
+		// The backup has been consumed; a second rollback must not restore it again
+		st.LastBackup = ""
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.logger.Info("rolled back", "backup", backup, "installed_file_id", st.InstalledFileID)
+	return &RollbackResult{Backup: backup, Snapshot: restored.Snapshot, State: st}, nil
+}
+
+// download fetches file from source into the download directory and records it in the metadata
+func (u *Updater) download(file *api.ModFile, source downloadSource) (string, error) {
+	if err := filesystem.EnsureDir(u.opts.DownloadPath); err != nil {
+		return "", fmt.Errorf("failed to create download directory: %w", err)
+	}
+	target := filepath.Join(u.opts.DownloadPath, filepath.Base(file.FileName))
+
+	tmp, err := os.CreateTemp(u.opts.DownloadPath, ".download_*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary download file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	out := &contextWriter{ctx: u.ctx, w: tmp}
+
+	switch {
+	case source.local != "":
+		err = copyInto(out, source.local)
+	case source.version != nil:
+		err = u.fetch(file.FileName, source.version.SHA1, api.NewProgressWriter(out, source.version.Size, u.downloadProgress), func(w io.Writer) error {
+			return u.pack.Download(source.version, w)
+		})
+	default:
+		err = u.fetch(file.FileName, fileSHA1(file), out, func(w io.Writer) error {
+			return u.client.DownloadFileProgress(source.url, w, u.downloadProgress)
+		})
+	}
+	if err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("failed to download %s: %w", file.FileName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close temporary download file: %w", err)
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		return "", fmt.Errorf("failed to move download into place: %w", err)
+	}
+
+	metadata, err := downloads.LoadMetadata(u.opts.DownloadPath)
+	if err != nil {
+		return "", err
+	}
+	metadata.Add(file.ID, downloads.NewRecord(file, u.clock.Now()))
+	if err := downloads.SaveMetadata(u.opts.DownloadPath, metadata); err != nil {
+		return "", err
+	}
+
+	return target, nil
+}
+
+// fetch writes the file called name with the given SHA-1 hash to w, from the download
+// cache when it has a copy
+func (u *Updater) fetch(name, hash string, w io.Writer, download func(io.Writer) error) error {
+	hit, err := u.cache.Fetch(hash, w, download)
+	if hit {
+		u.logger.Info("using cached download", "file_name", name, "sha1", hash)
+	}
+	return err
+}
+
+// fileSHA1 returns the SHA-1 hash CurseForge publishes for file, or ""
+func fileSHA1(file *api.ModFile) string {
+	for _, hash := range file.Hashes {
+		if hash.Algo == api.HashAlgoSHA1 {
+			return hash.Value
+		}
+	}
+	return ""
+}
+
+// copyInto copies the file at path to w
+func copyInto(w io.Writer, path string) error {
+	f, err := os.Open(path) // #nosec G304 -- path is a verified manual download
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// contextWriter passes writes on to w until ctx is done, which stops a download
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c *contextWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
+
+// sanitizeName makes a version string safe for use in a backup name
+func sanitizeName(s string) string {
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			out = append(out, r)
+		default:
+			out = append(out, '_')
+		}
+	}
+	return string(out)
+}
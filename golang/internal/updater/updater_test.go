@@ -0,0 +1,748 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/clock"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/downloads"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/events"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/klauspost/compress/zip"
+)
+
+// fakeCurseForge serves a single mod whose latest file can be swapped between calls
+type fakeCurseForge struct {
+	srv     *httptest.Server
+	latest  api.ModFile
+	earlier []api.ModFile // listed after latest
+	packs   map[int][]byte
+	files   map[int]api.ModFile // only served by id, such as the client file of a server pack
+}
+
+func newFakeCurseForge(t *testing.T) *fakeCurseForge {
+	f := &fakeCurseForge{packs: map[int][]byte{}, files: map[int]api.ModFile{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mods/1/files", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": append([]api.ModFile{f.latest}, f.earlier...)})
+	})
+	mux.HandleFunc("/mods/1", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": api.ModInfo{ID: 1, Links: api.ModLinks{WebsiteURL: "https://www.curseforge.com/minecraft/modpacks/test"}}})
+	})
+	mux.HandleFunc("/mods/1/files/", func(w http.ResponseWriter, r *http.Request) {
+		if id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/mods/1/files/")); err == nil {
+			if file, ok := f.files[id]; ok {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": file})
+				return
+			}
+		}
+		// download-url has no URL for files whose author disallows distribution
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": nil})
+	})
+	mux.HandleFunc("/download/", func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		_, _ = fmt.Sscanf(r.URL.Path, "/download/%d", &id)
+		_, _ = w.Write(f.packs[id])
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// publish makes a new server pack the latest file
+func (f *fakeCurseForge) publish(t *testing.T, id int, version string, date time.Time, files map[string]string) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create("pack/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.packs[id] = buf.Bytes()
+	f.latest = api.ModFile{
+		ID:           id,
+		ModID:        1,
+		DisplayName:  version,
+		FileName:     "pack-" + version + ".zip",
+		FileLength:   int64(buf.Len()),
+		FileDate:     date,
+		IsServerPack: true,
+		DownloadURL:  f.srv.URL + "/download/" + strconv.Itoa(id),
+	}
+}
+
+func TestUpdateSurvivesHistoryFailure(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	cf := newFakeCurseForge(t)
+
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, "data", state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: filepath.Join(dir, "downloads"),
+		})
+	u.SetClock(fake)
+	// A directory in place of the history file makes every append fail
+	historyPath := filepath.Join(dir, "data", history.FileName)
+	if err := os.MkdirAll(historyPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	u.SetHistory(history.NewLog(historyPath))
+
+	cf.publish(t, 100, "1.0.0", fake.Now(), map[string]string{"mods/a.jar": "v1"})
+	res, err := u.Update(false)
+	if err != nil || res.ToFileID != 100 {
+		t.Fatalf("Update = %+v, %v; want the installed update despite the history failure", res, err)
+	}
+	assertFile(t, filepath.Join(serverPath, "mods", "a.jar"), "v1")
+}
+
+func TestUpdateStopsWhenCanceled(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	cf := newFakeCurseForge(t)
+
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, "data", state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: filepath.Join(dir, "downloads"),
+		})
+	u.SetClock(fake)
+	cf.publish(t, 100, "1.0.0", fake.Now(), map[string]string{"mods/a.jar": "v1"})
+	if _, err := u.Update(false); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.Advance(time.Hour)
+	cf.publish(t, 101, "1.1.0", fake.Now(), map[string]string{"mods/a.jar": "v2"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	u.SetContext(ctx)
+	if _, err := u.Update(false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Update = %v, want context.Canceled", err)
+	}
+	assertFile(t, filepath.Join(serverPath, "mods", "a.jar"), "v1")
+	if entries, _ := os.ReadDir(filepath.Join(dir, "backups")); len(entries) != 0 {
+		t.Errorf("a canceled update left %d backups", len(entries))
+	}
+}
+
+func TestUpdateAndRollback(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	cf := newFakeCurseForge(t)
+
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	backups := server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0)
+	backups.SetClock(fake)
+	store := state.NewStore(filepath.Join(dir, "data", state.FileName))
+
+	u := New(client, backups, store, Options{
+		ModID:        1,
+		ServerPath:   serverPath,
+		DownloadPath: filepath.Join(dir, "downloads"),
+	})
+	u.SetClock(fake)
+	hist := history.NewLog(filepath.Join(dir, "data", history.FileName))
+	u.SetHistory(hist)
+
+	cf.publish(t, 100, "1.0.0", fake.Now(), map[string]string{"mods/a.jar": "v1"})
+	res, err := u.Update(false)
+	if err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	if res.Skipped || res.Backup != "" {
+		t.Fatalf("first install should run without a backup, got %+v", res)
+	}
+	assertFile(t, filepath.Join(serverPath, "mods", "a.jar"), "v1")
+
+	if res, err = u.Update(false); err != nil || !res.Skipped {
+		t.Fatalf("expected second update to be skipped, got %+v, %v", res, err)
+	}
+
+	fake.Advance(time.Hour)
+	cf.publish(t, 200, "1.1.0", fake.Now(), map[string]string{"mods/a.jar": "v2"})
+	if res, err = u.Update(false); err != nil {
+		t.Fatalf("second update: %v", err)
+	}
+	if res.FromFileID != 100 || res.ToFileID != 200 || res.Backup == "" {
+		t.Fatalf("unexpected update result %+v", res)
+	}
+	assertFile(t, filepath.Join(serverPath, "mods", "a.jar"), "v2")
+
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.InstalledFileID != 200 || st.PreviousFileID != 100 || st.LastBackup != res.Backup {
+		t.Fatalf("unexpected state after update: %+v", st)
+	}
+
+	rb, err := u.Rollback()
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if rb.State.InstalledFileID != 100 || rb.State.InstalledVersion != "1.0.0" {
+		t.Fatalf("unexpected state after rollback: %+v", rb.State)
+	}
+	assertFile(t, filepath.Join(serverPath, "mods", "a.jar"), "v1")
+
+	if _, err := u.Rollback(); err == nil {
+		t.Fatal("expected second rollback to fail without a recorded backup")
+	}
+
+	entries, err := hist.List(history.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantResults := []string{history.ResultSuccess, history.ResultSkipped, history.ResultSuccess}
+	if len(entries) != len(wantResults) {
+		t.Fatalf("expected %d history entries, got %d", len(wantResults), len(entries))
+	}
+	for i, want := range wantResults {
+		if entries[i].Result != want {
+			t.Fatalf("history entry %d result = %q, want %q", i, entries[i].Result, want)
+		}
+	}
+	if entries[0].Backup != res.Backup || entries[0].FromVersion != "1.0.0" || entries[0].ToVersion != "1.1.0" {
+		t.Fatalf("unexpected newest history entry %+v", entries[0])
+	}
+}
+
+func TestUpdateWithoutServerClearsLastBackup(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	cf := newFakeCurseForge(t)
+
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	store := state.NewStore(filepath.Join(dir, "data", state.FileName))
+	// An earlier update recorded a backup, then the server directory went away
+	if err := store.Save(&state.State{InstalledFileID: 100, InstalledVersion: "1.0.0", InstalledFileDate: fake.Now(),
+		LastBackup: "pre-update-1.0.0.zip", LastBackupAt: fake.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0), store, Options{
+		ModID:        1,
+		ServerPath:   serverPath,
+		DownloadPath: filepath.Join(dir, "downloads"),
+	})
+	u.SetClock(fake)
+
+	fake.Advance(time.Hour)
+	cf.publish(t, 101, "1.1.0", fake.Now(), map[string]string{"mods/a.jar": "v2"})
+	res, err := u.Update(false)
+	if err != nil || res.Backup != "" {
+		t.Fatalf("Update = %+v, %v; want an update without a backup", res, err)
+	}
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.LastBackup != "" || !st.LastBackupAt.IsZero() {
+		t.Errorf("last backup = %q at %s, want none", st.LastBackup, st.LastBackupAt)
+	}
+	if _, err := u.Rollback(); err == nil || !strings.Contains(err.Error(), "no pre-update backup") {
+		t.Errorf("Rollback = %v, want no recorded backup", err)
+	}
+}
+
+func TestUpdateEvents(t *testing.T) {
+	dir := t.TempDir()
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	serverPath := filepath.Join(dir, "server")
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: filepath.Join(dir, "downloads"),
+		})
+
+	var got []string
+	bus := events.NewBus(nil)
+	bus.Subscribe(func(e events.Event) {
+		if e.Type == EventUpdateProgress {
+			got = append(got, fmt.Sprintf("%s %v%%", e.Data["phase"], e.Data["percent"]))
+			return
+		}
+		got = append(got, e.Type)
+	})
+	u.SetBus(bus)
+
+	cf.publish(t, 100, "1.0.0", time.Now(), map[string]string{"mods/a.jar": "v1"})
+	if _, err := u.Update(false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pack has a single file, so unpacking it reports 99% at once
+	want := []string{EventCheckCompleted, EventUpdateStarted, "download 100%", "install 0%", "install 99%", "install 100%", EventUpdateFinished}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateManualDownload(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	manualPath := filepath.Join(dir, "manual")
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: filepath.Join(dir, "downloads"),
+			ManualPath:   manualPath,
+		})
+
+	cf.publish(t, 100, "1.0.0", time.Now(), map[string]string{"mods/a.jar": "v1"})
+	pack := cf.packs[100]
+	sum := sha1.Sum(pack)
+	cf.latest.DownloadURL = ""
+	cf.latest.Hashes = []api.FileHash{{Value: hex.EncodeToString(sum[:]), Algo: api.HashAlgoSHA1}}
+
+	_, err := u.Update(false)
+	var manual *ManualDownloadError
+	if !errors.As(err, &manual) {
+		t.Fatalf("err = %v, want a ManualDownloadError", err)
+	}
+	if len(manual.Files) != 1 || manual.Files[0].ProjectURL != "https://www.curseforge.com/minecraft/modpacks/test/files/100" {
+		t.Fatalf("unexpected manual downloads %+v", manual.Files)
+	}
+
+	if err := os.MkdirAll(manualPath, 0750); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(manualPath, cf.latest.FileName)
+	if err := os.WriteFile(target, []byte("not the pack"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Update(false); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("err = %v, want a hash mismatch", err)
+	}
+
+	// The wait hook lets the user drop the right file in while the update is running
+	u.SetManualWait(func(dir string, files []ManualDownload) error {
+		return os.WriteFile(target, pack, 0600)
+	})
+	if err := os.Remove(target); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Update(false); err != nil {
+		t.Fatalf("update with manual file: %v", err)
+	}
+	assertFile(t, filepath.Join(serverPath, "mods", "a.jar"), "v1")
+}
+
+// countingRestarter counts rollout restarts
+type countingRestarter struct{ restarts int }
+
+func (r *countingRestarter) Restart(time.Duration) error {
+	r.restarts++
+	return nil
+}
+
+func TestUpdateReadyFileAndRestart(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: filepath.Join(dir, "downloads"),
+		})
+	ready := filepath.Join(dir, "shared", DefaultReadyFile)
+	u.SetReadyFile(ready)
+	restarter := &countingRestarter{}
+	u.SetRestarter(restarter)
+
+	cf.publish(t, 100, "1.0.0", time.Now(), map[string]string{"mods/a.jar": "v1"})
+	if _, err := u.Update(false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(ready)
+	if err != nil || !strings.HasPrefix(string(data), "version=1.0.0\n") {
+		t.Fatalf("ready file = %q, %v", data, err)
+	}
+	if restarter.restarts != 1 {
+		t.Fatalf("restarts = %d after an update, want 1", restarter.restarts)
+	}
+
+	// An up to date install is ready without restarting the server again
+	if err := os.Remove(ready); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := u.Update(false); err != nil || !res.Skipped {
+		t.Fatalf("expected the update to be skipped, got %+v, %v", res, err)
+	}
+	if _, err := os.Stat(ready); err != nil || restarter.restarts != 1 {
+		t.Fatalf("ready file err = %v, restarts = %d", err, restarter.restarts)
+	}
+
+	// A failed install leaves the server marked as not ready
+	cf.publish(t, 200, "1.1.0", time.Now().Add(time.Hour), map[string]string{"mods/a.jar": "v2"})
+	cf.packs[200] = []byte("not a zip")
+	if _, err := u.Update(false); err == nil {
+		t.Fatal("expected the update of a broken pack to fail")
+	}
+	if _, err := os.Stat(ready); !os.IsNotExist(err) || restarter.restarts != 1 {
+		t.Fatalf("ready file err = %v, restarts = %d after a failed update", err, restarter.restarts)
+	}
+}
+
+// scriptedServer logs the lines of the current start attempt when its log is followed
+type scriptedServer struct {
+	restarts int
+	logs     [][]string // per restart
+}
+
+func (s *scriptedServer) Start() error                { return nil }
+func (s *scriptedServer) Stop(time.Duration) error    { return nil }
+func (s *scriptedServer) IsRunning() bool             { return true }
+func (s *scriptedServer) GetUptime() time.Duration    { return 0 }
+func (s *scriptedServer) Restart(time.Duration) error { s.restarts++; return nil }
+
+func (s *scriptedServer) Logs(ctx context.Context, w io.Writer, tail int, follow bool) error {
+	if s.restarts > len(s.logs) {
+		<-ctx.Done()
+		return nil
+	}
+	for _, line := range s.logs[s.restarts-1] {
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
+
+func TestUpdateRollsBackCrashLoop(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: filepath.Join(dir, "downloads"),
+		})
+	hist := history.NewLog(filepath.Join(dir, history.FileName))
+	u.SetHistory(hist)
+	mc := &scriptedServer{logs: [][]string{
+		{`[12:00:00] [Server thread/INFO]: Done (3.5s)! For help, type "help"`},
+		{
+			"[12:10:00] [main/ERROR] [net.minecraftforge.fml.ModLoader/LOADING]: Failed to load mod b",
+			"[12:10:00] [main/FATAL] [net.minecraftforge.server.loading.ServerModLoader/]: Crash report saved to ./crash-reports/crash-1-fml.txt",
+			"[12:10:30] [main/FATAL] [net.minecraftforge.server.loading.ServerModLoader/]: Crash report saved to ./crash-reports/crash-2-fml.txt",
+		},
+	}}
+	u.SetStartupCheck(mc, 0, config.HealthCheckConfig{Timeout: time.Minute, MaxCrashes: 2, Rollback: true})
+
+	cf.publish(t, 100, "1.0.0", time.Now(), map[string]string{"mods/a.jar": "v1"})
+	res, err := u.Update(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Startup == nil || !res.Startup.Done || res.Startup.StartupTime != 3500*time.Millisecond {
+		t.Fatalf("startup = %+v, want done in 3.5s", res.Startup)
+	}
+
+	cf.publish(t, 200, "1.1.0", time.Now().Add(time.Hour), map[string]string{"mods/a.jar": "v2", "mods/b.jar": "v1"})
+	_, err = u.Update(false)
+	if err == nil || !strings.Contains(err.Error(), "crashed 2 time(s)") || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("expected the crash-looping update to be rolled back, got %v", err)
+	}
+	assertFile(t, filepath.Join(serverPath, "mods", "a.jar"), "v1")
+	if mc.restarts != 3 {
+		t.Fatalf("restarts = %d, want one per update and one after the rollback", mc.restarts)
+	}
+	entries, err := hist.List(history.Filter{})
+	if err != nil || len(entries) != 2 || entries[0].Result != history.ResultFailed {
+		t.Fatalf("history = %+v, %v; want the rolled back update recorded as failed", entries, err)
+	}
+}
+
+// failingConsole records commands and fails the ones listed in fail
+type failingConsole struct {
+	sent []string
+	fail map[string]bool
+}
+
+func (c *failingConsole) SendCommand(command string) error {
+	c.sent = append(c.sent, command)
+	if c.fail[command] {
+		return errors.New("rcon: connection refused")
+	}
+	return nil
+}
+
+func TestUpdateRunsPostUpdateTasks(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: filepath.Join(dir, "downloads"),
+		})
+	done := []string{`[12:00:00] [Server thread/INFO]: Done (3.5s)! For help, type "help"`}
+	u.SetStartupCheck(&scriptedServer{logs: [][]string{done, done}}, 0, config.HealthCheckConfig{Timeout: time.Minute, MaxCrashes: 2})
+	console := &failingConsole{fail: map[string]bool{"chunky start": true}}
+	u.SetPostUpdateTasks(console, []config.PostUpdateTaskConfig{
+		{Name: "reload", Command: "reload"},
+		{Name: "pregenerate", Command: "chunky start", OnFailure: "fail"},
+		{Name: "announce", Command: "say pregenerated"},
+	})
+
+	cf.publish(t, 100, "1.0.0", time.Now(), map[string]string{"mods/a.jar": "v1"})
+	_, err := u.Update(false)
+	if err == nil || !strings.Contains(err.Error(), "post-update task pregenerate failed") {
+		t.Fatalf("expected the failed task to fail the update, got %v", err)
+	}
+	if got := strings.Join(console.sent, ","); got != "reload,chunky start" {
+		t.Errorf("sent %s, want the tasks after the failed one skipped", got)
+	}
+	// The update itself stays installed
+	assertFile(t, filepath.Join(serverPath, "mods", "a.jar"), "v1")
+
+	// With on_failure continue the remaining tasks still run
+	u.tasks[1].OnFailure = ""
+	console.sent = nil
+	cf.publish(t, 200, "1.1.0", time.Now().Add(time.Hour), map[string]string{"mods/a.jar": "v2"})
+	res, err := u.Update(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Tasks) != 3 || res.Tasks[1].Error == "" || res.Tasks[2].Error != "" || res.Tasks[2].Skipped {
+		t.Errorf("tasks = %+v", res.Tasks)
+	}
+}
+
+func TestUpdatePrunesDownloads(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	downloadPath := filepath.Join(dir, "downloads")
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: downloadPath,
+		})
+	u.SetKeepDownloads(2)
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, version := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+		cf.publish(t, 100+i, version, start.Add(time.Duration(i)*time.Hour), map[string]string{"mods/a.jar": version})
+		if _, err := u.Update(false); err != nil {
+			t.Fatalf("update to %s: %v", version, err)
+		}
+	}
+
+	for name, want := range map[string]bool{"pack-1.0.0.zip": false, "pack-1.1.0.zip": true, "pack-1.2.0.zip": true} {
+		if _, err := os.Stat(filepath.Join(downloadPath, name)); (err == nil) != want {
+			t.Errorf("%s exists = %t, want %t", name, err == nil, want)
+		}
+	}
+	metadata, err := downloads.LoadMetadata(downloadPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := metadata["100"]; ok || len(metadata) != 2 {
+		t.Errorf("metadata after pruning = %v, want files 101 and 102", metadata)
+	}
+}
+
+// dialingUploader records whether something answered on address while files were installed
+type dialingUploader struct {
+	address  string
+	answered bool
+}
+
+func (d *dialingUploader) Upload(string, []string) error {
+	conn, err := net.Dial("tcp", d.address)
+	if err == nil {
+		d.answered = true
+		conn.Close()
+	}
+	return nil
+}
+
+func TestUpdateMaintenanceMode(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, server.NewBackupManager(serverPath, filepath.Join(dir, "backups"), true, 0),
+		state.NewStore(filepath.Join(dir, state.FileName)), Options{
+			ModID:        1,
+			ServerPath:   serverPath,
+			DownloadPath: filepath.Join(dir, "downloads"),
+		})
+
+	// Find a free port for the stand-in
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	l.Close()
+	u.SetMaintenanceMode(address, "Updating to {version}", time.Minute)
+	uploader := &dialingUploader{address: address}
+	u.SetUploader(uploader)
+
+	cf.publish(t, 100, "1.0.0", time.Now(), map[string]string{"mods/a.jar": "v1"})
+	if _, err := u.Update(false); err != nil {
+		t.Fatal(err)
+	}
+	if !uploader.answered {
+		t.Error("nothing answered on the server port during the update")
+	}
+	if l, err := net.Listen("tcp", address); err != nil {
+		t.Errorf("server port still in use after the update: %v", err)
+	} else {
+		l.Close()
+	}
+
+	// A running server keeps its port and the update goes ahead without the stand-in
+	running, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer running.Close()
+	cf.publish(t, 101, "1.1.0", time.Now().Add(time.Hour), map[string]string{"mods/a.jar": "v2"})
+	if _, err := u.Update(false); err != nil {
+		t.Fatalf("update with the port in use: %v", err)
+	}
+}
+
+func TestCheckMinFileAge(t *testing.T) {
+	dir := t.TempDir()
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	cf := newFakeCurseForge(t)
+	client := api.NewClient("test")
+	client.BaseURL = cf.srv.URL
+	u := New(client, nil, state.NewStore(filepath.Join(dir, "data", state.FileName)), Options{ModID: 1, DownloadPath: filepath.Join(dir, "downloads")})
+	u.SetClock(fake)
+	u.SetMinFileAge(48 * time.Hour)
+
+	cf.publish(t, 100, "1.0.0", fake.Now(), nil)
+	if _, err := u.Check(); err == nil || !strings.Contains(err.Error(), "published 0s ago, min_age is 48h") {
+		t.Fatalf("Check with only a new file = %v, want a min_age error", err)
+	}
+
+	fake.Advance(72 * time.Hour)
+	cf.earlier = []api.ModFile{cf.latest}
+	cf.publish(t, 200, "1.1.0", fake.Now().Add(-5*time.Hour), nil)
+	res, err := u.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Latest.ID != 100 || len(res.HeldBack) != 1 || res.HeldBack[0].Version.Name != "1.1.0" {
+		t.Fatalf("Check = latest %d, held %+v; want 100 with 1.1.0 held back", res.Latest.ID, res.HeldBack)
+	}
+	if want := "published 5h ago, min_age is 48h"; res.HeldBack[0].Reason != want {
+		t.Errorf("reason = %q, want %q", res.HeldBack[0].Reason, want)
+	}
+
+	fake.Advance(48 * time.Hour)
+	if res, err = u.Check(); err != nil || res.Latest.ID != 200 || len(res.HeldBack) != 0 {
+		t.Fatalf("Check after min_age = %+v, %v; want 200", res, err)
+	}
+}
+
+func TestUpdateAvailable(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		state  state.State
+		latest api.ModFile
+		want   bool
+	}{
+		{"nothing installed", state.State{}, api.ModFile{ID: 1, FileDate: base}, true},
+		{"same file", state.State{InstalledFileID: 1, InstalledFileDate: base}, api.ModFile{ID: 1, FileDate: base}, false},
+		{"newer file", state.State{InstalledFileID: 1, InstalledFileDate: base}, api.ModFile{ID: 2, FileDate: base.Add(time.Hour)}, true},
+		{"older file", state.State{InstalledFileID: 2, InstalledFileDate: base}, api.ModFile{ID: 1, FileDate: base.Add(-time.Hour)}, false},
+		{"newer version uploaded earlier",
+			state.State{InstalledFileID: 1, InstalledVersion: "Pack 1.9.9", InstalledFileDate: base},
+			api.ModFile{ID: 2, DisplayName: "Pack 1.10.1", FileDate: base.Add(-time.Hour)}, true},
+		{"backport of an older version",
+			state.State{InstalledFileID: 2, InstalledVersion: "Pack 1.10.1", InstalledFileDate: base},
+			api.ModFile{ID: 3, DisplayName: "Pack 1.9.9", FileDate: base.Add(time.Hour)}, false},
+		{"same version uploaded again",
+			state.State{InstalledFileID: 1, InstalledVersion: "Pack 1.2.0", InstalledFileDate: base},
+			api.ModFile{ID: 2, DisplayName: "Pack 1.2.0", FileDate: base.Add(time.Hour)}, true},
+		{"same date, higher file ID", state.State{InstalledFileID: 1, InstalledFileDate: base}, api.ModFile{ID: 2, FileDate: base}, true},
+		{"same date, lower file ID", state.State{InstalledFileID: 2, InstalledFileDate: base}, api.ModFile{ID: 1, FileDate: base}, false},
+		{"Minecraft version first in the name",
+			state.State{InstalledFileID: 1, InstalledVersion: "Pack 3.2.0 for 1.20.1", InstalledGameVersion: "1.20.1", InstalledFileDate: base},
+			api.ModFile{ID: 2, DisplayName: "Pack (1.20.1) 3.3.0", FileDate: base.Add(-time.Hour)}, true},
+		{"Minecraft version first in an older name",
+			state.State{InstalledFileID: 2, InstalledVersion: "Pack (1.20.1) 3.3.0", InstalledFileDate: base},
+			api.ModFile{ID: 3, DisplayName: "Pack 3.2.0 for 1.20.1", FileDate: base.Add(time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateAvailable(&tt.state, &tt.latest, "1.20.1"); got != tt.want {
+				t.Fatalf("updateAvailable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func assertFile(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Fatalf("%s = %q, want %q", path, data, want)
+	}
+}
@@ -148,6 +148,8 @@ func Install() error {
 		"github.com/securego/gosec/v2/cmd/gosec@latest",
 		"github.com/a-h/templ/cmd/templ@latest",
 		"github.com/golangci/golangci-lint/cmd/golangci-lint@latest",
+		"google.golang.org/protobuf/cmd/protoc-gen-go@v1.36.11",
+		"google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.5.1",
 	}
 	for _, tool := range tools {
 		if err := sh.RunV("go", "install", tool); err != nil {
@@ -218,6 +220,15 @@ func Generate() error {
 	return sh.RunV("templ", "generate")
 }
 
+// Proto regenerates the gRPC control API in pkg/controlpb from control.proto. It needs
+// protoc on the PATH; Install sets up protoc-gen-go and protoc-gen-go-grpc.
+func Proto() error {
+	return sh.RunV("protoc", "-I", "pkg/controlpb",
+		"--go_out=pkg/controlpb", "--go_opt=paths=source_relative",
+		"--go-grpc_out=pkg/controlpb", "--go-grpc_opt=paths=source_relative",
+		"control.proto")
+}
+
 // Test runs unit tests with race detector and coverage analysis.
 func Test() error {
 	if os.Getenv("SHORT") == "1" {
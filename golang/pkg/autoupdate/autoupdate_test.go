@@ -0,0 +1,132 @@
+package autoupdate_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api/apitest"
+	"github.com/damianko135/curseforge-autoupdate/golang/pkg/autoupdate"
+)
+
+// recordingNotifier remembers what it was told
+type recordingNotifier struct {
+	succeeded []string
+	failed    []error
+}
+
+func (r *recordingNotifier) UpdateSucceeded(modpack string, result *autoupdate.UpdateResult) error {
+	r.succeeded = append(r.succeeded, modpack+" "+result.ToVersion)
+	return nil
+}
+
+func (r *recordingNotifier) UpdateFailed(modpack string, err error) error {
+	r.failed = append(r.failed, err)
+	return nil
+}
+
+// serverPack zips files into a server pack
+func serverPack(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create("pack/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUpdater(t *testing.T) {
+	dir := t.TempDir()
+	cf := apitest.NewServer(t)
+	pack := serverPack(t, map[string]string{"mods/a.jar": "v1"})
+	cf.AddMod(api.ModInfo{ID: 1, Name: "Test Pack", AllowModDistribution: true}, api.ModFile{
+		ID: 100, DisplayName: "1.0.0", FileName: "pack-1.0.0.zip", FileLength: int64(len(pack)),
+		FileDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), ReleaseType: api.ReleaseTypeRelease, IsServerPack: true,
+	})
+	cf.SetContent(100, pack)
+
+	notifier := &recordingNotifier{}
+	u, err := autoupdate.NewUpdater(autoupdate.UpdaterConfig{
+		APIKey:     "key",
+		ModpackID:  1,
+		Name:       "Test Pack",
+		ServerPath: filepath.Join(dir, "server"),
+		DataDir:    filepath.Join(dir, "data"),
+	}, autoupdate.WithBaseURL(cf.URL), autoupdate.WithNotifier(notifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := u.Check()
+	if err != nil || !check.UpdateAvailable || check.Latest.ID != 100 {
+		t.Fatalf("Check = %+v, %v; want file 100 available", check, err)
+	}
+	if _, err := u.Update(false); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "server", "mods", "a.jar")); err != nil || string(got) != "v1" {
+		t.Fatalf("installed mod = %q, %v", got, err)
+	}
+	if result, err := u.Update(false); err != nil || !result.Skipped {
+		t.Fatalf("second Update = %+v, %v; want it skipped", result, err)
+	}
+	if len(notifier.succeeded) != 1 || notifier.succeeded[0] != "Test Pack 1.0.0" || len(notifier.failed) != 0 {
+		t.Fatalf("notified %v and %v, want one success", notifier.succeeded, notifier.failed)
+	}
+
+	cf.Fail("/mods/1/files", 500, "down", 0)
+	if _, err := u.Update(false); err == nil || len(notifier.failed) != 1 {
+		t.Fatalf("Update with the API down: %v, notified %v", err, notifier.failed)
+	}
+}
+
+func TestNewUpdaterNeedsAKeyOrClient(t *testing.T) {
+	_, err := autoupdate.NewUpdater(autoupdate.UpdaterConfig{ModpackID: 1, ServerPath: "srv", DataDir: "data"})
+	if err == nil {
+		t.Fatal("NewUpdater succeeded without an API key or client")
+	}
+	cf := apitest.NewServer(t)
+	client := autoupdate.NewClient("", autoupdate.WithBaseURL(cf.URL))
+	if _, err := autoupdate.NewUpdater(autoupdate.UpdaterConfig{ModpackID: 1, ServerPath: "srv", DataDir: "data"}, autoupdate.WithClient(client)); err != nil {
+		t.Fatalf("NewUpdater with a client: %v", err)
+	}
+}
+
+func TestBackupManager(t *testing.T) {
+	dir := t.TempDir()
+	serverPath := filepath.Join(dir, "server")
+	if err := os.MkdirAll(filepath.Join(serverPath, "world"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(serverPath, "world", "level.dat"), []byte("level"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	bm := autoupdate.NewBackupManager(serverPath, filepath.Join(dir, "backups"), autoupdate.WithCompression(false))
+	info, err := bm.CreateBackup("before", "manual")
+	if err != nil {
+		t.Fatalf("CreateBackup: %v", err)
+	}
+	backups, err := bm.ListBackups()
+	if err != nil || len(backups) != 1 || backups[0].Name != info.Name {
+		t.Fatalf("ListBackups = %+v, %v", backups, err)
+	}
+	if err := bm.DeleteBackup(info.Name); err != nil {
+		t.Fatalf("DeleteBackup: %v", err)
+	}
+	if backups, err := bm.ListBackups(); err != nil || len(backups) != 0 {
+		t.Fatalf("ListBackups after delete = %+v, %v", backups, err)
+	}
+}
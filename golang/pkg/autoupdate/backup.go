@@ -0,0 +1,33 @@
+package autoupdate
+
+import (
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/server"
+)
+
+// BackupInfo describes a backup
+type BackupInfo = server.BackupInfo
+
+// BackupManager takes and restores backups of a server directory
+type BackupManager interface {
+	// CreateBackup backs up the server; backupType is recorded with it, e.g. "manual"
+	CreateBackup(name string, backupType string) (*BackupInfo, error)
+	// ListBackups returns the backups, newest first
+	ListBackups() ([]BackupInfo, error)
+	// RestoreBackup replaces the server files with those of a backup
+	RestoreBackup(name string) error
+	DeleteBackup(name string) error
+	// PruneBackups deletes the backups past the retention and returns them
+	PruneBackups() ([]BackupInfo, error)
+}
+
+// NewBackupManager manages backups of serverPath stored in backupPath. It reads
+// WithCompression, WithRetention and WithLogger.
+func NewBackupManager(serverPath, backupPath string, opts ...Option) BackupManager {
+	return newBackupManager(serverPath, backupPath, newOptions(opts))
+}
+
+func newBackupManager(serverPath, backupPath string, o *options) *server.BackupManager {
+	bm := server.NewBackupManager(serverPath, backupPath, o.compression, o.retentionDays)
+	bm.SetLogger(o.logger)
+	return bm
+}
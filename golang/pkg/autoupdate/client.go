@@ -0,0 +1,48 @@
+package autoupdate
+
+import (
+	"io"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/api"
+)
+
+// Types returned by Client
+type (
+	ModInfo      = api.ModInfo
+	ModFile      = api.ModFile
+	ProgressFunc = api.ProgressFunc
+)
+
+// Client looks up and downloads mods and their files on CurseForge
+type Client interface {
+	GetMod(modID int) (*ModInfo, error)
+	GetMods(modIDs []int) ([]ModInfo, error)
+	GetModFile(modID, fileID int) (*ModFile, error)
+	GetFiles(fileIDs []int) ([]ModFile, error)
+	// GetAllModFiles returns every file of a mod, following the API's pages
+	GetAllModFiles(modID int, gameVersion string, modLoaderType int) ([]ModFile, error)
+	GetModFileDownloadURL(modID, fileID int) (string, error)
+	// GetModFileChangelog returns the changelog of a file as plain text
+	GetModFileChangelog(modID, fileID int) (string, error)
+	DownloadFile(url string, writer io.Writer) error
+	DownloadFileProgress(url string, writer io.Writer, fn ProgressFunc) error
+}
+
+// NewClient creates a CurseForge client with the API key from the CurseForge console.
+// It reads WithHTTPClient, WithBaseURL, WithDownloadHost and WithLogger.
+func NewClient(apiKey string, opts ...Option) Client {
+	return newClient(apiKey, newOptions(opts))
+}
+
+func newClient(apiKey string, o *options) *api.Client {
+	client := api.NewClient(apiKey)
+	if o.httpClient != nil {
+		client.HTTPClient = o.httpClient
+	}
+	if o.baseURL != "" {
+		client.BaseURL = o.baseURL
+	}
+	client.DownloadHost = o.downloadHost
+	client.Logger = o.logger
+	return client
+}
@@ -0,0 +1,20 @@
+// Package autoupdate keeps a Minecraft server on the latest file of a CurseForge modpack,
+// for programs that embed the updater instead of running the CLI or the daemon.
+//
+// Everything is configured through constructor arguments and options; nothing is read
+// from config files or the environment:
+//
+//	client := autoupdate.NewClient(apiKey, autoupdate.WithLogger(logger))
+//	u, err := autoupdate.NewUpdater(autoupdate.UpdaterConfig{
+//		ModpackID:  925200,
+//		ServerPath: "/srv/minecraft",
+//		DataDir:    "/var/lib/modpack",
+//	}, autoupdate.WithClient(client), autoupdate.WithNotifier(autoupdate.NewDiscordNotifier(webhookURL)))
+//	if err != nil {
+//		return err
+//	}
+//	result, err := u.Update(false)
+//
+// The interfaces in this package are stable; the types aliased from the internal
+// packages may gain fields but keep the ones they have.
+package autoupdate
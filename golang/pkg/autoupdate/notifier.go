@@ -0,0 +1,69 @@
+package autoupdate
+
+import (
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/config"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/notification"
+)
+
+// Notifier is told about every update that installed a new file or failed; updates that
+// found nothing to install are not reported
+type Notifier interface {
+	UpdateSucceeded(modpack string, result *UpdateResult) error
+	UpdateFailed(modpack string, err error) error
+}
+
+// NewDiscordNotifier posts updates to a Discord webhook. It reads WithHTTPClient.
+func NewDiscordNotifier(webhookURL string, opts ...Option) Notifier {
+	return newManagerNotifier(&config.NotificationConfig{
+		Discord: config.DiscordConfig{Enabled: true, WebhookURL: webhookURL},
+	}, newOptions(opts))
+}
+
+// NewWebhookNotifier posts updates as JSON to url, in the same payload the daemon
+// sends. It reads WithHTTPClient.
+func NewWebhookNotifier(url string, opts ...Option) Notifier {
+	return newManagerNotifier(&config.NotificationConfig{
+		Webhook: config.WebhookConfig{Enabled: true, URL: url},
+	}, newOptions(opts))
+}
+
+// NewPushoverNotifier sends updates as Pushover messages from the application token
+// to a user or group key. It reads WithHTTPClient.
+func NewPushoverNotifier(token, user string, opts ...Option) Notifier {
+	return newManagerNotifier(&config.NotificationConfig{
+		Pushover: config.PushoverConfig{Enabled: true, Token: token, User: user},
+	}, newOptions(opts))
+}
+
+// NewNtfyNotifier publishes updates to topic on an ntfy server such as
+// "https://ntfy.sh"; token may be empty for public topics. It reads WithHTTPClient.
+func NewNtfyNotifier(server, topic, token string, opts ...Option) Notifier {
+	return newManagerNotifier(&config.NotificationConfig{
+		Ntfy: config.NtfyConfig{Enabled: true, Server: server, Topic: topic, Token: token},
+	}, newOptions(opts))
+}
+
+// managerNotifier adapts the daemon's notification channels to Notifier
+type managerNotifier struct {
+	manager *notification.Manager
+}
+
+func newManagerNotifier(cfg *config.NotificationConfig, o *options) *managerNotifier {
+	manager := notification.NewManager(cfg)
+	if o.httpClient != nil {
+		manager.SetHTTPClient(o.httpClient)
+	}
+	return &managerNotifier{manager: manager}
+}
+
+func (n *managerNotifier) UpdateSucceeded(modpack string, result *UpdateResult) error {
+	var client *notification.ClientPack
+	if c := result.Client; c != nil {
+		client = &notification.ClientPack{Version: c.Version, PageURL: c.PageURL, DownloadURL: c.DownloadURL}
+	}
+	return n.manager.SendUpdateSuccessNotification(modpack, result.ToVersion, result.Duration, result.Mods, client)
+}
+
+func (n *managerNotifier) UpdateFailed(modpack string, err error) error {
+	return n.manager.SendUpdateFailureNotification(modpack, "", err.Error())
+}
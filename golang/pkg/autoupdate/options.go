@@ -0,0 +1,79 @@
+package autoupdate
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Option configures the values built by the constructors of this package. Each option
+// lists the constructors that read it; the others ignore it.
+type Option func(*options)
+
+type options struct {
+	httpClient    *http.Client
+	baseURL       string
+	downloadHost  string
+	logger        *slog.Logger
+	client        Client
+	notifier      Notifier
+	compression   bool
+	retentionDays int
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{compression: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.logger == nil {
+		o.logger = slog.Default()
+	}
+	return o
+}
+
+// WithHTTPClient sends API requests, downloads and notifications through client, e.g. one
+// with a proxy or a longer timeout. Read by NewClient, NewUpdater and the notifiers.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) { o.httpClient = client }
+}
+
+// WithBaseURL sends API requests to url instead of https://api.curseforge.com/v1, e.g. a
+// caching proxy or a fake API in tests. A proxy that adds the API key itself needs no
+// key. Read by NewClient and NewUpdater.
+func WithBaseURL(url string) Option {
+	return func(o *options) { o.baseURL = url }
+}
+
+// WithDownloadHost fetches files on the CurseForge CDN from host, such as
+// http://cache:8080, instead. Read by NewClient and NewUpdater.
+func WithDownloadHost(host string) Option {
+	return func(o *options) { o.downloadHost = host }
+}
+
+// WithLogger logs through logger instead of slog.Default(). Read by every constructor.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// WithClient makes the updater use client instead of creating one from its API key and
+// the other options, e.g. to share a client or to substitute a fake. Read by NewUpdater.
+func WithClient(client Client) Option {
+	return func(o *options) { o.client = client }
+}
+
+// WithNotifier reports the outcome of updates to n. Read by NewUpdater.
+func WithNotifier(n Notifier) Option {
+	return func(o *options) { o.notifier = n }
+}
+
+// WithCompression writes backups as zip files (the default) or as plain directories.
+// Read by NewBackupManager and NewUpdater.
+func WithCompression(enabled bool) Option {
+	return func(o *options) { o.compression = enabled }
+}
+
+// WithRetention deletes backups older than days when new ones are taken; 0, the
+// default, keeps them all. Read by NewBackupManager and NewUpdater.
+func WithRetention(days int) Option {
+	return func(o *options) { o.retentionDays = days }
+}
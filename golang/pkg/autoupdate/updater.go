@@ -0,0 +1,130 @@
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/history"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/provider"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/state"
+	"github.com/damianko135/curseforge-autoupdate/golang/internal/updater"
+)
+
+// Types returned by Updater
+type (
+	CheckResult    = updater.CheckResult
+	UpdateResult   = updater.UpdateResult
+	RollbackResult = updater.RollbackResult
+	State          = state.State
+	Held           = provider.Held
+	Version        = provider.Version
+	ModChange      = history.ModChange
+)
+
+// Updater checks for, installs and rolls back versions of a modpack
+type Updater interface {
+	// Check compares the installed file with the latest one
+	Check() (*CheckResult, error)
+	// Update backs up the server and installs the latest file when it is newer than the
+	// installed one, or always with force. The result has Skipped set when there was
+	// nothing to install.
+	Update(force bool) (*UpdateResult, error)
+	// Rollback restores the backup taken before the last update
+	Rollback() (*RollbackResult, error)
+}
+
+// UpdaterConfig is what NewUpdater needs to know about the modpack and the server
+type UpdaterConfig struct {
+	APIKey     string // used unless WithClient is given; optional with a proxy, see WithBaseURL
+	ModpackID  int
+	Name       string // shown in notifications; "modpack <ModpackID>" when empty
+	ServerPath string
+	DataDir    string // holds the installed version and the update history
+
+	GameVersion string // empty for any
+	Channel     string // release (the default), beta or alpha
+
+	// Paths default to directories in DataDir
+	DownloadPath string
+	BackupPath   string
+	ManualPath   string // where files that cannot be downloaded automatically are dropped by hand
+}
+
+// NewUpdater creates an updater for the modpack in cfg. It reads every option.
+func NewUpdater(cfg UpdaterConfig, opts ...Option) (Updater, error) {
+	o := newOptions(opts)
+	switch {
+	case cfg.ModpackID <= 0:
+		return nil, errors.New("a modpack ID is required")
+	case cfg.ServerPath == "":
+		return nil, errors.New("a server path is required")
+	case cfg.DataDir == "":
+		return nil, errors.New("a data directory is required")
+	case cfg.APIKey == "" && o.client == nil && o.baseURL == "":
+		return nil, errors.New("an API key, a proxy base URL or a client is required")
+	}
+	if cfg.Name == "" {
+		cfg.Name = fmt.Sprintf("modpack %d", cfg.ModpackID)
+	}
+	if cfg.Channel == "" {
+		cfg.Channel = provider.ChannelRelease
+	}
+	if cfg.DownloadPath == "" {
+		cfg.DownloadPath = filepath.Join(cfg.DataDir, "downloads")
+	}
+	if cfg.BackupPath == "" {
+		cfg.BackupPath = filepath.Join(cfg.DataDir, "backups")
+	}
+	if cfg.ManualPath == "" {
+		cfg.ManualPath = filepath.Join(cfg.DataDir, "manual")
+	}
+
+	var client Client = o.client
+	if client == nil {
+		client = newClient(cfg.APIKey, o)
+	}
+	u := updater.New(
+		client,
+		newBackupManager(cfg.ServerPath, cfg.BackupPath, o),
+		state.NewStore(filepath.Join(cfg.DataDir, state.FileName)),
+		updater.Options{
+			ModID:          cfg.ModpackID,
+			GameVersion:    cfg.GameVersion,
+			ReleaseChannel: cfg.Channel,
+			ServerPath:     cfg.ServerPath,
+			DownloadPath:   cfg.DownloadPath,
+			ManualPath:     cfg.ManualPath,
+		},
+	)
+	u.SetHistory(history.NewLog(filepath.Join(cfg.DataDir, history.FileName)))
+	u.SetLogger(o.logger)
+	return &notifyingUpdater{Updater: u, name: cfg.Name, notifier: o.notifier, logger: o.logger}, nil
+}
+
+// notifyingUpdater reports the outcome of updates to its notifier
+type notifyingUpdater struct {
+	*updater.Updater
+	name     string
+	notifier Notifier
+	logger   *slog.Logger
+}
+
+func (n *notifyingUpdater) Update(force bool) (*UpdateResult, error) {
+	result, err := n.Updater.Update(force)
+	if n.notifier == nil {
+		return result, err
+	}
+	var nerr error
+	switch {
+	case err != nil:
+		nerr = n.notifier.UpdateFailed(n.name, err)
+	case !result.Skipped:
+		nerr = n.notifier.UpdateSucceeded(n.name, result)
+	}
+	if nerr != nil {
+		n.logger.Warn("failed to send update notification", "error", nerr)
+	}
+	return result, err
+}
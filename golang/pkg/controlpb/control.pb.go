@@ -0,0 +1,1812 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: control.proto
+
+package controlpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A [[servers]] entry; empty for the top-level server
+	Server        string `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetStatusRequest) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+type Status struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Server           string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	ModpackId        int64                  `protobuf:"varint,2,opt,name=modpack_id,json=modpackId,proto3" json:"modpack_id,omitempty"`
+	InstalledFileId  int64                  `protobuf:"varint,3,opt,name=installed_file_id,json=installedFileId,proto3" json:"installed_file_id,omitempty"`
+	InstalledVersion string                 `protobuf:"bytes,4,opt,name=installed_version,json=installedVersion,proto3" json:"installed_version,omitempty"`
+	LatestFileId     int64                  `protobuf:"varint,5,opt,name=latest_file_id,json=latestFileId,proto3" json:"latest_file_id,omitempty"`
+	LatestVersion    string                 `protobuf:"bytes,6,opt,name=latest_version,json=latestVersion,proto3" json:"latest_version,omitempty"`
+	// Unset before the first check
+	LastCheckAt   *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=last_check_at,json=lastCheckAt,proto3" json:"last_check_at,omitempty"`
+	UpToDate      bool                   `protobuf:"varint,8,opt,name=up_to_date,json=upToDate,proto3" json:"up_to_date,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Status) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *Status) GetModpackId() int64 {
+	if x != nil {
+		return x.ModpackId
+	}
+	return 0
+}
+
+func (x *Status) GetInstalledFileId() int64 {
+	if x != nil {
+		return x.InstalledFileId
+	}
+	return 0
+}
+
+func (x *Status) GetInstalledVersion() string {
+	if x != nil {
+		return x.InstalledVersion
+	}
+	return ""
+}
+
+func (x *Status) GetLatestFileId() int64 {
+	if x != nil {
+		return x.LatestFileId
+	}
+	return 0
+}
+
+func (x *Status) GetLatestVersion() string {
+	if x != nil {
+		return x.LatestVersion
+	}
+	return ""
+}
+
+func (x *Status) GetLastCheckAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastCheckAt
+	}
+	return nil
+}
+
+func (x *Status) GetUpToDate() bool {
+	if x != nil {
+		return x.UpToDate
+	}
+	return false
+}
+
+type CheckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckRequest) Reset() {
+	*x = CheckRequest{}
+	mi := &file_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRequest) ProtoMessage() {}
+
+func (x *CheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRequest.ProtoReflect.Descriptor instead.
+func (*CheckRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{2}
+}
+
+type CheckResult struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ModId            int64                  `protobuf:"varint,1,opt,name=mod_id,json=modId,proto3" json:"mod_id,omitempty"`
+	InstalledFileId  int64                  `protobuf:"varint,2,opt,name=installed_file_id,json=installedFileId,proto3" json:"installed_file_id,omitempty"`
+	InstalledVersion string                 `protobuf:"bytes,3,opt,name=installed_version,json=installedVersion,proto3" json:"installed_version,omitempty"`
+	LatestFileId     int64                  `protobuf:"varint,4,opt,name=latest_file_id,json=latestFileId,proto3" json:"latest_file_id,omitempty"`
+	LatestVersion    string                 `protobuf:"bytes,5,opt,name=latest_version,json=latestVersion,proto3" json:"latest_version,omitempty"`
+	LatestFileDate   *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=latest_file_date,json=latestFileDate,proto3" json:"latest_file_date,omitempty"`
+	UpdateAvailable  bool                   `protobuf:"varint,7,opt,name=update_available,json=updateAvailable,proto3" json:"update_available,omitempty"`
+	// Newer files the release policy held back
+	HeldBack []*HeldFile `protobuf:"bytes,8,rep,name=held_back,json=heldBack,proto3" json:"held_back,omitempty"`
+	// The update_policy hold rule that matched the update, if any
+	HeldForReview    *HoldRuleMatch    `protobuf:"bytes,9,opt,name=held_for_review,json=heldForReview,proto3" json:"held_for_review,omitempty"`
+	MinecraftUpgrade *MinecraftUpgrade `protobuf:"bytes,10,opt,name=minecraft_upgrade,json=minecraftUpgrade,proto3" json:"minecraft_upgrade,omitempty"`
+	// What each release channel would install
+	Channels      []*ChannelStatus `protobuf:"bytes,11,rep,name=channels,proto3" json:"channels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckResult) Reset() {
+	*x = CheckResult{}
+	mi := &file_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckResult) ProtoMessage() {}
+
+func (x *CheckResult) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckResult.ProtoReflect.Descriptor instead.
+func (*CheckResult) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CheckResult) GetModId() int64 {
+	if x != nil {
+		return x.ModId
+	}
+	return 0
+}
+
+func (x *CheckResult) GetInstalledFileId() int64 {
+	if x != nil {
+		return x.InstalledFileId
+	}
+	return 0
+}
+
+func (x *CheckResult) GetInstalledVersion() string {
+	if x != nil {
+		return x.InstalledVersion
+	}
+	return ""
+}
+
+func (x *CheckResult) GetLatestFileId() int64 {
+	if x != nil {
+		return x.LatestFileId
+	}
+	return 0
+}
+
+func (x *CheckResult) GetLatestVersion() string {
+	if x != nil {
+		return x.LatestVersion
+	}
+	return ""
+}
+
+func (x *CheckResult) GetLatestFileDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LatestFileDate
+	}
+	return nil
+}
+
+func (x *CheckResult) GetUpdateAvailable() bool {
+	if x != nil {
+		return x.UpdateAvailable
+	}
+	return false
+}
+
+func (x *CheckResult) GetHeldBack() []*HeldFile {
+	if x != nil {
+		return x.HeldBack
+	}
+	return nil
+}
+
+func (x *CheckResult) GetHeldForReview() *HoldRuleMatch {
+	if x != nil {
+		return x.HeldForReview
+	}
+	return nil
+}
+
+func (x *CheckResult) GetMinecraftUpgrade() *MinecraftUpgrade {
+	if x != nil {
+		return x.MinecraftUpgrade
+	}
+	return nil
+}
+
+func (x *CheckResult) GetChannels() []*ChannelStatus {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+type HeldFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Channel       string                 `protobuf:"bytes,2,opt,name=channel,proto3" json:"channel,omitempty"`
+	Published     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=published,proto3" json:"published,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeldFile) Reset() {
+	*x = HeldFile{}
+	mi := &file_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeldFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeldFile) ProtoMessage() {}
+
+func (x *HeldFile) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeldFile.ProtoReflect.Descriptor instead.
+func (*HeldFile) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *HeldFile) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *HeldFile) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *HeldFile) GetPublished() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Published
+	}
+	return nil
+}
+
+func (x *HeldFile) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type HoldRuleMatch struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Rule  string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	// file_name or changelog
+	Field         string `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`
+	Match         string `protobuf:"bytes,3,opt,name=match,proto3" json:"match,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldRuleMatch) Reset() {
+	*x = HoldRuleMatch{}
+	mi := &file_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldRuleMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldRuleMatch) ProtoMessage() {}
+
+func (x *HoldRuleMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldRuleMatch.ProtoReflect.Descriptor instead.
+func (*HoldRuleMatch) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *HoldRuleMatch) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *HoldRuleMatch) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *HoldRuleMatch) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+type MinecraftUpgrade struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MinecraftUpgrade) Reset() {
+	*x = MinecraftUpgrade{}
+	mi := &file_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MinecraftUpgrade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MinecraftUpgrade) ProtoMessage() {}
+
+func (x *MinecraftUpgrade) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MinecraftUpgrade.ProtoReflect.Descriptor instead.
+func (*MinecraftUpgrade) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MinecraftUpgrade) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *MinecraftUpgrade) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type ChannelStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// stable, beta or alpha
+	Channel         string                 `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	FileId          int64                  `protobuf:"varint,2,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Version         string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Published       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=published,proto3" json:"published,omitempty"`
+	UpdateAvailable bool                   `protobuf:"varint,5,opt,name=update_available,json=updateAvailable,proto3" json:"update_available,omitempty"`
+	// The channel is update_channel
+	Tracked       bool `protobuf:"varint,6,opt,name=tracked,proto3" json:"tracked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChannelStatus) Reset() {
+	*x = ChannelStatus{}
+	mi := &file_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChannelStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChannelStatus) ProtoMessage() {}
+
+func (x *ChannelStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChannelStatus.ProtoReflect.Descriptor instead.
+func (*ChannelStatus) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ChannelStatus) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *ChannelStatus) GetFileId() int64 {
+	if x != nil {
+		return x.FileId
+	}
+	return 0
+}
+
+func (x *ChannelStatus) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ChannelStatus) GetPublished() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Published
+	}
+	return nil
+}
+
+func (x *ChannelStatus) GetUpdateAvailable() bool {
+	if x != nil {
+		return x.UpdateAvailable
+	}
+	return false
+}
+
+func (x *ChannelStatus) GetTracked() bool {
+	if x != nil {
+		return x.Tracked
+	}
+	return false
+}
+
+type UpdateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Reinstall even when the latest file is installed
+	Force bool `protobuf:"varint,1,opt,name=force,proto3" json:"force,omitempty"`
+	// Install an update to a newer Minecraft version despite confirm_minecraft_upgrades
+	AllowMcUpgrade bool `protobuf:"varint,2,opt,name=allow_mc_upgrade,json=allowMcUpgrade,proto3" json:"allow_mc_upgrade,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UpdateRequest) Reset() {
+	*x = UpdateRequest{}
+	mi := &file_control_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRequest) ProtoMessage() {}
+
+func (x *UpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+func (x *UpdateRequest) GetAllowMcUpgrade() bool {
+	if x != nil {
+		return x.AllowMcUpgrade
+	}
+	return false
+}
+
+type UpdateResult struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ModId            int64                  `protobuf:"varint,1,opt,name=mod_id,json=modId,proto3" json:"mod_id,omitempty"`
+	FromFileId       int64                  `protobuf:"varint,2,opt,name=from_file_id,json=fromFileId,proto3" json:"from_file_id,omitempty"`
+	FromVersion      string                 `protobuf:"bytes,3,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"`
+	ToFileId         int64                  `protobuf:"varint,4,opt,name=to_file_id,json=toFileId,proto3" json:"to_file_id,omitempty"`
+	ToVersion        string                 `protobuf:"bytes,5,opt,name=to_version,json=toVersion,proto3" json:"to_version,omitempty"`
+	Backup           string                 `protobuf:"bytes,6,opt,name=backup,proto3" json:"backup,omitempty"`
+	DownloadedFile   string                 `protobuf:"bytes,7,opt,name=downloaded_file,json=downloadedFile,proto3" json:"downloaded_file,omitempty"`
+	DurationSeconds  float64                `protobuf:"fixed64,8,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`
+	Skipped          bool                   `protobuf:"varint,9,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	Preserved        []string               `protobuf:"bytes,10,rep,name=preserved,proto3" json:"preserved,omitempty"`
+	Merged           []*PropertiesMerge     `protobuf:"bytes,11,rep,name=merged,proto3" json:"merged,omitempty"`
+	Mods             []*ModChange           `protobuf:"bytes,12,rep,name=mods,proto3" json:"mods,omitempty"`
+	Startup          *StartupReport         `protobuf:"bytes,13,opt,name=startup,proto3" json:"startup,omitempty"`
+	Tasks            []*TaskResult          `protobuf:"bytes,14,rep,name=tasks,proto3" json:"tasks,omitempty"`
+	Sync             *ModSync               `protobuf:"bytes,15,opt,name=sync,proto3" json:"sync,omitempty"`
+	MinecraftUpgrade *MinecraftUpgrade      `protobuf:"bytes,16,opt,name=minecraft_upgrade,json=minecraftUpgrade,proto3" json:"minecraft_upgrade,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UpdateResult) Reset() {
+	*x = UpdateResult{}
+	mi := &file_control_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateResult) ProtoMessage() {}
+
+func (x *UpdateResult) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateResult.ProtoReflect.Descriptor instead.
+func (*UpdateResult) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateResult) GetModId() int64 {
+	if x != nil {
+		return x.ModId
+	}
+	return 0
+}
+
+func (x *UpdateResult) GetFromFileId() int64 {
+	if x != nil {
+		return x.FromFileId
+	}
+	return 0
+}
+
+func (x *UpdateResult) GetFromVersion() string {
+	if x != nil {
+		return x.FromVersion
+	}
+	return ""
+}
+
+func (x *UpdateResult) GetToFileId() int64 {
+	if x != nil {
+		return x.ToFileId
+	}
+	return 0
+}
+
+func (x *UpdateResult) GetToVersion() string {
+	if x != nil {
+		return x.ToVersion
+	}
+	return ""
+}
+
+func (x *UpdateResult) GetBackup() string {
+	if x != nil {
+		return x.Backup
+	}
+	return ""
+}
+
+func (x *UpdateResult) GetDownloadedFile() string {
+	if x != nil {
+		return x.DownloadedFile
+	}
+	return ""
+}
+
+func (x *UpdateResult) GetDurationSeconds() float64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+func (x *UpdateResult) GetSkipped() bool {
+	if x != nil {
+		return x.Skipped
+	}
+	return false
+}
+
+func (x *UpdateResult) GetPreserved() []string {
+	if x != nil {
+		return x.Preserved
+	}
+	return nil
+}
+
+func (x *UpdateResult) GetMerged() []*PropertiesMerge {
+	if x != nil {
+		return x.Merged
+	}
+	return nil
+}
+
+func (x *UpdateResult) GetMods() []*ModChange {
+	if x != nil {
+		return x.Mods
+	}
+	return nil
+}
+
+func (x *UpdateResult) GetStartup() *StartupReport {
+	if x != nil {
+		return x.Startup
+	}
+	return nil
+}
+
+func (x *UpdateResult) GetTasks() []*TaskResult {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+func (x *UpdateResult) GetSync() *ModSync {
+	if x != nil {
+		return x.Sync
+	}
+	return nil
+}
+
+func (x *UpdateResult) GetMinecraftUpgrade() *MinecraftUpgrade {
+	if x != nil {
+		return x.MinecraftUpgrade
+	}
+	return nil
+}
+
+type PropertiesMerge struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	File          string                 `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	FromPack      []string               `protobuf:"bytes,2,rep,name=from_pack,json=fromPack,proto3" json:"from_pack,omitempty"`
+	Removed       []string               `protobuf:"bytes,3,rep,name=removed,proto3" json:"removed,omitempty"`
+	Conflicts     []string               `protobuf:"bytes,4,rep,name=conflicts,proto3" json:"conflicts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PropertiesMerge) Reset() {
+	*x = PropertiesMerge{}
+	mi := &file_control_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PropertiesMerge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PropertiesMerge) ProtoMessage() {}
+
+func (x *PropertiesMerge) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PropertiesMerge.ProtoReflect.Descriptor instead.
+func (*PropertiesMerge) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PropertiesMerge) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *PropertiesMerge) GetFromPack() []string {
+	if x != nil {
+		return x.FromPack
+	}
+	return nil
+}
+
+func (x *PropertiesMerge) GetRemoved() []string {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *PropertiesMerge) GetConflicts() []string {
+	if x != nil {
+		return x.Conflicts
+	}
+	return nil
+}
+
+type ModChange struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId int64                  `protobuf:"varint,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	Name      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Empty when the mod was added
+	FromVersion string `protobuf:"bytes,3,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"`
+	// Empty when the mod was removed
+	ToVersion     string `protobuf:"bytes,4,opt,name=to_version,json=toVersion,proto3" json:"to_version,omitempty"`
+	Changelog     string `protobuf:"bytes,5,opt,name=changelog,proto3" json:"changelog,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModChange) Reset() {
+	*x = ModChange{}
+	mi := &file_control_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModChange) ProtoMessage() {}
+
+func (x *ModChange) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModChange.ProtoReflect.Descriptor instead.
+func (*ModChange) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ModChange) GetProjectId() int64 {
+	if x != nil {
+		return x.ProjectId
+	}
+	return 0
+}
+
+func (x *ModChange) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ModChange) GetFromVersion() string {
+	if x != nil {
+		return x.FromVersion
+	}
+	return ""
+}
+
+func (x *ModChange) GetToVersion() string {
+	if x != nil {
+		return x.ToVersion
+	}
+	return ""
+}
+
+func (x *ModChange) GetChangelog() string {
+	if x != nil {
+		return x.Changelog
+	}
+	return ""
+}
+
+type StartupReport struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Done           bool                   `protobuf:"varint,1,opt,name=done,proto3" json:"done,omitempty"`
+	StartupSeconds float64                `protobuf:"fixed64,2,opt,name=startup_seconds,json=startupSeconds,proto3" json:"startup_seconds,omitempty"`
+	Crashes        int32                  `protobuf:"varint,3,opt,name=crashes,proto3" json:"crashes,omitempty"`
+	CrashReports   []string               `protobuf:"bytes,4,rep,name=crash_reports,json=crashReports,proto3" json:"crash_reports,omitempty"`
+	Errors         []string               `protobuf:"bytes,5,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StartupReport) Reset() {
+	*x = StartupReport{}
+	mi := &file_control_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartupReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartupReport) ProtoMessage() {}
+
+func (x *StartupReport) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartupReport.ProtoReflect.Descriptor instead.
+func (*StartupReport) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StartupReport) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *StartupReport) GetStartupSeconds() float64 {
+	if x != nil {
+		return x.StartupSeconds
+	}
+	return 0
+}
+
+func (x *StartupReport) GetCrashes() int32 {
+	if x != nil {
+		return x.Crashes
+	}
+	return 0
+}
+
+func (x *StartupReport) GetCrashReports() []string {
+	if x != nil {
+		return x.CrashReports
+	}
+	return nil
+}
+
+func (x *StartupReport) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+type TaskResult struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Name            string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Command         string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	DurationSeconds float64                `protobuf:"fixed64,3,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`
+	Skipped         bool                   `protobuf:"varint,4,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	Error           string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TaskResult) Reset() {
+	*x = TaskResult{}
+	mi := &file_control_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskResult) ProtoMessage() {}
+
+func (x *TaskResult) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskResult.ProtoReflect.Descriptor instead.
+func (*TaskResult) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TaskResult) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TaskResult) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *TaskResult) GetDurationSeconds() float64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+func (x *TaskResult) GetSkipped() bool {
+	if x != nil {
+		return x.Skipped
+	}
+	return false
+}
+
+func (x *TaskResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ModSync struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Downloaded    []string               `protobuf:"bytes,1,rep,name=downloaded,proto3" json:"downloaded,omitempty"`
+	Removed       []string               `protobuf:"bytes,2,rep,name=removed,proto3" json:"removed,omitempty"`
+	Skipped       []string               `protobuf:"bytes,3,rep,name=skipped,proto3" json:"skipped,omitempty"`
+	Overrides     int32                  `protobuf:"varint,4,opt,name=overrides,proto3" json:"overrides,omitempty"`
+	DownloadBytes int64                  `protobuf:"varint,5,opt,name=download_bytes,json=downloadBytes,proto3" json:"download_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModSync) Reset() {
+	*x = ModSync{}
+	mi := &file_control_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModSync) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModSync) ProtoMessage() {}
+
+func (x *ModSync) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModSync.ProtoReflect.Descriptor instead.
+func (*ModSync) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ModSync) GetDownloaded() []string {
+	if x != nil {
+		return x.Downloaded
+	}
+	return nil
+}
+
+func (x *ModSync) GetRemoved() []string {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *ModSync) GetSkipped() []string {
+	if x != nil {
+		return x.Skipped
+	}
+	return nil
+}
+
+func (x *ModSync) GetOverrides() int32 {
+	if x != nil {
+		return x.Overrides
+	}
+	return 0
+}
+
+func (x *ModSync) GetDownloadBytes() int64 {
+	if x != nil {
+		return x.DownloadBytes
+	}
+	return 0
+}
+
+type ListBackupsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBackupsRequest) Reset() {
+	*x = ListBackupsRequest{}
+	mi := &file_control_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBackupsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBackupsRequest) ProtoMessage() {}
+
+func (x *ListBackupsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBackupsRequest.ProtoReflect.Descriptor instead.
+func (*ListBackupsRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{15}
+}
+
+type ListBackupsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Backups       []*Backup              `protobuf:"bytes,1,rep,name=backups,proto3" json:"backups,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBackupsResponse) Reset() {
+	*x = ListBackupsResponse{}
+	mi := &file_control_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBackupsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBackupsResponse) ProtoMessage() {}
+
+func (x *ListBackupsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBackupsResponse.ProtoReflect.Descriptor instead.
+func (*ListBackupsResponse) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListBackupsResponse) GetBackups() []*Backup {
+	if x != nil {
+		return x.Backups
+	}
+	return nil
+}
+
+type CreateBackupRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// File name without a path; empty for a generated one
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// manual (default) or world
+	Type          string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateBackupRequest) Reset() {
+	*x = CreateBackupRequest{}
+	mi := &file_control_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateBackupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBackupRequest) ProtoMessage() {}
+
+func (x *CreateBackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBackupRequest.ProtoReflect.Descriptor instead.
+func (*CreateBackupRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CreateBackupRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateBackupRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type Backup struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Name       string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Path       string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Type       string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	SizeBytes  int64                  `protobuf:"varint,4,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	Created    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created,proto3" json:"created,omitempty"`
+	Compressed bool                   `protobuf:"varint,6,opt,name=compressed,proto3" json:"compressed,omitempty"`
+	Encrypted  bool                   `protobuf:"varint,7,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	Protected  bool                   `protobuf:"varint,8,opt,name=protected,proto3" json:"protected,omitempty"`
+	// Recorded in the backup index; empty for backups made before it
+	Trigger         string  `protobuf:"bytes,9,opt,name=trigger,proto3" json:"trigger,omitempty"`
+	Version         string  `protobuf:"bytes,10,opt,name=version,proto3" json:"version,omitempty"`
+	Checksum        string  `protobuf:"bytes,11,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	DurationSeconds float64 `protobuf:"fixed64,12,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Backup) Reset() {
+	*x = Backup{}
+	mi := &file_control_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Backup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Backup) ProtoMessage() {}
+
+func (x *Backup) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Backup.ProtoReflect.Descriptor instead.
+func (*Backup) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Backup) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Backup) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Backup) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Backup) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *Backup) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Backup) GetCompressed() bool {
+	if x != nil {
+		return x.Compressed
+	}
+	return false
+}
+
+func (x *Backup) GetEncrypted() bool {
+	if x != nil {
+		return x.Encrypted
+	}
+	return false
+}
+
+func (x *Backup) GetProtected() bool {
+	if x != nil {
+		return x.Protected
+	}
+	return false
+}
+
+func (x *Backup) GetTrigger() string {
+	if x != nil {
+		return x.Trigger
+	}
+	return ""
+}
+
+func (x *Backup) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Backup) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *Backup) GetDurationSeconds() float64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+type WatchEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Event types to stream, e.g. update_progress; empty for all
+	Types         []string `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_control_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *WatchEventsRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// e.g. update_finished, see internal/events
+	Type string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Time *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// The event data as in the JSON of GET /api/v1/events
+	Data          *structpb.Struct `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_control_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_control_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_control_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetData() *structpb.Struct {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_control_proto protoreflect.FileDescriptor
+
+const file_control_proto_rawDesc = "" +
+	"\n" +
+	"\rcontrol.proto\x12\x16autoupdater.control.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"*\n" +
+	"\x10GetStatusRequest\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\"\xc3\x02\n" +
+	"\x06Status\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x1d\n" +
+	"\n" +
+	"modpack_id\x18\x02 \x01(\x03R\tmodpackId\x12*\n" +
+	"\x11installed_file_id\x18\x03 \x01(\x03R\x0finstalledFileId\x12+\n" +
+	"\x11installed_version\x18\x04 \x01(\tR\x10installedVersion\x12$\n" +
+	"\x0elatest_file_id\x18\x05 \x01(\x03R\flatestFileId\x12%\n" +
+	"\x0elatest_version\x18\x06 \x01(\tR\rlatestVersion\x12>\n" +
+	"\rlast_check_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\vlastCheckAt\x12\x1c\n" +
+	"\n" +
+	"up_to_date\x18\b \x01(\bR\bupToDate\"\x0e\n" +
+	"\fCheckRequest\"\xe3\x04\n" +
+	"\vCheckResult\x12\x15\n" +
+	"\x06mod_id\x18\x01 \x01(\x03R\x05modId\x12*\n" +
+	"\x11installed_file_id\x18\x02 \x01(\x03R\x0finstalledFileId\x12+\n" +
+	"\x11installed_version\x18\x03 \x01(\tR\x10installedVersion\x12$\n" +
+	"\x0elatest_file_id\x18\x04 \x01(\x03R\flatestFileId\x12%\n" +
+	"\x0elatest_version\x18\x05 \x01(\tR\rlatestVersion\x12D\n" +
+	"\x10latest_file_date\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x0elatestFileDate\x12)\n" +
+	"\x10update_available\x18\a \x01(\bR\x0fupdateAvailable\x12=\n" +
+	"\theld_back\x18\b \x03(\v2 .autoupdater.control.v1.HeldFileR\bheldBack\x12M\n" +
+	"\x0fheld_for_review\x18\t \x01(\v2%.autoupdater.control.v1.HoldRuleMatchR\rheldForReview\x12U\n" +
+	"\x11minecraft_upgrade\x18\n" +
+	" \x01(\v2(.autoupdater.control.v1.MinecraftUpgradeR\x10minecraftUpgrade\x12A\n" +
+	"\bchannels\x18\v \x03(\v2%.autoupdater.control.v1.ChannelStatusR\bchannels\"\x90\x01\n" +
+	"\bHeldFile\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x18\n" +
+	"\achannel\x18\x02 \x01(\tR\achannel\x128\n" +
+	"\tpublished\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tpublished\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"O\n" +
+	"\rHoldRuleMatch\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x14\n" +
+	"\x05field\x18\x02 \x01(\tR\x05field\x12\x14\n" +
+	"\x05match\x18\x03 \x01(\tR\x05match\"6\n" +
+	"\x10MinecraftUpgrade\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\"\xdb\x01\n" +
+	"\rChannelStatus\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\tR\achannel\x12\x17\n" +
+	"\afile_id\x18\x02 \x01(\x03R\x06fileId\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x128\n" +
+	"\tpublished\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tpublished\x12)\n" +
+	"\x10update_available\x18\x05 \x01(\bR\x0fupdateAvailable\x12\x18\n" +
+	"\atracked\x18\x06 \x01(\bR\atracked\"O\n" +
+	"\rUpdateRequest\x12\x14\n" +
+	"\x05force\x18\x01 \x01(\bR\x05force\x12(\n" +
+	"\x10allow_mc_upgrade\x18\x02 \x01(\bR\x0eallowMcUpgrade\"\xca\x05\n" +
+	"\fUpdateResult\x12\x15\n" +
+	"\x06mod_id\x18\x01 \x01(\x03R\x05modId\x12 \n" +
+	"\ffrom_file_id\x18\x02 \x01(\x03R\n" +
+	"fromFileId\x12!\n" +
+	"\ffrom_version\x18\x03 \x01(\tR\vfromVersion\x12\x1c\n" +
+	"\n" +
+	"to_file_id\x18\x04 \x01(\x03R\btoFileId\x12\x1d\n" +
+	"\n" +
+	"to_version\x18\x05 \x01(\tR\ttoVersion\x12\x16\n" +
+	"\x06backup\x18\x06 \x01(\tR\x06backup\x12'\n" +
+	"\x0fdownloaded_file\x18\a \x01(\tR\x0edownloadedFile\x12)\n" +
+	"\x10duration_seconds\x18\b \x01(\x01R\x0fdurationSeconds\x12\x18\n" +
+	"\askipped\x18\t \x01(\bR\askipped\x12\x1c\n" +
+	"\tpreserved\x18\n" +
+	" \x03(\tR\tpreserved\x12?\n" +
+	"\x06merged\x18\v \x03(\v2'.autoupdater.control.v1.PropertiesMergeR\x06merged\x125\n" +
+	"\x04mods\x18\f \x03(\v2!.autoupdater.control.v1.ModChangeR\x04mods\x12?\n" +
+	"\astartup\x18\r \x01(\v2%.autoupdater.control.v1.StartupReportR\astartup\x128\n" +
+	"\x05tasks\x18\x0e \x03(\v2\".autoupdater.control.v1.TaskResultR\x05tasks\x123\n" +
+	"\x04sync\x18\x0f \x01(\v2\x1f.autoupdater.control.v1.ModSyncR\x04sync\x12U\n" +
+	"\x11minecraft_upgrade\x18\x10 \x01(\v2(.autoupdater.control.v1.MinecraftUpgradeR\x10minecraftUpgrade\"z\n" +
+	"\x0fPropertiesMerge\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\tR\x04file\x12\x1b\n" +
+	"\tfrom_pack\x18\x02 \x03(\tR\bfromPack\x12\x18\n" +
+	"\aremoved\x18\x03 \x03(\tR\aremoved\x12\x1c\n" +
+	"\tconflicts\x18\x04 \x03(\tR\tconflicts\"\x9e\x01\n" +
+	"\tModChange\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\x03R\tprojectId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12!\n" +
+	"\ffrom_version\x18\x03 \x01(\tR\vfromVersion\x12\x1d\n" +
+	"\n" +
+	"to_version\x18\x04 \x01(\tR\ttoVersion\x12\x1c\n" +
+	"\tchangelog\x18\x05 \x01(\tR\tchangelog\"\xa3\x01\n" +
+	"\rStartupReport\x12\x12\n" +
+	"\x04done\x18\x01 \x01(\bR\x04done\x12'\n" +
+	"\x0fstartup_seconds\x18\x02 \x01(\x01R\x0estartupSeconds\x12\x18\n" +
+	"\acrashes\x18\x03 \x01(\x05R\acrashes\x12#\n" +
+	"\rcrash_reports\x18\x04 \x03(\tR\fcrashReports\x12\x16\n" +
+	"\x06errors\x18\x05 \x03(\tR\x06errors\"\x95\x01\n" +
+	"\n" +
+	"TaskResult\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\acommand\x18\x02 \x01(\tR\acommand\x12)\n" +
+	"\x10duration_seconds\x18\x03 \x01(\x01R\x0fdurationSeconds\x12\x18\n" +
+	"\askipped\x18\x04 \x01(\bR\askipped\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"\xa2\x01\n" +
+	"\aModSync\x12\x1e\n" +
+	"\n" +
+	"downloaded\x18\x01 \x03(\tR\n" +
+	"downloaded\x12\x18\n" +
+	"\aremoved\x18\x02 \x03(\tR\aremoved\x12\x18\n" +
+	"\askipped\x18\x03 \x03(\tR\askipped\x12\x1c\n" +
+	"\toverrides\x18\x04 \x01(\x05R\toverrides\x12%\n" +
+	"\x0edownload_bytes\x18\x05 \x01(\x03R\rdownloadBytes\"\x14\n" +
+	"\x12ListBackupsRequest\"O\n" +
+	"\x13ListBackupsResponse\x128\n" +
+	"\abackups\x18\x01 \x03(\v2\x1e.autoupdater.control.v1.BackupR\abackups\"=\n" +
+	"\x13CreateBackupRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\"\xf0\x02\n" +
+	"\x06Backup\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x04 \x01(\x03R\tsizeBytes\x124\n" +
+	"\acreated\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x12\x1e\n" +
+	"\n" +
+	"compressed\x18\x06 \x01(\bR\n" +
+	"compressed\x12\x1c\n" +
+	"\tencrypted\x18\a \x01(\bR\tencrypted\x12\x1c\n" +
+	"\tprotected\x18\b \x01(\bR\tprotected\x12\x18\n" +
+	"\atrigger\x18\t \x01(\tR\atrigger\x12\x18\n" +
+	"\aversion\x18\n" +
+	" \x01(\tR\aversion\x12\x1a\n" +
+	"\bchecksum\x18\v \x01(\tR\bchecksum\x12)\n" +
+	"\x10duration_seconds\x18\f \x01(\x01R\x0fdurationSeconds\"*\n" +
+	"\x12WatchEventsRequest\x12\x14\n" +
+	"\x05types\x18\x01 \x03(\tR\x05types\"x\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12+\n" +
+	"\x04data\x18\x03 \x01(\v2\x17.google.protobuf.StructR\x04data2\xac\x04\n" +
+	"\aControl\x12U\n" +
+	"\tGetStatus\x12(.autoupdater.control.v1.GetStatusRequest\x1a\x1e.autoupdater.control.v1.Status\x12R\n" +
+	"\x05Check\x12$.autoupdater.control.v1.CheckRequest\x1a#.autoupdater.control.v1.CheckResult\x12U\n" +
+	"\x06Update\x12%.autoupdater.control.v1.UpdateRequest\x1a$.autoupdater.control.v1.UpdateResult\x12f\n" +
+	"\vListBackups\x12*.autoupdater.control.v1.ListBackupsRequest\x1a+.autoupdater.control.v1.ListBackupsResponse\x12[\n" +
+	"\fCreateBackup\x12+.autoupdater.control.v1.CreateBackupRequest\x1a\x1e.autoupdater.control.v1.Backup\x12Z\n" +
+	"\vWatchEvents\x12*.autoupdater.control.v1.WatchEventsRequest\x1a\x1d.autoupdater.control.v1.Event0\x01BMZKgithub.com/damianko135/curseforge-autoupdate/golang/pkg/controlpb;controlpbb\x06proto3"
+
+var (
+	file_control_proto_rawDescOnce sync.Once
+	file_control_proto_rawDescData []byte
+)
+
+func file_control_proto_rawDescGZIP() []byte {
+	file_control_proto_rawDescOnce.Do(func() {
+		file_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_control_proto_rawDesc), len(file_control_proto_rawDesc)))
+	})
+	return file_control_proto_rawDescData
+}
+
+var file_control_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_control_proto_goTypes = []any{
+	(*GetStatusRequest)(nil),      // 0: autoupdater.control.v1.GetStatusRequest
+	(*Status)(nil),                // 1: autoupdater.control.v1.Status
+	(*CheckRequest)(nil),          // 2: autoupdater.control.v1.CheckRequest
+	(*CheckResult)(nil),           // 3: autoupdater.control.v1.CheckResult
+	(*HeldFile)(nil),              // 4: autoupdater.control.v1.HeldFile
+	(*HoldRuleMatch)(nil),         // 5: autoupdater.control.v1.HoldRuleMatch
+	(*MinecraftUpgrade)(nil),      // 6: autoupdater.control.v1.MinecraftUpgrade
+	(*ChannelStatus)(nil),         // 7: autoupdater.control.v1.ChannelStatus
+	(*UpdateRequest)(nil),         // 8: autoupdater.control.v1.UpdateRequest
+	(*UpdateResult)(nil),          // 9: autoupdater.control.v1.UpdateResult
+	(*PropertiesMerge)(nil),       // 10: autoupdater.control.v1.PropertiesMerge
+	(*ModChange)(nil),             // 11: autoupdater.control.v1.ModChange
+	(*StartupReport)(nil),         // 12: autoupdater.control.v1.StartupReport
+	(*TaskResult)(nil),            // 13: autoupdater.control.v1.TaskResult
+	(*ModSync)(nil),               // 14: autoupdater.control.v1.ModSync
+	(*ListBackupsRequest)(nil),    // 15: autoupdater.control.v1.ListBackupsRequest
+	(*ListBackupsResponse)(nil),   // 16: autoupdater.control.v1.ListBackupsResponse
+	(*CreateBackupRequest)(nil),   // 17: autoupdater.control.v1.CreateBackupRequest
+	(*Backup)(nil),                // 18: autoupdater.control.v1.Backup
+	(*WatchEventsRequest)(nil),    // 19: autoupdater.control.v1.WatchEventsRequest
+	(*Event)(nil),                 // 20: autoupdater.control.v1.Event
+	(*timestamppb.Timestamp)(nil), // 21: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 22: google.protobuf.Struct
+}
+var file_control_proto_depIdxs = []int32{
+	21, // 0: autoupdater.control.v1.Status.last_check_at:type_name -> google.protobuf.Timestamp
+	21, // 1: autoupdater.control.v1.CheckResult.latest_file_date:type_name -> google.protobuf.Timestamp
+	4,  // 2: autoupdater.control.v1.CheckResult.held_back:type_name -> autoupdater.control.v1.HeldFile
+	5,  // 3: autoupdater.control.v1.CheckResult.held_for_review:type_name -> autoupdater.control.v1.HoldRuleMatch
+	6,  // 4: autoupdater.control.v1.CheckResult.minecraft_upgrade:type_name -> autoupdater.control.v1.MinecraftUpgrade
+	7,  // 5: autoupdater.control.v1.CheckResult.channels:type_name -> autoupdater.control.v1.ChannelStatus
+	21, // 6: autoupdater.control.v1.HeldFile.published:type_name -> google.protobuf.Timestamp
+	21, // 7: autoupdater.control.v1.ChannelStatus.published:type_name -> google.protobuf.Timestamp
+	10, // 8: autoupdater.control.v1.UpdateResult.merged:type_name -> autoupdater.control.v1.PropertiesMerge
+	11, // 9: autoupdater.control.v1.UpdateResult.mods:type_name -> autoupdater.control.v1.ModChange
+	12, // 10: autoupdater.control.v1.UpdateResult.startup:type_name -> autoupdater.control.v1.StartupReport
+	13, // 11: autoupdater.control.v1.UpdateResult.tasks:type_name -> autoupdater.control.v1.TaskResult
+	14, // 12: autoupdater.control.v1.UpdateResult.sync:type_name -> autoupdater.control.v1.ModSync
+	6,  // 13: autoupdater.control.v1.UpdateResult.minecraft_upgrade:type_name -> autoupdater.control.v1.MinecraftUpgrade
+	18, // 14: autoupdater.control.v1.ListBackupsResponse.backups:type_name -> autoupdater.control.v1.Backup
+	21, // 15: autoupdater.control.v1.Backup.created:type_name -> google.protobuf.Timestamp
+	21, // 16: autoupdater.control.v1.Event.time:type_name -> google.protobuf.Timestamp
+	22, // 17: autoupdater.control.v1.Event.data:type_name -> google.protobuf.Struct
+	0,  // 18: autoupdater.control.v1.Control.GetStatus:input_type -> autoupdater.control.v1.GetStatusRequest
+	2,  // 19: autoupdater.control.v1.Control.Check:input_type -> autoupdater.control.v1.CheckRequest
+	8,  // 20: autoupdater.control.v1.Control.Update:input_type -> autoupdater.control.v1.UpdateRequest
+	15, // 21: autoupdater.control.v1.Control.ListBackups:input_type -> autoupdater.control.v1.ListBackupsRequest
+	17, // 22: autoupdater.control.v1.Control.CreateBackup:input_type -> autoupdater.control.v1.CreateBackupRequest
+	19, // 23: autoupdater.control.v1.Control.WatchEvents:input_type -> autoupdater.control.v1.WatchEventsRequest
+	1,  // 24: autoupdater.control.v1.Control.GetStatus:output_type -> autoupdater.control.v1.Status
+	3,  // 25: autoupdater.control.v1.Control.Check:output_type -> autoupdater.control.v1.CheckResult
+	9,  // 26: autoupdater.control.v1.Control.Update:output_type -> autoupdater.control.v1.UpdateResult
+	16, // 27: autoupdater.control.v1.Control.ListBackups:output_type -> autoupdater.control.v1.ListBackupsResponse
+	18, // 28: autoupdater.control.v1.Control.CreateBackup:output_type -> autoupdater.control.v1.Backup
+	20, // 29: autoupdater.control.v1.Control.WatchEvents:output_type -> autoupdater.control.v1.Event
+	24, // [24:30] is the sub-list for method output_type
+	18, // [18:24] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_control_proto_init() }
+func file_control_proto_init() {
+	if File_control_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_control_proto_rawDesc), len(file_control_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   21,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_control_proto_goTypes,
+		DependencyIndexes: file_control_proto_depIdxs,
+		MessageInfos:      file_control_proto_msgTypes,
+	}.Build()
+	File_control_proto = out.File
+	file_control_proto_goTypes = nil
+	file_control_proto_depIdxs = nil
+}
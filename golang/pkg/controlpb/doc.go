@@ -0,0 +1,14 @@
+// Package controlpb is the gRPC control API of the web UI, generated from control.proto.
+// It mirrors the REST API under /api/v1 with typed clients and an event stream, for
+// control planes that manage many updater instances:
+//
+//	conn, err := grpc.NewClient("mc1.example.com:9090", grpc.WithTransportCredentials(creds))
+//	if err != nil {
+//		return err
+//	}
+//	client := controlpb.NewControlClient(conn)
+//	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+//	status, err := client.GetStatus(ctx, &controlpb.GetStatusRequest{})
+//
+// Run "mage proto" after changing control.proto.
+package controlpb
@@ -1,5 +1,8 @@
 function updateTime() {
     const timeElement = document.getElementById('time-display');
+    if (!timeElement) {
+        return;
+    }
     const now = new Date();
 
     // Format date to YYYY-MM-DD HH:MM:SS
@@ -19,3 +22,15 @@ setInterval(updateTime, 1000);
 
 // Initial call
 updateTime();
+
+// apiToken returns the REST API token, asking for it once and keeping it in localStorage
+function apiToken() {
+    let token = localStorage.getItem('apiToken');
+    if (!token) {
+        token = prompt('API token (web.api_token)');
+        if (token) {
+            localStorage.setItem('apiToken', token);
+        }
+    }
+    return token;
+}
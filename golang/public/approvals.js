@@ -0,0 +1,23 @@
+// Approve and deny buttons call the REST API, then reload the page to show the decision.
+
+document.querySelectorAll('button[data-decision]').forEach((button) => {
+    button.addEventListener('click', async () => {
+        const params = new URLSearchParams();
+        if (button.dataset.server) {
+            params.set('server', button.dataset.server);
+        }
+        const response = await fetch('/api/v1/approvals/' + button.dataset.decision + '?' + params, {
+            method: 'POST',
+            headers: { 'Authorization': 'Bearer ' + apiToken() },
+        });
+        if (response.status === 401) {
+            localStorage.removeItem('apiToken');
+        }
+        if (!response.ok) {
+            const body = await response.json();
+            document.getElementById('approval-message').textContent = body.message;
+            return;
+        }
+        location.href = '/approvals';
+    });
+});
@@ -0,0 +1,69 @@
+// Backup buttons call the REST API, then reload the page to show the result. Downloads
+// pass the token in the query, as a link cannot set headers.
+
+function showMessage(text) {
+    document.getElementById('backup-message').textContent = text;
+}
+
+async function backupRequest(method, path, body) {
+    const response = await fetch('/api/v1/backups' + path, {
+        method: method,
+        headers: {
+            'Authorization': 'Bearer ' + apiToken(),
+            'Content-Type': 'application/json',
+        },
+        body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (response.status === 401) {
+        localStorage.removeItem('apiToken');
+    }
+    if (!response.ok) {
+        const result = await response.json();
+        showMessage(result.message);
+        return null;
+    }
+    return response;
+}
+
+document.querySelectorAll('button[data-backup-create]').forEach((button) => {
+    button.addEventListener('click', async () => {
+        showMessage('Creating backup...');
+        if (await backupRequest('POST', '', { type: button.dataset.backupCreate })) {
+            location.reload();
+        }
+    });
+});
+
+document.querySelectorAll('button[data-backup-action]').forEach((button) => {
+    button.addEventListener('click', async () => {
+        const name = button.dataset.backup;
+        const path = '/' + encodeURIComponent(name);
+        switch (button.dataset.backupAction) {
+        case 'download':
+            location.href = '/api/v1/backups' + path + '/download?token=' + encodeURIComponent(apiToken());
+            break;
+        case 'validate': {
+            const response = await backupRequest('POST', path + '/validate');
+            if (response) {
+                const result = await response.json();
+                showMessage(result.valid ? name + ' is valid' : name + ' is invalid: ' + result.error);
+            }
+            break;
+        }
+        case 'restore':
+            if (!confirm('Restore ' + name + '? The files it replaces are saved as a pre-restore backup first.')) {
+                return;
+            }
+            showMessage('Restoring ' + name + '...');
+            if (await backupRequest('POST', path + '/restore')) {
+                location.reload();
+            }
+            break;
+        case 'delete':
+            if (confirm('Delete ' + name + '?') && await backupRequest('DELETE', path)) {
+                location.reload();
+            }
+            break;
+        }
+    });
+});
@@ -0,0 +1,25 @@
+// Track buttons add a search result to the tracked mods through the REST API.
+
+document.querySelectorAll('[data-track]').forEach((button) => {
+    button.addEventListener('click', async () => {
+        const response = await fetch('/api/v1/mods', {
+            method: 'POST',
+            headers: {
+                'Authorization': 'Bearer ' + apiToken(),
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({ id: Number(button.dataset.track), name: button.dataset.name }),
+        });
+        if (response.status === 401) {
+            localStorage.removeItem('apiToken');
+        }
+        if (response.ok || response.status === 409) {
+            button.textContent = 'Tracked';
+            button.disabled = true;
+            button.className = 'btn btn-secondary';
+        } else {
+            const body = await response.json();
+            alert('Failed to track: ' + body.message);
+        }
+    });
+});
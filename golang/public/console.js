@@ -0,0 +1,51 @@
+// The console streams the server log and sends commands over a WebSocket.
+
+const output = document.getElementById('console-output');
+const status = document.getElementById('console-status');
+
+function appendLine(text, kind) {
+    const atBottom = output.scrollTop + output.clientHeight >= output.scrollHeight - 4;
+    const line = document.createElement('div');
+    line.className = 'console-' + kind;
+    line.textContent = text;
+    output.appendChild(line);
+    // Keep the page light on long-running servers
+    while (output.childElementCount > 2000) {
+        output.removeChild(output.firstChild);
+    }
+    if (atBottom) {
+        output.scrollTop = output.scrollHeight;
+    }
+}
+
+let socket;
+
+function connectConsole() {
+    const scheme = location.protocol === 'https:' ? 'wss://' : 'ws://';
+    socket = new WebSocket(scheme + location.host + '/api/v1/console?token=' + encodeURIComponent(apiToken()));
+    socket.addEventListener('open', () => {
+        status.textContent = 'Connected';
+    });
+    socket.addEventListener('message', (e) => {
+        const msg = JSON.parse(e.data);
+        appendLine(msg.line, msg.type);
+    });
+    socket.addEventListener('close', () => {
+        status.textContent = 'Disconnected, reconnecting…';
+        setTimeout(connectConsole, 3000);
+    });
+}
+
+document.getElementById('console-form').addEventListener('submit', (e) => {
+    e.preventDefault();
+    const input = document.getElementById('console-command');
+    const command = input.value.trim();
+    if (!command || socket.readyState !== WebSocket.OPEN) {
+        return;
+    }
+    appendLine('> ' + command, 'command');
+    socket.send(JSON.stringify({type: 'command', command: command}));
+    input.value = '';
+});
+
+connectConsole();
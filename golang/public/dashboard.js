@@ -0,0 +1,83 @@
+// Dashboard actions call the REST API and follow progress over Server-Sent Events.
+
+function setProgress(phase, percent, message) {
+    document.getElementById('progress-phase').textContent = phase;
+    document.getElementById('progress-fill').style.width = percent + '%';
+    document.getElementById('progress-message').textContent = message || '';
+}
+
+function formatBytes(n) {
+    const units = ['B', 'KB', 'MB', 'GB'];
+    let i = 0;
+    while (n >= 1024 && i < units.length - 1) {
+        n /= 1024;
+        i++;
+    }
+    return n.toFixed(i === 0 ? 0 : 1) + ' ' + units[i];
+}
+
+async function callAPI(action) {
+    const response = await fetch('/api/v1/' + action, {
+        method: 'POST',
+        headers: {
+            'Authorization': 'Bearer ' + apiToken(),
+            'Content-Type': 'application/json',
+        },
+        body: '{}',
+    });
+    const body = await response.json();
+    if (response.status === 401) {
+        localStorage.removeItem('apiToken');
+    }
+    if (!response.ok) {
+        setProgress('Error', 0, body.message);
+    }
+}
+
+function connectEvents() {
+    const source = new EventSource('/api/v1/events?token=' + encodeURIComponent(apiToken()));
+
+    source.addEventListener('check_completed', (e) => {
+        const data = JSON.parse(e.data).data;
+        document.getElementById('latest-version').textContent = data.latest_version;
+        document.getElementById('last-check').textContent = new Date().toLocaleString();
+        setProgress('Idle', 0, data.update_available ? 'Update available' : 'Up to date');
+    });
+    source.addEventListener('update_started', (e) => {
+        const data = JSON.parse(e.data).data;
+        setProgress('Starting', 0, (data.from_version || 'none') + ' → ' + data.to_version);
+    });
+    source.addEventListener('update_progress', (e) => {
+        const data = JSON.parse(e.data).data;
+        let message = '';
+        if (data.phase === 'download') {
+            message = formatBytes(data.downloaded_bytes);
+            if (data.total_bytes > 0) {
+                message += ' / ' + formatBytes(data.total_bytes);
+            }
+            message += ' at ' + formatBytes(data.bytes_per_second) + '/s';
+        }
+        setProgress(data.phase, data.percent, message);
+    });
+    source.addEventListener('update_finished', (e) => {
+        const data = JSON.parse(e.data).data;
+        if (!data.skipped) {
+            document.getElementById('installed-version').textContent = data.to_version;
+        }
+        setProgress('Done', 100, data.skipped ? 'Already up to date' : 'Updated in ' + data.duration);
+    });
+    source.addEventListener('update_failed', (e) => {
+        const data = JSON.parse(e.data).data;
+        setProgress('Failed', 0, data.error);
+    });
+    source.addEventListener('backup_created', (e) => {
+        const data = JSON.parse(e.data).data;
+        setProgress('Idle', 0, 'Backup created: ' + data.name);
+    });
+}
+
+document.querySelectorAll('[data-action]').forEach((button) => {
+    button.addEventListener('click', () => callAPI(button.dataset.action));
+});
+
+connectEvents();
@@ -0,0 +1,51 @@
+// Whitelist and operator forms call the REST API, then reload the page to show the result.
+// While the server runs, changes go through its console and it may take a moment before
+// the lists on disk show them.
+
+function showMessage(text) {
+    document.getElementById('players-message').textContent = text;
+}
+
+async function playerRequest(method, path, body) {
+    const response = await fetch('/api/v1/' + path, {
+        method: method,
+        headers: {
+            'Authorization': 'Bearer ' + apiToken(),
+            'Content-Type': 'application/json',
+        },
+        body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (response.status === 401) {
+        localStorage.removeItem('apiToken');
+    }
+    if (!response.ok) {
+        const result = await response.json();
+        showMessage(result.message);
+        return null;
+    }
+    return response;
+}
+
+document.querySelectorAll('form[data-player-add]').forEach((form) => {
+    form.addEventListener('submit', async (event) => {
+        event.preventDefault();
+        const name = form.elements.name.value.trim();
+        showMessage('Adding ' + name + '...');
+        if (await playerRequest('POST', form.dataset.playerAdd, { name: name })) {
+            setTimeout(() => location.reload(), 1000);
+        }
+    });
+});
+
+document.querySelectorAll('button[data-player-list]').forEach((button) => {
+    button.addEventListener('click', async () => {
+        const name = button.dataset.player;
+        const list = button.dataset.playerList;
+        if (!confirm('Remove ' + name + ' from the ' + (list === 'ops' ? 'operators' : 'whitelist') + '?')) {
+            return;
+        }
+        if (await playerRequest('DELETE', list + '/' + encodeURIComponent(name))) {
+            setTimeout(() => location.reload(), 1000);
+        }
+    });
+});
@@ -0,0 +1,21 @@
+// Package public embeds the stylesheets and scripts of the web UI, so that the web binary
+// does not depend on the directory it is started from
+package public
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+//go:embed *.css *.js
+var Assets embed.FS
+
+// FS returns the directory dir when it is set, so that assets can be edited without
+// rebuilding, and the embedded assets otherwise
+func FS(dir string) fs.FS {
+	if dir != "" {
+		return os.DirFS(dir)
+	}
+	return Assets
+}